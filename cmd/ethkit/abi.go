@@ -0,0 +1,427 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/0xsequence/ethkit/ethcontract"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+const (
+	flagAbiChain           = "chain"
+	flagAbiSource          = "source"
+	flagAbiEtherscanApiKey = "etherscan-api-key"
+	flagAbiRpcUrl          = "rpc-url"
+	flagAbiNoProxy         = "no-proxy"
+	flagAbiPrint           = "print"
+)
+
+// eip1967ImplementationSlot is the storage slot of the implementation address
+// of an EIP-1967 proxy, bytes32(uint256(keccak256('eip1967.proxy.implementation')) - 1).
+var eip1967ImplementationSlot = common.HexToHash("0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc")
+
+func init() {
+	rootCmd.AddCommand(NewAbiCmd())
+}
+
+type abiCmd struct {
+}
+
+// NewAbiCmd returns a new command to fetch verified abis into the local abi
+// cache, used to decode calldata and logs by the other commands.
+func NewAbiCmd() *cobra.Command {
+	c := &abiCmd{}
+	cmd := &cobra.Command{
+		Use:   "abi",
+		Short: "Manage the local cache of contract abis",
+		Long: "Manage the local cache of contract abis. The cached abis are used to decode calldata and logs\n" +
+			"by the tx, block and gas-profile commands. The cache directory is $ETHKIT_ABI_CACHE_DIR, or\n" +
+			"ethkit/abi in the user cache directory.",
+	}
+
+	fetchCmd := &cobra.Command{
+		Use:   "fetch [address]",
+		Short: "Fetch the verified abi of a contract from Etherscan or Sourcify into the cache",
+		Long: "Fetch the verified abi of a contract from Etherscan or Sourcify into the cache. Proxies are\n" +
+			"resolved to their implementation, and the cached abi of a proxy includes the abi of its implementation.\n" +
+			"The Etherscan api key is read from --etherscan-api-key or $ETHERSCAN_API_KEY.",
+		Args: cobra.ExactArgs(1),
+		RunE: c.Fetch,
+	}
+	fetchCmd.Flags().Uint64P(flagAbiChain, "c", 1, "The chain id of the contract")
+	fetchCmd.Flags().String(flagAbiSource, "auto", "The abi source: etherscan, sourcify or auto (etherscan with an api key, falling back to sourcify)")
+	fetchCmd.Flags().String(flagAbiEtherscanApiKey, "", "The Etherscan api key")
+	fetchCmd.Flags().StringP(flagAbiRpcUrl, "r", "", "The RPC endpoint used to resolve EIP-1967 proxies")
+	fetchCmd.Flags().Bool(flagAbiNoProxy, false, "Do not resolve proxies to their implementation")
+	fetchCmd.Flags().BoolP(flagAbiPrint, "p", false, "Print the abi")
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the cached abis",
+		Args:  cobra.NoArgs,
+		RunE:  c.List,
+	}
+
+	cmd.AddCommand(fetchCmd, listCmd)
+	return cmd
+}
+
+func (c *abiCmd) Fetch(cmd *cobra.Command, args []string) error {
+	fAddress := cmd.Flags().Args()[0]
+	fChain, err := cmd.Flags().GetUint64(flagAbiChain)
+	if err != nil {
+		return err
+	}
+	fSource, err := cmd.Flags().GetString(flagAbiSource)
+	if err != nil {
+		return err
+	}
+	fApiKey, err := cmd.Flags().GetString(flagAbiEtherscanApiKey)
+	if err != nil {
+		return err
+	}
+	fRpc, err := cmd.Flags().GetString(flagAbiRpcUrl)
+	if err != nil {
+		return err
+	}
+	fNoProxy, err := cmd.Flags().GetBool(flagAbiNoProxy)
+	if err != nil {
+		return err
+	}
+	fPrint, err := cmd.Flags().GetBool(flagAbiPrint)
+	if err != nil {
+		return err
+	}
+
+	if !common.IsHexAddress(fAddress) {
+		return errors.New("error: please provide a valid contract address")
+	}
+	if fApiKey == "" {
+		fApiKey = os.Getenv("ETHERSCAN_API_KEY")
+	}
+
+	fetcher := &abiFetcher{
+		etherscanURL:    "https://api.etherscan.io/v2/api",
+		etherscanApiKey: fApiKey,
+		sourcifyURL:     "https://sourcify.dev/server",
+		client:          &http.Client{Timeout: 30 * time.Second},
+	}
+	switch fSource {
+	case "auto", "etherscan", "sourcify":
+		fetcher.source = fSource
+	default:
+		return fmt.Errorf("error: unknown abi source %q", fSource)
+	}
+	if fetcher.source == "etherscan" && fApiKey == "" {
+		return errors.New("error: please provide an Etherscan api key")
+	}
+	if fRpc != "" && !fNoProxy {
+		if _, err = url.ParseRequestURI(fRpc); err != nil {
+			return errors.New("error: please provide a valid rpc url (e.g. https://nodes.sequence.app/mainnet)")
+		}
+		if fetcher.provider, err = ethrpc.NewProvider(fRpc); err != nil {
+			return err
+		}
+	}
+
+	address := common.HexToAddress(fAddress)
+	result, err := fetcher.fetch(context.Background(), fChain, address, !fNoProxy)
+	if err != nil {
+		return err
+	}
+
+	path, err := writeCachedABI(abiCacheDir(), fChain, address, result.ABI)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	if fPrint {
+		fmt.Fprintln(out, string(result.ABI))
+		return nil
+	}
+	fmt.Fprintf(out, "fetched the abi of %s from %s\n", address.Hex(), result.Source)
+	if result.Implementation != nil {
+		fmt.Fprintf(out, "resolved the proxy to its implementation %s\n", result.Implementation.Hex())
+	}
+	fmt.Fprintf(out, "saved to %s\n", path)
+	return nil
+}
+
+func (c *abiCmd) List(cmd *cobra.Command, args []string) error {
+	dir := abiCacheDir()
+	paths, err := filepath.Glob(filepath.Join(dir, "*", "0x*.json"))
+	if err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	for _, path := range paths {
+		rel, _ := filepath.Rel(dir, path)
+		chain, file := filepath.Split(rel)
+		fmt.Fprintf(out, "%-10s %s\n", strings.TrimSuffix(chain, string(filepath.Separator)), strings.TrimSuffix(file, ".json"))
+	}
+	return nil
+}
+
+// abiFetcher fetches verified abis from Etherscan and Sourcify.
+type abiFetcher struct {
+	source          string
+	etherscanURL    string
+	etherscanApiKey string
+	sourcifyURL     string
+	client          *http.Client
+
+	// provider is optional, to resolve EIP-1967 proxies with their
+	// implementation slot.
+	provider *ethrpc.Provider
+}
+
+// fetchedABI is the abi of a contract. The abi of a proxy includes the abi
+// of its implementation.
+type fetchedABI struct {
+	ABI            json.RawMessage
+	Source         string
+	Implementation *common.Address
+}
+
+func (f *abiFetcher) fetch(ctx context.Context, chainID uint64, address common.Address, resolveProxy bool) (*fetchedABI, error) {
+	result, implementation, err := f.fetchABI(ctx, chainID, address)
+	if err != nil {
+		return nil, err
+	}
+	if !resolveProxy {
+		return result, nil
+	}
+
+	if f.provider != nil {
+		slot, err := f.provider.StorageAt(ctx, address, eip1967ImplementationSlot, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error: failed to read the proxy implementation slot: %w", err)
+		}
+		if impl := common.BytesToAddress(slot); impl != (common.Address{}) {
+			implementation = &impl
+		}
+	}
+	if implementation == nil || *implementation == address {
+		return result, nil
+	}
+
+	implResult, _, err := f.fetchABI(ctx, chainID, *implementation)
+	if err != nil {
+		return nil, fmt.Errorf("error: failed to fetch the abi of the implementation %s: %w", implementation.Hex(), err)
+	}
+	merged, err := mergeABIs(result.ABI, implResult.ABI)
+	if err != nil {
+		return nil, err
+	}
+	result.ABI = merged
+	result.Implementation = implementation
+	return result, nil
+}
+
+// fetchABI fetches the abi of a contract, and the implementation address
+// reported by the source when it is a proxy.
+func (f *abiFetcher) fetchABI(ctx context.Context, chainID uint64, address common.Address) (*fetchedABI, *common.Address, error) {
+	var errs []string
+	if f.source == "etherscan" || (f.source == "auto" && f.etherscanApiKey != "") {
+		data, implementation, err := f.fetchEtherscan(ctx, chainID, address)
+		if err == nil {
+			return &fetchedABI{ABI: data, Source: "etherscan"}, implementation, nil
+		}
+		if f.source == "etherscan" {
+			return nil, nil, err
+		}
+		errs = append(errs, err.Error())
+	}
+
+	data, implementation, err := f.fetchSourcify(ctx, chainID, address)
+	if err == nil {
+		return &fetchedABI{ABI: data, Source: "sourcify"}, implementation, nil
+	}
+	errs = append(errs, err.Error())
+	return nil, nil, fmt.Errorf("error: failed to fetch the abi of %s: %s", address.Hex(), strings.Join(errs, "; "))
+}
+
+func (f *abiFetcher) fetchEtherscan(ctx context.Context, chainID uint64, address common.Address) (json.RawMessage, *common.Address, error) {
+	query := url.Values{
+		"chainid": {strconv.FormatUint(chainID, 10)},
+		"module":  {"contract"},
+		"action":  {"getsourcecode"},
+		"address": {address.Hex()},
+		"apikey":  {f.etherscanApiKey},
+	}
+	var resp struct {
+		Status  string          `json:"status"`
+		Message string          `json:"message"`
+		Result  json.RawMessage `json:"result"`
+	}
+	if err := f.getJSON(ctx, f.etherscanURL+"?"+query.Encode(), &resp); err != nil {
+		return nil, nil, fmt.Errorf("etherscan: %w", err)
+	}
+
+	var results []struct {
+		ABI            string `json:"ABI"`
+		Proxy          string `json:"Proxy"`
+		Implementation string `json:"Implementation"`
+	}
+	if resp.Status != "1" || json.Unmarshal(resp.Result, &results) != nil || len(results) == 0 {
+		// the result is an error message when the request fails
+		var message string
+		json.Unmarshal(resp.Result, &message)
+		return nil, nil, fmt.Errorf("etherscan: %s %s", resp.Message, message)
+	}
+	if !json.Valid([]byte(results[0].ABI)) {
+		return nil, nil, fmt.Errorf("etherscan: %s", results[0].ABI)
+	}
+
+	var implementation *common.Address
+	if results[0].Proxy == "1" && common.IsHexAddress(results[0].Implementation) {
+		impl := common.HexToAddress(results[0].Implementation)
+		implementation = &impl
+	}
+	return json.RawMessage(results[0].ABI), implementation, nil
+}
+
+func (f *abiFetcher) fetchSourcify(ctx context.Context, chainID uint64, address common.Address) (json.RawMessage, *common.Address, error) {
+	var resp struct {
+		ABI             json.RawMessage `json:"abi"`
+		ProxyResolution *struct {
+			IsProxy         bool `json:"isProxy"`
+			Implementations []struct {
+				Address common.Address `json:"address"`
+			} `json:"implementations"`
+		} `json:"proxyResolution"`
+	}
+	endpoint := fmt.Sprintf("%s/v2/contract/%d/%s?fields=abi,proxyResolution", f.sourcifyURL, chainID, address.Hex())
+	if err := f.getJSON(ctx, endpoint, &resp); err != nil {
+		return nil, nil, fmt.Errorf("sourcify: %w", err)
+	}
+	if len(resp.ABI) == 0 {
+		return nil, nil, errors.New("sourcify: contract is not verified")
+	}
+
+	var implementation *common.Address
+	if p := resp.ProxyResolution; p != nil && p.IsProxy && len(p.Implementations) > 0 {
+		implementation = &p.Implementations[0].Address
+	}
+	return resp.ABI, implementation, nil
+}
+
+func (f *abiFetcher) getJSON(ctx context.Context, endpoint string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return errors.New("contract is not verified")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return json.Unmarshal(body, v)
+}
+
+// mergeABIs returns the concatenation of abi json arrays, without the
+// duplicate entries.
+func mergeABIs(abis ...json.RawMessage) (json.RawMessage, error) {
+	var merged []json.RawMessage
+	seen := map[string]bool{}
+	for _, data := range abis {
+		var entries []json.RawMessage
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("error: invalid abi: %w", err)
+		}
+		for _, entry := range entries {
+			var e struct {
+				Type   string `json:"type"`
+				Name   string `json:"name"`
+				Inputs []struct {
+					Type string `json:"type"`
+				} `json:"inputs"`
+			}
+			if err := json.Unmarshal(entry, &e); err != nil {
+				return nil, fmt.Errorf("error: invalid abi: %w", err)
+			}
+			key := e.Type + " " + e.Name + "("
+			for _, input := range e.Inputs {
+				key += input.Type + ","
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged = append(merged, entry)
+		}
+	}
+	return json.Marshal(merged)
+}
+
+// abiCacheDir returns the directory of the abi cache.
+func abiCacheDir() string {
+	if dir := os.Getenv("ETHKIT_ABI_CACHE_DIR"); dir != "" {
+		return dir
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "ethkit", "abi")
+}
+
+// writeCachedABI writes the abi of a contract to the cache, and returns its
+// path.
+func writeCachedABI(dir string, chainID uint64, address common.Address, data json.RawMessage) (string, error) {
+	if dir == "" {
+		return "", errors.New("error: unable to determine the abi cache directory, set $ETHKIT_ABI_CACHE_DIR")
+	}
+	if _, err := ethcontract.ParseABI(string(data)); err != nil {
+		return "", fmt.Errorf("error: invalid abi: %w", err)
+	}
+
+	dir = filepath.Join(dir, strconv.FormatUint(chainID, 10))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, strings.ToLower(address.Hex())+".json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// readCachedABIs reads the abis of the cache. A missing cache is empty.
+func readCachedABIs(dir string) ([]abi.ABI, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*", "0x*.json"))
+	if err != nil {
+		return nil, err
+	}
+	return readABIFiles(paths)
+}
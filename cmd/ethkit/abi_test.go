@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func execAbiCmd(args string) (string, error) {
+	cmd := NewAbiCmd()
+	actual := new(bytes.Buffer)
+	cmd.SetOut(actual)
+	cmd.SetErr(actual)
+	cmd.SetArgs(strings.Split(args, " "))
+	if err := cmd.Execute(); err != nil {
+		return "", err
+	}
+
+	return actual.String(), nil
+}
+
+func Test_AbiFetcher(t *testing.T) {
+	proxy := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	impl := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	proxyABI := `[{"type":"function","name":"upgradeTo","inputs":[{"name":"impl","type":"address"}],"outputs":[]}]`
+	implABI := `[{"type":"function","name":"upgradeTo","inputs":[{"name":"impl","type":"address"}],"outputs":[]},{"type":"function","name":"mint","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[]}]`
+
+	etherscan := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "137", r.URL.Query().Get("chainid"))
+		result := map[string]string{"ABI": "Contract source code not verified"}
+		switch common.HexToAddress(r.URL.Query().Get("address")) {
+		case proxy:
+			result = map[string]string{"ABI": proxyABI, "Proxy": "1", "Implementation": impl.Hex()}
+		case impl:
+			result = map[string]string{"ABI": implABI, "Proxy": "0"}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"status": "1", "message": "OK", "result": []any{result}})
+	}))
+	defer etherscan.Close()
+
+	sourcify := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/contract/137/"+impl.Hex() {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"abi":` + implABI + `,"proxyResolution":{"isProxy":false}}`))
+	}))
+	defer sourcify.Close()
+
+	fetcher := &abiFetcher{
+		source:          "auto",
+		etherscanURL:    etherscan.URL,
+		etherscanApiKey: "key",
+		sourcifyURL:     sourcify.URL,
+		client:          http.DefaultClient,
+	}
+
+	result, err := fetcher.fetch(context.Background(), 137, proxy, true)
+	require.NoError(t, err)
+	assert.Equal(t, "etherscan", result.Source)
+	assert.Equal(t, &impl, result.Implementation)
+	assert.JSONEq(t, implABI, string(result.ABI))
+
+	result, err = fetcher.fetch(context.Background(), 137, proxy, false)
+	require.NoError(t, err)
+	assert.Nil(t, result.Implementation)
+	assert.JSONEq(t, proxyABI, string(result.ABI))
+
+	fetcher.etherscanApiKey = ""
+	result, err = fetcher.fetch(context.Background(), 137, impl, true)
+	require.NoError(t, err)
+	assert.Equal(t, "sourcify", result.Source)
+
+	_, err = fetcher.fetch(context.Background(), 137, proxy, true)
+	assert.Contains(t, err.Error(), "not verified")
+
+	// the cached abis are used by the decoder
+	dir := t.TempDir()
+	t.Setenv("ETHKIT_ABI_CACHE_DIR", dir)
+	_, err = writeCachedABI(dir, 137, impl, result.ABI)
+	require.NoError(t, err)
+
+	res, err := execAbiCmd("list")
+	require.NoError(t, err)
+	assert.Contains(t, res, "137")
+	assert.Contains(t, res, strings.ToLower(impl.Hex()))
+
+	decoder, err := NewDecoder()
+	require.NoError(t, err)
+	calldata, err := ethcoder.AbiEncodeMethodCalldataFromStringValues("mint(address,uint256)", []string{proxy.Hex(), "5"})
+	require.NoError(t, err)
+	assert.Equal(t, "mint(address,uint256)", decoder.DecodeCalldata(calldata).Function)
+}
+
+func Test_AbiCmd_InvalidArgs(t *testing.T) {
+	_, err := execAbiCmd("fetch 0x1234")
+	assert.Contains(t, err.Error(), "please provide a valid contract address")
+
+	_, err = execAbiCmd("fetch 0x1111111111111111111111111111111111111111 --source foo")
+	assert.Contains(t, err.Error(), "unknown abi source")
+
+	t.Setenv("ETHERSCAN_API_KEY", "")
+	_, err = execAbiCmd("fetch 0x1111111111111111111111111111111111111111 --source etherscan")
+	assert.Contains(t, err.Error(), "please provide an Etherscan api key")
+}
+
+func Test_MergeABIs(t *testing.T) {
+	merged, err := mergeABIs(
+		json.RawMessage(`[{"type":"function","name":"a","inputs":[{"type":"uint256"}]}]`),
+		json.RawMessage(`[{"type":"function","name":"a","inputs":[{"type":"uint256"}]},{"type":"function","name":"a","inputs":[]}]`),
+	)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"type":"function","name":"a","inputs":[{"type":"uint256"}]},{"type":"function","name":"a","inputs":[]}]`, string(merged))
+}
@@ -7,6 +7,7 @@ import (
 	"math/big"
 	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 
@@ -20,6 +21,8 @@ const (
 	flagBlockFull = "full"
 	flagBlockRpcUrl = "rpc-url"
 	flagBlockJson = "json"
+	flagBlockReceipts = "receipts"
+	flagBlockAbi = "abi"
 )
 
 func init() {
@@ -33,17 +36,21 @@ type block struct {
 func NewBlockCmd() *cobra.Command {
 	c := &block{}
 	cmd := &cobra.Command{
-		Use:     "block [number|tag]",
+		Use:     "block [number|hash|tag]",
 		Short:   "Get the information about the block",
 		Aliases: []string{"bl"},
 		Args:    cobra.ExactArgs(1),
 		RunE:    c.Run,
+
+		ValidArgsFunction: completeBlockArg,
 	}
 
 	cmd.Flags().StringP(flagBlockField, "f", "", "Get the specific field of a block")
 	cmd.Flags().Bool(flagBlockFull, false, "Get the full block information")
 	cmd.Flags().StringP(flagBlockRpcUrl, "r", "", "The RPC endpoint to the blockchain node to interact with")
 	cmd.Flags().BoolP(flagBlockJson, "j", false, "Print the block as JSON")
+	cmd.Flags().Bool(flagBlockReceipts, false, "Include the receipts of the transactions, with decoded logs (implies --full)")
+	cmd.Flags().StringSlice(flagBlockAbi, nil, "Path to abi json file(s) used to decode the logs of the receipts")
 
 	return cmd
 }
@@ -66,6 +73,14 @@ func (c *block) Run(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	fReceipts, err := cmd.Flags().GetBool(flagBlockReceipts)
+	if err != nil {
+		return err
+	}
+	fAbi, err := cmd.Flags().GetStringSlice(flagBlockAbi)
+	if err != nil {
+		return err
+	}
 
 	if _, err = url.ParseRequestURI(fRpc); err != nil {
 		return errors.New("error: please provide a valid rpc url (e.g. https://nodes.sequence.app/mainnet)")
@@ -76,13 +91,8 @@ func (c *block) Run(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	bh, err := strconv.ParseUint(fBlock, 10, 64)
-	if err != nil {
-		// TODO: implement support for all tags: earliest, latest, pending, finalized, safe
-		return errors.New("error: invalid block height")
-	}
-
-	block, err := provider.BlockByNumber(context.Background(), big.NewInt(int64(bh)))
+	ctx := context.Background()
+	block, err := fetchBlock(ctx, provider, fBlock)
 	if err != nil {
 		return err
 	}
@@ -90,10 +100,22 @@ func (c *block) Run(cmd *cobra.Command, args []string) error {
 	var obj any
 	obj = NewHeader(block)
 
-	if fFull {
+	if fFull || fReceipts {
 		obj = NewBlock(block)
 	}
 
+	if fReceipts {
+		decoder, err := NewDecoder(fAbi...)
+		if err != nil {
+			return err
+		}
+		receipts, err := fetchReceipts(ctx, provider, block.Transactions())
+		if err != nil {
+			return err
+		}
+		obj.(*Block).Receipts = NewReceipts(receipts, decoder)
+	}
+
 	if fField != "" {
 		obj = GetValueByJSONTag(obj, fField)
 	}
@@ -111,6 +133,50 @@ func (c *block) Run(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// fetchBlock returns the block of a number, hash or tag (latest, pending,
+// finalized, safe or earliest).
+func fetchBlock(ctx context.Context, provider *ethrpc.Provider, arg string) (*types.Block, error) {
+	if strings.HasPrefix(arg, "0x") && len(arg) == 2+2*common.HashLength {
+		return provider.BlockByHash(ctx, common.HexToHash(arg))
+	}
+
+	var blockNum *big.Int
+	switch arg {
+	case "latest":
+	case "pending":
+		blockNum = ethrpc.Pending
+	case "finalized":
+		blockNum = ethrpc.Finalized
+	case "safe":
+		blockNum = ethrpc.Safe
+	case "earliest":
+		blockNum = big.NewInt(0)
+	default:
+		bh, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			return nil, errors.New("error: invalid block height")
+		}
+		blockNum = new(big.Int).SetUint64(bh)
+	}
+	return provider.BlockByNumber(ctx, blockNum)
+}
+
+// fetchReceipts returns the receipts of the transactions in a single batch.
+func fetchReceipts(ctx context.Context, provider *ethrpc.Provider, txs types.Transactions) ([]*types.Receipt, error) {
+	if len(txs) == 0 {
+		return nil, nil
+	}
+	receipts := make([]*types.Receipt, len(txs))
+	calls := make([]ethrpc.Call, len(txs))
+	for i, tx := range txs {
+		calls[i] = ethrpc.TransactionReceipt(tx.Hash()).Into(&receipts[i])
+	}
+	if _, err := provider.Do(ctx, calls...); err != nil {
+		return nil, err
+	}
+	return receipts, nil
+}
+
 // Header is a customized block header for cli.
 type Header struct {
 	ParentHash       common.Hash        `json:"parentHash"`
@@ -160,7 +226,7 @@ func NewHeader(b *types.Block) *Header {
 		WithdrawalsHash:  b.Header().WithdrawalsHash,
 		Size:             b.Header().Size(),
 		// TotalDifficulty:  b.Difficulty(),
-		TransactionsHash: TransactionsHash(*b),
+		TransactionsHash: TransactionsHash(b),
 	}
 }
 
@@ -176,7 +242,7 @@ func (h *Header) String() string {
 }
 
 // TransactionsHash returns a list of transaction hash starting from a list of transactions contained in a block.
-func TransactionsHash(block types.Block) []common.Hash {
+func TransactionsHash(block *types.Block) []common.Hash {
 	txsh := make([]common.Hash, len(block.Transactions()))
 
 	for i, tx := range block.Transactions() {
@@ -212,6 +278,7 @@ type Block struct {
 	Uncles          []*types.Header    `json:"uncles"`
 	Transactions    types.Transactions `json:"transactions"`
 	Withdrawals     types.Withdrawals  `json:"withdrawals"`
+	Receipts        []*Receipt         `json:"receipts,omitempty"`
 }
 
 // NewBlock returns the custom-built Block object.
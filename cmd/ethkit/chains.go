@@ -0,0 +1,343 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/0xsequence/ethkit/ethproviders"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+const (
+	flagChainsJson    = "json"
+	flagChainsConfig  = "config"
+	flagChainsRpcUrl  = "rpc-url"
+	flagChainsTimeout = "timeout"
+	flagChainsMaxLag  = "max-lag"
+)
+
+func init() {
+	rootCmd.AddCommand(NewChainsCmd())
+}
+
+type chains struct {
+}
+
+// NewChainsCmd returns a new command to show the known chains and to check the
+// health of rpc endpoints.
+func NewChainsCmd() *cobra.Command {
+	c := &chains{}
+	cmd := &cobra.Command{
+		Use:   "chains",
+		Short: "List the known chains and health-check rpc endpoints",
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List the known chains",
+		Args:  cobra.NoArgs,
+		RunE:  c.List,
+	}
+	listCmd.Flags().BoolP(flagChainsJson, "j", false, "Print the chains as JSON")
+
+	infoCmd := &cobra.Command{
+		Use:   "info [chain id or name]",
+		Short: "Show the metadata of a known chain",
+		Args:  cobra.ExactArgs(1),
+		RunE:  c.Info,
+
+		ValidArgsFunction: completeChainArg,
+	}
+	infoCmd.Flags().BoolP(flagChainsJson, "j", false, "Print the chain as JSON")
+
+	checkCmd := &cobra.Command{
+		Use:   "check",
+		Short: "Health-check rpc endpoints: latency, head block and chain id",
+		Long: "Health-check the rpc endpoints of a providers config file (the ethproviders.Config JSON format,\n" +
+			"ie. {\"mainnet\": {\"id\": 1, \"url\": \"https://nodes.sequence.app/mainnet\"}}) and/or of --rpc-url.",
+		Args: cobra.NoArgs,
+		RunE: c.Check,
+	}
+	checkCmd.Flags().StringP(flagChainsConfig, "c", "", "Path to a providers config JSON file")
+	checkCmd.Flags().StringSliceP(flagChainsRpcUrl, "r", nil, "The RPC endpoint(s) to check")
+	checkCmd.Flags().Duration(flagChainsTimeout, 10*time.Second, "Timeout of each endpoint check")
+	checkCmd.Flags().Duration(flagChainsMaxLag, 5*time.Minute, "Max age of the head block of a healthy endpoint")
+	checkCmd.Flags().BoolP(flagChainsJson, "j", false, "Print the results as JSON")
+
+	cmd.AddCommand(listCmd, infoCmd, checkCmd)
+	return cmd
+}
+
+// Chain is the metadata of a known chain for cli.
+type Chain struct {
+	ChainID             uint64 `json:"chainId"`
+	Name                string `json:"name"`
+	NumBlocksToFinality int    `json:"numBlocksToFinality"`
+	OptimismChain       bool   `json:"optimismChain"`
+}
+
+func (c *Chain) String() string {
+	var p Printable
+	if err := p.FromStruct(c); err != nil {
+		panic(err)
+	}
+	return p.Columnize(*NewPrintableFormat(20, 0, 0, byte(' ')))
+}
+
+func knownChains() []*Chain {
+	list := make([]*Chain, 0, len(ethrpc.Networks))
+	for _, n := range ethrpc.Networks {
+		list = append(list, &Chain{
+			ChainID:             n.ChainID,
+			Name:                n.Name,
+			NumBlocksToFinality: n.NumBlocksToFinality,
+			OptimismChain:       n.OptimismChain,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ChainID < list[j].ChainID
+	})
+	return list
+}
+
+// findChain returns the known chain by id or name.
+func findChain(arg string) *Chain {
+	id, err := strconv.ParseUint(arg, 10, 64)
+	for _, chain := range knownChains() {
+		if (err == nil && chain.ChainID == id) || strings.EqualFold(chain.Name, arg) {
+			return chain
+		}
+	}
+	return nil
+}
+
+func (c *chains) List(cmd *cobra.Command, args []string) error {
+	fJson, err := cmd.Flags().GetBool(flagChainsJson)
+	if err != nil {
+		return err
+	}
+
+	list := knownChains()
+	out := cmd.OutOrStdout()
+	if fJson {
+		json, err := PrettyJSON(list)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, *json)
+		return nil
+	}
+
+	fmt.Fprintf(out, "%-10s %-24s %s\n", "CHAIN ID", "NAME", "FINALITY")
+	for _, chain := range list {
+		fmt.Fprintf(out, "%-10d %-24s %d blocks\n", chain.ChainID, chain.Name, chain.NumBlocksToFinality)
+	}
+	return nil
+}
+
+func (c *chains) Info(cmd *cobra.Command, args []string) error {
+	fJson, err := cmd.Flags().GetBool(flagChainsJson)
+	if err != nil {
+		return err
+	}
+
+	chain := findChain(args[0])
+	if chain == nil {
+		return fmt.Errorf("error: unknown chain %q", args[0])
+	}
+
+	var obj any = chain
+	if fJson {
+		json, err := PrettyJSON(chain)
+		if err != nil {
+			return err
+		}
+		obj = *json
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), obj)
+	return nil
+}
+
+func (c *chains) Check(cmd *cobra.Command, args []string) error {
+	fConfig, err := cmd.Flags().GetString(flagChainsConfig)
+	if err != nil {
+		return err
+	}
+	fRpcUrls, err := cmd.Flags().GetStringSlice(flagChainsRpcUrl)
+	if err != nil {
+		return err
+	}
+	fTimeout, err := cmd.Flags().GetDuration(flagChainsTimeout)
+	if err != nil {
+		return err
+	}
+	fMaxLag, err := cmd.Flags().GetDuration(flagChainsMaxLag)
+	if err != nil {
+		return err
+	}
+	fJson, err := cmd.Flags().GetBool(flagChainsJson)
+	if err != nil {
+		return err
+	}
+
+	var endpoints []*EndpointHealth
+	if fConfig != "" {
+		data, err := os.ReadFile(fConfig)
+		if err != nil {
+			return fmt.Errorf("error: failed to read config: %w", err)
+		}
+		var config ethproviders.Config
+		if err := json.Unmarshal(data, &config); err != nil {
+			return fmt.Errorf("error: invalid config: %w", err)
+		}
+		for name, network := range config {
+			if network.Disabled {
+				continue
+			}
+			endpoints = append(endpoints, &EndpointHealth{Name: name, URL: network.URL, ExpectedChainID: network.ID})
+		}
+		sort.Slice(endpoints, func(i, j int) bool {
+			return endpoints[i].ExpectedChainID < endpoints[j].ExpectedChainID
+		})
+	}
+	for _, rpcUrl := range fRpcUrls {
+		endpoints = append(endpoints, &EndpointHealth{URL: rpcUrl})
+	}
+	if len(endpoints) == 0 {
+		return errors.New("error: please provide a providers config file or an rpc url (e.g. https://nodes.sequence.app/mainnet)")
+	}
+	for _, endpoint := range endpoints {
+		if _, err = url.ParseRequestURI(endpoint.URL); err != nil {
+			return fmt.Errorf("error: invalid rpc url %q", endpoint.URL)
+		}
+	}
+
+	checkEndpoints(context.Background(), endpoints, fTimeout, fMaxLag, time.Now)
+
+	out := cmd.OutOrStdout()
+	if fJson {
+		json, err := PrettyJSON(endpoints)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, *json)
+	} else {
+		for _, endpoint := range endpoints {
+			fmt.Fprintln(out, endpoint)
+		}
+	}
+
+	unhealthy := 0
+	for _, endpoint := range endpoints {
+		if !endpoint.Healthy {
+			unhealthy++
+		}
+	}
+	if unhealthy > 0 {
+		return fmt.Errorf("error: %d of %d endpoints are unhealthy", unhealthy, len(endpoints))
+	}
+	return nil
+}
+
+// EndpointHealth is the result of the health check of an rpc endpoint.
+type EndpointHealth struct {
+	Name            string        `json:"name,omitempty"`
+	URL             string        `json:"url"`
+	ExpectedChainID uint64        `json:"expectedChainId,omitempty"`
+	ChainID         uint64        `json:"chainId"`
+	ChainName       string        `json:"chainName,omitempty"`
+	HeadBlock       uint64        `json:"headBlock"`
+	HeadAge         time.Duration `json:"headAge"`
+	Latency         time.Duration `json:"latency"`
+	Healthy         bool          `json:"healthy"`
+	Error           string        `json:"error,omitempty"`
+}
+
+func (e *EndpointHealth) String() string {
+	status := "ok"
+	if !e.Healthy {
+		status = "FAIL"
+	}
+	name := e.Name
+	if name == "" {
+		name = e.ChainName
+	}
+
+	s := fmt.Sprintf("%-4s %-20s %s", status, name, e.URL)
+	if e.Error != "" && e.HeadBlock == 0 {
+		return s + ": " + e.Error
+	}
+	s += fmt.Sprintf("  chainId=%d head=%d age=%s latency=%s", e.ChainID, e.HeadBlock, e.HeadAge.Round(time.Second), e.Latency.Round(time.Millisecond))
+	if e.Error != "" {
+		s += ": " + e.Error
+	}
+	return s
+}
+
+// checkEndpoints checks the endpoints concurrently.
+func checkEndpoints(ctx context.Context, endpoints []*EndpointHealth, timeout, maxLag time.Duration, now func() time.Time) {
+	var wg sync.WaitGroup
+	for _, endpoint := range endpoints {
+		wg.Add(1)
+		go func(endpoint *EndpointHealth) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			endpoint.check(ctx, maxLag, now)
+		}(endpoint)
+	}
+	wg.Wait()
+}
+
+func (e *EndpointHealth) check(ctx context.Context, maxLag time.Duration, now func() time.Time) {
+	provider, err := ethrpc.NewProvider(e.URL)
+	if err != nil {
+		e.Error = err.Error()
+		return
+	}
+
+	var chainID *big.Int
+	var head *types.Header
+	start := time.Now()
+	_, err = provider.Do(ctx,
+		ethrpc.ChainID().Into(&chainID),
+		ethrpc.HeaderByNumber(nil).Into(&head),
+	)
+	e.Latency = time.Since(start)
+	if err != nil {
+		e.Error = err.Error()
+		return
+	}
+
+	e.ChainID = chainID.Uint64()
+	if network, ok := ethrpc.Networks[e.ChainID]; ok {
+		e.ChainName = network.Name
+	}
+	e.HeadBlock = head.Number.Uint64()
+	e.HeadAge = now().Sub(time.Unix(int64(head.Time), 0))
+	if e.HeadAge < 0 {
+		e.HeadAge = 0
+	}
+
+	switch {
+	case e.ExpectedChainID != 0 && e.ExpectedChainID != e.ChainID:
+		e.Error = fmt.Sprintf("chain id mismatch, expected %d", e.ExpectedChainID)
+	case e.HeadAge > maxLag:
+		e.Error = fmt.Sprintf("head block is %s old", e.HeadAge.Round(time.Second))
+	default:
+		e.Healthy = true
+	}
+}
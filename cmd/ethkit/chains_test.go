@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethdevnode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func execChainsCmd(args string) (string, error) {
+	cmd := NewChainsCmd()
+	actual := new(bytes.Buffer)
+	cmd.SetOut(actual)
+	cmd.SetErr(actual)
+	cmd.SetArgs(strings.Split(args, " "))
+	if err := cmd.Execute(); err != nil {
+		return "", err
+	}
+
+	return actual.String(), nil
+}
+
+func Test_ChainsCmd_ListInfo(t *testing.T) {
+	res, err := execChainsCmd("list")
+	require.NoError(t, err)
+	assert.Contains(t, res, "mainnet")
+	assert.Contains(t, res, "polygon")
+
+	res, err = execChainsCmd("info polygon --json")
+	require.NoError(t, err)
+	assert.Contains(t, res, `"chainId": 137`)
+
+	res, err = execChainsCmd("info 1")
+	require.NoError(t, err)
+	assert.Contains(t, res, "mainnet")
+
+	_, err = execChainsCmd("info foo")
+	assert.Contains(t, err.Error(), "unknown chain")
+}
+
+func Test_ChainsCmd_Check(t *testing.T) {
+	node, err := ethdevnode.NewNode(context.Background())
+	require.NoError(t, err)
+	server := httptest.NewServer(node)
+	defer server.Close()
+
+	endpoints := []*EndpointHealth{
+		{Name: "dev", URL: server.URL, ExpectedChainID: 1337},
+		{Name: "wrong-chain", URL: server.URL, ExpectedChainID: 1},
+		{Name: "stale", URL: server.URL},
+	}
+	now := time.Now
+	checkEndpoints(context.Background(), endpoints[:2], time.Second, time.Minute, now)
+	checkEndpoints(context.Background(), endpoints[2:], time.Second, time.Minute, func() time.Time {
+		return now().Add(time.Hour)
+	})
+
+	assert.True(t, endpoints[0].Healthy)
+	assert.Equal(t, uint64(1337), endpoints[0].ChainID)
+	assert.Equal(t, uint64(0), endpoints[0].HeadBlock)
+
+	assert.False(t, endpoints[1].Healthy)
+	assert.Contains(t, endpoints[1].Error, "chain id mismatch")
+
+	assert.False(t, endpoints[2].Healthy)
+	assert.Contains(t, endpoints[2].Error, "old")
+
+	res, err := execChainsCmd("check --rpc-url " + server.URL)
+	require.NoError(t, err)
+	assert.Contains(t, res, "ok")
+
+	_, err = execChainsCmd("check")
+	assert.Contains(t, err.Error(), "please provide a providers config file or an rpc url")
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(NewCompletionCmd())
+}
+
+// NewCompletionCmd returns a new command to generate the shell completion
+// scripts.
+func NewCompletionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "completion [bash|zsh|fish|powershell]",
+		Short: "Generate the shell completion script",
+		Long: "Generate the shell completion script for ethkit.\n\n" +
+			"Bash:\n" +
+			"  $ source <(ethkit completion bash)\n" +
+			"  # or, to load the completions for each session:\n" +
+			"  $ ethkit completion bash > /etc/bash_completion.d/ethkit\n\n" +
+			"Zsh:\n" +
+			"  $ ethkit completion zsh > \"${fpath[1]}/_ethkit\"\n\n" +
+			"Fish:\n" +
+			"  $ ethkit completion fish > ~/.config/fish/completions/ethkit.fish\n\n" +
+			"PowerShell:\n" +
+			"  PS> ethkit completion powershell | Out-String | Invoke-Expression",
+		ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
+		Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root := cmd.Root()
+			out := cmd.OutOrStdout()
+			switch args[0] {
+			case "bash":
+				return root.GenBashCompletionV2(out, true)
+			case "zsh":
+				return root.GenZshCompletion(out)
+			case "fish":
+				return root.GenFishCompletion(out, true)
+			case "powershell":
+				return root.GenPowerShellCompletionWithDesc(out)
+			}
+			return fmt.Errorf("error: unsupported shell %q", args[0])
+		},
+	}
+
+	return cmd
+}
+
+// completeBlockArg completes the block tags of a block argument.
+func completeBlockArg(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return []string{"latest", "pending", "finalized", "safe", "earliest"}, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeChainArg completes the names and ids of the known chains.
+func completeChainArg(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var completions []string
+	for _, chain := range knownChains() {
+		completions = append(completions, chain.Name+"\t"+strconv.FormatUint(chain.ChainID, 10))
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,472 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/params"
+)
+
+const (
+	flagConsoleRpcUrl     = "rpc-url"
+	flagConsoleWalletFile = "wallet-file"
+	flagConsoleHistory    = "history-file"
+)
+
+const consolePrompt = "ethkit> "
+
+// consoleHelp is the usage of the console commands.
+var consoleHelp = [][2]string{
+	{"rpc [url]", "show or set the rpc endpoint"},
+	{"wallet [file]", "show the wallet address, or load a wallet from a mnemonic or private key file"},
+	{"chainid", "show the chain id"},
+	{"block [number|hash|tag]", "show a block header, default latest"},
+	{"balance [address]", "show the balance of an address, default the wallet"},
+	{"nonce [address]", "show the nonce of an address, default the wallet"},
+	{"encode <method> [args...]", "abi encode calldata, ie. encode 'transfer(address,uint256)' 0x... 1"},
+	{"decode <types> <hex>", "abi decode data, ie. decode '(uint256,address)' 0x..."},
+	{"call <to> <method> <returns> [args...]", "call a contract, ie. call 0x... 'balanceOf(address)' '(uint256)' 0x..."},
+	{"send <to> <value> [method args...]", "send a transaction from the wallet and wait for its receipt, value in wei or with an ether/gwei suffix"},
+	{"history", "show the command history"},
+	{"help", "show this help"},
+	{"exit", "exit the console"},
+}
+
+func init() {
+	rootCmd.AddCommand(NewConsoleCmd())
+}
+
+// NewConsoleCmd returns a new command to start an interactive console.
+func NewConsoleCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "console",
+		Short: "Start an interactive console with a persistent provider and wallet",
+		Long: "Start an interactive console with a persistent provider and wallet to query the chain,\n" +
+			"abi encode and decode, call contracts and send transactions. Type help for the list of commands.",
+		Args: cobra.NoArgs,
+		RunE: runConsole,
+	}
+
+	home, _ := os.UserHomeDir()
+	cmd.Flags().StringP(flagConsoleRpcUrl, "r", "", "The RPC endpoint to the blockchain node to interact with")
+	cmd.Flags().String(flagConsoleWalletFile, "", "Path to a file with the mnemonic or the private key of the wallet")
+	cmd.Flags().String(flagConsoleHistory, filepath.Join(home, ".ethkit_history"), "Path to the history file, empty to disable")
+
+	return cmd
+}
+
+func runConsole(cmd *cobra.Command, args []string) error {
+	fRpc, err := cmd.Flags().GetString(flagConsoleRpcUrl)
+	if err != nil {
+		return err
+	}
+	fWalletFile, err := cmd.Flags().GetString(flagConsoleWalletFile)
+	if err != nil {
+		return err
+	}
+	fHistory, err := cmd.Flags().GetString(flagConsoleHistory)
+	if err != nil {
+		return err
+	}
+
+	c := &console{out: cmd.OutOrStdout(), historyFile: fHistory}
+	ctx := context.Background()
+	if fRpc != "" {
+		if err := c.exec(ctx, []string{"rpc", fRpc}); err != nil {
+			return err
+		}
+	}
+	if fWalletFile != "" {
+		if err := c.exec(ctx, []string{"wallet", fWalletFile}); err != nil {
+			return err
+		}
+	}
+	if fHistory != "" {
+		if data, err := os.ReadFile(fHistory); err == nil {
+			c.history = strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+		}
+	}
+
+	return c.run(ctx, cmd.InOrStdin())
+}
+
+var errConsoleExit = errors.New("exit")
+
+// console is the state of an interactive console session.
+type console struct {
+	out         io.Writer
+	rpcUrl      string
+	provider    *ethrpc.Provider
+	wallet      *ethwallet.Wallet
+	history     []string
+	historyFile string
+}
+
+// run reads and executes the commands of in until exit or EOF.
+func (c *console) run(ctx context.Context, in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(c.out, consolePrompt)
+		if !scanner.Scan() {
+			fmt.Fprintln(c.out)
+			return scanner.Err()
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		c.addHistory(line)
+
+		args, err := splitConsoleLine(line)
+		if err == nil {
+			err = c.exec(ctx, args)
+		}
+		if errors.Is(err, errConsoleExit) {
+			return nil
+		}
+		if err != nil {
+			fmt.Fprintln(c.out, "error:", strings.TrimPrefix(err.Error(), "error: "))
+		}
+	}
+}
+
+func (c *console) addHistory(line string) {
+	c.history = append(c.history, line)
+	if c.historyFile == "" {
+		return
+	}
+	f, err := os.OpenFile(c.historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, line)
+}
+
+// exec executes a console command.
+func (c *console) exec(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	name, args := args[0], args[1:]
+
+	switch name {
+	case "help":
+		for _, h := range consoleHelp {
+			fmt.Fprintf(c.out, "  %-40s %s\n", h[0], h[1])
+		}
+		return nil
+
+	case "exit", "quit":
+		return errConsoleExit
+
+	case "history":
+		for i, line := range c.history {
+			fmt.Fprintf(c.out, "%5d  %s\n", i+1, line)
+		}
+		return nil
+
+	case "rpc":
+		if len(args) == 0 {
+			if c.provider == nil {
+				return errors.New("no rpc endpoint, set one with: rpc <url>")
+			}
+			fmt.Fprintln(c.out, c.rpcUrl)
+			return nil
+		}
+		if _, err := url.ParseRequestURI(args[0]); err != nil {
+			return errors.New("error: please provide a valid rpc url (e.g. https://nodes.sequence.app/mainnet)")
+		}
+		provider, err := ethrpc.NewProvider(args[0])
+		if err != nil {
+			return err
+		}
+		chainID, err := provider.ChainID(ctx)
+		if err != nil {
+			return err
+		}
+		c.rpcUrl, c.provider = args[0], provider
+		if c.wallet != nil {
+			c.wallet.SetProvider(provider)
+		}
+		fmt.Fprintf(c.out, "connected to %s, chain id %s\n", args[0], chainID)
+		return nil
+
+	case "wallet":
+		if len(args) == 0 {
+			if c.wallet == nil {
+				return errors.New("no wallet, load one with: wallet <file>")
+			}
+			fmt.Fprintln(c.out, c.wallet.Address().Hex())
+			return nil
+		}
+		wallet, err := readConsoleWallet(args[0])
+		if err != nil {
+			return err
+		}
+		if c.provider != nil {
+			wallet.SetProvider(c.provider)
+		}
+		c.wallet = wallet
+		fmt.Fprintln(c.out, wallet.Address().Hex())
+		return nil
+
+	case "encode":
+		if len(args) == 0 {
+			return errors.New("usage: encode <method> [args...]")
+		}
+		calldata, err := ethcoder.AbiEncodeMethodCalldataFromStringValues(args[0], args[1:])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(c.out, ethcoder.HexEncode(calldata))
+		return nil
+
+	case "decode":
+		if len(args) != 2 {
+			return errors.New("usage: decode <types> <hex>")
+		}
+		data, err := ethcoder.HexDecode(args[1])
+		if err != nil {
+			return err
+		}
+		values, err := ethcoder.AbiDecodeExprAndStringify(args[0], data)
+		if err != nil {
+			return err
+		}
+		for _, v := range values {
+			fmt.Fprintln(c.out, v)
+		}
+		return nil
+	}
+
+	// the remaining commands require a provider
+	if c.provider == nil {
+		return errors.New("no rpc endpoint, set one with: rpc <url>")
+	}
+
+	switch name {
+	case "chainid":
+		chainID, err := c.provider.ChainID(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(c.out, chainID)
+		return nil
+
+	case "block":
+		arg := "latest"
+		if len(args) > 0 {
+			arg = args[0]
+		}
+		block, err := fetchBlock(ctx, c.provider, arg)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(c.out, NewHeader(block))
+		return nil
+
+	case "balance", "nonce":
+		address, err := c.addressArg(args)
+		if err != nil {
+			return err
+		}
+		if name == "nonce" {
+			nonce, err := c.provider.NonceAt(ctx, address, nil)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(c.out, nonce)
+			return nil
+		}
+		balance, err := c.provider.BalanceAt(ctx, address, nil)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.out, "%s wei (%s ether)\n", balance, weiToEther(balance).Text('f', 18))
+		return nil
+
+	case "call":
+		if len(args) < 3 {
+			return errors.New("usage: call <to> <method> <returns> [args...]")
+		}
+		if !common.IsHexAddress(args[0]) {
+			return fmt.Errorf("invalid address %q", args[0])
+		}
+		query, err := ethrpc.ContractQuery(common.HexToAddress(args[0]), args[1], args[2], args[3:])
+		if err != nil {
+			return err
+		}
+		var values []string
+		if _, err := c.provider.Do(ctx, query.Into(&values)); err != nil {
+			return err
+		}
+		for _, v := range values {
+			fmt.Fprintln(c.out, v)
+		}
+		return nil
+
+	case "send":
+		return c.send(ctx, args)
+	}
+
+	return fmt.Errorf("unknown command %q, type help for the list of commands", name)
+}
+
+func (c *console) addressArg(args []string) (common.Address, error) {
+	if len(args) == 0 {
+		if c.wallet == nil {
+			return common.Address{}, errors.New("please provide an address or load a wallet")
+		}
+		return c.wallet.Address(), nil
+	}
+	if !common.IsHexAddress(args[0]) {
+		return common.Address{}, fmt.Errorf("invalid address %q", args[0])
+	}
+	return common.HexToAddress(args[0]), nil
+}
+
+func (c *console) send(ctx context.Context, args []string) error {
+	if len(args) < 2 {
+		return errors.New("usage: send <to> <value> [method args...]")
+	}
+	if c.wallet == nil {
+		return errors.New("no wallet, load one with: wallet <file>")
+	}
+	if !common.IsHexAddress(args[0]) {
+		return fmt.Errorf("invalid address %q", args[0])
+	}
+	to := common.HexToAddress(args[0])
+	value, err := parseEtherValue(args[1])
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	if len(args) > 2 {
+		data, err = ethcoder.AbiEncodeMethodCalldataFromStringValues(args[2], args[3:])
+		if err != nil {
+			return err
+		}
+	}
+
+	tx, err := c.wallet.NewTransaction(ctx, &ethtxn.TransactionRequest{To: &to, ETHValue: value, Data: data})
+	if err != nil {
+		return err
+	}
+	tx, waitReceipt, err := c.wallet.SendTransaction(ctx, tx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(c.out, "sent", tx.Hash().Hex())
+
+	receipt, err := waitReceipt(ctx)
+	if err != nil {
+		return err
+	}
+	status := "success"
+	if receipt.Status == 0 {
+		status = "reverted"
+	}
+	fmt.Fprintf(c.out, "%s in block %s, gas used %d\n", status, receipt.BlockNumber, receipt.GasUsed)
+	return nil
+}
+
+// readConsoleWallet reads a wallet from a file with a mnemonic or a hex
+// private key.
+func readConsoleWallet(path string) (*ethwallet.Wallet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wallet file: %w", err)
+	}
+	secret := strings.Join(strings.Fields(string(data)), " ")
+	if ethwallet.IsValidMnemonic(secret) {
+		return ethwallet.NewWalletFromMnemonic(secret)
+	}
+	wallet, err := ethwallet.NewWalletFromPrivateKey(strings.TrimPrefix(secret, "0x"))
+	if err != nil {
+		return nil, errors.New("wallet file must contain a mnemonic or a hex private key")
+	}
+	return wallet, nil
+}
+
+// parseEtherValue parses an amount in wei, or in ether or gwei with a suffix,
+// ie. 1000, 1.5ether or 20gwei.
+func parseEtherValue(s string) (*big.Int, error) {
+	unit := big.NewRat(1, 1)
+	amount := s
+	switch {
+	case strings.HasSuffix(s, "ether"):
+		unit.SetInt64(params.Ether)
+		amount = strings.TrimSuffix(s, "ether")
+	case strings.HasSuffix(s, "gwei"):
+		unit.SetInt64(params.GWei)
+		amount = strings.TrimSuffix(s, "gwei")
+	case strings.HasSuffix(s, "wei"):
+		amount = strings.TrimSuffix(s, "wei")
+	}
+
+	value, ok := new(big.Rat).SetString(amount)
+	if !ok || value.Sign() < 0 {
+		return nil, fmt.Errorf("invalid value %q", s)
+	}
+	value.Mul(value, unit)
+	if !value.IsInt() {
+		return nil, fmt.Errorf("invalid value %q, is a fraction of a wei", s)
+	}
+	return value.Num(), nil
+}
+
+// splitConsoleLine splits a console line into arguments, honoring single and
+// double quotes.
+func splitConsoleLine(line string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	var quote rune
+	inArg := false
+
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethdevnode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func execConsoleCmd(args string, input string) (string, error) {
+	cmd := NewConsoleCmd()
+	actual := new(bytes.Buffer)
+	cmd.SetOut(actual)
+	cmd.SetErr(actual)
+	cmd.SetIn(strings.NewReader(input))
+	cmd.SetArgs(strings.Split(args, " "))
+	if err := cmd.Execute(); err != nil {
+		return "", err
+	}
+
+	return actual.String(), nil
+}
+
+func Test_ConsoleCmd(t *testing.T) {
+	node, err := ethdevnode.NewNode(context.Background())
+	require.NoError(t, err)
+	server := httptest.NewServer(node)
+	defer server.Close()
+
+	dir := t.TempDir()
+	walletFile := filepath.Join(dir, "mnemonic.txt")
+	require.NoError(t, os.WriteFile(walletFile, []byte(testMnemonic+"\n"), 0600))
+	historyFile := filepath.Join(dir, "history")
+
+	input := strings.Join([]string{
+		"chainid",
+		"wallet",
+		"send 0xcDF87cA00696a756fd1e35dd2DBAa1f30A80C0A8 1.5ether",
+		"balance 0xcDF87cA00696a756fd1e35dd2DBAa1f30A80C0A8",
+		"nonce",
+		"encode 'transfer(address,uint256)' 0xcDF87cA00696a756fd1e35dd2DBAa1f30A80C0A8 1",
+		"decode '(uint256,address)' 0x000000000000000000000000000000000000000000000000000000000000002a000000000000000000000000cdf87ca00696a756fd1e35dd2dbaa1f30a80c0a8",
+		"foo",
+		"exit",
+		"chainid",
+	}, "\n")
+
+	res, err := execConsoleCmd("--rpc-url "+server.URL+" --wallet-file "+walletFile+" --history-file "+historyFile, input)
+	require.NoError(t, err)
+
+	assert.Contains(t, res, "chain id 1337")
+	assert.Contains(t, res, "0xB5c3023dbEcE7a6Bb78014000CD1C8ce940B50a0")
+	assert.Contains(t, res, "success in block 1")
+	assert.Contains(t, res, "100001500000000000000000 wei")
+	assert.Contains(t, res, consolePrompt+"1\n")
+	assert.Contains(t, res, "0xa9059cbb000000000000000000000000cdf87ca00696a756fd1e35dd2dbaa1f30a80c0a80000000000000000000000000000000000000000000000000000000000000001")
+	assert.Contains(t, res, "42\n0xcDF87cA00696a756fd1e35dd2DBAa1f30A80C0A8")
+	assert.Contains(t, res, `error: unknown command "foo"`)
+
+	history, err := os.ReadFile(historyFile)
+	require.NoError(t, err)
+	assert.Equal(t, 9, strings.Count(string(history), "\n"))
+}
+
+func Test_ConsoleCmd_NoProvider(t *testing.T) {
+	res, err := execConsoleCmd("--history-file=", "block\nhistory\n")
+	require.NoError(t, err)
+	assert.Contains(t, res, "error: no rpc endpoint")
+	assert.Contains(t, res, "    1  block")
+}
+
+func Test_SplitConsoleLine(t *testing.T) {
+	args, err := splitConsoleLine(`call 0x01 "balanceOf(address)"  '(uint256)' x""`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"call", "0x01", "balanceOf(address)", "(uint256)", "x"}, args)
+
+	args, err = splitConsoleLine(`encode f(string) ""`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"encode", "f(string)", ""}, args)
+
+	_, err = splitConsoleLine(`encode "f(string)`)
+	assert.Error(t, err)
+}
+
+func Test_ParseEtherValue(t *testing.T) {
+	value, err := parseEtherValue("1.5ether")
+	require.NoError(t, err)
+	assert.Equal(t, "1500000000000000000", value.String())
+
+	value, err = parseEtherValue("20gwei")
+	require.NoError(t, err)
+	assert.Equal(t, "20000000000", value.String())
+
+	value, err = parseEtherValue("1000")
+	require.NoError(t, err)
+	assert.Equal(t, "1000", value.String())
+
+	_, err = parseEtherValue("0.5")
+	assert.Error(t, err)
+	_, err = parseEtherValue("-1ether")
+	assert.Error(t, err)
+}
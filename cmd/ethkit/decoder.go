@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethcontract"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+// knownABIs are the events and functions of common token standards, used to
+// decode logs and calldata which are not in the supplied abis. ERC-20 and
+// ERC-721 are separate as their events share the same signatures.
+var knownABIs = []abi.ABI{
+	ethcontract.MustParseABI(`[
+		{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"value","type":"uint256","indexed":false}]},
+		{"type":"event","name":"Approval","inputs":[{"name":"owner","type":"address","indexed":true},{"name":"spender","type":"address","indexed":true},{"name":"value","type":"uint256","indexed":false}]},
+		{"type":"event","name":"Deposit","inputs":[{"name":"dst","type":"address","indexed":true},{"name":"wad","type":"uint256","indexed":false}]},
+		{"type":"event","name":"Withdrawal","inputs":[{"name":"src","type":"address","indexed":true},{"name":"wad","type":"uint256","indexed":false}]},
+		{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+		{"type":"function","name":"transferFrom","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+		{"type":"function","name":"approve","inputs":[{"name":"spender","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+		{"type":"function","name":"deposit","inputs":[],"outputs":[]},
+		{"type":"function","name":"withdraw","inputs":[{"name":"wad","type":"uint256"}],"outputs":[]}
+	]`),
+	ethcontract.MustParseABI(`[
+		{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"tokenId","type":"uint256","indexed":true}]},
+		{"type":"event","name":"Approval","inputs":[{"name":"owner","type":"address","indexed":true},{"name":"approved","type":"address","indexed":true},{"name":"tokenId","type":"uint256","indexed":true}]},
+		{"type":"event","name":"ApprovalForAll","inputs":[{"name":"owner","type":"address","indexed":true},{"name":"operator","type":"address","indexed":true},{"name":"approved","type":"bool","indexed":false}]},
+		{"type":"function","name":"safeTransferFrom","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"tokenId","type":"uint256"}],"outputs":[]},
+		{"type":"function","name":"setApprovalForAll","inputs":[{"name":"operator","type":"address"},{"name":"approved","type":"bool"}],"outputs":[]}
+	]`),
+	ethcontract.MustParseABI(`[
+		{"type":"event","name":"TransferSingle","inputs":[{"name":"operator","type":"address","indexed":true},{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"id","type":"uint256","indexed":false},{"name":"value","type":"uint256","indexed":false}]},
+		{"type":"event","name":"TransferBatch","inputs":[{"name":"operator","type":"address","indexed":true},{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"ids","type":"uint256[]","indexed":false},{"name":"values","type":"uint256[]","indexed":false}]},
+		{"type":"function","name":"safeTransferFrom","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"id","type":"uint256"},{"name":"value","type":"uint256"},{"name":"data","type":"bytes"}],"outputs":[]},
+		{"type":"function","name":"safeBatchTransferFrom","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"ids","type":"uint256[]"},{"name":"values","type":"uint256[]"},{"name":"data","type":"bytes"}],"outputs":[]}
+	]`),
+}
+
+// Decoder decodes logs and calldata with the supplied abis, falling back to
+// the abis of the local cache and of common token standards.
+type Decoder struct {
+	abis  []abi.ABI
+	calls *ethcoder.CalldataDecoder
+}
+
+// NewDecoder returns a Decoder of the abi json files at paths and of the abis
+// fetched into the local cache.
+func NewDecoder(paths ...string) (*Decoder, error) {
+	abis, err := readABIFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+	cached, err := readCachedABIs(abiCacheDir())
+	if err != nil {
+		return nil, err
+	}
+	abis = append(abis, cached...)
+	abis = append(abis, knownABIs...)
+	return &Decoder{abis: abis, calls: ethcoder.NewCalldataDecoder(abis...)}, nil
+}
+
+func readABIFiles(paths []string) ([]abi.ABI, error) {
+	var abis []abi.ABI
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := ethcontract.ParseABI(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("error: failed to parse abi %s: %w", path, err)
+		}
+		abis = append(abis, parsed)
+	}
+	return abis, nil
+}
+
+// DecodedLog is a log with its event and arguments, when known.
+type DecodedLog struct {
+	Address common.Address `json:"address"`
+	Topics  []common.Hash  `json:"topics"`
+	Data    string         `json:"data"`
+	Index   uint           `json:"logIndex"`
+	Event   string         `json:"event,omitempty"`
+	Args    map[string]any `json:"args,omitempty"`
+}
+
+// DecodedCall is calldata with its function and arguments, when known.
+type DecodedCall struct {
+	Selector string         `json:"selector"`
+	Function string         `json:"function,omitempty"`
+	Args     map[string]any `json:"args,omitempty"`
+}
+
+func (d *Decoder) DecodeLog(log *types.Log) *DecodedLog {
+	decoded := &DecodedLog{
+		Address: log.Address,
+		Topics:  log.Topics,
+		Data:    ethcoder.HexEncode(log.Data),
+		Index:   log.Index,
+	}
+	if len(log.Topics) == 0 {
+		return decoded
+	}
+
+	for _, contractABI := range d.abis {
+		event, err := contractABI.EventByID(log.Topics[0])
+		if err != nil {
+			continue
+		}
+		var indexed abi.Arguments
+		for _, arg := range event.Inputs {
+			if arg.Indexed {
+				indexed = append(indexed, arg)
+			}
+		}
+		if len(indexed) != len(log.Topics)-1 {
+			continue
+		}
+
+		args := map[string]any{}
+		if err := event.Inputs.NonIndexed().UnpackIntoMap(args, log.Data); err != nil {
+			continue
+		}
+		if err := abi.ParseTopicsIntoMap(args, indexed, log.Topics[1:]); err != nil {
+			continue
+		}
+		decoded.Event = event.Sig
+		decoded.Args = formatArgs(args)
+		break
+	}
+	return decoded
+}
+
+func (d *Decoder) DecodeCalldata(data []byte) *DecodedCall {
+	if len(data) < 4 {
+		return nil
+	}
+	decoded := &DecodedCall{Selector: ethcoder.HexEncode(data[:4])}
+
+	if call, err := d.calls.Decode(data); err == nil {
+		decoded.Function = call.Signature
+		decoded.Args = formatArgs(call.Map())
+	}
+	return decoded
+}
+
+// formatArgs converts decoded values to their usual string representation,
+// ie. numbers as decimal and bytes as hex.
+func formatArgs(args map[string]any) map[string]any {
+	formatted := make(map[string]any, len(args))
+	for k, v := range args {
+		formatted[k] = formatArg(v)
+	}
+	return formatted
+}
+
+func formatArg(v any) any {
+	switch v := v.(type) {
+	case *big.Int:
+		return v.String()
+	case common.Address:
+		return v.Hex()
+	case common.Hash:
+		return v.Hex()
+	case []byte:
+		return ethcoder.HexEncode(v)
+	case [32]byte:
+		return ethcoder.HexEncode(v[:])
+	case []*big.Int:
+		s := make([]any, len(v))
+		for i := range v {
+			s[i] = v[i].String()
+		}
+		return s
+	case []common.Address:
+		s := make([]any, len(v))
+		for i := range v {
+			s[i] = v[i].Hex()
+		}
+		return s
+	default:
+		return v
+	}
+}
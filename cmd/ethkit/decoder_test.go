@@ -0,0 +1,43 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DecoderKnownLogs(t *testing.T) {
+	decoder, err := NewDecoder()
+	require.NoError(t, err)
+
+	from := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	transferSig := ethcoder.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+	erc20 := decoder.DecodeLog(&types.Log{
+		Topics: []common.Hash{transferSig, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:   common.BigToHash(big.NewInt(1000)).Bytes(),
+	})
+	assert.Equal(t, "Transfer(address,address,uint256)", erc20.Event)
+	assert.Equal(t, map[string]any{"from": from.Hex(), "to": to.Hex(), "value": "1000"}, erc20.Args)
+
+	erc721 := decoder.DecodeLog(&types.Log{
+		Topics: []common.Hash{transferSig, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes()), common.BigToHash(big.NewInt(7))},
+	})
+	assert.Equal(t, map[string]any{"from": from.Hex(), "to": to.Hex(), "tokenId": "7"}, erc721.Args)
+
+	unknown := decoder.DecodeLog(&types.Log{Topics: []common.Hash{{0x01}}})
+	assert.Empty(t, unknown.Event)
+
+	calldata, err := ethcoder.AbiEncodeMethodCalldata("transfer(address,uint256)", []interface{}{to, big.NewInt(5)})
+	require.NoError(t, err)
+	call := decoder.DecodeCalldata(calldata)
+	assert.Equal(t, "0xa9059cbb", call.Selector)
+	assert.Equal(t, "transfer(address,uint256)", call.Function)
+	assert.Equal(t, map[string]any{"to": to.Hex(), "value": "5"}, call.Args)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/spf13/cobra"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethtrace"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+const (
+	flagGasProfileRpcUrl = "rpc-url"
+	flagGasProfileAbi    = "abi"
+	flagGasProfileJson   = "json"
+)
+
+func init() {
+	rootCmd.AddCommand(NewGasProfileCmd())
+}
+
+type gasProfile struct {
+}
+
+// NewGasProfileCmd returns a new command to profile the gas usage of a transaction.
+func NewGasProfileCmd() *cobra.Command {
+	c := &gasProfile{}
+	cmd := &cobra.Command{
+		Use:     "gas-profile [txhash]",
+		Short:   "Profile the gas usage of a transaction by contract and function",
+		Aliases: []string{"gp"},
+		Args:    cobra.ExactArgs(1),
+		RunE:    c.Run,
+	}
+
+	cmd.Flags().StringP(flagGasProfileRpcUrl, "r", "", "The RPC endpoint to the blockchain node to interact with (must support debug_traceTransaction)")
+	cmd.Flags().StringSlice(flagGasProfileAbi, nil, "Path to abi json file(s) used to resolve function names")
+	cmd.Flags().BoolP(flagGasProfileJson, "j", false, "Print the profile as JSON")
+
+	return cmd
+}
+
+func (c *gasProfile) Run(cmd *cobra.Command, args []string) error {
+	fTxHash := cmd.Flags().Args()[0]
+	fRpc, err := cmd.Flags().GetString(flagGasProfileRpcUrl)
+	if err != nil {
+		return err
+	}
+	fAbi, err := cmd.Flags().GetStringSlice(flagGasProfileAbi)
+	if err != nil {
+		return err
+	}
+	fJson, err := cmd.Flags().GetBool(flagGasProfileJson)
+	if err != nil {
+		return err
+	}
+
+	if len(common.FromHex(fTxHash)) != common.HashLength {
+		return errors.New("error: please provide a valid transaction hash")
+	}
+
+	if _, err = url.ParseRequestURI(fRpc); err != nil {
+		return errors.New("error: please provide a valid rpc url (e.g. https://nodes.sequence.app/mainnet)")
+	}
+
+	abis, err := readABIFiles(fAbi)
+	if err != nil {
+		return err
+	}
+
+	provider, err := ethrpc.NewProvider(fRpc)
+	if err != nil {
+		return err
+	}
+
+	frame, err := provider.DebugTraceTransaction(context.Background(), common.HexToHash(fTxHash))
+	if err != nil {
+		return err
+	}
+
+	profile := ethtrace.ProfileGas(frame, ethtrace.GasProfileOptions{
+		ResolveFunction: ethtrace.ABIFunctionResolver(abis...),
+	})
+
+	if fJson {
+		json, err := PrettyJSON(profile)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), *json)
+		return nil
+	}
+
+	return profile.WriteTable(cmd.OutOrStdout())
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+const (
+	flagHdMnemonicFile = "mnemonic-file"
+	flagHdPath         = "path"
+	flagHdStandard     = "standard"
+	flagHdCount        = "count"
+	flagHdRpcUrl       = "rpc-url"
+	flagHdFunded       = "funded"
+	flagHdJson         = "json"
+)
+
+// hdStandards are the derivation path templates used by popular wallets.
+var hdStandards = []struct {
+	Name string
+	Path string
+}{
+	{"bip44", "m/44'/60'/0'/0/{i}"},       // MetaMask, Trezor, Ledger (Ethereum app), ethwallet default
+	{"ledger-live", "m/44'/60'/{i}'/0/0"}, // Ledger Live
+	{"legacy", "m/44'/60'/0'/{i}"},        // Ledger legacy, MyEtherWallet
+}
+
+func init() {
+	rootCmd.AddCommand(NewHdCmd())
+}
+
+type hd struct {
+}
+
+// NewHdCmd returns a new command to list the addresses derived from a mnemonic
+// across derivation paths.
+func NewHdCmd() *cobra.Command {
+	c := &hd{}
+	cmd := &cobra.Command{
+		Use:   "hd",
+		Short: "List the accounts derived from a mnemonic across derivation paths",
+		Long: "List the accounts derived from a mnemonic across derivation paths, optionally with their balances.\n" +
+			"Paths accept ranges, ie. \"m/44'/60'/0'/0/{0..20}\", or use --standard to list the paths of\n" +
+			"the popular wallets: bip44 (m/44'/60'/0'/0/i), ledger-live (m/44'/60'/i'/0/0), legacy (m/44'/60'/0'/i) or all.",
+		Args: cobra.NoArgs,
+		RunE: c.Run,
+	}
+
+	cmd.Flags().String(flagHdMnemonicFile, "", "Path to the file with the mnemonic, or - to read it from stdin")
+	cmd.Flags().StringP(flagHdPath, "p", "", "The derivation path(s), with optional {from..to} ranges")
+	cmd.Flags().String(flagHdStandard, "", "The derivation standard: bip44, ledger-live, legacy or all")
+	cmd.Flags().IntP(flagHdCount, "n", 10, "The number of accounts to derive per standard")
+	cmd.Flags().StringP(flagHdRpcUrl, "r", "", "The RPC endpoint to fetch the balances and nonces of the accounts")
+	cmd.Flags().Bool(flagHdFunded, false, "Only list the accounts with a balance or a nonce, requires --rpc-url")
+	cmd.Flags().BoolP(flagHdJson, "j", false, "Print the accounts as JSON")
+
+	return cmd
+}
+
+func (c *hd) Run(cmd *cobra.Command, args []string) error {
+	fMnemonicFile, err := cmd.Flags().GetString(flagHdMnemonicFile)
+	if err != nil {
+		return err
+	}
+	fPath, err := cmd.Flags().GetString(flagHdPath)
+	if err != nil {
+		return err
+	}
+	fStandard, err := cmd.Flags().GetString(flagHdStandard)
+	if err != nil {
+		return err
+	}
+	fCount, err := cmd.Flags().GetInt(flagHdCount)
+	if err != nil {
+		return err
+	}
+	fRpc, err := cmd.Flags().GetString(flagHdRpcUrl)
+	if err != nil {
+		return err
+	}
+	fFunded, err := cmd.Flags().GetBool(flagHdFunded)
+	if err != nil {
+		return err
+	}
+	fJson, err := cmd.Flags().GetBool(flagHdJson)
+	if err != nil {
+		return err
+	}
+
+	if fMnemonicFile == "" {
+		return errors.New("error: please provide a mnemonic file (e.g. --mnemonic-file ./mnemonic.txt)")
+	}
+	mnemonic, err := readMnemonicFile(cmd.InOrStdin(), fMnemonicFile)
+	if err != nil {
+		return err
+	}
+
+	type pathSet struct {
+		standard string
+		paths    []string
+	}
+	var sets []pathSet
+
+	if fPath != "" {
+		paths, err := expandDerivationPaths(fPath)
+		if err != nil {
+			return err
+		}
+		sets = append(sets, pathSet{"custom", paths})
+	}
+	if fStandard != "" || fPath == "" {
+		if fStandard == "" {
+			fStandard = "bip44"
+		}
+		found := false
+		for _, s := range hdStandards {
+			if fStandard != "all" && fStandard != s.Name {
+				continue
+			}
+			found = true
+			template := strings.Replace(s.Path, "{i}", fmt.Sprintf("{0..%d}", fCount-1), 1)
+			paths, err := expandDerivationPaths(template)
+			if err != nil {
+				return err
+			}
+			sets = append(sets, pathSet{s.Name, paths})
+		}
+		if !found {
+			return fmt.Errorf("error: unknown derivation standard %q", fStandard)
+		}
+	}
+
+	if fRpc != "" {
+		if _, err = url.ParseRequestURI(fRpc); err != nil {
+			return errors.New("error: please provide a valid rpc url (e.g. https://nodes.sequence.app/mainnet)")
+		}
+	} else if fFunded {
+		return errors.New("error: --funded requires --rpc-url")
+	}
+
+	node, err := ethwallet.NewHDNodeFromMnemonic(mnemonic, nil)
+	if err != nil {
+		return fmt.Errorf("error: invalid mnemonic: %w", err)
+	}
+
+	var accounts []*HDAccount
+	for _, set := range sets {
+		for _, path := range set.paths {
+			if err := node.DerivePathFromString(path); err != nil {
+				return fmt.Errorf("error: invalid derivation path %q: %w", path, err)
+			}
+			accounts = append(accounts, &HDAccount{Standard: set.standard, Path: path, Address: node.Address()})
+		}
+	}
+
+	if fRpc != "" {
+		provider, err := ethrpc.NewProvider(fRpc)
+		if err != nil {
+			return err
+		}
+		if err := fetchHDAccountStates(context.Background(), provider, accounts); err != nil {
+			return err
+		}
+	}
+
+	if fFunded {
+		funded := accounts[:0]
+		for _, account := range accounts {
+			if account.Balance.Sign() > 0 || *account.Nonce > 0 {
+				funded = append(funded, account)
+			}
+		}
+		accounts = funded
+	}
+
+	out := cmd.OutOrStdout()
+	if fJson {
+		json, err := PrettyJSON(accounts)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, *json)
+		return nil
+	}
+	for _, account := range accounts {
+		fmt.Fprintln(out, account)
+	}
+	return nil
+}
+
+// HDAccount is an account derived from a mnemonic, with its balance and nonce
+// when fetched.
+type HDAccount struct {
+	Standard string         `json:"standard"`
+	Path     string         `json:"path"`
+	Address  common.Address `json:"address"`
+	Balance  *big.Int       `json:"balance,omitempty"`
+	Nonce    *uint64        `json:"nonce,omitempty"`
+}
+
+func (a *HDAccount) String() string {
+	s := fmt.Sprintf("%-12s %-24s %s", a.Standard, a.Path, a.Address.Hex())
+	if a.Balance != nil {
+		s += fmt.Sprintf("  %s ether", weiToEther(a.Balance).Text('f', 6))
+	}
+	if a.Nonce != nil {
+		s += fmt.Sprintf("  nonce %d", *a.Nonce)
+	}
+	return s
+}
+
+// fetchHDAccountStates fetches the balances and nonces of the accounts in a
+// single batch.
+func fetchHDAccountStates(ctx context.Context, provider *ethrpc.Provider, accounts []*HDAccount) error {
+	if len(accounts) == 0 {
+		return nil
+	}
+	calls := make([]ethrpc.Call, 0, len(accounts)*2)
+	for _, account := range accounts {
+		account.Nonce = new(uint64)
+		calls = append(calls,
+			ethrpc.BalanceAt(account.Address, nil).Into(&account.Balance),
+			ethrpc.NonceAt(account.Address, nil).Into(account.Nonce),
+		)
+	}
+	_, err := provider.Do(ctx, calls...)
+	return err
+}
+
+func readMnemonicFile(stdin io.Reader, path string) (string, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error: failed to read mnemonic: %w", err)
+	}
+
+	mnemonic := strings.Join(strings.Fields(string(data)), " ")
+	if !ethwallet.IsValidMnemonic(mnemonic) {
+		return "", errors.New("error: invalid mnemonic")
+	}
+	return mnemonic, nil
+}
+
+var derivationRangeRegexp = regexp.MustCompile(`\{(\d+)\.\.(\d+)\}`)
+
+// maxDerivationPaths is the max number of paths a template expands to.
+const maxDerivationPaths = 10_000
+
+// expandDerivationPaths expands the {from..to} ranges, inclusive, of a
+// derivation path template. Ranges of hardened components are written as
+// {from..to}'.
+func expandDerivationPaths(template string) ([]string, error) {
+	paths := []string{template}
+	for {
+		loc := derivationRangeRegexp.FindStringSubmatchIndex(paths[0])
+		if loc == nil {
+			break
+		}
+		from, err1 := strconv.ParseUint(paths[0][loc[2]:loc[3]], 10, 31)
+		to, err2 := strconv.ParseUint(paths[0][loc[4]:loc[5]], 10, 31)
+		if err1 != nil || err2 != nil || from > to {
+			return nil, fmt.Errorf("error: invalid derivation path range %q", paths[0][loc[0]:loc[1]])
+		}
+		if len(paths)*int(to-from+1) > maxDerivationPaths {
+			return nil, fmt.Errorf("error: derivation path %q expands to more than %d paths", template, maxDerivationPaths)
+		}
+
+		expanded := make([]string, 0, len(paths)*int(to-from+1))
+		for _, path := range paths {
+			loc := derivationRangeRegexp.FindStringIndex(path)
+			for i := from; i <= to; i++ {
+				expanded = append(expanded, path[:loc[0]]+strconv.FormatUint(i, 10)+path[loc[1]:])
+			}
+		}
+		paths = expanded
+	}
+
+	for _, path := range paths {
+		if _, err := ethwallet.ParseDerivationPath(path); err != nil {
+			return nil, fmt.Errorf("error: invalid derivation path %q: %w", path, err)
+		}
+	}
+	return paths, nil
+}
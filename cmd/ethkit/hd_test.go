@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMnemonic = "major danger this key only test please avoid main net use okay"
+
+func execHdCmd(args string) (string, error) {
+	cmd := NewHdCmd()
+	actual := new(bytes.Buffer)
+	cmd.SetOut(actual)
+	cmd.SetErr(actual)
+	cmd.SetArgs(strings.Split(args, " "))
+	if err := cmd.Execute(); err != nil {
+		return "", err
+	}
+
+	return actual.String(), nil
+}
+
+func Test_HdCmd(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "mnemonic.txt")
+	require.NoError(t, os.WriteFile(file, []byte(testMnemonic+"\n"), 0600))
+
+	res, err := execHdCmd("--mnemonic-file " + file + " --count 2")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(res), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "m/44'/60'/0'/0/0")
+	assert.Contains(t, lines[0], "0xB5c3023dbEcE7a6Bb78014000CD1C8ce940B50a0")
+	assert.Contains(t, lines[1], "0xcDF87cA00696a756fd1e35dd2DBAa1f30A80C0A8")
+
+	res, err = execHdCmd("--mnemonic-file " + file + " --standard all --count 3")
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSpace(res), "\n"), 9)
+	assert.Contains(t, res, "m/44'/60'/2'/0/0")
+	assert.Contains(t, res, "m/44'/60'/0'/2")
+
+	res, err = execHdCmd("--mnemonic-file " + file + " --path m/44'/60'/0'/0/{1..1}")
+	require.NoError(t, err)
+	assert.Contains(t, res, "0xcDF87cA00696a756fd1e35dd2DBAa1f30A80C0A8")
+
+	_, err = execHdCmd("--mnemonic-file " + file + " --standard foo")
+	assert.Contains(t, err.Error(), "unknown derivation standard")
+
+	_, err = execHdCmd("--mnemonic-file " + file + " --funded")
+	assert.Contains(t, err.Error(), "--funded requires --rpc-url")
+}
+
+func Test_ExpandDerivationPaths(t *testing.T) {
+	paths, err := expandDerivationPaths("m/44'/60'/{0..1}'/0/{0..2}")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"m/44'/60'/0'/0/0", "m/44'/60'/0'/0/1", "m/44'/60'/0'/0/2",
+		"m/44'/60'/1'/0/0", "m/44'/60'/1'/0/1", "m/44'/60'/1'/0/2",
+	}, paths)
+
+	_, err = expandDerivationPaths("m/44'/60'/0'/0/{2..1}")
+	assert.Error(t, err)
+	_, err = expandDerivationPaths("m/44'/60'/0'/0/{0..100000}")
+	assert.Error(t, err)
+	_, err = expandDerivationPaths("m/44'/x")
+	assert.Error(t, err)
+}
@@ -20,7 +20,7 @@ var rootCmd = &cobra.Command{
 	Long:  banner(),
 	Args:  cobra.MinimumNArgs(1),
 	CompletionOptions: cobra.CompletionOptions{
-		HiddenDefaultCmd: true,
+		DisableDefaultCmd: true,
 	},
 }
 
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethsnapshot"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+const (
+	flagNFTSnapshotRpcUrl     = "rpc-url"
+	flagNFTSnapshotBlock      = "block"
+	flagNFTSnapshotStandard   = "standard"
+	flagNFTSnapshotStartBlock = "start-block"
+	flagNFTSnapshotFormat     = "format"
+	flagNFTSnapshotOutput     = "output"
+)
+
+func init() {
+	rootCmd.AddCommand(NewNFTSnapshotCmd())
+}
+
+type nftSnapshot struct {
+}
+
+// NewNFTSnapshotCmd returns a new command to snapshot the owners of an NFT collection.
+func NewNFTSnapshotCmd() *cobra.Command {
+	c := &nftSnapshot{}
+	cmd := &cobra.Command{
+		Use:   "nft-snapshot [contract]",
+		Short: "Snapshot the owners of all tokens of an ERC-721 or ERC-1155 collection at a block",
+		Args:  cobra.ExactArgs(1),
+		RunE:  c.Run,
+	}
+
+	cmd.Flags().StringP(flagNFTSnapshotRpcUrl, "r", "", "The RPC endpoint to the blockchain node to interact with")
+	cmd.Flags().Uint64P(flagNFTSnapshotBlock, "b", 0, "Block number of the snapshot (default latest)")
+	cmd.Flags().StringP(flagNFTSnapshotStandard, "s", string(ethsnapshot.ERC721), "Token standard of the collection, erc721 or erc1155")
+	cmd.Flags().Uint64(flagNFTSnapshotStartBlock, 0, "Block the collection was deployed at, logs before it are not scanned")
+	cmd.Flags().StringP(flagNFTSnapshotFormat, "f", "csv", "Output format, csv or json")
+	cmd.Flags().StringP(flagNFTSnapshotOutput, "o", "", "Path of the output file (default stdout)")
+
+	return cmd
+}
+
+func (c *nftSnapshot) Run(cmd *cobra.Command, args []string) error {
+	fContract := cmd.Flags().Args()[0]
+	fRpc, err := cmd.Flags().GetString(flagNFTSnapshotRpcUrl)
+	if err != nil {
+		return err
+	}
+	fBlock, err := cmd.Flags().GetUint64(flagNFTSnapshotBlock)
+	if err != nil {
+		return err
+	}
+	fStandard, err := cmd.Flags().GetString(flagNFTSnapshotStandard)
+	if err != nil {
+		return err
+	}
+	fStartBlock, err := cmd.Flags().GetUint64(flagNFTSnapshotStartBlock)
+	if err != nil {
+		return err
+	}
+	fFormat, err := cmd.Flags().GetString(flagNFTSnapshotFormat)
+	if err != nil {
+		return err
+	}
+	fOutput, err := cmd.Flags().GetString(flagNFTSnapshotOutput)
+	if err != nil {
+		return err
+	}
+
+	if !common.IsHexAddress(fContract) {
+		return errors.New("error: please provide a valid contract address (e.g. 0x1234...)")
+	}
+	if _, err = url.ParseRequestURI(fRpc); err != nil {
+		return errors.New("error: please provide a valid rpc url (e.g. https://nodes.sequence.app/mainnet)")
+	}
+	if fFormat != "csv" && fFormat != "json" {
+		return fmt.Errorf("error: unsupported format %q, expecting csv or json", fFormat)
+	}
+
+	provider, err := ethrpc.NewProvider(fRpc)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if fBlock == 0 {
+		fBlock, err = provider.BlockNumber(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	contract := common.HexToAddress(fContract)
+	options := ethsnapshot.DefaultNFTSnapshotOptions
+	options.StartBlock = fStartBlock
+
+	var snapshot *ethsnapshot.NFTSnapshot
+	switch ethsnapshot.NFTStandard(fStandard) {
+	case ethsnapshot.ERC721:
+		snapshot, err = ethsnapshot.ERC721Snapshot(ctx, provider, contract, fBlock, options)
+	case ethsnapshot.ERC1155:
+		snapshot, err = ethsnapshot.ERC1155Snapshot(ctx, provider, contract, fBlock, options)
+	default:
+		return fmt.Errorf("error: unsupported standard %q, expecting erc721 or erc1155", fStandard)
+	}
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = cmd.OutOrStdout()
+	if fOutput != "" {
+		f, err := os.Create(fOutput)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if fFormat == "json" {
+		return snapshot.WriteJSON(out)
+	}
+	return snapshot.WriteCSV(out)
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/0xsequence/ethkit/ethdevnode"
+	"github.com/0xsequence/ethkit/go-ethereum/params"
+)
+
+const (
+	flagNodeHost      = "host"
+	flagNodePort      = "port"
+	flagNodeChainID   = "chain-id"
+	flagNodeAccounts  = "accounts"
+	flagNodeBalance   = "balance"
+	flagNodeMnemonic  = "mnemonic"
+	flagNodeForkUrl   = "fork-url"
+	flagNodeForkBlock = "fork-block"
+)
+
+func init() {
+	rootCmd.AddCommand(NewNodeCmd())
+}
+
+type node struct {
+}
+
+// NewNodeCmd returns a new command to run an in-process dev chain.
+func NewNodeCmd() *cobra.Command {
+	c := &node{}
+	cmd := &cobra.Command{
+		Use:   "node",
+		Short: "Run a local dev chain with instant mining and prefunded accounts",
+		Long: "Run a lightweight in-process dev chain exposing the standard JSON-RPC API, with instant mining,\n" +
+			"prefunded accounts, evm_snapshot/evm_revert and fork mode via an upstream RPC endpoint.\n" +
+			"Transactions and calls are executed by an EVM of the cancun fork.",
+		Args: cobra.NoArgs,
+		RunE: c.Run,
+	}
+
+	cmd.Flags().String(flagNodeHost, "127.0.0.1", "The host to listen on")
+	cmd.Flags().IntP(flagNodePort, "p", 8545, "The port to listen on")
+	cmd.Flags().Uint64(flagNodeChainID, 0, "The chain id, defaults to 1337 or to the chain id of the fork")
+	cmd.Flags().Int(flagNodeAccounts, ethdevnode.DefaultOptions.NumAccounts, "The number of prefunded accounts")
+	cmd.Flags().Uint64(flagNodeBalance, 100_000, "The balance of each prefunded account, in ether")
+	cmd.Flags().String(flagNodeMnemonic, ethdevnode.DefaultMnemonic, "The mnemonic of the prefunded accounts")
+	cmd.Flags().StringP(flagNodeForkUrl, "f", "", "The RPC endpoint of the chain to fork from")
+	cmd.Flags().String(flagNodeForkBlock, "latest", "The block number to fork from")
+
+	return cmd
+}
+
+func (c *node) Run(cmd *cobra.Command, args []string) error {
+	fHost, err := cmd.Flags().GetString(flagNodeHost)
+	if err != nil {
+		return err
+	}
+	fPort, err := cmd.Flags().GetInt(flagNodePort)
+	if err != nil {
+		return err
+	}
+	fChainID, err := cmd.Flags().GetUint64(flagNodeChainID)
+	if err != nil {
+		return err
+	}
+	fAccounts, err := cmd.Flags().GetInt(flagNodeAccounts)
+	if err != nil {
+		return err
+	}
+	fBalance, err := cmd.Flags().GetUint64(flagNodeBalance)
+	if err != nil {
+		return err
+	}
+	fMnemonic, err := cmd.Flags().GetString(flagNodeMnemonic)
+	if err != nil {
+		return err
+	}
+	fForkUrl, err := cmd.Flags().GetString(flagNodeForkUrl)
+	if err != nil {
+		return err
+	}
+	fForkBlock, err := cmd.Flags().GetString(flagNodeForkBlock)
+	if err != nil {
+		return err
+	}
+
+	options := ethdevnode.DefaultOptions
+	options.NumAccounts = fAccounts
+	options.Mnemonic = fMnemonic
+	options.Balance = new(big.Int).Mul(new(big.Int).SetUint64(fBalance), big.NewInt(params.Ether))
+	if fChainID != 0 {
+		options.ChainID = new(big.Int).SetUint64(fChainID)
+	}
+
+	if fForkUrl != "" {
+		if _, err = url.ParseRequestURI(fForkUrl); err != nil {
+			return errors.New("error: please provide a valid fork rpc url (e.g. https://nodes.sequence.app/mainnet)")
+		}
+		options.ForkURL = fForkUrl
+		if fChainID == 0 {
+			options.ChainID = nil
+		}
+		if fForkBlock != "latest" {
+			block, err := strconv.ParseUint(fForkBlock, 10, 64)
+			if err != nil {
+				return errors.New("error: invalid fork block height")
+			}
+			options.ForkBlockNumber = new(big.Int).SetUint64(block)
+		}
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	devnode, err := ethdevnode.NewNode(ctx, options)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(fHost, strconv.Itoa(fPort)))
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintln(out, "Accounts")
+	fmt.Fprintln(out, "========")
+	for i, wallet := range devnode.Accounts() {
+		fmt.Fprintf(out, "(%d) %s (%d ether)\n    private key: %s\n", i, wallet.Address().Hex(), fBalance, wallet.PrivateKeyHex())
+	}
+	fmt.Fprintln(out)
+	if options.ForkURL != "" {
+		fmt.Fprintf(out, "Forked %s at block %d\n", options.ForkURL, devnode.BlockNumber())
+	}
+	fmt.Fprintf(out, "Chain id %s, listening on http://%s\n", devnode.ChainID(), listener.Addr())
+
+	server := &http.Server{Handler: devnode, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
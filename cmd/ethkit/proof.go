@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+const (
+	flagProofRpcUrl = "rpc-url"
+	flagProofBlock  = "block"
+	flagProofSlot   = "slot"
+	flagProofJson   = "json"
+	flagProofRaw    = "raw"
+)
+
+func init() {
+	rootCmd.AddCommand(NewProofCmd())
+}
+
+type proof struct {
+}
+
+// NewProofCmd returns a new command to fetch and verify the merkle proof of an
+// account and its storage slots.
+func NewProofCmd() *cobra.Command {
+	c := &proof{}
+	cmd := &cobra.Command{
+		Use:   "proof [account]",
+		Short: "Get the merkle proof of an account and its storage, verified against the block state root",
+		Args:  cobra.ExactArgs(1),
+		RunE:  c.Run,
+	}
+
+	cmd.Flags().StringP(flagProofRpcUrl, "r", "", "The RPC endpoint to the blockchain node to interact with")
+	cmd.Flags().StringP(flagProofBlock, "B", "finalized", "The block number, hash or tag (latest, finalized, safe, earliest) to prove at")
+	cmd.Flags().StringSliceP(flagProofSlot, "s", nil, "The storage slot(s) to prove, as a number or 32 bytes hex")
+	cmd.Flags().BoolP(flagProofJson, "j", false, "Print the verified values as JSON")
+	cmd.Flags().Bool(flagProofRaw, false, "Include the proof nodes in the output, ie. as inputs of on-chain verifiers")
+
+	return cmd
+}
+
+func (c *proof) Run(cmd *cobra.Command, args []string) error {
+	fAccount := cmd.Flags().Args()[0]
+	fRpc, err := cmd.Flags().GetString(flagProofRpcUrl)
+	if err != nil {
+		return err
+	}
+	fBlock, err := cmd.Flags().GetString(flagProofBlock)
+	if err != nil {
+		return err
+	}
+	fSlots, err := cmd.Flags().GetStringSlice(flagProofSlot)
+	if err != nil {
+		return err
+	}
+	fJson, err := cmd.Flags().GetBool(flagProofJson)
+	if err != nil {
+		return err
+	}
+	fRaw, err := cmd.Flags().GetBool(flagProofRaw)
+	if err != nil {
+		return err
+	}
+
+	if !common.IsHexAddress(fAccount) {
+		return errors.New("error: please provide a valid account address (e.g. 0x213a286A1AF3Ac010d4F2D66A52DeAf762dF7742)")
+	}
+
+	slots := make([]common.Hash, len(fSlots))
+	for i, s := range fSlots {
+		slot, err := parseStorageSlot(s)
+		if err != nil {
+			return err
+		}
+		slots[i] = slot
+	}
+
+	if _, err = url.ParseRequestURI(fRpc); err != nil {
+		return errors.New("error: please provide a valid rpc url (e.g. https://nodes.sequence.app/mainnet)")
+	}
+
+	provider, err := ethrpc.NewProvider(fRpc)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	block, err := fetchBlock(ctx, provider, fBlock)
+	if err != nil {
+		return err
+	}
+
+	accountProof, err := provider.GetProof(ctx, common.HexToAddress(fAccount), slots, block.Number())
+	if err != nil {
+		return err
+	}
+	if err := accountProof.Verify(block.Root()); err != nil {
+		return err
+	}
+
+	var obj any
+	obj = NewProof(accountProof, block.NumberU64(), block.Hash(), block.Root(), fRaw)
+
+	if fJson {
+		json, err := PrettyJSON(obj)
+		if err != nil {
+			return err
+		}
+		obj = *json
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), obj)
+
+	return nil
+}
+
+// parseStorageSlot parses a storage slot as a decimal number or hex value of at
+// most 32 bytes.
+func parseStorageSlot(s string) (common.Hash, error) {
+	if strings.HasPrefix(s, "0x") {
+		b := common.FromHex(s)
+		if len(b) > common.HashLength || len(s) > 2+2*common.HashLength {
+			return common.Hash{}, fmt.Errorf("error: invalid storage slot %q", s)
+		}
+		return common.BytesToHash(b), nil
+	}
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok || n.Sign() < 0 || n.BitLen() > 256 {
+		return common.Hash{}, fmt.Errorf("error: invalid storage slot %q", s)
+	}
+	return common.BigToHash(n), nil
+}
+
+// Proof is a customized account proof for cli, with the values verified
+// against the state root of the block.
+type Proof struct {
+	Address      common.Address `json:"address"`
+	BlockNumber  uint64         `json:"blockNumber"`
+	BlockHash    common.Hash    `json:"blockHash"`
+	StateRoot    common.Hash    `json:"stateRoot"`
+	Balance      *big.Int       `json:"balance"`
+	Nonce        uint64         `json:"nonce"`
+	CodeHash     common.Hash    `json:"codeHash"`
+	StorageHash  common.Hash    `json:"storageHash"`
+	Storage      []*ProofSlot   `json:"storage,omitempty"`
+	AccountProof []string       `json:"accountProof,omitempty"`
+	Verified     bool           `json:"verified"`
+}
+
+// ProofSlot is a verified storage slot of a Proof.
+type ProofSlot struct {
+	Slot  common.Hash `json:"slot"`
+	Value common.Hash `json:"value"`
+	Proof []string    `json:"proof,omitempty"`
+}
+
+// NewProof returns the custom-built Proof object of a verified account proof.
+func NewProof(p *ethrpc.AccountProof, blockNum uint64, blockHash, stateRoot common.Hash, raw bool) *Proof {
+	proof := &Proof{
+		Address:     p.Address,
+		BlockNumber: blockNum,
+		BlockHash:   blockHash,
+		StateRoot:   stateRoot,
+		Balance:     p.Balance,
+		Nonce:       p.Nonce,
+		CodeHash:    p.CodeHash,
+		StorageHash: p.StorageHash,
+		Verified:    true,
+	}
+	if raw {
+		proof.AccountProof = hexNodes(p.AccountProof)
+	}
+	for _, s := range p.StorageProof {
+		slot := &ProofSlot{Slot: s.Key}
+		if s.Value != nil {
+			slot.Value = common.BigToHash(s.Value)
+		}
+		if raw {
+			slot.Proof = hexNodes(s.Proof)
+		}
+		proof.Storage = append(proof.Storage, slot)
+	}
+	return proof
+}
+
+func hexNodes(nodes [][]byte) []string {
+	out := make([]string, len(nodes))
+	for i, node := range nodes {
+		out[i] = "0x" + common.Bytes2Hex(node)
+	}
+	return out
+}
+
+// String overrides the standard behavior for Proof "to-string".
+func (p *Proof) String() string {
+	var pr Printable
+	if err := pr.FromStruct(p); err != nil {
+		panic(err)
+	}
+	s := pr.Columnize(*NewPrintableFormat(20, 0, 0, byte(' ')))
+
+	return s
+}
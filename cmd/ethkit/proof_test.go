@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func execProofCmd(args string) (string, error) {
+	cmd := NewProofCmd()
+	actual := new(bytes.Buffer)
+	cmd.SetOut(actual)
+	cmd.SetErr(actual)
+	cmd.SetArgs(strings.Split(args, " "))
+	if err := cmd.Execute(); err != nil {
+		return "", err
+	}
+
+	return actual.String(), nil
+}
+
+func Test_ProofCmd_InvalidArgs(t *testing.T) {
+	_, err := execProofCmd("0x1234 --rpc-url https://nodes.sequence.app/mainnet")
+	assert.Contains(t, err.Error(), "please provide a valid account address")
+
+	_, err = execProofCmd("0x213a286A1AF3Ac010d4F2D66A52DeAf762dF7742 --slot abc --rpc-url https://nodes.sequence.app/mainnet")
+	assert.Contains(t, err.Error(), "invalid storage slot")
+
+	_, err = execProofCmd("0x213a286A1AF3Ac010d4F2D66A52DeAf762dF7742")
+	assert.Contains(t, err.Error(), "please provide a valid rpc url")
+}
+
+func Test_ParseStorageSlot(t *testing.T) {
+	slot, err := parseStorageSlot("3")
+	require.NoError(t, err)
+	assert.Equal(t, common.HexToHash("0x03"), slot)
+
+	slot, err = parseStorageSlot("0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc")
+	require.NoError(t, err)
+	assert.Equal(t, common.HexToHash("0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc"), slot)
+
+	_, err = parseStorageSlot("0x" + strings.Repeat("00", 33))
+	assert.Error(t, err)
+	_, err = parseStorageSlot("-1")
+	assert.Error(t, err)
+}
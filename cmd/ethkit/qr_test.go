@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_QRReedSolomon(t *testing.T) {
+	// "HELLO WORLD" of version 1-M
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	ecc := qrReedSolomonRemainder(data, qrReedSolomonDivisor(10))
+	assert.Equal(t, []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}, ecc)
+}
+
+func Test_QRTables(t *testing.T) {
+	assert.Equal(t, 0x77C4, qrFormatBits(0))
+	assert.Equal(t, 0x662F, qrFormatBits(4))
+
+	assert.Nil(t, qrAlignmentPositions(1))
+	assert.Equal(t, []int{6, 18}, qrAlignmentPositions(2))
+	assert.Equal(t, []int{6, 22, 38}, qrAlignmentPositions(7))
+	assert.Equal(t, []int{6, 34, 60, 86, 112, 138}, qrAlignmentPositions(32))
+	assert.Equal(t, []int{6, 30, 58, 86, 114, 142, 170}, qrAlignmentPositions(40))
+
+	assert.Equal(t, 19, qrNumDataCodewords(1))
+	assert.Equal(t, 2956, qrNumDataCodewords(40))
+}
+
+func Test_QRCode(t *testing.T) {
+	q, err := newQRCode([]byte("0x02f86c8205398084773594008504a817c80082520894cdf87ca00696a756fd1e35dd2dbaa1f30a80c0a8880de0b6b3a764000080c0"))
+	require.NoError(t, err)
+	assert.Equal(t, 6, q.version)
+	assert.Equal(t, 41, q.size)
+
+	// the finder patterns, and the dark module
+	for _, c := range [][2]int{{0, 0}, {q.size - 7, 0}, {0, q.size - 7}} {
+		assert.True(t, q.modules[c[1]][c[0]])
+		assert.True(t, q.modules[c[1]+3][c[0]+3])
+		assert.False(t, q.modules[c[1]+1][c[0]+1])
+	}
+	assert.True(t, q.modules[q.size-8][8])
+
+	lines := strings.Split(strings.TrimSuffix(q.String(), "\n"), "\n")
+	assert.Len(t, lines, (q.size+4+1)/2)
+	assert.Equal(t, q.size+4, len([]rune(lines[0])))
+
+	_, err = newQRCode(make([]byte, 2954))
+	assert.Error(t, err)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"math/big"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+// Receipt is a customized transaction receipt for cli, with decoded logs.
+type Receipt struct {
+	TxHash            common.Hash     `json:"transactionHash"`
+	TxIndex           uint            `json:"transactionIndex"`
+	Status            uint64          `json:"status"`
+	Type              uint8           `json:"type"`
+	GasUsed           uint64          `json:"gasUsed"`
+	CumulativeGasUsed uint64          `json:"cumulativeGasUsed"`
+	EffectiveGasPrice *big.Int        `json:"effectiveGasPrice"`
+	ContractAddress   *common.Address `json:"contractAddress,omitempty"`
+	Logs              []*DecodedLog   `json:"logs"`
+}
+
+// NewReceipt returns the custom-built Receipt object.
+func NewReceipt(r *types.Receipt, decoder *Decoder) *Receipt {
+	receipt := &Receipt{
+		TxHash:            r.TxHash,
+		TxIndex:           r.TransactionIndex,
+		Status:            r.Status,
+		Type:              r.Type,
+		GasUsed:           r.GasUsed,
+		CumulativeGasUsed: r.CumulativeGasUsed,
+		EffectiveGasPrice: r.EffectiveGasPrice,
+		Logs:              make([]*DecodedLog, len(r.Logs)),
+	}
+	if r.ContractAddress != (common.Address{}) {
+		receipt.ContractAddress = &r.ContractAddress
+	}
+	for i, log := range r.Logs {
+		receipt.Logs[i] = decoder.DecodeLog(log)
+	}
+	return receipt
+}
+
+// NewReceipts returns the custom-built Receipt objects.
+func NewReceipts(receipts []*types.Receipt, decoder *Decoder) []*Receipt {
+	r := make([]*Receipt, len(receipts))
+	for i, receipt := range receipts {
+		r[i] = NewReceipt(receipt, decoder)
+	}
+	return r
+}
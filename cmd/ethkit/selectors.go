@@ -0,0 +1,416 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/sha3"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+const (
+	flagSelectorsArgs      = "args"
+	flagSelectorsPrefix    = "prefix"
+	flagSelectorsCharset   = "charset"
+	flagSelectorsMaxLength = "max-length"
+	flagSelectorsCount     = "count"
+	flagSelectorsThreads   = "threads"
+	flagSelectorsTimeout   = "timeout"
+	flagSelectorsSource    = "source"
+	flagSelectorsURL       = "url"
+)
+
+func init() {
+	rootCmd.AddCommand(NewSelectorsCmd())
+}
+
+type selectors struct {
+}
+
+// NewSelectorsCmd returns a new command to compute function selectors and to
+// find selector collisions.
+func NewSelectorsCmd() *cobra.Command {
+	c := &selectors{}
+	cmd := &cobra.Command{
+		Use:   "selectors",
+		Short: "Compute function selectors and find selector collisions",
+	}
+
+	computeCmd := &cobra.Command{
+		Use:   "compute [signature...]",
+		Short: "Compute the 4-byte selectors of function signatures",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  c.Compute,
+	}
+
+	collideCmd := &cobra.Command{
+		Use:   "collide [signature or selector]",
+		Short: "Grind function names with the given arguments producing a target selector",
+		Long: "Grind function names with the given arguments producing the selector of a target signature,\n" +
+			"ie. \"transfer(address,uint256)\", or a target selector, ie. 0xa9059cbb. The names are the prefix\n" +
+			"followed by all the combinations of the charset up to max-length characters.",
+		Args: cobra.ExactArgs(1),
+		RunE: c.Collide,
+	}
+	collideCmd.Flags().String(flagSelectorsArgs, "", "The arguments of the candidates, ie. \"(address,uint256)\", default the arguments of the target signature")
+	collideCmd.Flags().String(flagSelectorsPrefix, "", "The prefix of the candidate names")
+	collideCmd.Flags().String(flagSelectorsCharset, "abcdefghijklmnopqrstuvwxyz0123456789_", "The characters of the candidate names after the prefix")
+	collideCmd.Flags().Int(flagSelectorsMaxLength, 8, "The max number of characters after the prefix")
+	collideCmd.Flags().IntP(flagSelectorsCount, "n", 1, "The number of collisions to find")
+	collideCmd.Flags().Int(flagSelectorsThreads, runtime.NumCPU(), "The number of threads")
+	collideCmd.Flags().Duration(flagSelectorsTimeout, 0, "Max time to search, 0 for no limit")
+
+	clashCmd := &cobra.Command{
+		Use:   "clash [abi file...]",
+		Short: "Report the functions of abis sharing a selector, ie. of a proxy and its implementation",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  c.Clash,
+	}
+
+	lookupCmd := &cobra.Command{
+		Use:   "lookup [selector or topic...]",
+		Short: "Lookup the candidate signatures of 4-byte selectors or event topics in a signature directory",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  c.Lookup,
+	}
+	lookupCmd.Flags().String(flagSelectorsSource, ethcoder.SignatureDirectoryOpenchain, "The signature directory, openchain or 4byte")
+	lookupCmd.Flags().String(flagSelectorsURL, "", "The api url of the signature directory, default its public api")
+
+	cmd.AddCommand(computeCmd, collideCmd, clashCmd, lookupCmd)
+	return cmd
+}
+
+func (c *selectors) Compute(cmd *cobra.Command, args []string) error {
+	for _, sig := range args {
+		name, argTypes, err := splitFunctionSignature(sig)
+		if err != nil {
+			return err
+		}
+		sig = name + argTypes
+		fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", ethcoder.FunctionSignature(sig), sig)
+	}
+	return nil
+}
+
+func (c *selectors) Collide(cmd *cobra.Command, args []string) error {
+	fArgs, err := cmd.Flags().GetString(flagSelectorsArgs)
+	if err != nil {
+		return err
+	}
+	fPrefix, err := cmd.Flags().GetString(flagSelectorsPrefix)
+	if err != nil {
+		return err
+	}
+	fCharset, err := cmd.Flags().GetString(flagSelectorsCharset)
+	if err != nil {
+		return err
+	}
+	fMaxLength, err := cmd.Flags().GetInt(flagSelectorsMaxLength)
+	if err != nil {
+		return err
+	}
+	fCount, err := cmd.Flags().GetInt(flagSelectorsCount)
+	if err != nil {
+		return err
+	}
+	fThreads, err := cmd.Flags().GetInt(flagSelectorsThreads)
+	if err != nil {
+		return err
+	}
+	fTimeout, err := cmd.Flags().GetDuration(flagSelectorsTimeout)
+	if err != nil {
+		return err
+	}
+
+	g := &selectorGrinder{
+		prefix:    fPrefix,
+		charset:   fCharset,
+		maxLength: fMaxLength,
+	}
+	if strings.HasPrefix(args[0], "0x") {
+		selector := common.FromHex(args[0])
+		if len(selector) != 4 {
+			return errors.New("error: please provide a valid 4-byte selector")
+		}
+		copy(g.target[:], selector)
+		g.args = "()"
+	} else {
+		name, argTypes, err := splitFunctionSignature(args[0])
+		if err != nil {
+			return err
+		}
+		copy(g.target[:], ethcoder.Keccak256([]byte(name+argTypes)))
+		g.args = argTypes
+		g.exclude = name + argTypes
+	}
+	if fArgs != "" {
+		_, argTypes, err := splitFunctionSignature("f" + fArgs)
+		if err != nil {
+			return err
+		}
+		g.args = argTypes
+	}
+
+	if len(g.charset) == 0 || !isIdentifier(g.charset) {
+		return errors.New("error: the charset must be made of letters, digits, _ or $")
+	}
+	if g.prefix != "" && (!isIdentifier(g.prefix) || (g.prefix[0] >= '0' && g.prefix[0] <= '9')) {
+		return errors.New("error: the prefix must be a valid identifier")
+	}
+	if fMaxLength < 1 || fCount < 1 || fThreads < 1 {
+		return errors.New("error: max-length, count and threads must be positive")
+	}
+
+	ctx := context.Background()
+	if fTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, fTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	found, tried := g.grind(ctx, fThreads, fCount)
+	elapsed := time.Since(start)
+
+	out := cmd.OutOrStdout()
+	for _, sig := range found {
+		fmt.Fprintf(out, "%s %s\n", ethcoder.HexEncode(g.target[:]), sig)
+	}
+	fmt.Fprintf(out, "searched %d candidates in %s (%.0f/s)\n", tried, elapsed.Round(time.Millisecond), float64(tried)/elapsed.Seconds())
+	if len(found) == 0 {
+		return errors.New("error: no collision found, try a larger max-length or charset")
+	}
+	return nil
+}
+
+func (c *selectors) Clash(cmd *cobra.Command, args []string) error {
+	abis, err := readABIFiles(args)
+	if err != nil {
+		return err
+	}
+
+	type function struct {
+		file string
+		sig  string
+	}
+	bySelector := map[string][]function{}
+	for i, contractABI := range abis {
+		names := make([]string, 0, len(contractABI.Methods))
+		for name := range contractABI.Methods {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			method := contractABI.Methods[name]
+			selector := ethcoder.HexEncode(method.ID)
+			bySelector[selector] = append(bySelector[selector], function{filepath.Base(args[i]), method.Sig})
+		}
+	}
+
+	var clashes []string
+	for selector, functions := range bySelector {
+		for _, f := range functions[1:] {
+			if f.sig != functions[0].sig {
+				clashes = append(clashes, selector)
+				break
+			}
+		}
+	}
+	sort.Strings(clashes)
+
+	out := cmd.OutOrStdout()
+	for _, selector := range clashes {
+		fmt.Fprintln(out, selector)
+		for _, f := range bySelector[selector] {
+			fmt.Fprintf(out, "  %-24s %s\n", f.file, f.sig)
+		}
+	}
+	if len(clashes) == 0 {
+		fmt.Fprintln(out, "no selector clashes")
+	}
+	return nil
+}
+
+func (c *selectors) Lookup(cmd *cobra.Command, args []string) error {
+	fSource, err := cmd.Flags().GetString(flagSelectorsSource)
+	if err != nil {
+		return err
+	}
+	fURL, err := cmd.Flags().GetString(flagSelectorsURL)
+	if err != nil {
+		return err
+	}
+	directory, err := ethcoder.NewSignatureDirectory(ethcoder.SignatureDirectoryOptions{Source: fSource, URL: fURL})
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	out := cmd.OutOrStdout()
+	for _, arg := range args {
+		var sigs []string
+		switch hash := common.FromHex(arg); {
+		case !strings.HasPrefix(arg, "0x"):
+			return fmt.Errorf("error: %q is not a hex selector or topic", arg)
+		case len(hash) == 4:
+			sigs, err = directory.LookupFunction(ctx, [4]byte(hash))
+		case len(hash) == 32:
+			sigs, err = directory.LookupEvent(ctx, common.BytesToHash(hash))
+		default:
+			return fmt.Errorf("error: %q is not a 4-byte selector nor a 32-byte topic", arg)
+		}
+		if err != nil {
+			return err
+		}
+		if len(sigs) == 0 {
+			fmt.Fprintf(out, "%s unknown\n", arg)
+		}
+		for _, sig := range sigs {
+			fmt.Fprintf(out, "%s %s\n", arg, sig)
+		}
+	}
+	return nil
+}
+
+// splitFunctionSignature splits a function signature into its name and its
+// arguments, without spaces.
+func splitFunctionSignature(sig string) (string, string, error) {
+	sig = strings.ReplaceAll(sig, " ", "")
+	i := strings.Index(sig, "(")
+	if i < 1 || !strings.HasSuffix(sig, ")") || !isIdentifier(sig[:i]) {
+		return "", "", fmt.Errorf("error: invalid function signature %q", sig)
+	}
+	return sig[:i], sig[i:], nil
+}
+
+func isIdentifier(s string) bool {
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '$') {
+			return false
+		}
+	}
+	return true
+}
+
+// selectorGrinder searches the function names producing a target selector.
+type selectorGrinder struct {
+	target    [4]byte
+	prefix    string
+	args      string
+	charset   string
+	maxLength int
+
+	// exclude is the target signature itself
+	exclude string
+}
+
+// grindBatchSize is the number of candidates claimed at once by a worker.
+const grindBatchSize = 1 << 14
+
+// grind searches the candidates with threads workers until count collisions
+// are found, the candidates are exhausted or ctx is done. It returns the
+// colliding signatures and the number of candidates tried.
+func (g *selectorGrinder) grind(ctx context.Context, threads, count int) ([]string, uint64) {
+	// total is the number of candidates, the suffixes of 0 to maxLength
+	// characters, saturated at the max uint64
+	total, pow := uint64(1), uint64(1)
+	n := uint64(len(g.charset))
+	for i := 0; i < g.maxLength && total != ^uint64(0); i++ {
+		if pow > ^uint64(0)/n {
+			total = ^uint64(0)
+			break
+		}
+		pow *= n
+		if total+pow < total {
+			total = ^uint64(0)
+			break
+		}
+		total += pow
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		next, tried uint64
+		mu          sync.Mutex
+		found       []string
+		wg          sync.WaitGroup
+	)
+	for t := 0; t < threads; t++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hasher := sha3.NewLegacyKeccak256()
+			candidate := make([]byte, 0, len(g.prefix)+g.maxLength+len(g.args))
+			var sum []byte
+
+			for ctx.Err() == nil {
+				from := atomic.AddUint64(&next, grindBatchSize) - grindBatchSize
+				if from >= total {
+					return
+				}
+				to := from + grindBatchSize
+				if to > total || to < from {
+					to = total
+				}
+
+				for i := from; i < to; i++ {
+					candidate = g.candidate(candidate[:0], i)
+					if candidate == nil {
+						continue
+					}
+					hasher.Reset()
+					hasher.Write(candidate)
+					sum = hasher.Sum(sum[:0])
+					if !bytes.Equal(sum[:4], g.target[:]) || string(candidate) == g.exclude {
+						continue
+					}
+
+					mu.Lock()
+					if len(found) < count {
+						found = append(found, string(candidate))
+					}
+					if len(found) >= count {
+						cancel()
+					}
+					mu.Unlock()
+				}
+				atomic.AddUint64(&tried, to-from)
+			}
+		}()
+	}
+	wg.Wait()
+
+	sort.Strings(found)
+	return found, tried
+}
+
+// candidate appends the i-th candidate signature to buf, the prefix followed
+// by the suffix of the bijective base-n numeral of i, and the arguments. It
+// returns nil when the name is not a valid identifier.
+func (g *selectorGrinder) candidate(buf []byte, i uint64) []byte {
+	buf = append(buf, g.prefix...)
+	n := uint64(len(g.charset))
+	for i > 0 {
+		i--
+		buf = append(buf, g.charset[i%n])
+		i /= n
+	}
+	if len(buf) == 0 || (buf[0] >= '0' && buf[0] <= '9') {
+		return nil
+	}
+	return append(buf, g.args...)
+}
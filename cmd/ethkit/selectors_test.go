@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func execSelectorsCmd(args string) (string, error) {
+	cmd := NewSelectorsCmd()
+	actual := new(bytes.Buffer)
+	cmd.SetOut(actual)
+	cmd.SetErr(actual)
+	cmd.SetArgs(strings.Split(args, " "))
+	if err := cmd.Execute(); err != nil {
+		return "", err
+	}
+
+	return actual.String(), nil
+}
+
+func Test_SelectorsCmd_Compute(t *testing.T) {
+	res, err := execSelectorsCmd("compute transfer(address,uint256) balanceOf(address)")
+	require.NoError(t, err)
+	assert.Equal(t, "0xa9059cbb transfer(address,uint256)\n0x70a08231 balanceOf(address)\n", res)
+
+	_, err = execSelectorsCmd("compute transfer")
+	assert.Contains(t, err.Error(), "invalid function signature")
+}
+
+func Test_SelectorsCmd_Collide(t *testing.T) {
+	target := ethcoder.FunctionSignature("xab_ba(uint256)")
+
+	res, err := execSelectorsCmd("collide " + target + " --args (uint256) --prefix x --charset ab_ --max-length 5 --threads 3")
+	require.NoError(t, err)
+	assert.Contains(t, res, target+" xab_ba(uint256)\n")
+
+	// the target signature itself is not a collision
+	_, err = execSelectorsCmd("collide xab_ba(uint256) --prefix x --charset ab_ --max-length 5")
+	assert.Contains(t, err.Error(), "no collision found")
+
+	_, err = execSelectorsCmd("collide 0x1234")
+	assert.Contains(t, err.Error(), "valid 4-byte selector")
+	_, err = execSelectorsCmd("collide 0x12345678 --charset a-z")
+	assert.Contains(t, err.Error(), "charset")
+}
+
+func Test_SelectorGrinder(t *testing.T) {
+	g := &selectorGrinder{charset: "ab", maxLength: 2, args: "()"}
+	var candidates []string
+	for i := uint64(0); i < 7; i++ {
+		if c := g.candidate(nil, i); c != nil {
+			candidates = append(candidates, string(c))
+		}
+	}
+	assert.Equal(t, []string{"a()", "b()", "aa()", "ba()", "ab()", "bb()"}, candidates)
+
+	copy(g.target[:], ethcoder.Keccak256([]byte("bb()")))
+	found, tried := g.grind(context.Background(), 2, 5)
+	assert.Equal(t, []string{"bb()"}, found)
+	assert.Equal(t, uint64(7), tried)
+}
+
+func Test_SelectorsCmd_Clash(t *testing.T) {
+	dir := t.TempDir()
+	proxy := filepath.Join(dir, "proxy.json")
+	impl := filepath.Join(dir, "impl.json")
+	// burn(uint256) and collate_propagate_storage(bytes16) share the selector 0x42966c68
+	require.NoError(t, os.WriteFile(proxy, []byte(`[{"type":"function","name":"collate_propagate_storage","inputs":[{"name":"","type":"bytes16"}],"outputs":[]}]`), 0644))
+	require.NoError(t, os.WriteFile(impl, []byte(`[{"type":"function","name":"collate_propagate_storage","inputs":[{"name":"","type":"bytes16"}],"outputs":[]},{"type":"function","name":"burn","inputs":[{"name":"amount","type":"uint256"}],"outputs":[]}]`), 0644))
+
+	res, err := execSelectorsCmd("clash " + proxy + " " + impl)
+	require.NoError(t, err)
+	assert.Equal(t, "0x42966c68\n  proxy.json               collate_propagate_storage(bytes16)\n  impl.json                burn(uint256)\n  impl.json                collate_propagate_storage(bytes16)\n", res)
+
+	res, err = execSelectorsCmd("clash " + proxy)
+	require.NoError(t, err)
+	assert.Equal(t, "no selector clashes\n", res)
+}
+
+func Test_SelectorsCmd_Lookup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"count": 1, "results": [{"id": 1, "text_signature": "transfer(address,uint256)"}]}`))
+	}))
+	defer server.Close()
+
+	res, err := execSelectorsCmd("lookup 0xa9059cbb --source 4byte --url " + server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "0xa9059cbb transfer(address,uint256)\n", res)
+
+	_, err = execSelectorsCmd("lookup 0xa905 --source 4byte --url " + server.URL)
+	assert.Error(t, err)
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+const (
+	flagTxRpcUrl  = "rpc-url"
+	flagTxAbi     = "abi"
+	flagTxJson    = "json"
+	flagTxWait    = "wait"
+	flagTxTimeout = "timeout"
+	flagTxField   = "field"
+)
+
+func init() {
+	rootCmd.AddCommand(NewTxCmd())
+}
+
+type tx struct {
+}
+
+// NewTxCmd returns a new command to retrieve a transaction and its receipt.
+func NewTxCmd() *cobra.Command {
+	c := &tx{}
+	cmd := &cobra.Command{
+		Use:     "tx [txhash]",
+		Short:   "Get the transaction and its receipt, with decoded calldata and logs",
+		Aliases: []string{"transaction"},
+		Args:    cobra.ExactArgs(1),
+		RunE:    c.Run,
+	}
+
+	cmd.Flags().StringP(flagTxRpcUrl, "r", "", "The RPC endpoint to the blockchain node to interact with")
+	cmd.Flags().StringSlice(flagTxAbi, nil, "Path to abi json file(s) used to decode the calldata and logs")
+	cmd.Flags().BoolP(flagTxJson, "j", false, "Print the transaction as JSON")
+	cmd.Flags().BoolP(flagTxWait, "w", false, "Wait for the receipt of a pending transaction")
+	cmd.Flags().Duration(flagTxTimeout, 2*time.Minute, "Max time to wait for the receipt")
+	cmd.Flags().StringP(flagTxField, "f", "", "Get the specific field of the transaction")
+
+	cmd.AddCommand(newTxBuildCmd(), newTxCombineCmd())
+
+	return cmd
+}
+
+func (c *tx) Run(cmd *cobra.Command, args []string) error {
+	fTxHash := cmd.Flags().Args()[0]
+	fRpc, err := cmd.Flags().GetString(flagTxRpcUrl)
+	if err != nil {
+		return err
+	}
+	fAbi, err := cmd.Flags().GetStringSlice(flagTxAbi)
+	if err != nil {
+		return err
+	}
+	fJson, err := cmd.Flags().GetBool(flagTxJson)
+	if err != nil {
+		return err
+	}
+	fWait, err := cmd.Flags().GetBool(flagTxWait)
+	if err != nil {
+		return err
+	}
+	fTimeout, err := cmd.Flags().GetDuration(flagTxTimeout)
+	if err != nil {
+		return err
+	}
+	fField, err := cmd.Flags().GetString(flagTxField)
+	if err != nil {
+		return err
+	}
+
+	if len(common.FromHex(fTxHash)) != common.HashLength {
+		return errors.New("error: please provide a valid transaction hash")
+	}
+
+	if _, err = url.ParseRequestURI(fRpc); err != nil {
+		return errors.New("error: please provide a valid rpc url (e.g. https://nodes.sequence.app/mainnet)")
+	}
+
+	decoder, err := NewDecoder(fAbi...)
+	if err != nil {
+		return err
+	}
+
+	provider, err := ethrpc.NewProvider(fRpc)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	txHash := common.HexToHash(fTxHash)
+
+	txn, pending, err := provider.TransactionByHash(ctx, txHash)
+	if err != nil {
+		return err
+	}
+
+	var receipt *types.Receipt
+	if !pending {
+		receipt, err = provider.TransactionReceipt(ctx, txHash)
+		if err != nil {
+			return err
+		}
+	} else if fWait {
+		wctx, cancel := context.WithTimeout(ctx, fTimeout)
+		defer cancel()
+		receipt, err = ethrpc.WaitForTxnReceipt(wctx, provider, txHash)
+		if err != nil {
+			return err
+		}
+	}
+
+	var obj any
+	obj = NewTransaction(txn, receipt, decoder)
+
+	if fField != "" {
+		obj = GetValueByJSONTag(obj, fField)
+	}
+
+	if fJson {
+		json, err := PrettyJSON(obj)
+		if err != nil {
+			return err
+		}
+		obj = *json
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), obj)
+
+	return nil
+}
+
+// Transaction is a customized transaction for cli, with its decoded calldata
+// and receipt.
+type Transaction struct {
+	Hash        common.Hash     `json:"hash"`
+	Type        uint8           `json:"type"`
+	ChainID     *big.Int        `json:"chainId"`
+	From        common.Address  `json:"from"`
+	To          *common.Address `json:"to"`
+	Nonce       uint64          `json:"nonce"`
+	Value       *big.Int        `json:"value"`
+	Gas         uint64          `json:"gas"`
+	GasPrice    *big.Int        `json:"gasPrice"`
+	GasFeeCap   *big.Int        `json:"maxFeePerGas,omitempty"`
+	GasTipCap   *big.Int        `json:"maxPriorityFeePerGas,omitempty"`
+	Input       string          `json:"input"`
+	Call        *DecodedCall    `json:"call,omitempty"`
+	Pending     bool            `json:"pending"`
+	BlockNumber *big.Int        `json:"blockNumber,omitempty"`
+	BlockHash   *common.Hash    `json:"blockHash,omitempty"`
+	Fee         *big.Int        `json:"fee,omitempty"`
+	Receipt     *Receipt        `json:"receipt,omitempty"`
+}
+
+// NewTransaction returns the custom-built Transaction object. The receipt is
+// nil for pending transactions.
+func NewTransaction(txn *types.Transaction, receipt *types.Receipt, decoder *Decoder) *Transaction {
+	t := &Transaction{
+		Hash:     txn.Hash(),
+		Type:     txn.Type(),
+		ChainID:  txn.ChainId(),
+		To:       txn.To(),
+		Nonce:    txn.Nonce(),
+		Value:    txn.Value(),
+		Gas:      txn.Gas(),
+		GasPrice: txn.GasPrice(),
+		Input:    ethcoder.HexEncode(txn.Data()),
+		Call:     decoder.DecodeCalldata(txn.Data()),
+		Pending:  receipt == nil,
+	}
+	if txn.Type() >= types.DynamicFeeTxType {
+		t.GasFeeCap = txn.GasFeeCap()
+		t.GasTipCap = txn.GasTipCap()
+	}
+
+	signer := types.LatestSignerForChainID(txn.ChainId())
+	if !txn.Protected() {
+		signer = types.HomesteadSigner{}
+	}
+	if from, err := types.Sender(signer, txn); err == nil {
+		t.From = from
+	}
+
+	if receipt != nil {
+		t.BlockNumber = receipt.BlockNumber
+		t.BlockHash = &receipt.BlockHash
+		t.Receipt = NewReceipt(receipt, decoder)
+		if receipt.EffectiveGasPrice != nil {
+			t.Fee = new(big.Int).Mul(receipt.EffectiveGasPrice, new(big.Int).SetUint64(receipt.GasUsed))
+		}
+	}
+	return t
+}
+
+// String overrides the standard behavior for Transaction "to-string".
+func (t *Transaction) String() string {
+	var p Printable
+	if err := p.FromStruct(t); err != nil {
+		panic(err)
+	}
+	s := p.Columnize(*NewPrintableFormat(20, 0, 0, byte(' ')))
+
+	return s
+}
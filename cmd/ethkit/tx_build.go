@@ -0,0 +1,637 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+const (
+	flagTxBuildFrom           = "from"
+	flagTxBuildTo             = "to"
+	flagTxBuildValue          = "value"
+	flagTxBuildData           = "data"
+	flagTxBuildMethod         = "method"
+	flagTxBuildNonce          = "nonce"
+	flagTxBuildGasLimit       = "gas-limit"
+	flagTxBuildGasPrice       = "gas-price"
+	flagTxBuildMaxFee         = "max-fee"
+	flagTxBuildMaxPriorityFee = "max-priority-fee"
+	flagTxBuildChainID        = "chain-id"
+	flagTxBuildInteractive    = "interactive"
+	flagTxBuildQR             = "qr"
+)
+
+// newTxBuildCmd returns a new command to build an unsigned transaction for an
+// air-gapped signer.
+func newTxBuildCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "build [args of the method]",
+		Short: "Build an unsigned transaction, and print its payload to sign on an air-gapped device",
+		Long: "Build a fully-populated unsigned transaction, and print its summary with its canonical unsigned payload " +
+			"and signing hash. The chain id, nonce, fees and gas limit are fetched from the rpc endpoint when they are " +
+			"not set. The signature of the signing hash is attached with 'ethkit tx combine'.",
+		RunE: runTxBuild,
+	}
+
+	cmd.Flags().StringP(flagTxRpcUrl, "r", "", "The RPC endpoint to fetch the missing fields of the transaction")
+	cmd.Flags().String(flagTxBuildFrom, "", "The sender of the transaction, to fetch its nonce and estimate its gas")
+	cmd.Flags().String(flagTxBuildTo, "", "The recipient of the transaction")
+	cmd.Flags().String(flagTxBuildValue, "", "The value of the transaction, in wei or with a unit (e.g. 1.5ether, 20gwei)")
+	cmd.Flags().String(flagTxBuildData, "", "The calldata of the transaction, in hex")
+	cmd.Flags().String(flagTxBuildMethod, "", "The method of the calldata (e.g. 'transfer(address,uint256)'), with its values as args")
+	cmd.Flags().String(flagTxBuildNonce, "", "The nonce of the transaction")
+	cmd.Flags().String(flagTxBuildGasLimit, "", "The gas limit of the transaction")
+	cmd.Flags().String(flagTxBuildGasPrice, "", "The gas price of a legacy transaction")
+	cmd.Flags().String(flagTxBuildMaxFee, "", "The max fee per gas of an EIP-1559 transaction")
+	cmd.Flags().String(flagTxBuildMaxPriorityFee, "", "The max priority fee per gas of an EIP-1559 transaction")
+	cmd.Flags().String(flagTxBuildChainID, "", "The chain id of the transaction")
+	cmd.Flags().BoolP(flagTxBuildInteractive, "i", false, "Prompt for the fields which are not set")
+	cmd.Flags().Bool(flagTxBuildQR, false, "Print the unsigned payload as a QR code")
+	cmd.Flags().BoolP(flagTxJson, "j", false, "Print the unsigned transaction as JSON")
+
+	return cmd
+}
+
+// newTxCombineCmd returns a new command to attach the signature of an air-gapped
+// signer to an unsigned transaction, and broadcast it.
+func newTxCombineCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "combine [unsigned payload] [signature]",
+		Short: "Attach a signature to an unsigned transaction payload, and broadcast it",
+		Args:  cobra.ExactArgs(2),
+		RunE:  runTxCombine,
+	}
+
+	cmd.Flags().StringP(flagTxRpcUrl, "r", "", "The RPC endpoint to broadcast the signed transaction to")
+	cmd.Flags().BoolP(flagTxWait, "w", false, "Wait for the receipt of the broadcasted transaction")
+	cmd.Flags().Duration(flagTxTimeout, 2*time.Minute, "Max time to wait for the receipt")
+	cmd.Flags().BoolP(flagTxJson, "j", false, "Print the signed transaction as JSON")
+
+	return cmd
+}
+
+// UnsignedTransaction is the summary of an unsigned transaction, with its payload
+// and hash to sign. The amounts are in wei.
+type UnsignedTransaction struct {
+	Type        uint8           `json:"type"`
+	ChainID     string          `json:"chainId"`
+	From        *common.Address `json:"from,omitempty"`
+	To          *common.Address `json:"to"`
+	Nonce       uint64          `json:"nonce"`
+	Value       string          `json:"value"`
+	Gas         uint64          `json:"gas"`
+	GasPrice    string          `json:"gasPrice,omitempty"`
+	GasFeeCap   string          `json:"maxFeePerGas,omitempty"`
+	GasTipCap   string          `json:"maxPriorityFeePerGas,omitempty"`
+	MaxFee      string          `json:"maxFee"`
+	Input       string          `json:"input"`
+	Call        *DecodedCall    `json:"call,omitempty"`
+	Payload     string          `json:"payload"`
+	SigningHash common.Hash     `json:"signingHash"`
+}
+
+// NewUnsignedTransaction returns the summary of the unsigned transaction of the
+// chain.
+func NewUnsignedTransaction(txn *types.Transaction, chainID *big.Int, from *common.Address, decoder *Decoder) (*UnsignedTransaction, error) {
+	payload, err := ethtxn.EncodeUnsignedPayload(txn, chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &UnsignedTransaction{
+		Type:        txn.Type(),
+		ChainID:     chainID.String(),
+		From:        from,
+		To:          txn.To(),
+		Nonce:       txn.Nonce(),
+		Value:       txn.Value().String(),
+		Gas:         txn.Gas(),
+		MaxFee:      new(big.Int).Mul(txn.GasFeeCap(), new(big.Int).SetUint64(txn.Gas())).String(),
+		Input:       ethcoder.HexEncode(txn.Data()),
+		Call:        decoder.DecodeCalldata(txn.Data()),
+		Payload:     ethcoder.HexEncode(payload),
+		SigningHash: ethtxn.SigningHash(payload),
+	}
+	if txn.Type() == types.DynamicFeeTxType {
+		u.GasFeeCap = txn.GasFeeCap().String()
+		u.GasTipCap = txn.GasTipCap().String()
+	} else {
+		u.GasPrice = txn.GasPrice().String()
+	}
+	return u, nil
+}
+
+// String prints the summary in a stable order, to review before signing.
+func (u *UnsignedTransaction) String() string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 20, 0, 0, ' ', tabwriter.Debug)
+	to := "contract creation"
+	if u.To != nil {
+		to = u.To.String()
+	}
+	fmt.Fprintf(w, "type\t %d\n", u.Type)
+	fmt.Fprintf(w, "chainId\t %s\n", u.ChainID)
+	if u.From != nil {
+		fmt.Fprintf(w, "from\t %s\n", u.From)
+	}
+	fmt.Fprintf(w, "to\t %s\n", to)
+	fmt.Fprintf(w, "nonce\t %d\n", u.Nonce)
+	fmt.Fprintf(w, "value\t %s wei\n", u.Value)
+	fmt.Fprintf(w, "gas\t %d\n", u.Gas)
+	if u.GasPrice != "" {
+		fmt.Fprintf(w, "gasPrice\t %s wei\n", u.GasPrice)
+	} else {
+		fmt.Fprintf(w, "maxFeePerGas\t %s wei\n", u.GasFeeCap)
+		fmt.Fprintf(w, "maxPriorityFeePerGas\t %s wei\n", u.GasTipCap)
+	}
+	fmt.Fprintf(w, "maxFee\t %s wei\n", u.MaxFee)
+	if u.Call != nil && u.Call.Function != "" {
+		fmt.Fprintf(w, "call\t %s\n", u.Call.Function)
+	}
+	fmt.Fprintf(w, "input\t %s\n", u.Input)
+	fmt.Fprintf(w, "payload\t %s\n", u.Payload)
+	fmt.Fprintf(w, "signingHash\t %s\n", u.SigningHash)
+	w.Flush()
+	return sb.String()
+}
+
+// txBuildFields are the fields of the transaction to build, as strings of the
+// flags or of the prompts.
+type txBuildFields struct {
+	from, to, value, data, method    string
+	nonce, gasLimit, chainID         string
+	gasPrice, maxFee, maxPriorityFee string
+}
+
+func runTxBuild(cmd *cobra.Command, args []string) error {
+	flags := cmd.Flags()
+	var f txBuildFields
+	for name, v := range map[string]*string{
+		flagTxBuildFrom: &f.from, flagTxBuildTo: &f.to, flagTxBuildValue: &f.value,
+		flagTxBuildData: &f.data, flagTxBuildMethod: &f.method, flagTxBuildNonce: &f.nonce,
+		flagTxBuildGasLimit: &f.gasLimit, flagTxBuildGasPrice: &f.gasPrice, flagTxBuildMaxFee: &f.maxFee,
+		flagTxBuildMaxPriorityFee: &f.maxPriorityFee, flagTxBuildChainID: &f.chainID,
+	} {
+		s, err := flags.GetString(name)
+		if err != nil {
+			return err
+		}
+		*v = strings.TrimSpace(s)
+	}
+	fRpc, err := flags.GetString(flagTxRpcUrl)
+	if err != nil {
+		return err
+	}
+	fInteractive, err := flags.GetBool(flagTxBuildInteractive)
+	if err != nil {
+		return err
+	}
+	fQR, err := flags.GetBool(flagTxBuildQR)
+	if err != nil {
+		return err
+	}
+	fJson, err := flags.GetBool(flagTxJson)
+	if err != nil {
+		return err
+	}
+
+	if fInteractive {
+		if args, err = promptTxBuildFields(cmd.InOrStdin(), cmd.ErrOrStderr(), &f, args, fRpc != ""); err != nil {
+			return err
+		}
+	}
+
+	var provider *ethrpc.Provider
+	if fRpc != "" {
+		if _, err := url.ParseRequestURI(fRpc); err != nil {
+			return errors.New("error: please provide a valid rpc url (e.g. https://nodes.sequence.app/mainnet)")
+		}
+		if provider, err = ethrpc.NewProvider(fRpc); err != nil {
+			return err
+		}
+	}
+
+	txn, chainID, from, err := buildUnsignedTx(context.Background(), provider, &f, args)
+	if err != nil {
+		return err
+	}
+
+	decoder, err := NewDecoder()
+	if err != nil {
+		return err
+	}
+	unsigned, err := NewUnsignedTransaction(txn, chainID, from, decoder)
+	if err != nil {
+		return err
+	}
+
+	var obj any = unsigned
+	if fJson {
+		json, err := PrettyJSON(obj)
+		if err != nil {
+			return err
+		}
+		obj = *json
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), obj)
+
+	if fQR {
+		qr, err := newQRCode([]byte(unsigned.Payload))
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(cmd.OutOrStdout(), qr)
+	}
+	return nil
+}
+
+// txBuildPrompt is the prompt of a field of the transaction to build.
+type txBuildPrompt struct {
+	label    string
+	v        *string
+	optional bool
+}
+
+// promptTxBuildFields prompts for the fields which are not set, and returns the
+// args of the method. The fields fetched from the provider are optional.
+func promptTxBuildFields(in io.Reader, out io.Writer, f *txBuildFields, args []string, withProvider bool) ([]string, error) {
+	reader := bufio.NewReader(in)
+	prompt := func(prompts ...txBuildPrompt) error {
+		for _, p := range prompts {
+			if *p.v != "" {
+				continue
+			}
+			label := p.label
+			if p.optional {
+				label += " (optional)"
+			}
+			fmt.Fprintf(out, "%s: ", label)
+			line, err := reader.ReadString('\n')
+			if err != nil && (err != io.EOF || line == "") {
+				return fmt.Errorf("error: failed to read %s: %w", p.label, err)
+			}
+			*p.v = strings.TrimSpace(line)
+		}
+		return nil
+	}
+
+	calldata := f.data
+	err := prompt(
+		txBuildPrompt{"from", &f.from, true},
+		txBuildPrompt{"to", &f.to, true},
+		txBuildPrompt{"value", &f.value, true},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if calldata == "" && f.method == "" {
+		if err := prompt(txBuildPrompt{"method or calldata", &calldata, true}); err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(calldata, "0x") {
+			f.data = calldata
+		} else {
+			f.method = calldata
+		}
+	}
+	if f.method != "" && len(args) == 0 {
+		var values string
+		if err := prompt(txBuildPrompt{"args of " + f.method, &values, true}); err != nil {
+			return nil, err
+		}
+		if args, err = splitConsoleLine(values); err != nil {
+			return nil, err
+		}
+	}
+
+	err = prompt(
+		txBuildPrompt{"chain id", &f.chainID, withProvider},
+		txBuildPrompt{"nonce", &f.nonce, withProvider},
+		txBuildPrompt{"gas limit", &f.gasLimit, withProvider},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if f.gasPrice == "" {
+		err = prompt(
+			txBuildPrompt{"max fee per gas", &f.maxFee, withProvider},
+			txBuildPrompt{"max priority fee per gas", &f.maxPriorityFee, true},
+		)
+	}
+	return args, err
+}
+
+// buildUnsignedTx builds the unsigned transaction of the fields, with the missing
+// ones fetched from the provider. It returns the transaction, its chain id and its
+// sender when set.
+func buildUnsignedTx(ctx context.Context, provider *ethrpc.Provider, f *txBuildFields, args []string) (*types.Transaction, *big.Int, *common.Address, error) {
+	var from *common.Address
+	if f.from != "" {
+		if !common.IsHexAddress(f.from) {
+			return nil, nil, nil, fmt.Errorf("error: invalid from address '%s'", f.from)
+		}
+		addr := common.HexToAddress(f.from)
+		from = &addr
+	}
+
+	var to *common.Address
+	if f.to != "" {
+		if !common.IsHexAddress(f.to) {
+			return nil, nil, nil, fmt.Errorf("error: invalid to address '%s'", f.to)
+		}
+		addr := common.HexToAddress(f.to)
+		to = &addr
+	}
+
+	value := big.NewInt(0)
+	if f.value != "" {
+		v, err := parseEtherValue(f.value)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		value = v
+	}
+
+	var data []byte
+	switch {
+	case f.method != "" && f.data != "":
+		return nil, nil, nil, errors.New("error: please provide either the calldata or the method")
+	case f.method != "":
+		calldata, err := ethcoder.AbiEncodeMethodCalldataFromStringValues(f.method, args)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		data = calldata
+	case f.data != "":
+		calldata, err := ethcoder.HexDecode(f.data)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error: invalid calldata: %w", err)
+		}
+		data = calldata
+	}
+	if to == nil && len(data) == 0 {
+		return nil, nil, nil, errors.New("error: please provide the recipient or the init code of the transaction")
+	}
+
+	needsProvider := func(field string) error {
+		if provider == nil {
+			return fmt.Errorf("error: please provide the %s, or an rpc url to fetch it", field)
+		}
+		return nil
+	}
+
+	chainID, err := parseTxBuildBigInt("chain id", f.chainID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if chainID == nil {
+		if err := needsProvider("chain id"); err != nil {
+			return nil, nil, nil, err
+		}
+		if chainID, err = provider.ChainID(ctx); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	var nonce uint64
+	if f.nonce != "" {
+		if nonce, err = strconv.ParseUint(f.nonce, 10, 64); err != nil {
+			return nil, nil, nil, fmt.Errorf("error: invalid nonce '%s'", f.nonce)
+		}
+	} else {
+		if err := needsProvider("nonce"); err != nil {
+			return nil, nil, nil, err
+		}
+		if from == nil {
+			return nil, nil, nil, errors.New("error: please provide the nonce, or the from address to fetch it")
+		}
+		if nonce, err = provider.PendingNonceAt(ctx, *from); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	gasPrice, err := parseTxBuildFee("gas price", f.gasPrice)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	maxFee, err := parseTxBuildFee("max fee per gas", f.maxFee)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	maxPriorityFee, err := parseTxBuildFee("max priority fee per gas", f.maxPriorityFee)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if gasPrice != nil && (maxFee != nil || maxPriorityFee != nil) {
+		return nil, nil, nil, errors.New("error: please provide either the gas price or the EIP-1559 fees")
+	}
+	if gasPrice == nil {
+		if maxPriorityFee == nil {
+			if provider == nil {
+				maxPriorityFee = big.NewInt(0)
+			} else if maxPriorityFee, err = provider.SuggestGasTipCap(ctx); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+		if maxFee == nil {
+			if err := needsProvider("max fee per gas"); err != nil {
+				return nil, nil, nil, err
+			}
+			head, err := provider.HeaderByNumber(ctx, nil)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			if head.BaseFee == nil {
+				return nil, nil, nil, errors.New("error: the chain does not support EIP-1559, please provide the gas price")
+			}
+			// twice the base fee, for the increases of the next blocks
+			maxFee = new(big.Int).Add(new(big.Int).Mul(head.BaseFee, big.NewInt(2)), maxPriorityFee)
+		}
+		if maxFee.Cmp(maxPriorityFee) < 0 {
+			return nil, nil, nil, errors.New("error: the max priority fee per gas is higher than the max fee per gas")
+		}
+	}
+
+	var gasLimit uint64
+	if f.gasLimit != "" {
+		if gasLimit, err = strconv.ParseUint(f.gasLimit, 10, 64); err != nil {
+			return nil, nil, nil, fmt.Errorf("error: invalid gas limit '%s'", f.gasLimit)
+		}
+	} else {
+		if err := needsProvider("gas limit"); err != nil {
+			return nil, nil, nil, err
+		}
+		msg := ethereum.CallMsg{To: to, Value: value, Data: data}
+		if from != nil {
+			msg.From = *from
+		}
+		if gasLimit, err = provider.EstimateGas(ctx, msg); err != nil {
+			return nil, nil, nil, fmt.Errorf("error: failed to estimate the gas limit: %w", err)
+		}
+	}
+
+	if gasPrice != nil {
+		return types.NewTx(&types.LegacyTx{
+			Nonce: nonce, GasPrice: gasPrice, Gas: gasLimit, To: to, Value: value, Data: data,
+		}), chainID, from, nil
+	}
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID: chainID, Nonce: nonce, GasTipCap: maxPriorityFee, GasFeeCap: maxFee, Gas: gasLimit,
+		To: to, Value: value, Data: data,
+	}), chainID, from, nil
+}
+
+func parseTxBuildBigInt(field, s string) (*big.Int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	v, ok := new(big.Int).SetString(s, 0)
+	if !ok || v.Sign() < 0 {
+		return nil, fmt.Errorf("error: invalid %s '%s'", field, s)
+	}
+	return v, nil
+}
+
+// parseTxBuildFee parses a fee in wei or with a unit, ie. 20gwei.
+func parseTxBuildFee(field, s string) (*big.Int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	v, err := parseEtherValue(s)
+	if err != nil {
+		return nil, fmt.Errorf("error: invalid %s '%s'", field, s)
+	}
+	return v, nil
+}
+
+// SignedTransaction is a transaction signed with ethkit tx combine.
+type SignedTransaction struct {
+	Hash    common.Hash    `json:"hash"`
+	From    common.Address `json:"from"`
+	Raw     string         `json:"raw"`
+	Sent    bool           `json:"sent"`
+	Receipt *Receipt       `json:"receipt,omitempty"`
+}
+
+// String overrides the standard behavior for SignedTransaction "to-string".
+func (s *SignedTransaction) String() string {
+	var p Printable
+	if err := p.FromStruct(s); err != nil {
+		panic(err)
+	}
+	return p.Columnize(*NewPrintableFormat(20, 0, 0, byte(' ')))
+}
+
+func runTxCombine(cmd *cobra.Command, args []string) error {
+	fRpc, err := cmd.Flags().GetString(flagTxRpcUrl)
+	if err != nil {
+		return err
+	}
+	fWait, err := cmd.Flags().GetBool(flagTxWait)
+	if err != nil {
+		return err
+	}
+	fTimeout, err := cmd.Flags().GetDuration(flagTxTimeout)
+	if err != nil {
+		return err
+	}
+	fJson, err := cmd.Flags().GetBool(flagTxJson)
+	if err != nil {
+		return err
+	}
+
+	payload, err := ethcoder.HexDecode(strings.TrimSpace(args[0]))
+	if err != nil {
+		return fmt.Errorf("error: invalid unsigned payload: %w", err)
+	}
+	signature, err := ethcoder.HexDecode(strings.TrimSpace(args[1]))
+	if err != nil {
+		return fmt.Errorf("error: invalid signature: %w", err)
+	}
+
+	unsigned, chainID, err := ethtxn.DecodeUnsignedPayload(payload)
+	if err != nil {
+		return err
+	}
+	signed, err := ethtxn.CombineSignature(unsigned, chainID, signature)
+	if err != nil {
+		return err
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+	if err != nil {
+		return err
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	result := &SignedTransaction{Hash: signed.Hash(), From: from, Raw: ethcoder.HexEncode(raw)}
+
+	if fRpc != "" {
+		if _, err := url.ParseRequestURI(fRpc); err != nil {
+			return errors.New("error: please provide a valid rpc url (e.g. https://nodes.sequence.app/mainnet)")
+		}
+		provider, err := ethrpc.NewProvider(fRpc)
+		if err != nil {
+			return err
+		}
+
+		ctx := context.Background()
+		providerChainID, err := provider.ChainID(ctx)
+		if err != nil {
+			return err
+		}
+		if providerChainID.Cmp(chainID) != 0 {
+			return fmt.Errorf("error: the transaction is for chain %s, but the rpc endpoint is of chain %s", chainID, providerChainID)
+		}
+		if _, err := provider.SendRawTransaction(ctx, result.Raw); err != nil {
+			return err
+		}
+		result.Sent = true
+
+		if fWait {
+			wctx, cancel := context.WithTimeout(ctx, fTimeout)
+			defer cancel()
+			receipt, err := ethrpc.WaitForTxnReceipt(wctx, provider, signed.Hash())
+			if err != nil {
+				return err
+			}
+			decoder, err := NewDecoder()
+			if err != nil {
+				return err
+			}
+			result.Receipt = NewReceipt(receipt, decoder)
+		}
+	}
+
+	var obj any = result
+	if fJson {
+		json, err := PrettyJSON(obj)
+		if err != nil {
+			return err
+		}
+		obj = *json
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), obj)
+
+	return nil
+}
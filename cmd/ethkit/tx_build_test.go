@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethdevnode"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func execTxSubCmd(args []string, input string) (string, error) {
+	cmd := NewTxCmd()
+	actual := new(bytes.Buffer)
+	cmd.SetOut(actual)
+	cmd.SetErr(actual)
+	cmd.SetIn(strings.NewReader(input))
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		return "", err
+	}
+
+	return actual.String(), nil
+}
+
+func Test_TxBuildCmd_Offline(t *testing.T) {
+	res, err := execTxSubCmd([]string{
+		"build", "--to", "0xcDF87cA00696a756fd1e35dd2DBAa1f30A80C0A8", "--chain-id", "1", "--nonce", "3",
+		"--gas-limit", "60000", "--gas-price", "20gwei", "--method", "transfer(address,uint256)",
+		"0xcDF87cA00696a756fd1e35dd2DBAa1f30A80C0A8", "1", "--json",
+	}, "")
+	require.NoError(t, err)
+
+	var unsigned UnsignedTransaction
+	require.NoError(t, json.Unmarshal([]byte(res), &unsigned))
+	assert.Equal(t, uint8(0), unsigned.Type)
+	assert.Equal(t, "20000000000", unsigned.GasPrice)
+	assert.Equal(t, "1200000000000000", unsigned.MaxFee)
+	assert.Equal(t, "transfer(address,uint256)", unsigned.Call.Function)
+
+	payload, err := ethcoder.HexDecode(unsigned.Payload)
+	require.NoError(t, err)
+	txn, chainID, err := ethtxn.DecodeUnsignedPayload(payload)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), chainID.Int64())
+	assert.Equal(t, uint64(3), txn.Nonce())
+	assert.Equal(t, unsigned.SigningHash, ethtxn.SigningHash(payload))
+
+	_, err = execTxSubCmd([]string{"build", "--to", "0xcDF87cA00696a756fd1e35dd2DBAa1f30A80C0A8", "--chain-id", "1"}, "")
+	assert.ErrorContains(t, err, "please provide the nonce, or an rpc url to fetch it")
+}
+
+func Test_TxBuildCmd_Interactive(t *testing.T) {
+	input := strings.Join([]string{
+		"",
+		"0xcDF87cA00696a756fd1e35dd2DBAa1f30A80C0A8",
+		"1.5ether",
+		"",
+		"7",
+		"21000",
+		"30gwei",
+		"1gwei",
+	}, "\n")
+	res, err := execTxSubCmd([]string{"build", "-i", "--chain-id", "5", "--qr"}, input)
+	require.NoError(t, err)
+
+	assert.Contains(t, res, "from (optional): ")
+	assert.Contains(t, res, "max fee per gas: ")
+	assert.Contains(t, res, "1500000000000000000 wei")
+	assert.Contains(t, res, "630000000000000 wei")
+	assert.Contains(t, res, "signingHash")
+	assert.Contains(t, res, "▀")
+}
+
+func Test_TxBuildCombineCmd(t *testing.T) {
+	ctx := context.Background()
+	node, err := ethdevnode.NewNode(ctx)
+	require.NoError(t, err)
+	server := httptest.NewServer(node)
+	defer server.Close()
+
+	sender := node.Accounts()[0]
+	to := common.HexToAddress("0xcDF87cA00696a756fd1e35dd2DBAa1f30A80C0A8")
+
+	res, err := execTxSubCmd([]string{
+		"build", "--rpc-url", server.URL, "--from", sender.Address().Hex(), "--to", to.Hex(), "--value", "2ether", "--json",
+	}, "")
+	require.NoError(t, err)
+
+	var unsigned UnsignedTransaction
+	require.NoError(t, json.Unmarshal([]byte(res), &unsigned))
+	assert.Equal(t, uint8(2), unsigned.Type)
+	assert.Equal(t, "1337", unsigned.ChainID)
+	assert.Equal(t, uint64(21000), unsigned.Gas)
+
+	// signed on the air-gapped device
+	sig, err := crypto.Sign(unsigned.SigningHash.Bytes(), sender.PrivateKey())
+	require.NoError(t, err)
+
+	res, err = execTxSubCmd([]string{"combine", unsigned.Payload, ethcoder.HexEncode(sig), "--rpc-url", server.URL, "--wait", "--json"}, "")
+	require.NoError(t, err)
+
+	var signed SignedTransaction
+	require.NoError(t, json.Unmarshal([]byte(res), &signed))
+	assert.Equal(t, sender.Address(), signed.From)
+	assert.True(t, signed.Sent)
+	require.NotNil(t, signed.Receipt)
+	assert.Equal(t, uint64(1), signed.Receipt.Status)
+
+	_, err = execTxSubCmd([]string{"combine", unsigned.Payload, "0x1234"}, "")
+	assert.ErrorContains(t, err, "invalid signature length")
+}
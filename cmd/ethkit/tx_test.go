@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func execTxCmd(args string) (string, error) {
+	cmd := NewTxCmd()
+	actual := new(bytes.Buffer)
+	cmd.SetOut(actual)
+	cmd.SetErr(actual)
+	cmd.SetArgs(strings.Split(args, " "))
+	if err := cmd.Execute(); err != nil {
+		return "", err
+	}
+
+	return actual.String(), nil
+}
+
+func Test_TxCmd_InvalidHash(t *testing.T) {
+	res, err := execTxCmd("0x1234 --rpc-url https://nodes.sequence.app/mainnet")
+	assert.Contains(t, err.Error(), "please provide a valid transaction hash")
+	assert.Empty(t, res)
+}
+
+func Test_NewTransaction(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	calldata, err := ethcoder.AbiEncodeMethodCalldata("approve(address,uint256)", []interface{}{to, big.NewInt(1)})
+	require.NoError(t, err)
+
+	txn, err := types.SignNewTx(key, types.LatestSignerForChainID(big.NewInt(1)), &types.DynamicFeeTx{
+		ChainID:   big.NewInt(1),
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(10),
+		Gas:       50000,
+		To:        &to,
+		Data:      calldata,
+	})
+	require.NoError(t, err)
+
+	decoder, err := NewDecoder()
+	require.NoError(t, err)
+
+	pending := NewTransaction(txn, nil, decoder)
+	assert.True(t, pending.Pending)
+	assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), pending.From)
+	assert.Equal(t, "approve(address,uint256)", pending.Call.Function)
+	assert.Nil(t, pending.Receipt)
+
+	mined := NewTransaction(txn, &types.Receipt{
+		Status:            types.ReceiptStatusSuccessful,
+		TxHash:            txn.Hash(),
+		GasUsed:           40000,
+		EffectiveGasPrice: big.NewInt(5),
+		BlockNumber:       big.NewInt(100),
+	}, decoder)
+	assert.False(t, mined.Pending)
+	assert.Equal(t, big.NewInt(200000), mined.Fee)
+	assert.Equal(t, uint64(1), mined.Receipt.Status)
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethmonitor"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+const (
+	flagWatchRpcUrl       = "rpc-url"
+	flagWatchAddress      = "address"
+	flagWatchToken        = "token"
+	flagWatchBelow        = "below"
+	flagWatchAbove        = "above"
+	flagWatchJson         = "json"
+	flagWatchPollInterval = "poll-interval"
+)
+
+func init() {
+	rootCmd.AddCommand(NewWatchCmd())
+}
+
+type watch struct {
+}
+
+// NewWatchCmd returns a new command to stream the balance and nonce changes
+// of accounts on every new block.
+func NewWatchCmd() *cobra.Command {
+	c := &watch{}
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Watch the native and ERC-20 balances and nonces of accounts on every block",
+		Args:  cobra.NoArgs,
+		RunE:  c.Run,
+	}
+
+	cmd.Flags().StringP(flagWatchRpcUrl, "r", "", "The RPC endpoint to the blockchain node to interact with")
+	cmd.Flags().StringSliceP(flagWatchAddress, "a", nil, "The account address(es) to watch")
+	cmd.Flags().StringSliceP(flagWatchToken, "t", nil, "The ERC-20 token address(es) to watch, in addition to the native balance")
+	cmd.Flags().String(flagWatchBelow, "", "Alert when a balance drops below the amount, in wei or token base units")
+	cmd.Flags().String(flagWatchAbove, "", "Alert when a balance rises above the amount, in wei or token base units")
+	cmd.Flags().BoolP(flagWatchJson, "j", false, "Print the changes as JSON, one per line")
+	cmd.Flags().Duration(flagWatchPollInterval, 1500*time.Millisecond, "Polling interval of new blocks")
+
+	return cmd
+}
+
+func (c *watch) Run(cmd *cobra.Command, args []string) error {
+	fRpc, err := cmd.Flags().GetString(flagWatchRpcUrl)
+	if err != nil {
+		return err
+	}
+	fAddresses, err := cmd.Flags().GetStringSlice(flagWatchAddress)
+	if err != nil {
+		return err
+	}
+	fTokens, err := cmd.Flags().GetStringSlice(flagWatchToken)
+	if err != nil {
+		return err
+	}
+	fBelow, err := cmd.Flags().GetString(flagWatchBelow)
+	if err != nil {
+		return err
+	}
+	fAbove, err := cmd.Flags().GetString(flagWatchAbove)
+	if err != nil {
+		return err
+	}
+	fJson, err := cmd.Flags().GetBool(flagWatchJson)
+	if err != nil {
+		return err
+	}
+	fPollInterval, err := cmd.Flags().GetDuration(flagWatchPollInterval)
+	if err != nil {
+		return err
+	}
+
+	if len(fAddresses) == 0 {
+		return errors.New("error: please provide an account address to watch (e.g. --address 0x213a286A1AF3Ac010d4F2D66A52DeAf762dF7742)")
+	}
+	accounts := make([]common.Address, len(fAddresses))
+	for i, addr := range fAddresses {
+		if !common.IsHexAddress(addr) {
+			return fmt.Errorf("error: invalid account address %q", addr)
+		}
+		accounts[i] = common.HexToAddress(addr)
+	}
+	tokens := make([]common.Address, len(fTokens))
+	for i, addr := range fTokens {
+		if !common.IsHexAddress(addr) {
+			return fmt.Errorf("error: invalid token address %q", addr)
+		}
+		tokens[i] = common.HexToAddress(addr)
+	}
+
+	below, err := parseWatchThreshold(fBelow)
+	if err != nil {
+		return err
+	}
+	above, err := parseWatchThreshold(fAbove)
+	if err != nil {
+		return err
+	}
+
+	if _, err = url.ParseRequestURI(fRpc); err != nil {
+		return errors.New("error: please provide a valid rpc url (e.g. https://nodes.sequence.app/mainnet)")
+	}
+
+	provider, err := ethrpc.NewProvider(fRpc)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	monitorOptions := ethmonitor.DefaultOptions
+	monitorOptions.PollingInterval = fPollInterval
+
+	monitor, err := ethmonitor.NewMonitor(provider, monitorOptions)
+	if err != nil {
+		return err
+	}
+
+	sub := monitor.Subscribe("watch")
+	defer sub.Unsubscribe()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- monitor.Run(ctx)
+	}()
+	defer monitor.Stop()
+
+	w := newBalanceWatcher(provider, accounts, tokens, below, above)
+	out := cmd.OutOrStdout()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case err := <-errCh:
+			return err
+
+		case <-sub.Done():
+			return sub.Err()
+
+		case blocks := <-sub.Blocks():
+			for _, block := range blocks {
+				if block.Event != ethmonitor.Added {
+					continue
+				}
+				changes, err := w.check(ctx, block.Number())
+				if err != nil {
+					if ctx.Err() != nil {
+						return nil
+					}
+					return err
+				}
+				for _, change := range changes {
+					if fJson {
+						data, err := json.Marshal(change)
+						if err != nil {
+							return err
+						}
+						fmt.Fprintln(out, string(data))
+					} else {
+						fmt.Fprintln(out, change)
+					}
+				}
+			}
+		}
+	}
+}
+
+func parseWatchThreshold(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok || v.Sign() < 0 {
+		return nil, fmt.Errorf("error: invalid threshold amount %q", s)
+	}
+	return v, nil
+}
+
+// BalanceChange is a change of the native or token balance, or of the nonce,
+// of a watched account.
+type BalanceChange struct {
+	BlockNumber uint64          `json:"blockNumber"`
+	Account     common.Address  `json:"account"`
+	Token       *common.Address `json:"token,omitempty"`
+	Balance     *big.Int        `json:"balance"`
+	Previous    *big.Int        `json:"previous,omitempty"`
+	Nonce       *uint64         `json:"nonce,omitempty"`
+	Alert       string          `json:"alert,omitempty"`
+}
+
+func (c *BalanceChange) String() string {
+	asset := "native"
+	if c.Token != nil {
+		asset = c.Token.Hex()
+	}
+
+	s := fmt.Sprintf("block %d: %s %s balance %s", c.BlockNumber, c.Account.Hex(), asset, c.Balance)
+	if c.Previous != nil && c.Previous.Cmp(c.Balance) != 0 {
+		diff := new(big.Int).Sub(c.Balance, c.Previous)
+		sign := ""
+		if diff.Sign() > 0 {
+			sign = "+"
+		}
+		s += fmt.Sprintf(" (%s%s)", sign, diff)
+	}
+	if c.Nonce != nil {
+		s += fmt.Sprintf(", nonce %d", *c.Nonce)
+	}
+	if c.Alert != "" {
+		s += fmt.Sprintf(" [ALERT: %s]", c.Alert)
+	}
+	return s
+}
+
+type watchKey struct {
+	account common.Address
+	token   common.Address
+}
+
+type watchState struct {
+	balance *big.Int
+	nonce   uint64
+}
+
+// balanceWatcher keeps the last seen balances and nonces of the watched
+// accounts and reports the changes at each block.
+type balanceWatcher struct {
+	provider ethrpc.Interface
+	accounts []common.Address
+	tokens   []common.Address
+	below    *big.Int
+	above    *big.Int
+	last     map[watchKey]*watchState
+}
+
+func newBalanceWatcher(provider ethrpc.Interface, accounts, tokens []common.Address, below, above *big.Int) *balanceWatcher {
+	return &balanceWatcher{
+		provider: provider,
+		accounts: accounts,
+		tokens:   tokens,
+		below:    below,
+		above:    above,
+		last:     map[watchKey]*watchState{},
+	}
+}
+
+// check fetches the balances and nonces at blockNum and returns the ones which
+// changed since the last check. All of them are returned on the first check.
+func (w *balanceWatcher) check(ctx context.Context, blockNum *big.Int) ([]*BalanceChange, error) {
+	var changes []*BalanceChange
+
+	for _, account := range w.accounts {
+		balance, err := w.provider.BalanceAt(ctx, account, blockNum)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch balance of %s: %w", account.Hex(), err)
+		}
+		nonce, err := w.provider.NonceAt(ctx, account, blockNum)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch nonce of %s: %w", account.Hex(), err)
+		}
+		if change := w.update(watchKey{account: account}, blockNum, balance, nonce); change != nil {
+			change.Nonce = &nonce
+			changes = append(changes, change)
+		}
+
+		for _, token := range w.tokens {
+			balance, err := w.tokenBalance(ctx, token, account, blockNum)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch %s balance of %s: %w", token.Hex(), account.Hex(), err)
+			}
+			if change := w.update(watchKey{account: account, token: token}, blockNum, balance, 0); change != nil {
+				token := token
+				change.Token = &token
+				changes = append(changes, change)
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+func (w *balanceWatcher) update(key watchKey, blockNum, balance *big.Int, nonce uint64) *BalanceChange {
+	prev, ok := w.last[key]
+	w.last[key] = &watchState{balance: balance, nonce: nonce}
+	if ok && prev.balance.Cmp(balance) == 0 && prev.nonce == nonce {
+		return nil
+	}
+
+	change := &BalanceChange{
+		BlockNumber: blockNum.Uint64(),
+		Account:     key.account,
+		Balance:     balance,
+	}
+	if ok {
+		change.Previous = prev.balance
+	}
+
+	// alert when crossing a threshold, or when already past it on the first check
+	if w.below != nil && balance.Cmp(w.below) < 0 && (!ok || prev.balance.Cmp(w.below) >= 0) {
+		change.Alert = fmt.Sprintf("balance below %s", w.below)
+	}
+	if w.above != nil && balance.Cmp(w.above) > 0 && (!ok || prev.balance.Cmp(w.above) <= 0) {
+		change.Alert = fmt.Sprintf("balance above %s", w.above)
+	}
+	return change
+}
+
+func (w *balanceWatcher) tokenBalance(ctx context.Context, token, account common.Address, blockNum *big.Int) (*big.Int, error) {
+	calldata, err := ethcoder.AbiEncodeMethodCalldata("balanceOf(address)", []interface{}{account})
+	if err != nil {
+		return nil, err
+	}
+	data, err := w.provider.CallContract(ctx, ethereum.CallMsg{To: &token, Data: calldata}, blockNum)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 32 {
+		return nil, fmt.Errorf("unexpected balanceOf result %x", data)
+	}
+	return new(big.Int).SetBytes(data[:32]), nil
+}
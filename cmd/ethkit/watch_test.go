@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func execWatchCmd(args string) (string, error) {
+	cmd := NewWatchCmd()
+	actual := new(bytes.Buffer)
+	cmd.SetOut(actual)
+	cmd.SetErr(actual)
+	cmd.SetArgs(strings.Split(args, " "))
+	if err := cmd.Execute(); err != nil {
+		return "", err
+	}
+
+	return actual.String(), nil
+}
+
+func Test_WatchCmd_InvalidArgs(t *testing.T) {
+	_, err := execWatchCmd("--rpc-url https://nodes.sequence.app/mainnet")
+	assert.Contains(t, err.Error(), "please provide an account address")
+
+	_, err = execWatchCmd("--address 0x1234 --rpc-url https://nodes.sequence.app/mainnet")
+	assert.Contains(t, err.Error(), "invalid account address")
+
+	_, err = execWatchCmd("--address 0x213a286A1AF3Ac010d4F2D66A52DeAf762dF7742 --below abc --rpc-url https://nodes.sequence.app/mainnet")
+	assert.Contains(t, err.Error(), "invalid threshold amount")
+
+	_, err = execWatchCmd("--address 0x213a286A1AF3Ac010d4F2D66A52DeAf762dF7742")
+	assert.Contains(t, err.Error(), "please provide a valid rpc url")
+}
+
+type watchProvider struct {
+	ethrpc.Interface
+	balance      *big.Int
+	nonce        uint64
+	tokenBalance *big.Int
+}
+
+func (p *watchProvider) BalanceAt(ctx context.Context, account common.Address, blockNum *big.Int) (*big.Int, error) {
+	return p.balance, nil
+}
+
+func (p *watchProvider) NonceAt(ctx context.Context, account common.Address, blockNum *big.Int) (uint64, error) {
+	return p.nonce, nil
+}
+
+func (p *watchProvider) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNum *big.Int) ([]byte, error) {
+	return common.LeftPadBytes(p.tokenBalance.Bytes(), 32), nil
+}
+
+func Test_BalanceWatcher(t *testing.T) {
+	account := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	token := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	provider := &watchProvider{balance: big.NewInt(100), tokenBalance: big.NewInt(5)}
+
+	w := newBalanceWatcher(provider, []common.Address{account}, []common.Address{token}, big.NewInt(50), nil)
+	ctx := context.Background()
+
+	// the first check reports the current state
+	changes, err := w.check(ctx, big.NewInt(1))
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Nil(t, changes[0].Token)
+	assert.Equal(t, uint64(0), *changes[0].Nonce)
+	assert.Empty(t, changes[0].Alert)
+	assert.Equal(t, token, *changes[1].Token)
+	assert.Equal(t, "balance below 50", changes[1].Alert)
+
+	// nothing changed
+	changes, err = w.check(ctx, big.NewInt(2))
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+
+	// a transfer out of the account drops the native balance below the threshold
+	provider.balance, provider.nonce = big.NewInt(40), 1
+	changes, err = w.check(ctx, big.NewInt(3))
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, uint64(3), changes[0].BlockNumber)
+	assert.Equal(t, big.NewInt(100), changes[0].Previous)
+	assert.Equal(t, "balance below 50", changes[0].Alert)
+	assert.Equal(t, "block 3: 0x1111111111111111111111111111111111111111 native balance 40 (-60), nonce 1 [ALERT: balance below 50]", changes[0].String())
+
+	// remaining below the threshold doesn't alert again
+	provider.balance = big.NewInt(30)
+	changes, err = w.check(ctx, big.NewInt(4))
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Empty(t, changes[0].Alert)
+}
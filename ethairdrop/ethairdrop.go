@@ -0,0 +1,213 @@
+// Package ethairdrop builds token distributions which are claimed with a
+// merkle proof, compatible with Uniswap's MerkleDistributor contract.
+package ethairdrop
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+)
+
+// Entry is the amount of tokens claimable by an account.
+type Entry struct {
+	Account common.Address
+	Amount  *big.Int
+}
+
+// Claim is the data an account passes to MerkleDistributor.claim.
+type Claim struct {
+	Index  uint64        `json:"index"`
+	Amount *hexutil.Big  `json:"amount"`
+	Proof  []common.Hash `json:"proof"`
+}
+
+// Distribution is the merkle root and the claims of all accounts, which
+// marshals to the same JSON as Uniswap's parse-balance-map script.
+type Distribution struct {
+	MerkleRoot common.Hash              `json:"merkleRoot"`
+	TokenTotal *hexutil.Big             `json:"tokenTotal"`
+	Claims     map[common.Address]Claim `json:"claims"`
+}
+
+// NewDistribution builds the distribution of the entries. Indexes are assigned
+// in the order of the checksummed addresses of the accounts, as done by
+// Uniswap's tooling, so the same entries always produce the same root.
+func NewDistribution(entries []Entry) (*Distribution, error) {
+	if len(entries) == 0 {
+		return nil, errors.New("ethairdrop: no entries")
+	}
+
+	sorted := make([]Entry, len(entries))
+	copy(sorted, entries)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Account.Hex() < sorted[j].Account.Hex()
+	})
+
+	total := new(big.Int)
+	leaves := make([][]byte, len(sorted))
+	for i, entry := range sorted {
+		if i > 0 && entry.Account == sorted[i-1].Account {
+			return nil, fmt.Errorf("ethairdrop: duplicate account %s", entry.Account)
+		}
+		if entry.Amount == nil || entry.Amount.Sign() <= 0 {
+			return nil, fmt.Errorf("ethairdrop: invalid amount for account %s", entry.Account)
+		}
+		leaf, err := LeafHash(uint64(i), entry.Account, entry.Amount)
+		if err != nil {
+			return nil, err
+		}
+		leaves[i] = leaf
+		total.Add(total, entry.Amount)
+	}
+
+	tree := ethcoder.NewMerkleTree(leaves, nil, &ethcoder.Options{SortLeaves: true, SortPairs: true})
+
+	d := &Distribution{
+		MerkleRoot: common.BytesToHash(tree.GetRoot()),
+		TokenTotal: (*hexutil.Big)(total),
+		Claims:     make(map[common.Address]Claim, len(sorted)),
+	}
+	for i, entry := range sorted {
+		proof, err := tree.GetProof(leaves[i])
+		if err != nil {
+			return nil, fmt.Errorf("ethairdrop: failed to get proof of account %s: %w", entry.Account, err)
+		}
+		claim := Claim{
+			Index:  uint64(i),
+			Amount: (*hexutil.Big)(new(big.Int).Set(entry.Amount)),
+			Proof:  make([]common.Hash, len(proof)),
+		}
+		for j, p := range proof {
+			claim.Proof[j] = common.BytesToHash(p.Data)
+		}
+		d.Claims[entry.Account] = claim
+	}
+	return d, nil
+}
+
+// LeafHash is the leaf of a claim, keccak256(abi.encodePacked(index, account, amount)).
+func LeafHash(index uint64, account common.Address, amount *big.Int) ([]byte, error) {
+	hash, err := ethcoder.SolidityPackedKeccak256(
+		[]string{"uint256", "address", "uint256"},
+		[]interface{}{new(big.Int).SetUint64(index), account, amount},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ethairdrop: failed to encode leaf: %w", err)
+	}
+	return hash.Bytes(), nil
+}
+
+// Verify reports if the claim of the account is included in the distribution
+// with the merkle root, the same as MerkleDistributor.claim does on-chain.
+func Verify(root common.Hash, account common.Address, claim Claim) (bool, error) {
+	hash, err := LeafHash(claim.Index, account, claim.Amount.ToInt())
+	if err != nil {
+		return false, err
+	}
+	for _, p := range claim.Proof {
+		if bytes.Compare(hash, p.Bytes()) <= 0 {
+			hash = ethcoder.Keccak256(append(hash, p.Bytes()...))
+		} else {
+			hash = ethcoder.Keccak256(append(p.Bytes(), hash...))
+		}
+	}
+	return bytes.Equal(hash, root.Bytes()), nil
+}
+
+// MarshalJSON encodes the claims keyed by checksummed address.
+func (d *Distribution) MarshalJSON() ([]byte, error) {
+	claims := make(map[string]Claim, len(d.Claims))
+	for account, claim := range d.Claims {
+		claims[account.Hex()] = claim
+	}
+	return json.Marshal(struct {
+		MerkleRoot common.Hash      `json:"merkleRoot"`
+		TokenTotal *hexutil.Big     `json:"tokenTotal"`
+		Claims     map[string]Claim `json:"claims"`
+	}{d.MerkleRoot, d.TokenTotal, claims})
+}
+
+func (d *Distribution) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+// ReadEntriesJSON reads entries from a JSON object of accounts to amounts, the
+// input format of Uniswap's parse-balance-map script. Amounts are numbers, or
+// decimal or 0x-prefixed hex strings.
+func ReadEntriesJSON(r io.Reader) ([]Entry, error) {
+	var balances map[string]json.RawMessage
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if err := dec.Decode(&balances); err != nil {
+		return nil, fmt.Errorf("ethairdrop: failed to decode entries: %w", err)
+	}
+
+	entries := make([]Entry, 0, len(balances))
+	for account, raw := range balances {
+		var amount string
+		if err := json.Unmarshal(raw, &amount); err != nil {
+			amount = string(raw)
+		}
+		entry, err := parseEntry(account, amount)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// ReadEntriesCSV reads entries from CSV rows of account,amount. A header row is
+// skipped if its first column is not an address.
+func ReadEntriesCSV(r io.Reader) ([]Entry, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 2
+	cr.TrimLeadingSpace = true
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("ethairdrop: failed to read entries: %w", err)
+	}
+	if len(records) > 0 && !common.IsHexAddress(records[0][0]) {
+		records = records[1:]
+	}
+
+	entries := make([]Entry, len(records))
+	for i, record := range records {
+		entries[i], err = parseEntry(record[0], record[1])
+		if err != nil {
+			return nil, fmt.Errorf("%w (row %d)", err, i+1)
+		}
+	}
+	return entries, nil
+}
+
+func parseEntry(account, amount string) (Entry, error) {
+	if !common.IsHexAddress(account) {
+		return Entry{}, fmt.Errorf("ethairdrop: invalid account %q", account)
+	}
+	amount = strings.TrimSpace(amount)
+	var value *big.Int
+	var ok bool
+	if strings.HasPrefix(amount, "0x") {
+		value, ok = new(big.Int).SetString(amount[2:], 16)
+	} else {
+		value, ok = new(big.Int).SetString(amount, 10)
+	}
+	if !ok {
+		return Entry{}, fmt.Errorf("ethairdrop: invalid amount %q for account %s", amount, account)
+	}
+	return Entry{Account: common.HexToAddress(account), Amount: value}, nil
+}
@@ -0,0 +1,82 @@
+package ethairdrop_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethairdrop"
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDistribution(t *testing.T) {
+	entries, err := ethairdrop.ReadEntriesJSON(strings.NewReader(`{
+		"0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266": 200,
+		"0x70997970C51812dc3A010C7d01b50e0d17dc79C8": "0x3e8",
+		"0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC": "1000000000000000000000",
+		"0x90F79bf6EB2c4f870365E785982E1f101E93b906": "5"
+	}`))
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+
+	d, err := ethairdrop.NewDistribution(entries)
+	require.NoError(t, err)
+	assert.Equal(t, "1000000000000000001205", d.TokenTotal.ToInt().String())
+	require.Len(t, d.Claims, 4)
+
+	// indexes follow the order of the checksummed addresses
+	assert.Equal(t, uint64(0), d.Claims[common.HexToAddress("0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC")].Index)
+	assert.Equal(t, uint64(3), d.Claims[common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")].Index)
+
+	for account, claim := range d.Claims {
+		ok, err := ethairdrop.Verify(d.MerkleRoot, account, claim)
+		require.NoError(t, err)
+		assert.True(t, ok, account.String())
+	}
+
+	claim := d.Claims[common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")]
+	claim.Amount = (*hexutil.Big)(big.NewInt(1001))
+	ok, err := ethairdrop.Verify(d.MerkleRoot, common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8"), claim)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	var buf bytes.Buffer
+	require.NoError(t, d.WriteJSON(&buf))
+	var out map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	assert.Equal(t, d.MerkleRoot.Hex(), out["merkleRoot"])
+	assert.Contains(t, out["claims"], "0x90F79bf6EB2c4f870365E785982E1f101E93b906")
+
+	var decoded ethairdrop.Distribution
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, d.Claims, decoded.Claims)
+}
+
+func TestLeafHash(t *testing.T) {
+	account := common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")
+	leaf, err := ethairdrop.LeafHash(1, account, big.NewInt(1000))
+	require.NoError(t, err)
+
+	packed := append(common.BigToHash(big.NewInt(1)).Bytes(), account.Bytes()...)
+	packed = append(packed, common.BigToHash(big.NewInt(1000)).Bytes()...)
+	assert.Equal(t, ethcoder.Keccak256(packed), leaf)
+}
+
+func TestReadEntriesCSV(t *testing.T) {
+	entries, err := ethairdrop.ReadEntriesCSV(strings.NewReader("account,amount\n0x70997970C51812dc3A010C7d01b50e0d17dc79C8,10\n0x70997970c51812dc3a010c7d01b50e0d17dc79c8, 20\n"))
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, big.NewInt(20), entries[1].Amount)
+
+	_, err = ethairdrop.NewDistribution(entries)
+	assert.ErrorContains(t, err, "duplicate account")
+
+	_, err = ethairdrop.ReadEntriesCSV(strings.NewReader("0x70997970C51812dc3A010C7d01b50e0d17dc79C8,abc\n"))
+	assert.Error(t, err)
+}
@@ -4,8 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"strings"
 
+	"github.com/0xsequence/ethkit/ethcoder"
 	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
 	"github.com/0xsequence/ethkit/go-ethereum/common"
 )
@@ -39,11 +39,11 @@ func ParseArtifactJSON(artifactJSON string) (Artifact, error) {
 		return Artifact{}, fmt.Errorf("contract name is empty")
 	}
 
-	parsedABI, err := abi.JSON(strings.NewReader(string(rawArtifact.ABI)))
+	parsedABI, err := ethcoder.ParseABIJSON(rawArtifact.ABI)
 	if err != nil {
 		return Artifact{}, fmt.Errorf("unable to parse abi json in artifact: %w", err)
 	}
-	artifact.ABI = parsedABI
+	artifact.ABI = *parsedABI
 
 	if len(rawArtifact.Bytecode) > 2 {
 		artifact.Bin = common.FromHex(rawArtifact.Bytecode)
@@ -68,6 +68,13 @@ type RawArtifact struct {
 	ABI              json.RawMessage `json:"abi"`
 	Bytecode         string          `json:"bytecode"`
 	DeployedBytecode string          `json:"deployedBytecode"`
+
+	// Optional solc source maps and sources, as included in truffle artifacts
+	SourceMap         string          `json:"sourceMap,omitempty"`
+	DeployedSourceMap string          `json:"deployedSourceMap,omitempty"`
+	Source            string          `json:"source,omitempty"`
+	SourcePath        string          `json:"sourcePath,omitempty"`
+	AST               json.RawMessage `json:"ast,omitempty"`
 }
 
 func ParseArtifactFile(path string) (RawArtifact, error) {
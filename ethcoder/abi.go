@@ -1,10 +1,12 @@
 package ethcoder
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
+	"reflect"
 	"strconv"
 	"strings"
 
@@ -17,10 +19,21 @@ func AbiCoder(argTypes []string, argValues []interface{}) ([]byte, error) {
 	if len(argTypes) != len(argValues) {
 		return nil, errors.New("invalid arguments - types and values do not match")
 	}
+	argTypes, argValues, err := fixedArgsToInt(argTypes, argValues)
+	if err != nil {
+		return nil, err
+	}
 	args, err := buildArgumentsFromTypes(argTypes)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build abi: %v", err)
 	}
+	argValues, err = abiTupleArgValues(args, argValues)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateAbiValues(args, argValues); err != nil {
+		return nil, err
+	}
 	return args.Pack(argValues...)
 }
 
@@ -33,15 +46,43 @@ func AbiCoderHex(argTypes []string, argValues []interface{}) (string, error) {
 	return h, nil
 }
 
-func AbiDecoder(argTypes []string, input []byte, argValues []interface{}) error {
+// AbiDecodeOptions are the options of the decoding of abi encoded data.
+type AbiDecodeOptions struct {
+	// Strict rejects the data which is not the canonical encoding of its values,
+	// ie. of dirty upper bits, offsets out of order or trailing bytes, with
+	// ErrNonCanonicalAbiEncoding. The decoded values are re-encoded and
+	// compared to the data, ie. when the data is verified by a signature.
+	Strict bool
+}
+
+// ErrNonCanonicalAbiEncoding is returned by the strict decoding of data which
+// is not the canonical encoding of its values.
+var ErrNonCanonicalAbiEncoding = errors.New("ethcoder: non-canonical abi encoding")
+
+func AbiDecoder(argTypes []string, input []byte, argValues []interface{}, opts ...AbiDecodeOptions) error {
 	if len(argTypes) != len(argValues) {
 		return errors.New("invalid arguments - types and values do not match")
 	}
+	if hasFixedTypes(argTypes) {
+		// the decimal values of the fixed-point types are set directly
+		values, err := AbiDecoderWithReturnedValues(argTypes, input, opts...)
+		if err != nil {
+			return err
+		}
+		for i, v := range values {
+			dst := reflect.ValueOf(argValues[i])
+			if dst.Kind() != reflect.Ptr || !reflect.TypeOf(v).AssignableTo(dst.Elem().Type()) {
+				return fmt.Errorf("ethcoder: value at position %d must be a *%T", i, v)
+			}
+			dst.Elem().Set(reflect.ValueOf(v))
+		}
+		return nil
+	}
 	args, err := buildArgumentsFromTypes(argTypes)
 	if err != nil {
 		return fmt.Errorf("failed to build abi: %v", err)
 	}
-	values, err := args.Unpack(input)
+	values, err := unpackAbiValues(args, input, opts)
 	if err != nil {
 		return err
 	}
@@ -52,12 +93,47 @@ func AbiDecoder(argTypes []string, input []byte, argValues []interface{}) error
 	}
 }
 
-func AbiDecoderWithReturnedValues(argTypes []string, input []byte) ([]interface{}, error) {
-	args, err := buildArgumentsFromTypes(argTypes)
+func AbiDecoderWithReturnedValues(argTypes []string, input []byte, opts ...AbiDecodeOptions) ([]interface{}, error) {
+	intTypes, _, err := fixedArgsToInt(argTypes, nil)
+	if err != nil {
+		return nil, err
+	}
+	args, err := buildArgumentsFromTypes(intTypes)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build abi: %v", err)
 	}
-	return args.UnpackValues(input)
+	values, err := unpackAbiValues(args, input, opts)
+	if err != nil {
+		return nil, err
+	}
+	return fixedValuesFromInt(argTypes, values), nil
+}
+
+// unpackAbiValues decodes the values of the args of data, which is the canonical
+// encoding of the values of the strict options.
+func unpackAbiValues(args abi.Arguments, data []byte, opts []AbiDecodeOptions) ([]interface{}, error) {
+	values, err := args.UnpackValues(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts) == 0 || !opts[0].Strict {
+		return values, nil
+	}
+	encoded, err := args.Pack(values...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNonCanonicalAbiEncoding, err)
+	}
+	if !bytes.Equal(encoded, data) {
+		if len(encoded) != len(data) {
+			return nil, fmt.Errorf("%w: data of %d bytes, expected %d", ErrNonCanonicalAbiEncoding, len(data), len(encoded))
+		}
+		for i := range data {
+			if data[i] != encoded[i] {
+				return nil, fmt.Errorf("%w: unexpected byte at %d", ErrNonCanonicalAbiEncoding, i)
+			}
+		}
+	}
+	return values, nil
 }
 
 func AbiEncodeMethodCalldata(methodExpr string, argValues []interface{}) ([]byte, error) {
@@ -65,6 +141,23 @@ func AbiEncodeMethodCalldata(methodExpr string, argValues []interface{}) ([]byte
 	if err != nil {
 		return nil, err
 	}
+	_, argsList, err := parseMethodExpr(methodExpr)
+	if err != nil {
+		return nil, err
+	}
+	argTypes := make([]string, len(argsList))
+	for i, arg := range argsList {
+		argTypes[i] = arg.Type
+	}
+	if len(argTypes) == len(argValues) {
+		_, argValues, err = fixedArgsToInt(argTypes, argValues)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := validateAbiValues(mabi.Methods[methodName].Inputs, argValues); err != nil {
+		return nil, err
+	}
 	data, err := mabi.Pack(methodName, argValues...)
 	if err != nil {
 		return nil, err
@@ -72,21 +165,32 @@ func AbiEncodeMethodCalldata(methodExpr string, argValues []interface{}) ([]byte
 	return data, nil
 }
 
+// AbiEncodeMethodCalldataFromStringValues returns the calldata of a call to the
+// method, ie. "mint(address,uint256[])", with its arguments given as strings,
+// ie. "0x..." and "[1,2,3]". The strings are converted to the types of the
+// arguments by EncodeFromStrings, where the arrays and tuples are JSON, ie.
+// "batch((address to,uint256 amount)[])" of `[{"to":"0x..","amount":1}]`.
 func AbiEncodeMethodCalldataFromStringValues(methodExpr string, argStringValues []string) ([]byte, error) {
-	_, argsList, err := parseMethodExpr(methodExpr)
-	if err != nil {
-		return nil, err
+	methodExpr = strings.TrimSpace(methodExpr)
+	idx := strings.Index(methodExpr, "(")
+	if idx < 0 || !strings.HasSuffix(methodExpr, ")") {
+		return nil, errors.New("ethcoder: invalid input expr. expected format is: methodName(arg1Type, arg2Type)")
 	}
-	argTypes := []string{}
-	for _, v := range argsList {
-		argTypes = append(argTypes, v.Type)
+	methodName := methodExpr[:idx]
+
+	argTypes := splitAbiTypes(methodExpr[idx+1 : len(methodExpr)-1])
+	sigTypes := make([]string, len(argTypes))
+	for i, typ := range argTypes {
+		typ, _ = splitAbiTypeName(typ)
+		sigTypes[i] = canonicalAbiType(typ)
 	}
 
-	argValues, err := AbiUnmarshalStringValues(argTypes, argStringValues)
+	data, err := EncodeFromStrings(argTypes, argStringValues)
 	if err != nil {
 		return nil, err
 	}
-	return AbiEncodeMethodCalldata(methodExpr, argValues)
+	selector := Keccak256([]byte(fmt.Sprintf("%s(%s)", methodName, strings.Join(sigTypes, ","))))[:4]
+	return append(selector, data...), nil
 }
 
 func AbiDecodeExpr(expr string, input []byte, argValues []interface{}) error {
@@ -163,6 +267,16 @@ func AbiUnmarshalStringValues(argTypes []string, stringValues []string) ([]inter
 			continue
 		}
 
+		// fixed-point numbers
+		if regexArgFixed.MatchString(typ) {
+			d, err := ParseDecimal(s)
+			if err != nil {
+				return nil, fmt.Errorf("ethcoder: value at position %d is invalid. expecting decimal number. reason: %w", i, err)
+			}
+			values = append(values, d)
+			continue
+		}
+
 		// numbers
 		if match := regexArgNumber.FindStringSubmatch(typ); len(match) > 0 {
 			size, err := strconv.ParseInt(match[2], 10, 64)
@@ -220,10 +334,17 @@ func AbiUnmarshalStringValues(argTypes []string, stringValues []string) ([]inter
 				}
 			}
 
-			var stringValues []string
-			err = json.Unmarshal([]byte(s), &stringValues)
+			// the elements are strings, ie. `["1","2"]`, or numbers, ie. `[1,2]`
+			var elements []json.RawMessage
+			err = json.Unmarshal([]byte(s), &elements)
 			if err != nil {
-				return nil, fmt.Errorf("ethcoder: value at position %d is invalid. failed to unmarshal json string array '%s'", i, s)
+				return nil, fmt.Errorf("ethcoder: value at position %d is invalid. failed to unmarshal json array '%s'", i, s)
+			}
+			stringValues := make([]string, len(elements))
+			for j, element := range elements {
+				if err := json.Unmarshal(element, &stringValues[j]); err != nil {
+					stringValues[j] = string(element)
+				}
 			}
 			if count > 0 && len(stringValues) != int(count) {
 				return nil, fmt.Errorf("ethcoder: value at position %d is invalid. array size does not match required size of %d", i, count)
@@ -282,6 +403,24 @@ func ParseMethodABI(methodExpr, returnsExpr string) (*abi.ABI, string, error) {
 		outputArgs = parseArgumentExpr(returnsExpr)
 	}
 
+	// the fixed-point types are parsed as their integer types, under the method
+	// signature of the fixed-point types
+	sigTypes := make([]string, len(inputArgs))
+	hasFixed := false
+	for i, arg := range inputArgs {
+		sigTypes[i] = canonicalFixedType(arg.Type)
+	}
+	for _, list := range [][]abiArgument{inputArgs, outputArgs} {
+		for i, arg := range list {
+			intTypes, _, err := fixedArgsToInt([]string{arg.Type}, nil)
+			if err != nil {
+				return nil, "", err
+			}
+			hasFixed = hasFixed || intTypes[0] != arg.Type
+			list[i].Type = intTypes[0]
+		}
+	}
+
 	// generate method abi json for parsing
 	methodABI := abiJSON{
 		Name:    methodName,
@@ -300,13 +439,237 @@ func ParseMethodABI(methodExpr, returnsExpr string) (*abi.ABI, string, error) {
 		return nil, methodName, err
 	}
 
+	if hasFixed {
+		method := mabi.Methods[methodName]
+		method.Sig = fmt.Sprintf("%s(%s)", methodName, strings.Join(sigTypes, ","))
+		method.ID = Keccak256([]byte(method.Sig))[:4]
+		mabi.Methods[methodName] = method
+	}
+
 	return &mabi, methodName, nil
 }
 
+// validateAbiValues returns an error when a *big.Int value is out of the range of
+// its number type, which abi packing otherwise silently wraps.
+func validateAbiValues(args abi.Arguments, values []interface{}) error {
+	if len(args) != len(values) {
+		return nil // reported by Pack
+	}
+	for i, arg := range args {
+		if err := validateAbiValue(arg.Type, reflect.ValueOf(values[i])); err != nil {
+			return fmt.Errorf("ethcoder: value at position %d is invalid: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func validateAbiValue(typ abi.Type, v reflect.Value) error {
+	if !v.IsValid() {
+		return nil
+	}
+	switch typ.T {
+	case abi.IntTy, abi.UintTy:
+		if n, ok := v.Interface().(*big.Int); ok && n != nil {
+			return checkNumberRange(typ.String(), typ.T == abi.IntTy, typ.Size, n)
+		}
+	case abi.SliceTy, abi.ArrayTy:
+		if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+			for i := 0; i < v.Len(); i++ {
+				if err := validateAbiValue(*typ.Elem, v.Index(i)); err != nil {
+					return fmt.Errorf("element %d: %w", i, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// abiTupleArgValues returns the values of the tuple arguments, and of their arrays,
+// as the structs of their abi types, see abiTupleValue.
+func abiTupleArgValues(args abi.Arguments, values []interface{}) ([]interface{}, error) {
+	if len(args) != len(values) {
+		return values, nil // reported by Pack
+	}
+	var out []interface{}
+	for i, arg := range args {
+		if !hasAbiTuple(arg.Type) {
+			continue
+		}
+		if out == nil {
+			out = append([]interface{}{}, values...)
+		}
+		v, err := abiTupleValue(arg.Type, reflect.ValueOf(values[i]))
+		if err != nil {
+			return nil, fmt.Errorf("ethcoder: value at position %d is invalid: %w", i, err)
+		}
+		out[i] = v.Interface()
+	}
+	if out == nil {
+		return values, nil
+	}
+	return out, nil
+}
+
+// abiTupleValue returns the value of a tuple type, or of an array of tuples, as
+// the Go type of typ, ie. abi.Type.GetType. The values of a tuple are a slice or
+// array of its values, a map of its values by name, or a struct, see
+// abiTupleFields.
+func abiTupleValue(typ abi.Type, v reflect.Value) (reflect.Value, error) {
+	goType := typ.GetType()
+	for v.IsValid() && v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return v, fmt.Errorf("nil value for type %s", typ)
+	}
+	if v.Type() == goType {
+		return v, nil
+	}
+
+	switch typ.T {
+	case abi.TupleTy:
+		fields, err := abiTupleFields(typ.TupleRawNames, v)
+		if err != nil {
+			return v, err
+		}
+		out := reflect.New(goType).Elem()
+		for i, field := range fields {
+			fv, err := abiTupleValue(*typ.TupleElems[i], field)
+			if err != nil {
+				return v, fmt.Errorf("field %d: %w", i, err)
+			}
+			if err := setAbiField(out.Field(i), fv); err != nil {
+				return v, fmt.Errorf("field %d: %w", i, err)
+			}
+		}
+		return out, nil
+
+	case abi.SliceTy, abi.ArrayTy:
+		if !hasAbiTuple(typ) {
+			return v, nil
+		}
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return v, fmt.Errorf("expecting a slice or array for type %s, got %s", typ, v.Type())
+		}
+		var out reflect.Value
+		if typ.T == abi.SliceTy {
+			out = reflect.MakeSlice(goType, v.Len(), v.Len())
+		} else {
+			if v.Len() != typ.Size {
+				return v, fmt.Errorf("expecting %d elements for type %s, got %d", typ.Size, typ, v.Len())
+			}
+			out = reflect.New(goType).Elem()
+		}
+		for i := 0; i < v.Len(); i++ {
+			ev, err := abiTupleValue(*typ.Elem, v.Index(i))
+			if err != nil {
+				return v, fmt.Errorf("element %d: %w", i, err)
+			}
+			out.Index(i).Set(ev)
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+// abiTupleFields returns the values of a tuple of the component names. The
+// value is a slice or array of the values in order, a map of the values by
+// name, or a struct, of the fields named by the component names as in
+// AbiDecodeInto, or of its exported fields in order when the components are
+// unnamed.
+func abiTupleFields(names []string, v reflect.Value) ([]reflect.Value, error) {
+	for v.IsValid() && (v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr) {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, fmt.Errorf("nil tuple value")
+	}
+
+	fields := make([]reflect.Value, len(names))
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Len() != len(names) {
+			return nil, fmt.Errorf("expecting %d tuple values, got %d", len(names), v.Len())
+		}
+		for i := range names {
+			fields[i] = v.Index(i)
+		}
+
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("expecting a map of string keys for a tuple, got %s", v.Type())
+		}
+		if v.Len() != len(names) {
+			return nil, fmt.Errorf("expecting %d tuple values, got %d", len(names), v.Len())
+		}
+		for i, name := range names {
+			fields[i] = v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+			if !fields[i].IsValid() {
+				return nil, fmt.Errorf("missing tuple value '%s'", name)
+			}
+		}
+
+	case reflect.Struct:
+		structFields := abiStructFields(v.Type())
+		if !isNamedAbiTuple(names) {
+			if len(structFields) != len(names) {
+				return nil, fmt.Errorf("expecting %d tuple values, %s has %d exported fields", len(names), v.Type(), len(structFields))
+			}
+			for i, field := range structFields {
+				fields[i] = v.Field(field.index)
+			}
+			break
+		}
+		for i, name := range names {
+			for _, field := range structFields {
+				if field.tag == name || (field.tag == "" && field.name == abi.ToCamelCase(name)) {
+					fields[i] = v.Field(field.index)
+					break
+				}
+			}
+			if !fields[i].IsValid() {
+				return nil, fmt.Errorf("missing field of tuple value '%s' in %s", name, v.Type())
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("expecting a slice, map or struct for a tuple, got %s", v.Type())
+	}
+	return fields, nil
+}
+
+// setAbiField sets the value to a field of the struct of a tuple type.
+func setAbiField(dst reflect.Value, v reflect.Value) error {
+	for v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	switch {
+	case !v.IsValid():
+		return fmt.Errorf("nil value, expecting %s", dst.Type())
+	case v.Type().AssignableTo(dst.Type()):
+		dst.Set(v)
+	case v.Kind() == dst.Kind() && v.Type().ConvertibleTo(dst.Type()):
+		dst.Set(v.Convert(dst.Type()))
+	default:
+		return fmt.Errorf("expecting %s, got %s", dst.Type(), v.Type())
+	}
+	return nil
+}
+
+func hasAbiTuple(typ abi.Type) bool {
+	switch typ.T {
+	case abi.TupleTy:
+		return true
+	case abi.SliceTy, abi.ArrayTy:
+		return hasAbiTuple(*typ.Elem)
+	}
+	return false
+}
+
 func buildArgumentsFromTypes(argTypes []string) (abi.Arguments, error) {
 	args := abi.Arguments{}
 	for _, argType := range argTypes {
-		abiType, err := abi.NewType(argType, "", nil)
+		abiType, err := parseAbiType(argType)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,317 @@
+package ethcoder
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+)
+
+// AbiCursor is a lazy decoder of abi encoded data, ie. of the arguments of very
+// large calldata such as the batches of rollups. A cursor is a value of the data,
+// of which the elements of tuples and arrays are walked to with Index, Field and
+// Each, and only the values asked for with Value or Bytes are decoded. The data
+// is never copied, and must not be modified while it's walked.
+type AbiCursor struct {
+	typ  abi.Type
+	data []byte
+
+	// loc is the position of the encoding of the value in data
+	loc int
+
+	// args is set for the cursor of the arguments, of which typ is the tuple
+	args bool
+}
+
+// NewAbiCursor returns the cursor of the arguments of the data of argTypes, with
+// their names, ie. "address to" or "(address to,uint256 amount)[] transfers".
+func NewAbiCursor(argTypes []string, data []byte) (*AbiCursor, error) {
+	args := make(abi.Arguments, len(argTypes))
+	for i, argType := range argTypes {
+		typ, name := splitAbiTypeName(argType)
+		abiType, err := parseAbiType(typ)
+		if err != nil {
+			return nil, fmt.Errorf("ethcoder: invalid type %q: %w", argType, err)
+		}
+		args[i] = abi.Argument{Name: name, Type: abiType}
+	}
+	return newArgsCursor(args, data), nil
+}
+
+// NewCalldataCursor returns the cursor of the arguments of calldata of the
+// method.
+func NewCalldataCursor(method abi.Method, calldata []byte) (*AbiCursor, error) {
+	if len(calldata) < 4 || !bytes.Equal(calldata[:4], method.ID) {
+		return nil, fmt.Errorf("ethcoder: calldata is not of method %s", method.Sig)
+	}
+	return newArgsCursor(method.Inputs, calldata[4:]), nil
+}
+
+func newArgsCursor(args abi.Arguments, data []byte) *AbiCursor {
+	typ := abi.Type{T: abi.TupleTy, TupleElems: make([]*abi.Type, len(args)), TupleRawNames: make([]string, len(args))}
+	for i := range args {
+		typ.TupleElems[i] = &args[i].Type
+		typ.TupleRawNames[i] = args[i].Name
+	}
+	return &AbiCursor{typ: typ, data: data, args: true}
+}
+
+// Type returns the type of the value of the cursor, which is a tuple of the
+// arguments for the cursor of the arguments.
+func (c *AbiCursor) Type() abi.Type {
+	return c.typ
+}
+
+// Len returns the number of elements of a tuple or an array.
+func (c *AbiCursor) Len() (int, error) {
+	switch c.typ.T {
+	case abi.TupleTy:
+		return len(c.typ.TupleElems), nil
+	case abi.ArrayTy:
+		return c.typ.Size, nil
+	case abi.SliceTy:
+		n, err := c.readLength(c.loc, abiHeadSize(*c.typ.Elem))
+		if err != nil {
+			return 0, err
+		}
+		return n, nil
+	}
+	return 0, fmt.Errorf("ethcoder: %s has no elements", c.typ)
+}
+
+// Index returns the cursor of the element i of a tuple or an array.
+func (c *AbiCursor) Index(i int) (*AbiCursor, error) {
+	n, err := c.Len()
+	if err != nil {
+		return nil, err
+	}
+	if i < 0 || i >= n {
+		return nil, fmt.Errorf("ethcoder: index %d out of range of %s of %d elements", i, c.typ, n)
+	}
+
+	base := c.loc
+	var elem abi.Type
+	var head int
+	switch c.typ.T {
+	case abi.TupleTy:
+		elem = *c.typ.TupleElems[i]
+		for _, e := range c.typ.TupleElems[:i] {
+			head += abiHeadSize(*e)
+		}
+	case abi.SliceTy:
+		// the elements follow the length
+		base += 32
+		fallthrough
+	default:
+		elem = *c.typ.Elem
+		head = i * abiHeadSize(elem)
+	}
+
+	loc := base + head
+	if abiIsDynamic(elem) {
+		offset, err := c.readOffset(loc)
+		if err != nil {
+			return nil, err
+		}
+		loc = base + offset
+	}
+	return &AbiCursor{typ: elem, data: c.data, loc: loc}, nil
+}
+
+// Field returns the cursor of the element of a tuple of the name.
+func (c *AbiCursor) Field(name string) (*AbiCursor, error) {
+	if c.typ.T == abi.TupleTy {
+		for i, n := range c.typ.TupleRawNames {
+			if n == name {
+				return c.Index(i)
+			}
+		}
+	}
+	return nil, fmt.Errorf("ethcoder: %s has no field %q", c.typ, name)
+}
+
+// Each calls fn with the cursors of the elements of a tuple or an array in order,
+// until it returns an error.
+func (c *AbiCursor) Each(fn func(i int, elem *AbiCursor) error) error {
+	n, err := c.Len()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		elem, err := c.Index(i)
+		if err != nil {
+			return err
+		}
+		if err := fn(i, elem); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Bytes returns the contents of a bytes or string value, as a slice of the data.
+func (c *AbiCursor) Bytes() ([]byte, error) {
+	if c.typ.T != abi.BytesTy && c.typ.T != abi.StringTy {
+		return nil, fmt.Errorf("ethcoder: %s is not bytes", c.typ)
+	}
+	n, err := c.readLength(c.loc, 1)
+	if err != nil {
+		return nil, err
+	}
+	return c.data[c.loc+32 : c.loc+32+n], nil
+}
+
+// Value decodes the value of the cursor, of the Go type of the type as of
+// go-ethereum. The value of the cursor of the arguments is their values.
+func (c *AbiCursor) Value() (interface{}, error) {
+	if c.args {
+		n, _ := c.Len()
+		values := make([]interface{}, n)
+		for i := range values {
+			elem, err := c.Index(i)
+			if err != nil {
+				return nil, err
+			}
+			if values[i], err = elem.Value(); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+
+	if !abiIsDynamic(c.typ) {
+		size := abiHeadSize(c.typ)
+		if c.loc+size > len(c.data) {
+			return nil, c.errShort(c.loc)
+		}
+		values, err := (abi.Arguments{{Type: c.typ}}).UnpackValues(c.data[c.loc : c.loc+size])
+		if err != nil {
+			return nil, fmt.Errorf("ethcoder: failed to decode %s: %w", c.typ, err)
+		}
+		return values[0], nil
+	}
+
+	switch c.typ.T {
+	case abi.BytesTy, abi.StringTy:
+		b, err := c.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		if c.typ.T == abi.StringTy {
+			return string(b), nil
+		}
+		return append([]byte{}, b...), nil
+
+	case abi.TupleTy:
+		v := reflect.New(c.typ.TupleType).Elem()
+		err := c.Each(func(i int, elem *AbiCursor) error {
+			return elem.setValue(v.Field(i))
+		})
+		if err != nil {
+			return nil, err
+		}
+		return v.Interface(), nil
+
+	default:
+		n, err := c.Len()
+		if err != nil {
+			return nil, err
+		}
+		v := reflect.New(c.typ.GetType()).Elem()
+		if c.typ.T == abi.SliceTy {
+			v = reflect.MakeSlice(c.typ.GetType(), n, n)
+		}
+		err = c.Each(func(i int, elem *AbiCursor) error {
+			return elem.setValue(v.Index(i))
+		})
+		if err != nil {
+			return nil, err
+		}
+		return v.Interface(), nil
+	}
+}
+
+func (c *AbiCursor) setValue(dst reflect.Value) error {
+	value, err := c.Value()
+	if err != nil {
+		return err
+	}
+	dst.Set(reflect.ValueOf(value))
+	return nil
+}
+
+// readOffset reads the offset at pos, which is within the data.
+func (c *AbiCursor) readOffset(pos int) (int, error) {
+	word, err := c.readWord(pos)
+	if err != nil {
+		return 0, err
+	}
+	offset := new(big.Int).SetBytes(word)
+	if !offset.IsInt64() || offset.Int64() > int64(len(c.data)) {
+		return 0, fmt.Errorf("ethcoder: invalid offset %s at %d", offset, pos)
+	}
+	return int(offset.Int64()), nil
+}
+
+// readLength reads the length at pos of the elements of size which follow it,
+// which are within the data.
+func (c *AbiCursor) readLength(pos int, size int) (int, error) {
+	word, err := c.readWord(pos)
+	if err != nil {
+		return 0, err
+	}
+	length := new(big.Int).SetBytes(word)
+	if !length.IsInt64() || length.Int64() > math.MaxInt32 || int64(pos+32)+length.Int64()*int64(size) > int64(len(c.data)) {
+		return 0, fmt.Errorf("ethcoder: invalid length %s at %d", length, pos)
+	}
+	return int(length.Int64()), nil
+}
+
+func (c *AbiCursor) readWord(pos int) ([]byte, error) {
+	if pos < 0 || pos+32 > len(c.data) {
+		return nil, c.errShort(pos)
+	}
+	return c.data[pos : pos+32], nil
+}
+
+func (c *AbiCursor) errShort(pos int) error {
+	return fmt.Errorf("ethcoder: abi data of %d bytes is too short for %s at %d", len(c.data), c.typ, pos)
+}
+
+// abiIsDynamic returns whether the encoding of typ is at an offset of its head.
+func abiIsDynamic(typ abi.Type) bool {
+	switch typ.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy:
+		return true
+	case abi.ArrayTy:
+		return abiIsDynamic(*typ.Elem)
+	case abi.TupleTy:
+		for _, elem := range typ.TupleElems {
+			if abiIsDynamic(*elem) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// abiHeadSize returns the size of typ in the head of a tuple or an array.
+func abiHeadSize(typ abi.Type) int {
+	if abiIsDynamic(typ) {
+		return 32
+	}
+	switch typ.T {
+	case abi.ArrayTy:
+		return typ.Size * abiHeadSize(*typ.Elem)
+	case abi.TupleTy:
+		size := 0
+		for _, elem := range typ.TupleElems {
+			size += abiHeadSize(*elem)
+		}
+		return size
+	}
+	return 32
+}
@@ -0,0 +1,135 @@
+package ethcoder_test
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAbiCursor(t *testing.T) {
+	argTypes := []string{"uint256 id", "(address to,bytes data)[] calls", "string memo", "uint8[2] flags", "(uint64,bytes32)[2] proofs"}
+	calls := `[["0x1111111111111111111111111111111111111111", "0x0102"], ["0x2222222222222222222222222222222222222222", "0x"]]`
+	proofs := `[[1, "0x0000000000000000000000000000000000000000000000000000000000000001"], [2, "0x0000000000000000000000000000000000000000000000000000000000000002"]]`
+	data, err := ethcoder.EncodeFromStrings(argTypes, []string{"42", calls, "hello", "[3, 4]", proofs})
+	require.NoError(t, err)
+
+	cursor, err := ethcoder.NewAbiCursor(argTypes, data)
+	require.NoError(t, err)
+	n, err := cursor.Len()
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+
+	id, err := cursor.Field("id")
+	require.NoError(t, err)
+	v, err := id.Value()
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(42), v)
+
+	callsCursor, err := cursor.Field("calls")
+	require.NoError(t, err)
+	n, err = callsCursor.Len()
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	var tos []common.Address
+	var datas [][]byte
+	err = callsCursor.Each(func(i int, call *ethcoder.AbiCursor) error {
+		to, err := call.Field("to")
+		if err != nil {
+			return err
+		}
+		v, err := to.Value()
+		if err != nil {
+			return err
+		}
+		tos = append(tos, v.(common.Address))
+
+		data, err := call.Field("data")
+		if err != nil {
+			return err
+		}
+		b, err := data.Bytes()
+		datas = append(datas, b)
+		return err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []common.Address{common.HexToAddress("0x1111111111111111111111111111111111111111"), common.HexToAddress("0x2222222222222222222222222222222222222222")}, tos)
+	assert.Equal(t, [][]byte{{0x01, 0x02}, {}}, datas)
+
+	memo, err := cursor.Field("memo")
+	require.NoError(t, err)
+	v, err = memo.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "hello", v)
+
+	proof, err := cursor.Index(4)
+	require.NoError(t, err)
+	proof, err = proof.Index(1)
+	require.NoError(t, err)
+	field, err := proof.Index(0)
+	require.NoError(t, err)
+	v, err = field.Value()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), v)
+
+	// the values of the cursor are the ones of go-ethereum
+	expected, err := ethcoder.AbiDecoderWithReturnedValues(argTypes, data)
+	require.NoError(t, err)
+	values, err := cursor.Value()
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%v", expected), fmt.Sprintf("%v", values))
+	v, err = callsCursor.Value()
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%v", expected[1]), fmt.Sprintf("%v", v))
+
+	t.Run("calldata", func(t *testing.T) {
+		method, err := ethcoder.ParseABIFunction("function submit(bytes[] batches)")
+		require.NoError(t, err)
+		calldata, err := ethcoder.AbiEncodeMethodCalldataFromStringValues("submit(bytes[] batches)", []string{`["0xaa", "0xbbcc"]`})
+		require.NoError(t, err)
+
+		cursor, err := ethcoder.NewCalldataCursor(*method, calldata)
+		require.NoError(t, err)
+		batches, err := cursor.Field("batches")
+		require.NoError(t, err)
+		batch, err := batches.Index(1)
+		require.NoError(t, err)
+		b, err := batch.Bytes()
+		require.NoError(t, err)
+		assert.Equal(t, []byte{0xbb, 0xcc}, b)
+
+		_, err = batches.Index(2)
+		assert.Error(t, err)
+		_, err = ethcoder.NewCalldataCursor(*method, common.FromHex("0xa9059cbb"))
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		cursor, err := ethcoder.NewAbiCursor(argTypes, data[:100])
+		require.NoError(t, err)
+		_, err = cursor.Field("calls")
+		assert.Error(t, err)
+		_, err = cursor.Value()
+		assert.Error(t, err)
+
+		// a length beyond the data
+		bad := append([]byte{}, data...)
+		bad[288+31] = 0xff
+		cursor, err = ethcoder.NewAbiCursor(argTypes, bad)
+		require.NoError(t, err)
+		callsCursor, err := cursor.Field("calls")
+		require.NoError(t, err)
+		_, err = callsCursor.Len()
+		assert.Error(t, err)
+
+		_, err = id.Bytes()
+		assert.Error(t, err)
+		_, err = cursor.Field("unknown")
+		assert.Error(t, err)
+	})
+}
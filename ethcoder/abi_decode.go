@@ -0,0 +1,206 @@
+package ethcoder
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+)
+
+// AbiDecodeInto decodes the data of argTypes into the fields of the struct out
+// points to. The types are of EncodeFromStrings, with their names, ie.
+// "address owner" or "(address to,uint256 amount)[] transfers", and a value is
+// set to the field of its name tag, ie. `abi:"owner"`, or else of its name in
+// camel case, ie. Owner. The values of unnamed types are set to the exported
+// fields in order. Tuples are set to structs, or slices and arrays of structs,
+// the same way.
+func AbiDecodeInto(argTypes []string, data []byte, out interface{}, opts ...AbiDecodeOptions) error {
+	dst := reflect.ValueOf(out)
+	if dst.Kind() != reflect.Ptr || dst.IsNil() || dst.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("ethcoder: expecting a pointer to a struct, got %T", out)
+	}
+
+	args := make(abi.Arguments, len(argTypes))
+	names := make([]string, len(argTypes))
+	for i, argType := range argTypes {
+		typ, name := splitAbiTypeName(argType)
+		abiType, err := parseAbiType(typ)
+		if err != nil {
+			return fmt.Errorf("ethcoder: invalid type %q: %w", argType, err)
+		}
+		args[i] = abi.Argument{Name: name, Type: abiType}
+		names[i] = name
+	}
+	values, err := unpackAbiValues(args, data, opts)
+	if err != nil {
+		return fmt.Errorf("ethcoder: failed to decode: %w", err)
+	}
+
+	types := make([]*abi.Type, len(args))
+	srcs := make([]reflect.Value, len(values))
+	for i := range args {
+		types[i] = &args[i].Type
+		srcs[i] = reflect.ValueOf(values[i])
+	}
+	if err := setAbiTuple(dst.Elem(), names, types, srcs, ""); err != nil {
+		return fmt.Errorf("ethcoder: %w", err)
+	}
+	return nil
+}
+
+// setAbiTuple sets the values of a tuple to the fields of the struct dst.
+func setAbiTuple(dst reflect.Value, names []string, types []*abi.Type, values []reflect.Value, path string) error {
+	fields := abiStructFields(dst.Type())
+
+	if !isNamedAbiTuple(names) {
+		if len(fields) != len(values) {
+			return fmt.Errorf("%s has %d values, %s has %d exported fields", abiValuePath(path, "tuple"), len(values), dst.Type(), len(fields))
+		}
+		for i, field := range fields {
+			if err := setAbiValue(dst.Field(field.index), types[i], values[i], joinAbiValuePath(path, fmt.Sprint(i))); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, field := range fields {
+		if field.tag == "" {
+			continue
+		}
+		found := false
+		for _, name := range names {
+			found = found || name == field.tag
+		}
+		if !found {
+			return fmt.Errorf("field %s of %s has tag %q which is not a value of %s", field.name, dst.Type(), field.tag, abiValuePath(path, "tuple"))
+		}
+	}
+	for i, name := range names {
+		var match *abiStructField
+		for j := range fields {
+			if fields[j].tag == name {
+				match = &fields[j]
+				break
+			}
+		}
+		if match == nil {
+			for j := range fields {
+				if fields[j].tag == "" && fields[j].name == abi.ToCamelCase(name) {
+					match = &fields[j]
+					break
+				}
+			}
+		}
+		if match == nil {
+			// the values without a field are skipped
+			continue
+		}
+		if err := setAbiValue(dst.Field(match.index), types[i], values[i], joinAbiValuePath(path, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setAbiValue sets a decoded value of typ to dst.
+func setAbiValue(dst reflect.Value, typ *abi.Type, src reflect.Value, path string) error {
+	switch {
+	case dst.Kind() == reflect.Interface && dst.NumMethod() == 0:
+		dst.Set(src)
+		return nil
+	case dst.Kind() == reflect.Ptr && src.Type() != dst.Type():
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return setAbiValue(dst.Elem(), typ, src, path)
+	}
+
+	switch typ.T {
+	case abi.TupleTy:
+		if dst.Kind() != reflect.Struct {
+			break
+		}
+		types := typ.TupleElems
+		values := make([]reflect.Value, len(types))
+		for i := range types {
+			values[i] = src.Field(i)
+		}
+		return setAbiTuple(dst, typ.TupleRawNames, types, values, path)
+
+	case abi.SliceTy, abi.ArrayTy:
+		if typ.Elem.T != abi.TupleTy && src.Type().AssignableTo(dst.Type()) {
+			break
+		}
+		switch dst.Kind() {
+		case reflect.Slice:
+			dst.Set(reflect.MakeSlice(dst.Type(), src.Len(), src.Len()))
+		case reflect.Array:
+			if dst.Len() != src.Len() {
+				return fmt.Errorf("%s has %d values, %s has %d", path, src.Len(), dst.Type(), dst.Len())
+			}
+		default:
+			return fmt.Errorf("can't set %s of type %s to %s", path, typ, dst.Type())
+		}
+		for i := 0; i < src.Len(); i++ {
+			if err := setAbiValue(dst.Index(i), typ.Elem, src.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	switch {
+	case src.Type().AssignableTo(dst.Type()):
+		dst.Set(src)
+	case src.Kind() == dst.Kind() && src.Type().ConvertibleTo(dst.Type()):
+		// ie. to named types of the same kind, such as ethkit.Address
+		dst.Set(src.Convert(dst.Type()))
+	default:
+		return fmt.Errorf("can't set %s of type %s to %s", path, typ, dst.Type())
+	}
+	return nil
+}
+
+// isNamedAbiTuple returns whether any of the components of a tuple is named,
+// where the unnamed components of parseAbiType are named by their position.
+func isNamedAbiTuple(names []string) bool {
+	for i, name := range names {
+		if name != "" && name != fmt.Sprintf("field%d", i) {
+			return true
+		}
+	}
+	return false
+}
+
+type abiStructField struct {
+	index int
+	name  string
+	tag   string
+}
+
+func abiStructFields(typ reflect.Type) []abiStructField {
+	var fields []abiStructField
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fields = append(fields, abiStructField{index: i, name: field.Name, tag: field.Tag.Get("abi")})
+	}
+	return fields
+}
+
+func joinAbiValuePath(path, name string) string {
+	if path == "" {
+		return "value " + name
+	}
+	return path + "." + name
+}
+
+func abiValuePath(path, root string) string {
+	if path == "" {
+		return root
+	}
+	return path
+}
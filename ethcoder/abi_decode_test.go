@@ -0,0 +1,114 @@
+package ethcoder_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit"
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAbiDecodeInto(t *testing.T) {
+	owner := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	type Transfer struct {
+		Recipient ethkit.Address `abi:"to"`
+		Amount    *big.Int
+	}
+	type Order struct {
+		Owner     common.Address
+		Nonce     uint64 `abi:"nonce"`
+		Transfers []Transfer
+		Fee       *Transfer `abi:"fee"`
+		Memo      string
+		ignored   string
+	}
+
+	data, err := ethcoder.EncodeFromStrings(
+		[]string{"address", "uint64", "(address to,uint256 amount)[]", "(address to,uint256 amount)", "string"},
+		[]string{owner.Hex(), "7", `[{"to": "` + to.Hex() + `", "amount": 5}, {"to": "` + owner.Hex() + `", "amount": 6}]`, `{"to": "` + to.Hex() + `", "amount": 1}`, `"hello"`},
+	)
+	require.NoError(t, err)
+
+	var order Order
+	err = ethcoder.AbiDecodeInto([]string{"address owner", "uint64 nonce", "(address to,uint256 amount)[] transfers", "(address to,uint256 amount) fee", "string memo"}, data, &order)
+	require.NoError(t, err)
+	assert.Equal(t, Order{
+		Owner:     owner,
+		Nonce:     7,
+		Transfers: []Transfer{{Recipient: to, Amount: big.NewInt(5)}, {Recipient: owner, Amount: big.NewInt(6)}},
+		Fee:       &Transfer{Recipient: to, Amount: big.NewInt(1)},
+		Memo:      "hello",
+	}, order)
+
+	// the unnamed values are set in order, and the values without fields are skipped
+	var positional struct {
+		Owner common.Address
+		Nonce interface{}
+	}
+	err = ethcoder.AbiDecodeInto([]string{"address", "uint64"}, data[:64], &positional)
+	require.NoError(t, err)
+	assert.Equal(t, owner, positional.Owner)
+	assert.Equal(t, uint64(7), positional.Nonce)
+
+	var partial struct {
+		Memo string
+	}
+	require.NoError(t, ethcoder.AbiDecodeInto([]string{"address owner", "uint64 nonce", "(address to,uint256 amount)[] transfers", "(address to,uint256 amount) fee", "string memo"}, data, &partial))
+	assert.Equal(t, "hello", partial.Memo)
+
+	// the errors
+	var wrongType struct {
+		Owner string
+	}
+	assert.Error(t, ethcoder.AbiDecodeInto([]string{"address owner"}, data[:32], &wrongType))
+	var wrongTag struct {
+		Owner common.Address `abi:"sender"`
+	}
+	assert.Error(t, ethcoder.AbiDecodeInto([]string{"address owner"}, data[:32], &wrongTag))
+	assert.Error(t, ethcoder.AbiDecodeInto([]string{"address owner"}, data[:32], order))
+	assert.Error(t, ethcoder.AbiDecodeInto([]string{"uint256 owner"}, data[:16], &order))
+}
+
+func TestAbiDecodeStrict(t *testing.T) {
+	data, err := ethcoder.AbiCoder([]string{"address", "bytes"}, []interface{}{common.HexToAddress("0x1111111111111111111111111111111111111111"), []byte{1, 2}})
+	require.NoError(t, err)
+
+	var to common.Address
+	var b []byte
+	strict := ethcoder.AbiDecodeOptions{Strict: true}
+	require.NoError(t, ethcoder.AbiDecoder([]string{"address", "bytes"}, data, []interface{}{&to, &b}, strict))
+	assert.Equal(t, []byte{1, 2}, b)
+
+	// the dirty upper bits of the address
+	dirty := append([]byte{}, data...)
+	dirty[0] = 0xff
+	require.NoError(t, ethcoder.AbiDecoder([]string{"address", "bytes"}, dirty, []interface{}{&to, &b}))
+	err = ethcoder.AbiDecoder([]string{"address", "bytes"}, dirty, []interface{}{&to, &b}, strict)
+	assert.ErrorIs(t, err, ethcoder.ErrNonCanonicalAbiEncoding)
+	assert.ErrorContains(t, err, "unexpected byte at 0")
+
+	// the offset of the bytes after a gap
+	gap := append(append(append([]byte{}, data[:32]...), common.LeftPadBytes([]byte{0x60}, 32)...), make([]byte, 32)...)
+	gap = append(gap, data[64:]...)
+	values, err := ethcoder.AbiDecoderWithReturnedValues([]string{"address", "bytes"}, gap)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1, 2}, values[1])
+	_, err = ethcoder.AbiDecoderWithReturnedValues([]string{"address", "bytes"}, gap, strict)
+	assert.ErrorIs(t, err, ethcoder.ErrNonCanonicalAbiEncoding)
+
+	// the trailing bytes
+	var out struct {
+		To   common.Address
+		Data []byte
+	}
+	trailing := append(append([]byte{}, data...), 0)
+	require.NoError(t, ethcoder.AbiDecodeInto([]string{"address to", "bytes data"}, trailing, &out))
+	err = ethcoder.AbiDecodeInto([]string{"address to", "bytes data"}, trailing, &out, strict)
+	assert.ErrorIs(t, err, ethcoder.ErrNonCanonicalAbiEncoding)
+	require.NoError(t, ethcoder.AbiDecodeInto([]string{"address to", "bytes data"}, data, &out, strict))
+}
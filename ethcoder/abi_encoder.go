@@ -0,0 +1,331 @@
+package ethcoder
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// AbiEncoder is a reusable encoder of the values of argTypes, ie. for the hot
+// loops of indexers, which encodes the elementary types without allocating once
+// its buffer has grown. The values are appended in the order of the types, and
+// the encoding is returned by Finish, then the encoder is Reset for the next one:
+//
+//	enc.Reset()
+//	enc.Address(to).BigInt(amount)
+//	data, err := enc.Finish()
+//
+// The arrays and tuples are encoded with Value, which allocates. An encoder is
+// not safe for concurrent use, see AbiEncoderPool.
+type AbiEncoder struct {
+	types  []abi.Type
+	prefix []byte
+	heads  []int
+
+	buf     []byte
+	next    int
+	err     error
+	scratch big.Int
+}
+
+// NewAbiEncoder returns an encoder of the values of argTypes, as of AbiCoder.
+func NewAbiEncoder(argTypes []string) (*AbiEncoder, error) {
+	types := make([]abi.Type, len(argTypes))
+	for i, argType := range argTypes {
+		typ, _ := splitAbiTypeName(argType)
+		abiType, err := parseAbiType(typ)
+		if err != nil {
+			return nil, fmt.Errorf("ethcoder: invalid type %q: %w", argType, err)
+		}
+		types[i] = abiType
+	}
+	return newAbiEncoder(types, nil), nil
+}
+
+// NewCalldataEncoder returns an encoder of the calldata of the method of the
+// human-readable signature, ie. "transfer(address,uint256)".
+func NewCalldataEncoder(methodSig string) (*AbiEncoder, error) {
+	method, err := ParseABIFunction(methodSig)
+	if err != nil {
+		return nil, err
+	}
+	types := make([]abi.Type, len(method.Inputs))
+	for i, input := range method.Inputs {
+		types[i] = input.Type
+	}
+	return newAbiEncoder(types, method.ID), nil
+}
+
+func newAbiEncoder(types []abi.Type, prefix []byte) *AbiEncoder {
+	e := &AbiEncoder{types: types, prefix: prefix, heads: make([]int, len(types))}
+	head := 0
+	for i, typ := range types {
+		e.heads[i] = head
+		head += abiHeadSize(typ)
+	}
+	e.buf = make([]byte, 0, len(prefix)+head)
+	e.Reset()
+	return e
+}
+
+// Reset clears the encoder for the encoding of the next values, reusing its
+// buffer.
+func (e *AbiEncoder) Reset() {
+	size := len(e.prefix)
+	if n := len(e.types); n > 0 {
+		size += e.heads[n-1] + abiHeadSize(e.types[n-1])
+	}
+	e.buf = append(e.buf[:0], e.prefix...)
+	for len(e.buf) < size {
+		e.buf = append(e.buf, 0)
+	}
+	clear(e.buf[len(e.prefix):])
+	e.next = 0
+	e.err = nil
+}
+
+// Finish returns the encoding of the values, or the first error of the values.
+// The encoding is the buffer of the encoder, which is valid until it's Reset.
+func (e *AbiEncoder) Finish() ([]byte, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	if e.next != len(e.types) {
+		return nil, fmt.Errorf("ethcoder: encoder expects %d values, got %d", len(e.types), e.next)
+	}
+	return e.buf, nil
+}
+
+// Address appends an address value.
+func (e *AbiEncoder) Address(v common.Address) *AbiEncoder {
+	if _, ok := e.nextType(abi.AddressTy); ok {
+		copy(e.take()[12:], v[:])
+	}
+	return e
+}
+
+// Bool appends a bool value.
+func (e *AbiEncoder) Bool(v bool) *AbiEncoder {
+	if _, ok := e.nextType(abi.BoolTy); ok {
+		slot := e.take()
+		if v {
+			slot[31] = 1
+		}
+	}
+	return e
+}
+
+// Uint64 appends an integer value of any of the integer types.
+func (e *AbiEncoder) Uint64(v uint64) *AbiEncoder {
+	return e.number(e.scratch.SetUint64(v))
+}
+
+// Int64 appends an integer value of any of the integer types.
+func (e *AbiEncoder) Int64(v int64) *AbiEncoder {
+	return e.number(e.scratch.SetInt64(v))
+}
+
+// BigInt appends an integer value of any of the integer types.
+func (e *AbiEncoder) BigInt(v *big.Int) *AbiEncoder {
+	if v == nil {
+		return e.fail(fmt.Errorf("nil *big.Int value at position %d", e.next))
+	}
+	return e.number(e.scratch.Set(v))
+}
+
+// FixedBytes appends a bytesN value, of N bytes.
+func (e *AbiEncoder) FixedBytes(v []byte) *AbiEncoder {
+	typ, ok := e.nextType(abi.FixedBytesTy)
+	if !ok {
+		return e
+	}
+	if len(v) != typ.Size {
+		return e.fail(fmt.Errorf("value of %d bytes at position %d for type %s", len(v), e.next, typ))
+	}
+	copy(e.take(), v)
+	return e
+}
+
+// Bytes appends a bytes value.
+func (e *AbiEncoder) Bytes(v []byte) *AbiEncoder {
+	if _, ok := e.nextType(abi.BytesTy); ok {
+		e.putOffset(e.take())
+		e.appendWord(uint64(len(v)))
+		e.buf = append(e.buf, v...)
+		e.pad(len(v))
+	}
+	return e
+}
+
+// String appends a string value.
+func (e *AbiEncoder) String(v string) *AbiEncoder {
+	if _, ok := e.nextType(abi.StringTy); ok {
+		e.putOffset(e.take())
+		e.appendWord(uint64(len(v)))
+		e.buf = append(e.buf, v...)
+		e.pad(len(v))
+	}
+	return e
+}
+
+// Value appends a value of any type, of the Go types of AbiCoder, ie. of an
+// array or a tuple, which allocates.
+func (e *AbiEncoder) Value(v interface{}) *AbiEncoder {
+	if e.err != nil {
+		return e
+	}
+	if e.next >= len(e.types) {
+		return e.fail(fmt.Errorf("encoder expects %d values", len(e.types)))
+	}
+	args := abi.Arguments{{Type: e.types[e.next]}}
+	values, err := abiTupleArgValues(args, []interface{}{v})
+	if err != nil {
+		return e.fail(err)
+	}
+	data, err := args.Pack(values...)
+	if err != nil {
+		return e.fail(fmt.Errorf("value at position %d is invalid: %w", e.next, err))
+	}
+	if !abiIsDynamic(args[0].Type) {
+		copy(e.buf[len(e.prefix)+e.heads[e.next]:], data)
+		e.next++
+		return e
+	}
+	// the encoding of the value follows its offset
+	e.putOffset(e.take())
+	e.buf = append(e.buf, data[32:]...)
+	return e
+}
+
+// nextType returns the type of the next value, and fails when it's not of the
+// kind, where IntTy is of any integer type.
+func (e *AbiEncoder) nextType(kind byte) (abi.Type, bool) {
+	if e.err != nil {
+		return abi.Type{}, false
+	}
+	if e.next >= len(e.types) {
+		e.fail(fmt.Errorf("encoder expects %d values", len(e.types)))
+		return abi.Type{}, false
+	}
+	typ := e.types[e.next]
+	if typ.T != kind && !(kind == abi.IntTy && typ.T == abi.UintTy) {
+		e.fail(fmt.Errorf("value at position %d is not of type %s", e.next, typ))
+		return abi.Type{}, false
+	}
+	return typ, true
+}
+
+// take returns the head slot of the next value.
+func (e *AbiEncoder) take() []byte {
+	pos := len(e.prefix) + e.heads[e.next]
+	e.next++
+	return e.buf[pos : pos+32]
+}
+
+// number appends the integer n, which is the scratch of the encoder.
+func (e *AbiEncoder) number(n *big.Int) *AbiEncoder {
+	typ, ok := e.nextType(abi.IntTy)
+	if !ok {
+		return e
+	}
+	// the range of checkNumberRange, without allocating
+	inRange := n.Sign() >= 0 && n.BitLen() <= typ.Size
+	if typ.T == abi.IntTy {
+		// -2^(size-1) <= n < 2^(size-1)
+		inRange = n.BitLen() < typ.Size || (n.Sign() < 0 && n.BitLen() == typ.Size && int(n.TrailingZeroBits()) == typ.Size-1)
+	}
+	if !inRange {
+		return e.fail(checkNumberRange(typ.String(), typ.T == abi.IntTy, typ.Size, n))
+	}
+	if n.Sign() < 0 {
+		// two's complement
+		n.Add(n, tt256)
+	}
+	n.FillBytes(e.take())
+	return e
+}
+
+var tt256 = new(big.Int).Lsh(big.NewInt(1), 256)
+
+// putOffset sets the offset of the slot to the end of the encoding.
+func (e *AbiEncoder) putOffset(slot []byte) {
+	putUint64Word(slot, uint64(len(e.buf)-len(e.prefix)))
+}
+
+func (e *AbiEncoder) appendWord(v uint64) {
+	pos := len(e.buf)
+	e.buf = append(e.buf, zeroWord[:]...)
+	putUint64Word(e.buf[pos:], v)
+}
+
+func (e *AbiEncoder) pad(n int) {
+	if r := n % 32; r != 0 {
+		e.buf = append(e.buf, zeroWord[:32-r]...)
+	}
+}
+
+func (e *AbiEncoder) fail(err error) *AbiEncoder {
+	if e.err == nil {
+		e.err = fmt.Errorf("ethcoder: %w", err)
+	}
+	return e
+}
+
+var zeroWord [32]byte
+
+func putUint64Word(slot []byte, v uint64) {
+	clear(slot[:24])
+	for i := 0; i < 8; i++ {
+		slot[31-i] = byte(v >> (8 * i))
+	}
+}
+
+// AbiEncoderPool is a pool of the encoders of the same types, for the encoding
+// of many goroutines.
+type AbiEncoderPool struct {
+	pool sync.Pool
+}
+
+// NewAbiEncoderPool returns a pool of the encoders of argTypes, see
+// NewAbiEncoder.
+func NewAbiEncoderPool(argTypes []string) (*AbiEncoderPool, error) {
+	e, err := NewAbiEncoder(argTypes)
+	if err != nil {
+		return nil, err
+	}
+	return newAbiEncoderPool(e), nil
+}
+
+// NewCalldataEncoderPool returns a pool of the encoders of the calldata of the
+// method, see NewCalldataEncoder.
+func NewCalldataEncoderPool(methodSig string) (*AbiEncoderPool, error) {
+	e, err := NewCalldataEncoder(methodSig)
+	if err != nil {
+		return nil, err
+	}
+	return newAbiEncoderPool(e), nil
+}
+
+func newAbiEncoderPool(e *AbiEncoder) *AbiEncoderPool {
+	p := &AbiEncoderPool{}
+	p.pool.New = func() interface{} {
+		return newAbiEncoder(e.types, e.prefix)
+	}
+	p.pool.Put(e)
+	return p
+}
+
+// Get returns an encoder of the pool, which is Reset.
+func (p *AbiEncoderPool) Get() *AbiEncoder {
+	e := p.pool.Get().(*AbiEncoder)
+	e.Reset()
+	return e
+}
+
+// Put returns the encoder to the pool, after which its encoding is invalid.
+func (p *AbiEncoderPool) Put(e *AbiEncoder) {
+	p.pool.Put(e)
+}
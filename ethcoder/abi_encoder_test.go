@@ -0,0 +1,106 @@
+package ethcoder_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAbiEncoder(t *testing.T) {
+	argTypes := []string{"address", "uint256", "int24", "bool", "bytes4", "bytes", "string", "(address,uint256)[]", "uint8[2]"}
+	to := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	transfers := []interface{}{[]interface{}{to, big.NewInt(1)}}
+
+	expected, err := ethcoder.AbiCoder(argTypes, []interface{}{
+		to, big.NewInt(1000), big.NewInt(-5), true, [4]byte{1, 2, 3, 4}, []byte("hello, world"), "ethkit", transfers, [2]uint8{7, 8},
+	})
+	require.NoError(t, err)
+
+	enc, err := ethcoder.NewAbiEncoder(argTypes)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		// the same encoding of the reused encoder
+		enc.Reset()
+		data, err := enc.Address(to).BigInt(big.NewInt(1000)).Int64(-5).Bool(true).FixedBytes([]byte{1, 2, 3, 4}).
+			Bytes([]byte("hello, world")).String("ethkit").Value(transfers).Value([2]uint8{7, 8}).Finish()
+		require.NoError(t, err)
+		assert.Equal(t, ethcoder.HexEncode(expected), ethcoder.HexEncode(data))
+	}
+
+	enc.Reset()
+	_, err = enc.Address(to).Finish()
+	assert.ErrorContains(t, err, "expects 9 values, got 1")
+	enc.Reset()
+	_, err = enc.Bool(true).Finish()
+	assert.ErrorContains(t, err, "value at position 0 is not of type address")
+	enc.Reset()
+	_, err = enc.Address(to).Uint64(1).Int64(1 << 23).Finish()
+	assert.ErrorContains(t, err, "overflows type 'int24'")
+	enc.Reset()
+	_, err = enc.Address(to).Int64(-1).Finish()
+	assert.ErrorContains(t, err, "negative value")
+	enc.Reset()
+	_, err = enc.Address(to).Uint64(1).Int64(-1 << 23).Bool(true).FixedBytes([]byte{1}).Finish()
+	assert.ErrorContains(t, err, "value of 1 bytes at position 4")
+
+	t.Run("calldata", func(t *testing.T) {
+		expected, err := ethcoder.AbiEncodeMethodCalldata("transfer(address,uint256)", []interface{}{to, big.NewInt(5)})
+		require.NoError(t, err)
+
+		pool, err := ethcoder.NewCalldataEncoderPool("transfer(address to, uint256 amount)")
+		require.NoError(t, err)
+		for i := 0; i < 3; i++ {
+			enc := pool.Get()
+			data, err := enc.Address(to).Uint64(5).Finish()
+			require.NoError(t, err)
+			assert.Equal(t, expected, data)
+			pool.Put(enc)
+		}
+	})
+
+	t.Run("allocations", func(t *testing.T) {
+		enc, err := ethcoder.NewCalldataEncoder("swap(address,uint256,int128,bytes,string)")
+		require.NoError(t, err)
+		amount := new(big.Int).Lsh(big.NewInt(1), 100)
+		payload := make([]byte, 100)
+		allocs := testing.AllocsPerRun(100, func() {
+			enc.Reset()
+			_, err := enc.Address(to).BigInt(amount).Int64(-1).Bytes(payload).String("memo").Finish()
+			if err != nil {
+				panic(err)
+			}
+		})
+		assert.Zero(t, allocs)
+	})
+}
+
+func BenchmarkAbiEncoder(b *testing.B) {
+	to := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	amount := big.NewInt(1000000)
+	enc, err := ethcoder.NewCalldataEncoder("transfer(address,uint256)")
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		enc.Reset()
+		if _, err := enc.Address(to).BigInt(amount).Finish(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAbiEncodeMethodCalldata(b *testing.B) {
+	to := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	amount := big.NewInt(1000000)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := ethcoder.AbiEncodeMethodCalldata("transfer(address,uint256)", []interface{}{to, amount}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package ethcoder
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"reflect"
+
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+)
+
+// AbiGeneratorOptions are the options of an AbiGenerator.
+type AbiGeneratorOptions struct {
+	// MaxSliceLen is the max length of the values of the slice types, ie. T[],
+	// which defaults to 4.
+	MaxSliceLen int
+
+	// MaxBytesLen is the max length of the values of bytes and string, which
+	// defaults to 96.
+	MaxBytesLen int
+}
+
+// AbiGenerator generates random valid values of abi types, ie. for the property
+// based testing of encoders and contracts. The values are of the Go types of
+// go-ethereum, which are packed by abi.Arguments as is, and the integers are
+// biased to the bounds of their types. The values of a seed are deterministic.
+type AbiGenerator struct {
+	rand *rand.Rand
+	opts AbiGeneratorOptions
+}
+
+// NewAbiGenerator returns a generator of the seed.
+func NewAbiGenerator(seed int64, opts ...AbiGeneratorOptions) *AbiGenerator {
+	g := &AbiGenerator{rand: rand.New(rand.NewSource(seed))}
+	if len(opts) > 0 {
+		g.opts = opts[0]
+	}
+	if g.opts.MaxSliceLen <= 0 {
+		g.opts.MaxSliceLen = 4
+	}
+	if g.opts.MaxBytesLen <= 0 {
+		g.opts.MaxBytesLen = 96
+	}
+	return g
+}
+
+// MethodValues returns random values of the inputs of the method.
+func (g *AbiGenerator) MethodValues(method abi.Method) ([]interface{}, error) {
+	return g.Values(method.Inputs)
+}
+
+// Values returns random values of the arguments.
+func (g *AbiGenerator) Values(args abi.Arguments) ([]interface{}, error) {
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		v, err := g.Value(arg.Type)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// Value returns a random value of the type.
+func (g *AbiGenerator) Value(typ abi.Type) (interface{}, error) {
+	v, err := g.value(typ)
+	if err != nil {
+		return nil, err
+	}
+	return v.Interface(), nil
+}
+
+func (g *AbiGenerator) value(typ abi.Type) (reflect.Value, error) {
+	switch typ.T {
+	case abi.IntTy, abi.UintTy:
+		n := g.number(typ.T == abi.IntTy, typ.Size)
+		if typ.GetType() == reflect.TypeOf(n) {
+			// the sizes other than 8, 16, 32 and 64
+			return reflect.ValueOf(n), nil
+		}
+		if typ.T == abi.IntTy {
+			return reflect.ValueOf(n.Int64()).Convert(typ.GetType()), nil
+		}
+		return reflect.ValueOf(n.Uint64()).Convert(typ.GetType()), nil
+
+	case abi.BoolTy:
+		return reflect.ValueOf(g.rand.Intn(2) == 1), nil
+
+	case abi.StringTy:
+		return reflect.ValueOf(g.string()), nil
+
+	case abi.BytesTy:
+		return reflect.ValueOf(g.bytes(g.rand.Intn(g.opts.MaxBytesLen + 1))), nil
+
+	case abi.AddressTy, abi.FixedBytesTy, abi.FunctionTy:
+		v := reflect.New(typ.GetType()).Elem()
+		reflect.Copy(v, reflect.ValueOf(g.bytes(v.Len())))
+		return v, nil
+
+	case abi.SliceTy, abi.ArrayTy:
+		n := typ.Size
+		v := reflect.New(typ.GetType()).Elem()
+		if typ.T == abi.SliceTy {
+			n = g.rand.Intn(g.opts.MaxSliceLen + 1)
+			v = reflect.MakeSlice(typ.GetType(), n, n)
+		}
+		for i := 0; i < n; i++ {
+			elem, err := g.value(*typ.Elem)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v.Index(i).Set(elem)
+		}
+		return v, nil
+
+	case abi.TupleTy:
+		v := reflect.New(typ.TupleType).Elem()
+		for i, elemType := range typ.TupleElems {
+			elem, err := g.value(*elemType)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v.Field(i).Set(elem)
+		}
+		return v, nil
+	}
+	return reflect.Value{}, fmt.Errorf("ethcoder: no random values of type %s", typ)
+}
+
+// number returns a random integer of the type of the size, which is one of the
+// bounds of the type for a quarter of the values.
+func (g *AbiGenerator) number(signed bool, size int) *big.Int {
+	max := new(big.Int).Lsh(big.NewInt(1), uint(size))
+	if signed {
+		max.Rsh(max, 1)
+	}
+	min := new(big.Int)
+	if signed {
+		min.Neg(max)
+	}
+	max.Sub(max, big.NewInt(1))
+
+	if g.rand.Intn(4) == 0 {
+		switch g.rand.Intn(4) {
+		case 0:
+			return min
+		case 1:
+			return max
+		case 2:
+			return big.NewInt(0)
+		default:
+			return big.NewInt(1)
+		}
+	}
+
+	n := new(big.Int).SetBytes(g.bytes(size / 8))
+	if signed {
+		n.Add(n, min)
+	}
+	return n
+}
+
+func (g *AbiGenerator) bytes(n int) []byte {
+	b := make([]byte, n)
+	g.rand.Read(b)
+	return b
+}
+
+// string returns a random string of ascii and multi-byte runes.
+func (g *AbiGenerator) string() string {
+	runes := []rune("abcdefghijklmnopqrstuvwxyz0123456789 éß€😀")
+	n := g.rand.Intn(g.opts.MaxBytesLen + 1)
+	s := make([]rune, n)
+	for i := range s {
+		s[i] = runes[g.rand.Intn(len(runes))]
+	}
+	return string(s)
+}
@@ -0,0 +1,74 @@
+package ethcoder_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAbiGenerator(t *testing.T) {
+	method, err := ethcoder.ParseABIFunction("function f(uint8 a, int24 b, int256 c, uint256 d, bool e, address f, bytes4 g, bytes h, string i, uint16[3] j, (address to, int64[] amounts)[] k, string[2][] l)")
+	require.NoError(t, err)
+
+	g := ethcoder.NewAbiGenerator(1)
+	for i := 0; i < 200; i++ {
+		values, err := g.MethodValues(*method)
+		require.NoError(t, err)
+
+		// the values of the types round trip
+		data, err := method.Inputs.Pack(values...)
+		require.NoError(t, err)
+		unpacked, err := method.Inputs.Unpack(data)
+		require.NoError(t, err)
+		repacked, err := method.Inputs.Pack(unpacked...)
+		require.NoError(t, err)
+		assert.Equal(t, data, repacked)
+	}
+
+	// the values of a seed are deterministic
+	a, err := ethcoder.NewAbiGenerator(7).MethodValues(*method)
+	require.NoError(t, err)
+	b, err := ethcoder.NewAbiGenerator(7).MethodValues(*method)
+	require.NoError(t, err)
+	assert.Equal(t, a, b)
+
+	t.Run("bounds", func(t *testing.T) {
+		typ, err := abi.NewType("int8", "", nil)
+		require.NoError(t, err)
+		seen := map[int8]bool{}
+		for i := 0; i < 1000; i++ {
+			v, err := g.Value(typ)
+			require.NoError(t, err)
+			seen[v.(int8)] = true
+		}
+		assert.True(t, seen[-128] && seen[127] && seen[0])
+
+		typ, err = abi.NewType("uint256", "", nil)
+		require.NoError(t, err)
+		max := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+		for i := 0; i < 100; i++ {
+			v, err := g.Value(typ)
+			require.NoError(t, err)
+			n := v.(*big.Int)
+			assert.True(t, n.Sign() >= 0 && n.Cmp(max) <= 0)
+		}
+	})
+
+	t.Run("lengths", func(t *testing.T) {
+		g := ethcoder.NewAbiGenerator(1, ethcoder.AbiGeneratorOptions{MaxSliceLen: 2, MaxBytesLen: 8})
+		typ, err := abi.NewType("bytes[]", "", nil)
+		require.NoError(t, err)
+		for i := 0; i < 100; i++ {
+			v, err := g.Value(typ)
+			require.NoError(t, err)
+			assert.LessOrEqual(t, len(v.([][]byte)), 2)
+			for _, b := range v.([][]byte) {
+				assert.LessOrEqual(t, len(b), 8)
+			}
+		}
+	})
+}
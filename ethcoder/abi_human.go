@@ -0,0 +1,307 @@
+package ethcoder
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+)
+
+// ParseHumanReadableABI returns the abi of the fragments of an ethers-style
+// human-readable abi, ie.
+//
+//	"function transfer(address to, uint256 amount) returns (bool)"
+//	"function balanceOf(address) view returns (uint256)"
+//	"event Transfer(address indexed from, address indexed to, uint256 value)"
+//	"error InsufficientBalance(uint256 available, uint256 required)"
+//	"function swap((address to, uint256[] amounts)[] orders) payable"
+//
+// as well as constructor, fallback and receive fragments. A fragment without a
+// keyword is a function. Tuples are written as (...) or tuple(...), and uint and
+// int are uint256 and int256. The fixed-point and user-defined value types are
+// parsed as of ParseABIJSON.
+func ParseHumanReadableABI(fragments []string, opts ...ABIOptions) (*abi.ABI, error) {
+	entries := make([]humanABIEntry, 0, len(fragments))
+	for _, fragment := range fragments {
+		if strings.TrimSpace(fragment) == "" {
+			continue
+		}
+		entry, err := parseHumanABIFragment(fragment, "function")
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+	return ParseABIJSON(data, opts...)
+}
+
+// ParseABIFunction returns the method of a human-readable function fragment, see
+// ParseHumanReadableABI.
+func ParseABIFunction(fragment string) (*abi.Method, error) {
+	parsed, err := parseHumanABISingle(fragment, "function")
+	if err != nil {
+		return nil, err
+	}
+	for _, method := range parsed.Methods {
+		return &method, nil
+	}
+	return nil, fmt.Errorf("ethcoder: %q is not a function", fragment)
+}
+
+// ParseABIEvent returns the event of a human-readable event fragment, see
+// ParseHumanReadableABI.
+func ParseABIEvent(fragment string) (*abi.Event, error) {
+	parsed, err := parseHumanABISingle(fragment, "event")
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range parsed.Events {
+		return &event, nil
+	}
+	return nil, fmt.Errorf("ethcoder: %q is not an event", fragment)
+}
+
+// ParseABIError returns the error of a human-readable error fragment, see
+// ParseHumanReadableABI.
+func ParseABIError(fragment string) (*abi.Error, error) {
+	parsed, err := parseHumanABISingle(fragment, "error")
+	if err != nil {
+		return nil, err
+	}
+	for _, abiErr := range parsed.Errors {
+		return &abiErr, nil
+	}
+	return nil, fmt.Errorf("ethcoder: %q is not an error", fragment)
+}
+
+// parseHumanABISingle parses the fragment as the kind, with the keyword of the
+// kind optional.
+func parseHumanABISingle(fragment string, kind string) (*abi.ABI, error) {
+	entry, err := parseHumanABIFragment(fragment, kind)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Type != kind {
+		return nil, fmt.Errorf("ethcoder: %q is not an abi %s", fragment, kind)
+	}
+	data, err := json.Marshal([]humanABIEntry{entry})
+	if err != nil {
+		return nil, err
+	}
+	return ParseABIJSON(data)
+}
+
+type humanABIEntry struct {
+	Type            string          `json:"type"`
+	Name            string          `json:"name,omitempty"`
+	Inputs          []humanABIParam `json:"inputs,omitempty"`
+	Outputs         []humanABIParam `json:"outputs,omitempty"`
+	StateMutability string          `json:"stateMutability,omitempty"`
+	Anonymous       bool            `json:"anonymous,omitempty"`
+}
+
+type humanABIParam struct {
+	Name       string          `json:"name"`
+	Type       string          `json:"type"`
+	Indexed    bool            `json:"indexed,omitempty"`
+	Components []humanABIParam `json:"components,omitempty"`
+}
+
+// parseHumanABIFragment parses a fragment, of the type of its keyword or the
+// default type without one.
+func parseHumanABIFragment(fragment string, defaultType string) (humanABIEntry, error) {
+	s := strings.TrimSuffix(strings.TrimSpace(fragment), ";")
+	invalid := func(reason string) (humanABIEntry, error) {
+		return humanABIEntry{}, fmt.Errorf("ethcoder: invalid abi fragment %q: %s", fragment, reason)
+	}
+
+	entry := humanABIEntry{Type: defaultType}
+	if keyword, rest, ok := strings.Cut(s, " "); ok || s == "fallback" || s == "receive" {
+		switch keyword {
+		case "function", "event", "error", "constructor", "fallback", "receive":
+			entry.Type, s = keyword, strings.TrimSpace(rest)
+		}
+	}
+	if strings.HasPrefix(s, "constructor(") || strings.HasPrefix(s, "fallback(") || strings.HasPrefix(s, "receive(") {
+		entry.Type = s[:strings.Index(s, "(")]
+	}
+
+	// the name and inputs
+	var inputs string
+	if idx := strings.Index(s, "("); idx >= 0 {
+		entry.Name = strings.TrimSpace(s[:idx])
+		end := matchingParen(s[idx:])
+		if end < 0 {
+			return invalid("unbalanced parentheses")
+		}
+		end += idx
+		inputs, s = s[idx+1:end], strings.TrimSpace(s[end+1:])
+	} else if entry.Type != "fallback" && entry.Type != "receive" {
+		return invalid("missing arguments")
+	}
+	switch entry.Type {
+	case "constructor", "fallback", "receive":
+		entry.Name = ""
+	default:
+		if !isIdentifier(entry.Name) {
+			return invalid("invalid name")
+		}
+	}
+
+	var err error
+	if entry.Inputs, err = parseHumanABIParams(inputs, entry.Type == "event"); err != nil {
+		return invalid(err.Error())
+	}
+
+	// the modifiers and outputs
+	for s != "" {
+		word, rest, _ := strings.Cut(s, " ")
+		if strings.HasPrefix(s, "returns") {
+			word, rest = "returns", strings.TrimSpace(strings.TrimPrefix(s, "returns"))
+		}
+		s = strings.TrimSpace(rest)
+		switch word {
+		case "view", "pure", "payable", "nonpayable":
+			entry.StateMutability = word
+		case "constant":
+			entry.StateMutability = "view"
+		case "external", "public", "internal", "private", "virtual", "override":
+		case "anonymous":
+			if entry.Type != "event" {
+				return invalid("anonymous is only for events")
+			}
+			entry.Anonymous = true
+		case "returns":
+			if entry.Type != "function" || !strings.HasPrefix(s, "(") {
+				return invalid("invalid returns")
+			}
+			end := matchingParen(s)
+			if end < 0 {
+				return invalid("unbalanced parentheses")
+			}
+			var err error
+			if entry.Outputs, err = parseHumanABIParams(s[1:end], false); err != nil {
+				return invalid(err.Error())
+			}
+			s = strings.TrimSpace(s[end+1:])
+		default:
+			return invalid(fmt.Sprintf("unexpected %q", word))
+		}
+	}
+
+	switch entry.Type {
+	case "function", "constructor", "fallback", "receive":
+		if entry.StateMutability == "" {
+			entry.StateMutability = "nonpayable"
+		}
+		if entry.Type == "receive" {
+			entry.StateMutability = "payable"
+		}
+	default:
+		if entry.StateMutability != "" {
+			return invalid(fmt.Sprintf("%s can't be %s", entry.Type, entry.StateMutability))
+		}
+	}
+	return entry, nil
+}
+
+// parseHumanABIParams parses the comma separated params of a list, ie. of the
+// inside of its parens.
+func parseHumanABIParams(list string, indexed bool) ([]humanABIParam, error) {
+	params := []humanABIParam{}
+	for _, part := range splitAbiTypes(list) {
+		if part == "" {
+			return nil, fmt.Errorf("empty argument")
+		}
+		param, err := parseHumanABIParam(part, indexed)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, param)
+	}
+	return params, nil
+}
+
+func parseHumanABIParam(s string, indexed bool) (humanABIParam, error) {
+	s = strings.TrimSpace(s)
+	var param humanABIParam
+
+	if strings.HasPrefix(s, "tuple(") {
+		s = s[len("tuple"):]
+	}
+	if strings.HasPrefix(s, "(") {
+		end := matchingParen(s)
+		if end < 0 {
+			return param, fmt.Errorf("unbalanced parentheses")
+		}
+		var err error
+		if param.Components, err = parseHumanABIParams(s[1:end], false); err != nil {
+			return param, err
+		}
+		s = s[end+1:]
+		suffix := s
+		if idx := strings.IndexAny(s, " \t"); idx >= 0 {
+			suffix = s[:idx]
+		}
+		param.Type = "tuple" + suffix
+		s = strings.TrimSpace(s[len(suffix):])
+	} else {
+		typ, rest, _ := strings.Cut(s, " ")
+		param.Type = normalizeHumanABIType(typ)
+		s = strings.TrimSpace(rest)
+	}
+	if param.Type == "" {
+		return param, fmt.Errorf("missing type")
+	}
+
+	for _, word := range strings.Fields(s) {
+		switch {
+		case word == "indexed":
+			if !indexed {
+				return param, fmt.Errorf("indexed is only for event arguments")
+			}
+			param.Indexed = true
+		case word == "memory" || word == "calldata" || word == "storage" || (word == "payable" && param.Type == "address"):
+		case param.Name == "" && isIdentifier(word):
+			param.Name = word
+		default:
+			return param, fmt.Errorf("unexpected %q in argument %q", word, strings.TrimSpace(s))
+		}
+	}
+	return param, nil
+}
+
+// normalizeHumanABIType returns the canonical type of an elementary type, ie.
+// uint256 of uint, with its array suffix.
+func normalizeHumanABIType(typ string) string {
+	base, suffix := typ, ""
+	if idx := strings.Index(typ, "["); idx >= 0 {
+		base, suffix = typ[:idx], typ[idx:]
+	}
+	switch base {
+	case "uint", "int":
+		base += "256"
+	case "byte":
+		base = "bytes1"
+	}
+	return base + suffix
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		if c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9') {
+			continue
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package ethcoder
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHumanReadableABI(t *testing.T) {
+	parsed, err := ParseHumanReadableABI([]string{
+		"constructor(string symbol, string name)",
+		"function transfer(address to, uint amount) returns (bool)",
+		"function balanceOf(address owner) external view returns (uint256 balance)",
+		"function swap((address to, uint256[] amounts)[] calldata orders, tuple(bytes32 id, bool ok) extra) payable;",
+		"event Transfer(address indexed from, address indexed to, uint256 value)",
+		"event Log(string) anonymous",
+		"error InsufficientBalance(uint256 available, uint256 required)",
+		"receive() external payable",
+		"fallback",
+	})
+	require.NoError(t, err)
+
+	transfer := parsed.Methods["transfer"]
+	assert.Equal(t, "transfer(address,uint256)", transfer.Sig)
+	assert.Equal(t, "a9059cbb", common.Bytes2Hex(transfer.ID))
+	assert.Equal(t, "bool", transfer.Outputs[0].Type.String())
+	assert.Equal(t, "amount", transfer.Inputs[1].Name)
+	assert.False(t, transfer.IsConstant())
+
+	balanceOf := parsed.Methods["balanceOf"]
+	assert.True(t, balanceOf.IsConstant())
+	assert.Equal(t, "balance", balanceOf.Outputs[0].Name)
+
+	swap := parsed.Methods["swap"]
+	assert.Equal(t, "swap((address,uint256[])[],(bytes32,bool))", swap.Sig)
+	assert.True(t, swap.IsPayable())
+	assert.Equal(t, "orders", swap.Inputs[0].Name)
+
+	transferEvent := parsed.Events["Transfer"]
+	assert.Equal(t, "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef", transferEvent.ID.Hex())
+	assert.True(t, transferEvent.Inputs[0].Indexed)
+	assert.False(t, transferEvent.Inputs[2].Indexed)
+	assert.True(t, parsed.Events["Log"].Anonymous)
+
+	assert.Equal(t, "InsufficientBalance(uint256,uint256)", parsed.Errors["InsufficientBalance"].Sig)
+	assert.Len(t, parsed.Constructor.Inputs, 2)
+	assert.True(t, parsed.HasReceive())
+	assert.True(t, parsed.HasFallback())
+
+	packed, err := parsed.Pack("transfer", common.HexToAddress("0x1234567890123456789012345678901234567890"), big.NewInt(1))
+	require.NoError(t, err)
+	assert.Equal(t, transfer.ID, packed[:4])
+}
+
+func TestParseABIFragment(t *testing.T) {
+	method, err := ParseABIFunction("balanceOf(address) view returns (uint)")
+	require.NoError(t, err)
+	assert.Equal(t, "balanceOf(address)", method.Sig)
+	assert.Equal(t, "uint256", method.Outputs[0].Type.String())
+
+	event, err := ParseABIEvent("Approval(address indexed owner, address indexed spender, uint256 value)")
+	require.NoError(t, err)
+	assert.Equal(t, "Approval", event.Name)
+	assert.True(t, event.Inputs[1].Indexed)
+
+	abiErr, err := ParseABIError("error Unauthorized()")
+	require.NoError(t, err)
+	assert.Equal(t, "Unauthorized()", abiErr.Sig)
+
+	_, err = ParseABIEvent("function transfer(address,uint256)")
+	assert.ErrorContains(t, err, "is not an abi event")
+
+	for _, invalid := range []string{
+		"function transfer(address to, uint256 amount",
+		"function transfer(address indexed to)",
+		"function transfer(address,uint256) returns bool",
+		"event Transfer(address) view",
+		"function 1transfer(address)",
+		"function transfer(address,,uint256)",
+		"function transfer(address to from)",
+	} {
+		_, err := ParseHumanReadableABI([]string{invalid})
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,339 @@
+package ethcoder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+type ABIOptions struct {
+	// UserDefinedTypes are the underlying types of the user-defined value types
+	// of the abi by name, ie. {"Price": "uint128"}, for abis which have the
+	// names of the types instead of their underlying types.
+	UserDefinedTypes map[string]string
+}
+
+// ParseABIJSON returns the abi of json, ie. of a compiler artifact. Unlike
+// abi.JSON, it accepts the fixedMxN and ufixedMxN types, which are parsed as the
+// integer types of their scaled values under the signatures and selectors of the
+// fixed-point types, see AbiCoder, and the user-defined value types of the
+// options.
+func ParseABIJSON(abiJSON []byte, opts ...ABIOptions) (*abi.ABI, error) {
+	var options ABIOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+
+	var entries []map[string]interface{}
+	if err := json.Unmarshal(abiJSON, &entries); err != nil {
+		return nil, fmt.Errorf("ethcoder: invalid abi json: %w", err)
+	}
+
+	// the signatures of the entries of fixed-point types, by their signatures of
+	// the integer types
+	sigs := map[string]string{}
+	for _, entry := range entries {
+		name, _ := entry["name"].(string)
+		for _, key := range []string{"inputs", "outputs"} {
+			params, _ := entry[key].([]interface{})
+			sigTypes, intTypes, hasFixed, err := mapABIJSONParams(params, options)
+			if err != nil {
+				return nil, fmt.Errorf("ethcoder: invalid abi %q: %w", name, err)
+			}
+			if hasFixed && key == "inputs" {
+				sigs[name+"("+intTypes+")"] = name + "(" + sigTypes + ")"
+			}
+		}
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := abi.JSON(strings.NewReader(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("ethcoder: invalid abi: %w", err)
+	}
+	if len(sigs) == 0 {
+		return &parsed, nil
+	}
+
+	for name, method := range parsed.Methods {
+		if sig, ok := sigs[method.Sig]; ok {
+			method.Sig = sig
+			method.ID = Keccak256([]byte(sig))[:4]
+			parsed.Methods[name] = method
+		}
+	}
+	for name, event := range parsed.Events {
+		if sig, ok := sigs[event.Sig]; ok {
+			event.Sig = sig
+			event.ID = common.BytesToHash(Keccak256([]byte(sig)))
+			parsed.Events[name] = event
+		}
+	}
+	for name, abiErr := range parsed.Errors {
+		if sig, ok := sigs[abiErr.Sig]; ok {
+			abiErr.Sig = sig
+			abiErr.ID = common.BytesToHash(Keccak256([]byte(sig)))
+			parsed.Errors[name] = abiErr
+		}
+	}
+	return &parsed, nil
+}
+
+// mapABIJSONParams sets the types of the json params to the types go-ethereum
+// parses, and returns the comma separated types of the signature of the params
+// and of the integer types of their fixed-point types.
+func mapABIJSONParams(params []interface{}, options ABIOptions) (string, string, bool, error) {
+	sigTypes := make([]string, len(params))
+	intTypes := make([]string, len(params))
+	hasFixed := false
+	for i, p := range params {
+		param, ok := p.(map[string]interface{})
+		if !ok {
+			return "", "", false, fmt.Errorf("invalid argument %d", i)
+		}
+		typ, _ := param["type"].(string)
+		base, suffix := typ, ""
+		if idx := strings.Index(typ, "["); idx >= 0 {
+			base, suffix = typ[:idx], typ[idx:]
+		}
+
+		if base == "tuple" {
+			components, _ := param["components"].([]interface{})
+			sigType, intType, fixed, err := mapABIJSONParams(components, options)
+			if err != nil {
+				return "", "", false, err
+			}
+			sigTypes[i], intTypes[i] = "("+sigType+")"+suffix, "("+intType+")"+suffix
+			hasFixed = hasFixed || fixed
+			continue
+		}
+
+		if underlying, ok := options.UserDefinedTypes[base]; ok {
+			base = underlying
+		}
+		base = normalizeHumanABIType(base)
+		sigTypes[i], intTypes[i] = base+suffix, base+suffix
+		ft, isFixed, err := parseFixedType(base)
+		if err != nil {
+			return "", "", false, err
+		}
+		if isFixed {
+			sigTypes[i], intTypes[i] = ft.String()+suffix, ft.intType()+suffix
+			hasFixed = true
+		}
+		param["type"] = intTypes[i]
+	}
+	return strings.Join(sigTypes, ","), strings.Join(intTypes, ","), hasFixed, nil
+}
+
+// ErrSelectorCollision is returned by MergeABIs when methods of different
+// signatures have the same selector.
+var ErrSelectorCollision = errors.New("ethcoder: selector collision")
+
+// MergeABIs returns the abi of the entries of the abis, ie. of a proxy and its
+// implementation, or of the facets of a diamond. The entries of the same
+// signature are merged, and the overloaded ones are named as of abi.JSON, ie.
+// transfer0. The constructor, fallback and receive are the ones of the first abi
+// which has them. It returns ErrSelectorCollision when methods of different
+// signatures have the same selector.
+func MergeABIs(abis ...abi.ABI) (*abi.ABI, error) {
+	merged := abi.ABI{
+		Methods: map[string]abi.Method{},
+		Events:  map[string]abi.Event{},
+		Errors:  map[string]abi.Error{},
+	}
+	selectors := map[string]string{}
+	events := map[string]bool{}
+	errs := map[string]bool{}
+
+	for _, contractABI := range abis {
+		if !hasAbiConstructor(merged) && hasAbiConstructor(contractABI) {
+			merged.Constructor = contractABI.Constructor
+		}
+		if !merged.HasFallback() && contractABI.HasFallback() {
+			merged.Fallback = contractABI.Fallback
+		}
+		if !merged.HasReceive() && contractABI.HasReceive() {
+			merged.Receive = contractABI.Receive
+		}
+
+		for _, key := range sortedKeys(contractABI.Methods) {
+			method := contractABI.Methods[key]
+			selector := string(method.ID)
+			if sig, ok := selectors[selector]; ok {
+				if sig == method.Sig {
+					continue
+				}
+				return nil, fmt.Errorf("%w %s of %s and %s", ErrSelectorCollision, HexEncode(method.ID), sig, method.Sig)
+			}
+			selectors[selector] = method.Sig
+			method.Name = abi.ResolveNameConflict(method.RawName, func(name string) bool { _, ok := merged.Methods[name]; return ok })
+			merged.Methods[method.Name] = method
+		}
+
+		for _, key := range sortedKeys(contractABI.Events) {
+			event := contractABI.Events[key]
+			// the events of the same topic with other indexed arguments, ie. the
+			// Transfer of erc20 and erc721, are both kept
+			layout := event.Sig
+			for _, input := range event.Inputs {
+				layout += fmt.Sprintf(",%v", input.Indexed)
+			}
+			if events[layout] {
+				continue
+			}
+			events[layout] = true
+			event.Name = abi.ResolveNameConflict(event.RawName, func(name string) bool { _, ok := merged.Events[name]; return ok })
+			merged.Events[event.Name] = event
+		}
+
+		for _, key := range sortedKeys(contractABI.Errors) {
+			abiErr := contractABI.Errors[key]
+			if errs[abiErr.Sig] {
+				continue
+			}
+			errs[abiErr.Sig] = true
+			name := abi.ResolveNameConflict(abiErr.Name, func(name string) bool { _, ok := merged.Errors[name]; return ok })
+			merged.Errors[name] = abiErr
+		}
+	}
+	return &merged, nil
+}
+
+// MarshalABIJSON returns the normalized json of the abi, of the constructor, the
+// functions, events and errors sorted by name, then the fallback and receive.
+// The fixed-point types of the inputs are of their signatures, see ParseABIJSON,
+// and the ones of the outputs are their integer types.
+func MarshalABIJSON(contractABI abi.ABI) ([]byte, error) {
+	entries := []abiJSONEntry{}
+	if hasAbiConstructor(contractABI) {
+		entries = append(entries, abiJSONEntry{
+			Type:            "constructor",
+			Inputs:          abiJSONParams(contractABI.Constructor.Inputs, ""),
+			StateMutability: contractABI.Constructor.StateMutability,
+		})
+	}
+	for _, name := range sortedKeys(contractABI.Methods) {
+		method := contractABI.Methods[name]
+		outputs := abiJSONParams(method.Outputs, "")
+		entries = append(entries, abiJSONEntry{
+			Type:            "function",
+			Name:            method.RawName,
+			Inputs:          abiJSONParams(method.Inputs, method.Sig),
+			Outputs:         &outputs,
+			StateMutability: method.StateMutability,
+		})
+	}
+	for _, name := range sortedKeys(contractABI.Events) {
+		event := contractABI.Events[name]
+		anonymous := event.Anonymous
+		entries = append(entries, abiJSONEntry{
+			Type:      "event",
+			Name:      event.RawName,
+			Inputs:    abiJSONParams(event.Inputs, event.Sig),
+			Anonymous: &anonymous,
+		})
+	}
+	for _, name := range sortedKeys(contractABI.Errors) {
+		abiErr := contractABI.Errors[name]
+		entries = append(entries, abiJSONEntry{
+			Type:   "error",
+			Name:   abiErr.Name,
+			Inputs: abiJSONParams(abiErr.Inputs, abiErr.Sig),
+		})
+	}
+	if contractABI.HasFallback() {
+		entries = append(entries, abiJSONEntry{Type: "fallback", StateMutability: contractABI.Fallback.StateMutability})
+	}
+	if contractABI.HasReceive() {
+		entries = append(entries, abiJSONEntry{Type: "receive", StateMutability: contractABI.Receive.StateMutability})
+	}
+	return json.Marshal(entries)
+}
+
+type abiJSONEntry struct {
+	Type            string          `json:"type"`
+	Name            string          `json:"name,omitempty"`
+	Inputs          []abiJSONParam  `json:"inputs,omitempty"`
+	Outputs         *[]abiJSONParam `json:"outputs,omitempty"`
+	StateMutability string          `json:"stateMutability,omitempty"`
+	Anonymous       *bool           `json:"anonymous,omitempty"`
+}
+
+type abiJSONParam struct {
+	Name       string         `json:"name"`
+	Type       string         `json:"type"`
+	Indexed    bool           `json:"indexed,omitempty"`
+	Components []abiJSONParam `json:"components,omitempty"`
+}
+
+// abiJSONParams returns the json params of the args, of the types of the
+// signature sig when it's given, ie. of the fixed-point types.
+func abiJSONParams(args abi.Arguments, sig string) []abiJSONParam {
+	var sigTypes []string
+	if idx := strings.Index(sig, "("); idx >= 0 && strings.HasSuffix(sig, ")") {
+		sigTypes = splitAbiTypes(sig[idx+1 : len(sig)-1])
+	}
+	params := make([]abiJSONParam, len(args))
+	for i, arg := range args {
+		var sigType string
+		if len(sigTypes) == len(args) {
+			sigType = sigTypes[i]
+		}
+		params[i] = abiJSONParamOf(arg.Name, arg.Type, sigType)
+		params[i].Indexed = arg.Indexed
+	}
+	return params
+}
+
+func abiJSONParamOf(name string, typ abi.Type, sigType string) abiJSONParam {
+	elem := typ
+	for elem.T == abi.SliceTy || elem.T == abi.ArrayTy {
+		elem = *elem.Elem
+	}
+	if elem.T != abi.TupleTy {
+		if _, ok, _ := parseFixedType(sigType); ok {
+			return abiJSONParam{Name: name, Type: sigType}
+		}
+		return abiJSONParam{Name: name, Type: typ.String()}
+	}
+
+	// the suffix of the tuple arrays, ie. [2][]
+	suffix := typ.String()[matchingParen(typ.String())+1:]
+	var sigComponents []string
+	if end := matchingParen(sigType); strings.HasPrefix(sigType, "(") && end > 0 {
+		sigComponents = splitAbiTypes(sigType[1:end])
+	}
+	param := abiJSONParam{Name: name, Type: "tuple" + suffix}
+	for i, component := range elem.TupleElems {
+		var sigComponent string
+		if len(sigComponents) == len(elem.TupleElems) {
+			sigComponent = sigComponents[i]
+		}
+		param.Components = append(param.Components, abiJSONParamOf(elem.TupleRawNames[i], *component, sigComponent))
+	}
+	return param
+}
+
+// hasAbiConstructor returns whether the abi has a constructor, of which the
+// state mutability is set when it is parsed.
+func hasAbiConstructor(contractABI abi.ABI) bool {
+	return contractABI.Constructor.StateMutability != "" || len(contractABI.Constructor.Inputs) > 0
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
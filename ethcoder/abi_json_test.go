@@ -0,0 +1,195 @@
+package ethcoder_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseABIJSON(t *testing.T) {
+	abiJSON := `[
+		{"type":"function","name":"setRate","stateMutability":"nonpayable","inputs":[
+			{"name":"rate","type":"ufixed128x18"},
+			{"name":"limits","type":"tuple[]","components":[{"name":"min","type":"fixed"},{"name":"max","type":"uint256"}]}
+		],"outputs":[{"name":"","type":"fixed64x10"}]},
+		{"type":"function","name":"price","stateMutability":"view","inputs":[],"outputs":[
+			{"name":"","type":"uint128","internalType":"Price"}
+		]},
+		{"type":"event","name":"RateSet","inputs":[{"name":"rate","type":"ufixed128x18","indexed":false}],"anonymous":false},
+		{"type":"error","name":"InvalidRate","inputs":[{"name":"rate","type":"ufixed128x18"}]}
+	]`
+
+	contractABI, err := ethcoder.ParseABIJSON([]byte(abiJSON))
+	require.NoError(t, err)
+
+	method := contractABI.Methods["setRate"]
+	assert.Equal(t, "setRate(ufixed128x18,(fixed128x18,uint256)[])", method.Sig)
+	assert.Equal(t, ethcoder.Keccak256([]byte(method.Sig))[:4], method.ID)
+	assert.Equal(t, "uint128", method.Inputs[0].Type.String())
+	assert.Equal(t, "int64", method.Outputs[0].Type.String())
+
+	// the values of the fixed-point types are their scaled integers
+	data, err := contractABI.Pack("setRate", big.NewInt(1500000000000000000), []struct {
+		Min *big.Int
+		Max *big.Int
+	}{{Min: big.NewInt(-1), Max: big.NewInt(2)}})
+	require.NoError(t, err)
+	assert.Equal(t, method.ID, data[:4])
+
+	assert.Equal(t, "price()", contractABI.Methods["price"].Sig)
+	assert.Equal(t, "uint128", contractABI.Methods["price"].Outputs[0].Type.String())
+
+	event := contractABI.Events["RateSet"]
+	assert.Equal(t, "RateSet(ufixed128x18)", event.Sig)
+	assert.Equal(t, common.BytesToHash(ethcoder.Keccak256([]byte(event.Sig))), event.ID)
+
+	abiErr := contractABI.Errors["InvalidRate"]
+	assert.Equal(t, "InvalidRate(ufixed128x18)", abiErr.Sig)
+
+	t.Run("user-defined value types", func(t *testing.T) {
+		contractABI, err := ethcoder.ParseABIJSON([]byte(`[
+			{"type":"function","name":"setPrice","stateMutability":"nonpayable","inputs":[{"name":"price","type":"Price[2]"}],"outputs":[]}
+		]`), ethcoder.ABIOptions{UserDefinedTypes: map[string]string{"Price": "uint128"}})
+		require.NoError(t, err)
+		assert.Equal(t, "setPrice(uint128[2])", contractABI.Methods["setPrice"].Sig)
+
+		_, err = ethcoder.ParseABIJSON([]byte(`[
+			{"type":"function","name":"setPrice","stateMutability":"nonpayable","inputs":[{"name":"price","type":"Price"}],"outputs":[]}
+		]`))
+		assert.Error(t, err)
+	})
+
+	t.Run("human-readable", func(t *testing.T) {
+		method, err := ethcoder.ParseABIFunction("function setRate(ufixed rate, (fixed8x2 a, bool b) t)")
+		require.NoError(t, err)
+		assert.Equal(t, "setRate(ufixed128x18,(fixed8x2,bool))", method.Sig)
+
+		selector, err := ethcoder.FunctionSelector("setRate(ufixed128x18)")
+		require.NoError(t, err)
+		assert.Equal(t, ethcoder.Keccak256([]byte("setRate(ufixed128x18)"))[:4], selector[:])
+
+		contractABI, err := ethcoder.ParseHumanReadableABI([]string{"function setPrice(Price price)"}, ethcoder.ABIOptions{
+			UserDefinedTypes: map[string]string{"Price": "uint128"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "setPrice(uint128)", contractABI.Methods["setPrice"].Sig)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ethcoder.ParseABIJSON([]byte(`[{"type":"function","name":"f","inputs":[{"name":"x","type":"fixed7x2"}]}]`))
+		assert.Error(t, err)
+	})
+}
+
+func TestAbiDecodeIntoFixed(t *testing.T) {
+	data, err := ethcoder.AbiCoder([]string{"ufixed128x18", "(int128,bool)"}, []interface{}{"1.5", []interface{}{big.NewInt(-5), true}})
+	require.NoError(t, err)
+
+	// the values of the fixed-point types are their scaled integers
+	var out struct {
+		Rate  *big.Int
+		Limit struct {
+			Min *big.Int
+			Ok  bool
+		}
+	}
+	require.NoError(t, ethcoder.AbiDecodeInto([]string{"ufixed128x18 rate", "(fixed128x1 min,bool ok) limit"}, data, &out))
+	assert.Equal(t, big.NewInt(1500000000000000000), out.Rate)
+	assert.Equal(t, big.NewInt(-5), out.Limit.Min)
+	assert.True(t, out.Limit.Ok)
+}
+
+func TestMergeABIs(t *testing.T) {
+	proxy, err := ethcoder.ParseHumanReadableABI([]string{
+		"constructor(address implementation)",
+		"function upgradeTo(address implementation)",
+		"event Upgraded(address indexed implementation)",
+		"fallback() payable",
+	})
+	require.NoError(t, err)
+	implementation, err := ethcoder.ParseHumanReadableABI([]string{
+		"constructor()",
+		"function upgradeTo(address newImplementation)",
+		"function transfer(address to, uint256 amount) returns (bool)",
+		"event Transfer(address indexed from, address indexed to, uint256 value)",
+		"error Unauthorized()",
+	})
+	require.NoError(t, err)
+	facet, err := ethcoder.ParseHumanReadableABI([]string{
+		"function transfer(address to, uint256 amount, bytes data) returns (bool)",
+		"event Transfer(address indexed from, address indexed to, uint256 indexed tokenId)",
+		"error Unauthorized()",
+		"receive()",
+	})
+	require.NoError(t, err)
+
+	merged, err := ethcoder.MergeABIs(*proxy, *implementation, *facet)
+	require.NoError(t, err)
+
+	assert.Len(t, merged.Methods, 3)
+	assert.Equal(t, "upgradeTo(address)", merged.Methods["upgradeTo"].Sig)
+	assert.Equal(t, "implementation", merged.Methods["upgradeTo"].Inputs[0].Name)
+	assert.Equal(t, "transfer(address,uint256)", merged.Methods["transfer"].Sig)
+	assert.Equal(t, "transfer(address,uint256,bytes)", merged.Methods["transfer0"].Sig)
+	assert.Equal(t, "transfer0", merged.Methods["transfer0"].Name)
+
+	// the erc20 and erc721 transfers of the same topic
+	assert.Len(t, merged.Events, 3)
+	assert.Equal(t, merged.Events["Transfer"].ID, merged.Events["Transfer0"].ID)
+	assert.True(t, merged.Events["Transfer0"].Inputs[2].Indexed)
+	assert.Len(t, merged.Errors, 1)
+
+	assert.Len(t, merged.Constructor.Inputs, 1)
+	assert.True(t, merged.HasFallback())
+	assert.True(t, merged.HasReceive())
+
+	method, err := merged.MethodById(merged.Methods["transfer0"].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "transfer(address,uint256,bytes)", method.Sig)
+
+	t.Run("selector collision", func(t *testing.T) {
+		colliding, err := ethcoder.ParseHumanReadableABI([]string{"function many_msg_babbage(bytes1)"})
+		require.NoError(t, err)
+		_, err = ethcoder.MergeABIs(*implementation, *colliding)
+		assert.ErrorIs(t, err, ethcoder.ErrSelectorCollision)
+		assert.ErrorContains(t, err, "0xa9059cbb")
+	})
+}
+
+func TestMarshalABIJSON(t *testing.T) {
+	contractABI, err := ethcoder.ParseHumanReadableABI([]string{
+		"constructor(address owner) payable",
+		"function setRate(ufixed128x18 rate, (address to, fixed64x2[] limits)[2] routes) returns (uint256)",
+		"function owner() view returns (address)",
+		"event RateSet(address indexed by, ufixed128x18 rate) anonymous",
+		"error Unauthorized(address caller)",
+		"receive()",
+	})
+	require.NoError(t, err)
+
+	data, err := ethcoder.MarshalABIJSON(*contractABI)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"type":"constructor","inputs":[{"name":"owner","type":"address"}],"stateMutability":"payable"},
+		{"type":"function","name":"owner","outputs":[{"name":"","type":"address"}],"stateMutability":"view"},
+		{"type":"function","name":"setRate","inputs":[
+			{"name":"rate","type":"ufixed128x18"},
+			{"name":"routes","type":"tuple[2]","components":[{"name":"to","type":"address"},{"name":"limits","type":"fixed64x2[]"}]}
+		],"outputs":[{"name":"","type":"uint256"}],"stateMutability":"nonpayable"},
+		{"type":"event","name":"RateSet","inputs":[{"name":"by","type":"address","indexed":true},{"name":"rate","type":"ufixed128x18"}],"anonymous":true},
+		{"type":"error","name":"Unauthorized","inputs":[{"name":"caller","type":"address"}]},
+		{"type":"receive","stateMutability":"payable"}
+	]`, string(data))
+
+	// the same abi of its json
+	parsed, err := ethcoder.ParseABIJSON(data)
+	require.NoError(t, err)
+	assert.Equal(t, contractABI.Methods["setRate"].Sig, parsed.Methods["setRate"].Sig)
+	assert.Equal(t, contractABI.Methods["setRate"].ID, parsed.Methods["setRate"].ID)
+	assert.Equal(t, contractABI.Events["RateSet"].ID, parsed.Events["RateSet"].ID)
+	assert.Equal(t, contractABI.Constructor.Inputs, parsed.Constructor.Inputs)
+}
@@ -0,0 +1,411 @@
+package ethcoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+)
+
+// EncodeFromStrings abi encodes the string values of argTypes, ie. user input.
+// The scalar values are strings, ie. "0xabc..", "123", "0x7b" or "true", and the
+// values of the arrays and tuples are JSON, ie. `["1","2"]`, or `{"to":"0x..",
+// "amount":1}` for a tuple "(address to,uint256 amount)". The errors have the
+// location of the invalid value, ie. "value 1[2].amount".
+func EncodeFromStrings(argTypes []string, values []string) ([]byte, error) {
+	if len(argTypes) != len(values) {
+		return nil, fmt.Errorf("ethcoder: expecting %d values, got %d", len(argTypes), len(values))
+	}
+
+	args := make(abi.Arguments, len(argTypes))
+	argValues := make([]interface{}, len(argTypes))
+	for i, typ := range argTypes {
+		path := fmt.Sprintf("value %d", i)
+		typ, _ = splitAbiTypeName(typ)
+
+		node, err := parseStringValue(typ, values[i])
+		if err != nil {
+			return nil, fmt.Errorf("ethcoder: %s: %w", path, err)
+		}
+
+		ft, isFixed, err := parseFixedType(typ)
+		if err != nil {
+			return nil, err
+		}
+		if isFixed {
+			intTypes, _, _ := fixedArgsToInt([]string{typ}, nil)
+			args[i].Type, err = abi.NewType(intTypes[0], "", nil)
+			if err != nil {
+				return nil, fmt.Errorf("ethcoder: %s: %w", path, err)
+			}
+			v, err := ft.toAbiValue(args[i].Type.GetType(), reflect.ValueOf(jsonNumbersToStrings(node)))
+			if err != nil {
+				return nil, fmt.Errorf("ethcoder: %s: %w", path, err)
+			}
+			argValues[i] = v.Interface()
+			continue
+		}
+
+		args[i].Type, err = parseAbiType(typ)
+		if err != nil {
+			return nil, fmt.Errorf("ethcoder: %s: invalid type '%s': %w", path, typ, err)
+		}
+		v, err := coerceAbiValue(args[i].Type, node, path)
+		if err != nil {
+			return nil, fmt.Errorf("ethcoder: %w", err)
+		}
+		argValues[i] = v.Interface()
+	}
+
+	return args.Pack(argValues...)
+}
+
+// parseStringValue returns the JSON value of the arrays and tuples, and the
+// unquoted string of the other types.
+func parseStringValue(typ, value string) (interface{}, error) {
+	typ = strings.TrimSpace(typ)
+	if strings.HasPrefix(typ, "(") || strings.HasSuffix(typ, "]") {
+		dec := json.NewDecoder(strings.NewReader(value))
+		dec.UseNumber()
+		var node interface{}
+		if err := dec.Decode(&node); err != nil {
+			return nil, fmt.Errorf("invalid JSON for type '%s': %w", typ, err)
+		}
+		return node, nil
+	}
+	if strings.HasPrefix(value, `"`) {
+		var s string
+		if err := json.Unmarshal([]byte(value), &s); err != nil {
+			return nil, fmt.Errorf("invalid JSON string: %w", err)
+		}
+		return s, nil
+	}
+	return value, nil
+}
+
+func jsonNumbersToStrings(node interface{}) interface{} {
+	switch v := node.(type) {
+	case json.Number:
+		return v.String()
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i := range v {
+			out[i] = jsonNumbersToStrings(v[i])
+		}
+		return out
+	}
+	return node
+}
+
+// parseAbiType parses typ, with the tuples and their names, ie.
+// "(address to,uint256 amount)[]". The fixed-point types are parsed as the
+// integer types of their scaled values.
+func parseAbiType(typ string) (abi.Type, error) {
+	typ = strings.TrimSpace(typ)
+	if !strings.HasPrefix(typ, "(") {
+		intType, err := fixedIntType(typ)
+		if err != nil {
+			return abi.Type{}, err
+		}
+		return abi.NewType(intType, "", nil)
+	}
+	components, suffix, err := parseTupleComponents(typ)
+	if err != nil {
+		return abi.Type{}, err
+	}
+	return abi.NewType("tuple"+suffix, "", components)
+}
+
+func parseTupleComponents(typ string) ([]abi.ArgumentMarshaling, string, error) {
+	end := matchingParen(typ)
+	if end < 0 {
+		return nil, "", fmt.Errorf("unbalanced parentheses")
+	}
+	suffix := typ[end+1:]
+
+	var components []abi.ArgumentMarshaling
+	var err error
+	for i, field := range splitAbiTypes(typ[1:end]) {
+		fieldType, name := splitAbiTypeName(field)
+		c := abi.ArgumentMarshaling{Name: name, Type: fieldType}
+		if c.Name == "" {
+			c.Name = fmt.Sprintf("field%d", i)
+		}
+		if strings.HasPrefix(fieldType, "(") {
+			var fieldSuffix string
+			c.Components, fieldSuffix, err = parseTupleComponents(fieldType)
+			if err != nil {
+				return nil, "", err
+			}
+			c.Type = "tuple" + fieldSuffix
+		} else if c.Type, err = fixedIntType(fieldType); err != nil {
+			return nil, "", err
+		}
+		components = append(components, c)
+	}
+	return components, suffix, nil
+}
+
+// canonicalAbiType returns typ without its names, for method signatures.
+func canonicalAbiType(typ string) string {
+	typ = strings.TrimSpace(typ)
+	if !strings.HasPrefix(typ, "(") {
+		return canonicalFixedType(typ)
+	}
+	end := matchingParen(typ)
+	if end < 0 {
+		return typ
+	}
+	fields := splitAbiTypes(typ[1:end])
+	for i, field := range fields {
+		fieldType, _ := splitAbiTypeName(field)
+		fields[i] = canonicalAbiType(fieldType)
+	}
+	return "(" + strings.Join(fields, ",") + ")" + typ[end+1:]
+}
+
+// splitAbiTypes splits a list of types on its top-level commas.
+func splitAbiTypes(list string) []string {
+	var types []string
+	depth, start := 0, 0
+	for i, c := range list {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				types = append(types, strings.TrimSpace(list[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(list[start:]); last != "" || len(types) > 0 {
+		types = append(types, last)
+	}
+	return types
+}
+
+// splitAbiTypeName splits "uint256 amount" into its type and name.
+func splitAbiTypeName(field string) (string, string) {
+	field = strings.TrimSpace(field)
+	if strings.HasPrefix(field, "(") {
+		end := matchingParen(field)
+		if end < 0 {
+			return field, ""
+		}
+		rest := field[end+1:]
+		i := strings.LastIndex(rest, "]") + 1
+		return field[:end+1] + rest[:i], strings.TrimSpace(rest[i:])
+	}
+	f := strings.Fields(field)
+	if len(f) < 2 {
+		return field, ""
+	}
+	return f[0], f[len(f)-1]
+}
+
+func matchingParen(s string) int {
+	depth := 0
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// coerceAbiValue returns the value of the Go type of typ, ie. the one of
+// abi.Type.GetType, of a JSON or string node.
+func coerceAbiValue(typ abi.Type, node interface{}, path string) (reflect.Value, error) {
+	fail := func(format string, args ...interface{}) (reflect.Value, error) {
+		return reflect.Value{}, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...))
+	}
+	goType := typ.GetType()
+
+	switch typ.T {
+	case abi.IntTy, abi.UintTy:
+		n, ok := nodeBigInt(node)
+		if !ok {
+			return fail("expecting %s number, got %s", typ, nodeString(node))
+		}
+		if err := checkNumberRange(typ.String(), typ.T == abi.IntTy, typ.Size, n); err != nil {
+			return fail("%v", err)
+		}
+		v := reflect.New(goType).Elem()
+		switch goType.Kind() {
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			v.SetInt(n.Int64())
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			v.SetUint(n.Uint64())
+		default:
+			v.Set(reflect.ValueOf(n))
+		}
+		return v, nil
+
+	case abi.BoolTy:
+		switch v := node.(type) {
+		case bool:
+			return reflect.ValueOf(v), nil
+		case string:
+			if v == "true" || v == "false" {
+				return reflect.ValueOf(v == "true"), nil
+			}
+		}
+		return fail("expecting bool as 'true' or 'false', got %s", nodeString(node))
+
+	case abi.AddressTy:
+		s, ok := node.(string)
+		if !ok || !common.IsHexAddress(s) || !strings.HasPrefix(s, "0x") {
+			return fail("expecting address in hex, got %s", nodeString(node))
+		}
+		return reflect.ValueOf(common.HexToAddress(s)), nil
+
+	case abi.StringTy:
+		switch v := node.(type) {
+		case string:
+			return reflect.ValueOf(v), nil
+		case json.Number:
+			return reflect.ValueOf(v.String()), nil
+		}
+		return fail("expecting string, got %s", nodeString(node))
+
+	case abi.BytesTy, abi.FixedBytesTy:
+		s, ok := node.(string)
+		if !ok {
+			return fail("expecting %s in hex, got %s", typ, nodeString(node))
+		}
+		b, err := hexutil.Decode(s)
+		if err != nil {
+			return fail("expecting %s in hex, got %s: %v", typ, nodeString(node), err)
+		}
+		if typ.T == abi.BytesTy {
+			return reflect.ValueOf(b), nil
+		}
+		if len(b) != typ.Size {
+			return fail("expecting %d bytes for type %s, got %d", typ.Size, typ, len(b))
+		}
+		v := reflect.New(goType).Elem()
+		reflect.Copy(v, reflect.ValueOf(b))
+		return v, nil
+
+	case abi.SliceTy, abi.ArrayTy:
+		list, ok := node.([]interface{})
+		if !ok {
+			return fail("expecting JSON array for type %s, got %s", typ, nodeString(node))
+		}
+		var v reflect.Value
+		if typ.T == abi.SliceTy {
+			v = reflect.MakeSlice(goType, len(list), len(list))
+		} else {
+			if len(list) != typ.Size {
+				return fail("expecting %d elements for type %s, got %d", typ.Size, typ, len(list))
+			}
+			v = reflect.New(goType).Elem()
+		}
+		for i, elem := range list {
+			ev, err := coerceAbiValue(*typ.Elem, elem, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v.Index(i).Set(ev)
+		}
+		return v, nil
+
+	case abi.TupleTy:
+		v := reflect.New(goType).Elem()
+		switch fields := node.(type) {
+		case []interface{}:
+			if len(fields) != len(typ.TupleElems) {
+				return fail("expecting %d fields for type %s, got %d", len(typ.TupleElems), typ, len(fields))
+			}
+			for i, field := range fields {
+				fv, err := coerceAbiValue(*typ.TupleElems[i], field, fmt.Sprintf("%s[%d]", path, i))
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				v.Field(i).Set(fv)
+			}
+		case map[string]interface{}:
+			for i, name := range typ.TupleRawNames {
+				field, ok := fields[name]
+				if !ok {
+					return fail("missing field '%s' of type %s", name, typ)
+				}
+				fv, err := coerceAbiValue(*typ.TupleElems[i], field, path+"."+name)
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				v.Field(i).Set(fv)
+			}
+			if len(fields) != len(typ.TupleRawNames) {
+				return fail("unknown fields for type %s", typ)
+			}
+		default:
+			return fail("expecting JSON array or object for type %s, got %s", typ, nodeString(node))
+		}
+		return v, nil
+	}
+
+	return fail("unsupported type %s", typ)
+}
+
+// nodeBigInt returns the integer of a decimal or 0x hex string, or of a JSON
+// number.
+func nodeBigInt(node interface{}) (*big.Int, bool) {
+	var s string
+	switch v := node.(type) {
+	case string:
+		s = strings.TrimSpace(v)
+	case json.Number:
+		s = v.String()
+	default:
+		return nil, false
+	}
+	neg := strings.HasPrefix(s, "-")
+	digits := strings.TrimPrefix(s, "-")
+	base := 10
+	if strings.HasPrefix(digits, "0x") || strings.HasPrefix(digits, "0X") {
+		digits, base = digits[2:], 16
+	}
+	if digits == "" || strings.HasPrefix(digits, "+") || strings.HasPrefix(digits, "-") {
+		return nil, false
+	}
+	n, ok := new(big.Int).SetString(digits, base)
+	if !ok {
+		return nil, false
+	}
+	if neg {
+		n.Neg(n)
+	}
+	return n, true
+}
+
+func nodeString(node interface{}) string {
+	switch v := node.(type) {
+	case string:
+		return strconv.Quote(v)
+	case nil:
+		return "null"
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	if err := enc.Encode(node); err != nil {
+		return fmt.Sprintf("%v", node)
+	}
+	return strings.TrimSpace(buf.String())
+}
@@ -0,0 +1,66 @@
+package ethcoder
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeFromStrings(t *testing.T) {
+	to := common.HexToAddress("0x6615e4e985bf0d137196897dfa182dbd7127f54f")
+
+	data, err := EncodeFromStrings(
+		[]string{"address", "uint256", "int8", "bool", "bytes", "bytes2", "string", "uint64[]"},
+		[]string{to.Hex(), "0x7b", "-12", "true", "0xabcd", "0x0102", `"hello"`, `["1", 2, "0x3"]`},
+	)
+	require.NoError(t, err)
+	expected, err := AbiCoder(
+		[]string{"address", "uint256", "int8", "bool", "bytes", "bytes2", "string", "uint64[]"},
+		[]interface{}{to, big.NewInt(123), int8(-12), true, []byte{0xab, 0xcd}, [2]byte{1, 2}, "hello", []uint64{1, 2, 3}},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, expected, data)
+
+	// tuples, by position or by name
+	byPosition, err := EncodeFromStrings([]string{"(address to,uint256 amount)[]"}, []string{`[["` + to.Hex() + `", "5"]]`})
+	require.NoError(t, err)
+	byName, err := EncodeFromStrings([]string{"(address to,uint256 amount)[]"}, []string{`[{"to": "` + to.Hex() + `", "amount": 5}]`})
+	require.NoError(t, err)
+	assert.Equal(t, byPosition, byName)
+	expected, err = AbiCoder([]string{"uint256", "uint256", "address", "uint256"}, []interface{}{big.NewInt(32), big.NewInt(1), to, big.NewInt(5)})
+	require.NoError(t, err)
+	assert.Equal(t, expected, byName)
+
+	// fixed-point
+	data, err = EncodeFromStrings([]string{"ufixed64x2[]"}, []string{`["1.5", 2]`})
+	require.NoError(t, err)
+	expected, err = AbiCoder([]string{"uint64[]"}, []interface{}{[]uint64{150, 200}})
+	require.NoError(t, err)
+	assert.Equal(t, expected, data)
+}
+
+func TestEncodeFromStringsErrors(t *testing.T) {
+	cases := []struct {
+		types  []string
+		values []string
+		err    string
+	}{
+		{[]string{"uint8"}, []string{"256"}, "value 0: value 256 overflows type 'uint8'"},
+		{[]string{"bool", "uint256"}, []string{"true", "abc"}, `value 1: expecting uint256 number, got "abc"`},
+		{[]string{"uint8[]"}, []string{`[1, 2, -3]`}, "value 0[2]: negative value -3"},
+		{[]string{"(address to,uint256 amount)[]"}, []string{`[{"to": "0x01", "amount": 1}]`}, `value 0[0].to: expecting address in hex, got "0x01"`},
+		{[]string{"(address to,uint256 amount)"}, []string{`{"to": "0x6615e4e985bf0d137196897dfa182dbd7127f54f"}`}, "value 0: missing field 'amount'"},
+		{[]string{"bytes4"}, []string{"0x0102"}, "value 0: expecting 4 bytes for type bytes4, got 2"},
+		{[]string{"uint256[2]"}, []string{`[1]`}, "value 0: expecting 2 elements"},
+		{[]string{"uint256[]"}, []string{`[1,`}, "value 0: invalid JSON"},
+		{[]string{"bool"}, []string{"yes"}, "value 0: expecting bool"},
+		{[]string{"uint256"}, []string{}, "expecting 1 values, got 0"},
+	}
+	for _, c := range cases {
+		_, err := EncodeFromStrings(c.types, c.values)
+		assert.ErrorContains(t, err, c.err, c.types)
+	}
+}
@@ -139,6 +139,25 @@ func TestAbiEncodeMethodCalldata(t *testing.T) {
 		calldata, err = AbiEncodeMethodCalldataFromStringValues("getCurrencyReserves(uint256[])", []string{`["1","2","3"]`})
 		assert.NoError(t, err)
 		assert.Equal(t, "0x209b96c500000000000000000000000000000000000000000000000000000000000000200000000000000000000000000000000000000000000000000000000000000003000000000000000000000000000000000000000000000000000000000000000100000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000003", HexEncode(calldata)) // same as above
+
+		// the json numbers of the arrays
+		calldata, err = AbiEncodeMethodCalldataFromStringValues("getCurrencyReserves(uint256[])", []string{`[1,2,3]`})
+		assert.NoError(t, err)
+		assert.Equal(t, "0x209b96c500000000000000000000000000000000000000000000000000000000000000200000000000000000000000000000000000000000000000000000000000000003000000000000000000000000000000000000000000000000000000000000000100000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000003", HexEncode(calldata)) // same as above
+
+		calldata, err = AbiEncodeMethodCalldataFromStringValues("mint(address,uint256[])", []string{"0x6615e4e985bf0d137196897dfa182dbd7127f54f", `[1,2,3]`})
+		assert.NoError(t, err)
+		expected, err := AbiEncodeMethodCalldata("mint(address,uint256[])", []interface{}{common.HexToAddress("0x6615e4e985bf0d137196897dfa182dbd7127f54f"), []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}})
+		assert.NoError(t, err)
+		assert.Equal(t, expected, calldata)
+
+		_, err = AbiEncodeMethodCalldataFromStringValues("mint(address,uint256[])", []string{"0x6615e4e985bf0d137196897dfa182dbd7127f54f", `[1,"a"]`})
+		assert.Error(t, err)
+
+		// tuples and fixed-point numbers
+		calldata, err = AbiEncodeMethodCalldataFromStringValues("batch((address to, uint256 amount)[] transfers, ufixed rate)", []string{`[]`, "0.5"})
+		assert.NoError(t, err)
+		assert.Equal(t, Keccak256([]byte("batch((address,uint256)[],ufixed128x18)"))[:4], calldata[:4])
 	}
 }
 
@@ -315,3 +334,61 @@ func TestAbiUnmarshalStringValues(t *testing.T) {
 
 // 	spew.Dump(values)
 // }
+
+func TestAbiEncodingRanges(t *testing.T) {
+	_, err := AbiCoder([]string{"uint128"}, []interface{}{new(big.Int).Lsh(big.NewInt(1), 128)})
+	assert.ErrorContains(t, err, "overflows type 'uint128'")
+	_, err = AbiCoder([]string{"uint256"}, []interface{}{big.NewInt(-1)})
+	assert.ErrorContains(t, err, "negative value -1 for type 'uint256'")
+	_, err = AbiCoder([]string{"int256[]"}, []interface{}{[]*big.Int{big.NewInt(1), new(big.Int).Lsh(big.NewInt(1), 255)}})
+	assert.ErrorContains(t, err, "element 1")
+
+	_, err = AbiEncodeMethodCalldata("transfer(address,uint256)", []interface{}{common.Address{}, big.NewInt(-1)})
+	assert.ErrorContains(t, err, "value at position 1 is invalid")
+
+	_, err = AbiCoder([]string{"int256"}, []interface{}{big.NewInt(-1)})
+	assert.NoError(t, err)
+}
+
+func TestAbiCoderTuples(t *testing.T) {
+	to := common.HexToAddress("0x6615e4e985bf0d137196897dfa182dbd7127f54f")
+	expected, err := EncodeFromStrings([]string{"(address,uint256)[]", "(address to,uint256 amount)"}, []string{`[["` + to.Hex() + `", 5], ["` + to.Hex() + `", 6]]`, `["` + to.Hex() + `", 7]`})
+	assert.NoError(t, err)
+
+	// the values of the tuples as slices, maps or structs
+	type transfer struct {
+		To     common.Address
+		Amount *big.Int `abi:"amount"`
+	}
+	data, err := AbiCoder(
+		[]string{"(address,uint256)[]", "(address to,uint256 amount)"},
+		[]interface{}{
+			[]interface{}{[]interface{}{to, big.NewInt(5)}, transfer{To: to, Amount: big.NewInt(6)}},
+			map[string]interface{}{"to": to, "amount": big.NewInt(7)},
+		},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, data)
+
+	data, err = AbiCoder([]string{"(address to,uint256 amount)"}, []interface{}{&transfer{To: to, Amount: big.NewInt(7)}})
+	assert.NoError(t, err)
+	assert.Equal(t, expected[32:96], data)
+
+	// the tuples are decoded too
+	values, err := AbiDecoderWithReturnedValues([]string{"(address to,uint256 amount)"}, data)
+	assert.NoError(t, err)
+	assert.Len(t, values, 1)
+	var decoded transfer
+	assert.NoError(t, AbiDecodeInto([]string{"(address to,uint256 amount) transfer"}, data, &struct{ Transfer *transfer }{&decoded}))
+	assert.Equal(t, transfer{To: to, Amount: big.NewInt(7)}, decoded)
+
+	// the errors
+	_, err = AbiCoder([]string{"(address,uint256)"}, []interface{}{[]interface{}{to}})
+	assert.Error(t, err)
+	_, err = AbiCoder([]string{"(address to,uint256 amount)"}, []interface{}{map[string]interface{}{"to": to, "value": big.NewInt(1)}})
+	assert.Error(t, err)
+	_, err = AbiCoder([]string{"(address,uint256)"}, []interface{}{[]interface{}{to, "1"}})
+	assert.Error(t, err)
+	_, err = AbiCoder([]string{"(uint8)"}, []interface{}{[]interface{}{big.NewInt(256)}})
+	assert.Error(t, err)
+}
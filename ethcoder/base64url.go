@@ -0,0 +1,17 @@
+package ethcoder
+
+import (
+	"encoding/base64"
+	"strings"
+)
+
+// Base64URLEncode returns the unpadded base64url encoding of data, ie. of the
+// payloads of wallet deep links.
+func Base64URLEncode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Base64URLDecode decodes base64url, with or without its padding.
+func Base64URLDecode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
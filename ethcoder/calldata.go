@@ -0,0 +1,274 @@
+package ethcoder
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sync"
+
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+)
+
+// ErrUnknownSelector is returned when the selector of calldata is none of the
+// methods of a CalldataDecoder.
+var ErrUnknownSelector = errors.New("ethcoder: unknown selector")
+
+// CalldataDecoder decodes the calldata of the methods of many abis, ie. of the
+// transactions to any contract, by the selector of the calldata.
+type CalldataDecoder struct {
+	// methods by selector, in the order they were added
+	methods map[[4]byte][]abi.Method
+	mu      sync.RWMutex
+}
+
+// DecodedCall is calldata decoded by a CalldataDecoder.
+type DecodedCall struct {
+	// Name of the method, ie. transfer
+	Name string
+
+	// Signature of the method, ie. transfer(address,uint256)
+	Signature string
+
+	// Selector of the method, ie. 0xa9059cbb
+	Selector [4]byte
+
+	// Args are the arguments of the method, in order, with their values
+	Args []DecodedCallArg
+
+	// Values are the values of the arguments by name, in order, with the
+	// tuples by name recursively, ie. for json output
+	Values AbiValues
+}
+
+// DecodedCallArg is an argument of a DecodedCall.
+type DecodedCallArg struct {
+	Name  string
+	Type  string
+	Value interface{}
+}
+
+// Map returns the values of the arguments by name, where an unnamed argument is
+// named by its position, ie. arg0.
+func (c *DecodedCall) Map() map[string]interface{} {
+	m := make(map[string]interface{}, len(c.Args))
+	for i, arg := range c.Args {
+		name := arg.Name
+		if name == "" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		m[name] = arg.Value
+	}
+	return m
+}
+
+// DecodeCalldata decodes the calldata, ie. the input of a transaction, as the
+// method of its selector of the abi, see CalldataDecoder.
+func DecodeCalldata(contractABI abi.ABI, calldata []byte) (*DecodedCall, error) {
+	return NewCalldataDecoder(contractABI).Decode(calldata)
+}
+
+// NewCalldataDecoder returns a decoder of the methods of the abis.
+func NewCalldataDecoder(abis ...abi.ABI) *CalldataDecoder {
+	d := &CalldataDecoder{methods: map[[4]byte][]abi.Method{}}
+	for _, contractABI := range abis {
+		d.AddABI(contractABI)
+	}
+	return d
+}
+
+// AddABI adds the methods of the abi. The methods of a selector are tried in
+// the order they were added, ie. of colliding selectors.
+func (d *CalldataDecoder) AddABI(contractABI abi.ABI) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, method := range contractABI.Methods {
+		d.addMethod(method)
+	}
+}
+
+// AddSignatures adds the methods of human-readable function signatures, see
+// ParseABIFunction, ie. "transfer(address to, uint256 amount)".
+func (d *CalldataDecoder) AddSignatures(sigs ...string) error {
+	methods := make([]abi.Method, 0, len(sigs))
+	for _, sig := range sigs {
+		method, err := ParseABIFunction(sig)
+		if err != nil {
+			return err
+		}
+		methods = append(methods, *method)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, method := range methods {
+		d.addMethod(method)
+	}
+	return nil
+}
+
+func (d *CalldataDecoder) addMethod(method abi.Method) {
+	var selector [4]byte
+	copy(selector[:], method.ID)
+	for _, m := range d.methods[selector] {
+		if m.Sig == method.Sig {
+			// the same method of another abi
+			return
+		}
+	}
+	d.methods[selector] = append(d.methods[selector], method)
+}
+
+// Decode decodes the calldata as the first method of its selector to decode it.
+// It returns ErrUnknownSelector when the selector is none of the methods.
+func (d *CalldataDecoder) Decode(calldata []byte) (*DecodedCall, error) {
+	if len(calldata) < 4 {
+		return nil, fmt.Errorf("ethcoder: calldata of %d bytes has no selector", len(calldata))
+	}
+	var selector [4]byte
+	copy(selector[:], calldata)
+
+	d.mu.RLock()
+	methods := d.methods[selector]
+	d.mu.RUnlock()
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("%w %s", ErrUnknownSelector, HexEncode(selector[:]))
+	}
+
+	var errs []error
+	for _, method := range methods {
+		values, err := method.Inputs.UnpackValues(calldata[4:])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", method.Sig, err))
+			continue
+		}
+		decoded := &DecodedCall{
+			Name:      method.RawName,
+			Signature: method.Sig,
+			Selector:  selector,
+			Args:      make([]DecodedCallArg, len(method.Inputs)),
+		}
+		for i, input := range method.Inputs {
+			decoded.Args[i] = DecodedCallArg{Name: input.Name, Type: input.Type.String(), Value: values[i]}
+		}
+		decoded.Values = NewAbiValues(method.Inputs, values)
+		return decoded, nil
+	}
+	return nil, fmt.Errorf("ethcoder: failed to decode calldata: %w", errors.Join(errs...))
+}
+
+// AbiValues are abi values by name, in order, of which the json is an object of
+// the values in order. The bytes are marshaled as hex, and the integers as
+// decimal strings.
+type AbiValues []AbiValue
+
+type AbiValue struct {
+	Name  string
+	Value interface{}
+}
+
+// NewAbiValues returns the decoded values of the args by name, where an unnamed
+// argument is named by its position, ie. arg0, and a tuple is the AbiValues of
+// its components, where an unnamed component is named as of field0. The values
+// of arrays are []interface{}.
+func NewAbiValues(args abi.Arguments, values []interface{}) AbiValues {
+	v := make(AbiValues, len(args))
+	for i, arg := range args {
+		name := arg.Name
+		if name == "" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		v[i] = AbiValue{Name: name, Value: abiValueOf(arg.Type, reflect.ValueOf(values[i]))}
+	}
+	return v
+}
+
+func abiValueOf(typ abi.Type, v reflect.Value) interface{} {
+	switch typ.T {
+	case abi.TupleTy:
+		values := make(AbiValues, len(typ.TupleElems))
+		for i, elem := range typ.TupleElems {
+			name := typ.TupleRawNames[i]
+			if name == "" {
+				name = fmt.Sprintf("field%d", i)
+			}
+			values[i] = AbiValue{Name: name, Value: abiValueOf(*elem, v.Field(i))}
+		}
+		return values
+	case abi.SliceTy, abi.ArrayTy:
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			values[i] = abiValueOf(*typ.Elem, v.Index(i))
+		}
+		return values
+	}
+	return v.Interface()
+}
+
+// Get returns the value of the name.
+func (v AbiValues) Get(name string) (interface{}, bool) {
+	for _, value := range v {
+		if value.Name == name {
+			return value.Value, true
+		}
+	}
+	return nil, false
+}
+
+func (v AbiValues) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, value := range v {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(value.Name)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(jsonAbiValue(value.Value))
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(data)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// jsonAbiValue returns the json value of an abi value, of the bytes as hex and
+// of the integers as decimal strings, ie. as they are in javascript.
+func jsonAbiValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *big.Int:
+		return v.String()
+	case AbiValues, encoding.TextMarshaler:
+		return v
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i := range v {
+			values[i] = jsonAbiValue(v[i])
+		}
+		return values
+	case []byte:
+		return hexutil.Encode(v)
+	}
+
+	rv := reflect.ValueOf(value)
+	switch {
+	case rv.Kind() == reflect.Array && rv.Type().Elem().Kind() == reflect.Uint8:
+		// ie. bytes32
+		b := make([]byte, rv.Len())
+		reflect.Copy(reflect.ValueOf(b), rv)
+		return hexutil.Encode(b)
+	case rv.Kind() >= reflect.Int && rv.Kind() <= reflect.Uint64:
+		return fmt.Sprint(value)
+	}
+	return value
+}
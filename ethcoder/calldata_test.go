@@ -0,0 +1,85 @@
+package ethcoder_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalldataDecoder(t *testing.T) {
+	erc20, err := ethcoder.ParseHumanReadableABI([]string{
+		"function transfer(address to, uint256 amount) returns (bool)",
+		"function approve(address spender, uint256 amount) returns (bool)",
+	})
+	require.NoError(t, err)
+	decoder := ethcoder.NewCalldataDecoder(*erc20)
+	require.NoError(t, decoder.AddSignatures("transfer(address,uint256)", "swap((address to, uint256 amount)[] orders, bytes data)"))
+
+	to := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	calldata, err := ethcoder.AbiEncodeMethodCalldata("transfer(address,uint256)", []interface{}{to, big.NewInt(5)})
+	require.NoError(t, err)
+	decoded, err := decoder.Decode(calldata)
+	require.NoError(t, err)
+	assert.Equal(t, "transfer", decoded.Name)
+	assert.Equal(t, "transfer(address,uint256)", decoded.Signature)
+	assert.Equal(t, "0xa9059cbb", ethcoder.HexEncode(decoded.Selector[:]))
+	// the arguments of the first abi of the method
+	assert.Equal(t, map[string]interface{}{"to": to, "amount": big.NewInt(5)}, decoded.Map())
+
+	calldata, err = ethcoder.AbiEncodeMethodCalldataFromStringValues("swap((address to, uint256 amount)[] orders, bytes data)", []string{`[["` + to.Hex() + `", 1]]`, "0x01"})
+	require.NoError(t, err)
+	decoded, err = decoder.Decode(calldata)
+	require.NoError(t, err)
+	assert.Equal(t, "swap((address,uint256)[],bytes)", decoded.Signature)
+	require.Len(t, decoded.Args, 2)
+	assert.Equal(t, "(address,uint256)[]", decoded.Args[0].Type)
+	assert.Equal(t, []byte{0x01}, decoded.Args[1].Value)
+
+	// unknown selectors, and invalid calldata
+	_, err = decoder.Decode(common.FromHex("0x12345678"))
+	assert.ErrorIs(t, err, ethcoder.ErrUnknownSelector)
+	_, err = decoder.Decode(calldata[:40])
+	assert.Error(t, err)
+	_, err = decoder.Decode(nil)
+	assert.Error(t, err)
+	assert.Error(t, decoder.AddSignatures("transfer(address"))
+}
+
+func TestDecodeCalldata(t *testing.T) {
+	contractABI, err := ethcoder.ParseHumanReadableABI([]string{
+		"function fill((address maker, (uint256 amount, bytes32 id)[] parts) order, uint8, bytes signature)",
+	})
+	require.NoError(t, err)
+
+	maker := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	calldata, err := ethcoder.AbiEncodeMethodCalldataFromStringValues("fill((address maker, (uint256 amount, bytes32 id)[] parts) order, uint8, bytes signature)", []string{
+		`["` + maker.Hex() + `", [[1000000000000000000000, "0x0000000000000000000000000000000000000000000000000000000000000001"]]]`, "7", "0xabcd",
+	})
+	require.NoError(t, err)
+
+	decoded, err := ethcoder.DecodeCalldata(*contractABI, calldata)
+	require.NoError(t, err)
+	assert.Equal(t, "fill", decoded.Name)
+	require.Len(t, decoded.Values, 3)
+	assert.Equal(t, "order", decoded.Values[0].Name)
+	assert.Equal(t, "arg1", decoded.Values[1].Name)
+	assert.Equal(t, uint8(7), decoded.Values[1].Value)
+
+	order, ok := decoded.Values.Get("order")
+	require.True(t, ok)
+	parts, _ := order.(ethcoder.AbiValues).Get("parts")
+	amount, _ := parts.([]interface{})[0].(ethcoder.AbiValues).Get("amount")
+	assert.Equal(t, "1000000000000000000000", amount.(*big.Int).String())
+
+	data, err := json.Marshal(decoded.Values)
+	require.NoError(t, err)
+	assert.Equal(t, `{"order":{"maker":"`+maker.Hex()+`","parts":[{"amount":"1000000000000000000000","id":"0x0000000000000000000000000000000000000000000000000000000000000001"}]},"arg1":"7","signature":"0xabcd"}`, string(data))
+
+	_, err = ethcoder.DecodeCalldata(*contractABI, common.FromHex("0xa9059cbb"))
+	assert.ErrorIs(t, err, ethcoder.ErrUnknownSelector)
+}
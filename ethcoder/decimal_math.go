@@ -0,0 +1,207 @@
+package ethcoder
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var ErrDivisionByZero = errors.New("ethcoder: division by zero")
+
+// RoundingMode is the rounding of the results of Decimal and token amount math
+// that don't fit their scale.
+type RoundingMode int
+
+const (
+	// RoundDown rounds towards zero, ie. truncates.
+	RoundDown RoundingMode = iota
+	// RoundFloor rounds towards negative infinity.
+	RoundFloor
+	// RoundCeil rounds towards positive infinity.
+	RoundCeil
+	// RoundHalfUp rounds to the nearest, and the halves away from zero.
+	RoundHalfUp
+	// RoundHalfEven rounds to the nearest, and the halves to the even neighbour,
+	// ie. banker's rounding.
+	RoundHalfEven
+)
+
+func (m RoundingMode) String() string {
+	switch m {
+	case RoundDown:
+		return "down"
+	case RoundFloor:
+		return "floor"
+	case RoundCeil:
+		return "ceil"
+	case RoundHalfUp:
+		return "half-up"
+	case RoundHalfEven:
+		return "half-even"
+	}
+	return fmt.Sprintf("RoundingMode(%d)", int(m))
+}
+
+// QuoRound returns x/y rounded with the mode.
+func QuoRound(x, y *big.Int, mode RoundingMode) (*big.Int, error) {
+	if y.Sign() == 0 {
+		return nil, ErrDivisionByZero
+	}
+	q, r := new(big.Int).QuoRem(x, y, new(big.Int))
+	if r.Sign() == 0 {
+		return q, nil
+	}
+
+	// the sign of the exact result, as q is truncated towards zero
+	sign := x.Sign() * y.Sign()
+	var away bool
+	switch mode {
+	case RoundDown:
+	case RoundFloor:
+		away = sign < 0
+	case RoundCeil:
+		away = sign > 0
+	case RoundHalfUp, RoundHalfEven:
+		cmp := new(big.Int).Abs(new(big.Int).Lsh(r, 1)).Cmp(new(big.Int).Abs(y))
+		away = cmp > 0 || (cmp == 0 && (mode == RoundHalfUp || q.Bit(0) == 1))
+	default:
+		return nil, fmt.Errorf("ethcoder: invalid rounding mode %d", int(mode))
+	}
+	if away {
+		q.Add(q, big.NewInt(int64(sign)))
+	}
+	return q, nil
+}
+
+// MulDiv returns x*y/denom rounded with the mode, without the precision loss of
+// dividing first.
+func MulDiv(x, y, denom *big.Int, mode RoundingMode) (*big.Int, error) {
+	return QuoRound(new(big.Int).Mul(x, y), denom, mode)
+}
+
+// ConvertDecimals converts the amount of a token of fromDecimals to the amount of
+// a token of toDecimals, ie. 1.5 USDC of 6 decimals to 1.5e18 of 18 decimals.
+func ConvertDecimals(amount *big.Int, fromDecimals, toDecimals int, mode RoundingMode) (*big.Int, error) {
+	if fromDecimals < 0 || toDecimals < 0 {
+		return nil, fmt.Errorf("ethcoder: invalid decimals %d to %d", fromDecimals, toDecimals)
+	}
+	if toDecimals >= fromDecimals {
+		return new(big.Int).Mul(amount, pow10(toDecimals-fromDecimals)), nil
+	}
+	return QuoRound(amount, pow10(fromDecimals-toDecimals), mode)
+}
+
+// BpsOf returns bps basis points of the amount, ie. the fee of 30 bps.
+func BpsOf(amount *big.Int, bps int64, mode RoundingMode) (*big.Int, error) {
+	return MulDiv(amount, big.NewInt(bps), big.NewInt(10000), mode)
+}
+
+// PercentOf returns percent percent of the amount, ie. of 0.5 for 0.5%.
+func PercentOf(amount *big.Int, percent Decimal, mode RoundingMode) (*big.Int, error) {
+	p := percent.normalized()
+	return MulDiv(amount, p.Value, new(big.Int).Mul(big.NewInt(100), pow10(p.Scale)), mode)
+}
+
+// WithSlippage returns the bounds of the amount with a slippage of bps basis
+// points: the min is rounded down and the max rounded up, so that both are
+// within the tolerance.
+func WithSlippage(amount *big.Int, bps int64) (*big.Int, *big.Int, error) {
+	if bps < 0 || bps > 10000 {
+		return nil, nil, fmt.Errorf("ethcoder: invalid slippage of %d bps", bps)
+	}
+	lo, err := MulDiv(amount, big.NewInt(10000-bps), big.NewInt(10000), RoundFloor)
+	if err != nil {
+		return nil, nil, err
+	}
+	hi, err := MulDiv(amount, big.NewInt(10000+bps), big.NewInt(10000), RoundCeil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return lo, hi, nil
+}
+
+// Round returns d at the scale, rounded with the mode.
+func (d Decimal) Round(scale int, mode RoundingMode) (Decimal, error) {
+	n, err := d.RescaleRound(scale, mode)
+	if err != nil {
+		return Decimal{}, err
+	}
+	return Decimal{Value: n, Scale: scale}, nil
+}
+
+// RescaleRound returns the value of d at the scale like Rescale, rounded with the
+// mode instead of failing on a loss of precision.
+func (d Decimal) RescaleRound(scale int, mode RoundingMode) (*big.Int, error) {
+	if scale < 0 {
+		return nil, fmt.Errorf("ethcoder: invalid scale %d", scale)
+	}
+	d = d.normalized()
+	if scale >= d.Scale {
+		return new(big.Int).Mul(d.Value, pow10(scale-d.Scale)), nil
+	}
+	return QuoRound(d.Value, pow10(d.Scale-scale), mode)
+}
+
+func (d Decimal) Add(o Decimal) Decimal {
+	x, y, scale := alignDecimals(d, o)
+	return Decimal{Value: x.Add(x, y), Scale: scale}
+}
+
+func (d Decimal) Sub(o Decimal) Decimal {
+	x, y, scale := alignDecimals(d, o)
+	return Decimal{Value: x.Sub(x, y), Scale: scale}
+}
+
+// Mul returns the exact d*o, of the sum of their scales.
+func (d Decimal) Mul(o Decimal) Decimal {
+	d, o = d.normalized(), o.normalized()
+	return Decimal{Value: new(big.Int).Mul(d.Value, o.Value), Scale: d.Scale + o.Scale}
+}
+
+// Quo returns d/o at the scale, rounded with the mode.
+func (d Decimal) Quo(o Decimal, scale int, mode RoundingMode) (Decimal, error) {
+	if scale < 0 {
+		return Decimal{}, fmt.Errorf("ethcoder: invalid scale %d", scale)
+	}
+	d, o = d.normalized(), o.normalized()
+
+	// d/o * 10^scale = d.Value * 10^(scale + o.Scale - d.Scale) / o.Value
+	x := new(big.Int).Set(d.Value)
+	y := new(big.Int).Set(o.Value)
+	if exp := scale + o.Scale - d.Scale; exp >= 0 {
+		x.Mul(x, pow10(exp))
+	} else {
+		y.Mul(y, pow10(-exp))
+	}
+	n, err := QuoRound(x, y, mode)
+	if err != nil {
+		return Decimal{}, err
+	}
+	return Decimal{Value: n, Scale: scale}, nil
+}
+
+// Cmp compares d and o, like big.Int.Cmp.
+func (d Decimal) Cmp(o Decimal) int {
+	x, y, _ := alignDecimals(d, o)
+	return x.Cmp(y)
+}
+
+// normalized returns d with a non-nil value and a non-negative scale.
+func (d Decimal) normalized() Decimal {
+	if d.Value == nil {
+		return Decimal{Value: new(big.Int), Scale: max(d.Scale, 0)}
+	}
+	if d.Scale < 0 {
+		return Decimal{Value: new(big.Int).Mul(d.Value, pow10(-d.Scale))}
+	}
+	return d
+}
+
+// alignDecimals returns the values of d and o at the largest of their scales.
+func alignDecimals(d, o Decimal) (*big.Int, *big.Int, int) {
+	d, o = d.normalized(), o.normalized()
+	scale := max(d.Scale, o.Scale)
+	x := new(big.Int).Mul(d.Value, pow10(scale-d.Scale))
+	y := new(big.Int).Mul(o.Value, pow10(scale-o.Scale))
+	return x, y, scale
+}
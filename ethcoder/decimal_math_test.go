@@ -0,0 +1,100 @@
+package ethcoder
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuoRound(t *testing.T) {
+	cases := []struct {
+		x, y int64
+		mode RoundingMode
+		out  int64
+	}{
+		{7, 2, RoundDown, 3},
+		{-7, 2, RoundDown, -3},
+		{7, 2, RoundFloor, 3},
+		{-7, 2, RoundFloor, -4},
+		{7, 2, RoundCeil, 4},
+		{-7, 2, RoundCeil, -3},
+		{7, 2, RoundHalfUp, 4},
+		{-7, 2, RoundHalfUp, -4},
+		{5, 2, RoundHalfEven, 2},
+		{7, 2, RoundHalfEven, 4},
+		{-5, 2, RoundHalfEven, -2},
+		{8, 3, RoundHalfEven, 3},
+		{7, 3, RoundHalfEven, 2},
+		{6, 3, RoundCeil, 2},
+	}
+	for _, c := range cases {
+		q, err := QuoRound(big.NewInt(c.x), big.NewInt(c.y), c.mode)
+		require.NoError(t, err)
+		assert.Equal(t, c.out, q.Int64(), "%d/%d %s", c.x, c.y, c.mode)
+	}
+
+	_, err := QuoRound(big.NewInt(1), big.NewInt(0), RoundDown)
+	assert.ErrorIs(t, err, ErrDivisionByZero)
+}
+
+func TestTokenMath(t *testing.T) {
+	// 1.5 USDC of 6 decimals to 18 decimals, and back with rounding
+	n, err := ConvertDecimals(big.NewInt(1500000), 6, 18, RoundDown)
+	require.NoError(t, err)
+	assert.Equal(t, "1500000000000000000", n.String())
+	n, err = ConvertDecimals(big.NewInt(1500000500000000000), 18, 6, RoundDown)
+	require.NoError(t, err)
+	assert.Equal(t, "1500000", n.String())
+	n, err = ConvertDecimals(big.NewInt(1500000500000000000), 18, 6, RoundCeil)
+	require.NoError(t, err)
+	assert.Equal(t, "1500001", n.String())
+
+	// a fee of 30 bps
+	n, err = BpsOf(big.NewInt(1001), 30, RoundDown)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n.Int64())
+	n, err = BpsOf(big.NewInt(1001), 30, RoundCeil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), n.Int64())
+
+	n, err = PercentOf(big.NewInt(1000), Decimal{big.NewInt(25), 1}, RoundDown)
+	require.NoError(t, err)
+	assert.Equal(t, int64(25), n.Int64())
+
+	lo, hi, err := WithSlippage(big.NewInt(999), 50)
+	require.NoError(t, err)
+	assert.Equal(t, int64(994), lo.Int64())
+	assert.Equal(t, int64(1004), hi.Int64())
+	_, _, err = WithSlippage(big.NewInt(999), 10001)
+	assert.Error(t, err)
+}
+
+func TestDecimalMath(t *testing.T) {
+	a, err := ParseDecimal("1.25")
+	require.NoError(t, err)
+	b, err := ParseDecimal("0.5")
+	require.NoError(t, err)
+
+	assert.Equal(t, "1.75", a.Add(b).String())
+	assert.Equal(t, "0.75", a.Sub(b).String())
+	assert.Equal(t, "0.625", a.Mul(b).String())
+	assert.Equal(t, 1, a.Cmp(b))
+
+	q, err := a.Quo(Decimal{big.NewInt(3), 0}, 4, RoundHalfEven)
+	require.NoError(t, err)
+	assert.Equal(t, "0.4167", q.String())
+	_, err = a.Quo(Decimal{}, 4, RoundHalfEven)
+	assert.ErrorIs(t, err, ErrDivisionByZero)
+
+	r, err := a.Round(1, RoundHalfEven)
+	require.NoError(t, err)
+	assert.Equal(t, "1.2", r.String())
+	r, err = a.Round(1, RoundHalfUp)
+	require.NoError(t, err)
+	assert.Equal(t, "1.3", r.String())
+	r, err = a.Round(4, RoundDown)
+	require.NoError(t, err)
+	assert.Equal(t, "1.2500", r.String())
+}
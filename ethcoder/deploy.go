@@ -0,0 +1,61 @@
+package ethcoder
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+)
+
+// DeployData returns the deploy data of a contract, ie. its creation bytecode
+// followed by the abi encoding of the arguments of its constructor.
+func DeployData(bytecode []byte, contractABI abi.ABI, args ...interface{}) ([]byte, error) {
+	inputs := contractABI.Constructor.Inputs
+	if len(args) != len(inputs) {
+		return nil, fmt.Errorf("ethcoder: constructor expects %d arguments, got %d", len(inputs), len(args))
+	}
+	encodedArgs, err := contractABI.Pack("", args...)
+	if err != nil {
+		return nil, fmt.Errorf("ethcoder: failed to encode constructor arguments: %w", err)
+	}
+	return append(append([]byte{}, bytecode...), encodedArgs...), nil
+}
+
+// DeployDataFromStrings returns the deploy data of a contract with the string
+// values of the arguments of its constructor, see EncodeFromStrings.
+func DeployDataFromStrings(bytecode []byte, contractABI abi.ABI, args []string) ([]byte, error) {
+	inputs := contractABI.Constructor.Inputs
+	argTypes := make([]string, len(inputs))
+	for i, input := range inputs {
+		argTypes[i] = input.Type.String()
+	}
+	encodedArgs, err := EncodeFromStrings(argTypes, args)
+	if err != nil {
+		return nil, fmt.Errorf("ethcoder: constructor arguments: %w", err)
+	}
+	return append(append([]byte{}, bytecode...), encodedArgs...), nil
+}
+
+// SplitDeployData returns the encoded constructor arguments of deploy data of
+// the creation bytecode, ie. of a contract creation transaction, as expected by
+// the contract verification of Etherscan.
+func SplitDeployData(deployData []byte, bytecode []byte) ([]byte, error) {
+	if len(bytecode) == 0 || !bytes.HasPrefix(deployData, bytecode) {
+		return nil, fmt.Errorf("ethcoder: deploy data is not of the creation bytecode")
+	}
+	return deployData[len(bytecode):], nil
+}
+
+// DecodeConstructorArgs returns the values of the constructor arguments of
+// deploy data of the creation bytecode, see SplitDeployData.
+func DecodeConstructorArgs(contractABI abi.ABI, deployData []byte, bytecode []byte) ([]interface{}, error) {
+	encodedArgs, err := SplitDeployData(deployData, bytecode)
+	if err != nil {
+		return nil, err
+	}
+	values, err := contractABI.Constructor.Inputs.UnpackValues(encodedArgs)
+	if err != nil {
+		return nil, fmt.Errorf("ethcoder: failed to decode constructor arguments: %w", err)
+	}
+	return values, nil
+}
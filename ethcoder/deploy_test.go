@@ -0,0 +1,46 @@
+package ethcoder_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeployData(t *testing.T) {
+	contractABI, err := ethcoder.ParseHumanReadableABI([]string{"constructor(address owner, uint256 supply)"})
+	require.NoError(t, err)
+	bytecode := common.FromHex("0x6080604052348015600f57600080fd5b50")
+	owner := common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+	deployData, err := ethcoder.DeployData(bytecode, *contractABI, owner, big.NewInt(1000))
+	require.NoError(t, err)
+	encodedArgs, err := ethcoder.AbiCoder([]string{"address", "uint256"}, []interface{}{owner, big.NewInt(1000)})
+	require.NoError(t, err)
+	assert.Equal(t, append(append([]byte{}, bytecode...), encodedArgs...), deployData)
+
+	fromStrings, err := ethcoder.DeployDataFromStrings(bytecode, *contractABI, []string{owner.Hex(), "1000"})
+	require.NoError(t, err)
+	assert.Equal(t, deployData, fromStrings)
+
+	// the inverse
+	split, err := ethcoder.SplitDeployData(deployData, bytecode)
+	require.NoError(t, err)
+	assert.Equal(t, encodedArgs, split)
+	values, err := ethcoder.DecodeConstructorArgs(*contractABI, deployData, bytecode)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{owner, big.NewInt(1000)}, values)
+
+	// the errors
+	_, err = ethcoder.DeployData(bytecode, *contractABI, owner)
+	assert.Error(t, err)
+	_, err = ethcoder.DeployDataFromStrings(bytecode, *contractABI, []string{owner.Hex(), "-1"})
+	assert.Error(t, err)
+	_, err = ethcoder.SplitDeployData(deployData, common.FromHex("0x6060"))
+	assert.Error(t, err)
+	_, err = ethcoder.DecodeConstructorArgs(*contractABI, deployData[:len(deployData)-1], bytecode)
+	assert.Error(t, err)
+}
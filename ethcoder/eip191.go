@@ -0,0 +1,44 @@
+package ethcoder
+
+import (
+	"strconv"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// The EIP-191 versions of signed data, of 0x19 <version> <version data> <data>.
+const (
+	EIP191VersionIntendedValidator byte = 0x00
+	EIP191VersionStructuredData    byte = 0x01
+	EIP191VersionPersonalMessage   byte = 0x45
+)
+
+const eip191PersonalMessagePrefix = "\x19Ethereum Signed Message:\n"
+
+// EIP191PersonalMessage returns the version 0x45 signed data of message, ie.
+// "\x19Ethereum Signed Message:\n" <len(message)> <message>, of eth_sign and
+// personal_sign.
+func EIP191PersonalMessage(message []byte) []byte {
+	data := make([]byte, 0, len(eip191PersonalMessagePrefix)+20+len(message))
+	data = append(data, eip191PersonalMessagePrefix...)
+	data = strconv.AppendInt(data, int64(len(message)), 10)
+	return append(data, message...)
+}
+
+// EIP191PersonalMessageDigest returns the digest of the version 0x45 signed data
+// of message.
+func EIP191PersonalMessageDigest(message []byte) common.Hash {
+	return Keccak256Hash(EIP191PersonalMessage(message))
+}
+
+// EIP191IntendedValidatorDigest returns the digest of the version 0x00 signed
+// data of the intended validator, ie. 0x19 0x00 <validator> <data>.
+func EIP191IntendedValidatorDigest(validator common.Address, data []byte) common.Hash {
+	return Keccak256Hash([]byte{0x19, EIP191VersionIntendedValidator}, validator.Bytes(), data)
+}
+
+// EIP191StructuredDataDigest returns the digest of the version 0x01 signed data
+// of EIP-712, ie. 0x19 0x01 <domain separator> <hash of the struct>.
+func EIP191StructuredDataDigest(domainSeparator, structHash common.Hash) common.Hash {
+	return Keccak256Hash([]byte{0x19, EIP191VersionStructuredData}, domainSeparator.Bytes(), structHash.Bytes())
+}
@@ -0,0 +1,30 @@
+package ethcoder_test
+
+import (
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEIP191(t *testing.T) {
+	message := []byte("hello world")
+	assert.Equal(t, "\x19Ethereum Signed Message:\n11hello world", string(ethcoder.EIP191PersonalMessage(message)))
+	assert.Equal(t, common.BytesToHash(accounts.TextHash(message)), ethcoder.EIP191PersonalMessageDigest(message))
+	assert.Equal(t, common.BytesToHash(accounts.TextHash(nil)), ethcoder.EIP191PersonalMessageDigest(nil))
+
+	validator := common.HexToAddress("0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC")
+	assert.Equal(t,
+		ethcoder.Keccak256Hash(ethcoder.MustHexDecode("0x1900cccccccccccccccccccccccccccccccccccccccc"), message),
+		ethcoder.EIP191IntendedValidatorDigest(validator, message),
+	)
+
+	domainSeparator := ethcoder.Keccak256Hash([]byte("domain"))
+	structHash := ethcoder.Keccak256Hash([]byte("struct"))
+	assert.Equal(t,
+		ethcoder.Keccak256Hash([]byte{0x19, 0x01}, domainSeparator.Bytes(), structHash.Bytes()),
+		ethcoder.EIP191StructuredDataDigest(domainSeparator, structHash),
+	)
+}
@@ -0,0 +1,103 @@
+package ethcoder
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// EventFilter builds the topics of a logs filter of an event of the values of its
+// indexed arguments by name, ie.
+//
+//	query, err := NewEventFilter(transfer).Where("to", addr).FilterQuery(token)
+//
+// of the transfers of any sender to addr. The values are of EventTopicValue, or
+// hashed of the dynamic types. The errors are returned by Topics.
+type EventFilter struct {
+	event   abi.Event
+	indexed []abi.Argument
+	values  []interface{}
+	err     error
+}
+
+// NewEventFilter returns a filter of the event, which matches any values.
+func NewEventFilter(event abi.Event) *EventFilter {
+	f := &EventFilter{event: event}
+	for _, input := range event.Inputs {
+		if input.Indexed {
+			f.indexed = append(f.indexed, input)
+		}
+	}
+	f.values = make([]interface{}, len(f.indexed))
+	return f
+}
+
+// NewEventFilterFromSignature returns a filter of the event of a human-readable
+// signature, see ParseABIEvent, ie.
+// "event Transfer(address indexed from, address indexed to, uint256 value)".
+func NewEventFilterFromSignature(event string) (*EventFilter, error) {
+	abiEvent, err := ParseABIEvent(event)
+	if err != nil {
+		return nil, err
+	}
+	return NewEventFilter(*abiEvent), nil
+}
+
+// Where matches the indexed argument of the name to any of the values, or to any
+// value when there are none.
+func (f *EventFilter) Where(name string, values ...interface{}) *EventFilter {
+	if f.err != nil {
+		return f
+	}
+	for i, arg := range f.indexed {
+		if arg.Name != name {
+			continue
+		}
+		switch len(values) {
+		case 0:
+			f.values[i] = nil
+		case 1:
+			f.values[i] = values[0]
+		default:
+			f.values[i] = EventTopicOneOf(values)
+		}
+		return f
+	}
+	for _, input := range f.event.Inputs {
+		if input.Name == name {
+			f.err = fmt.Errorf("ethcoder: argument %s of event %s is not indexed", name, f.event.Name)
+			return f
+		}
+	}
+	f.err = fmt.Errorf("ethcoder: event %s has no indexed argument %s, expected one of %s", f.event.Name, name, strings.Join(f.names(), ", "))
+	return f
+}
+
+// Topics returns the topics of the filter, see EventFilterTopics.
+func (f *EventFilter) Topics() ([][]common.Hash, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return EventFilterTopics(f.event, f.values...)
+}
+
+// FilterQuery returns the logs filter of the topics of the filter, of the logs
+// of the addresses, or of any contract when there are none.
+func (f *EventFilter) FilterQuery(addresses ...common.Address) (ethereum.FilterQuery, error) {
+	topics, err := f.Topics()
+	if err != nil {
+		return ethereum.FilterQuery{}, err
+	}
+	return ethereum.FilterQuery{Addresses: addresses, Topics: topics}, nil
+}
+
+func (f *EventFilter) names() []string {
+	names := make([]string, len(f.indexed))
+	for i, arg := range f.indexed {
+		names[i] = arg.Name
+	}
+	return names
+}
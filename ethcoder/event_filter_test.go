@@ -0,0 +1,68 @@
+package ethcoder_test
+
+import (
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventFilter(t *testing.T) {
+	token := common.HexToAddress("0x2000000000000000000000000000000000000002")
+	alice := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	bob := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	transferTopic := common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+
+	filter, err := ethcoder.NewEventFilterFromSignature("event Transfer(address indexed from, address indexed to, uint256 value)")
+	require.NoError(t, err)
+
+	query, err := filter.Where("to", alice).FilterQuery(token)
+	require.NoError(t, err)
+	assert.Equal(t, []common.Address{token}, query.Addresses)
+	assert.Equal(t, [][]common.Hash{{transferTopic}, nil, {common.BytesToHash(alice.Bytes())}}, query.Topics)
+
+	// the any of the values, and the trailing wildcards
+	topics, err := filter.Where("from", alice, bob).Where("to").Topics()
+	require.NoError(t, err)
+	assert.Equal(t, [][]common.Hash{{transferTopic}, {common.BytesToHash(alice.Bytes()), common.BytesToHash(bob.Bytes())}}, topics)
+
+	t.Run("dynamic types", func(t *testing.T) {
+		filter, err := ethcoder.NewEventFilterFromSignature("event Registered(string indexed name, bytes indexed data, address owner)")
+		require.NoError(t, err)
+		topics, err := filter.Where("name", "alice.eth").Where("data", []byte{1, 2}).Topics()
+		require.NoError(t, err)
+		assert.Equal(t, ethcoder.Keccak256Hash([]byte("alice.eth")), topics[1][0])
+		assert.Equal(t, ethcoder.Keccak256Hash([]byte{1, 2}), topics[2][0])
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		filter, err := ethcoder.NewEventFilterFromSignature("event Ping(address indexed from) anonymous")
+		require.NoError(t, err)
+		topics, err := filter.Where("from", alice).Topics()
+		require.NoError(t, err)
+		assert.Equal(t, [][]common.Hash{{common.BytesToHash(alice.Bytes())}}, topics)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		filter, err := ethcoder.NewEventFilterFromSignature("event Transfer(address indexed from, address indexed to, uint256 value)")
+		require.NoError(t, err)
+		_, err = filter.Where("value", 1).Topics()
+		assert.ErrorContains(t, err, "argument value of event Transfer is not indexed")
+
+		// the errors are sticky
+		_, err = filter.Where("to", bob).Topics()
+		assert.ErrorContains(t, err, "argument value of event Transfer is not indexed")
+
+		filter, err = ethcoder.NewEventFilterFromSignature("event Transfer(address indexed from, address indexed to, uint256 value)")
+		require.NoError(t, err)
+		_, err = filter.Where("sender", alice).Topics()
+		assert.ErrorContains(t, err, "expected one of from, to")
+
+		filter, err = ethcoder.NewEventFilterFromSignature("event Transfer(address indexed from, address indexed to, uint256 value)")
+		require.NoError(t, err)
+		_, err = filter.Where("to", "not an address").Topics()
+		assert.Error(t, err)
+	})
+}
@@ -2,9 +2,14 @@ package ethcoder
 
 import (
 	"fmt"
+	"math/big"
 	"strings"
 
 	"github.com/0xsequence/ethkit"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/math"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
 )
 
 // EventTopicHash returns the keccak256 hash of the event signature
@@ -19,27 +24,315 @@ func EventTopicHash(event string) (ethkit.Hash, error) {
 	return Keccak256Hash([]byte(eventSig)), nil
 }
 
+// parseEventSignature returns the canonical signature of an event, ie. without
+// the names and indexed of its arguments, or "" if it is invalid.
 func parseEventSignature(event string) string {
-	if !strings.Contains(event, "(") || !strings.Contains(event, ")") {
+	event = strings.TrimSpace(event)
+	idx := strings.Index(event, "(")
+	if idx < 0 || matchingParen(event[idx:]) != len(event)-idx-1 {
 		return ""
 	}
-	p := strings.Split(event, "(")
-	if len(p) != 2 {
-		return ""
+	typs := splitAbiTypes(event[idx+1 : len(event)-1])
+	for i, a := range typs {
+		typ, _ := splitAbiTypeName(a)
+		typs[i] = canonicalAbiType(typ)
+	}
+	return fmt.Sprintf("%s(%s)", event[:idx], strings.Join(typs, ","))
+}
+
+// EventTopicOneOf is an indexed value of EventTopics matching any of the values.
+type EventTopicOneOf []interface{}
+
+// EventTopics returns the topics of a logs filter, ie. ethereum.FilterQuery.Topics,
+// matching the event and the given values of its indexed arguments, in order. A nil
+// value matches any value, and an EventTopicOneOf value matches any of its values.
+// The values are of the Go types of AbiCoder, ethkit.Hash topics, or strings as
+// in AbiUnmarshalStringValues.
+//
+// e.g. EventTopics("Transfer(address indexed from, address indexed to, uint256 value)", nil, "0x...")
+// will return the topics of the transfers to 0x...
+//
+// The leading arguments are the indexed ones when none is marked as indexed, ie.
+// "Transfer(address,address,uint256)".
+func EventTopics(event string, indexedValues ...interface{}) ([][]ethkit.Hash, error) {
+	topicHash, err := EventTopicHash(event)
+	if err != nil {
+		return nil, err
+	}
+	indexedTypes := parseEventIndexedTypes(event)
+	if len(indexedTypes) == 0 {
+		indexedTypes = parseEventTypes(event)
+	}
+	if len(indexedTypes) > 3 {
+		indexedTypes = indexedTypes[:3]
+	}
+	return eventTopics(event, [][]ethkit.Hash{{topicHash}}, indexedTypes, indexedValues)
+}
+
+// EventTopic0 returns the topic of the event, ie. the keccak256 hash of its
+// canonical signature, see EventSignature. Anonymous events have no topic.
+func EventTopic0(event abi.Event) ethkit.Hash {
+	return Keccak256Hash([]byte(EventSignature(event)))
+}
+
+// EventFilterTopics returns the topics of a logs filter matching the event and
+// the given values of its indexed arguments, in order, as EventTopics. The topics
+// of anonymous events start with the indexed values.
+func EventFilterTopics(event abi.Event, indexedValues ...interface{}) ([][]ethkit.Hash, error) {
+	var indexedTypes []string
+	for _, input := range event.Inputs {
+		if input.Indexed {
+			indexedTypes = append(indexedTypes, input.Type.String())
+		}
+	}
+	topics := [][]ethkit.Hash{{EventTopic0(event)}}
+	if event.Anonymous {
+		topics = [][]ethkit.Hash{}
+	}
+	return eventTopics(EventSignature(event), topics, indexedTypes, indexedValues)
+}
+
+func eventTopics(event string, topics [][]ethkit.Hash, indexedTypes []string, indexedValues []interface{}) ([][]ethkit.Hash, error) {
+	if len(indexedValues) > len(indexedTypes) {
+		return nil, fmt.Errorf("ethcoder: event %s has %d indexed arguments, got %d values", event, len(indexedTypes), len(indexedValues))
+	}
+
+	n := len(topics)
+	for i, value := range indexedValues {
+		values, ok := value.(EventTopicOneOf)
+		if !ok && value != nil {
+			values = EventTopicOneOf{value}
+		}
+
+		var hashes []ethkit.Hash
+		for _, v := range values {
+			hash, err := EventTopicValue(indexedTypes[i], v)
+			if err != nil {
+				return nil, fmt.Errorf("ethcoder: indexed value at position %d is invalid: %w", i, err)
+			}
+			hashes = append(hashes, hash)
+		}
+		topics = append(topics, hashes)
+	}
+
+	// trailing wildcards are implied
+	for len(topics) > n && len(topics[len(topics)-1]) == 0 {
+		topics = topics[:len(topics)-1]
+	}
+	return topics, nil
+}
+
+// EventTopicValue returns the topic of the value of an indexed event argument of
+// type typ. The topics of the dynamic types, the strings, bytes and arrays, are the
+// keccak256 hash of their encoding.
+func EventTopicValue(typ string, value interface{}) (ethkit.Hash, error) {
+	if hash, ok := value.(ethkit.Hash); ok {
+		return hash, nil
+	}
+	if s, ok := value.(string); ok && typ != "string" {
+		values, err := AbiUnmarshalStringValues([]string{typ}, []string{s})
+		if err != nil {
+			return ethkit.Hash{}, err
+		}
+		if len(values) != 1 {
+			return ethkit.Hash{}, fmt.Errorf("ethcoder: unsupported indexed type %s", typ)
+		}
+		value = values[0]
+	}
+
+	switch typ {
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return ethkit.Hash{}, fmt.Errorf("ethcoder: expecting string value, got %T", value)
+		}
+		return Keccak256Hash([]byte(s)), nil
+	case "bytes":
+		b, ok := value.([]byte)
+		if !ok {
+			return ethkit.Hash{}, fmt.Errorf("ethcoder: expecting []byte value, got %T", value)
+		}
+		return Keccak256Hash(b), nil
+	}
+
+	// numbers as *big.Int of any size, which AbiCoder accepts only for the
+	// types over 64 bits
+	if n, ok := value.(*big.Int); ok {
+		if match := regexArgNumber.FindStringSubmatch(typ); len(match) > 0 {
+			if match[1] == "uint" && n.Sign() < 0 {
+				return ethkit.Hash{}, fmt.Errorf("ethcoder: negative value for %s", typ)
+			}
+			return common.BytesToHash(math.U256Bytes(new(big.Int).Set(n))), nil
+		}
+	}
+
+	data, err := AbiCoder([]string{typ}, []interface{}{value})
+	if err != nil {
+		return ethkit.Hash{}, err
+	}
+
+	if idx := strings.LastIndex(typ, "["); idx >= 0 {
+		// arrays are hashed as the concatenation of their elements, without the
+		// offset nor the length of the dynamic arrays
+		elemTyp := typ[:idx]
+		if elemTyp == "string" || elemTyp == "bytes" || strings.Contains(elemTyp, "[") || strings.HasPrefix(elemTyp, "(") {
+			return ethkit.Hash{}, fmt.Errorf("ethcoder: unsupported indexed type %s", typ)
+		}
+		if strings.HasSuffix(typ, "[]") {
+			data = data[64:]
+		}
+		return Keccak256Hash(data), nil
+	}
+
+	return common.BytesToHash(data), nil
+}
+
+func parseEventTypes(event string) []string {
+	sig := parseEventSignature(event)
+	return splitAbiTypes(strings.TrimSuffix(sig[strings.Index(sig, "(")+1:], ")"))
+}
+
+func parseEventIndexedTypes(event string) []string {
+	event = strings.TrimSpace(event)
+	idx := strings.Index(event, "(")
+	if idx < 0 {
+		return nil
+	}
+	var typs []string
+	for _, a := range splitAbiTypes(strings.TrimSuffix(event[idx+1:], ")")) {
+		typ, _ := splitAbiTypeName(a)
+		f := strings.Fields(strings.TrimSpace(a)[len(typ):])
+		if len(f) > 0 && f[0] == "indexed" {
+			typs = append(typs, canonicalAbiType(typ))
+		}
+	}
+	return typs
+}
+
+// DecodedEvent is a log decoded by DecodeEventLog.
+type DecodedEvent struct {
+	// Name of the event, ie. Transfer
+	Name string
+
+	// Signature of the event, ie. Transfer(address,address,uint256)
+	Signature string
+
+	// Args are the arguments of the event, in order, with their values
+	Args []DecodedEventArg
+}
+
+// DecodedEventArg is an argument of a DecodedEvent. The value of an indexed
+// argument of a dynamic type, ie. a string, bytes, array or tuple, is its topic,
+// an ethkit.Hash, as the topic is the keccak256 of its encoding.
+type DecodedEventArg struct {
+	Name    string
+	Type    string
+	Indexed bool
+	Value   interface{}
+}
+
+// Map returns the values of the arguments by name, where an unnamed argument is
+// named by its position, ie. arg0.
+func (e *DecodedEvent) Map() map[string]interface{} {
+	m := make(map[string]interface{}, len(e.Args))
+	for i, arg := range e.Args {
+		name := arg.Name
+		if name == "" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		m[name] = arg.Value
 	}
-	method := p[0]
+	return m
+}
 
-	args := strings.TrimSuffix(p[1], ")")
-	if args == "" {
-		return fmt.Sprintf("%s()", method)
+// DecodeEventLog decodes the log of the event, ie. the values of its indexed
+// arguments from the topics and of the others from the data.
+func DecodeEventLog(event abi.Event, log types.Log) (*DecodedEvent, error) {
+	topics := log.Topics
+	if !event.Anonymous {
+		if len(topics) == 0 || topics[0] != event.ID {
+			return nil, fmt.Errorf("ethcoder: log is not an event %s", event.Sig)
+		}
+		topics = topics[1:]
 	}
 
-	typs := []string{}
-	p = strings.Split(args, ",")
-	for _, a := range p {
-		typ := strings.Split(strings.TrimSpace(a), " ")[0]
-		typs = append(typs, typ)
+	var numIndexed int
+	for _, input := range event.Inputs {
+		if input.Indexed {
+			numIndexed++
+		}
+	}
+	if len(topics) != numIndexed {
+		return nil, fmt.Errorf("ethcoder: event %s has %d indexed arguments, log has %d topics", event.Sig, numIndexed, len(topics))
 	}
 
-	return fmt.Sprintf("%s(%s)", method, strings.Join(typs, ","))
+	values, err := event.Inputs.NonIndexed().UnpackValues(log.Data)
+	if err != nil {
+		return nil, fmt.Errorf("ethcoder: failed to decode data of event %s: %w", event.Sig, err)
+	}
+
+	decoded := &DecodedEvent{
+		Name:      event.RawName,
+		Signature: event.Sig,
+		Args:      make([]DecodedEventArg, len(event.Inputs)),
+	}
+	for i, input := range event.Inputs {
+		arg := DecodedEventArg{Name: input.Name, Type: input.Type.String(), Indexed: input.Indexed}
+		if input.Indexed {
+			arg.Value, err = decodeEventTopic(input.Type, topics[0])
+			if err != nil {
+				return nil, fmt.Errorf("ethcoder: failed to decode indexed argument %d of event %s: %w", i, event.Sig, err)
+			}
+			topics = topics[1:]
+		} else {
+			arg.Value, values = values[0], values[1:]
+		}
+		decoded.Args[i] = arg
+	}
+	return decoded, nil
+}
+
+// DecodeEventLogFromSignature decodes the log of the event of a human-readable
+// signature, see ParseABIEvent and DecodeEventLog. The leading arguments are the
+// indexed ones when none is marked as indexed, as many as the topics of the log,
+// ie. "Transfer(address,address,uint256)".
+func DecodeEventLogFromSignature(event string, log types.Log) (*DecodedEvent, error) {
+	abiEvent, err := ParseABIEvent(event)
+	if err != nil {
+		return nil, err
+	}
+
+	indexed := false
+	for _, input := range abiEvent.Inputs {
+		indexed = indexed || input.Indexed
+	}
+	numTopics := len(log.Topics)
+	if !abiEvent.Anonymous {
+		numTopics--
+	}
+	if !indexed && numTopics > 0 {
+		if numTopics > len(abiEvent.Inputs) {
+			return nil, fmt.Errorf("ethcoder: event %s has %d arguments, log has %d topics", abiEvent.Sig, len(abiEvent.Inputs), numTopics)
+		}
+		inputs := make(abi.Arguments, len(abiEvent.Inputs))
+		copy(inputs, abiEvent.Inputs)
+		for i := 0; i < numTopics; i++ {
+			inputs[i].Indexed = true
+		}
+		e := abi.NewEvent(abiEvent.RawName, abiEvent.RawName, abiEvent.Anonymous, inputs)
+		abiEvent = &e
+	}
+	return DecodeEventLog(*abiEvent, log)
+}
+
+func decodeEventTopic(typ abi.Type, topic common.Hash) (interface{}, error) {
+	switch typ.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+		return topic, nil
+	}
+	values, err := abi.Arguments{{Type: typ}}.UnpackValues(topic.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return values[0], nil
 }
@@ -1,9 +1,15 @@
 package ethcoder_test
 
 import (
+	"math/big"
 	"testing"
 
+	"github.com/0xsequence/ethkit"
 	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -22,3 +28,92 @@ func TestEventTopicHash(t *testing.T) {
 		require.Equal(t, "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef", topicHash.String())
 	}
 }
+
+func TestEventTopics(t *testing.T) {
+	transfer := "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+	from := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	expected, err := abi.MakeTopics([]interface{}{from}, []interface{}{to})
+	require.NoError(t, err)
+
+	topics, err := ethcoder.EventTopics("Transfer(address indexed from, address indexed to, uint256 value)")
+	require.NoError(t, err)
+	require.Len(t, topics, 1)
+	assert.Equal(t, transfer, topics[0][0].String())
+
+	// the leading arguments are indexed, and the trailing wildcards are trimmed
+	topics, err = ethcoder.EventTopics("Transfer(address,address,uint256)", from.Hex(), nil)
+	require.NoError(t, err)
+	require.Len(t, topics, 2)
+	assert.Equal(t, expected[0], topics[1])
+
+	topics, err = ethcoder.EventTopics("Transfer(address indexed from, address indexed to, uint256 value)", nil, ethcoder.EventTopicOneOf{to, from})
+	require.NoError(t, err)
+	require.Len(t, topics, 3)
+	assert.Empty(t, topics[1])
+	assert.Equal(t, []ethkit.Hash{expected[1][0], expected[0][0]}, topics[2])
+
+	_, err = ethcoder.EventTopics("Transfer(address indexed from, address indexed to, uint256 value)", nil, nil, big.NewInt(1))
+	assert.Error(t, err)
+
+	// dynamic types are hashed
+	expected, err = abi.MakeTopics([]interface{}{"hello"}, []interface{}{[]byte{0x01, 0x02}}, []interface{}{big.NewInt(-5)})
+	require.NoError(t, err)
+	topics, err = ethcoder.EventTopics("Event(string indexed name, bytes indexed data, int64 indexed n)", "hello", "0x0102", "-5")
+	require.NoError(t, err)
+	require.Len(t, topics, 4)
+	assert.Equal(t, expected, topics[1:])
+
+	topic, err := ethcoder.EventTopicValue("uint256[]", []*big.Int{big.NewInt(1), big.NewInt(2)})
+	require.NoError(t, err)
+	assert.Equal(t, ethcoder.Keccak256Hash(append(common.LeftPadBytes([]byte{1}, 32), common.LeftPadBytes([]byte{2}, 32)...)), topic)
+	topic, err = ethcoder.EventTopicValue("address[2]", []common.Address{from, to})
+	require.NoError(t, err)
+	assert.Equal(t, ethcoder.Keccak256Hash(append(common.LeftPadBytes(from.Bytes(), 32), common.LeftPadBytes(to.Bytes(), 32)...)), topic)
+	_, err = ethcoder.EventTopicValue("string[]", []string{"a"})
+	assert.Error(t, err)
+}
+
+func TestDecodeEventLog(t *testing.T) {
+	from := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	topics, err := ethcoder.EventTopics("Transfer(address indexed from, address indexed to, uint256 value)", from, to)
+	require.NoError(t, err)
+	data, err := ethcoder.AbiCoder([]string{"uint256"}, []interface{}{big.NewInt(1000)})
+	require.NoError(t, err)
+	log := types.Log{Topics: []ethkit.Hash{topics[0][0], topics[1][0], topics[2][0]}, Data: data}
+
+	event, err := ethcoder.DecodeEventLogFromSignature("event Transfer(address indexed from, address indexed to, uint256 value)", log)
+	require.NoError(t, err)
+	assert.Equal(t, "Transfer", event.Name)
+	assert.Equal(t, "Transfer(address,address,uint256)", event.Signature)
+	assert.Equal(t, []ethcoder.DecodedEventArg{
+		{Name: "from", Type: "address", Indexed: true, Value: from},
+		{Name: "to", Type: "address", Indexed: true, Value: to},
+		{Name: "value", Type: "uint256", Value: big.NewInt(1000)},
+	}, event.Args)
+	assert.Equal(t, map[string]interface{}{"from": from, "to": to, "value": big.NewInt(1000)}, event.Map())
+
+	// the leading arguments are indexed
+	event, err = ethcoder.DecodeEventLogFromSignature("Transfer(address,address,uint256)", log)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"arg0": from, "arg1": to, "arg2": big.NewInt(1000)}, event.Map())
+
+	// the topics of the dynamic types are hashes
+	topics, err = ethcoder.EventTopics("Named(string indexed name, bytes data)", "hello")
+	require.NoError(t, err)
+	data, err = ethcoder.AbiCoder([]string{"bytes"}, []interface{}{[]byte{0x01, 0x02}})
+	require.NoError(t, err)
+	abiEvent, err := ethcoder.ParseABIEvent("Named(string indexed name, bytes data)")
+	require.NoError(t, err)
+	event, err = ethcoder.DecodeEventLog(*abiEvent, types.Log{Topics: []ethkit.Hash{topics[0][0], topics[1][0]}, Data: data})
+	require.NoError(t, err)
+	assert.Equal(t, ethcoder.Keccak256Hash([]byte("hello")), event.Args[0].Value)
+	assert.Equal(t, []byte{0x01, 0x02}, event.Args[1].Value)
+
+	// another event, or the wrong number of topics
+	_, err = ethcoder.DecodeEventLog(*abiEvent, log)
+	assert.Error(t, err)
+	_, err = ethcoder.DecodeEventLogFromSignature("Transfer(address indexed from, address to, uint256 value)", log)
+	assert.Error(t, err)
+}
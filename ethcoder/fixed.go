@@ -0,0 +1,342 @@
+package ethcoder
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+)
+
+// Decimal is a fixed-point number of Value * 10^-Scale, the Go type of the
+// solidity fixedMxN and ufixedMxN types.
+type Decimal struct {
+	Value *big.Int
+	Scale int
+}
+
+func NewDecimal(value *big.Int, scale int) Decimal {
+	return Decimal{Value: value, Scale: scale}
+}
+
+// ParseDecimal parses a decimal number, ie. "-12.345".
+func ParseDecimal(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	digits := s
+	scale := 0
+	if i := strings.Index(s, "."); i >= 0 {
+		digits = s[:i] + s[i+1:]
+		scale = len(s) - i - 1
+	}
+	value, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("ethcoder: invalid decimal '%s'", s)
+	}
+	return Decimal{Value: value, Scale: scale}, nil
+}
+
+func (d Decimal) String() string {
+	if d.Value == nil {
+		return "0"
+	}
+	if d.Scale <= 0 {
+		return new(big.Int).Mul(d.Value, pow10(-d.Scale)).String()
+	}
+
+	s := new(big.Int).Abs(d.Value).String()
+	if len(s) <= d.Scale {
+		s = strings.Repeat("0", d.Scale-len(s)+1) + s
+	}
+	s = s[:len(s)-d.Scale] + "." + s[len(s)-d.Scale:]
+	if d.Value.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// Rat returns the exact value of d.
+func (d Decimal) Rat() *big.Rat {
+	if d.Value == nil {
+		return new(big.Rat)
+	}
+	if d.Scale < 0 {
+		return new(big.Rat).SetInt(new(big.Int).Mul(d.Value, pow10(-d.Scale)))
+	}
+	return new(big.Rat).SetFrac(d.Value, pow10(d.Scale))
+}
+
+// Rescale returns the value of d at the scale, ie. the integer of a fixedMxN of
+// N decimals, and an error if it loses precision.
+func (d Decimal) Rescale(scale int) (*big.Int, error) {
+	return ratToScaledInt(d.Rat(), scale)
+}
+
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Decimal) UnmarshalText(text []byte) error {
+	v, err := ParseDecimal(string(text))
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+func ratToScaledInt(r *big.Rat, scale int) (*big.Int, error) {
+	scaled := new(big.Rat).Mul(r, new(big.Rat).SetInt(pow10(scale)))
+	if !scaled.IsInt() {
+		return nil, fmt.Errorf("value %s has more than %d decimals", r.FloatString(scale+1), scale)
+	}
+	return new(big.Int).Set(scaled.Num()), nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// fixedType is a fixedMxN or ufixedMxN type.
+type fixedType struct {
+	signed   bool
+	bits     int
+	decimals int
+}
+
+var regexArgFixed = regexp.MustCompile(`^(u?)fixed(([0-9]+)x([0-9]+))?$`)
+
+// parseFixedType parses the fixed-point base type of typ, ie. of ufixed128x18[],
+// and returns false if it's not one.
+func parseFixedType(typ string) (fixedType, bool, error) {
+	if i := strings.Index(typ, "["); i >= 0 {
+		typ = typ[:i]
+	}
+	match := regexArgFixed.FindStringSubmatch(typ)
+	if len(match) == 0 {
+		return fixedType{}, false, nil
+	}
+
+	t := fixedType{signed: match[1] == "", bits: 128, decimals: 18}
+	if match[2] != "" {
+		t.bits, _ = strconv.Atoi(match[3])
+		t.decimals, _ = strconv.Atoi(match[4])
+	}
+	if t.bits%8 != 0 || t.bits == 0 || t.bits > 256 || t.decimals > 80 {
+		return fixedType{}, true, fmt.Errorf("ethcoder: invalid fixed-point type '%s'", typ)
+	}
+	return t, true, nil
+}
+
+func (t fixedType) String() string {
+	s := fmt.Sprintf("fixed%dx%d", t.bits, t.decimals)
+	if !t.signed {
+		s = "u" + s
+	}
+	return s
+}
+
+// intType returns the integer type of the scaled values of t.
+func (t fixedType) intType() string {
+	if t.signed {
+		return fmt.Sprintf("int%d", t.bits)
+	}
+	return fmt.Sprintf("uint%d", t.bits)
+}
+
+// scaledInt returns the integer of the fixed-point value v. The values are
+// Decimal, *big.Rat, decimal strings or Go integers.
+func (t fixedType) scaledInt(v interface{}) (*big.Int, error) {
+	var r *big.Rat
+	switch v := v.(type) {
+	case Decimal:
+		r = v.Rat()
+	case *Decimal:
+		r = v.Rat()
+	case *big.Rat:
+		r = v
+	case *big.Int:
+		r = new(big.Rat).SetInt(v)
+	case string:
+		d, err := ParseDecimal(v)
+		if err != nil {
+			return nil, err
+		}
+		r = d.Rat()
+	case int, int8, int16, int32, int64:
+		r = new(big.Rat).SetInt64(reflect.ValueOf(v).Int())
+	case uint, uint8, uint16, uint32, uint64:
+		r = new(big.Rat).SetInt(new(big.Int).SetUint64(reflect.ValueOf(v).Uint()))
+	default:
+		return nil, fmt.Errorf("expecting Decimal value for type '%s', got %T", t, v)
+	}
+
+	n, err := ratToScaledInt(r, t.decimals)
+	if err != nil {
+		return nil, fmt.Errorf("%w for type '%s'", err, t)
+	}
+	if err := checkNumberRange(t.String(), t.signed, t.bits, n); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// fixedIntType returns the integer type of the scaled values of a fixed-point
+// type, with its array suffix, or typ if it's not one.
+func fixedIntType(typ string) (string, error) {
+	ft, ok, err := parseFixedType(typ)
+	if !ok || err != nil {
+		return typ, err
+	}
+	return ft.intType() + typ[len(strings.SplitN(typ, "[", 2)[0]):], nil
+}
+
+func hasFixedTypes(argTypes []string) bool {
+	for _, typ := range argTypes {
+		if _, ok, _ := parseFixedType(typ); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// fixedArgsToInt returns the integer types of the fixed-point types, and the
+// values converted to the Go types of the integer types. It returns the args as
+// is when none is fixed-point.
+func fixedArgsToInt(argTypes []string, argValues []interface{}) ([]string, []interface{}, error) {
+	var intTypes []string
+	var intValues []interface{}
+	for i, typ := range argTypes {
+		ft, ok, err := parseFixedType(typ)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !ok {
+			continue
+		}
+		if intTypes == nil {
+			intTypes = append([]string{}, argTypes...)
+			intValues = append([]interface{}{}, argValues...)
+		}
+		intTypes[i] = ft.intType() + typ[len(strings.SplitN(typ, "[", 2)[0]):]
+		if i >= len(argValues) {
+			continue
+		}
+
+		abiType, err := abi.NewType(intTypes[i], "", nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		v, err := ft.toAbiValue(abiType.GetType(), reflect.ValueOf(argValues[i]))
+		if err != nil {
+			return nil, nil, fmt.Errorf("ethcoder: value at position %d is invalid: %w", i, err)
+		}
+		intValues[i] = v.Interface()
+	}
+	if intTypes == nil {
+		return argTypes, argValues, nil
+	}
+	return intTypes, intValues, nil
+}
+
+func (t fixedType) toAbiValue(typ reflect.Type, v reflect.Value) (reflect.Value, error) {
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return reflect.Value{}, fmt.Errorf("not an array")
+		}
+		var out reflect.Value
+		if typ.Kind() == reflect.Slice {
+			out = reflect.MakeSlice(typ, v.Len(), v.Len())
+		} else {
+			if v.Len() != typ.Len() {
+				return reflect.Value{}, fmt.Errorf("array size does not match required size of %d", typ.Len())
+			}
+			out = reflect.New(typ).Elem()
+		}
+		for i := 0; i < v.Len(); i++ {
+			elem, err := t.toAbiValue(typ.Elem(), v.Index(i))
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			out.Index(i).Set(elem)
+		}
+		return out, nil
+	}
+
+	if !v.IsValid() {
+		return reflect.Value{}, fmt.Errorf("nil value for type '%s'", t)
+	}
+	n, err := t.scaledInt(v.Interface())
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	out := reflect.New(typ).Elem()
+	switch typ.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		out.SetInt(n.Int64())
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		out.SetUint(n.Uint64())
+	default:
+		out.Set(reflect.ValueOf(n))
+	}
+	return out, nil
+}
+
+// fixedValuesFromInt converts the decoded values of the integer types of the
+// fixed-point argTypes to Decimal values.
+func fixedValuesFromInt(argTypes []string, values []interface{}) []interface{} {
+	for i, typ := range argTypes {
+		ft, ok, _ := parseFixedType(typ)
+		if ok && i < len(values) {
+			values[i] = ft.fromAbiValue(reflect.ValueOf(values[i])).Interface()
+		}
+	}
+	return values
+}
+
+func (t fixedType) fromAbiValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		typ := decimalType(v.Type())
+		var out reflect.Value
+		if typ.Kind() == reflect.Slice {
+			out = reflect.MakeSlice(typ, v.Len(), v.Len())
+		} else {
+			out = reflect.New(typ).Elem()
+		}
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(t.fromAbiValue(v.Index(i)))
+		}
+		return out
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflect.ValueOf(Decimal{Value: big.NewInt(v.Int()), Scale: t.decimals})
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return reflect.ValueOf(Decimal{Value: new(big.Int).SetUint64(v.Uint()), Scale: t.decimals})
+	}
+	n, _ := v.Interface().(*big.Int)
+	return reflect.ValueOf(Decimal{Value: n, Scale: t.decimals})
+}
+
+// decimalType returns typ with its integer elements replaced by Decimal.
+func decimalType(typ reflect.Type) reflect.Type {
+	switch typ.Kind() {
+	case reflect.Slice:
+		return reflect.SliceOf(decimalType(typ.Elem()))
+	case reflect.Array:
+		return reflect.ArrayOf(typ.Len(), decimalType(typ.Elem()))
+	}
+	return reflect.TypeOf(Decimal{})
+}
+
+// canonicalFixedType returns typ with the fixed and ufixed aliases expanded, for
+// method signatures.
+func canonicalFixedType(typ string) string {
+	ft, ok, err := parseFixedType(typ)
+	if !ok || err != nil {
+		return typ
+	}
+	return ft.String() + typ[len(strings.SplitN(typ, "[", 2)[0]):]
+}
@@ -0,0 +1,110 @@
+package ethcoder
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecimal(t *testing.T) {
+	cases := []struct {
+		in  string
+		out string
+	}{
+		{"1.5", "1.5"},
+		{"-0.001", "-0.001"},
+		{".25", "0.25"},
+		{"42", "42"},
+		{"10.00", "10.00"},
+	}
+	for _, c := range cases {
+		d, err := ParseDecimal(c.in)
+		require.NoError(t, err)
+		assert.Equal(t, c.out, d.String())
+	}
+
+	_, err := ParseDecimal("1.2.3")
+	assert.Error(t, err)
+	_, err = ParseDecimal("")
+	assert.Error(t, err)
+
+	d, err := ParseDecimal("1.5")
+	require.NoError(t, err)
+	n, err := d.Rescale(18)
+	require.NoError(t, err)
+	assert.Equal(t, "1500000000000000000", n.String())
+	_, err = d.Rescale(0)
+	assert.ErrorContains(t, err, "more than 0 decimals")
+}
+
+func TestFixedAbiEncoding(t *testing.T) {
+	d, err := ParseDecimal("1.5")
+	require.NoError(t, err)
+
+	// ufixed128x18 is encoded as the uint128 of its scaled value
+	data, err := AbiCoder([]string{"ufixed128x18", "fixed8x1", "ufixed"}, []interface{}{d, "-12.8", big.NewRat(1, 4)})
+	require.NoError(t, err)
+	expected, err := AbiCoder([]string{"uint128", "int8", "uint128"}, []interface{}{big.NewInt(15e17), int8(-128), big.NewInt(25e16)})
+	require.NoError(t, err)
+	assert.Equal(t, expected, data)
+
+	values, err := AbiDecoderWithReturnedValues([]string{"ufixed128x18", "fixed8x1", "ufixed"}, data)
+	require.NoError(t, err)
+	require.Len(t, values, 3)
+	assert.Equal(t, "1.500000000000000000", values[0].(Decimal).String())
+	assert.Equal(t, "-12.8", values[1].(Decimal).String())
+
+	var a, b, c Decimal
+	err = AbiDecoder([]string{"ufixed128x18", "fixed8x1", "ufixed"}, data, []interface{}{&a, &b, &c})
+	require.NoError(t, err)
+	assert.Equal(t, 0, a.Rat().Cmp(big.NewRat(3, 2)))
+	assert.Equal(t, "0.250000000000000000", c.String())
+
+	s, err := AbiMarshalStringValues([]string{"ufixed128x18", "fixed8x1", "ufixed"}, data)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1.500000000000000000", "-12.8", "0.250000000000000000"}, s)
+
+	// arrays
+	data, err = AbiCoder([]string{"fixed64x2[]"}, []interface{}{[]string{"1.01", "-2"}})
+	require.NoError(t, err)
+	values, err = AbiDecoderWithReturnedValues([]string{"fixed64x2[]"}, data)
+	require.NoError(t, err)
+	assert.Equal(t, []Decimal{{big.NewInt(101), 2}, {big.NewInt(-200), 2}}, values[0])
+
+	// range and precision
+	_, err = AbiCoder([]string{"fixed8x1"}, []interface{}{"12.8"})
+	assert.ErrorContains(t, err, "overflows type 'fixed8x1'")
+	_, err = AbiCoder([]string{"ufixed128x18"}, []interface{}{"-1"})
+	assert.ErrorContains(t, err, "negative value")
+	_, err = AbiCoder([]string{"ufixed64x2"}, []interface{}{"0.001"})
+	assert.ErrorContains(t, err, "more than 2 decimals")
+	_, err = AbiCoder([]string{"ufixed7x2"}, []interface{}{"1"})
+	assert.Error(t, err)
+}
+
+func TestFixedAbiMethodCalldata(t *testing.T) {
+	data, err := AbiEncodeMethodCalldata("setRate(address,ufixed)", []interface{}{common.Address{}, "0.05"})
+	require.NoError(t, err)
+	assert.Equal(t, Keccak256([]byte("setRate(address,ufixed128x18)"))[:4], data[:4])
+	assert.Equal(t, big.NewInt(5e16), new(big.Int).SetBytes(data[36:]))
+
+	data, err = AbiEncodeMethodCalldataFromStringValues("setRate(address,ufixed)", []string{"0x0000000000000000000000000000000000000001", "0.05"})
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(5e16), new(big.Int).SetBytes(data[36:]))
+
+	mabi, _, err := ParseMethodABI("setRate(address,ufixed)", "fixed128x18")
+	require.NoError(t, err)
+	assert.Equal(t, "setRate(address,ufixed128x18)", mabi.Methods["setRate"].Sig)
+}
+
+func TestFixedSolidityPack(t *testing.T) {
+	h, err := SolidityPackHex([]string{"ufixed16x2", "fixed8x1[]"}, []interface{}{"1.5", []string{"-0.1"}})
+	require.NoError(t, err)
+	assert.Equal(t, "0x0096"+"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", h)
+
+	_, err = SolidityPackHex([]string{"ufixed8x1"}, []interface{}{"25.6"})
+	assert.ErrorContains(t, err, "overflows")
+}
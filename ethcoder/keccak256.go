@@ -1,20 +1,66 @@
 package ethcoder
 
 import (
+	"crypto/sha256"
+	"hash"
+
 	"github.com/0xsequence/ethkit/go-ethereum/common"
 	"golang.org/x/crypto/sha3"
 )
 
-func Keccak256Hash(input []byte) common.Hash {
-	return common.BytesToHash(Keccak256(input))
+// Keccak256Hash returns the keccak256 hash of the concatenation of the inputs.
+func Keccak256Hash(input ...[]byte) common.Hash {
+	var h common.Hash
+	hasher := NewKeccak256()
+	for _, b := range input {
+		hasher.Write(b)
+	}
+	hasher.Sum(h[:0])
+	return h
 }
 
-func Keccak256(input []byte) []byte {
-	hasher := sha3.NewLegacyKeccak256()
-	hasher.Write(input)
+// Keccak256 returns the keccak256 hash of the concatenation of the inputs, ie.
+// the hash of the ethereum signatures, addresses and abi.encodePacked values.
+func Keccak256(input ...[]byte) []byte {
+	hasher := NewKeccak256()
+	for _, b := range input {
+		hasher.Write(b)
+	}
 	return hasher.Sum(nil)
 }
 
+// Keccak512 returns the keccak512 hash of the concatenation of the inputs.
+func Keccak512(input ...[]byte) []byte {
+	hasher := sha3.NewLegacyKeccak512()
+	for _, b := range input {
+		hasher.Write(b)
+	}
+	return hasher.Sum(nil)
+}
+
+// Sha256 returns the sha256 hash of the concatenation of the inputs.
+func Sha256(input ...[]byte) common.Hash {
+	hasher := sha256.New()
+	for _, b := range input {
+		hasher.Write(b)
+	}
+	var h common.Hash
+	hasher.Sum(h[:0])
+	return h
+}
+
+// NewKeccak256 returns a streaming keccak256 hash, ie. of data written to it
+// incrementally. It's the legacy keccak256 of ethereum, not the sha3-256 of the
+// NIST standard.
+func NewKeccak256() hash.Hash {
+	return sha3.NewLegacyKeccak256()
+}
+
+// NewKeccak512 returns a streaming keccak512 hash, as NewKeccak256.
+func NewKeccak512() hash.Hash {
+	return sha3.NewLegacyKeccak512()
+}
+
 func SHA3(input []byte) common.Hash {
 	return Keccak256Hash(input)
 }
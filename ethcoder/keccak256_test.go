@@ -0,0 +1,35 @@
+package ethcoder_test
+
+import (
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashes(t *testing.T) {
+	assert.Equal(t, "0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470", ethcoder.HexEncode(ethcoder.Keccak256(nil)))
+	assert.Equal(t, common.HexToHash("0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470"), ethcoder.Keccak256Hash())
+	assert.Equal(t, "0x0eab42de4c3ceb9235fc91acffe746b29c29a8c366b7c60e4e67c466f36a4304c00fa9caf9d87976ba469bcbe06713b435f091ef2769fb160cdab33d3670680e", ethcoder.HexEncode(ethcoder.Keccak512(nil)))
+	assert.Equal(t, common.HexToHash("0xe3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"), ethcoder.Sha256())
+
+	// the hashes of the concatenation of the inputs
+	assert.Equal(t, "0xa9059cbb", ethcoder.HexEncode(ethcoder.Keccak256([]byte("transfer("), []byte("address,uint256)"))[:4]))
+	assert.Equal(t, ethcoder.Keccak256Hash([]byte("hello world")), ethcoder.Keccak256Hash([]byte("hello "), []byte("world")))
+	assert.Equal(t, ethcoder.Keccak512([]byte("hello world")), ethcoder.Keccak512([]byte("hello "), []byte("world")))
+	assert.Equal(t, ethcoder.Sha256([]byte("hello world")), ethcoder.Sha256([]byte("hello "), []byte("world")))
+
+	// the streaming hashes
+	h := ethcoder.NewKeccak256()
+	h.Write([]byte("hello "))
+	h.Write([]byte("world"))
+	assert.Equal(t, ethcoder.Keccak256([]byte("hello world")), h.Sum(nil))
+	assert.Equal(t, 32, h.Size())
+	h.Reset()
+	assert.Equal(t, ethcoder.Keccak256(nil), h.Sum(nil))
+
+	h = ethcoder.NewKeccak512()
+	h.Write([]byte("hello world"))
+	assert.Equal(t, ethcoder.Keccak512([]byte("hello world")), h.Sum(nil))
+}
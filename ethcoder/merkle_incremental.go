@@ -0,0 +1,119 @@
+package ethcoder
+
+import (
+	"fmt"
+)
+
+// IncrementalMerkleTree is a merkle tree of leaves which are appended or updated
+// one at a time, ie. for deposit trees, where each change only recomputes the
+// path of the leaf to the root. Its root and proofs are those of a MerkleTree of
+// the same leaves without SortLeaves.
+type IncrementalMerkleTree[TLeaf any] struct {
+	merkleHasher
+	hashFn func(TLeaf) ([]byte, error)
+	layers [][][]byte
+}
+
+// NewIncrementalMerkleTree returns an empty tree. The SortLeaves option is
+// ignored, as the leaves are in the order they're appended.
+func NewIncrementalMerkleTree[TLeaf any](hashFn *func(TLeaf) ([]byte, error), options *Options) *IncrementalMerkleTree[TLeaf] {
+	if hashFn == nil {
+		// Assume TLeaf is []byte
+		fn := func(leaf TLeaf) ([]byte, error) {
+			return any(leaf).([]byte), nil
+		}
+		hashFn = &fn
+	}
+	if options == nil {
+		options = &DefaultMerkleTreeOptions
+	}
+	return &IncrementalMerkleTree[TLeaf]{
+		merkleHasher: newMerkleHasher(options),
+		hashFn:       *hashFn,
+		layers:       [][][]byte{{}},
+	}
+}
+
+// Len returns the number of leaves of the tree.
+func (mt *IncrementalMerkleTree[TLeaf]) Len() int {
+	return len(mt.layers[0])
+}
+
+// Append appends the leaf to the tree, and returns its index.
+func (mt *IncrementalMerkleTree[TLeaf]) Append(leaf TLeaf) (int, error) {
+	node, err := mt.hashFn(leaf)
+	if err != nil {
+		return 0, err
+	}
+	index := len(mt.layers[0])
+	mt.layers[0] = append(mt.layers[0], node)
+	mt.updatePath(index)
+	return index, nil
+}
+
+// Update replaces the leaf at the index.
+func (mt *IncrementalMerkleTree[TLeaf]) Update(index int, leaf TLeaf) error {
+	if index < 0 || index >= mt.Len() {
+		return fmt.Errorf("ethcoder: leaf index %d out of range", index)
+	}
+	node, err := mt.hashFn(leaf)
+	if err != nil {
+		return err
+	}
+	mt.layers[0][index] = node
+	mt.updatePath(index)
+	return nil
+}
+
+// updatePath recomputes the parents of the node at the index of the first layer,
+// adding the nodes and layers the tree grew by.
+func (mt *IncrementalMerkleTree[TLeaf]) updatePath(index int) {
+	for level := 0; len(mt.layers[level]) > 1; level++ {
+		node := mt.parent(mt.layers[level], index)
+		index /= 2
+		if level+1 == len(mt.layers) {
+			mt.layers = append(mt.layers, nil)
+		}
+		if index == len(mt.layers[level+1]) {
+			mt.layers[level+1] = append(mt.layers[level+1], node)
+		} else {
+			mt.layers[level+1][index] = node
+		}
+	}
+}
+
+// GetRoot returns the root of the tree, or nil if it's empty.
+func (mt *IncrementalMerkleTree[TLeaf]) GetRoot() []byte {
+	top := mt.layers[len(mt.layers)-1]
+	if len(top) == 0 {
+		return nil
+	}
+	return top[0]
+}
+
+// GetProofByIndex returns the proof of the leaf at the index.
+func (mt *IncrementalMerkleTree[TLeaf]) GetProofByIndex(index int) ([]Proof, error) {
+	if index < 0 || index >= mt.Len() {
+		return nil, fmt.Errorf("ethcoder: leaf index %d out of range", index)
+	}
+	proof := []Proof{}
+	for i := 0; i < len(mt.layers)-1; i++ {
+		layer := mt.layers[i]
+		if pair := mt.sibling(layer, index); pair != nil {
+			proof = append(proof, Proof{
+				IsLeft: index%2 != 0,
+				Data:   pair,
+			})
+		}
+		index /= 2
+	}
+	return proof, nil
+}
+
+func (mt *IncrementalMerkleTree[TLeaf]) Verify(proof []Proof, leaf TLeaf, root []byte) (bool, error) {
+	hash, err := mt.hashFn(leaf)
+	if err != nil {
+		return false, err
+	}
+	return mt.verify(proof, hash, root)
+}
@@ -0,0 +1,53 @@
+package ethcoder
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIncrementalMerkleTree(t *testing.T) {
+	for _, sortPairs := range []bool{true, false} {
+		options := &Options{SortPairs: sortPairs}
+		mt := NewIncrementalMerkleTree[[]byte](nil, options)
+		assert.Nil(t, mt.GetRoot())
+
+		var leaves [][]byte
+		for i := 0; i < 37; i++ {
+			leaf := make([]byte, 32)
+			rand.Read(leaf)
+			leaves = append(leaves, leaf)
+
+			index, err := mt.Append(leaf)
+			require.NoError(t, err)
+			assert.Equal(t, i, index)
+			assert.Equal(t, NewMerkleTree(leaves, nil, options).GetRoot(), mt.GetRoot(), "append %d", i)
+		}
+
+		for _, index := range []int{0, 17, 36} {
+			leaf := make([]byte, 32)
+			rand.Read(leaf)
+			leaves[index] = leaf
+			require.NoError(t, mt.Update(index, leaf))
+
+			full := NewMerkleTree(leaves, nil, options)
+			assert.Equal(t, full.GetRoot(), mt.GetRoot(), "update %d", index)
+
+			proof, err := mt.GetProofByIndex(index)
+			require.NoError(t, err)
+			expected, err := full.GetProof(leaf)
+			require.NoError(t, err)
+			assert.Equal(t, expected, proof)
+
+			ok, err := mt.Verify(proof, leaf, mt.GetRoot())
+			require.NoError(t, err)
+			assert.True(t, ok)
+		}
+
+		assert.Error(t, mt.Update(37, leaves[0]))
+		_, err := mt.GetProofByIndex(-1)
+		assert.Error(t, err)
+	}
+}
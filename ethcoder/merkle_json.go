@@ -0,0 +1,153 @@
+package ethcoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// merkleTreeJSON is the dump of a MerkleTree, in the format of merkletreejs'
+// MerkleTree.marshalTree, where the leaves are the hashed leaves of the tree.
+type merkleTreeJSON struct {
+	Options merkleTreeJSONOptions `json:"options"`
+	Root    string                `json:"root"`
+	Layers  [][]string            `json:"layers"`
+	Leaves  []string              `json:"leaves"`
+}
+
+type merkleTreeJSONOptions struct {
+	Complete      bool `json:"complete"`
+	IsBitcoinTree bool `json:"isBitcoinTree"`
+	HashLeaves    bool `json:"hashLeaves"`
+	SortLeaves    bool `json:"sortLeaves"`
+	SortPairs     bool `json:"sortPairs"`
+	Sort          bool `json:"sort"`
+	DuplicateOdd  bool `json:"duplicateOdd"`
+
+	// ZeroHashOdd is the MerkleOddNodeZeroHash option, which merkletreejs doesn't
+	// have.
+	ZeroHashOdd bool `json:"zeroHashOdd,omitempty"`
+
+	// NodeHash is the name of the NodeHash option, if not keccak256.
+	NodeHash string `json:"nodeHash,omitempty"`
+}
+
+var merkleNodeHashNames = map[MerkleNodeHash]string{
+	MerkleNodeHashSHA256:          "sha256",
+	MerkleNodeHashDoubleKeccak256: "doubleKeccak256",
+	MerkleNodeHashDoubleSHA256:    "doubleSha256",
+}
+
+// MarshalJSON returns the dump of the tree with all its layers, which is
+// compatible with merkletreejs' MerkleTree.marshalTree.
+func (mt *MerkleTree[TLeaf]) MarshalJSON() ([]byte, error) {
+	dump := merkleTreeJSON{
+		Options: merkleTreeJSONOptions{
+			SortLeaves: mt.sortLeaves,
+			SortPairs:  mt.sortPairs,
+			Sort:       mt.sortLeaves && mt.sortPairs,
+
+			DuplicateOdd: mt.oddNode == MerkleOddNodeDuplicate,
+			ZeroHashOdd:  mt.oddNode == MerkleOddNodeZeroHash,
+			NodeHash:     merkleNodeHashNames[mt.nodeHash],
+		},
+		Root:   HexEncode(mt.GetRoot()),
+		Layers: make([][]string, len(mt.layers)),
+	}
+	for i, layer := range mt.layers {
+		dump.Layers[i] = make([]string, len(layer))
+		for j, node := range layer {
+			dump.Layers[i][j] = HexEncode(node)
+		}
+	}
+	if len(dump.Layers) > 0 {
+		dump.Leaves = dump.Layers[0]
+	}
+	return json.Marshal(dump)
+}
+
+// UnmarshalJSON loads a dump of MarshalJSON or merkletreejs' marshalTree without
+// recomputing its layers. The leaves of a loaded tree are only known by their
+// hash, so the tree keeps its hash function, or the default one of []byte leaves
+// for a zero tree, see LoadMerkleTree.
+func (mt *MerkleTree[TLeaf]) UnmarshalJSON(data []byte) error {
+	var dump merkleTreeJSON
+	if err := json.Unmarshal(data, &dump); err != nil {
+		return err
+	}
+	if dump.Options.Complete || dump.Options.IsBitcoinTree || (dump.Options.DuplicateOdd && dump.Options.ZeroHashOdd) {
+		return fmt.Errorf("ethcoder: unsupported merkle tree options")
+	}
+	if len(dump.Layers) == 0 && len(dump.Leaves) > 0 {
+		dump.Layers = [][]string{dump.Leaves}
+	}
+	if len(dump.Layers) == 0 || len(dump.Layers[0]) == 0 {
+		return fmt.Errorf("ethcoder: merkle tree has no leaves")
+	}
+
+	layers := make([][][]byte, len(dump.Layers))
+	for i, layer := range dump.Layers {
+		if i > 0 && len(layer) != (len(dump.Layers[i-1])+1)/2 {
+			return fmt.Errorf("ethcoder: merkle tree layer %d has %d nodes, expected %d", i, len(layer), (len(dump.Layers[i-1])+1)/2)
+		}
+		layers[i] = make([][]byte, len(layer))
+		for j, node := range layer {
+			data, err := HexDecode(node)
+			if err != nil {
+				return fmt.Errorf("ethcoder: invalid node %d of merkle tree layer %d: %w", j, i, err)
+			}
+			layers[i][j] = data
+		}
+	}
+	if top := layers[len(layers)-1]; len(top) != 1 {
+		return fmt.Errorf("ethcoder: merkle tree top layer has %d nodes", len(top))
+	}
+	if dump.Root != "" {
+		root, err := HexDecode(dump.Root)
+		if err != nil || !bytes.Equal(root, layers[len(layers)-1][0]) {
+			return fmt.Errorf("ethcoder: merkle tree root is not the top of its layers")
+		}
+	}
+
+	if mt.hashFn == nil {
+		mt.hashFn = func(leaf TLeaf) ([]byte, error) {
+			return any(leaf).([]byte), nil
+		}
+	}
+	mt.sortLeaves = dump.Options.SortLeaves || dump.Options.Sort
+	mt.sortPairs = dump.Options.SortPairs || dump.Options.Sort
+	mt.nodeHash = MerkleNodeHashKeccak256
+	if dump.Options.NodeHash != "" {
+		found := false
+		for nodeHash, name := range merkleNodeHashNames {
+			if name == dump.Options.NodeHash {
+				mt.nodeHash, found = nodeHash, true
+			}
+		}
+		if !found {
+			return fmt.Errorf("ethcoder: unsupported merkle tree node hash %q", dump.Options.NodeHash)
+		}
+	}
+	mt.oddNode = MerkleOddNodePromote
+	if dump.Options.DuplicateOdd {
+		mt.oddNode = MerkleOddNodeDuplicate
+	} else if dump.Options.ZeroHashOdd {
+		mt.oddNode = MerkleOddNodeZeroHash
+	}
+	mt.leaves = nil
+	mt.layers = layers
+	return nil
+}
+
+// LoadMerkleTree loads a tree from a dump of MarshalJSON, with the hash function
+// of its leaves as in NewMerkleTree.
+func LoadMerkleTree[TLeaf any](data []byte, hashFn *func(TLeaf) ([]byte, error)) (*MerkleTree[TLeaf], error) {
+	mt := &MerkleTree[TLeaf]{}
+	if hashFn != nil {
+		mt.hashFn = *hashFn
+	}
+	if err := json.Unmarshal(data, mt); err != nil {
+		return nil, err
+	}
+	return mt, nil
+}
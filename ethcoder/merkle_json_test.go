@@ -0,0 +1,85 @@
+package ethcoder
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerkleTreeJSON(t *testing.T) {
+	testAddr := common.HexToAddress("0x1e946c284bdBb05Fb6EF41016C524E8681e3d05E")
+	leaves := [][]byte{
+		testAddr.Bytes(),
+		common.HexToAddress("0x1D74B866598B339006160d704642459B04ba890B").Bytes(),
+		common.HexToAddress("0x37e948435E916069D3a1431Ddf508421073fF3E7").Bytes(),
+		common.HexToAddress("0x29c34A7d23B8BCBE7c5Ec94C6525b78bb5cbAf36").Bytes(),
+		common.HexToAddress("0x1111111111111111111111111111111111111111").Bytes(),
+	}
+	mt := NewMerkleTree(leaves, nil, nil)
+
+	data, err := json.Marshal(mt)
+	require.NoError(t, err)
+
+	var dump map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(data, &dump))
+	assert.JSONEq(t, `"`+HexEncode(mt.GetRoot())+`"`, string(dump["root"]))
+	assert.JSONEq(t, string(dump["leaves"]), `["0x1111111111111111111111111111111111111111","0x1d74b866598b339006160d704642459b04ba890b","0x1e946c284bdbb05fb6ef41016c524e8681e3d05e","0x29c34a7d23b8bcbe7c5ec94c6525b78bb5cbaf36","0x37e948435e916069d3a1431ddf508421073ff3e7"]`)
+
+	var loaded MerkleTree[[]byte]
+	require.NoError(t, json.Unmarshal(data, &loaded))
+	assert.Equal(t, mt.GetRoot(), loaded.GetRoot())
+	proof, err := loaded.GetProof(testAddr.Bytes())
+	require.NoError(t, err)
+	expected, err := mt.GetProof(testAddr.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, expected, proof)
+	ok, err := loaded.Verify(proof, testAddr.Bytes(), loaded.GetRoot())
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// a merkletreejs dump, of sorted keccak256 leaves
+	hashFn := func(leaf TLeaf) ([]byte, error) {
+		return Keccak256(append(leaf.Addr.Bytes(), common.LeftPadBytes(leaf.TokenId.Bytes(), 32)...)), nil
+	}
+	tleaves := []TLeaf{
+		{Addr: testAddr, TokenId: big.NewInt(1)},
+		{Addr: testAddr, TokenId: big.NewInt(2)},
+		{Addr: testAddr, TokenId: big.NewInt(3)},
+	}
+	js := NewMerkleTree(tleaves, &hashFn, &Options{SortLeaves: true, SortPairs: true})
+	var hexLayers [][]string
+	for _, layer := range js.layers {
+		var hexLayer []string
+		for _, node := range layer {
+			hexLayer = append(hexLayer, HexEncode(node))
+		}
+		hexLayers = append(hexLayers, hexLayer)
+	}
+	jsDump, err := json.Marshal(map[string]any{
+		"options": map[string]any{"complete": false, "isBitcoinTree": false, "hashLeaves": true, "sortLeaves": false, "sortPairs": false, "sort": true, "fillDefaultHash": nil, "duplicateOdd": false},
+		"root":    HexEncode(js.GetRoot()),
+		"layers":  hexLayers,
+		"leaves":  hexLayers[0],
+	})
+	require.NoError(t, err)
+
+	loadedJS, err := LoadMerkleTree(jsDump, &hashFn)
+	require.NoError(t, err)
+	assert.Equal(t, js.GetRoot(), loadedJS.GetRoot())
+	tproof, err := loadedJS.GetProof(tleaves[2])
+	require.NoError(t, err)
+	ok, err = loadedJS.Verify(tproof, tleaves[2], loadedJS.GetRoot())
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = LoadMerkleTree[[]byte]([]byte(`{"options":{"isBitcoinTree":true},"leaves":["0x01"]}`), nil)
+	assert.ErrorContains(t, err, "unsupported")
+	_, err = LoadMerkleTree[[]byte]([]byte(`{"root":"0x02","layers":[["0x01"]]}`), nil)
+	assert.ErrorContains(t, err, "root")
+	_, err = LoadMerkleTree[[]byte]([]byte(`{"layers":[["0x01","0x02"],["0x03","0x04"]]}`), nil)
+	assert.ErrorContains(t, err, "layer 1")
+}
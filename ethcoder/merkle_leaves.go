@@ -0,0 +1,192 @@
+package ethcoder
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// MerkleLeafSchema declares how the rows of a CSV or JSON file are encoded as
+// merkle tree leaves, as keccak256(abi.encodePacked(...)) of the values of
+// Columns with the solidity Types.
+type MerkleLeafSchema struct {
+	Columns []string
+	Types   []string
+}
+
+// MerkleLeaves are the leaves read from a file, in the order of its rows.
+type MerkleLeaves struct {
+	Leaves [][]byte
+
+	// Duplicates are the leaves which appear in more than one row.
+	Duplicates []DuplicateMerkleLeaf
+}
+
+// DuplicateMerkleLeaf is a leaf and the indexes of the rows it appears in.
+type DuplicateMerkleLeaf struct {
+	Leaf []byte
+	Rows []int
+}
+
+func (d DuplicateMerkleLeaf) String() string {
+	return fmt.Sprintf("leaf %s in rows %v", HexEncode(d.Leaf), d.Rows)
+}
+
+// Validate returns an error listing the duplicate leaves, if any, as a tree
+// with duplicate leaves can't tell apart their proofs.
+func (l *MerkleLeaves) Validate() error {
+	if len(l.Duplicates) == 0 {
+		return nil
+	}
+	duplicates := make([]string, len(l.Duplicates))
+	for i, d := range l.Duplicates {
+		duplicates[i] = d.String()
+	}
+	return fmt.Errorf("ethcoder: %d duplicate merkle leaves: %s", len(l.Duplicates), strings.Join(duplicates, ", "))
+}
+
+func (s MerkleLeafSchema) validate() error {
+	if len(s.Columns) == 0 || len(s.Columns) != len(s.Types) {
+		return fmt.Errorf("ethcoder: merkle leaf schema has %d columns and %d types", len(s.Columns), len(s.Types))
+	}
+	return nil
+}
+
+// EncodeLeaf returns the leaf of the string values of a row, which are in the
+// order of the columns of the schema.
+func (s MerkleLeafSchema) EncodeLeaf(stringValues []string) ([]byte, error) {
+	values, err := AbiUnmarshalStringValues(s.Types, stringValues)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := SolidityPackedKeccak256(s.Types, values)
+	if err != nil {
+		return nil, err
+	}
+	return hash.Bytes(), nil
+}
+
+// ReadMerkleLeavesCSV reads the leaves of a CSV file with a header row, which
+// must contain all the columns of the schema. Other columns are ignored.
+func ReadMerkleLeavesCSV(r io.Reader, schema MerkleLeafSchema) (*MerkleLeaves, error) {
+	if err := schema.validate(); err != nil {
+		return nil, err
+	}
+
+	cr, positions, err := newMerkleLeavesCSVReader(r, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ethcoder: failed to read csv: %w", err)
+		}
+		rows = append(rows, merkleLeavesCSVRow(record, positions))
+	}
+
+	return encodeMerkleLeaves(schema, rows)
+}
+
+// newMerkleLeavesCSVReader reads the header row of a CSV file, and returns the
+// positions of the columns of the schema.
+func newMerkleLeavesCSVReader(r io.Reader, schema MerkleLeafSchema) (*csv.Reader, []int, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("ethcoder: failed to read csv header: %w", err)
+	}
+	positions := make([]int, len(schema.Columns))
+	for i, column := range schema.Columns {
+		positions[i] = -1
+		for j, name := range header {
+			if strings.TrimSpace(name) == column {
+				positions[i] = j
+				break
+			}
+		}
+		if positions[i] == -1 {
+			return nil, nil, fmt.Errorf("ethcoder: csv header is missing column %q", column)
+		}
+	}
+
+	return cr, positions, nil
+}
+
+func merkleLeavesCSVRow(record []string, positions []int) []string {
+	row := make([]string, len(positions))
+	for i, p := range positions {
+		row[i] = strings.TrimSpace(record[p])
+	}
+	return row
+}
+
+// ReadMerkleLeavesJSON reads the leaves of a JSON array of objects, which must
+// have a key for every column of the schema. Values are strings in the format
+// of AbiUnmarshalStringValues, numbers, booleans or arrays.
+func ReadMerkleLeavesJSON(r io.Reader, schema MerkleLeafSchema) (*MerkleLeaves, error) {
+	if err := schema.validate(); err != nil {
+		return nil, err
+	}
+
+	var objects []map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&objects); err != nil {
+		return nil, fmt.Errorf("ethcoder: failed to decode json: %w", err)
+	}
+
+	rows := make([][]string, len(objects))
+	for i, object := range objects {
+		rows[i] = make([]string, len(schema.Columns))
+		for j, column := range schema.Columns {
+			raw, ok := object[column]
+			if !ok {
+				return nil, fmt.Errorf("ethcoder: row %d is missing column %q", i, column)
+			}
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				// numbers, booleans and arrays are passed as their json
+				s = string(bytes.TrimSpace(raw))
+			}
+			rows[i][j] = s
+		}
+	}
+
+	return encodeMerkleLeaves(schema, rows)
+}
+
+func encodeMerkleLeaves(schema MerkleLeafSchema, rows [][]string) (*MerkleLeaves, error) {
+	leaves := &MerkleLeaves{Leaves: make([][]byte, len(rows))}
+
+	seen := map[string][]int{}
+	var order []string
+	for i, row := range rows {
+		leaf, err := schema.EncodeLeaf(row)
+		if err != nil {
+			return nil, fmt.Errorf("ethcoder: failed to encode row %d: %w", i, err)
+		}
+		leaves.Leaves[i] = leaf
+
+		key := hex.EncodeToString(leaf)
+		if len(seen[key]) == 1 {
+			order = append(order, key)
+		}
+		seen[key] = append(seen[key], i)
+	}
+
+	for _, key := range order {
+		leaf, _ := hex.DecodeString(key)
+		leaves.Duplicates = append(leaves.Duplicates, DuplicateMerkleLeaf{Leaf: leaf, Rows: seen[key]})
+	}
+	return leaves, nil
+}
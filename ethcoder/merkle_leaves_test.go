@@ -0,0 +1,56 @@
+package ethcoder
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadMerkleLeaves(t *testing.T) {
+	schema := MerkleLeafSchema{
+		Columns: []string{"account", "tokenId"},
+		Types:   []string{"address", "uint256"},
+	}
+
+	csvLeaves, err := ReadMerkleLeavesCSV(strings.NewReader(
+		"tokenId,account,note\n"+
+			"1,0x1e946c284bdBb05Fb6EF41016C524E8681e3d05E,a\n"+
+			"2,0x1D74B866598B339006160d704642459B04ba890B,b\n"+
+			"1,0x1e946c284bdBb05Fb6EF41016C524E8681e3d05E,c\n",
+	), schema)
+	require.NoError(t, err)
+	require.Len(t, csvLeaves.Leaves, 3)
+
+	packed, err := SolidityPack(schema.Types, []interface{}{common.HexToAddress("0x1e946c284bdBb05Fb6EF41016C524E8681e3d05E"), big.NewInt(1)})
+	require.NoError(t, err)
+	assert.Equal(t, Keccak256(packed), csvLeaves.Leaves[0])
+
+	require.Len(t, csvLeaves.Duplicates, 1)
+	assert.Equal(t, []int{0, 2}, csvLeaves.Duplicates[0].Rows)
+	assert.ErrorContains(t, csvLeaves.Validate(), "1 duplicate merkle leaves")
+
+	jsonLeaves, err := ReadMerkleLeavesJSON(strings.NewReader(`[
+		{"account": "0x1e946c284bdBb05Fb6EF41016C524E8681e3d05E", "tokenId": 1},
+		{"account": "0x1D74B866598B339006160d704642459B04ba890B", "tokenId": "2"}
+	]`), schema)
+	require.NoError(t, err)
+	assert.NoError(t, jsonLeaves.Validate())
+	assert.Equal(t, csvLeaves.Leaves[:2], jsonLeaves.Leaves)
+
+	mt := NewMerkleTree(jsonLeaves.Leaves, nil, nil)
+	proof, err := mt.GetProof(jsonLeaves.Leaves[1])
+	require.NoError(t, err)
+	ok, err := mt.Verify(proof, jsonLeaves.Leaves[1], mt.GetRoot())
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = ReadMerkleLeavesCSV(strings.NewReader("account\n0x1e946c284bdBb05Fb6EF41016C524E8681e3d05E\n"), schema)
+	assert.ErrorContains(t, err, `missing column "tokenId"`)
+
+	_, err = ReadMerkleLeavesJSON(strings.NewReader(`[{"account": "0x1e946c284bdBb05Fb6EF41016C524E8681e3d05E", "tokenId": "abc"}]`), schema)
+	assert.Error(t, err)
+}
@@ -2,15 +2,81 @@ package ethcoder
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"sort"
 
 	"github.com/0xsequence/ethkit/go-ethereum/crypto"
 )
 
 type Options struct {
+	// SortLeaves sorts the leaves by their hash before building the tree.
 	SortLeaves bool
-	SortPairs  bool
+
+	// SortPairs sorts each pair of sibling nodes before hashing them, ie. the
+	// commutative hashing of OpenZeppelin's MerkleProof, so that the proofs of
+	// the tree verify on-chain with MerkleProof.verify, see VerifyMerkleProof.
+	SortPairs bool
+
+	// OddNode is how the last node of a layer with an odd number of nodes is
+	// hashed, by default promoted to the next layer.
+	OddNode MerkleOddNode
+
+	// NodeHash is the hash function of the pairs of nodes, by default keccak256.
+	// The leaves are hashed by the hash function of the tree.
+	NodeHash MerkleNodeHash
+}
+
+// MerkleOddNode is a strategy of hashing of the unpaired last node of a layer.
+type MerkleOddNode int
+
+const (
+	// MerkleOddNodePromote promotes the node to the next layer as is, as
+	// merkletreejs does by default.
+	MerkleOddNodePromote MerkleOddNode = iota
+
+	// MerkleOddNodeDuplicate hashes the node with itself, as merkletreejs does
+	// with duplicateOdd and bitcoin trees.
+	MerkleOddNodeDuplicate
+
+	// MerkleOddNodeZeroHash hashes the node with a zero bytes32.
+	MerkleOddNodeZeroHash
+)
+
+// MerkleNodeHash is a hash function of the pairs of nodes of a tree.
+type MerkleNodeHash int
+
+const (
+	MerkleNodeHashKeccak256 MerkleNodeHash = iota
+	MerkleNodeHashSHA256
+
+	// MerkleNodeHashDoubleKeccak256 is keccak256(keccak256(...)).
+	MerkleNodeHashDoubleKeccak256
+
+	// MerkleNodeHashDoubleSHA256 is sha256(sha256(...)), as bitcoin trees.
+	MerkleNodeHashDoubleSHA256
+)
+
+func (h MerkleNodeHash) hash(data ...[]byte) []byte {
+	switch h {
+	case MerkleNodeHashSHA256, MerkleNodeHashDoubleSHA256:
+		d := sha256.New()
+		for _, b := range data {
+			d.Write(b)
+		}
+		sum := d.Sum(nil)
+		if h == MerkleNodeHashDoubleSHA256 {
+			double := sha256.Sum256(sum)
+			sum = double[:]
+		}
+		return sum
+	case MerkleNodeHashDoubleKeccak256:
+		return crypto.Keccak256(crypto.Keccak256(data...))
+	default:
+		return crypto.Keccak256(data...)
+	}
 }
 
 var DefaultMerkleTreeOptions = Options{
@@ -24,9 +90,30 @@ type Proof struct {
 	Data   []byte
 }
 
+// MarshalJSON returns the 0x-prefixed hex string of the node of the proof, so a
+// []Proof is the bytes32[] proof of MerkleProof.verify. IsLeft isn't kept, as the
+// pairs of the proofs of MerkleProof are sorted.
+func (p Proof) MarshalJSON() ([]byte, error) {
+	return json.Marshal(HexEncode(p.Data))
+}
+
+// UnmarshalJSON reads the node of a proof of MarshalJSON.
+func (p *Proof) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	node, err := HexDecode(s)
+	if err != nil {
+		return fmt.Errorf("ethcoder: invalid proof node: %w", err)
+	}
+	*p = Proof{Data: node}
+	return nil
+}
+
 type MerkleTree[TLeaf any] struct {
+	merkleHasher
 	sortLeaves bool
-	sortPairs  bool
 	hashFn     func(TLeaf) ([]byte, error)
 	leaves     []TLeaf
 	layers     [][][]byte
@@ -44,9 +131,9 @@ func NewMerkleTree[TLeaf any](leaves []TLeaf, hashFn *func(TLeaf) ([]byte, error
 		options = &DefaultMerkleTreeOptions
 	}
 	mt := &MerkleTree[TLeaf]{
-		hashFn:     *hashFn,
-		sortLeaves: options.SortLeaves,
-		sortPairs:  options.SortPairs,
+		merkleHasher: newMerkleHasher(options),
+		hashFn:       *hashFn,
+		sortLeaves:   options.SortLeaves,
 	}
 	mt.processLeaves(leaves)
 	return mt
@@ -81,17 +168,7 @@ func (mt *MerkleTree[TLeaf]) createHashes(nodes [][]byte) {
 	for len(nodes) > 1 {
 		var nextLayer [][]byte
 		for i := 0; i < len(nodes); i += 2 {
-			if i+1 == len(nodes) {
-				nextLayer = append(nextLayer, nodes[i])
-			} else {
-				left := nodes[i]
-				right := nodes[i+1]
-				if mt.sortPairs && bytes.Compare(left, right) > 0 {
-					left, right = right, left
-				}
-				hash := crypto.Keccak256(append(left, right...))
-				nextLayer = append(nextLayer, hash)
-			}
+			nextLayer = append(nextLayer, mt.parent(nodes, i))
 		}
 		nodes = nextLayer
 		mt.layers = append(mt.layers, nodes)
@@ -105,34 +182,35 @@ func (mt *MerkleTree[TLeaf]) GetRoot() []byte {
 	return mt.layers[len(mt.layers)-1][0]
 }
 
+// GetProof returns the proof of the leaf, or of its first index if the tree has
+// duplicates of it, see GetProofByIndex.
 func (mt *MerkleTree[TLeaf]) GetProof(leaf TLeaf) ([]Proof, error) {
-	leafIndex := -1
 	targetNode, err := mt.hashFn(leaf)
 	if err != nil {
 		return nil, err
 	}
-
-	for i, l := range mt.leaves {
-		// Ignore err. Already checked in processLeaves
-		node, _ := mt.hashFn(l)
-		if bytes.Equal(node, targetNode) {
-			leafIndex = i
-			break
-		}
-	}
+	leafIndex := mt.indexOfNode(targetNode)
 	if leafIndex == -1 {
 		return nil, errors.New("leaf not found in tree")
 	}
+	return mt.GetProofByIndex(leafIndex)
+}
+
+// GetProofByIndex returns the proof of the leaf at the index of the leaves of
+// the tree, which are sorted by their hash with SortLeaves.
+func (mt *MerkleTree[TLeaf]) GetProofByIndex(leafIndex int) ([]Proof, error) {
+	if len(mt.layers) == 0 || leafIndex < 0 || leafIndex >= len(mt.layers[0]) {
+		return nil, fmt.Errorf("ethcoder: leaf index %d out of range", leafIndex)
+	}
 
 	proof := []Proof{}
 	for i := 0; i < len(mt.layers)-1; i++ {
 		layer := mt.layers[i]
-		pairIndex := leafIndex ^ 1
-		if pairIndex < len(layer) {
+		if pair := mt.sibling(layer, leafIndex); pair != nil {
 			isLeft := leafIndex%2 != 0
 			proof = append(proof, Proof{
 				IsLeft: isLeft,
-				Data:   layer[pairIndex],
+				Data:   pair,
 			})
 		}
 		leafIndex /= 2
@@ -140,21 +218,124 @@ func (mt *MerkleTree[TLeaf]) GetProof(leaf TLeaf) ([]Proof, error) {
 	return proof, nil
 }
 
-func (mt *MerkleTree[TLeaf]) GetHexProof(leaf TLeaf) [][]byte {
-	proof, _ := mt.GetProof(leaf)
-	hexProof := make([][]byte, len(proof))
+// IndexOf returns the first index of the leaf in the leaves of the tree, or -1
+// if it isn't in the tree.
+func (mt *MerkleTree[TLeaf]) IndexOf(leaf TLeaf) int {
+	node, err := mt.hashFn(leaf)
+	if err != nil {
+		return -1
+	}
+	return mt.indexOfNode(node)
+}
+
+func (mt *MerkleTree[TLeaf]) indexOfNode(node []byte) int {
+	for i := 0; len(mt.layers) > 0 && i < len(mt.layers[0]); i++ {
+		if bytes.Equal(mt.layers[0][i], node) {
+			return i
+		}
+	}
+	return -1
+}
+
+// GetHexProof returns the proof of the leaf as 0x-prefixed hex strings, ie. the
+// bytes32[] proof of MerkleProof.verify for a frontend, or nil if the leaf isn't
+// in the tree.
+func (mt *MerkleTree[TLeaf]) GetHexProof(leaf TLeaf) []string {
+	proof, err := mt.GetProof(leaf)
+	if err != nil {
+		return nil
+	}
+	hexProof := make([]string, 0, len(proof))
 	for _, p := range proof {
-		hexProof = append(hexProof, []byte(p.Data))
+		hexProof = append(hexProof, HexEncode(p.Data))
 	}
 	return hexProof
 }
 
+// GetProofData returns the nodes of the proof of the leaf, or nil if the leaf
+// isn't in the tree.
+func (mt *MerkleTree[TLeaf]) GetProofData(leaf TLeaf) [][]byte {
+	proof, err := mt.GetProof(leaf)
+	if err != nil {
+		return nil
+	}
+	data := make([][]byte, 0, len(proof))
+	for _, p := range proof {
+		data = append(data, p.Data)
+	}
+	return data
+}
+
 func (mt *MerkleTree[TLeaf]) Verify(proof []Proof, leaf TLeaf, root []byte) (bool, error) {
 	hash, err := mt.hashFn(leaf)
 	if err != nil {
 		return false, err
 	}
 
+	return mt.verify(proof, hash, root)
+}
+
+// merkleHasher hashes the nodes of a tree with its options.
+type merkleHasher struct {
+	sortPairs bool
+	oddNode   MerkleOddNode
+	nodeHash  MerkleNodeHash
+}
+
+func newMerkleHasher(options *Options) merkleHasher {
+	return merkleHasher{
+		sortPairs: options.SortPairs,
+		oddNode:   options.OddNode,
+		nodeHash:  options.NodeHash,
+	}
+}
+
+// sibling returns the sibling of the node at the index of the layer, or nil if
+// the node is promoted.
+func (h merkleHasher) sibling(layer [][]byte, index int) []byte {
+	if pairIndex := index ^ 1; pairIndex < len(layer) {
+		return layer[pairIndex]
+	}
+	return h.oddSibling(layer[index])
+}
+
+// oddSibling returns the sibling of the unpaired last node of a layer, or nil if
+// it's promoted.
+func (h merkleHasher) oddSibling(node []byte) []byte {
+	switch h.oddNode {
+	case MerkleOddNodeDuplicate:
+		return node
+	case MerkleOddNodeZeroHash:
+		return make([]byte, 32)
+	default:
+		return nil
+	}
+}
+
+// parent returns the node of the next layer of the node at the index of the
+// layer.
+func (h merkleHasher) parent(layer [][]byte, index int) []byte {
+	return h.parentOf(layer[index], h.sibling(layer, index), index)
+}
+
+func (h merkleHasher) parentOf(node, pair []byte, index int) []byte {
+	if pair == nil {
+		return node
+	}
+	if index%2 != 0 {
+		return h.hashPair(pair, node)
+	}
+	return h.hashPair(node, pair)
+}
+
+func (h merkleHasher) hashPair(left, right []byte) []byte {
+	if h.sortPairs && bytes.Compare(left, right) > 0 {
+		left, right = right, left
+	}
+	return h.nodeHash.hash(left, right)
+}
+
+func (h merkleHasher) verify(proof []Proof, hash []byte, root []byte) (bool, error) {
 	if proof == nil || len(hash) == 0 || len(root) == 0 {
 		return false, errors.New("invalid proof, leaf or root")
 	}
@@ -169,13 +350,13 @@ func (mt *MerkleTree[TLeaf]) Verify(proof []Proof, leaf TLeaf, root []byte) (boo
 
 		var buffers [][]byte
 
-		if mt.sortPairs {
+		if h.sortPairs {
 			if bytes.Compare(hash, data) < 0 {
 				buffers = append(buffers, hash, data)
 			} else {
 				buffers = append(buffers, data, hash)
 			}
-			hash = crypto.Keccak256(bytes.Join(buffers, []byte{}))
+			hash = h.nodeHash.hash(bytes.Join(buffers, []byte{}))
 		} else {
 			buffers = append(buffers, hash)
 			if isLeftNode {
@@ -183,9 +364,25 @@ func (mt *MerkleTree[TLeaf]) Verify(proof []Proof, leaf TLeaf, root []byte) (boo
 			} else {
 				buffers = append(buffers, data)
 			}
-			hash = crypto.Keccak256(bytes.Join(buffers, []byte{}))
+			hash = h.nodeHash.hash(bytes.Join(buffers, []byte{}))
 		}
 	}
 
 	return bytes.Equal(hash, root), nil
 }
+
+// VerifyMerkleProof verifies the proof of the leaf hash against the root the way
+// OpenZeppelin's MerkleProof.verify does, ie. by hashing each pair of nodes in
+// sorted order. It verifies the proofs of a tree built with SortPairs, as
+// returned by GetProofData.
+func VerifyMerkleProof(proof [][]byte, root []byte, leaf []byte) bool {
+	hash := leaf
+	for _, node := range proof {
+		if bytes.Compare(hash, node) < 0 {
+			hash = crypto.Keccak256(hash, node)
+		} else {
+			hash = crypto.Keccak256(node, hash)
+		}
+	}
+	return len(root) > 0 && bytes.Equal(hash, root)
+}
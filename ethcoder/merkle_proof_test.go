@@ -2,6 +2,7 @@ package ethcoder
 
 import (
 	"crypto/rand"
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"testing"
@@ -151,3 +152,209 @@ func TestMerkleProofHashFn(t *testing.T) {
 	assert.Nil(t, err)
 	assert.True(t, isValid)
 }
+
+func TestMerkleProofSortedPairs(t *testing.T) {
+	leaves := make([][]byte, 5)
+	for i := range leaves {
+		leaf := make([]byte, 32, 64)
+		rand.Read(leaf)
+		leaves[i] = leaf
+	}
+	leaf0 := append([]byte{}, leaves[0]...)
+
+	mt := NewMerkleTree(leaves, nil, &Options{SortPairs: true})
+	root := mt.GetRoot()
+	assert.Len(t, root, 32)
+
+	// the spare capacity of the leaves is not written to
+	assert.Equal(t, leaf0, leaves[0][:cap(leaves[0])][:32])
+	assert.Equal(t, make([]byte, 32), leaves[0][:cap(leaves[0])][32:])
+
+	for _, leaf := range leaves {
+		proof := mt.GetProofData(leaf)
+		assert.NotEmpty(t, proof)
+		for _, p := range proof {
+			assert.Len(t, p, 32)
+		}
+		assert.True(t, VerifyMerkleProof(proof, root, leaf))
+		assert.False(t, VerifyMerkleProof(proof, root, Keccak256(leaf)))
+	}
+
+	// the odd node of a layer is promoted to the next layer, without a sibling
+	assert.Len(t, mt.GetProofData(mt.leaves[4]), 1)
+	assert.False(t, VerifyMerkleProof(mt.GetProofData(leaves[0]), root, leaves[1]))
+	assert.False(t, VerifyMerkleProof(nil, nil, leaves[0]))
+}
+
+func TestMerkleProofByIndex(t *testing.T) {
+	leaves := [][]byte{
+		common.HexToAddress("0x1e946c284bdBb05Fb6EF41016C524E8681e3d05E").Bytes(),
+		common.HexToAddress("0x1D74B866598B339006160d704642459B04ba890B").Bytes(),
+		common.HexToAddress("0x1e946c284bdBb05Fb6EF41016C524E8681e3d05E").Bytes(),
+	}
+	mt := NewMerkleTree(leaves, nil, &Options{SortPairs: true})
+	root := mt.GetRoot()
+
+	assert.Equal(t, 0, mt.IndexOf(leaves[0]))
+	assert.Equal(t, 1, mt.IndexOf(leaves[1]))
+	assert.Equal(t, -1, mt.IndexOf(common.HexToAddress("0x37e948435E916069D3a1431Ddf508421073fF3E7").Bytes()))
+
+	// the duplicate leaves have different proofs
+	proof0, err := mt.GetProofByIndex(0)
+	assert.Nil(t, err)
+	proof2, err := mt.GetProofByIndex(2)
+	assert.Nil(t, err)
+	assert.NotEqual(t, proof0, proof2)
+	for _, proof := range [][]Proof{proof0, proof2} {
+		isValid, err := mt.Verify(proof, leaves[0], root)
+		assert.Nil(t, err)
+		assert.True(t, isValid)
+	}
+	proof, err := mt.GetProof(leaves[0])
+	assert.Nil(t, err)
+	assert.Equal(t, proof0, proof)
+
+	_, err = mt.GetProofByIndex(3)
+	assert.Error(t, err)
+}
+
+func TestMerkleOddNode(t *testing.T) {
+	leaves := make([][]byte, 5)
+	for i := range leaves {
+		leaves[i] = Keccak256([]byte{byte(i)})
+	}
+	pair := func(a, b []byte) []byte {
+		return Keccak256(append(append([]byte{}, a...), b...))
+	}
+	zero := make([]byte, 32)
+
+	// the last node of the second layer, of the odd leaf
+	lastNodes := map[MerkleOddNode][]byte{
+		MerkleOddNodePromote:   leaves[4],
+		MerkleOddNodeDuplicate: pair(leaves[4], leaves[4]),
+		MerkleOddNodeZeroHash:  pair(leaves[4], zero),
+	}
+	for oddNode, last := range lastNodes {
+		// layers of 5, 3, 2 and 1 nodes
+		n01, n23 := pair(leaves[0], leaves[1]), pair(leaves[2], leaves[3])
+		var n2 []byte
+		switch oddNode {
+		case MerkleOddNodePromote:
+			n2 = last
+		case MerkleOddNodeDuplicate:
+			n2 = pair(last, last)
+		case MerkleOddNodeZeroHash:
+			n2 = pair(last, zero)
+		}
+		expectedRoot := pair(pair(n01, n23), n2)
+
+		options := &Options{OddNode: oddNode}
+		mt := NewMerkleTree(leaves, nil, options)
+		assert.Equal(t, expectedRoot, mt.GetRoot(), "odd node %d", oddNode)
+
+		imt := NewIncrementalMerkleTree[[]byte](nil, options)
+		for _, leaf := range leaves {
+			_, err := imt.Append(leaf)
+			assert.Nil(t, err)
+		}
+		assert.Equal(t, expectedRoot, imt.GetRoot(), "odd node %d", oddNode)
+
+		for i, leaf := range leaves {
+			proof, err := mt.GetProofByIndex(i)
+			assert.Nil(t, err)
+			isValid, err := mt.Verify(proof, leaf, expectedRoot)
+			assert.Nil(t, err)
+			assert.True(t, isValid, "odd node %d, leaf %d", oddNode, i)
+
+			iproof, err := imt.GetProofByIndex(i)
+			assert.Nil(t, err)
+			assert.Equal(t, proof, iproof)
+		}
+	}
+}
+
+func TestMerkleHexProof(t *testing.T) {
+	testAddr := common.HexToAddress("0x1e946c284bdBb05Fb6EF41016C524E8681e3d05E")
+	leaves := [][]byte{
+		Keccak256(testAddr.Bytes()),
+		Keccak256(common.HexToAddress("0x1D74B866598B339006160d704642459B04ba890B").Bytes()),
+		Keccak256(common.HexToAddress("0x37e948435E916069D3a1431Ddf508421073fF3E7").Bytes()),
+	}
+	mt := NewMerkleTree(leaves, nil, nil)
+
+	hexProof := mt.GetHexProof(leaves[0])
+	assert.Len(t, hexProof, 2)
+	for i, node := range mt.GetProofData(leaves[0]) {
+		assert.Equal(t, HexEncode(node), hexProof[i])
+		assert.Len(t, hexProof[i], 66)
+	}
+	assert.Nil(t, mt.GetHexProof(Keccak256(testAddr.Bytes()[1:])))
+
+	proof, err := mt.GetProof(leaves[0])
+	assert.Nil(t, err)
+	data, err := json.Marshal(proof)
+	assert.Nil(t, err)
+	expected, err := json.Marshal(hexProof)
+	assert.Nil(t, err)
+	assert.JSONEq(t, string(expected), string(data))
+
+	var decoded []Proof
+	assert.Nil(t, json.Unmarshal(data, &decoded))
+	for i := range decoded {
+		assert.Equal(t, proof[i].Data, decoded[i].Data)
+	}
+	isValid, err := mt.Verify(decoded, leaves[0], mt.GetRoot())
+	assert.Nil(t, err)
+	assert.True(t, isValid)
+}
+
+func TestMerkleNodeHash(t *testing.T) {
+	reverse := func(b []byte) []byte {
+		out := make([]byte, len(b))
+		for i := range b {
+			out[len(b)-1-i] = b[i]
+		}
+		return out
+	}
+
+	// the transactions of bitcoin block 170, whose hashes are displayed in
+	// reverse byte order
+	txs := [][]byte{
+		reverse(common.Hex2Bytes("b1fea52486ce0c62bb442b530a3f0132b826c74e473d1f2c220bfa78111c5082")),
+		reverse(common.Hex2Bytes("f4184fc596403b9d638783cf57adfe4c75c605f6356fbc91338530e9831e9e16")),
+	}
+	options := &Options{OddNode: MerkleOddNodeDuplicate, NodeHash: MerkleNodeHashDoubleSHA256}
+	mt := NewMerkleTree(txs, nil, options)
+	assert.Equal(t, "7dac2c5666815c17a3b36427de37bb9d2e2c5ccec3f8633eb91a4205cb4c10ff", common.Bytes2Hex(reverse(mt.GetRoot())))
+
+	leaves := [][]byte{Keccak256([]byte{1}), Keccak256([]byte{2}), Keccak256([]byte{3})}
+	for _, nodeHash := range []MerkleNodeHash{MerkleNodeHashKeccak256, MerkleNodeHashSHA256, MerkleNodeHashDoubleKeccak256, MerkleNodeHashDoubleSHA256} {
+		options := &Options{NodeHash: nodeHash}
+		mt := NewMerkleTree(leaves, nil, options)
+		l := mt.layers[0]
+		assert.Equal(t, nodeHash.hash(nodeHash.hash(l[0], l[1]), l[2]), mt.GetRoot())
+
+		imt := NewIncrementalMerkleTree[[]byte](nil, options)
+		for _, leaf := range l {
+			_, err := imt.Append(leaf)
+			assert.Nil(t, err)
+		}
+		assert.Equal(t, mt.GetRoot(), imt.GetRoot())
+
+		proof, err := mt.GetProof(leaves[1])
+		assert.Nil(t, err)
+		isValid, err := mt.Verify(proof, leaves[1], mt.GetRoot())
+		assert.Nil(t, err)
+		assert.True(t, isValid)
+
+		data, err := json.Marshal(mt)
+		assert.Nil(t, err)
+		loaded, err := LoadMerkleTree[[]byte](data, nil)
+		assert.Nil(t, err)
+		isValid, err = loaded.Verify(proof, leaves[1], mt.GetRoot())
+		assert.Nil(t, err)
+		assert.True(t, isValid)
+	}
+	assert.Len(t, MerkleNodeHashSHA256.hash([]byte("abc")), 32)
+	assert.Equal(t, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", common.Bytes2Hex(MerkleNodeHashSHA256.hash([]byte("ab"), []byte("c"))))
+}
@@ -0,0 +1,264 @@
+package ethcoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// StandardMerkleTreeFormat is the format of the dumps of a StandardMerkleTree.
+const StandardMerkleTreeFormat = "standard-v1"
+
+// StandardMerkleTree is a merkle tree compatible with the StandardMerkleTree of
+// OpenZeppelin's @openzeppelin/merkle-tree, ie. its leaves are the double keccak256
+// of the abi.encode of the values, sorted, and its pairs are hashed in sorted
+// order. Its proofs verify on-chain with MerkleProof.verify, and off-chain with
+// VerifyMerkleProof, of the leaf StandardMerkleLeafHash.
+type StandardMerkleTree struct {
+	leafEncoding []string
+	tree         [][]byte
+	values       []StandardMerkleTreeValue
+}
+
+// StandardMerkleTreeValue is a value of the tree, as string values in the format
+// of AbiUnmarshalStringValues, and the index of its leaf in the tree.
+type StandardMerkleTreeValue struct {
+	Value     []string
+	TreeIndex int
+}
+
+// StandardMerkleLeafHash returns the leaf of the values, ie.
+// keccak256(bytes.concat(keccak256(abi.encode(...)))).
+func StandardMerkleLeafHash(leafEncoding []string, values []interface{}) ([]byte, error) {
+	encoded, err := AbiCoder(leafEncoding, values)
+	if err != nil {
+		return nil, err
+	}
+	return Keccak256(Keccak256(encoded)), nil
+}
+
+// NewStandardMerkleTree builds the tree of the string values, each encoded with
+// the types of leafEncoding, as StandardMerkleTree.of(values, leafEncoding).
+func NewStandardMerkleTree(leafEncoding []string, values [][]string) (*StandardMerkleTree, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("ethcoder: standard merkle tree has no values")
+	}
+
+	type hashedValue struct {
+		index int
+		hash  []byte
+	}
+	hashed := make([]hashedValue, len(values))
+	for i, value := range values {
+		hash, err := standardMerkleLeafHash(leafEncoding, value)
+		if err != nil {
+			return nil, fmt.Errorf("ethcoder: failed to encode value %d: %w", i, err)
+		}
+		hashed[i] = hashedValue{index: i, hash: hash}
+	}
+	sort.SliceStable(hashed, func(i, j int) bool {
+		return bytes.Compare(hashed[i].hash, hashed[j].hash) < 0
+	})
+
+	t := &StandardMerkleTree{
+		leafEncoding: append([]string{}, leafEncoding...),
+		tree:         make([][]byte, 2*len(values)-1),
+		values:       make([]StandardMerkleTreeValue, len(values)),
+	}
+
+	// the leaves are at the end of the tree, in reverse order
+	for i, h := range hashed {
+		treeIndex := len(t.tree) - 1 - i
+		t.tree[treeIndex] = h.hash
+		t.values[h.index] = StandardMerkleTreeValue{
+			Value:     append([]string{}, values[h.index]...),
+			TreeIndex: treeIndex,
+		}
+	}
+	for i := len(t.tree) - 1 - len(values); i >= 0; i-- {
+		t.tree[i] = hashSortedPair(t.tree[2*i+1], t.tree[2*i+2])
+	}
+	return t, nil
+}
+
+func standardMerkleLeafHash(leafEncoding []string, stringValues []string) ([]byte, error) {
+	values, err := AbiUnmarshalStringValues(leafEncoding, stringValues)
+	if err != nil {
+		return nil, err
+	}
+	return StandardMerkleLeafHash(leafEncoding, values)
+}
+
+func hashSortedPair(a, b []byte) []byte {
+	if bytes.Compare(a, b) > 0 {
+		a, b = b, a
+	}
+	return crypto.Keccak256(a, b)
+}
+
+// GetRoot returns the root of the tree.
+func (t *StandardMerkleTree) GetRoot() []byte {
+	return t.tree[0]
+}
+
+// LeafEncoding returns the types of the values of the tree.
+func (t *StandardMerkleTree) LeafEncoding() []string {
+	return t.leafEncoding
+}
+
+// Values returns the values of the tree, in the order they were passed.
+func (t *StandardMerkleTree) Values() []StandardMerkleTreeValue {
+	return t.values
+}
+
+// IndexOf returns the index of the value in Values, or -1 if it isn't in the tree.
+func (t *StandardMerkleTree) IndexOf(value []string) int {
+	hash, err := standardMerkleLeafHash(t.leafEncoding, value)
+	if err != nil {
+		return -1
+	}
+	for i, v := range t.values {
+		if bytes.Equal(t.tree[v.TreeIndex], hash) {
+			return i
+		}
+	}
+	return -1
+}
+
+// GetProof returns the proof of the value, see GetProofByIndex.
+func (t *StandardMerkleTree) GetProof(value []string) ([][]byte, error) {
+	i := t.IndexOf(value)
+	if i == -1 {
+		return nil, fmt.Errorf("ethcoder: value not found in tree")
+	}
+	return t.GetProofByIndex(i)
+}
+
+// GetProofByIndex returns the proof of the value of index i in Values, ie. the
+// siblings of the path from its leaf to the root, as tree.getProof(i).
+func (t *StandardMerkleTree) GetProofByIndex(i int) ([][]byte, error) {
+	if i < 0 || i >= len(t.values) {
+		return nil, fmt.Errorf("ethcoder: value index %d out of range", i)
+	}
+	proof := [][]byte{}
+	for node := t.values[i].TreeIndex; node > 0; node = (node - 1) / 2 {
+		sibling := node + 1
+		if node%2 == 0 {
+			sibling = node - 1
+		}
+		proof = append(proof, t.tree[sibling])
+	}
+	return proof, nil
+}
+
+// Verify returns whether the proof of the value verifies against the root of the
+// tree.
+func (t *StandardMerkleTree) Verify(value []string, proof [][]byte) (bool, error) {
+	hash, err := standardMerkleLeafHash(t.leafEncoding, value)
+	if err != nil {
+		return false, err
+	}
+	return VerifyMerkleProof(proof, t.GetRoot(), hash), nil
+}
+
+type standardMerkleTreeDump struct {
+	Format       string                        `json:"format"`
+	Tree         []string                      `json:"tree"`
+	Values       []standardMerkleTreeDumpValue `json:"values"`
+	LeafEncoding []string                      `json:"leafEncoding"`
+}
+
+type standardMerkleTreeDumpValue struct {
+	Value     []json.RawMessage `json:"value"`
+	TreeIndex int               `json:"treeIndex"`
+}
+
+// MarshalJSON returns the dump of the tree, in the format of tree.dump().
+func (t *StandardMerkleTree) MarshalJSON() ([]byte, error) {
+	dump := standardMerkleTreeDump{
+		Format:       StandardMerkleTreeFormat,
+		Tree:         make([]string, len(t.tree)),
+		Values:       make([]standardMerkleTreeDumpValue, len(t.values)),
+		LeafEncoding: t.leafEncoding,
+	}
+	for i, node := range t.tree {
+		dump.Tree[i] = HexEncode(node)
+	}
+	for i, v := range t.values {
+		dump.Values[i] = standardMerkleTreeDumpValue{Value: make([]json.RawMessage, len(v.Value)), TreeIndex: v.TreeIndex}
+		for j, s := range v.Value {
+			dump.Values[i].Value[j] = marshalStandardMerkleValue(t.leafEncoding[j], s)
+		}
+	}
+	return json.Marshal(dump)
+}
+
+// marshalStandardMerkleValue returns the json of a string value, which is the
+// value itself for booleans and arrays.
+func marshalStandardMerkleValue(typ string, s string) json.RawMessage {
+	if (typ == "bool" || strings.HasSuffix(typ, "]")) && json.Valid([]byte(s)) {
+		return json.RawMessage(s)
+	}
+	data, _ := json.Marshal(s)
+	return data
+}
+
+// ReadStandardMerkleTreeJSON reads a tree from a dump of tree.dump(), and
+// validates that its nodes and leaves are those of its values.
+func ReadStandardMerkleTreeJSON(r io.Reader) (*StandardMerkleTree, error) {
+	var dump standardMerkleTreeDump
+	if err := json.NewDecoder(r).Decode(&dump); err != nil {
+		return nil, fmt.Errorf("ethcoder: failed to decode json: %w", err)
+	}
+	if dump.Format != StandardMerkleTreeFormat {
+		return nil, fmt.Errorf("ethcoder: unknown standard merkle tree format %q", dump.Format)
+	}
+	if len(dump.Tree) == 0 {
+		return nil, fmt.Errorf("ethcoder: standard merkle tree is empty")
+	}
+
+	t := &StandardMerkleTree{
+		leafEncoding: dump.LeafEncoding,
+		tree:         make([][]byte, len(dump.Tree)),
+		values:       make([]StandardMerkleTreeValue, len(dump.Values)),
+	}
+	for i, node := range dump.Tree {
+		data, err := HexDecode(node)
+		if err != nil || len(data) != 32 {
+			return nil, fmt.Errorf("ethcoder: invalid node %d of the standard merkle tree", i)
+		}
+		t.tree[i] = data
+	}
+	for i := 0; 2*i+2 < len(t.tree); i++ {
+		if !bytes.Equal(t.tree[i], hashSortedPair(t.tree[2*i+1], t.tree[2*i+2])) {
+			return nil, fmt.Errorf("ethcoder: node %d of the standard merkle tree is not the hash of its children", i)
+		}
+	}
+
+	for i, v := range dump.Values {
+		if v.TreeIndex < len(t.tree)/2 || v.TreeIndex >= len(t.tree) {
+			return nil, fmt.Errorf("ethcoder: value %d has tree index %d which isn't a leaf", i, v.TreeIndex)
+		}
+		value := make([]string, len(v.Value))
+		for j, raw := range v.Value {
+			if err := json.Unmarshal(raw, &value[j]); err != nil {
+				// numbers, booleans and arrays are passed as their json
+				value[j] = string(bytes.TrimSpace(raw))
+			}
+		}
+		hash, err := standardMerkleLeafHash(t.leafEncoding, value)
+		if err != nil {
+			return nil, fmt.Errorf("ethcoder: failed to encode value %d: %w", i, err)
+		}
+		if !bytes.Equal(t.tree[v.TreeIndex], hash) {
+			return nil, fmt.Errorf("ethcoder: value %d is not the leaf of tree index %d", i, v.TreeIndex)
+		}
+		t.values[i] = StandardMerkleTreeValue{Value: value, TreeIndex: v.TreeIndex}
+	}
+	return t, nil
+}
@@ -0,0 +1,85 @@
+package ethcoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStandardMerkleTree(t *testing.T) {
+	// the example of the README of @openzeppelin/merkle-tree
+	leafEncoding := []string{"address", "uint256"}
+	values := [][]string{
+		{"0x1111111111111111111111111111111111111111", "5000000000000000000"},
+		{"0x2222222222222222222222222222222222222222", "2500000000000000000"},
+	}
+	tree, err := NewStandardMerkleTree(leafEncoding, values)
+	require.NoError(t, err)
+	assert.Equal(t, "0xd4dee0beab2d53f2cc83e567171bd2820e49898130a22622b10ead383e90bd77", HexEncode(tree.GetRoot()))
+
+	for i, value := range values {
+		assert.Equal(t, i, tree.IndexOf(value))
+		proof, err := tree.GetProof(value)
+		require.NoError(t, err)
+		require.Len(t, proof, 1)
+		ok, err := tree.Verify(value, proof)
+		require.NoError(t, err)
+		assert.True(t, ok)
+	}
+	assert.Equal(t, -1, tree.IndexOf([]string{"0x3333333333333333333333333333333333333333", "1"}))
+	_, err = tree.GetProofByIndex(2)
+	assert.Error(t, err)
+
+	_, err = NewStandardMerkleTree(leafEncoding, nil)
+	assert.Error(t, err)
+}
+
+func TestStandardMerkleTreeDump(t *testing.T) {
+	leafEncoding := []string{"address", "uint256", "bool", "uint256[]"}
+	var values [][]string
+	for _, addr := range []string{
+		"0x1e946c284bdBb05Fb6EF41016C524E8681e3d05E",
+		"0x1D74B866598B339006160d704642459B04ba890B",
+		"0x37e948435E916069D3a1431Ddf508421073fF3E7",
+		"0x29c34A7d23B8BCBE7c5Ec94C6525b78bb5cbAf36",
+		"0x1111111111111111111111111111111111111111",
+	} {
+		values = append(values, []string{addr, "100", "true", `["1","2"]`})
+	}
+	tree, err := NewStandardMerkleTree(leafEncoding, values)
+	require.NoError(t, err)
+
+	dump, err := json.Marshal(tree)
+	require.NoError(t, err)
+	assert.Contains(t, string(dump), `"format":"standard-v1"`)
+	assert.Contains(t, string(dump), `"value":["0x1e946c284bdBb05Fb6EF41016C524E8681e3d05E","100",true,["1","2"]]`)
+
+	loaded, err := ReadStandardMerkleTreeJSON(bytes.NewReader(dump))
+	require.NoError(t, err)
+	assert.Equal(t, tree.GetRoot(), loaded.GetRoot())
+	assert.Equal(t, tree.Values(), loaded.Values())
+	for i := range values {
+		proof, err := loaded.GetProofByIndex(i)
+		require.NoError(t, err)
+		expected, err := tree.GetProofByIndex(i)
+		require.NoError(t, err)
+		assert.Equal(t, expected, proof)
+
+		leaf, err := standardMerkleLeafHash(leafEncoding, values[i])
+		require.NoError(t, err)
+		assert.True(t, VerifyMerkleProof(proof, loaded.GetRoot(), leaf))
+	}
+
+	// a tampered value or node doesn't load
+	_, err = ReadStandardMerkleTreeJSON(strings.NewReader(strings.Replace(string(dump), `"100"`, `"101"`, 1)))
+	assert.ErrorContains(t, err, "is not the leaf")
+	root := HexEncode(tree.GetRoot())
+	_, err = ReadStandardMerkleTreeJSON(strings.NewReader(strings.Replace(string(dump), root, "0x"+strings.Repeat("00", 32), 1)))
+	assert.ErrorContains(t, err, "is not the hash of its children")
+	_, err = ReadStandardMerkleTreeJSON(strings.NewReader(`{"format":"simple-v1","tree":[]}`))
+	assert.ErrorContains(t, err, "unknown standard merkle tree format")
+}
@@ -0,0 +1,423 @@
+package ethcoder
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// MerkleNodeSize is the size of the nodes of a StreamingMerkleTree.
+const MerkleNodeSize = 32
+
+// MerkleLeafIterator returns the next leaf hash of a stream of leaves, or io.EOF
+// at the end of the stream.
+type MerkleLeafIterator func() ([]byte, error)
+
+// MerkleNodeStore stores the layers of a StreamingMerkleTree, ie. in memory or
+// spilled to disk with FileMerkleNodeStore.
+type MerkleNodeStore interface {
+	// Append appends the node to the layer.
+	Append(layer int, node []byte) error
+
+	// Get returns the node at the index of the layer.
+	Get(layer int, index int64) ([]byte, error)
+
+	// Len returns the number of nodes of the layer, zero for a layer which
+	// doesn't exist.
+	Len(layer int) int64
+}
+
+// StreamingMerkleTree is a merkle tree of a stream of leaves, which are in the
+// order they're streamed, whose layers are kept in a MerkleNodeStore instead of
+// memory. Its root and proofs are those of a MerkleTree of the same leaves
+// without SortLeaves.
+type StreamingMerkleTree struct {
+	merkleHasher
+	store     MerkleNodeStore
+	numLayers int
+}
+
+// BuildStreamingMerkleTree builds the tree of the leaves of the iterator into the
+// store, which must be empty. The leaves must already be sorted, as the
+// SortLeaves option isn't supported, and be of MerkleNodeSize.
+func BuildStreamingMerkleTree(leaves MerkleLeafIterator, store MerkleNodeStore, options *Options) (*StreamingMerkleTree, error) {
+	mt, err := newStreamingMerkleTree(store, options)
+	if err != nil {
+		return nil, err
+	}
+	if store.Len(0) != 0 {
+		return nil, fmt.Errorf("ethcoder: merkle node store is not empty")
+	}
+
+	for {
+		leaf, err := leaves()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(leaf) != MerkleNodeSize {
+			return nil, fmt.Errorf("ethcoder: leaf %d is %d bytes, expected %d", store.Len(0), len(leaf), MerkleNodeSize)
+		}
+		if err := store.Append(0, leaf); err != nil {
+			return nil, err
+		}
+	}
+	if store.Len(0) == 0 {
+		return nil, fmt.Errorf("ethcoder: merkle tree has no leaves")
+	}
+
+	// each layer is read sequentially by pairs to build the next one
+	for layer := 0; store.Len(layer) > 1; layer++ {
+		n := store.Len(layer)
+		for i := int64(0); i < n; i += 2 {
+			node, err := mt.storedParent(layer, i)
+			if err != nil {
+				return nil, err
+			}
+			if err := store.Append(layer+1, node); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	mt.countLayers()
+	return mt, nil
+}
+
+// LoadStreamingMerkleTree returns the tree of a store of BuildStreamingMerkleTree,
+// ie. a FileMerkleNodeStore reopened, with the options it was built with.
+func LoadStreamingMerkleTree(store MerkleNodeStore, options *Options) (*StreamingMerkleTree, error) {
+	mt, err := newStreamingMerkleTree(store, options)
+	if err != nil {
+		return nil, err
+	}
+	if store.Len(0) == 0 {
+		return nil, fmt.Errorf("ethcoder: merkle tree has no leaves")
+	}
+	mt.countLayers()
+	for layer := 1; layer < mt.numLayers; layer++ {
+		if store.Len(layer) != (store.Len(layer-1)+1)/2 {
+			return nil, fmt.Errorf("ethcoder: merkle tree layer %d is incomplete", layer)
+		}
+	}
+	return mt, nil
+}
+
+func newStreamingMerkleTree(store MerkleNodeStore, options *Options) (*StreamingMerkleTree, error) {
+	if options == nil {
+		options = &Options{SortPairs: DefaultMerkleTreeOptions.SortPairs}
+	}
+	if options.SortLeaves {
+		return nil, fmt.Errorf("ethcoder: streaming merkle tree doesn't support SortLeaves, the leaves must be streamed sorted")
+	}
+	return &StreamingMerkleTree{
+		merkleHasher: newMerkleHasher(options),
+		store:        store,
+	}, nil
+}
+
+func (mt *StreamingMerkleTree) countLayers() {
+	mt.numLayers = 1
+	for mt.store.Len(mt.numLayers-1) > 1 {
+		mt.numLayers++
+	}
+}
+
+func (mt *StreamingMerkleTree) storedParent(layer int, index int64) ([]byte, error) {
+	pair, err := mt.storedSibling(layer, index)
+	if err != nil {
+		return nil, err
+	}
+	node, err := mt.store.Get(layer, index)
+	if err != nil {
+		return nil, err
+	}
+	return mt.parentOf(node, pair, int(index%2)), nil
+}
+
+// storedSibling returns the sibling of the node at the index of the layer, or
+// nil if the node is promoted.
+func (mt *StreamingMerkleTree) storedSibling(layer int, index int64) ([]byte, error) {
+	if pairIndex := index ^ 1; pairIndex < mt.store.Len(layer) {
+		return mt.store.Get(layer, pairIndex)
+	}
+	node, err := mt.store.Get(layer, index)
+	if err != nil {
+		return nil, err
+	}
+	return mt.oddSibling(node), nil
+}
+
+// Len returns the number of leaves of the tree.
+func (mt *StreamingMerkleTree) Len() int64 {
+	return mt.store.Len(0)
+}
+
+// GetRoot returns the root of the tree.
+func (mt *StreamingMerkleTree) GetRoot() ([]byte, error) {
+	return mt.store.Get(mt.numLayers-1, 0)
+}
+
+// GetProofByIndex returns the proof of the leaf at the index of the stream.
+func (mt *StreamingMerkleTree) GetProofByIndex(index int64) ([]Proof, error) {
+	if index < 0 || index >= mt.Len() {
+		return nil, fmt.Errorf("ethcoder: leaf index %d out of range", index)
+	}
+	proof := []Proof{}
+	for layer := 0; layer < mt.numLayers-1; layer++ {
+		pair, err := mt.storedSibling(layer, index)
+		if err != nil {
+			return nil, err
+		}
+		if pair != nil {
+			proof = append(proof, Proof{
+				IsLeft: index%2 != 0,
+				Data:   pair,
+			})
+		}
+		index /= 2
+	}
+	return proof, nil
+}
+
+// Verify returns whether the proof of the leaf hash verifies against the root.
+func (mt *StreamingMerkleTree) Verify(proof []Proof, leaf []byte, root []byte) (bool, error) {
+	return mt.verify(proof, leaf, root)
+}
+
+// ReadMerkleLeaves returns an iterator of the leaves of a stream of concatenated
+// leaf hashes of MerkleNodeSize, ie. of a binary file.
+func ReadMerkleLeaves(r io.Reader) MerkleLeafIterator {
+	br := bufio.NewReader(r)
+	return func() ([]byte, error) {
+		leaf := make([]byte, MerkleNodeSize)
+		if _, err := io.ReadFull(br, leaf); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				return nil, fmt.Errorf("ethcoder: truncated merkle leaf")
+			}
+			return nil, err
+		}
+		return leaf, nil
+	}
+}
+
+// StreamMerkleLeavesCSV returns an iterator of the leaves of the rows of a CSV
+// file, as ReadMerkleLeavesCSV without keeping the rows in memory or checking
+// for duplicates.
+func StreamMerkleLeavesCSV(r io.Reader, schema MerkleLeafSchema) (MerkleLeafIterator, error) {
+	if err := schema.validate(); err != nil {
+		return nil, err
+	}
+	cr, positions, err := newMerkleLeavesCSVReader(r, schema)
+	if err != nil {
+		return nil, err
+	}
+	row := 0
+	return func() ([]byte, error) {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ethcoder: failed to read csv: %w", err)
+		}
+		leaf, err := schema.EncodeLeaf(merkleLeavesCSVRow(record, positions))
+		if err != nil {
+			return nil, fmt.Errorf("ethcoder: failed to encode row %d: %w", row, err)
+		}
+		row++
+		return leaf, nil
+	}, nil
+}
+
+// MemoryMerkleNodeStore is a MerkleNodeStore of the layers in memory.
+type MemoryMerkleNodeStore struct {
+	layers [][][]byte
+}
+
+var _ MerkleNodeStore = &MemoryMerkleNodeStore{}
+
+func NewMemoryMerkleNodeStore() *MemoryMerkleNodeStore {
+	return &MemoryMerkleNodeStore{}
+}
+
+func (s *MemoryMerkleNodeStore) Append(layer int, node []byte) error {
+	for len(s.layers) <= layer {
+		s.layers = append(s.layers, nil)
+	}
+	s.layers[layer] = append(s.layers[layer], node)
+	return nil
+}
+
+func (s *MemoryMerkleNodeStore) Get(layer int, index int64) ([]byte, error) {
+	if layer >= len(s.layers) || index < 0 || index >= int64(len(s.layers[layer])) {
+		return nil, fmt.Errorf("ethcoder: no merkle node %d in layer %d", index, layer)
+	}
+	return s.layers[layer][index], nil
+}
+
+func (s *MemoryMerkleNodeStore) Len(layer int) int64 {
+	if layer >= len(s.layers) {
+		return 0
+	}
+	return int64(len(s.layers[layer]))
+}
+
+// FileMerkleNodeStore is a MerkleNodeStore of a file per layer in a directory, of
+// its nodes of MerkleNodeSize. The writes and sequential reads of a layer are
+// buffered.
+type FileMerkleNodeStore struct {
+	dir     string
+	tempDir bool
+	layers  []*fileMerkleLayer
+}
+
+type fileMerkleLayer struct {
+	file   *os.File
+	writer *bufio.Writer
+	len    int64
+
+	// read buffer of the nodes from index cacheStart
+	cache      []byte
+	cacheStart int64
+}
+
+const fileMerkleReadBuffer = 4096
+
+var _ MerkleNodeStore = &FileMerkleNodeStore{}
+
+// NewFileMerkleNodeStore opens the store of the directory, with the layers
+// already in it, or of a new temporary directory if dir is empty, which is
+// removed on Close.
+func NewFileMerkleNodeStore(dir string) (*FileMerkleNodeStore, error) {
+	s := &FileMerkleNodeStore{dir: dir}
+	if dir == "" {
+		var err error
+		if s.dir, err = os.MkdirTemp("", "ethcoder-merkle-"); err != nil {
+			return nil, err
+		}
+		s.tempDir = true
+	} else if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	for layer := 0; ; layer++ {
+		info, err := os.Stat(s.layerPath(layer))
+		if errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		if info.Size()%MerkleNodeSize != 0 {
+			s.Close()
+			return nil, fmt.Errorf("ethcoder: merkle layer file %s is truncated", s.layerPath(layer))
+		}
+		if _, err := s.layer(layer); err != nil {
+			s.Close()
+			return nil, err
+		}
+		s.layers[layer].len = info.Size() / MerkleNodeSize
+	}
+	return s, nil
+}
+
+func (s *FileMerkleNodeStore) layerPath(layer int) string {
+	return filepath.Join(s.dir, fmt.Sprintf("layer-%03d.bin", layer))
+}
+
+func (s *FileMerkleNodeStore) layer(layer int) (*fileMerkleLayer, error) {
+	for len(s.layers) <= layer {
+		f, err := os.OpenFile(s.layerPath(len(s.layers)), os.O_RDWR|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Seek(0, io.SeekEnd); err != nil {
+			f.Close()
+			return nil, err
+		}
+		s.layers = append(s.layers, &fileMerkleLayer{file: f, writer: bufio.NewWriter(f)})
+	}
+	return s.layers[layer], nil
+}
+
+func (s *FileMerkleNodeStore) Append(layer int, node []byte) error {
+	if len(node) != MerkleNodeSize {
+		return fmt.Errorf("ethcoder: merkle node is %d bytes, expected %d", len(node), MerkleNodeSize)
+	}
+	l, err := s.layer(layer)
+	if err != nil {
+		return err
+	}
+	if _, err := l.writer.Write(node); err != nil {
+		return err
+	}
+	l.len++
+	return nil
+}
+
+func (s *FileMerkleNodeStore) Get(layer int, index int64) ([]byte, error) {
+	if layer >= len(s.layers) || index < 0 || index >= s.layers[layer].len {
+		return nil, fmt.Errorf("ethcoder: no merkle node %d in layer %d", index, layer)
+	}
+	l := s.layers[layer]
+	if index < l.cacheStart || index >= l.cacheStart+int64(len(l.cache)/MerkleNodeSize) {
+		if err := l.writer.Flush(); err != nil {
+			return nil, err
+		}
+		count := min(int64(fileMerkleReadBuffer), l.len-index)
+		if cap(l.cache) < int(count)*MerkleNodeSize {
+			l.cache = make([]byte, fileMerkleReadBuffer*MerkleNodeSize)
+		}
+		l.cache = l.cache[:count*MerkleNodeSize]
+		if _, err := l.file.ReadAt(l.cache, index*MerkleNodeSize); err != nil {
+			l.cache = l.cache[:0]
+			return nil, err
+		}
+		l.cacheStart = index
+	}
+	offset := (index - l.cacheStart) * MerkleNodeSize
+	node := make([]byte, MerkleNodeSize)
+	copy(node, l.cache[offset:offset+MerkleNodeSize])
+	return node, nil
+}
+
+func (s *FileMerkleNodeStore) Len(layer int) int64 {
+	if layer >= len(s.layers) {
+		return 0
+	}
+	return s.layers[layer].len
+}
+
+// Flush writes the buffered nodes to the files.
+func (s *FileMerkleNodeStore) Flush() error {
+	for _, l := range s.layers {
+		if err := l.writer.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close flushes and closes the files, and removes the directory of a temporary
+// store.
+func (s *FileMerkleNodeStore) Close() error {
+	var errs []error
+	for _, l := range s.layers {
+		errs = append(errs, l.writer.Flush(), l.file.Close())
+	}
+	s.layers = nil
+	if s.tempDir {
+		errs = append(errs, os.RemoveAll(s.dir))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("ethcoder: failed to close merkle node store: %w", err)
+	}
+	return nil
+}
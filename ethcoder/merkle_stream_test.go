@@ -0,0 +1,102 @@
+package ethcoder
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamingMerkleTree(t *testing.T) {
+	leaves := make([][]byte, 10001)
+	for i := range leaves {
+		leaves[i] = make([]byte, MerkleNodeSize)
+		rand.Read(leaves[i])
+	}
+
+	for _, oddNode := range []MerkleOddNode{MerkleOddNodePromote, MerkleOddNodeDuplicate, MerkleOddNodeZeroHash} {
+		options := &Options{SortPairs: true, OddNode: oddNode}
+		expected := NewMerkleTree(leaves, nil, options)
+
+		store, err := NewFileMerkleNodeStore("")
+		require.NoError(t, err)
+		mt, err := BuildStreamingMerkleTree(ReadMerkleLeaves(bytes.NewReader(bytes.Join(leaves, nil))), store, options)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(leaves)), mt.Len())
+
+		root, err := mt.GetRoot()
+		require.NoError(t, err)
+		assert.Equal(t, expected.GetRoot(), root)
+
+		for _, i := range []int{0, 1, 4096, 9999, 10000} {
+			proof, err := mt.GetProofByIndex(int64(i))
+			require.NoError(t, err)
+			expectedProof, err := expected.GetProofByIndex(i)
+			require.NoError(t, err)
+			assert.Equal(t, expectedProof, proof)
+			ok, err := mt.Verify(proof, leaves[i], root)
+			require.NoError(t, err)
+			assert.True(t, ok)
+		}
+
+		require.NoError(t, store.Close())
+		_, err = os.Stat(store.dir)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	}
+
+	_, err := BuildStreamingMerkleTree(ReadMerkleLeaves(bytes.NewReader(nil)), NewMemoryMerkleNodeStore(), nil)
+	assert.ErrorContains(t, err, "no leaves")
+	_, err = BuildStreamingMerkleTree(ReadMerkleLeaves(bytes.NewReader(make([]byte, 40))), NewMemoryMerkleNodeStore(), nil)
+	assert.ErrorContains(t, err, "truncated")
+	_, err = BuildStreamingMerkleTree(ReadMerkleLeaves(bytes.NewReader(nil)), NewMemoryMerkleNodeStore(), &DefaultMerkleTreeOptions)
+	assert.ErrorContains(t, err, "SortLeaves")
+}
+
+func TestStreamingMerkleTreeReopen(t *testing.T) {
+	schema := MerkleLeafSchema{
+		Columns: []string{"account", "amount"},
+		Types:   []string{"address", "uint256"},
+	}
+	csv := "account,amount\n" +
+		"0x1e946c284bdBb05Fb6EF41016C524E8681e3d05E,1\n" +
+		"0x1D74B866598B339006160d704642459B04ba890B,2\n" +
+		"0x37e948435E916069D3a1431Ddf508421073fF3E7,3\n"
+	read, err := ReadMerkleLeavesCSV(strings.NewReader(csv), schema)
+	require.NoError(t, err)
+
+	leaves, err := StreamMerkleLeavesCSV(strings.NewReader(csv), schema)
+	require.NoError(t, err)
+	dir := filepath.Join(t.TempDir(), "tree")
+	store, err := NewFileMerkleNodeStore(dir)
+	require.NoError(t, err)
+	mt, err := BuildStreamingMerkleTree(leaves, store, nil)
+	require.NoError(t, err)
+	root, err := mt.GetRoot()
+	require.NoError(t, err)
+	assert.Equal(t, NewMerkleTree(read.Leaves, nil, &Options{SortPairs: true}).GetRoot(), root)
+	require.NoError(t, store.Close())
+
+	// the reopened store has the layers of the tree
+	store, err = NewFileMerkleNodeStore(dir)
+	require.NoError(t, err)
+	defer store.Close()
+	loaded, err := LoadStreamingMerkleTree(store, nil)
+	require.NoError(t, err)
+	loadedRoot, err := loaded.GetRoot()
+	require.NoError(t, err)
+	assert.Equal(t, root, loadedRoot)
+	proof, err := loaded.GetProofByIndex(2)
+	require.NoError(t, err)
+	ok, err := loaded.Verify(proof, read.Leaves[2], root)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = BuildStreamingMerkleTree(func() ([]byte, error) { return nil, io.EOF }, store, nil)
+	assert.ErrorContains(t, err, "not empty")
+}
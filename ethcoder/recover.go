@@ -0,0 +1,60 @@
+package ethcoder
+
+import (
+	"fmt"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// RecoverAddressFromDigest returns the address of the signer of the 32 byte
+// digest of the 65 byte signature sig, ie. of EIP191PersonalMessageDigest for
+// personal_sign, of TypedData.EncodeDigest for EIP-712, or of a raw digest. The
+// v of the signature is of NormalizeSignatureV.
+func RecoverAddressFromDigest(digest, sig []byte) (common.Address, error) {
+	if len(digest) != 32 {
+		return common.Address{}, fmt.Errorf("ethcoder: digest of %d bytes, expected 32", len(digest))
+	}
+	if len(sig) != 65 {
+		return common.Address{}, fmt.Errorf("ethcoder: signature of %d bytes, expected 65", len(sig))
+	}
+
+	v, err := NormalizeSignatureV(uint64(sig[64]))
+	if err != nil {
+		return common.Address{}, err
+	}
+	rsv := make([]byte, 65)
+	copy(rsv, sig)
+	rsv[64] = v - 27
+
+	pubkey, err := crypto.SigToPub(digest, rsv)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("ethcoder: failed to recover signer: %w", err)
+	}
+	return crypto.PubkeyToAddress(*pubkey), nil
+}
+
+// ValidateSignature returns whether sig of the digest is of the signer addr, see
+// RecoverAddressFromDigest. The error is of an invalid digest or signature.
+func ValidateSignature(addr common.Address, digest, sig []byte) (bool, error) {
+	signer, err := RecoverAddressFromDigest(digest, sig)
+	if err != nil {
+		return false, err
+	}
+	return signer == addr, nil
+}
+
+// NormalizeSignatureV returns the v in {27,28} of a v in {0,1}, {27,28}, or of a
+// transaction of EIP-155, ie. chainId*2+35+yParity.
+func NormalizeSignatureV(v uint64) (byte, error) {
+	switch {
+	case v == 0 || v == 1:
+		return byte(v) + 27, nil
+	case v == 27 || v == 28:
+		return byte(v), nil
+	case v >= 35:
+		return byte((v-35)%2) + 27, nil
+	default:
+		return 0, fmt.Errorf("ethcoder: invalid signature v %d", v)
+	}
+}
@@ -0,0 +1,92 @@
+package ethcoder_test
+
+import (
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoverAddressFromDigest(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("dose weasel clever culture letter volume endorse used harvest ripple circle install")
+	require.NoError(t, err)
+
+	t.Run("personal-sign", func(t *testing.T) {
+		message := []byte("hello world")
+		sig, err := wallet.SignMessage(message)
+		require.NoError(t, err)
+
+		digest := ethcoder.EIP191PersonalMessageDigest(message)
+		signer, err := ethcoder.RecoverAddressFromDigest(digest.Bytes(), sig)
+		require.NoError(t, err)
+		assert.Equal(t, wallet.Address(), signer)
+
+		// the v of 0 or 1
+		sig[64] -= 27
+		valid, err := ethcoder.ValidateSignature(wallet.Address(), digest.Bytes(), sig)
+		require.NoError(t, err)
+		assert.True(t, valid)
+
+		// the v of EIP-155 of chain 1
+		valid, err = ethcoder.ValidateSignature(wallet.Address(), digest.Bytes(), append(sig[:64:64], 37+sig[64]))
+		require.NoError(t, err)
+		assert.True(t, valid)
+
+		valid, err = ethcoder.ValidateSignature(common.Address{}, digest.Bytes(), sig)
+		require.NoError(t, err)
+		assert.False(t, valid)
+	})
+
+	t.Run("eip712", func(t *testing.T) {
+		typedData := &ethcoder.TypedData{
+			Types: ethcoder.TypedDataTypes{
+				"EIP712Domain": {{Name: "name", Type: "string"}},
+				"Person":       {{Name: "name", Type: "string"}},
+			},
+			PrimaryType: "Person",
+			Domain:      ethcoder.TypedDataDomain{Name: "Ether Mail"},
+			Message:     map[string]interface{}{"name": "Bob"},
+		}
+		digest, err := typedData.EncodeDigest()
+		require.NoError(t, err)
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		sig, err := crypto.Sign(digest, key)
+		require.NoError(t, err)
+
+		signer, err := ethcoder.RecoverAddressFromDigest(digest, sig)
+		require.NoError(t, err)
+		assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), signer)
+	})
+
+	t.Run("raw digest", func(t *testing.T) {
+		// SignData signs the keccak256 digest of its data
+		data := []byte("raw data")
+		sig, err := wallet.SignData(data)
+		require.NoError(t, err)
+
+		valid, err := ethcoder.ValidateSignature(wallet.Address(), ethcoder.Keccak256(data), sig)
+		require.NoError(t, err)
+		assert.True(t, valid)
+
+		valid, err = ethcoder.ValidateSignature(wallet.Address(), ethcoder.Keccak256(nil), sig)
+		require.NoError(t, err)
+		assert.False(t, valid)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ethcoder.RecoverAddressFromDigest(make([]byte, 31), make([]byte, 65))
+		assert.Error(t, err)
+		_, err = ethcoder.RecoverAddressFromDigest(make([]byte, 32), make([]byte, 64))
+		assert.Error(t, err)
+
+		sig := make([]byte, 65)
+		sig[64] = 29
+		_, err = ethcoder.RecoverAddressFromDigest(make([]byte, 32), sig)
+		assert.ErrorContains(t, err, "invalid signature v")
+	})
+}
@@ -0,0 +1,149 @@
+package ethcoder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+)
+
+var (
+	// revertErrorSelector is the selector of Error(string)
+	revertErrorSelector = []byte{0x08, 0xc3, 0x79, 0xa0}
+
+	// revertPanicSelector is the selector of Panic(uint256)
+	revertPanicSelector = []byte{0x4e, 0x48, 0x7b, 0x71}
+)
+
+// PanicCodes are the meanings of the codes of Panic(uint256), see
+// https://docs.soliditylang.org/en/latest/control-structures.html#panic-via-assert-and-error-via-require
+var PanicCodes = map[uint64]string{
+	0x00: "generic compiler panic",
+	0x01: "assertion failed",
+	0x11: "arithmetic underflow or overflow",
+	0x12: "division or modulo by zero",
+	0x21: "invalid enum value",
+	0x22: "invalid storage byte array encoding",
+	0x31: "pop on an empty array",
+	0x32: "array index out of bounds",
+	0x41: "out of memory",
+	0x51: "call to an uninitialized internal function",
+}
+
+// ErrUnknownRevertError is returned when the selector of revert data is none of
+// the selectors of the errors.
+var ErrUnknownRevertError = errors.New("ethcoder: unknown revert error")
+
+// DecodeRevertReason returns the message of the revert data of Error(string),
+// ie. of a require or revert with a reason, or of Panic(uint256), ie.
+// "panic: arithmetic underflow or overflow (0x11)". It returns
+// ErrUnknownRevertError for the other selectors, ie. custom errors, see
+// DecodeRevertError.
+func DecodeRevertReason(data []byte) (string, error) {
+	if len(data) < 4 {
+		return "", fmt.Errorf("ethcoder: revert data of %d bytes has no selector", len(data))
+	}
+
+	switch {
+	case bytes.Equal(data[:4], revertErrorSelector):
+		values, err := AbiDecoderWithReturnedValues([]string{"string"}, data[4:])
+		if err != nil {
+			return "", fmt.Errorf("ethcoder: failed to decode Error(string): %w", err)
+		}
+		return values[0].(string), nil
+
+	case bytes.Equal(data[:4], revertPanicSelector):
+		values, err := AbiDecoderWithReturnedValues([]string{"uint256"}, data[4:])
+		if err != nil {
+			return "", fmt.Errorf("ethcoder: failed to decode Panic(uint256): %w", err)
+		}
+		code := values[0].(*big.Int)
+		reason := "unknown panic code"
+		if code.IsUint64() {
+			if r, ok := PanicCodes[code.Uint64()]; ok {
+				reason = r
+			}
+		}
+		return fmt.Sprintf("panic: %s (0x%x)", reason, code), nil
+
+	default:
+		return "", fmt.Errorf("%w %s", ErrUnknownRevertError, HexEncode(data[:4]))
+	}
+}
+
+// DecodedError is revert data decoded by DecodeRevertError.
+type DecodedError struct {
+	// Name of the error, ie. InsufficientBalance
+	Name string
+
+	// Signature of the error, ie. InsufficientBalance(uint256,uint256)
+	Signature string
+
+	// Args are the arguments of the error, in order, with their values
+	Args []DecodedErrorArg
+}
+
+// DecodedErrorArg is an argument of a DecodedError.
+type DecodedErrorArg struct {
+	Name  string
+	Type  string
+	Value interface{}
+}
+
+// Map returns the values of the arguments by name, where an unnamed argument is
+// named by its position, ie. arg0.
+func (e *DecodedError) Map() map[string]interface{} {
+	m := make(map[string]interface{}, len(e.Args))
+	for i, arg := range e.Args {
+		name := arg.Name
+		if name == "" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		m[name] = arg.Value
+	}
+	return m
+}
+
+// DecodeRevertError decodes the revert data of a call as the error of its
+// selector, one of errs, ie. the errors of a contract abi. It returns
+// ErrUnknownRevertError when the selector is none of the errors.
+func DecodeRevertError(errs []abi.Error, data []byte) (*DecodedError, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("ethcoder: revert data of %d bytes has no selector", len(data))
+	}
+	for _, abiErr := range errs {
+		if !bytes.Equal(abiErr.ID[:4], data[:4]) {
+			continue
+		}
+		values, err := abiErr.Inputs.UnpackValues(data[4:])
+		if err != nil {
+			return nil, fmt.Errorf("ethcoder: failed to decode error %s: %w", abiErr.Sig, err)
+		}
+		decoded := &DecodedError{
+			Name:      abiErr.Name,
+			Signature: abiErr.Sig,
+			Args:      make([]DecodedErrorArg, len(abiErr.Inputs)),
+		}
+		for i, input := range abiErr.Inputs {
+			decoded.Args[i] = DecodedErrorArg{Name: input.Name, Type: input.Type.String(), Value: values[i]}
+		}
+		return decoded, nil
+	}
+	return nil, fmt.Errorf("%w %s", ErrUnknownRevertError, HexEncode(data[:4]))
+}
+
+// DecodeRevertErrorFromSignatures decodes the revert data as one of the errors
+// of the human-readable signatures, see ParseABIError and DecodeRevertError.
+func DecodeRevertErrorFromSignatures(errs []string, data []byte) (*DecodedError, error) {
+	abiErrs := make([]abi.Error, 0, len(errs))
+	for _, e := range errs {
+		abiErr, err := ParseABIError(e)
+		if err != nil {
+			return nil, err
+		}
+		abiErrs = append(abiErrs, *abiErr)
+	}
+	return DecodeRevertError(abiErrs, data)
+}
@@ -0,0 +1,76 @@
+package ethcoder_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeRevertError(t *testing.T) {
+	errs := []string{
+		"error Unauthorized()",
+		"error InsufficientBalance(uint256 available, uint256 required)",
+		"error ERC20InvalidReceiver(address)",
+	}
+
+	data, err := ethcoder.AbiEncodeMethodCalldata("InsufficientBalance(uint256,uint256)", []interface{}{big.NewInt(10), big.NewInt(20)})
+	require.NoError(t, err)
+	decoded, err := ethcoder.DecodeRevertErrorFromSignatures(errs, data)
+	require.NoError(t, err)
+	assert.Equal(t, "InsufficientBalance", decoded.Name)
+	assert.Equal(t, "InsufficientBalance(uint256,uint256)", decoded.Signature)
+	assert.Equal(t, []ethcoder.DecodedErrorArg{
+		{Name: "available", Type: "uint256", Value: big.NewInt(10)},
+		{Name: "required", Type: "uint256", Value: big.NewInt(20)},
+	}, decoded.Args)
+
+	receiver := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	data, err = ethcoder.AbiEncodeMethodCalldata("ERC20InvalidReceiver(address)", []interface{}{receiver})
+	require.NoError(t, err)
+	decoded, err = ethcoder.DecodeRevertErrorFromSignatures(errs, data)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"arg0": receiver}, decoded.Map())
+
+	decoded, err = ethcoder.DecodeRevertErrorFromSignatures(errs, ethcoder.Keccak256([]byte("Unauthorized()"))[:4])
+	require.NoError(t, err)
+	assert.Equal(t, "Unauthorized", decoded.Name)
+	assert.Empty(t, decoded.Args)
+
+	// an unknown selector, or invalid data
+	_, err = ethcoder.DecodeRevertErrorFromSignatures(errs, common.FromHex("0x4e487b710000000000000000000000000000000000000000000000000000000000000011"))
+	assert.ErrorIs(t, err, ethcoder.ErrUnknownRevertError)
+	_, err = ethcoder.DecodeRevertErrorFromSignatures(errs, data[:20])
+	assert.Error(t, err)
+	_, err = ethcoder.DecodeRevertErrorFromSignatures(errs, []byte{0x01})
+	assert.Error(t, err)
+}
+
+func TestDecodeRevertReason(t *testing.T) {
+	data, err := ethcoder.AbiEncodeMethodCalldata("Error(string)", []interface{}{"ERC20: transfer amount exceeds balance"})
+	require.NoError(t, err)
+	assert.Equal(t, "0x08c379a0", ethcoder.HexEncode(data[:4]))
+	reason, err := ethcoder.DecodeRevertReason(data)
+	require.NoError(t, err)
+	assert.Equal(t, "ERC20: transfer amount exceeds balance", reason)
+
+	reason, err = ethcoder.DecodeRevertReason(common.FromHex("0x4e487b710000000000000000000000000000000000000000000000000000000000000011"))
+	require.NoError(t, err)
+	assert.Equal(t, "panic: arithmetic underflow or overflow (0x11)", reason)
+	reason, err = ethcoder.DecodeRevertReason(common.FromHex("0x4e487b7100000000000000000000000000000000000000000000000000000000000000ff"))
+	require.NoError(t, err)
+	assert.Equal(t, "panic: unknown panic code (0xff)", reason)
+
+	// custom errors, and invalid data
+	data, err = ethcoder.AbiEncodeMethodCalldata("InsufficientBalance(uint256,uint256)", []interface{}{big.NewInt(10), big.NewInt(20)})
+	require.NoError(t, err)
+	_, err = ethcoder.DecodeRevertReason(data)
+	assert.ErrorIs(t, err, ethcoder.ErrUnknownRevertError)
+	_, err = ethcoder.DecodeRevertReason(common.FromHex("0x08c379a00000"))
+	assert.Error(t, err)
+	_, err = ethcoder.DecodeRevertReason(nil)
+	assert.Error(t, err)
+}
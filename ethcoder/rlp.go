@@ -0,0 +1,135 @@
+package ethcoder
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/go-ethereum/rlp"
+)
+
+// RLPEncode returns the rlp encoding of the value, ie. of a []byte, string,
+// unsigned integer, *big.Int, slice, RLPItem, or struct of those, with the
+// `rlp:"optional"`, `rlp:"tail"`, `rlp:"nil"` and `rlp:"-"` field tags.
+func RLPEncode(val interface{}) ([]byte, error) {
+	data, err := rlp.EncodeToBytes(val)
+	if err != nil {
+		return nil, fmt.Errorf("ethcoder: rlp encode: %w", err)
+	}
+	return data, nil
+}
+
+// RLPDecode decodes the rlp data into the value out points to, of the types of
+// RLPEncode. The data must be a single item, without trailing bytes.
+func RLPDecode(data []byte, out interface{}) error {
+	if err := rlp.DecodeBytes(data, out); err != nil {
+		return fmt.Errorf("ethcoder: rlp decode: %w", err)
+	}
+	return nil
+}
+
+// RLPItem is an rlp item of an unknown structure, a byte string or a list of
+// items, ie. of raw transactions and custom payloads.
+type RLPItem struct {
+	IsList bool
+	Bytes  []byte
+	List   []RLPItem
+}
+
+// RLPString returns the byte string item of b.
+func RLPString(b []byte) RLPItem {
+	return RLPItem{Bytes: b}
+}
+
+// RLPUint returns the byte string item of an unsigned integer, ie. its big-endian
+// bytes without leading zeros.
+func RLPUint(n uint64) RLPItem {
+	return RLPItem{Bytes: new(big.Int).SetUint64(n).Bytes()}
+}
+
+// RLPBigInt returns the byte string item of a non-negative integer.
+func RLPBigInt(n *big.Int) RLPItem {
+	return RLPItem{Bytes: n.Bytes()}
+}
+
+// RLPList returns the list item of the items.
+func RLPList(items ...RLPItem) RLPItem {
+	return RLPItem{IsList: true, List: items}
+}
+
+// RLPDecodeItem decodes rlp data of an unknown structure.
+func RLPDecodeItem(data []byte) (RLPItem, error) {
+	var item RLPItem
+	if err := RLPDecode(data, &item); err != nil {
+		return RLPItem{}, err
+	}
+	return item, nil
+}
+
+// Uint64 returns the unsigned integer of a byte string item.
+func (i RLPItem) Uint64() (uint64, error) {
+	n, err := i.BigInt()
+	if err != nil {
+		return 0, err
+	}
+	if !n.IsUint64() {
+		return 0, fmt.Errorf("ethcoder: rlp integer %s overflows uint64", n)
+	}
+	return n.Uint64(), nil
+}
+
+// BigInt returns the integer of a byte string item, which must be canonical, ie.
+// without leading zeros.
+func (i RLPItem) BigInt() (*big.Int, error) {
+	if i.IsList {
+		return nil, errors.New("ethcoder: rlp item is a list, expecting an integer")
+	}
+	if len(i.Bytes) > 0 && i.Bytes[0] == 0 {
+		return nil, errors.New("ethcoder: rlp integer has leading zeros")
+	}
+	return new(big.Int).SetBytes(i.Bytes), nil
+}
+
+// EncodeRLP implements rlp.Encoder.
+func (i RLPItem) EncodeRLP(w io.Writer) error {
+	if i.IsList {
+		if i.List == nil {
+			return rlp.Encode(w, []RLPItem{})
+		}
+		return rlp.Encode(w, i.List)
+	}
+	return rlp.Encode(w, i.Bytes)
+}
+
+// DecodeRLP implements rlp.Decoder.
+func (i *RLPItem) DecodeRLP(s *rlp.Stream) error {
+	kind, _, err := s.Kind()
+	if err != nil {
+		return err
+	}
+	if kind != rlp.List {
+		b, err := s.Bytes()
+		if err != nil {
+			return err
+		}
+		*i = RLPItem{Bytes: b}
+		return nil
+	}
+
+	if _, err := s.List(); err != nil {
+		return err
+	}
+	items := []RLPItem{}
+	for {
+		var item RLPItem
+		if err := item.DecodeRLP(s); err == rlp.EOL {
+			break
+		} else if err != nil {
+			return err
+		}
+		items = append(items, item)
+	}
+	*i = RLPItem{IsList: true, List: items}
+	return s.ListEnd()
+}
@@ -0,0 +1,79 @@
+package ethcoder_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRLP(t *testing.T) {
+	// the examples of the rlp spec
+	data, err := ethcoder.RLPEncode([]string{"cat", "dog"})
+	require.NoError(t, err)
+	assert.Equal(t, "0xc88363617483646f67", ethcoder.HexEncode(data))
+
+	item, err := ethcoder.RLPDecodeItem(data)
+	require.NoError(t, err)
+	assert.Equal(t, ethcoder.RLPList(ethcoder.RLPString([]byte("cat")), ethcoder.RLPString([]byte("dog"))), item)
+
+	// the set theoretical representation of three, [ [], [[]], [ [], [[]] ] ]
+	empty := ethcoder.RLPList()
+	three := ethcoder.RLPList(empty, ethcoder.RLPList(empty), ethcoder.RLPList(empty, ethcoder.RLPList(empty)))
+	data, err = ethcoder.RLPEncode(three)
+	require.NoError(t, err)
+	assert.Equal(t, "0xc7c0c1c0c3c0c1c0", ethcoder.HexEncode(data))
+	item, err = ethcoder.RLPDecodeItem(data)
+	require.NoError(t, err)
+	reencoded, err := ethcoder.RLPEncode(item)
+	require.NoError(t, err)
+	assert.Equal(t, data, reencoded)
+
+	// integers
+	data, err = ethcoder.RLPEncode(ethcoder.RLPUint(1024))
+	require.NoError(t, err)
+	assert.Equal(t, "0x820400", ethcoder.HexEncode(data))
+	item, err = ethcoder.RLPDecodeItem(data)
+	require.NoError(t, err)
+	n, err := item.Uint64()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1024), n)
+	_, err = ethcoder.RLPString([]byte{0, 1}).BigInt()
+	assert.Error(t, err)
+	_, err = empty.Uint64()
+	assert.Error(t, err)
+
+	// structs with tags
+	type payload struct {
+		Nonce uint64
+		To    common.Address
+		Data  []byte
+		Value *big.Int `rlp:"optional"`
+	}
+	in := payload{Nonce: 7, To: common.HexToAddress("0x1111111111111111111111111111111111111111"), Data: []byte{0x01}}
+	data, err = ethcoder.RLPEncode(in)
+	require.NoError(t, err)
+	var out payload
+	require.NoError(t, ethcoder.RLPDecode(data, &out))
+	assert.Equal(t, in, out)
+
+	// the items of a raw legacy transaction
+	tx := types.NewTransaction(3, in.To, big.NewInt(10), 21000, big.NewInt(1e9), nil)
+	raw, err := tx.MarshalBinary()
+	require.NoError(t, err)
+	item, err = ethcoder.RLPDecodeItem(raw)
+	require.NoError(t, err)
+	require.Len(t, item.List, 9)
+	nonce, err := item.List[0].Uint64()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), nonce)
+	assert.Equal(t, in.To.Bytes(), item.List[3].Bytes)
+
+	assert.Error(t, ethcoder.RLPDecode(append(data, 0x00), &out))
+	_, err = ethcoder.RLPDecodeItem([]byte{0xc8, 0x83})
+	assert.Error(t, err)
+}
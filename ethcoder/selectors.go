@@ -0,0 +1,223 @@
+package ethcoder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// FunctionSelector returns the 4-byte selector of a function signature, ie.
+// 0xa9059cbb of "transfer(address,uint256)". The signature is human-readable,
+// see ParseABIFunction, ie. "function transfer(address to, uint256 amount)".
+func FunctionSelector(sig string) ([4]byte, error) {
+	method, err := ParseABIFunction(sig)
+	if err != nil {
+		return [4]byte{}, err
+	}
+	var selector [4]byte
+	copy(selector[:], method.ID)
+	return selector, nil
+}
+
+const (
+	// SignatureDirectoryOpenchain is the signature database of openchain.xyz
+	SignatureDirectoryOpenchain = "openchain"
+
+	// SignatureDirectory4byte is 4byte.directory
+	SignatureDirectory4byte = "4byte"
+)
+
+var DefaultSignatureDirectoryOptions = SignatureDirectoryOptions{
+	Source:  SignatureDirectoryOpenchain,
+	Timeout: 10 * time.Second,
+}
+
+type SignatureDirectoryOptions struct {
+	// Source is the directory of the signatures, SignatureDirectoryOpenchain or
+	// SignatureDirectory4byte.
+	Source string
+
+	// URL of the api of the source, by default its public api.
+	URL string
+
+	// HTTPClient of the requests, by default a client of the Timeout.
+	HTTPClient *http.Client
+
+	// Timeout of the requests of the default client.
+	Timeout time.Duration
+}
+
+// SignatureDirectory resolves selectors and event topics to the candidate
+// signatures of a public signature directory, ie. of the unknown selectors of
+// calldata. The results are cached.
+type SignatureDirectory struct {
+	options SignatureDirectoryOptions
+	client  *http.Client
+
+	cache map[string][]string
+	mu    sync.Mutex
+}
+
+// NewSignatureDirectory returns a client of the directory of the options, by
+// default DefaultSignatureDirectoryOptions.
+func NewSignatureDirectory(opts ...SignatureDirectoryOptions) (*SignatureDirectory, error) {
+	options := DefaultSignatureDirectoryOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	if options.Source == "" {
+		options.Source = DefaultSignatureDirectoryOptions.Source
+	}
+	if options.URL == "" {
+		switch options.Source {
+		case SignatureDirectoryOpenchain:
+			options.URL = "https://api.openchain.xyz/signature-database/v1"
+		case SignatureDirectory4byte:
+			options.URL = "https://www.4byte.directory/api/v1"
+		}
+	}
+	if options.Source != SignatureDirectoryOpenchain && options.Source != SignatureDirectory4byte {
+		return nil, fmt.Errorf("ethcoder: unknown signature directory %q", options.Source)
+	}
+
+	client := options.HTTPClient
+	if client == nil {
+		timeout := options.Timeout
+		if timeout == 0 {
+			timeout = DefaultSignatureDirectoryOptions.Timeout
+		}
+		client = &http.Client{Timeout: timeout}
+	}
+	return &SignatureDirectory{options: options, client: client, cache: map[string][]string{}}, nil
+}
+
+// LookupFunction returns the candidate signatures of a function selector, ie.
+// "transfer(address,uint256)" of 0xa9059cbb, or none if it is unknown.
+func (d *SignatureDirectory) LookupFunction(ctx context.Context, selector [4]byte) ([]string, error) {
+	return d.lookup(ctx, "function", HexEncode(selector[:]))
+}
+
+// LookupCalldata returns the candidate signatures of the selector of calldata.
+func (d *SignatureDirectory) LookupCalldata(ctx context.Context, calldata []byte) ([]string, error) {
+	if len(calldata) < 4 {
+		return nil, fmt.Errorf("ethcoder: calldata of %d bytes has no selector", len(calldata))
+	}
+	var selector [4]byte
+	copy(selector[:], calldata)
+	return d.LookupFunction(ctx, selector)
+}
+
+// LookupEvent returns the candidate signatures of an event topic, ie.
+// "Transfer(address,address,uint256)" of 0xddf252ad...
+func (d *SignatureDirectory) LookupEvent(ctx context.Context, topic common.Hash) ([]string, error) {
+	return d.lookup(ctx, "event", topic.Hex())
+}
+
+func (d *SignatureDirectory) lookup(ctx context.Context, kind string, hash string) ([]string, error) {
+	key := kind + ":" + hash
+	d.mu.Lock()
+	sigs, ok := d.cache[key]
+	d.mu.Unlock()
+	if ok {
+		return sigs, nil
+	}
+
+	var err error
+	switch d.options.Source {
+	case SignatureDirectoryOpenchain:
+		sigs, err = d.lookupOpenchain(ctx, kind, hash)
+	default:
+		sigs, err = d.lookup4byte(ctx, kind, hash)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ethcoder: failed to lookup %s %s: %w", kind, hash, err)
+	}
+
+	d.mu.Lock()
+	d.cache[key] = sigs
+	d.mu.Unlock()
+	return sigs, nil
+}
+
+func (d *SignatureDirectory) lookupOpenchain(ctx context.Context, kind string, hash string) ([]string, error) {
+	var resp struct {
+		OK     bool   `json:"ok"`
+		Error  string `json:"error"`
+		Result map[string]map[string][]struct {
+			Name     string `json:"name"`
+			Filtered bool   `json:"filtered"`
+		} `json:"result"`
+	}
+	query := url.Values{kind: {hash}, "filter": {"true"}}
+	if err := d.getJSON(ctx, d.options.URL+"/lookup?"+query.Encode(), &resp); err != nil {
+		return nil, err
+	}
+	if !resp.OK {
+		return nil, fmt.Errorf("openchain: %s", resp.Error)
+	}
+
+	sigs := []string{}
+	for _, sig := range resp.Result[kind][hash] {
+		if !sig.Filtered {
+			sigs = append(sigs, sig.Name)
+		}
+	}
+	return sigs, nil
+}
+
+func (d *SignatureDirectory) lookup4byte(ctx context.Context, kind string, hash string) ([]string, error) {
+	var resp struct {
+		Results []struct {
+			ID            int64  `json:"id"`
+			TextSignature string `json:"text_signature"`
+		} `json:"results"`
+	}
+	endpoint := "/signatures/"
+	if kind == "event" {
+		endpoint = "/event-signatures/"
+	}
+	query := url.Values{"hex_signature": {hash}}
+	if err := d.getJSON(ctx, d.options.URL+endpoint+"?"+query.Encode(), &resp); err != nil {
+		return nil, err
+	}
+
+	// the first submitted signatures first, as the later ones are more likely
+	// to be collisions
+	sort.Slice(resp.Results, func(i, j int) bool {
+		return resp.Results[i].ID < resp.Results[j].ID
+	})
+	sigs := make([]string, len(resp.Results))
+	for i, result := range resp.Results {
+		sigs[i] = result.TextSignature
+	}
+	return sigs, nil
+}
+
+func (d *SignatureDirectory) getJSON(ctx context.Context, endpoint string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return json.Unmarshal(body, v)
+}
@@ -0,0 +1,65 @@
+package ethcoder_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFunctionSelector(t *testing.T) {
+	for _, sig := range []string{"transfer(address,uint256)", "function transfer(address to, uint256 amount) returns (bool)"} {
+		selector, err := ethcoder.FunctionSelector(sig)
+		require.NoError(t, err)
+		assert.Equal(t, "0xa9059cbb", ethcoder.HexEncode(selector[:]))
+	}
+	_, err := ethcoder.FunctionSelector("transfer(address")
+	assert.Error(t, err)
+}
+
+func TestSignatureDirectory(t *testing.T) {
+	ctx := context.Background()
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/lookup":
+			assert.Equal(t, "0xa9059cbb", r.URL.Query().Get("function"))
+			w.Write([]byte(`{"ok": true, "result": {"event": {}, "function": {"0xa9059cbb": [{"name": "transfer(address,uint256)", "filtered": false}, {"name": "spam(bytes)", "filtered": true}]}}}`))
+		case "/event-signatures/":
+			assert.Equal(t, "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef", r.URL.Query().Get("hex_signature"))
+			w.Write([]byte(`{"count": 2, "results": [{"id": 9, "text_signature": "Collision(uint256)"}, {"id": 1, "text_signature": "Transfer(address,address,uint256)"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	openchain, err := ethcoder.NewSignatureDirectory(ethcoder.SignatureDirectoryOptions{URL: server.URL})
+	require.NoError(t, err)
+	sigs, err := openchain.LookupCalldata(ctx, common.FromHex("0xa9059cbb0000"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"transfer(address,uint256)"}, sigs)
+
+	// the results are cached
+	sigs, err = openchain.LookupFunction(ctx, [4]byte{0xa9, 0x05, 0x9c, 0xbb})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"transfer(address,uint256)"}, sigs)
+	assert.Equal(t, 1, requests)
+
+	fourbyte, err := ethcoder.NewSignatureDirectory(ethcoder.SignatureDirectoryOptions{Source: ethcoder.SignatureDirectory4byte, URL: server.URL})
+	require.NoError(t, err)
+	sigs, err = fourbyte.LookupEvent(ctx, common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Transfer(address,address,uint256)", "Collision(uint256)"}, sigs)
+	_, err = fourbyte.LookupFunction(ctx, [4]byte{0xa9, 0x05, 0x9c, 0xbb})
+	assert.Error(t, err)
+
+	_, err = ethcoder.NewSignatureDirectory(ethcoder.SignatureDirectoryOptions{Source: "etherscan"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,56 @@
+package ethcoder
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+)
+
+// AbiSignature returns the canonical signature of an abi entry of the name and
+// arguments, ie. "swap((address,uint256[])[],bool)", where the tuples are the
+// lists of the types of their components.
+func AbiSignature(name string, args abi.Arguments) string {
+	types := make([]string, len(args))
+	for i, arg := range args {
+		types[i] = arg.Type.String()
+	}
+	return fmt.Sprintf("%s(%s)", name, strings.Join(types, ","))
+}
+
+// MethodSignature returns the canonical signature of the method, ie.
+// "transfer(address,uint256)". The signature of a parsed method is its Sig, ie.
+// of its fixed-point types, see ParseABIJSON.
+func MethodSignature(method abi.Method) string {
+	if method.Sig != "" {
+		return method.Sig
+	}
+	return AbiSignature(abiEntryName(method.RawName, method.Name), method.Inputs)
+}
+
+// EventSignature returns the canonical signature of the event, ie.
+// "Transfer(address,address,uint256)", as MethodSignature.
+func EventSignature(event abi.Event) string {
+	if event.Sig != "" {
+		return event.Sig
+	}
+	return AbiSignature(abiEntryName(event.RawName, event.Name), event.Inputs)
+}
+
+// ErrorSignature returns the canonical signature of the error, ie.
+// "InsufficientBalance(uint256,uint256)", as MethodSignature.
+func ErrorSignature(abiErr abi.Error) string {
+	if abiErr.Sig != "" {
+		return abiErr.Sig
+	}
+	return AbiSignature(abiErr.Name, abiErr.Inputs)
+}
+
+// abiEntryName returns the raw name of an entry, or else its name, ie. of the
+// entries which aren't parsed.
+func abiEntryName(rawName, name string) string {
+	if rawName != "" {
+		return rawName
+	}
+	return name
+}
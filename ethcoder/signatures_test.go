@@ -0,0 +1,79 @@
+package ethcoder_test
+
+import (
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAbiSignatures(t *testing.T) {
+	method, err := ethcoder.ParseABIFunction("function swap((address to, uint256[] amounts)[] orders, bool exact)")
+	require.NoError(t, err)
+	assert.Equal(t, "swap((address,uint256[])[],bool)", ethcoder.MethodSignature(*method))
+	assert.Equal(t, "swap((address,uint256[])[],bool)", ethcoder.AbiSignature("swap", method.Inputs))
+
+	// the entries which aren't parsed
+	assert.Equal(t, "swap((address,uint256[])[],bool)", ethcoder.MethodSignature(abi.Method{Name: "swap", Inputs: method.Inputs}))
+	assert.Equal(t, "Swapped((address,uint256[])[],bool)", ethcoder.EventSignature(abi.Event{RawName: "Swapped", Inputs: method.Inputs}))
+	assert.Equal(t, "SwapFailed((address,uint256[])[],bool)", ethcoder.ErrorSignature(abi.Error{Name: "SwapFailed", Inputs: method.Inputs}))
+
+	abiErr, err := ethcoder.ParseABIError("error InsufficientBalance(uint available, uint required)")
+	require.NoError(t, err)
+	assert.Equal(t, "InsufficientBalance(uint256,uint256)", ethcoder.ErrorSignature(*abiErr))
+}
+
+func TestEventFilterTopics(t *testing.T) {
+	event, err := ethcoder.ParseABIEvent("event Transfer(address indexed from, address indexed to, uint256 value)")
+	require.NoError(t, err)
+	assert.Equal(t, "Transfer(address,address,uint256)", ethcoder.EventSignature(*event))
+
+	topic0 := common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+	assert.Equal(t, topic0, ethcoder.EventTopic0(*event))
+
+	to := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	topics, err := ethcoder.EventFilterTopics(*event, nil, to)
+	require.NoError(t, err)
+	assert.Equal(t, [][]common.Hash{{topic0}, nil, {common.BytesToHash(to.Bytes())}}, topics)
+
+	// the same topics of the event string
+	expected, err := ethcoder.EventTopics("Transfer(address indexed from, address indexed to, uint256 value)", nil, to)
+	require.NoError(t, err)
+	assert.Equal(t, expected, topics)
+
+	topics, err = ethcoder.EventFilterTopics(*event)
+	require.NoError(t, err)
+	assert.Equal(t, [][]common.Hash{{topic0}}, topics)
+
+	_, err = ethcoder.EventFilterTopics(*event, nil, nil, "1")
+	assert.Error(t, err)
+
+	t.Run("anonymous", func(t *testing.T) {
+		event, err := ethcoder.ParseABIEvent("event Deposit(address indexed account, uint256 amount) anonymous")
+		require.NoError(t, err)
+		topics, err := ethcoder.EventFilterTopics(*event, to)
+		require.NoError(t, err)
+		assert.Equal(t, [][]common.Hash{{common.BytesToHash(to.Bytes())}}, topics)
+
+		topics, err = ethcoder.EventFilterTopics(*event, nil)
+		require.NoError(t, err)
+		assert.Empty(t, topics)
+	})
+
+	t.Run("tuples", func(t *testing.T) {
+		event, err := ethcoder.ParseABIEvent("event OrderFilled((address maker, uint256 amount) order, address indexed taker)")
+		require.NoError(t, err)
+
+		hash, err := ethcoder.EventTopicHash("OrderFilled((address maker, uint256 amount) order, address indexed taker)")
+		require.NoError(t, err)
+		assert.Equal(t, ethcoder.EventTopic0(*event), hash)
+		assert.Equal(t, ethcoder.Keccak256Hash([]byte("OrderFilled((address,uint256),address)")), hash)
+
+		topics, err := ethcoder.EventTopics("OrderFilled((address maker, uint256 amount) order, address indexed taker)", to)
+		require.NoError(t, err)
+		assert.Equal(t, [][]common.Hash{{hash}, {common.BytesToHash(to.Bytes())}}, topics)
+	})
+}
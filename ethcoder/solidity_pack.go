@@ -1,11 +1,14 @@
 package ethcoder
 
 import (
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"reflect"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/0xsequence/ethkit/go-ethereum/common"
 	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
@@ -38,6 +41,26 @@ func SolidityPackHex(argTypes []string, argValues []interface{}) (string, error)
 	return h, nil
 }
 
+// SolidityPackedKeccak256 returns the keccak256 of the packed values, ie.
+// keccak256(abi.encodePacked(...)), as ethers' solidityPackedKeccak256.
+func SolidityPackedKeccak256(argTypes []string, argValues []interface{}) (common.Hash, error) {
+	b, err := SolidityPack(argTypes, argValues)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return Keccak256Hash(b), nil
+}
+
+// SolidityPackedSha256 returns the sha256 of the packed values, ie.
+// sha256(abi.encodePacked(...)), as ethers' solidityPackedSha256.
+func SolidityPackedSha256(argTypes []string, argValues []interface{}) (common.Hash, error) {
+	b, err := SolidityPack(argTypes, argValues)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return sha256.Sum256(b), nil
+}
+
 func solidityArgumentPackHex(typ string, val interface{}, isArray bool) (string, error) {
 	b, err := solidityArgumentPack(typ, val, isArray)
 	if err != nil {
@@ -96,8 +119,24 @@ func solidityArgumentPack(typ string, val interface{}, isArray bool) ([]byte, er
 		return b, nil
 	}
 
+	// fixed-point numbers, packed as their integers
+	if regexArgFixed.MatchString(typ) {
+		ft, _, err := parseFixedType(typ)
+		if err != nil {
+			return nil, err
+		}
+		n, err := ft.scaledInt(val)
+		if err != nil {
+			return nil, err
+		}
+		return solidityArgumentPack(ft.intType(), n, isArray)
+	}
+
 	// numbers
 	if match := regexArgNumber.FindStringSubmatch(typ); len(match) > 0 {
+		if match[2] == "" {
+			match[2] = "256"
+		}
 		size, err := strconv.ParseInt(match[2], 10, 64)
 		if err != nil {
 			return nil, err
@@ -105,14 +144,16 @@ func solidityArgumentPack(typ string, val interface{}, isArray bool) ([]byte, er
 		if (size%8 != 0) || size == 0 || size > 256 {
 			return nil, fmt.Errorf("invalid number type '%s'", typ)
 		}
-		if isArray {
-			size = 256
-		}
 
 		num := big.NewInt(0)
 		switch v := val.(type) {
 		case *big.Int:
+			if v == nil {
+				return nil, fmt.Errorf("nil *big.Int value for type '%s'", typ)
+			}
 			num = v
+		case uint:
+			num.SetUint64(uint64(v))
 		case uint8:
 			num.SetUint64(uint64(v))
 		case uint16:
@@ -121,6 +162,8 @@ func solidityArgumentPack(typ string, val interface{}, isArray bool) ([]byte, er
 			num.SetUint64(uint64(v))
 		case uint64:
 			num.SetUint64(v)
+		case int:
+			num.SetInt64(int64(v))
 		case int8:
 			num.SetInt64(int64(v))
 		case int16:
@@ -129,12 +172,33 @@ func solidityArgumentPack(typ string, val interface{}, isArray bool) ([]byte, er
 			num.SetInt64(int64(v))
 		case int64:
 			num.SetInt64(v)
+		case string, json.Number:
+			// decimal or 0x prefixed hex numbers, ie. "-1" or "0xff", as ethers'
+			// BigNumberish strings
+			s := fmt.Sprint(v)
+			digits, base := strings.TrimPrefix(s, "-"), 10
+			if strings.HasPrefix(digits, "0x") {
+				digits, base = digits[2:], 16
+			}
+			if _, ok := num.SetString(digits, base); !ok || strings.HasPrefix(digits, "+") || strings.HasPrefix(digits, "-") {
+				return nil, fmt.Errorf("invalid number '%s' for type '%s'", s, typ)
+			}
+			if strings.HasPrefix(s, "-") {
+				num.Neg(num)
+			}
 		default:
-			return nil, fmt.Errorf("expecting *big.Int or (u)intX value for type '%s'", typ)
+			return nil, fmt.Errorf("expecting *big.Int, (u)intX or string value for type '%s'", typ)
+		}
+		if err := checkNumberRange(typ, match[1] == "int", int(size), num); err != nil {
+			return nil, err
+		}
+		if isArray {
+			size = 256
 		}
 
-		b := math.PaddedBigBytes(num, int(size/8))
-		return b, nil
+		// two's complement, sign extended to the size
+		b := math.U256Bytes(new(big.Int).Set(num))
+		return b[32-size/8:], nil
 	}
 
 	// bytes
@@ -160,7 +224,7 @@ func solidityArgumentPack(typ string, val interface{}, isArray bool) ([]byte, er
 			return nil, fmt.Errorf("not a byte array")
 		}
 		if rv.Len() != int(size) {
-			return nil, fmt.Errorf("not a [%d]byte", size)
+			return nil, fmt.Errorf("value of %d bytes for type '%s', expecting a [%d]byte", rv.Len(), typ, size)
 		}
 
 		v := make([]byte, size, size)
@@ -174,6 +238,35 @@ func solidityArgumentPack(typ string, val interface{}, isArray bool) ([]byte, er
 		return v, nil
 	}
 
+	// tuples, packed as their values, ie. of the type (address,uint256)
+	if strings.HasPrefix(typ, "tuple(") {
+		typ = typ[len("tuple"):]
+	}
+	if strings.HasPrefix(typ, "(") && matchingParen(typ) == len(typ)-1 {
+		components := splitAbiTypes(typ[1 : len(typ)-1])
+		types := make([]string, len(components))
+		names := make([]string, len(components))
+		for i, component := range components {
+			types[i], names[i] = splitAbiTypeName(component)
+			if types[i] == "" {
+				return nil, fmt.Errorf("invalid tuple type '%s'", typ)
+			}
+		}
+		fields, err := abiTupleFields(names, reflect.ValueOf(val))
+		if err != nil {
+			return nil, err
+		}
+		buf := []byte{}
+		for i, field := range fields {
+			b, err := solidityArgumentPack(types[i], field.Interface(), isArray)
+			if err != nil {
+				return nil, err
+			}
+			buf = append(buf, b...)
+		}
+		return buf, nil
+	}
+
 	// arrays
 	if match := regexArgArray.FindStringSubmatch(typ); len(match) > 0 {
 		baseTyp := match[1]
@@ -209,6 +302,27 @@ func solidityArgumentPack(typ string, val interface{}, isArray bool) ([]byte, er
 	return nil, fmt.Errorf("unknown type '%s'", typ)
 }
 
+// checkNumberRange returns an error when num is out of the range of the number
+// type typ, a signed or unsigned integer of the bits.
+func checkNumberRange(typ string, signed bool, bits int, num *big.Int) error {
+	if !signed {
+		if num.Sign() < 0 {
+			return fmt.Errorf("negative value %s for type '%s'", num, typ)
+		}
+		if num.BitLen() > bits {
+			return fmt.Errorf("value %s overflows type '%s'", num, typ)
+		}
+		return nil
+	}
+
+	max := new(big.Int).Lsh(big.NewInt(1), uint(bits-1))
+	min := new(big.Int).Neg(max)
+	if num.Cmp(min) < 0 || num.Cmp(max) >= 0 {
+		return fmt.Errorf("value %s overflows type '%s'", num, typ)
+	}
+	return nil
+}
+
 func PadZeros(array []byte, totalLength int) ([]byte, error) {
 	if len(array) > totalLength {
 		return nil, fmt.Errorf("array is larger than total expected length")
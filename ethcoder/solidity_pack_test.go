@@ -1,11 +1,14 @@
 package ethcoder
 
 import (
+	"fmt"
 	"math/big"
+	"strings"
 	"testing"
 
 	"github.com/0xsequence/ethkit/go-ethereum/common"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSolidityPack(t *testing.T) {
@@ -121,3 +124,146 @@ func TestSolidityPack(t *testing.T) {
 		assert.Equal(t, "0x00000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000001", h)
 	}
 }
+
+func TestSolidityPackRanges(t *testing.T) {
+	// int8 / negative
+	{
+		// ethers.utils.solidityPack(['int8'], [-1])
+		// 0xff
+		h, err := solidityArgumentPackHex("int8", big.NewInt(-1), false)
+		assert.NoError(t, err)
+		assert.Equal(t, "0xff", h)
+
+		h, err = solidityArgumentPackHex("int16[]", []int16{-2}, false)
+		assert.NoError(t, err)
+		assert.Equal(t, "0xfffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe", h)
+	}
+
+	// int / uint
+	{
+		h, err := solidityArgumentPackHex("uint", 1, false)
+		assert.NoError(t, err)
+		assert.Equal(t, "0x0000000000000000000000000000000000000000000000000000000000000001", h)
+	}
+
+	_, err := solidityArgumentPackHex("uint8", big.NewInt(256), false)
+	assert.ErrorContains(t, err, "value 256 overflows type 'uint8'")
+	_, err = solidityArgumentPackHex("uint8", int64(256), false)
+	assert.ErrorContains(t, err, "overflows")
+	_, err = solidityArgumentPackHex("uint256", big.NewInt(-1), false)
+	assert.ErrorContains(t, err, "negative value -1 for type 'uint256'")
+	_, err = solidityArgumentPackHex("int8", big.NewInt(128), false)
+	assert.ErrorContains(t, err, "overflows")
+	_, err = solidityArgumentPackHex("int8", big.NewInt(-129), false)
+	assert.ErrorContains(t, err, "overflows")
+	_, err = solidityArgumentPackHex("uint8[]", []*big.Int{big.NewInt(1), big.NewInt(300)}, false)
+	assert.ErrorContains(t, err, "overflows")
+	_, err = solidityArgumentPackHex("bytes4", []byte{1, 2, 3, 4, 5}, false)
+	assert.ErrorContains(t, err, "value of 5 bytes for type 'bytes4'")
+
+	h, err := solidityArgumentPackHex("int8", big.NewInt(-128), false)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x80", h)
+}
+
+func TestSolidityPackSignedIntegers(t *testing.T) {
+	// ethers.solidityPacked([type], [-1]) is the two's complement of the width
+	for bits := 8; bits <= 256; bits += 8 {
+		typ := fmt.Sprintf("int%d", bits)
+		h, err := solidityArgumentPackHex(typ, big.NewInt(-1), false)
+		require.NoError(t, err, typ)
+		assert.Equal(t, "0x"+strings.Repeat("ff", bits/8), h, typ)
+
+		// the min and max of the width
+		max := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(bits-1)), big.NewInt(1))
+		h, err = solidityArgumentPackHex(typ, new(big.Int).Neg(new(big.Int).Add(max, big.NewInt(1))), false)
+		require.NoError(t, err, typ)
+		assert.Equal(t, "0x80"+strings.Repeat("00", bits/8-1), h, typ)
+		h, err = solidityArgumentPackHex(typ, max, false)
+		require.NoError(t, err, typ)
+		assert.Equal(t, "0x7f"+strings.Repeat("ff", bits/8-1), h, typ)
+	}
+
+	// ethers.solidityPacked(['int24', 'int8', 'int40'], [-2, 5, '-0x10'])
+	h, err := SolidityPackHex([]string{"int24", "int8", "int40"}, []interface{}{int32(-2), "5", "-0x10"})
+	require.NoError(t, err)
+	assert.Equal(t, "0xfffffe05fffffffff0", h)
+
+	// ethers.solidityPacked(['int8[]', 'int256[2]'], [[-1, 1], ['-2', 3]]) pads the
+	// array elements to 32 bytes, sign extended
+	h, err = SolidityPackHex([]string{"int8[]", "int256[2]"}, []interface{}{
+		[]int8{-1, 1},
+		[]interface{}{"-2", big.NewInt(3)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "0x"+
+		"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"+
+		"0000000000000000000000000000000000000000000000000000000000000001"+
+		"fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe"+
+		"0000000000000000000000000000000000000000000000000000000000000003", h)
+
+	// ethers.solidityPacked(['int16[2][]'], [[[-1, 2], [-3, 4]]])
+	h, err = SolidityPackHex([]string{"int16[2][]"}, []interface{}{[][2]int16{{-1, 2}, {-3, 4}}})
+	require.NoError(t, err)
+	assert.Equal(t, "0x"+
+		"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"+
+		"0000000000000000000000000000000000000000000000000000000000000002"+
+		"fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffd"+
+		"0000000000000000000000000000000000000000000000000000000000000004", h)
+
+	_, err = SolidityPack([]string{"int8[]"}, []interface{}{[]int{-1, -129}})
+	assert.ErrorContains(t, err, "overflows")
+	_, err = SolidityPack([]string{"int8"}, []interface{}{"012x"})
+	assert.ErrorContains(t, err, "invalid number")
+	_, err = SolidityPack([]string{"int8"}, []interface{}{"--1"})
+	assert.ErrorContains(t, err, "invalid number")
+	_, err = SolidityPack([]string{"uint8"}, []interface{}{"-1"})
+	assert.ErrorContains(t, err, "negative value")
+}
+
+func TestSolidityPackTuples(t *testing.T) {
+	to := common.HexToAddress("0x39d28D4c4191a584acabe021F5B905887a6B5247")
+
+	// the values of a tuple are packed tightly, and padded in arrays
+	h, err := SolidityPackHex([]string{"(address,uint16)", "tuple(bool ok,bytes2 id)"}, []interface{}{
+		[]interface{}{to, uint16(0x0102)},
+		map[string]interface{}{"ok": true, "id": [2]byte{0xab, 0xcd}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "0x39d28d4c4191a584acabe021f5b905887a6b52470102"+"01abcd", h)
+
+	type transfer struct {
+		To     common.Address
+		Amount *big.Int
+	}
+	h, err = SolidityPackHex([]string{"(address,uint16)[]"}, []interface{}{
+		[]transfer{{To: to, Amount: big.NewInt(1)}, {To: to, Amount: big.NewInt(2)}},
+	})
+	assert.NoError(t, err)
+	paddedTo := "00000000000000000000000039d28d4c4191a584acabe021f5b905887a6b5247"
+	assert.Equal(t, "0x"+
+		paddedTo+"0000000000000000000000000000000000000000000000000000000000000001"+
+		paddedTo+"0000000000000000000000000000000000000000000000000000000000000002", h)
+
+	_, err = SolidityPack([]string{"(address,uint16)"}, []interface{}{[]interface{}{to}})
+	assert.Error(t, err)
+	_, err = SolidityPack([]string{"(address,uint16)[2]"}, []interface{}{[]transfer{{To: to, Amount: big.NewInt(1)}}})
+	assert.Error(t, err)
+}
+
+func TestSolidityPackedHashes(t *testing.T) {
+	// ethers.solidityPackedKeccak256(['string', 'uint8'], ['hello', 1])
+	hash, err := SolidityPackedKeccak256([]string{"string", "uint8"}, []interface{}{"hello", uint8(1)})
+	assert.NoError(t, err)
+	assert.Equal(t, Keccak256Hash([]byte("hello\x01")), hash)
+
+	// ethers.solidityPackedSha256(['string'], ['hello'])
+	hash, err = SolidityPackedSha256([]string{"string"}, []interface{}{"hello"})
+	assert.NoError(t, err)
+	assert.Equal(t, "0x2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", hash.Hex())
+
+	_, err = SolidityPackedKeccak256([]string{"uint8"}, []interface{}{"hello"})
+	assert.Error(t, err)
+	_, err = SolidityPackedSha256([]string{"uint8"}, []interface{}{uint8(1), uint8(2)})
+	assert.Error(t, err)
+}
@@ -1,12 +1,17 @@
 package ethcoder
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
+	"reflect"
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/0xsequence/ethkit/go-ethereum/common"
-	"github.com/0xsequence/ethkit/go-ethereum/crypto"
 )
 
 // EIP-712 -- https://eips.ethereum.org/EIPS/eip-712
@@ -20,46 +25,63 @@ type TypedData struct {
 
 type TypedDataTypes map[string][]TypedDataArgument
 
+// EncodeType returns the encoding of the type, ie. of the type and its
+// dependencies, the struct types it references directly or not, sorted by name.
 func (t TypedDataTypes) EncodeType(primaryType string) (string, error) {
-	args, ok := t[primaryType]
-	if !ok {
+	if _, ok := t[primaryType]; !ok {
 		return "", fmt.Errorf("%s type is not defined", primaryType)
 	}
 
-	subTypes := []string{}
-	s := primaryType + "("
+	deps := map[string]bool{}
+	t.dependencies(primaryType, deps)
+	delete(deps, primaryType)
+	subTypes := make([]string, 0, len(deps))
+	for dep := range deps {
+		subTypes = append(subTypes, dep)
+	}
+	sort.Strings(subTypes)
 
-	for i, arg := range args {
-		_, ok := t[arg.Type]
-		if ok {
-			set := false
-			for _, v := range subTypes {
-				if v == arg.Type {
-					set = true
-				}
-			}
-			if !set {
-				subTypes = append(subTypes, arg.Type)
-			}
-		}
+	s := t.encodeStructType(primaryType)
+	for _, subType := range subTypes {
+		s += t.encodeStructType(subType)
+	}
+	return s, nil
+}
 
-		s += arg.Type + " " + arg.Name
-		if i < len(args)-1 {
-			s += ","
+// dependencies adds the struct type and the struct types it references to deps.
+func (t TypedDataTypes) dependencies(structType string, deps map[string]bool) {
+	if deps[structType] {
+		return
+	}
+	deps[structType] = true
+	for _, arg := range t[structType] {
+		if baseType := typedDataBaseType(arg.Type); t.isStruct(baseType) {
+			t.dependencies(baseType, deps)
 		}
 	}
-	s += ")"
+}
 
-	sort.Strings(subTypes)
-	for _, subType := range subTypes {
-		subEncodeType, err := t.EncodeType(subType)
-		if err != nil {
-			return "", err
-		}
-		s += subEncodeType
+func (t TypedDataTypes) encodeStructType(structType string) string {
+	args := t[structType]
+	fields := make([]string, len(args))
+	for i, arg := range args {
+		fields[i] = arg.Type + " " + arg.Name
 	}
+	return structType + "(" + strings.Join(fields, ",") + ")"
+}
 
-	return s, nil
+func (t TypedDataTypes) isStruct(typ string) bool {
+	_, ok := t[typ]
+	return ok
+}
+
+// typedDataBaseType returns the type of the elements of an array type, of its
+// nested arrays, or the type itself.
+func typedDataBaseType(typ string) string {
+	if idx := strings.Index(typ, "["); idx >= 0 {
+		return typ[:idx]
+	}
+	return typ
 }
 
 func (t TypedDataTypes) TypeHash(primaryType string) ([]byte, error) {
@@ -83,6 +105,48 @@ type TypedDataDomain struct {
 	Salt              *[32]byte       `json:"salt,omitempty"`
 }
 
+// typedDataDomainFields are the fields of the EIP712Domain type, in order.
+var typedDataDomainFields = []TypedDataArgument{
+	{Name: "name", Type: "string"},
+	{Name: "version", Type: "string"},
+	{Name: "chainId", Type: "uint256"},
+	{Name: "verifyingContract", Type: "address"},
+	{Name: "salt", Type: "bytes32"},
+}
+
+// UnmarshalJSON reads the domain of an eth_signTypedData_v4 payload, where the
+// chainId is a number, or a decimal or hex string.
+func (t *TypedDataDomain) UnmarshalJSON(data []byte) error {
+	var domain struct {
+		Name              string          `json:"name"`
+		Version           string          `json:"version"`
+		ChainID           json.RawMessage `json:"chainId"`
+		VerifyingContract *common.Address `json:"verifyingContract"`
+		Salt              *string         `json:"salt"`
+	}
+	if err := json.Unmarshal(data, &domain); err != nil {
+		return err
+	}
+	*t = TypedDataDomain{Name: domain.Name, Version: domain.Version, VerifyingContract: domain.VerifyingContract}
+
+	if len(domain.ChainID) > 0 && string(domain.ChainID) != "null" {
+		s := string(bytes.Trim(domain.ChainID, `"`))
+		chainID, ok := new(big.Int).SetString(s, 0)
+		if !ok {
+			return fmt.Errorf("ethcoder: invalid typed data domain chainId %s", domain.ChainID)
+		}
+		t.ChainID = chainID
+	}
+	if domain.Salt != nil {
+		salt, err := HexDecodeBytes32(*domain.Salt)
+		if err != nil {
+			return fmt.Errorf("ethcoder: invalid typed data domain salt: %w", err)
+		}
+		t.Salt = &salt
+	}
+	return nil
+}
+
 func (t TypedDataDomain) Map() map[string]interface{} {
 	m := map[string]interface{}{}
 	if t.Name != "" {
@@ -103,6 +167,136 @@ func (t TypedDataDomain) Map() map[string]interface{} {
 	return m
 }
 
+// Type returns the EIP712Domain type of the fields of the domain, see Map.
+func (t TypedDataDomain) Type() []TypedDataArgument {
+	m := t.Map()
+	domainType := []TypedDataArgument{}
+	for _, field := range typedDataDomainFields {
+		if _, ok := m[field.Name]; ok {
+			domainType = append(domainType, field)
+		}
+	}
+	return domainType
+}
+
+// Separator returns the domain separator of the domain, ie. the hash of the
+// EIP712Domain struct of its fields, as DOMAIN_SEPARATOR() on-chain.
+func (t TypedDataDomain) Separator() (common.Hash, error) {
+	typedData := &TypedData{Types: TypedDataTypes{"EIP712Domain": t.Type()}}
+	hash, err := typedData.HashStruct("EIP712Domain", t.Map())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(hash), nil
+}
+
+// TypedDataDomainBuilder builds a TypedDataDomain of the fields which are set,
+// ie.
+//
+//	NewTypedDataDomain().Name("Ether Mail").Version("1").ChainID(big.NewInt(1)).Separator()
+type TypedDataDomainBuilder struct {
+	domain TypedDataDomain
+	errs   []error
+}
+
+// NewTypedDataDomain returns a builder of a domain without fields.
+func NewTypedDataDomain() *TypedDataDomainBuilder {
+	return &TypedDataDomainBuilder{}
+}
+
+func (b *TypedDataDomainBuilder) Name(name string) *TypedDataDomainBuilder {
+	if name == "" {
+		b.errs = append(b.errs, fmt.Errorf("ethcoder: typed data domain name is empty"))
+	}
+	b.domain.Name = name
+	return b
+}
+
+func (b *TypedDataDomainBuilder) Version(version string) *TypedDataDomainBuilder {
+	if version == "" {
+		b.errs = append(b.errs, fmt.Errorf("ethcoder: typed data domain version is empty"))
+	}
+	b.domain.Version = version
+	return b
+}
+
+func (b *TypedDataDomainBuilder) ChainID(chainID *big.Int) *TypedDataDomainBuilder {
+	if chainID == nil || chainID.Sign() < 0 {
+		b.errs = append(b.errs, fmt.Errorf("ethcoder: invalid typed data domain chainId %v", chainID))
+		return b
+	}
+	b.domain.ChainID = new(big.Int).Set(chainID)
+	return b
+}
+
+func (b *TypedDataDomainBuilder) VerifyingContract(address common.Address) *TypedDataDomainBuilder {
+	if address == (common.Address{}) {
+		b.errs = append(b.errs, fmt.Errorf("ethcoder: typed data domain verifyingContract is the zero address"))
+	}
+	b.domain.VerifyingContract = &address
+	return b
+}
+
+func (b *TypedDataDomainBuilder) Salt(salt [32]byte) *TypedDataDomainBuilder {
+	b.domain.Salt = &salt
+	return b
+}
+
+// Build returns the domain, or the errors of the invalid fields, or of a domain
+// without fields.
+func (b *TypedDataDomainBuilder) Build() (TypedDataDomain, error) {
+	if len(b.errs) > 0 {
+		return TypedDataDomain{}, errors.Join(b.errs...)
+	}
+	if len(b.domain.Map()) == 0 {
+		return TypedDataDomain{}, fmt.Errorf("ethcoder: typed data domain has no fields")
+	}
+	return b.domain, nil
+}
+
+// Separator returns the domain separator of the domain, see Build.
+func (b *TypedDataDomainBuilder) Separator() (common.Hash, error) {
+	domain, err := b.Build()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return domain.Separator()
+}
+
+// TypedDataFromJSON reads the typed data of an eth_signTypedData_v4 payload, ie.
+// its types, primaryType, domain and message. The EIP712Domain type is the one
+// of the fields of the domain when the payload doesn't have it, as ethers does.
+func TypedDataFromJSON(data []byte) (*TypedData, error) {
+	var typedData TypedData
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&typedData); err != nil {
+		return nil, fmt.Errorf("ethcoder: failed to decode typed data: %w", err)
+	}
+
+	if typedData.Types == nil {
+		return nil, fmt.Errorf("ethcoder: typed data has no types")
+	}
+	if _, ok := typedData.Types[typedData.PrimaryType]; !ok {
+		return nil, fmt.Errorf("ethcoder: typed data primaryType %q is not defined", typedData.PrimaryType)
+	}
+	if typedData.Message == nil {
+		return nil, fmt.Errorf("ethcoder: typed data has no message")
+	}
+	if _, ok := typedData.Types["EIP712Domain"]; !ok {
+		typedData.Types["EIP712Domain"] = typedData.Domain.Type()
+	}
+	for typeName, args := range typedData.Types {
+		for _, arg := range args {
+			baseType := typedDataBaseType(arg.Type)
+			if _, err := buildArgumentsFromTypes([]string{baseType}); err != nil && !typedData.Types.isStruct(baseType) {
+				return nil, fmt.Errorf("ethcoder: typed data type %s has field %s of unknown type %s", typeName, arg.Name, arg.Type)
+			}
+		}
+	}
+	return &typedData, nil
+}
+
 func (t *TypedData) HashStruct(primaryType string, data map[string]interface{}) ([]byte, error) {
 	typeHash, err := t.Types.TypeHash(primaryType)
 	if err != nil {
@@ -128,77 +322,110 @@ func (t *TypedData) encodeData(primaryType string, data map[string]interface{})
 		return nil, fmt.Errorf("encoding failed for type %s, expecting %d arguments but received %d data values", primaryType, len(args), len(data))
 	}
 
-	abiTypes := []string{}
-	abiValues := []interface{}{}
-
+	// NOTE: each part must be bytes32
+	encodedData := make([]byte, 0, 32*len(args))
 	for _, arg := range args {
 		dataValue, ok := data[arg.Name]
 		if !ok {
 			return nil, fmt.Errorf("data value missing for type %s with argument name %s", primaryType, arg.Name)
 		}
+		encoded, err := t.encodeValue(arg.Type, dataValue)
+		if err != nil {
+			return nil, fmt.Errorf("encoding failed for type %s with argument name %s, because %w", primaryType, arg.Name, err)
+		}
+		encodedData = append(encodedData, encoded...)
+	}
+	return encodedData, nil
+}
 
-		switch arg.Type {
-		case "bytes", "string":
-			var bytesValue []byte
-			if v, ok := dataValue.([]byte); ok {
-				bytesValue = v
-			} else if v, ok := dataValue.(string); ok {
-				bytesValue = []byte(v)
-			} else {
-				return nil, fmt.Errorf("data value invalid for type %s with argument name %s", primaryType, arg.Name)
+// encodeValue returns the 32 bytes encoding of the value of the type, ie. the hash
+// of the encodings of the elements of an array, the hashStruct of a struct, the
+// hash of dynamic bytes and strings, or the abi encoding of an atomic value.
+func (t *TypedData) encodeValue(typ string, value interface{}) ([]byte, error) {
+	if match := regexArgArray.FindStringSubmatch(typ); len(match) > 0 {
+		v := reflect.ValueOf(value)
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return nil, fmt.Errorf("expecting an array for type %s", typ)
+		}
+		if match[2] != "" {
+			size, err := strconv.Atoi(match[2])
+			if err != nil {
+				return nil, err
+			}
+			if v.Len() != size {
+				return nil, fmt.Errorf("expecting %d elements for type %s, got %d", size, typ, v.Len())
+			}
+		}
+		encoded := make([]byte, 0, 32*v.Len())
+		for i := 0; i < v.Len(); i++ {
+			e, err := t.encodeValue(match[1], v.Index(i).Interface())
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", i, err)
 			}
-			abiTypes = append(abiTypes, "bytes32")
-			abiValues = append(abiValues, BytesToBytes32(Keccak256(bytesValue)))
+			encoded = append(encoded, e...)
+		}
+		return Keccak256(encoded), nil
+	}
+
+	if t.Types.isStruct(typ) {
+		data, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expecting a map[string]interface{} for struct type %s", typ)
+		}
+		return t.HashStruct(typ, data)
+	}
 
-		default:
-			dataValueString, isString := dataValue.(string)
-			if isString {
-				v, err := AbiUnmarshalStringValues([]string{arg.Type}, []string{dataValueString})
+	switch typ {
+	case "bytes", "string":
+		var bytesValue []byte
+		if v, ok := value.([]byte); ok {
+			bytesValue = v
+		} else if v, ok := value.(string); ok {
+			bytesValue = []byte(v)
+			if typ == "bytes" && strings.HasPrefix(v, "0x") {
+				b, err := HexDecode(v)
 				if err != nil {
-					return nil, fmt.Errorf("failed to unmarshal string value for type %s with argument name %s, because %w", primaryType, arg.Name, err)
+					return nil, err
 				}
-				abiValues = append(abiValues, v[0])
-			} else {
-				abiValues = append(abiValues, dataValue)
+				bytesValue = b
 			}
-			abiTypes = append(abiTypes, arg.Type)
+		} else {
+			return nil, fmt.Errorf("data value invalid for type %s", typ)
 		}
+		return Keccak256(bytesValue), nil
 	}
 
-	if len(args) != len(abiTypes) || len(args) != len(abiValues) {
-		return nil, fmt.Errorf("argument encoding failed to encode all values")
+	if n, ok := value.(json.Number); ok {
+		value = n.String()
 	}
-
-	// NOTE: each part must be bytes32
-	var err error
-	encodedTypes := make([]string, len(args))
-	encodedValues := make([]interface{}, len(args))
-	for i := 0; i < len(args); i++ {
-		pack, err := SolidityPack([]string{abiTypes[i]}, []interface{}{abiValues[i]})
-		if err != nil {
-			return nil, err
+	if s, ok := value.(string); ok && regexArgNumber.MatchString(typ) && strings.HasPrefix(s, "0x") {
+		n, ok := new(big.Int).SetString(s[2:], 16)
+		if !ok {
+			return nil, fmt.Errorf("invalid hex number %s for type %s", s, typ)
 		}
-		encodedValues[i], err = PadZeros(pack, 32)
+		value = n
+	}
+	if s, ok := value.(string); ok {
+		v, err := AbiUnmarshalStringValues([]string{typ}, []string{s})
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("failed to unmarshal string value for type %s, because %w", typ, err)
 		}
-		encodedTypes[i] = "bytes"
+		value = v[0]
 	}
 
-	encodedData, err := SolidityPack(encodedTypes, encodedValues)
+	// the numbers are sign extended
+	pack, err := solidityArgumentPack(typ, value, regexArgNumber.MatchString(typ))
 	if err != nil {
 		return nil, err
 	}
-	return encodedData, nil
+	if len(pack) < 32 && regexArgBytes.MatchString(typ) {
+		// the fixed bytes are left aligned
+		return append(pack, make([]byte, 32-len(pack))...), nil
+	}
+	return PadZeros(pack, 32)
 }
 
 func (t *TypedData) EncodeDigest() ([]byte, error) {
-	EIP191_HEADER := "0x1901"
-	eip191Header, err := HexDecode(EIP191_HEADER)
-	if err != nil {
-		return nil, err
-	}
-
 	// Prepare hash struct for the domain
 	domainHash, err := t.HashStruct("EIP712Domain", t.Domain.Map())
 	if err != nil {
@@ -211,11 +438,6 @@ func (t *TypedData) EncodeDigest() ([]byte, error) {
 		return nil, err
 	}
 
-	hashPack, err := SolidityPack([]string{"bytes", "bytes32", "bytes32"}, []interface{}{eip191Header, domainHash, messageHash})
-	if err != nil {
-		return []byte{}, err
-	}
-	hashBytes := crypto.Keccak256(hashPack)
-
-	return hashBytes, nil
+	digest := EIP191StructuredDataDigest(common.BytesToHash(domainHash), common.BytesToHash(messageHash))
+	return digest.Bytes(), nil
 }
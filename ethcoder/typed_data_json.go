@@ -0,0 +1,187 @@
+package ethcoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// MarshalCompactJSON returns the canonical eth_signTypedData_v4 payload of the
+// typed data, of compact json with sorted keys, as wallets and WalletConnect
+// expect in deep links. The values of the message are of their types, ie. the
+// integers are decimal strings, the addresses are checksummed and the bytes
+// are hex.
+func (t *TypedData) MarshalCompactJSON() ([]byte, error) {
+	types := t.Types
+	if _, ok := types["EIP712Domain"]; !ok {
+		types = TypedDataTypes{"EIP712Domain": t.Domain.Type()}
+		for name, args := range t.Types {
+			types[name] = args
+		}
+	}
+
+	message, err := t.jsonStruct(t.PrimaryType, t.Message)
+	if err != nil {
+		return nil, fmt.Errorf("ethcoder: failed to encode typed data message: %w", err)
+	}
+
+	domain := map[string]interface{}{}
+	for k, v := range t.Domain.Map() {
+		switch k {
+		case "chainId":
+			domain[k] = json.Number(v.(*big.Int).String())
+		case "verifyingContract":
+			domain[k] = v.(common.Address).Hex()
+		case "salt":
+			salt := v.([32]byte)
+			domain[k] = HexEncode(salt[:])
+		default:
+			domain[k] = v
+		}
+	}
+
+	return marshalCompactJSON(struct {
+		Types       TypedDataTypes         `json:"types"`
+		PrimaryType string                 `json:"primaryType"`
+		Domain      map[string]interface{} `json:"domain"`
+		Message     map[string]interface{} `json:"message"`
+	}{types, t.PrimaryType, domain, message})
+}
+
+// EncodeBase64URL returns the base64url encoding of the compact json of the
+// typed data, see MarshalCompactJSON.
+func (t *TypedData) EncodeBase64URL() (string, error) {
+	data, err := t.MarshalCompactJSON()
+	if err != nil {
+		return "", err
+	}
+	return Base64URLEncode(data), nil
+}
+
+// TypedDataFromBase64URL reads the typed data of the base64url encoding of its
+// json, see TypedDataFromJSON.
+func TypedDataFromBase64URL(s string) (*TypedData, error) {
+	data, err := Base64URLDecode(s)
+	if err != nil {
+		return nil, fmt.Errorf("ethcoder: invalid base64url typed data: %w", err)
+	}
+	return TypedDataFromJSON(data)
+}
+
+func (t *TypedData) jsonStruct(structType string, data map[string]interface{}) (map[string]interface{}, error) {
+	args, ok := t.Types[structType]
+	if !ok {
+		return nil, fmt.Errorf("%s type is unknown", structType)
+	}
+	m := make(map[string]interface{}, len(args))
+	for _, arg := range args {
+		value, ok := data[arg.Name]
+		if !ok {
+			return nil, fmt.Errorf("data value missing for type %s with argument name %s", structType, arg.Name)
+		}
+		v, err := t.jsonValue(arg.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", structType, arg.Name, err)
+		}
+		m[arg.Name] = v
+	}
+	return m, nil
+}
+
+// jsonValue returns the canonical json value of the value of the type, as of
+// encodeValue.
+func (t *TypedData) jsonValue(typ string, value interface{}) (interface{}, error) {
+	if match := regexArgArray.FindStringSubmatch(typ); len(match) > 0 {
+		v := reflect.ValueOf(value)
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return nil, fmt.Errorf("expecting an array for type %s", typ)
+		}
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			e, err := t.jsonValue(match[1], v.Index(i).Interface())
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", i, err)
+			}
+			values[i] = e
+		}
+		return values, nil
+	}
+
+	if t.Types.isStruct(typ) {
+		data, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expecting a map[string]interface{} for struct type %s", typ)
+		}
+		return t.jsonStruct(typ, data)
+	}
+
+	switch typ {
+	case "string":
+		if v, ok := value.([]byte); ok {
+			return string(v), nil
+		}
+		if v, ok := value.(string); ok {
+			return v, nil
+		}
+		return nil, fmt.Errorf("data value invalid for type %s", typ)
+	case "bytes":
+		if v, ok := value.([]byte); ok {
+			return HexEncode(v), nil
+		}
+		if v, ok := value.(string); ok {
+			if strings.HasPrefix(v, "0x") {
+				if _, err := HexDecode(v); err != nil {
+					return nil, err
+				}
+				return strings.ToLower(v), nil
+			}
+			return HexEncode([]byte(v)), nil
+		}
+		return nil, fmt.Errorf("data value invalid for type %s", typ)
+	}
+
+	// the atomic values of their encoding
+	word, err := t.encodeValue(typ, value)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case typ == "address":
+		return common.BytesToAddress(word).Hex(), nil
+	case typ == "bool":
+		return word[31] == 1, nil
+	case strings.HasPrefix(typ, "bytes"):
+		size, err := strconv.Atoi(typ[len("bytes"):])
+		if err != nil {
+			return nil, fmt.Errorf("invalid type %s", typ)
+		}
+		return HexEncode(word[:size]), nil
+	case strings.HasPrefix(typ, "int"):
+		n := new(big.Int).SetBytes(word)
+		if word[0]&0x80 != 0 {
+			n.Sub(n, new(big.Int).Lsh(big.NewInt(1), 256))
+		}
+		return n.String(), nil
+	case strings.HasPrefix(typ, "uint"):
+		return new(big.Int).SetBytes(word).String(), nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", typ)
+}
+
+// marshalCompactJSON returns the json of v without the escaping of html, ie. of
+// the "<", ">" and "&" of strings.
+func marshalCompactJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
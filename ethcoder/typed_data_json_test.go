@@ -0,0 +1,73 @@
+package ethcoder_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypedDataMarshalCompactJSON(t *testing.T) {
+	verifyingContract := common.HexToAddress("0xcccccccccccccccccccccccccccccccccccccccc")
+	typedData := &ethcoder.TypedData{
+		Types: ethcoder.TypedDataTypes{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"Order": {
+				{Name: "maker", Type: "address"},
+				{Name: "amount", Type: "uint256"},
+				{Name: "delta", Type: "int8"},
+				{Name: "salt", Type: "bytes4"},
+				{Name: "data", Type: "bytes"},
+				{Name: "note", Type: "string"},
+				{Name: "fills", Type: "Fill[]"},
+			},
+			"Fill": {
+				{Name: "ok", Type: "bool"},
+			},
+		},
+		PrimaryType: "Order",
+		Domain: ethcoder.TypedDataDomain{
+			Name:              "Exchange",
+			Version:           "1",
+			ChainID:           big.NewInt(137),
+			VerifyingContract: &verifyingContract,
+		},
+		Message: map[string]interface{}{
+			"maker":  "0xcd2a3d9f938e13cd947ec05abc7fe734df8dd826",
+			"amount": new(big.Int).Lsh(big.NewInt(1), 100),
+			"delta":  -5,
+			"salt":   "0x01020304",
+			"data":   []byte{0xab, 0xcd},
+			"note":   "<a & b>",
+			"fills":  []interface{}{map[string]interface{}{"ok": true}},
+		},
+	}
+
+	data, err := typedData.MarshalCompactJSON()
+	require.NoError(t, err)
+	assert.Equal(t, `{"types":{"EIP712Domain":[{"name":"name","type":"string"},{"name":"version","type":"string"},{"name":"chainId","type":"uint256"},{"name":"verifyingContract","type":"address"}],"Fill":[{"name":"ok","type":"bool"}],"Order":[{"name":"maker","type":"address"},{"name":"amount","type":"uint256"},{"name":"delta","type":"int8"},{"name":"salt","type":"bytes4"},{"name":"data","type":"bytes"},{"name":"note","type":"string"},{"name":"fills","type":"Fill[]"}]},"primaryType":"Order","domain":{"chainId":137,"name":"Exchange","verifyingContract":"0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC","version":"1"},"message":{"amount":"1267650600228229401496703205376","data":"0xabcd","delta":"-5","fills":[{"ok":true}],"maker":"0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826","note":"<a & b>","salt":"0x01020304"}}`, string(data))
+
+	// the same digest of the decoded payload
+	encoded, err := typedData.EncodeBase64URL()
+	require.NoError(t, err)
+	assert.NotContains(t, encoded, "=")
+	decoded, err := ethcoder.TypedDataFromBase64URL(encoded)
+	require.NoError(t, err)
+
+	digest, err := typedData.EncodeDigest()
+	require.NoError(t, err)
+	decodedDigest, err := decoded.EncodeDigest()
+	require.NoError(t, err)
+	assert.Equal(t, digest, decodedDigest)
+
+	_, err = ethcoder.TypedDataFromBase64URL("not base64!")
+	assert.Error(t, err)
+}
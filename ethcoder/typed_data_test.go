@@ -142,3 +142,272 @@ func TestTypedDataCase2(t *testing.T) {
 	// fmt.Println("===> digest", HexEncode(digest))
 
 }
+
+func TestTypedDataNested(t *testing.T) {
+	verifyingContract := common.HexToAddress("0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC")
+	domain := ethcoder.TypedDataDomain{
+		Name:              "Ether Mail",
+		Version:           "1",
+		ChainID:           big.NewInt(1),
+		VerifyingContract: &verifyingContract,
+	}
+	domainType := []ethcoder.TypedDataArgument{
+		{Name: "name", Type: "string"},
+		{Name: "version", Type: "string"},
+		{Name: "chainId", Type: "uint256"},
+		{Name: "verifyingContract", Type: "address"},
+	}
+
+	// the example of EIP-712
+	typedData := &ethcoder.TypedData{
+		Types: ethcoder.TypedDataTypes{
+			"EIP712Domain": domainType,
+			"Person": {
+				{Name: "name", Type: "string"},
+				{Name: "wallet", Type: "address"},
+			},
+			"Mail": {
+				{Name: "from", Type: "Person"},
+				{Name: "to", Type: "Person"},
+				{Name: "contents", Type: "string"},
+			},
+		},
+		PrimaryType: "Mail",
+		Domain:      domain,
+		Message: map[string]interface{}{
+			"from": map[string]interface{}{
+				"name":   "Cow",
+				"wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826",
+			},
+			"to": map[string]interface{}{
+				"name":   "Bob",
+				"wallet": common.HexToAddress("0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"),
+			},
+			"contents": "Hello, Bob!",
+		},
+	}
+
+	typeHash, err := typedData.Types.TypeHash("Mail")
+	assert.NoError(t, err)
+	assert.Equal(t, "0xa0cedeb2dc280ba39b857546d74f5549c3a1d7bdc2dd96bf881f76108e23dac2", ethcoder.HexEncode(typeHash))
+	messageHash, err := typedData.HashStruct("Mail", typedData.Message)
+	assert.NoError(t, err)
+	assert.Equal(t, "0xc52c0ee5d84264471806290a3f2c4cecfc5490626bf912d01f240d7a274b371e", ethcoder.HexEncode(messageHash))
+	digest, err := typedData.EncodeDigest()
+	assert.NoError(t, err)
+	assert.Equal(t, "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2", ethcoder.HexEncode(digest))
+
+	// the arrays of structs and of atomic values of eth_signTypedData_v4
+	typedData = &ethcoder.TypedData{
+		Types: ethcoder.TypedDataTypes{
+			"EIP712Domain": domainType,
+			"Person": {
+				{Name: "name", Type: "string"},
+				{Name: "wallets", Type: "address[]"},
+			},
+			"Mail": {
+				{Name: "from", Type: "Person"},
+				{Name: "to", Type: "Person[]"},
+				{Name: "contents", Type: "string"},
+			},
+			"Group": {
+				{Name: "name", Type: "string"},
+				{Name: "members", Type: "Person[]"},
+			},
+		},
+		PrimaryType: "Mail",
+		Domain:      domain,
+		Message: map[string]interface{}{
+			"from": map[string]interface{}{
+				"name": "Cow",
+				"wallets": []interface{}{
+					"0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826",
+					"0xDeaDbeefdEAdbeefdEadbEEFdeadbeEFdEaDbeeF",
+				},
+			},
+			"to": []interface{}{
+				map[string]interface{}{
+					"name": "Bob",
+					"wallets": []common.Address{
+						common.HexToAddress("0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"),
+						common.HexToAddress("0xB0BdaBea57B0BDABeA57b0bdABEA57b0BDabEa57"),
+						common.HexToAddress("0xB0B0b0b0b0b0B000000000000000000000000000"),
+					},
+				},
+			},
+			"contents": "Hello, Bob!",
+		},
+	}
+
+	encodeType, err := typedData.Types.EncodeType("Group")
+	assert.NoError(t, err)
+	assert.Equal(t, "Group(string name,Person[] members)Person(string name,address[] wallets)", encodeType)
+	encodeType, err = typedData.Types.EncodeType("Mail")
+	assert.NoError(t, err)
+	assert.Equal(t, "Mail(Person from,Person[] to,string contents)Person(string name,address[] wallets)", encodeType)
+
+	typeHash, err = typedData.Types.TypeHash("Person")
+	assert.NoError(t, err)
+	assert.Equal(t, "0xfabfe1ed996349fc6027709802be19d047da1aa5d6894ff5f6486d92db2e6860", ethcoder.HexEncode(typeHash))
+	messageHash, err = typedData.HashStruct("Mail", typedData.Message)
+	assert.NoError(t, err)
+	assert.Equal(t, "0xeb4221181ff3f1a83ea7313993ca9218496e424604ba9492bb4052c03d5c3df8", ethcoder.HexEncode(messageHash))
+	digest, err = typedData.EncodeDigest()
+	assert.NoError(t, err)
+	assert.Equal(t, "0xa85c2e2b118698e88db68a8105b794a8cc7cec074e89ef991cb4f5f533819cc2", ethcoder.HexEncode(digest))
+
+	// the dependencies are encoded once, in order
+	types := ethcoder.TypedDataTypes{
+		"A": {{Name: "b", Type: "B"}, {Name: "c", Type: "C[2]"}},
+		"B": {{Name: "c", Type: "C"}, {Name: "id", Type: "bytes4"}},
+		"C": {{Name: "a", Type: "A[]"}},
+	}
+	encodeType, err = types.EncodeType("A")
+	assert.NoError(t, err)
+	assert.Equal(t, "A(B b,C[2] c)B(C c,bytes4 id)C(A[] a)", encodeType)
+}
+
+func TestTypedDataFixedBytes(t *testing.T) {
+	typedData := &ethcoder.TypedData{
+		Types: ethcoder.TypedDataTypes{
+			"T": {{Name: "id", Type: "bytes4"}},
+		},
+	}
+	hash, err := typedData.HashStruct("T", map[string]interface{}{"id": "0x12345678"})
+	assert.NoError(t, err)
+
+	typeHash, err := typedData.Types.TypeHash("T")
+	assert.NoError(t, err)
+	id := append([]byte{0x12, 0x34, 0x56, 0x78}, make([]byte, 28)...)
+	assert.Equal(t, ethcoder.Keccak256(append(typeHash, id...)), hash)
+}
+
+func TestTypedDataFromJSON(t *testing.T) {
+	// the payload of eth_signTypedData_v4
+	data := `{
+		"types": {
+			"EIP712Domain": [
+				{"name": "name", "type": "string"},
+				{"name": "version", "type": "string"},
+				{"name": "chainId", "type": "uint256"},
+				{"name": "verifyingContract", "type": "address"}
+			],
+			"Person": [
+				{"name": "name", "type": "string"},
+				{"name": "wallets", "type": "address[]"}
+			],
+			"Mail": [
+				{"name": "from", "type": "Person"},
+				{"name": "to", "type": "Person[]"},
+				{"name": "contents", "type": "string"}
+			],
+			"Group": [
+				{"name": "name", "type": "string"},
+				{"name": "members", "type": "Person[]"}
+			]
+		},
+		"primaryType": "Mail",
+		"domain": {
+			"name": "Ether Mail",
+			"version": "1",
+			"chainId": 1,
+			"verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+		},
+		"message": {
+			"from": {
+				"name": "Cow",
+				"wallets": ["0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826", "0xDeaDbeefdEAdbeefdEadbEEFdeadbeEFdEaDbeeF"]
+			},
+			"to": [{
+				"name": "Bob",
+				"wallets": ["0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB", "0xB0BdaBea57B0BDABeA57b0bdABEA57b0BDabEa57", "0xB0B0b0b0b0b0B000000000000000000000000000"]
+			}],
+			"contents": "Hello, Bob!"
+		}
+	}`
+	typedData, err := ethcoder.TypedDataFromJSON([]byte(data))
+	assert.NoError(t, err)
+	assert.Equal(t, "Mail", typedData.PrimaryType)
+	assert.Equal(t, big.NewInt(1), typedData.Domain.ChainID)
+	digest, err := typedData.EncodeDigest()
+	assert.NoError(t, err)
+	assert.Equal(t, "0xa85c2e2b118698e88db68a8105b794a8cc7cec074e89ef991cb4f5f533819cc2", ethcoder.HexEncode(digest))
+
+	// the chainId as a string, numbers of the message, and the EIP712Domain type of the domain
+	data = `{
+		"types": {
+			"Order": [
+				{"name": "amount", "type": "uint256"},
+				{"name": "nonce", "type": "uint64"},
+				{"name": "delta", "type": "int8"}
+			]
+		},
+		"primaryType": "Order",
+		"domain": {"name": "Exchange", "chainId": "0x89"},
+		"message": {"amount": "1000000000000000000000", "nonce": 7, "delta": -3}
+	}`
+	typedData, err = ethcoder.TypedDataFromJSON([]byte(data))
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(137), typedData.Domain.ChainID)
+	assert.Equal(t, []ethcoder.TypedDataArgument{{Name: "name", Type: "string"}, {Name: "chainId", Type: "uint256"}}, typedData.Types["EIP712Domain"])
+
+	amount, _ := new(big.Int).SetString("1000000000000000000000", 10)
+	expected := &ethcoder.TypedData{
+		Types:       typedData.Types,
+		PrimaryType: "Order",
+		Domain:      ethcoder.TypedDataDomain{Name: "Exchange", ChainID: big.NewInt(137)},
+		Message:     map[string]interface{}{"amount": amount, "nonce": uint64(7), "delta": int8(-3)},
+	}
+	expectedDigest, err := expected.EncodeDigest()
+	assert.NoError(t, err)
+	digest, err = typedData.EncodeDigest()
+	assert.NoError(t, err)
+	assert.Equal(t, expectedDigest, digest)
+
+	_, err = ethcoder.TypedDataFromJSON([]byte(`{"types": {"A": [{"name": "b", "type": "B"}]}, "primaryType": "A", "domain": {}, "message": {}}`))
+	assert.Error(t, err)
+	_, err = ethcoder.TypedDataFromJSON([]byte(`{"types": {"A": []}, "primaryType": "Mail", "domain": {}, "message": {}}`))
+	assert.Error(t, err)
+	_, err = ethcoder.TypedDataFromJSON([]byte(`{"types": {"A": []}, "primaryType": "A", "domain": {"chainId": "abc"}, "message": {}}`))
+	assert.Error(t, err)
+}
+
+func TestTypedDataDomain(t *testing.T) {
+	verifyingContract := common.HexToAddress("0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC")
+
+	// the domain of the example of EIP-712
+	separator, err := ethcoder.NewTypedDataDomain().
+		Name("Ether Mail").
+		Version("1").
+		ChainID(big.NewInt(1)).
+		VerifyingContract(verifyingContract).
+		Separator()
+	assert.NoError(t, err)
+	assert.Equal(t, "0xf2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f", separator.Hex())
+
+	// the type of the fields which are set
+	salt := ethcoder.Keccak256Hash([]byte("salt"))
+	domain, err := ethcoder.NewTypedDataDomain().Name("Permit2").ChainID(big.NewInt(137)).Salt(salt).Build()
+	assert.NoError(t, err)
+	assert.Equal(t, []ethcoder.TypedDataArgument{
+		{Name: "name", Type: "string"},
+		{Name: "chainId", Type: "uint256"},
+		{Name: "salt", Type: "bytes32"},
+	}, domain.Type())
+
+	typeHash := ethcoder.Keccak256([]byte("EIP712Domain(string name,uint256 chainId,bytes32 salt)"))
+	expected := ethcoder.Keccak256(append(append(append(typeHash, ethcoder.Keccak256([]byte("Permit2"))...), common.LeftPadBytes([]byte{137}, 32)...), salt[:]...))
+	separator, err = domain.Separator()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, separator.Bytes())
+
+	// the invalid fields
+	_, err = ethcoder.NewTypedDataDomain().Build()
+	assert.Error(t, err)
+	_, err = ethcoder.NewTypedDataDomain().Name("").Version("1").Build()
+	assert.Error(t, err)
+	_, err = ethcoder.NewTypedDataDomain().ChainID(big.NewInt(-1)).Separator()
+	assert.Error(t, err)
+	_, err = ethcoder.NewTypedDataDomain().VerifyingContract(common.Address{}).Separator()
+	assert.Error(t, err)
+}
@@ -2,17 +2,19 @@ package ethcontract
 
 import (
 	"fmt"
-	"strings"
 
+	"github.com/0xsequence/ethkit/ethcoder"
 	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
 )
 
+// ParseABI parses the abi json, with its fixed-point types, see
+// ethcoder.ParseABIJSON.
 func ParseABI(abiJSON string) (abi.ABI, error) {
-	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	parsed, err := ethcoder.ParseABIJSON([]byte(abiJSON))
 	if err != nil {
 		return abi.ABI{}, fmt.Errorf("unable to parse abi json: %w", err)
 	}
-	return parsed, nil
+	return *parsed, nil
 }
 
 func MustParseABI(abiJSON string) abi.ABI {
@@ -0,0 +1,79 @@
+package ethcontract
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// EIP5267ABI is the abi of eip712Domain() of EIP-5267.
+var EIP5267ABI = MustParseABI(`[
+	{"type":"function","name":"eip712Domain","stateMutability":"view","inputs":[],"outputs":[
+		{"name":"fields","type":"bytes1"},
+		{"name":"name","type":"string"},
+		{"name":"version","type":"string"},
+		{"name":"chainId","type":"uint256"},
+		{"name":"verifyingContract","type":"address"},
+		{"name":"salt","type":"bytes32"},
+		{"name":"extensions","type":"uint256[]"}
+	]}
+]`)
+
+// EIP712Domain returns the EIP-712 domain of the contract of its EIP-5267
+// eip712Domain(), ie. of the domain of the permits of a token, with the options
+// of the call.
+func EIP712Domain(ctx context.Context, provider ethrpc.Interface, contract common.Address, opts *CallOpts) (*ethcoder.TypedDataDomain, error) {
+	data, err := Call(ctx, provider, contract, EIP5267ABI.Methods["eip712Domain"].ID, opts)
+	if err != nil {
+		return nil, fmt.Errorf("ethcontract: failed to call eip712Domain of %s: %w", contract.Hex(), err)
+	}
+	return DecodeEIP712Domain(data)
+}
+
+// DecodeEIP712Domain decodes the return data of eip712Domain(), with the fields
+// of its bitmap, which are, from the least significant bit, the name, version,
+// chainId, verifyingContract and salt. The domains of extensions are not
+// supported, as their fields are of the EIPs of the extensions.
+func DecodeEIP712Domain(data []byte) (*ethcoder.TypedDataDomain, error) {
+	var result struct {
+		Fields            [1]byte
+		Name              string
+		Version           string
+		ChainId           *big.Int
+		VerifyingContract common.Address
+		Salt              [32]byte
+		Extensions        []*big.Int
+	}
+	if err := EIP5267ABI.UnpackIntoInterface(&result, "eip712Domain", data); err != nil {
+		return nil, fmt.Errorf("ethcontract: failed to decode eip712Domain: %w", err)
+	}
+	if len(result.Extensions) > 0 {
+		return nil, fmt.Errorf("ethcontract: eip712Domain has unsupported extensions %v", result.Extensions)
+	}
+
+	fields := result.Fields[0]
+	if fields>>5 != 0 {
+		return nil, fmt.Errorf("ethcontract: eip712Domain has unknown fields 0x%02x", fields)
+	}
+	domain := &ethcoder.TypedDataDomain{}
+	if fields&0x01 != 0 {
+		domain.Name = result.Name
+	}
+	if fields&0x02 != 0 {
+		domain.Version = result.Version
+	}
+	if fields&0x04 != 0 {
+		domain.ChainID = result.ChainId
+	}
+	if fields&0x08 != 0 {
+		domain.VerifyingContract = &result.VerifyingContract
+	}
+	if fields&0x10 != 0 {
+		domain.Salt = &result.Salt
+	}
+	return domain, nil
+}
@@ -0,0 +1,56 @@
+package ethcontract_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethcontract"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockProvider struct {
+	ethrpc.Interface
+	result []byte
+}
+
+func (p *mockProvider) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNum *big.Int) ([]byte, error) {
+	return p.result, nil
+}
+
+func TestEIP712Domain(t *testing.T) {
+	token := common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	outputs := ethcontract.EIP5267ABI.Methods["eip712Domain"].Outputs
+	result, err := outputs.Pack([1]byte{0x0f}, "USD Coin", "2", big.NewInt(1), token, [32]byte{}, []*big.Int{})
+	require.NoError(t, err)
+
+	domain, err := ethcontract.EIP712Domain(context.Background(), &mockProvider{result: result}, token, nil)
+	require.NoError(t, err)
+	assert.Equal(t, &ethcoder.TypedDataDomain{Name: "USD Coin", Version: "2", ChainID: big.NewInt(1), VerifyingContract: &token}, domain)
+
+	expected, err := ethcoder.NewTypedDataDomain().Name("USD Coin").Version("2").ChainID(big.NewInt(1)).VerifyingContract(token).Separator()
+	require.NoError(t, err)
+	separator, err := domain.Separator()
+	require.NoError(t, err)
+	assert.Equal(t, expected, separator)
+
+	// the fields of the bitmap only
+	result, err = outputs.Pack([1]byte{0x14}, "ignored", "", big.NewInt(137), common.Address{}, [32]byte{1}, []*big.Int{})
+	require.NoError(t, err)
+	domain, err = ethcontract.DecodeEIP712Domain(result)
+	require.NoError(t, err)
+	assert.Equal(t, &ethcoder.TypedDataDomain{ChainID: big.NewInt(137), Salt: &[32]byte{1}}, domain)
+
+	result, err = outputs.Pack([1]byte{0x0f}, "Token", "1", big.NewInt(1), token, [32]byte{}, []*big.Int{big.NewInt(1)})
+	require.NoError(t, err)
+	_, err = ethcontract.DecodeEIP712Domain(result)
+	assert.Error(t, err)
+
+	_, err = ethcontract.DecodeEIP712Domain(nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,237 @@
+package ethcontract
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi/bind"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+// CallOpts are the options of a contract call, the same for the contracts, the
+// multicalls and the generated bindings. A nil *CallOpts is a call at the latest
+// block.
+type CallOpts struct {
+	// From is the sender of the call.
+	From common.Address
+
+	// BlockNum of the call, or a tag like ethrpc.Pending, ethrpc.Safe or
+	// ethrpc.Finalized. The latest block when nil and BlockHash is empty.
+	BlockNum *big.Int
+
+	// BlockHash of the call, instead of BlockNum.
+	BlockHash common.Hash
+
+	// Value in wei sent with the call.
+	Value *big.Int
+
+	// Gas limit and price overrides of the call.
+	Gas       uint64
+	GasPrice  *big.Int
+	GasFeeCap *big.Int
+	GasTipCap *big.Int
+
+	AccessList types.AccessList
+
+	// StateOverride optional of the accounts during the call.
+	StateOverride ethrpc.StateOverride
+}
+
+// CallMsg returns the eth_call message of the calldata to the contract.
+func (o *CallOpts) CallMsg(to common.Address, data []byte) ethereum.CallMsg {
+	msg := ethereum.CallMsg{To: &to, Data: data}
+	if o != nil {
+		msg.From = o.From
+		msg.Value = o.Value
+		msg.Gas = o.Gas
+		msg.GasPrice = o.GasPrice
+		msg.GasFeeCap = o.GasFeeCap
+		msg.GasTipCap = o.GasTipCap
+		msg.AccessList = o.AccessList
+	}
+	return msg
+}
+
+// BindCallOpts returns the options of o for the calls of the generated bindings.
+// The value, gas and state overrides are not supported by bind.CallOpts.
+func (o *CallOpts) BindCallOpts(ctx context.Context) *bind.CallOpts {
+	opts := &bind.CallOpts{Context: ctx}
+	if o == nil {
+		return opts
+	}
+	opts.From = o.From
+	if o.BlockHash != (common.Hash{}) {
+		opts.BlockHash = o.BlockHash
+	} else if o.BlockNum != nil && o.BlockNum.Cmp(ethrpc.Pending) == 0 {
+		opts.Pending = true
+	} else {
+		opts.BlockNumber = o.BlockNum
+	}
+	return opts
+}
+
+// Call executes the calldata to the contract with the options.
+func Call(ctx context.Context, provider ethrpc.Interface, to common.Address, data []byte, opts *CallOpts) ([]byte, error) {
+	msg := opts.CallMsg(to, data)
+	if opts == nil {
+		return provider.CallContract(ctx, msg, nil)
+	}
+	if opts.BlockHash == (common.Hash{}) && len(opts.StateOverride) == 0 {
+		return provider.CallContract(ctx, msg, opts.BlockNum)
+	}
+
+	call := ethrpc.CallContractWithOverride(msg, opts.BlockNum, opts.StateOverride)
+	if opts.BlockHash != (common.Hash{}) {
+		call = ethrpc.CallContractAtHashWithOverride(msg, opts.BlockHash, opts.StateOverride)
+	}
+	var result []byte
+	if _, err := provider.Do(ctx, call.Into(&result)); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// TxOpts are the options of a contract transaction. A nil *TxOpts is a
+// transaction with the nonce and fees set automatically.
+type TxOpts struct {
+	// Value in wei sent with the transaction.
+	Value *big.Int
+
+	// Nonce, gas limit and fee overrides, automatically set when empty.
+	Nonce     *big.Int
+	GasLimit  uint64
+	GasPrice  *big.Int
+	GasTip    *big.Int
+	GasFeeCap *big.Int
+
+	AccessList types.AccessList
+
+	// Simulate executes the transaction with eth_call before sending it, which
+	// fails without sending the transaction on a revert.
+	Simulate bool
+}
+
+// TransactionRequest returns the transaction request of the calldata to the
+// contract, or of a contract creation when to is nil.
+func (o *TxOpts) TransactionRequest(to *common.Address, data []byte) *ethtxn.TransactionRequest {
+	txr := &ethtxn.TransactionRequest{To: to, Data: data}
+	if o != nil {
+		txr.ETHValue = o.Value
+		txr.Nonce = o.Nonce
+		txr.GasLimit = o.GasLimit
+		txr.GasPrice = o.GasPrice
+		if o.GasFeeCap != nil {
+			txr.GasPrice = o.GasFeeCap
+		}
+		txr.GasTip = o.GasTip
+		txr.AccessList = o.AccessList
+	}
+	return txr
+}
+
+// BindTransactOpts returns auth with the overrides of o, for the transactions of
+// the generated bindings.
+func (o *TxOpts) BindTransactOpts(auth *bind.TransactOpts) *bind.TransactOpts {
+	opts := *auth
+	if o == nil {
+		return &opts
+	}
+	if o.Value != nil {
+		opts.Value = o.Value
+	}
+	if o.Nonce != nil {
+		opts.Nonce = o.Nonce
+	}
+	if o.GasLimit != 0 {
+		opts.GasLimit = o.GasLimit
+	}
+	if o.GasPrice != nil {
+		opts.GasPrice = o.GasPrice
+	}
+	if o.GasFeeCap != nil {
+		opts.GasFeeCap = o.GasFeeCap
+	}
+	if o.GasTip != nil {
+		opts.GasTipCap = o.GasTip
+	}
+	return &opts
+}
+
+// Transactor sends the transactions of a contract, ie. an *ethwallet.Wallet.
+type Transactor interface {
+	Address() common.Address
+	GetProvider() *ethrpc.Provider
+	NewTransaction(ctx context.Context, txnRequest *ethtxn.TransactionRequest) (*types.Transaction, error)
+	SendTransaction(ctx context.Context, signedTx *types.Transaction) (*types.Transaction, ethtxn.WaitReceipt, error)
+}
+
+// Query calls the method of the contract with the options, and returns its
+// decoded outputs.
+func (c *Contract) Query(ctx context.Context, provider ethrpc.Interface, opts *CallOpts, method string, args ...interface{}) ([]interface{}, error) {
+	output, err := c.call(ctx, provider, opts, method, args...)
+	if err != nil {
+		return nil, err
+	}
+	values, err := c.ABI.Unpack(method, output)
+	if err != nil {
+		return nil, fmt.Errorf("ethcontract: failed to decode %s: %w", method, err)
+	}
+	return values, nil
+}
+
+// QueryInto calls the method of the contract with the options, and decodes its
+// outputs into result.
+func (c *Contract) QueryInto(ctx context.Context, provider ethrpc.Interface, opts *CallOpts, result interface{}, method string, args ...interface{}) error {
+	output, err := c.call(ctx, provider, opts, method, args...)
+	if err != nil {
+		return err
+	}
+	if err := c.ABI.UnpackIntoInterface(result, method, output); err != nil {
+		return fmt.Errorf("ethcontract: failed to decode %s: %w", method, err)
+	}
+	return nil
+}
+
+func (c *Contract) call(ctx context.Context, provider ethrpc.Interface, opts *CallOpts, method string, args ...interface{}) ([]byte, error) {
+	data, err := c.Encode(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	return Call(ctx, provider, c.Address, data, opts)
+}
+
+// Transact sends a transaction of the method of the contract with the options.
+func (c *Contract) Transact(ctx context.Context, transactor Transactor, opts *TxOpts, method string, args ...interface{}) (*types.Transaction, ethtxn.WaitReceipt, error) {
+	data, err := c.Encode(method, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if opts != nil && opts.Simulate {
+		provider := transactor.GetProvider()
+		if provider == nil {
+			return nil, nil, fmt.Errorf("ethcontract: transactor provider is not set")
+		}
+		callOpts := &CallOpts{
+			From:       transactor.Address(),
+			BlockNum:   ethrpc.Pending,
+			Value:      opts.Value,
+			Gas:        opts.GasLimit,
+			AccessList: opts.AccessList,
+		}
+		if _, err := Call(ctx, provider, c.Address, data, callOpts); err != nil {
+			return nil, nil, fmt.Errorf("ethcontract: simulation of %s failed: %w", method, err)
+		}
+	}
+
+	tx, err := transactor.NewTransaction(ctx, opts.TransactionRequest(&c.Address, data))
+	if err != nil {
+		return nil, nil, err
+	}
+	return transactor.SendTransaction(ctx, tx)
+}
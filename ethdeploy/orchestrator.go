@@ -0,0 +1,384 @@
+package ethdeploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethartifact"
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+var ErrTransactionFailed = errors.New("ethdeploy: transaction failed")
+
+// Backend is the chain of the deployments, ie. an *ethrpc.Provider.
+type Backend interface {
+	CodeAt(ctx context.Context, account common.Address, blockNum *big.Int) ([]byte, error)
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+}
+
+// Sender sends the transactions of the deployments and waits for their receipts,
+// ie. a WalletSender.
+type Sender interface {
+	Address() common.Address
+	Send(ctx context.Context, txnRequest *ethtxn.TransactionRequest) (*types.Receipt, error)
+}
+
+// WalletSender is the Sender of a wallet with a provider.
+type WalletSender struct {
+	Wallet *ethwallet.Wallet
+}
+
+func (s WalletSender) Address() common.Address {
+	return s.Wallet.Address()
+}
+
+func (s WalletSender) Send(ctx context.Context, txnRequest *ethtxn.TransactionRequest) (*types.Receipt, error) {
+	tx, err := s.Wallet.NewTransaction(ctx, txnRequest)
+	if err != nil {
+		return nil, err
+	}
+	_, waitReceipt, err := s.Wallet.SendTransaction(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	return waitReceipt(ctx)
+}
+
+type OrchestratorOptions struct {
+	// Registry of the executed steps, by default in memory.
+	Registry Registry
+
+	// DryRun resolves the plan and its addresses without sending any transaction.
+	DryRun bool
+}
+
+// Orchestrator executes the deployment plans, idempotently: the contracts
+// deployed already, either recorded in the registry or at their CREATE2 address,
+// and the calls recorded in the registry are skipped.
+type Orchestrator struct {
+	backend   Backend
+	sender    Sender
+	artifacts *ethartifact.ContractRegistry
+	options   OrchestratorOptions
+}
+
+func NewOrchestrator(backend Backend, sender Sender, artifacts *ethartifact.ContractRegistry, opts ...OrchestratorOptions) (*Orchestrator, error) {
+	if backend == nil || sender == nil || artifacts == nil {
+		return nil, fmt.Errorf("ethdeploy: backend, sender and artifacts are required")
+	}
+	options := OrchestratorOptions{}
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	if options.Registry == nil {
+		options.Registry = NewMemoryRegistry()
+	}
+	return &Orchestrator{backend: backend, sender: sender, artifacts: artifacts, options: options}, nil
+}
+
+type StepStatus string
+
+const (
+	// StepExecuted is a step of which the transaction was sent.
+	StepExecuted StepStatus = "executed"
+	// StepSkipped is a step executed already.
+	StepSkipped StepStatus = "skipped"
+	// StepPlanned is a step to execute, of a dry run.
+	StepPlanned StepStatus = "planned"
+)
+
+type StepResult struct {
+	Name    string
+	Status  StepStatus
+	Address common.Address
+
+	// Implementation of a contract with a proxy.
+	Implementation *common.Address
+
+	// TxHash of the last transaction of the step, empty when not executed.
+	TxHash common.Hash
+}
+
+type Result struct {
+	Steps []StepResult
+}
+
+// Address returns the address of the deployment of the name.
+func (r *Result) Address(name string) (common.Address, bool) {
+	for _, s := range r.Steps {
+		if s.Name == name && s.Address != (common.Address{}) {
+			return s.Address, true
+		}
+	}
+	return common.Address{}, false
+}
+
+// run is the state of the execution of a plan.
+type run struct {
+	plan      *Plan
+	addresses map[string]string
+	nonce     uint64
+}
+
+// Run executes the plan. On a failure, the steps executed so far are recorded in
+// the registry and a new Run resumes from the failed step.
+func (o *Orchestrator) Run(ctx context.Context, plan *Plan) (*Result, error) {
+	steps, err := plan.steps()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &run{plan: plan, addresses: map[string]string{}}
+	if o.options.DryRun {
+		r.nonce, err = o.backend.PendingNonceAt(ctx, o.sender.Address())
+		if err != nil {
+			return nil, fmt.Errorf("ethdeploy: failed to get the nonce: %w", err)
+		}
+	}
+
+	result := &Result{}
+	for _, s := range steps {
+		var res StepResult
+		var err error
+		if s.contract != nil {
+			res, err = o.runContract(ctx, r, s.contract)
+		} else {
+			res, err = o.runCall(ctx, r, s.call)
+		}
+		if err != nil {
+			return result, fmt.Errorf("ethdeploy: step '%s': %w", s.name, err)
+		}
+		result.Steps = append(result.Steps, res)
+	}
+	return result, nil
+}
+
+func (o *Orchestrator) runContract(ctx context.Context, r *run, c *Contract) (StepResult, error) {
+	res := StepResult{Name: c.Name}
+
+	artifactName := c.Artifact
+	if artifactName == "" {
+		artifactName = c.Name
+	}
+	args, err := r.resolve(c.Args, nil)
+	if err != nil {
+		return res, err
+	}
+	address, status, txHash, err := o.deploy(ctx, r, c.Name, artifactName, args, c.Salt)
+	if err != nil {
+		return res, err
+	}
+	res.Address, res.Status, res.TxHash = address, status, txHash
+	if c.Proxy == nil {
+		r.addresses[c.Name] = address.Hex()
+		return res, nil
+	}
+
+	// the proxy in front of the implementation
+	implementation := address
+	r.addresses[c.Name+".implementation"] = implementation.Hex()
+	res.Implementation = &implementation
+
+	local := map[string]string{"implementation": implementation.Hex(), "init": "0x"}
+	if c.Proxy.Init != "" {
+		initArgs, err := r.resolve(c.Proxy.InitArgs, nil)
+		if err != nil {
+			return res, err
+		}
+		calldata, err := ethcoder.AbiEncodeMethodCalldataFromStringValues(c.Proxy.Init, initArgs)
+		if err != nil {
+			return res, fmt.Errorf("proxy init: %w", err)
+		}
+		local["init"] = hexutil.Encode(calldata)
+	}
+	proxyArgs, err := r.resolve(c.Proxy.Args, local)
+	if err != nil {
+		return res, err
+	}
+	address, status, txHash, err = o.deploy(ctx, r, c.Name+".proxy", c.Proxy.Artifact, proxyArgs, c.Proxy.Salt)
+	if err != nil {
+		return res, fmt.Errorf("proxy: %w", err)
+	}
+	r.addresses[c.Name] = address.Hex()
+	res.Address = address
+	if status != StepSkipped {
+		res.Status, res.TxHash = status, txHash
+	}
+	return res, nil
+}
+
+// deploy deploys the artifact under the registry key, unless it's deployed
+// already.
+func (o *Orchestrator) deploy(ctx context.Context, r *run, key, artifactName string, args []string, salt *common.Hash) (common.Address, StepStatus, common.Hash, error) {
+	artifact, ok := o.artifacts.Get(artifactName)
+	if !ok {
+		return common.Address{}, "", common.Hash{}, fmt.Errorf("unknown artifact '%s'", artifactName)
+	}
+	if len(artifact.Bin) == 0 {
+		return common.Address{}, "", common.Hash{}, fmt.Errorf("artifact '%s' has no bytecode", artifactName)
+	}
+
+	initCode, err := ethcoder.DeployDataFromStrings(artifact.Bin, artifact.ABI, args)
+	if err != nil {
+		return common.Address{}, "", common.Hash{}, fmt.Errorf("constructor of '%s': %w", artifactName, err)
+	}
+
+	if salt != nil {
+		factory := DeterministicDeploymentProxy
+		if r.plan.Factory != nil {
+			factory = *r.plan.Factory
+		}
+		address := crypto.CreateAddress2(factory, *salt, crypto.Keccak256(initCode))
+		deployed, err := o.isDeployed(ctx, address)
+		if err != nil {
+			return address, "", common.Hash{}, err
+		}
+		if deployed {
+			return address, StepSkipped, common.Hash{}, nil
+		}
+		if o.options.DryRun {
+			r.nonce++
+			return address, StepPlanned, common.Hash{}, nil
+		}
+
+		receipt, err := o.send(ctx, &ethtxn.TransactionRequest{To: &factory, Data: append(salt.Bytes(), initCode...)})
+		if err != nil {
+			return address, "", common.Hash{}, err
+		}
+		deployed, err = o.isDeployed(ctx, address)
+		if err != nil {
+			return address, "", common.Hash{}, err
+		}
+		if !deployed {
+			return address, "", receipt.TxHash, fmt.Errorf("%w: no code at %s after the CREATE2 deployment", ErrTransactionFailed, address.Hex())
+		}
+		return address, StepExecuted, receipt.TxHash, o.options.Registry.Put(key, Deployment{Address: address, TxHash: receipt.TxHash})
+	}
+
+	d, ok, err := o.options.Registry.Get(key)
+	if err != nil {
+		return common.Address{}, "", common.Hash{}, err
+	}
+	if ok {
+		deployed, err := o.isDeployed(ctx, d.Address)
+		if err != nil {
+			return d.Address, "", common.Hash{}, err
+		}
+		if deployed {
+			return d.Address, StepSkipped, common.Hash{}, nil
+		}
+	}
+	if o.options.DryRun {
+		address := crypto.CreateAddress(o.sender.Address(), r.nonce)
+		r.nonce++
+		return address, StepPlanned, common.Hash{}, nil
+	}
+
+	receipt, err := o.send(ctx, &ethtxn.TransactionRequest{Data: initCode})
+	if err != nil {
+		return common.Address{}, "", common.Hash{}, err
+	}
+	return receipt.ContractAddress, StepExecuted, receipt.TxHash, o.options.Registry.Put(key, Deployment{Address: receipt.ContractAddress, TxHash: receipt.TxHash})
+}
+
+func (o *Orchestrator) runCall(ctx context.Context, r *run, c *Call) (StepResult, error) {
+	res := StepResult{Name: c.Name}
+
+	to, err := r.resolveValue(c.To, nil)
+	if err != nil {
+		return res, err
+	}
+	if !common.IsHexAddress(to) {
+		return res, fmt.Errorf("invalid address '%s'", to)
+	}
+	toAddress := common.HexToAddress(to)
+	args, err := r.resolve(c.Args, nil)
+	if err != nil {
+		return res, err
+	}
+	calldata, err := ethcoder.AbiEncodeMethodCalldataFromStringValues(c.Method, args)
+	if err != nil {
+		return res, err
+	}
+
+	d, ok, err := o.options.Registry.Get(c.Name)
+	if err != nil {
+		return res, err
+	}
+	if ok {
+		res.Status = StepSkipped
+		res.TxHash = d.TxHash
+		return res, nil
+	}
+	if o.options.DryRun {
+		res.Status = StepPlanned
+		r.nonce++
+		return res, nil
+	}
+
+	receipt, err := o.send(ctx, &ethtxn.TransactionRequest{To: &toAddress, Data: calldata, ETHValue: c.Value})
+	if err != nil {
+		return res, err
+	}
+	res.Status, res.TxHash = StepExecuted, receipt.TxHash
+	return res, o.options.Registry.Put(c.Name, Deployment{TxHash: receipt.TxHash})
+}
+
+func (o *Orchestrator) send(ctx context.Context, txnRequest *ethtxn.TransactionRequest) (*types.Receipt, error) {
+	receipt, err := o.sender.Send(ctx, txnRequest)
+	if err != nil {
+		return nil, err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return receipt, fmt.Errorf("%w: %s", ErrTransactionFailed, receipt.TxHash.Hex())
+	}
+	return receipt, nil
+}
+
+func (o *Orchestrator) isDeployed(ctx context.Context, address common.Address) (bool, error) {
+	code, err := o.backend.CodeAt(ctx, address, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to get the code at %s: %w", address.Hex(), err)
+	}
+	return len(code) > 0, nil
+}
+
+func (r *run) resolve(values []string, local map[string]string) ([]string, error) {
+	out := make([]string, len(values))
+	for i, v := range values {
+		var err error
+		out[i], err = r.resolveValue(v, local)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// resolveValue replaces the references of value by their addresses, and the
+// local ones of a proxy.
+func (r *run) resolveValue(value string, local map[string]string) (string, error) {
+	var err error
+	out := regexReference.ReplaceAllStringFunc(value, func(ref string) string {
+		name := ref[2 : len(ref)-1]
+		if v, ok := local[name]; ok {
+			return v
+		}
+		if v, ok := r.addresses[name]; ok {
+			return v
+		}
+		if err == nil {
+			err = fmt.Errorf("%w '%s'", ErrUnknownReference, ref)
+		}
+		return ref
+	})
+	return out, err
+}
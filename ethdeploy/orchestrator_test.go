@@ -0,0 +1,176 @@
+package ethdeploy_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethartifact"
+	"github.com/0xsequence/ethkit/ethdeploy"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeChain deploys the init code of the transactions as the code of the
+// contracts, without executing them.
+type fakeChain struct {
+	from  common.Address
+	nonce uint64
+	code  map[common.Address][]byte
+	sent  []*ethtxn.TransactionRequest
+}
+
+func newFakeChain() *fakeChain {
+	return &fakeChain{from: common.HexToAddress("0x1"), code: map[common.Address][]byte{}}
+}
+
+func (c *fakeChain) CodeAt(ctx context.Context, account common.Address, blockNum *big.Int) ([]byte, error) {
+	return c.code[account], nil
+}
+
+func (c *fakeChain) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return c.nonce, nil
+}
+
+func (c *fakeChain) Address() common.Address {
+	return c.from
+}
+
+func (c *fakeChain) Send(ctx context.Context, txr *ethtxn.TransactionRequest) (*types.Receipt, error) {
+	c.sent = append(c.sent, txr)
+	receipt := &types.Receipt{Status: types.ReceiptStatusSuccessful, TxHash: common.BigToHash(big.NewInt(int64(len(c.sent))))}
+	switch {
+	case txr.To == nil:
+		receipt.ContractAddress = crypto.CreateAddress(c.from, c.nonce)
+		c.code[receipt.ContractAddress] = txr.Data
+	case *txr.To == ethdeploy.DeterministicDeploymentProxy:
+		var salt [32]byte
+		copy(salt[:], txr.Data[:32])
+		c.code[crypto.CreateAddress2(*txr.To, salt, crypto.Keccak256(txr.Data[32:]))] = txr.Data[32:]
+	}
+	c.nonce++
+	return receipt, nil
+}
+
+func newArtifacts(t *testing.T) *ethartifact.ContractRegistry {
+	artifacts := ethartifact.NewContractRegistry()
+	_, err := artifacts.RegisterJSON("Token", `[{"type":"constructor","inputs":[{"name":"name","type":"string"}]}]`, []byte{0x01})
+	require.NoError(t, err)
+	_, err = artifacts.RegisterJSON("Vault", `[{"type":"constructor","inputs":[{"name":"token","type":"address"},{"name":"owners","type":"address[]"}]},{"type":"function","name":"initialize","inputs":[{"name":"admin","type":"address"}]}]`, []byte{0x02})
+	require.NoError(t, err)
+	_, err = artifacts.RegisterJSON("Proxy", `[{"type":"constructor","inputs":[{"name":"implementation","type":"address"},{"name":"data","type":"bytes"}]}]`, []byte{0x03})
+	require.NoError(t, err)
+	return artifacts
+}
+
+const testPlan = `{
+	"contracts": [
+		{"name": "Vault", "args": ["${Token}", "[\"${Token}\"]"], "proxy": {"artifact": "Proxy", "args": ["${implementation}", "${init}"], "init": "initialize(address)", "initArgs": ["0x0000000000000000000000000000000000000002"]}},
+		{"name": "Token", "args": ["Test"], "salt": "0x0000000000000000000000000000000000000000000000000000000000000001"}
+	],
+	"calls": [
+		{"name": "setup", "to": "${Vault}", "method": "setToken(address)", "args": ["${Token}"]}
+	]
+}`
+
+func TestPlanSteps(t *testing.T) {
+	plan, err := ethdeploy.ParsePlan([]byte(testPlan))
+	require.NoError(t, err)
+	steps, err := plan.Steps()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Token", "Vault", "setup"}, steps)
+
+	plan.Contracts[1].DependsOn = []string{"setup"}
+	_, err = plan.Steps()
+	assert.ErrorIs(t, err, ethdeploy.ErrCyclicPlan)
+
+	plan.Contracts[1].DependsOn = []string{"Unknown"}
+	_, err = plan.Steps()
+	assert.ErrorIs(t, err, ethdeploy.ErrUnknownReference)
+}
+
+func TestOrchestrator(t *testing.T) {
+	ctx := context.Background()
+	plan, err := ethdeploy.ParsePlan([]byte(testPlan))
+	require.NoError(t, err)
+	chain := newFakeChain()
+	artifacts := newArtifacts(t)
+	registry := ethdeploy.NewMemoryRegistry()
+
+	// the dry run sends nothing, and resolves the addresses ahead
+	dry, err := ethdeploy.NewOrchestrator(chain, chain, artifacts, ethdeploy.OrchestratorOptions{Registry: registry, DryRun: true})
+	require.NoError(t, err)
+	planned, err := dry.Run(ctx, plan)
+	require.NoError(t, err)
+	assert.Empty(t, chain.sent)
+	for _, s := range planned.Steps {
+		assert.Equal(t, ethdeploy.StepPlanned, s.Status, s.Name)
+	}
+
+	orchestrator, err := ethdeploy.NewOrchestrator(chain, chain, artifacts, ethdeploy.OrchestratorOptions{Registry: registry})
+	require.NoError(t, err)
+	result, err := orchestrator.Run(ctx, plan)
+	require.NoError(t, err)
+	require.Len(t, chain.sent, 4)
+	assert.Equal(t, planned.Steps, asPlanned(result.Steps))
+
+	token, ok := result.Address("Token")
+	require.True(t, ok)
+	vault, ok := result.Address("Vault")
+	require.True(t, ok)
+	assert.Equal(t, crypto.CreateAddress(chain.from, 2), vault)
+	assert.Equal(t, crypto.CreateAddress(chain.from, 1), *result.Steps[1].Implementation)
+	assert.NotEmpty(t, chain.code[token])
+	assert.Equal(t, vault, *chain.sent[3].To)
+
+	// executed again, all the steps are skipped
+	again, err := orchestrator.Run(ctx, plan)
+	require.NoError(t, err)
+	require.Len(t, chain.sent, 4)
+	for _, s := range again.Steps {
+		assert.Equal(t, ethdeploy.StepSkipped, s.Status, s.Name)
+	}
+	vaultAgain, _ := again.Address("Vault")
+	assert.Equal(t, vault, vaultAgain)
+
+	// the CREATE2 deployment is skipped without the registry too
+	fresh, err := ethdeploy.NewOrchestrator(chain, chain, artifacts)
+	require.NoError(t, err)
+	result, err = fresh.Run(ctx, &ethdeploy.Plan{Contracts: plan.Contracts[1:]})
+	require.NoError(t, err)
+	assert.Equal(t, ethdeploy.StepSkipped, result.Steps[0].Status)
+	assert.Equal(t, token, result.Steps[0].Address)
+}
+
+func asPlanned(steps []ethdeploy.StepResult) []ethdeploy.StepResult {
+	out := make([]ethdeploy.StepResult, len(steps))
+	for i, s := range steps {
+		s.TxHash = common.Hash{}
+		s.Status = ethdeploy.StepPlanned
+		out[i] = s
+	}
+	return out
+}
+
+func TestFileRegistry(t *testing.T) {
+	path := t.TempDir() + "/deployments.json"
+	registry, err := ethdeploy.NewFileRegistry(path)
+	require.NoError(t, err)
+	_, ok, err := registry.Get("Token")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	d := ethdeploy.Deployment{Address: common.HexToAddress("0x1234"), TxHash: common.HexToHash("0x01")}
+	require.NoError(t, registry.Put("Token", d))
+
+	registry, err = ethdeploy.NewFileRegistry(path)
+	require.NoError(t, err)
+	got, ok, err := registry.Get("Token")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, d, got)
+}
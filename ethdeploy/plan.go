@@ -0,0 +1,235 @@
+package ethdeploy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+var (
+	ErrInvalidPlan      = errors.New("ethdeploy: invalid plan")
+	ErrUnknownReference = errors.New("ethdeploy: unknown reference")
+	ErrCyclicPlan       = errors.New("ethdeploy: cyclic dependencies")
+)
+
+// DeterministicDeploymentProxy is the default CREATE2 factory of the contracts
+// with a salt, deployed at the same address on most chains. Its calldata is the
+// salt followed by the init code.
+var DeterministicDeploymentProxy = common.HexToAddress("0x4e59b44847b379578588920ca78fbf26c0b4956c")
+
+// Plan is a declarative multi-contract deployment: the contracts, the proxies in
+// front of them and the calls after their deployment. The args of the steps can
+// reference the addresses of the other contracts as "${Name}", and of the
+// implementation of a proxy as "${Name.implementation}". The steps are executed
+// in the order of their dependencies.
+type Plan struct {
+	// Factory is the CREATE2 factory of the contracts with a Salt, by default the
+	// DeterministicDeploymentProxy.
+	Factory *common.Address `json:"factory,omitempty"`
+
+	Contracts []Contract `json:"contracts"`
+	Calls     []Call     `json:"calls,omitempty"`
+}
+
+// Contract is a contract deployment of a Plan.
+type Contract struct {
+	// Name of the deployment, unique in the plan.
+	Name string `json:"name"`
+
+	// Artifact is the name of the contract in the artifacts registry, by default
+	// the Name.
+	Artifact string `json:"artifact,omitempty"`
+
+	// Args are the constructor args, as string values of ethcoder.EncodeFromStrings.
+	Args []string `json:"args,omitempty"`
+
+	// Salt optional of a CREATE2 deployment by the factory of the plan, at an
+	// address known ahead of the deployment.
+	Salt *common.Hash `json:"salt,omitempty"`
+
+	// Proxy optional in front of the contract. The address of the deployment is
+	// then the one of the proxy.
+	Proxy *Proxy `json:"proxy,omitempty"`
+
+	// DependsOn are the steps to execute before this one, besides the ones
+	// referenced in the args.
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// Proxy is the proxy of a Contract. Its args can reference the implementation as
+// "${implementation}" and the calldata of its initializer as "${init}".
+type Proxy struct {
+	Artifact string       `json:"artifact"`
+	Args     []string     `json:"args,omitempty"`
+	Salt     *common.Hash `json:"salt,omitempty"`
+
+	// Init optional initializer of the proxy, ie. "initialize(address)", and its
+	// args.
+	Init     string   `json:"init,omitempty"`
+	InitArgs []string `json:"initArgs,omitempty"`
+}
+
+// Call is a call of a Plan after the deployments, ie. to set up the roles of a
+// contract.
+type Call struct {
+	// Name of the call, unique in the plan.
+	Name string `json:"name"`
+
+	// To is the address of the contract, or a reference like "${Name}".
+	To string `json:"to"`
+
+	// Method is the method expr, ie. "grantRole(bytes32,address)", and Args its
+	// string values.
+	Method string   `json:"method"`
+	Args   []string `json:"args,omitempty"`
+
+	// Value optional in wei.
+	Value *big.Int `json:"value,omitempty"`
+
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+func ParsePlan(data []byte) (*Plan, error) {
+	var plan Plan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPlan, err)
+	}
+	return &plan, nil
+}
+
+func ParsePlanFile(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePlan(data)
+}
+
+var regexReference = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// references returns the names of the steps referenced in the values.
+func references(values ...string) []string {
+	var names []string
+	for _, v := range values {
+		for _, m := range regexReference.FindAllStringSubmatch(v, -1) {
+			name := m[1]
+			if name == "implementation" || name == "init" {
+				continue
+			}
+			names = append(names, strings.TrimSuffix(name, ".implementation"))
+		}
+	}
+	return names
+}
+
+// step is a contract or a call of a plan.
+type step struct {
+	name     string
+	contract *Contract
+	call     *Call
+	deps     []string
+}
+
+// Steps returns the names of the steps of the plan in their order of execution,
+// ie. after their dependencies and otherwise in the order of the plan.
+func (p *Plan) Steps() ([]string, error) {
+	steps, err := p.steps()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(steps))
+	for i, s := range steps {
+		names[i] = s.name
+	}
+	return names, nil
+}
+
+func (p *Plan) steps() ([]*step, error) {
+	var steps []*step
+	byName := map[string]*step{}
+	add := func(s *step) error {
+		if s.name == "" {
+			return fmt.Errorf("%w: step without a name", ErrInvalidPlan)
+		}
+		if strings.ContainsAny(s.name, "${}.") {
+			return fmt.Errorf("%w: invalid step name '%s'", ErrInvalidPlan, s.name)
+		}
+		if _, ok := byName[s.name]; ok {
+			return fmt.Errorf("%w: duplicate step '%s'", ErrInvalidPlan, s.name)
+		}
+		byName[s.name] = s
+		steps = append(steps, s)
+		return nil
+	}
+
+	for i := range p.Contracts {
+		c := &p.Contracts[i]
+		refs := references(c.Args...)
+		if c.Proxy != nil {
+			refs = append(refs, references(c.Proxy.Args...)...)
+			refs = append(refs, references(c.Proxy.InitArgs...)...)
+		}
+		if err := add(&step{name: c.Name, contract: c, deps: append(refs, c.DependsOn...)}); err != nil {
+			return nil, err
+		}
+	}
+	for i := range p.Calls {
+		c := &p.Calls[i]
+		refs := references(append([]string{c.To}, c.Args...)...)
+		if err := add(&step{name: c.Name, call: c, deps: append(refs, c.DependsOn...)}); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range steps {
+		for _, dep := range s.deps {
+			if _, ok := byName[dep]; !ok {
+				return nil, fmt.Errorf("%w '%s' in step '%s'", ErrUnknownReference, dep, s.name)
+			}
+			if dep == s.name {
+				return nil, fmt.Errorf("%w: step '%s' depends on itself", ErrCyclicPlan, s.name)
+			}
+		}
+	}
+
+	// topological sort, stable in the order of the plan
+	var sorted []*step
+	done := map[string]bool{}
+	for len(sorted) < len(steps) {
+		progress := false
+		for _, s := range steps {
+			if done[s.name] {
+				continue
+			}
+			ready := true
+			for _, dep := range s.deps {
+				if !done[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				done[s.name] = true
+				sorted = append(sorted, s)
+				progress = true
+				break
+			}
+		}
+		if !progress {
+			var pending []string
+			for _, s := range steps {
+				if !done[s.name] {
+					pending = append(pending, s.name)
+				}
+			}
+			return nil, fmt.Errorf("%w between steps %s", ErrCyclicPlan, strings.Join(pending, ", "))
+		}
+	}
+	return sorted, nil
+}
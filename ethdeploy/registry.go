@@ -0,0 +1,95 @@
+package ethdeploy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// Deployment is the record of an executed step of a plan.
+type Deployment struct {
+	Address        common.Address  `json:"address,omitempty"`
+	Implementation *common.Address `json:"implementation,omitempty"`
+	TxHash         common.Hash     `json:"txHash"`
+}
+
+// Registry records the executed steps of the plans, so that a plan executed again
+// skips them.
+type Registry interface {
+	Get(name string) (Deployment, bool, error)
+	Put(name string, deployment Deployment) error
+}
+
+type MemoryRegistry struct {
+	deployments map[string]Deployment
+	mu          sync.Mutex
+}
+
+func NewMemoryRegistry() *MemoryRegistry {
+	return &MemoryRegistry{deployments: map[string]Deployment{}}
+}
+
+func (r *MemoryRegistry) Get(name string) (Deployment, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d, ok := r.deployments[name]
+	return d, ok, nil
+}
+
+func (r *MemoryRegistry) Put(name string, deployment Deployment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deployments[name] = deployment
+	return nil
+}
+
+// FileRegistry is a Registry of a JSON file, written on every Put.
+type FileRegistry struct {
+	path        string
+	deployments map[string]Deployment
+	mu          sync.Mutex
+}
+
+// NewFileRegistry returns the registry of the file at path, which is created on
+// the first Put if it doesn't exist.
+func NewFileRegistry(path string) (*FileRegistry, error) {
+	r := &FileRegistry{path: path, deployments: map[string]Deployment{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &r.deployments); err != nil {
+		return nil, fmt.Errorf("ethdeploy: invalid registry file %s: %w", path, err)
+	}
+	return r, nil
+}
+
+func (r *FileRegistry) Get(name string) (Deployment, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d, ok := r.deployments[name]
+	return d, ok, nil
+}
+
+func (r *FileRegistry) Put(name string, deployment Deployment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deployments[name] = deployment
+
+	data, err := json.MarshalIndent(r.deployments, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.path)
+}
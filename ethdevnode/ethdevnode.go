@@ -0,0 +1,678 @@
+// Package ethdevnode is a lightweight in-process development chain which exposes
+// the standard Ethereum JSON-RPC API, with instant mining, prefunded accounts,
+// snapshot/revert and an optional fork mode on top of an upstream node.
+//
+// Transactions and calls are executed by an EVM of the cancun fork, with the
+// precompiles, and eth_estimateGas searches the lowest gas limit of a
+// successful execution. In fork mode, the code and storage of the forked
+// contracts are fetched from the upstream node at the fork block, and
+// BLOCKHASH is zero for the blocks before the fork block.
+package ethdevnode
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/ethkit/go-ethereum/params"
+	"github.com/0xsequence/ethkit/go-ethereum/rlp"
+)
+
+// DefaultMnemonic is the mnemonic of the prefunded accounts, the same as the one
+// of the ethtest testchain.
+const DefaultMnemonic = "major danger this key only test please avoid main net use okay"
+
+var (
+	ErrUnknownAccount    = errors.New("ethdevnode: unknown account")
+	ErrNonceTooLow       = errors.New("ethdevnode: nonce too low")
+	ErrNonceTooHigh      = errors.New("ethdevnode: nonce too high")
+	ErrInsufficientFunds = errors.New("ethdevnode: insufficient funds for gas * price + value")
+	ErrIntrinsicGas      = errors.New("ethdevnode: intrinsic gas too low")
+	ErrGasLimitExceeded  = errors.New("ethdevnode: gas limit exceeds the block gas limit")
+	ErrMaxInitCodeSize   = errors.New("ethdevnode: max initcode size exceeded")
+	ErrFeeCapTooLow      = errors.New("ethdevnode: max fee per gas less than block base fee")
+	ErrInvalidChainID    = errors.New("ethdevnode: invalid chain id")
+	ErrInvalidSnapshot   = errors.New("ethdevnode: invalid snapshot id")
+)
+
+var DefaultOptions = Options{
+	ChainID:     big.NewInt(1337),
+	Mnemonic:    DefaultMnemonic,
+	NumAccounts: 10,
+	Balance:     new(big.Int).Mul(big.NewInt(100_000), big.NewInt(params.Ether)),
+	GasLimit:    30_000_000,
+	BaseFee:     big.NewInt(params.GWei),
+}
+
+type Options struct {
+	// ChainID of the dev chain. In fork mode, the chain id of the upstream node
+	// is used when ChainID is nil.
+	ChainID *big.Int
+
+	// Mnemonic and NumAccounts of the prefunded accounts, derived at
+	// m/44'/60'/0'/0/{index}.
+	Mnemonic    string
+	NumAccounts int
+
+	// Balance of each prefunded account.
+	Balance *big.Int
+
+	// GasLimit of each block.
+	GasLimit uint64
+
+	// BaseFee of each block, which is burned.
+	BaseFee *big.Int
+
+	// ForkURL is the url of an upstream node to fork from. The state of the
+	// accounts unknown to the dev chain is fetched from it at ForkBlockNumber,
+	// and the blocks up to ForkBlockNumber are served by it.
+	ForkURL string
+
+	// ForkBlockNumber to fork from, or the latest block when nil.
+	ForkBlockNumber *big.Int
+}
+
+// Node is an in-process dev chain. It implements http.Handler to serve the
+// JSON-RPC API.
+type Node struct {
+	options  Options
+	chainID  *big.Int
+	signer   types.Signer
+	wallets  []*ethwallet.Wallet
+	keys     map[common.Address]*ecdsa.PrivateKey
+	upstream ethrpc.Interface
+
+	// fork is the header of the upstream block the chain is forked from, which
+	// is blocks[0] in fork mode
+	fork     *types.Header
+	forkHash common.Hash
+	forkBase map[common.Address]*account
+
+	// forkStorage is the storage of the forked contracts, fetched from the
+	// upstream node at the fork block
+	forkStorage map[common.Address]map[common.Hash]common.Hash
+
+	// blocks and states at each local block, where states[i] is the state after
+	// blocks[i]. States are copy-on-write, accounts are never mutated in place.
+	blocks      []*types.Block
+	states      []map[common.Address]*account
+	receipts    map[common.Hash]*types.Receipt
+	txs         map[common.Hash]*types.Transaction
+	blockHashes map[common.Hash]*types.Block
+
+	snapshots []uint64
+
+	mu sync.Mutex
+}
+
+type account struct {
+	balance *big.Int
+	nonce   uint64
+	code    []byte
+
+	// storage of the account, of the slots written locally
+	storage map[common.Hash]common.Hash
+
+	// forked is true if the account is fetched from the upstream node, of which
+	// the slots not written locally are fetched from the upstream too
+	forked bool
+}
+
+func (a *account) copy() *account {
+	return &account{
+		balance: new(big.Int).Set(a.balance),
+		nonce:   a.nonce,
+		code:    a.code,
+		storage: maps.Clone(a.storage),
+		forked:  a.forked,
+	}
+}
+
+// NewNode creates a dev chain with a mined genesis block, or forked from the
+// upstream node when Options.ForkURL is set.
+func NewNode(ctx context.Context, opts ...Options) (*Node, error) {
+	options := DefaultOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	if options.Mnemonic == "" {
+		options.Mnemonic = DefaultOptions.Mnemonic
+	}
+	if options.Balance == nil {
+		options.Balance = DefaultOptions.Balance
+	}
+	if options.GasLimit == 0 {
+		options.GasLimit = DefaultOptions.GasLimit
+	}
+	if options.BaseFee == nil {
+		options.BaseFee = DefaultOptions.BaseFee
+	}
+
+	n := &Node{
+		options:     options,
+		chainID:     options.ChainID,
+		keys:        map[common.Address]*ecdsa.PrivateKey{},
+		receipts:    map[common.Hash]*types.Receipt{},
+		txs:         map[common.Hash]*types.Transaction{},
+		blockHashes: map[common.Hash]*types.Block{},
+	}
+
+	if options.ForkURL != "" {
+		if err := n.initFork(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if n.chainID == nil {
+		n.chainID = DefaultOptions.ChainID
+	}
+	n.signer = types.LatestSignerForChainID(n.chainID)
+
+	wallet, err := ethwallet.NewWalletFromMnemonic(options.Mnemonic)
+	if err != nil {
+		return nil, fmt.Errorf("ethdevnode: invalid mnemonic: %w", err)
+	}
+	genesis := map[common.Address]*account{}
+	for i := 0; i < options.NumAccounts; i++ {
+		w, address, err := wallet.DeriveAccountIndex(uint32(i))
+		if err != nil {
+			return nil, fmt.Errorf("ethdevnode: failed to derive account %d: %w", i, err)
+		}
+		n.wallets = append(n.wallets, w)
+		n.keys[address] = w.PrivateKey()
+		genesis[address] = &account{balance: new(big.Int).Set(options.Balance)}
+	}
+
+	if n.fork != nil {
+		// blocks[0] stands for the fork block, which is served by the upstream
+		block := types.NewBlockWithHeader(n.fork)
+		block.SetHash(n.forkHash)
+		n.blocks = []*types.Block{block}
+		n.states = []map[common.Address]*account{genesis}
+	} else {
+		n.states = []map[common.Address]*account{genesis}
+		n.blocks = []*types.Block{n.newBlock(n.nextHeader(nil), nil, nil, genesis)}
+		n.blockHashes[n.blocks[0].Hash()] = n.blocks[0]
+	}
+
+	return n, nil
+}
+
+func (n *Node) initFork(ctx context.Context) error {
+	provider, err := ethrpc.NewProvider(n.options.ForkURL)
+	if err != nil {
+		return fmt.Errorf("ethdevnode: invalid fork url: %w", err)
+	}
+
+	var chainID *big.Int
+	var raw json.RawMessage
+	_, err = provider.Do(ctx,
+		ethrpc.ChainID().Into(&chainID),
+		ethrpc.RawBlockByNumber(n.options.ForkBlockNumber).Into(&raw),
+	)
+	if err != nil {
+		return fmt.Errorf("ethdevnode: failed to fetch the fork block: %w", err)
+	}
+
+	var header *types.Header
+	var hash struct {
+		Hash common.Hash `json:"hash"`
+	}
+	if err := json.Unmarshal(raw, &header); err != nil || header == nil {
+		return fmt.Errorf("ethdevnode: failed to decode the fork block: %v", err)
+	}
+	if err := json.Unmarshal(raw, &hash); err != nil {
+		return fmt.Errorf("ethdevnode: failed to decode the fork block: %w", err)
+	}
+
+	if n.chainID == nil {
+		n.chainID = chainID
+	}
+	n.upstream = provider
+	n.fork = header
+	n.forkHash = hash.Hash
+	n.forkBase = map[common.Address]*account{}
+	n.forkStorage = map[common.Address]map[common.Hash]common.Hash{}
+	return nil
+}
+
+// ChainID returns the chain id of the dev chain.
+func (n *Node) ChainID() *big.Int {
+	return new(big.Int).Set(n.chainID)
+}
+
+// Accounts returns the wallets of the prefunded accounts.
+func (n *Node) Accounts() []*ethwallet.Wallet {
+	return n.wallets
+}
+
+// BlockNumber returns the number of the head block.
+func (n *Node) BlockNumber() uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.head().NumberU64()
+}
+
+// Mine mines an empty block.
+func (n *Node) Mine() *types.Block {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.mine(n.nextHeader(n.head()), nil, nil, n.states[len(n.states)-1])
+}
+
+// Snapshot returns the id of a snapshot of the chain at its current head.
+func (n *Node) Snapshot() uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.snapshots = append(n.snapshots, n.head().NumberU64())
+	return uint64(len(n.snapshots))
+}
+
+// Revert reverts the chain to the snapshot, removing all blocks mined after it.
+// The snapshot and all the snapshots taken after it are no longer valid.
+func (n *Node) Revert(id uint64) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if id == 0 || id > uint64(len(n.snapshots)) {
+		return ErrInvalidSnapshot
+	}
+	height := n.snapshots[id-1]
+	n.snapshots = n.snapshots[:id-1]
+
+	keep := int(height-n.blocks[0].NumberU64()) + 1
+	for _, block := range n.blocks[keep:] {
+		delete(n.blockHashes, block.Hash())
+		for _, tx := range block.Transactions() {
+			delete(n.txs, tx.Hash())
+			delete(n.receipts, tx.Hash())
+		}
+	}
+	n.blocks = n.blocks[:keep]
+	n.states = n.states[:keep]
+	return nil
+}
+
+// SendTransaction validates the signed transaction, executes it and mines it in
+// a new block. A transaction which fails in execution, ie. of a revert, is
+// mined with a failed receipt.
+func (n *Node) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if tx.Protected() && tx.ChainId().Cmp(n.chainID) != 0 {
+		return ErrInvalidChainID
+	}
+	sender, err := types.Sender(n.signer, tx)
+	if err != nil {
+		return fmt.Errorf("ethdevnode: invalid transaction signature: %w", err)
+	}
+
+	state := newTxState(ctx, n, n.states[len(n.states)-1])
+	nonce := state.nonce(sender)
+	if state.err != nil {
+		return state.err
+	}
+	if tx.Gas() > n.options.GasLimit {
+		return ErrGasLimitExceeded
+	}
+	if tx.GasFeeCap().Cmp(n.options.BaseFee) < 0 {
+		return ErrFeeCapTooLow
+	}
+	if tx.Nonce() < nonce {
+		return ErrNonceTooLow
+	}
+	if tx.Nonce() > nonce {
+		return ErrNonceTooHigh
+	}
+
+	maxCost := new(big.Int).Mul(new(big.Int).SetUint64(tx.Gas()), tx.GasFeeCap())
+	maxCost.Add(maxCost, tx.Value())
+	if state.balance(sender).Cmp(maxCost) < 0 {
+		return ErrInsufficientFunds
+	}
+
+	gasPrice := new(big.Int).Add(n.options.BaseFee, tx.GasTipCap())
+	if gasPrice.Cmp(tx.GasFeeCap()) > 0 {
+		gasPrice.Set(tx.GasFeeCap())
+	}
+
+	header := n.nextHeader(n.head())
+	result, err := n.applyMessage(state, header, callMessage{
+		from:       sender,
+		to:         tx.To(),
+		gas:        tx.Gas(),
+		gasPrice:   gasPrice,
+		value:      tx.Value(),
+		data:       tx.Data(),
+		accessList: tx.AccessList(),
+	})
+	if err != nil {
+		return err
+	}
+
+	receipt := &types.Receipt{
+		Type:              tx.Type(),
+		Status:            types.ReceiptStatusSuccessful,
+		CumulativeGasUsed: result.gasUsed,
+		Logs:              state.logs,
+		TxHash:            tx.Hash(),
+		GasUsed:           result.gasUsed,
+		EffectiveGasPrice: gasPrice,
+	}
+	if result.err != nil {
+		receipt.Status = types.ReceiptStatusFailed
+	}
+	if tx.To() == nil {
+		receipt.ContractAddress = crypto.CreateAddress(sender, tx.Nonce())
+	}
+	if receipt.Logs == nil {
+		receipt.Logs = []*types.Log{}
+	}
+	receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+	n.mine(header, types.Transactions{tx}, []*types.Receipt{receipt}, state.commit())
+	return nil
+}
+
+// IntrinsicGas returns the gas used by a value transfer with the calldata and
+// access list.
+func IntrinsicGas(data []byte, accessList types.AccessList) uint64 {
+	gas := params.TxGas
+	for _, b := range data {
+		if b == 0 {
+			gas += params.TxDataZeroGas
+		} else {
+			gas += params.TxDataNonZeroGasEIP2028
+		}
+	}
+	gas += uint64(len(accessList)) * params.TxAccessListAddressGas
+	gas += uint64(accessList.StorageKeys()) * params.TxAccessListStorageKeyGas
+	return gas
+}
+
+// intrinsicGas returns the intrinsic gas of the message, ie. of IntrinsicGas
+// and of the creation of a contract as of EIP-3860.
+func intrinsicGas(msg callMessage) uint64 {
+	gas := IntrinsicGas(msg.data, msg.accessList)
+	if msg.to == nil {
+		gas += params.TxGasContractCreation - params.TxGas
+		gas += toWords(uint64(len(msg.data))) * params.InitCodeWordGas
+	}
+	return gas
+}
+
+// callMessage is a transaction, or the call of eth_call and eth_estimateGas.
+type callMessage struct {
+	from       common.Address
+	to         *common.Address
+	gas        uint64
+	gasPrice   *big.Int
+	value      *big.Int
+	data       []byte
+	accessList types.AccessList
+}
+
+// executionResult is the result of the execution of a callMessage, of which err
+// is the error of the execution, ie. errExecutionReverted, and ret is the
+// output or the revert data.
+type executionResult struct {
+	gasUsed uint64
+	ret     []byte
+	err     error
+}
+
+// applyMessage buys the gas of the message, executes it in the block of the
+// header and refunds the gas left. It returns an error if the message can't be
+// executed, and must be called with the lock held.
+func (n *Node) applyMessage(state *txState, header *types.Header, msg callMessage) (*executionResult, error) {
+	gas := intrinsicGas(msg)
+	if msg.gas < gas {
+		return nil, ErrIntrinsicGas
+	}
+	if msg.to == nil && len(msg.data) > params.MaxInitCodeSize {
+		return nil, ErrMaxInitCodeSize
+	}
+
+	cost := new(big.Int).Mul(new(big.Int).SetUint64(msg.gas), msg.gasPrice)
+	if state.balance(msg.from).Cmp(new(big.Int).Add(cost, msg.value)) < 0 {
+		return nil, ErrInsufficientFunds
+	}
+	state.subBalance(msg.from, cost)
+
+	// warm the accounts and slots of EIP-2929, EIP-2930 and EIP-3651
+	state.warmAddress(msg.from)
+	state.warmAddress(header.Coinbase)
+	for address := range precompiles {
+		state.warmAddress(address)
+	}
+	for _, tuple := range msg.accessList {
+		state.warmAddress(tuple.Address)
+		for _, slot := range tuple.StorageKeys {
+			state.warmSlot(tuple.Address, slot)
+		}
+	}
+
+	e := &evm{
+		state: state,
+		block: blockContext{
+			number:   header.Number.Uint64(),
+			time:     header.Time,
+			coinbase: header.Coinbase,
+			gasLimit: header.GasLimit,
+			baseFee:  header.BaseFee,
+			random:   header.MixDigest,
+		},
+		chainID:   n.chainID,
+		origin:    msg.from,
+		gasPrice:  msg.gasPrice,
+		blockHash: n.blockHash,
+	}
+
+	var ret []byte
+	var gasLeft uint64
+	var err error
+	if msg.to == nil {
+		address := crypto.CreateAddress(msg.from, state.nonce(msg.from))
+		ret, gasLeft, err = e.create(msg.from, msg.data, msg.gas-gas, msg.value, address)
+	} else {
+		state.setNonce(msg.from, state.nonce(msg.from)+1)
+		state.warmAddress(*msg.to)
+		ret, gasLeft, err = e.call(opCall, message{
+			caller:      msg.from,
+			address:     *msg.to,
+			codeAddress: *msg.to,
+			value:       msg.value,
+			input:       msg.data,
+			gas:         msg.gas - gas,
+		})
+	}
+	if state.err != nil {
+		return nil, state.err
+	}
+
+	// the refund is capped to a fifth of the gas used, as of EIP-3529
+	gasUsed := msg.gas - gasLeft
+	refund := min(state.refund, gasUsed/params.RefundQuotientEIP3529)
+	gasUsed -= refund
+	gasLeft += refund
+	state.addBalance(msg.from, new(big.Int).Mul(new(big.Int).SetUint64(gasLeft), msg.gasPrice))
+
+	if tip := new(big.Int).Sub(msg.gasPrice, header.BaseFee); tip.Sign() > 0 {
+		state.addBalance(header.Coinbase, tip.Mul(tip, new(big.Int).SetUint64(gasUsed)))
+	}
+	return &executionResult{gasUsed: gasUsed, ret: ret, err: err}, nil
+}
+
+// blockHash returns the hash of a local block, or the zero hash of the blocks
+// before the fork block. It must be called with the lock held.
+func (n *Node) blockHash(number uint64) common.Hash {
+	first := n.blocks[0].NumberU64()
+	if number < first || number > n.head().NumberU64() {
+		return common.Hash{}
+	}
+	return n.blocks[number-first].Hash()
+}
+
+func (n *Node) head() *types.Block {
+	return n.blocks[len(n.blocks)-1]
+}
+
+// nextHeader returns the header of the block after the parent, of which the
+// root and gas used are set by newBlock.
+func (n *Node) nextHeader(parent *types.Block) *types.Header {
+	header := &types.Header{
+		UncleHash:  types.EmptyUncleHash,
+		Difficulty: new(big.Int),
+		Number:     new(big.Int),
+		GasLimit:   n.options.GasLimit,
+		Time:       uint64(time.Now().Unix()),
+		BaseFee:    new(big.Int).Set(n.options.BaseFee),
+	}
+	if parent != nil {
+		header.ParentHash = parent.Hash()
+		header.Number.SetUint64(parent.NumberU64() + 1)
+		if header.Time <= parent.Time() {
+			header.Time = parent.Time() + 1
+		}
+	}
+	return header
+}
+
+// mine must be called with the lock held.
+func (n *Node) mine(header *types.Header, txs types.Transactions, receipts []*types.Receipt, state map[common.Address]*account) *types.Block {
+	block := n.newBlock(header, txs, receipts, state)
+
+	var logIndex uint
+	for i, receipt := range receipts {
+		receipt.BlockHash = block.Hash()
+		receipt.BlockNumber = block.Number()
+		receipt.TransactionIndex = uint(i)
+		for _, log := range receipt.Logs {
+			log.BlockNumber = block.NumberU64()
+			log.BlockHash = block.Hash()
+			log.TxHash = receipt.TxHash
+			log.TxIndex = uint(i)
+			log.Index = logIndex
+			logIndex++
+		}
+		n.receipts[receipt.TxHash] = receipt
+	}
+	for _, tx := range txs {
+		n.txs[tx.Hash()] = tx
+	}
+	n.blocks = append(n.blocks, block)
+	n.states = append(n.states, state)
+	n.blockHashes[block.Hash()] = block
+	return block
+}
+
+func (n *Node) newBlock(header *types.Header, txs types.Transactions, receipts []*types.Receipt, state map[common.Address]*account) *types.Block {
+	header.Root = stateRoot(state)
+	for _, receipt := range receipts {
+		header.GasUsed += receipt.GasUsed
+	}
+	header.Bloom = types.CreateBloom(receipts)
+	return types.NewBlock(header, &types.Body{Transactions: txs}, receipts, &listHasher{})
+}
+
+// account returns the account in state, or from the upstream node at the fork
+// block. It must be called with the lock held.
+func (n *Node) account(ctx context.Context, state map[common.Address]*account, address common.Address) (*account, error) {
+	if acc, ok := state[address]; ok {
+		return acc, nil
+	}
+	if n.fork == nil {
+		return &account{balance: new(big.Int)}, nil
+	}
+	if acc, ok := n.forkBase[address]; ok {
+		return acc, nil
+	}
+
+	acc := &account{forked: true}
+	_, err := n.upstream.Do(ctx,
+		ethrpc.BalanceAt(address, n.fork.Number).Into(&acc.balance),
+		ethrpc.NonceAt(address, n.fork.Number).Into(&acc.nonce),
+		ethrpc.CodeAt(address, n.fork.Number).Into(&acc.code),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ethdevnode: failed to fetch account %s from the upstream: %w", address, err)
+	}
+	n.forkBase[address] = acc
+	return acc, nil
+}
+
+// stateRoot is a commitment to the accounts of the dev chain. It is not the
+// root of a merkle patricia trie.
+func stateRoot(state map[common.Address]*account) common.Hash {
+	addresses := make([]common.Address, 0, len(state))
+	for address := range state {
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return addresses[i].Cmp(addresses[j]) < 0
+	})
+
+	hasher := crypto.NewKeccakState()
+	for _, address := range addresses {
+		acc := state[address]
+		data, _ := rlp.EncodeToBytes([]interface{}{address, acc.balance, acc.nonce, crypto.Keccak256(acc.code), storageRoot(acc.storage)})
+		hasher.Write(data)
+	}
+	var root common.Hash
+	hasher.Read(root[:])
+	return root
+}
+
+// storageRoot is a commitment to the non-zero slots of the storage.
+func storageRoot(storage map[common.Hash]common.Hash) common.Hash {
+	slots := make([]common.Hash, 0, len(storage))
+	for slot, value := range storage {
+		if value != (common.Hash{}) {
+			slots = append(slots, slot)
+		}
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		return slots[i].Cmp(slots[j]) < 0
+	})
+
+	hasher := crypto.NewKeccakState()
+	for _, slot := range slots {
+		value := storage[slot]
+		hasher.Write(slot[:])
+		hasher.Write(value[:])
+	}
+	var root common.Hash
+	hasher.Read(root[:])
+	return root
+}
+
+// listHasher implements types.TrieHasher as the keccak256 of the list items, in
+// place of the merkle patricia trie which is not part of ethkit.
+type listHasher struct {
+	data []byte
+}
+
+func (h *listHasher) Reset() {
+	h.data = h.data[:0]
+}
+
+func (h *listHasher) Update(key, value []byte) error {
+	h.data = append(h.data, key...)
+	h.data = append(h.data, value...)
+	return nil
+}
+
+func (h *listHasher) Hash() common.Hash {
+	return crypto.Keccak256Hash(h.data)
+}
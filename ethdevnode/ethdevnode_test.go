@@ -0,0 +1,306 @@
+package ethdevnode_test
+
+import (
+	"context"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethdevnode"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethtest"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestNode(t *testing.T, opts ...ethdevnode.Options) (*ethdevnode.Node, *ethrpc.Provider, string) {
+	node, err := ethdevnode.NewNode(context.Background(), opts...)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+
+	provider, err := ethrpc.NewProvider(server.URL)
+	require.NoError(t, err)
+	return node, provider, server.URL
+}
+
+func TestNodeTransfer(t *testing.T) {
+	node, provider, _ := newTestNode(t)
+	ctx := context.Background()
+
+	chainID, err := provider.ChainID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(1337), chainID)
+
+	accounts := node.Accounts()
+	require.Len(t, accounts, 10)
+	assert.Equal(t, "0xB5c3023dbEcE7a6Bb78014000CD1C8ce940B50a0", accounts[0].Address().Hex())
+
+	balance, err := provider.BalanceAt(ctx, accounts[0].Address(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, ethdevnode.DefaultOptions.Balance, balance)
+
+	wallet := accounts[0]
+	wallet.SetProvider(provider)
+	to := common.HexToAddress("0x1234567890123456789012345678901234567890")
+
+	txn, err := wallet.NewTransaction(ctx, &ethtxn.TransactionRequest{To: &to, ETHValue: big.NewInt(1000)})
+	require.NoError(t, err)
+	_, waitReceipt, err := wallet.SendTransaction(ctx, txn)
+	require.NoError(t, err)
+
+	receipt, err := waitReceipt(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), receipt.Status)
+	assert.Equal(t, uint64(21000), receipt.GasUsed)
+	assert.Equal(t, big.NewInt(1), receipt.BlockNumber)
+
+	block, err := provider.BlockByNumber(ctx, receipt.BlockNumber)
+	require.NoError(t, err)
+	assert.Equal(t, receipt.BlockHash, block.Hash())
+	require.Len(t, block.Transactions(), 1)
+	assert.Equal(t, txn.Hash(), block.Transactions()[0].Hash())
+
+	blockReceipts, err := provider.BlockReceipts(ctx, block.Hash())
+	require.NoError(t, err)
+	require.Len(t, blockReceipts, 1)
+	assert.Equal(t, receipt.TxHash, blockReceipts[0].TxHash)
+	assert.Equal(t, receipt.GasUsed, blockReceipts[0].GasUsed)
+
+	fetched, pending, err := provider.TransactionByHash(ctx, txn.Hash())
+	require.NoError(t, err)
+	assert.False(t, pending)
+	assert.Equal(t, txn.Hash(), fetched.Hash())
+
+	balance, err = provider.BalanceAt(ctx, to, nil)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(1000), balance)
+
+	// the state at the genesis block is kept
+	balance, err = provider.BalanceAt(ctx, to, big.NewInt(0))
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), balance.Int64())
+
+	nonce, err := provider.NonceAt(ctx, wallet.Address(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), nonce)
+
+	// replaying the transaction fails
+	_, _, err = wallet.SendTransaction(ctx, txn)
+	assert.ErrorContains(t, err, ethdevnode.ErrNonceTooLow.Error())
+}
+
+func TestNodeContract(t *testing.T) {
+	node, provider, _ := newTestNode(t)
+	ctx := context.Background()
+
+	wallet := node.Accounts()[0]
+	wallet.SetProvider(provider)
+	erc20 := ethtest.Contracts.MustGet("ERC20Mock")
+
+	txn, err := wallet.NewTransaction(ctx, &ethtxn.TransactionRequest{Data: erc20.Bin})
+	require.NoError(t, err)
+	_, waitReceipt, err := wallet.SendTransaction(ctx, txn)
+	require.NoError(t, err)
+	receipt, err := waitReceipt(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), receipt.Status)
+	assert.Equal(t, crypto.CreateAddress(wallet.Address(), 0), receipt.ContractAddress)
+
+	contract := receipt.ContractAddress
+	code, err := provider.CodeAt(ctx, contract, nil)
+	require.NoError(t, err)
+	assert.Equal(t, erc20.DeployedBin, code)
+
+	to := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	receipt, err = ethtest.ContractTransact(wallet, contract, erc20.ABI, "mockMint", wallet.Address(), big.NewInt(100))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), receipt.Status)
+	receipt, err = ethtest.ContractTransact(wallet, contract, erc20.ABI, "transfer", to, big.NewInt(40))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), receipt.Status)
+	require.Len(t, receipt.Logs, 1)
+	assert.Equal(t, contract, receipt.Logs[0].Address)
+	assert.Equal(t, receipt.TxHash, receipt.Logs[0].TxHash)
+
+	var balance *big.Int
+	_, err = ethtest.ContractCall(provider, contract, erc20.ABI, &balance, "balanceOf", to)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(40), balance)
+
+	// the total supply is at slot 2
+	supply, err := provider.StorageAt(ctx, contract, common.BigToHash(big.NewInt(2)), nil)
+	require.NoError(t, err)
+	assert.Equal(t, common.BigToHash(big.NewInt(100)).Bytes(), supply)
+
+	// the logs of the mint and the transfer
+	logs, err := provider.FilterLogs(ctx, ethereum.FilterQuery{Addresses: []common.Address{contract}})
+	require.NoError(t, err)
+	require.Len(t, logs, 2)
+	assert.Equal(t, big.NewInt(2), new(big.Int).SetUint64(logs[0].BlockNumber))
+	logs, err = provider.FilterLogs(ctx, ethereum.FilterQuery{
+		Addresses: []common.Address{contract},
+		Topics:    [][]common.Hash{nil, nil, {common.BytesToHash(to.Bytes())}},
+	})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, receipt.TxHash, logs[0].TxHash)
+
+	// a revert is of the reason and the revert data
+	calldata, err := erc20.Encode("transfer", to, big.NewInt(1000))
+	require.NoError(t, err)
+	_, err = provider.CallContract(ctx, ethereum.CallMsg{From: wallet.Address(), To: &contract, Data: calldata}, nil)
+	assert.ErrorContains(t, err, "execution reverted: SafeMath#sub: UNDERFLOW")
+	_, err = provider.EstimateGas(ctx, ethereum.CallMsg{From: wallet.Address(), To: &contract, Data: calldata})
+	assert.ErrorContains(t, err, "execution reverted")
+
+	// the estimate is the lowest gas limit of a successful transfer
+	calldata, err = erc20.Encode("transfer", to, big.NewInt(1))
+	require.NoError(t, err)
+	gas, err := provider.EstimateGas(ctx, ethereum.CallMsg{From: wallet.Address(), To: &contract, Data: calldata})
+	require.NoError(t, err)
+	_, err = provider.EstimateGas(ctx, ethereum.CallMsg{From: wallet.Address(), To: &contract, Data: calldata, Gas: gas - 1})
+	assert.ErrorContains(t, err, "gas required exceeds allowance")
+
+	txn, err = wallet.NewTransaction(ctx, &ethtxn.TransactionRequest{To: &contract, Data: calldata, GasLimit: gas})
+	require.NoError(t, err)
+	_, waitReceipt, err = wallet.SendTransaction(ctx, txn)
+	require.NoError(t, err)
+	receipt, err = waitReceipt(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), receipt.Status)
+	assert.LessOrEqual(t, receipt.GasUsed, gas)
+}
+
+func TestNodeSnapshotRevert(t *testing.T) {
+	node, provider, _ := newTestNode(t)
+	ctx := context.Background()
+
+	from, to := node.Accounts()[0].Address(), node.Accounts()[1].Address()
+
+	var snapshotID hexutil.Uint64
+	_, err := provider.Do(ctx, ethrpc.NewCallBuilder[hexutil.Uint64]("evm_snapshot", nil).Into(&snapshotID))
+	require.NoError(t, err)
+
+	var txnHash common.Hash
+	_, err = provider.Do(ctx, ethrpc.NewCallBuilder[common.Hash]("eth_sendTransaction", nil, map[string]any{
+		"from":  from,
+		"to":    to,
+		"value": hexutil.Big(*big.NewInt(1)),
+	}).Into(&txnHash))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), node.BlockNumber())
+
+	receipt, err := provider.TransactionReceipt(ctx, txnHash)
+	require.NoError(t, err)
+	assert.Equal(t, txnHash, receipt.TxHash)
+
+	var reverted bool
+	_, err = provider.Do(ctx, ethrpc.NewCallBuilder[bool]("evm_revert", nil, snapshotID).Into(&reverted))
+	require.NoError(t, err)
+	assert.True(t, reverted)
+	assert.Equal(t, uint64(0), node.BlockNumber())
+
+	_, err = provider.TransactionReceipt(ctx, txnHash)
+	assert.ErrorIs(t, err, ethereum.NotFound)
+
+	balance, err := provider.BalanceAt(ctx, to, nil)
+	require.NoError(t, err)
+	assert.Equal(t, ethdevnode.DefaultOptions.Balance, balance)
+
+	// the snapshot can't be reverted to twice
+	assert.ErrorIs(t, node.Revert(uint64(snapshotID)), ethdevnode.ErrInvalidSnapshot)
+}
+
+func TestNodeFork(t *testing.T) {
+	upstream, upstreamProvider, upstreamURL := newTestNode(t)
+	ctx := context.Background()
+	upstream.Mine()
+	upstream.Mine()
+
+	node, provider, _ := newTestNode(t, ethdevnode.Options{
+		ForkURL:         upstreamURL,
+		ForkBlockNumber: big.NewInt(1),
+		NumAccounts:     1,
+		Mnemonic:        "test test test test test test test test test test test junk",
+	})
+	assert.Equal(t, big.NewInt(1337), node.ChainID())
+	assert.Equal(t, uint64(1), node.BlockNumber())
+
+	// blocks up to the fork block are served by the upstream
+	forkBlock, err := upstreamProvider.BlockByNumber(ctx, big.NewInt(1))
+	require.NoError(t, err)
+	block, err := provider.BlockByNumber(ctx, big.NewInt(1))
+	require.NoError(t, err)
+	assert.Equal(t, forkBlock.Hash(), block.Hash())
+
+	// the state of the upstream accounts is forked
+	balance, err := provider.BalanceAt(ctx, upstream.Accounts()[0].Address(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, ethdevnode.DefaultOptions.Balance, balance)
+
+	wallet := node.Accounts()[0]
+	wallet.SetProvider(provider)
+	to := common.HexToAddress("0x1234567890123456789012345678901234567890")
+
+	txn, err := wallet.NewTransaction(ctx, &ethtxn.TransactionRequest{To: &to, ETHValue: big.NewInt(5)})
+	require.NoError(t, err)
+	_, waitReceipt, err := wallet.SendTransaction(ctx, txn)
+	require.NoError(t, err)
+	receipt, err := waitReceipt(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(2), receipt.BlockNumber)
+
+	block, err = provider.BlockByNumber(ctx, big.NewInt(2))
+	require.NoError(t, err)
+	assert.Equal(t, forkBlock.Hash(), block.ParentHash())
+}
+
+func TestNodeForkContract(t *testing.T) {
+	upstream, upstreamProvider, upstreamURL := newTestNode(t)
+	ctx := context.Background()
+
+	owner := upstream.Accounts()[0]
+	owner.SetProvider(upstreamProvider)
+	erc20 := ethtest.Contracts.MustGet("ERC20Mock")
+
+	txn, err := owner.NewTransaction(ctx, &ethtxn.TransactionRequest{Data: erc20.Bin})
+	require.NoError(t, err)
+	_, waitReceipt, err := owner.SendTransaction(ctx, txn)
+	require.NoError(t, err)
+	receipt, err := waitReceipt(ctx)
+	require.NoError(t, err)
+	contract := receipt.ContractAddress
+	_, err = ethtest.ContractTransact(owner, contract, erc20.ABI, "mockMint", owner.Address(), big.NewInt(100))
+	require.NoError(t, err)
+
+	node, provider, _ := newTestNode(t, ethdevnode.Options{ForkURL: upstreamURL, NumAccounts: 1})
+	assert.Equal(t, uint64(2), node.BlockNumber())
+
+	// the storage of the forked contract is fetched from the upstream
+	var balance *big.Int
+	_, err = ethtest.ContractCall(provider, contract, erc20.ABI, &balance, "balanceOf", owner.Address())
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(100), balance)
+
+	owner.SetProvider(provider)
+	to := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	receipt, err = ethtest.ContractTransact(owner, contract, erc20.ABI, "transfer", to, big.NewInt(30))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), receipt.Status)
+
+	_, err = ethtest.ContractCall(provider, contract, erc20.ABI, &balance, "balanceOf", owner.Address())
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(70), balance)
+
+	// the upstream is left as is
+	_, err = ethtest.ContractCall(upstreamProvider, contract, erc20.ABI, &balance, "balanceOf", owner.Address())
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(100), balance)
+}
@@ -0,0 +1,1090 @@
+package ethdevnode
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/ethkit/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// The errors of the execution of a call frame. All of them but
+// errExecutionReverted consume the gas of the frame.
+var (
+	errExecutionReverted        = errors.New("execution reverted")
+	errOutOfGas                 = errors.New("out of gas")
+	errStackUnderflow           = errors.New("stack underflow")
+	errStackOverflow            = errors.New("stack limit reached 1024")
+	errInvalidJump              = errors.New("invalid jump destination")
+	errWriteProtection          = errors.New("write protection")
+	errReturnDataOutOfBounds    = errors.New("return data out of bounds")
+	errDepth                    = errors.New("max call depth exceeded")
+	errInsufficientBalance      = errors.New("insufficient balance for transfer")
+	errContractAddressCollision = errors.New("contract address collision")
+	errMaxCodeSize              = errors.New("max code size exceeded")
+	errMaxInitCodeSize          = errors.New("max initcode size exceeded")
+	errInvalidCode              = errors.New("invalid code: must not begin with 0xef")
+	errNonceMax                 = errors.New("nonce uint64 overflow")
+)
+
+// The opcodes of the cancun fork, of their gas and stack.
+const (
+	opStop           = 0x00
+	opAdd            = 0x01
+	opMul            = 0x02
+	opSub            = 0x03
+	opDiv            = 0x04
+	opSDiv           = 0x05
+	opMod            = 0x06
+	opSMod           = 0x07
+	opAddMod         = 0x08
+	opMulMod         = 0x09
+	opExp            = 0x0a
+	opSignExtend     = 0x0b
+	opLt             = 0x10
+	opGt             = 0x11
+	opSlt            = 0x12
+	opSgt            = 0x13
+	opEq             = 0x14
+	opIsZero         = 0x15
+	opAnd            = 0x16
+	opOr             = 0x17
+	opXor            = 0x18
+	opNot            = 0x19
+	opByte           = 0x1a
+	opShl            = 0x1b
+	opShr            = 0x1c
+	opSar            = 0x1d
+	opKeccak256      = 0x20
+	opAddress        = 0x30
+	opBalance        = 0x31
+	opOrigin         = 0x32
+	opCaller         = 0x33
+	opCallValue      = 0x34
+	opCallDataLoad   = 0x35
+	opCallDataSize   = 0x36
+	opCallDataCopy   = 0x37
+	opCodeSize       = 0x38
+	opCodeCopy       = 0x39
+	opGasPrice       = 0x3a
+	opExtCodeSize    = 0x3b
+	opExtCodeCopy    = 0x3c
+	opReturnDataSize = 0x3d
+	opReturnDataCopy = 0x3e
+	opExtCodeHash    = 0x3f
+	opBlockHash      = 0x40
+	opCoinbase       = 0x41
+	opTimestamp      = 0x42
+	opNumber         = 0x43
+	opPrevRandao     = 0x44
+	opGasLimit       = 0x45
+	opChainID        = 0x46
+	opSelfBalance    = 0x47
+	opBaseFee        = 0x48
+	opBlobHash       = 0x49
+	opBlobBaseFee    = 0x4a
+	opPop            = 0x50
+	opMLoad          = 0x51
+	opMStore         = 0x52
+	opMStore8        = 0x53
+	opSLoad          = 0x54
+	opSStore         = 0x55
+	opJump           = 0x56
+	opJumpI          = 0x57
+	opPC             = 0x58
+	opMSize          = 0x59
+	opGas            = 0x5a
+	opJumpDest       = 0x5b
+	opTLoad          = 0x5c
+	opTStore         = 0x5d
+	opMCopy          = 0x5e
+	opPush0          = 0x5f
+	opPush1          = 0x60
+	opPush32         = 0x7f
+	opDup1           = 0x80
+	opDup16          = 0x8f
+	opSwap1          = 0x90
+	opSwap16         = 0x9f
+	opLog0           = 0xa0
+	opLog4           = 0xa4
+	opCreate         = 0xf0
+	opCall           = 0xf1
+	opCallCode       = 0xf2
+	opReturn         = 0xf3
+	opDelegateCall   = 0xf4
+	opCreate2        = 0xf5
+	opStaticCall     = 0xfa
+	opRevert         = 0xfd
+	opInvalid        = 0xfe
+	opSelfDestruct   = 0xff
+)
+
+type instruction struct {
+	gas    uint64
+	pops   int
+	pushes int
+	writes bool
+	valid  bool
+}
+
+var instructions [256]instruction
+
+func init() {
+	set := func(gas uint64, pops, pushes int, ops ...byte) {
+		for _, op := range ops {
+			instructions[op] = instruction{gas: gas, pops: pops, pushes: pushes, valid: true}
+		}
+	}
+	set(0, 0, 0, opStop)
+	set(3, 2, 1, opAdd, opSub, opLt, opGt, opSlt, opSgt, opEq, opAnd, opOr, opXor, opByte, opShl, opShr, opSar)
+	set(5, 2, 1, opMul, opDiv, opSDiv, opMod, opSMod, opSignExtend)
+	set(8, 3, 1, opAddMod, opMulMod)
+	set(params.ExpGas, 2, 1, opExp)
+	set(3, 1, 1, opIsZero, opNot, opCallDataLoad)
+	set(params.Keccak256Gas, 2, 1, opKeccak256)
+	set(2, 0, 1, opAddress, opOrigin, opCaller, opCallValue, opCallDataSize, opCodeSize, opGasPrice,
+		opReturnDataSize, opCoinbase, opTimestamp, opNumber, opPrevRandao, opGasLimit, opChainID, opBaseFee,
+		opBlobBaseFee, opPC, opMSize, opGas, opPush0)
+	set(params.WarmStorageReadCostEIP2929, 1, 1, opBalance, opExtCodeSize, opExtCodeHash)
+	set(3, 3, 0, opCallDataCopy, opCodeCopy, opReturnDataCopy, opMCopy)
+	set(params.WarmStorageReadCostEIP2929, 4, 0, opExtCodeCopy)
+	set(20, 1, 1, opBlockHash)
+	set(5, 0, 1, opSelfBalance)
+	set(3, 1, 1, opBlobHash)
+	set(2, 1, 0, opPop)
+	set(3, 1, 1, opMLoad)
+	set(3, 2, 0, opMStore, opMStore8)
+	set(0, 1, 1, opSLoad)
+	set(0, 2, 0, opSStore)
+	set(8, 1, 0, opJump)
+	set(10, 2, 0, opJumpI)
+	set(params.JumpdestGas, 0, 0, opJumpDest)
+	set(params.WarmStorageReadCostEIP2929, 1, 1, opTLoad)
+	set(params.WarmStorageReadCostEIP2929, 2, 0, opTStore)
+	for op := opPush1; op <= opPush32; op++ {
+		set(3, 0, 1, byte(op))
+	}
+	for i := 0; i < 16; i++ {
+		set(3, i+1, i+2, byte(opDup1+i))
+		set(3, i+2, i+2, byte(opSwap1+i))
+	}
+	for i := 0; i < 5; i++ {
+		set(params.LogGas+uint64(i)*params.LogTopicGas, i+2, 0, byte(opLog0+i))
+	}
+	set(params.CreateGas, 3, 1, opCreate)
+	set(params.Create2Gas, 4, 1, opCreate2)
+	set(params.WarmStorageReadCostEIP2929, 7, 1, opCall, opCallCode)
+	set(params.WarmStorageReadCostEIP2929, 6, 1, opDelegateCall, opStaticCall)
+	set(0, 2, 0, opReturn, opRevert)
+	set(params.SelfdestructGasEIP150, 1, 0, opSelfDestruct)
+
+	for _, op := range []byte{opSStore, opTStore, opCreate, opCreate2, opSelfDestruct, opLog0, opLog0 + 1, opLog0 + 2, opLog0 + 3, opLog4} {
+		instructions[op].writes = true
+	}
+}
+
+// blockContext is the block of the execution of a transaction or call.
+type blockContext struct {
+	number   uint64
+	time     uint64
+	coinbase common.Address
+	gasLimit uint64
+	baseFee  *big.Int
+	random   common.Hash
+}
+
+// evm is the interpreter of the transactions and calls of the dev chain, of
+// the opcodes, gas and precompiles of the cancun fork.
+type evm struct {
+	state     *txState
+	block     blockContext
+	chainID   *big.Int
+	origin    common.Address
+	gasPrice  *big.Int
+	blockHash func(number uint64) common.Hash
+	depth     int
+}
+
+// message is the call of a frame, of the code of codeAddress run as address.
+type message struct {
+	caller      common.Address
+	address     common.Address
+	codeAddress common.Address
+	value       *big.Int
+	input       []byte
+	gas         uint64
+	static      bool
+}
+
+// call runs the call of the kind, of opCall, opCallCode, opDelegateCall or
+// opStaticCall, and returns its output and the gas left.
+func (e *evm) call(kind byte, msg message) ([]byte, uint64, error) {
+	if e.depth > int(params.CallCreateDepth) {
+		return nil, msg.gas, errDepth
+	}
+	if (kind == opCall || kind == opCallCode) && e.state.balance(msg.caller).Cmp(msg.value) < 0 {
+		return nil, msg.gas, errInsufficientBalance
+	}
+
+	snapshot := e.state.snapshot()
+	switch kind {
+	case opCall:
+		e.state.subBalance(msg.caller, msg.value)
+		e.state.addBalance(msg.address, msg.value)
+	case opStaticCall:
+		e.state.addBalance(msg.address, new(big.Int))
+	}
+
+	var ret []byte
+	var err error
+	gas := msg.gas
+	if p, ok := precompiles[msg.codeAddress]; ok {
+		if cost := p.gas(msg.input); cost > gas {
+			gas, err = 0, errOutOfGas
+		} else {
+			gas -= cost
+			ret, err = p.run(msg.input)
+		}
+	} else if code := e.state.code(msg.codeAddress); len(code) > 0 {
+		e.depth++
+		ret, gas, err = e.run(msg, code)
+		e.depth--
+	}
+
+	if err != nil {
+		e.state.revertToSnapshot(snapshot)
+		if err != errExecutionReverted {
+			gas = 0
+		}
+	}
+	return ret, gas, err
+}
+
+// create runs the init code of a contract at the address, and returns the
+// output of its revert and the gas left.
+func (e *evm) create(caller common.Address, initCode []byte, gas uint64, value *big.Int, address common.Address) ([]byte, uint64, error) {
+	if e.depth > int(params.CallCreateDepth) {
+		return nil, gas, errDepth
+	}
+	if e.state.balance(caller).Cmp(value) < 0 {
+		return nil, gas, errInsufficientBalance
+	}
+	nonce := e.state.nonce(caller)
+	if nonce == math.MaxUint64 {
+		return nil, gas, errNonceMax
+	}
+	e.state.setNonce(caller, nonce+1)
+	e.state.warmAddress(address)
+
+	if e.state.nonce(address) != 0 || len(e.state.code(address)) > 0 {
+		return nil, 0, errContractAddressCollision
+	}
+
+	snapshot := e.state.snapshot()
+	e.state.createAccount(address)
+	e.state.setNonce(address, 1)
+	e.state.subBalance(caller, value)
+	e.state.addBalance(address, value)
+
+	msg := message{caller: caller, address: address, codeAddress: address, value: value, gas: gas}
+	e.depth++
+	ret, gas, err := e.run(msg, initCode)
+	e.depth--
+
+	if err == nil {
+		switch {
+		case len(ret) > params.MaxCodeSize:
+			err = errMaxCodeSize
+		case len(ret) > 0 && ret[0] == 0xef:
+			err = errInvalidCode
+		case uint64(len(ret))*params.CreateDataGas > gas:
+			err = errOutOfGas
+		default:
+			gas -= uint64(len(ret)) * params.CreateDataGas
+			e.state.setCode(address, ret)
+			return nil, gas, nil
+		}
+	}
+
+	e.state.revertToSnapshot(snapshot)
+	if err != errExecutionReverted {
+		return nil, 0, err
+	}
+	return ret, gas, err
+}
+
+// frame is the state of the execution of the code of a call.
+type frame struct {
+	message
+	code       []byte
+	jumpdests  []bool
+	stack      []uint256.Int
+	memory     []byte
+	returnData []byte
+	pc         uint64
+}
+
+func (f *frame) useGas(gas uint64) bool {
+	if f.gas < gas {
+		f.gas = 0
+		return false
+	}
+	f.gas -= gas
+	return true
+}
+
+func (f *frame) pop() uint256.Int {
+	v := f.stack[len(f.stack)-1]
+	f.stack = f.stack[:len(f.stack)-1]
+	return v
+}
+
+func (f *frame) peek() *uint256.Int {
+	return &f.stack[len(f.stack)-1]
+}
+
+func (f *frame) push(v *uint256.Int) {
+	f.stack = append(f.stack, *v)
+}
+
+func (f *frame) pushAddress(address common.Address) {
+	f.push(new(uint256.Int).SetBytes20(address.Bytes()))
+}
+
+func (f *frame) pushBig(v *big.Int) {
+	u, _ := uint256.FromBig(v)
+	f.push(u)
+}
+
+// memoryRange returns the range of the memory of the offset and size, once
+// the memory is expanded to it and its gas is paid.
+func (f *frame) memoryRange(offset, size *uint256.Int) (uint64, uint64, error) {
+	if size.IsZero() {
+		return 0, 0, nil
+	}
+	if !offset.IsUint64() || !size.IsUint64() {
+		return 0, 0, errOutOfGas
+	}
+	start, length := offset.Uint64(), size.Uint64()
+	// the max memory of which the gas of expansion fits in a uint64
+	if start > 0x1FFFFFFFE0 || length > 0x1FFFFFFFE0 || start+length > 0x1FFFFFFFE0 {
+		return 0, 0, errOutOfGas
+	}
+	words := toWords(start + length)
+	if current := uint64(len(f.memory)) / 32; words > current {
+		if !f.useGas(memoryGas(words) - memoryGas(current)) {
+			return 0, 0, errOutOfGas
+		}
+		f.memory = append(f.memory, make([]byte, (words-current)*32)...)
+	}
+	return start, length, nil
+}
+
+func memoryGas(words uint64) uint64 {
+	return words*params.MemoryGas + words*words/params.QuadCoeffDiv
+}
+
+func toWords(size uint64) uint64 {
+	if size > math.MaxUint64-31 {
+		return math.MaxUint64/32 + 1
+	}
+	return (size + 31) / 32
+}
+
+// copyGas returns the gas of copying the words of the size.
+func copyGas(size *uint256.Int) (uint64, bool) {
+	if !size.IsUint64() {
+		return 0, false
+	}
+	words := toWords(size.Uint64())
+	if words > math.MaxUint64/params.CopyGas {
+		return 0, false
+	}
+	return words * params.CopyGas, true
+}
+
+// dataAt returns the size bytes of the data at the offset, padded with zeros.
+func dataAt(data []byte, offset *uint256.Int, size uint64) []byte {
+	out := make([]byte, size)
+	if offset.IsUint64() && offset.Uint64() < uint64(len(data)) {
+		copy(out, data[offset.Uint64():])
+	}
+	return out
+}
+
+// accessGas returns the surcharge of accessing a cold account, of which the
+// warm access is of the static gas.
+func (e *evm) accessGas(address common.Address) uint64 {
+	if e.state.warmAddress(address) {
+		return 0
+	}
+	return params.ColdAccountAccessCostEIP2929 - params.WarmStorageReadCostEIP2929
+}
+
+// jumpdests returns the valid jump destinations of the code, ie. of the
+// JUMPDEST opcodes which are not push data.
+func jumpdests(code []byte) []bool {
+	dests := make([]bool, len(code))
+	for pc := 0; pc < len(code); pc++ {
+		op := code[pc]
+		if op == opJumpDest {
+			dests[pc] = true
+		} else if op >= opPush1 && op <= opPush32 {
+			pc += int(op - opPush1 + 1)
+		}
+	}
+	return dests
+}
+
+func (f *frame) jump(dest *uint256.Int) error {
+	if !dest.IsUint64() || dest.Uint64() >= uint64(len(f.code)) || !f.jumpdests[dest.Uint64()] {
+		return errInvalidJump
+	}
+	f.pc = dest.Uint64()
+	return nil
+}
+
+// run runs the code of the message, and returns its output and the gas left.
+func (e *evm) run(msg message, code []byte) ([]byte, uint64, error) {
+	f := &frame{message: msg, code: code, jumpdests: jumpdests(code), stack: make([]uint256.Int, 0, 16)}
+	ret, err := e.interpret(f)
+	return ret, f.gas, err
+}
+
+func (e *evm) interpret(f *frame) ([]byte, error) {
+	for {
+		var op byte = opStop
+		if f.pc < uint64(len(f.code)) {
+			op = f.code[f.pc]
+		}
+
+		ins := instructions[op]
+		if !ins.valid {
+			return nil, fmt.Errorf("invalid opcode: 0x%x", op)
+		}
+		if len(f.stack) < ins.pops {
+			return nil, errStackUnderflow
+		}
+		if len(f.stack)-ins.pops+ins.pushes > int(params.StackLimit) {
+			return nil, errStackOverflow
+		}
+		if f.static && ins.writes {
+			return nil, errWriteProtection
+		}
+		if !f.useGas(ins.gas) {
+			return nil, errOutOfGas
+		}
+
+		switch {
+		case op >= opPush1 && op <= opPush32:
+			n := uint64(op - opPush1 + 1)
+			start := min(f.pc+1, uint64(len(f.code)))
+			end := min(f.pc+1+n, uint64(len(f.code)))
+			data := make([]byte, n)
+			copy(data, f.code[start:end])
+			f.push(new(uint256.Int).SetBytes(data))
+			f.pc += n + 1
+			continue
+
+		case op >= opDup1 && op <= opDup16:
+			v := f.stack[len(f.stack)-int(op-opDup1+1)]
+			f.push(&v)
+			f.pc++
+			continue
+
+		case op >= opSwap1 && op <= opSwap16:
+			top, n := len(f.stack)-1, len(f.stack)-1-int(op-opSwap1+1)
+			f.stack[top], f.stack[n] = f.stack[n], f.stack[top]
+			f.pc++
+			continue
+
+		case op >= opLog0 && op <= opLog4:
+			offset, size := f.pop(), f.pop()
+			topics := make([]common.Hash, op-opLog0)
+			for i := range topics {
+				v := f.pop()
+				topics[i] = v.Bytes32()
+			}
+			start, length, err := f.memoryRange(&offset, &size)
+			if err != nil {
+				return nil, err
+			}
+			if length > math.MaxUint64/params.LogDataGas || !f.useGas(length*params.LogDataGas) {
+				return nil, errOutOfGas
+			}
+			e.state.addLog(&types.Log{
+				Address:     f.address,
+				Topics:      topics,
+				Data:        append([]byte{}, f.memory[start:start+length]...),
+				BlockNumber: e.block.number,
+			})
+			f.pc++
+			continue
+		}
+
+		switch op {
+		case opStop:
+			return nil, nil
+
+		case opAdd:
+			x := f.pop()
+			y := f.peek()
+			y.Add(&x, y)
+		case opMul:
+			x := f.pop()
+			y := f.peek()
+			y.Mul(&x, y)
+		case opSub:
+			x := f.pop()
+			y := f.peek()
+			y.Sub(&x, y)
+		case opDiv:
+			x := f.pop()
+			y := f.peek()
+			y.Div(&x, y)
+		case opSDiv:
+			x := f.pop()
+			y := f.peek()
+			y.SDiv(&x, y)
+		case opMod:
+			x := f.pop()
+			y := f.peek()
+			y.Mod(&x, y)
+		case opSMod:
+			x := f.pop()
+			y := f.peek()
+			y.SMod(&x, y)
+		case opAddMod:
+			x, y := f.pop(), f.pop()
+			z := f.peek()
+			z.AddMod(&x, &y, z)
+		case opMulMod:
+			x, y := f.pop(), f.pop()
+			z := f.peek()
+			z.MulMod(&x, &y, z)
+		case opExp:
+			base := f.pop()
+			exponent := f.peek()
+			if !f.useGas(uint64(exponent.ByteLen()) * params.ExpByteEIP158) {
+				return nil, errOutOfGas
+			}
+			exponent.Exp(&base, exponent)
+		case opSignExtend:
+			back := f.pop()
+			num := f.peek()
+			num.ExtendSign(num, &back)
+
+		case opLt:
+			x := f.pop()
+			y := f.peek()
+			setBool(y, x.Lt(y))
+		case opGt:
+			x := f.pop()
+			y := f.peek()
+			setBool(y, x.Gt(y))
+		case opSlt:
+			x := f.pop()
+			y := f.peek()
+			setBool(y, x.Slt(y))
+		case opSgt:
+			x := f.pop()
+			y := f.peek()
+			setBool(y, x.Sgt(y))
+		case opEq:
+			x := f.pop()
+			y := f.peek()
+			setBool(y, x.Eq(y))
+		case opIsZero:
+			x := f.peek()
+			setBool(x, x.IsZero())
+		case opAnd:
+			x := f.pop()
+			y := f.peek()
+			y.And(&x, y)
+		case opOr:
+			x := f.pop()
+			y := f.peek()
+			y.Or(&x, y)
+		case opXor:
+			x := f.pop()
+			y := f.peek()
+			y.Xor(&x, y)
+		case opNot:
+			x := f.peek()
+			x.Not(x)
+		case opByte:
+			th := f.pop()
+			val := f.peek()
+			val.Byte(&th)
+		case opShl:
+			shift := f.pop()
+			value := f.peek()
+			if shift.LtUint64(256) {
+				value.Lsh(value, uint(shift.Uint64()))
+			} else {
+				value.Clear()
+			}
+		case opShr:
+			shift := f.pop()
+			value := f.peek()
+			if shift.LtUint64(256) {
+				value.Rsh(value, uint(shift.Uint64()))
+			} else {
+				value.Clear()
+			}
+		case opSar:
+			shift := f.pop()
+			value := f.peek()
+			if shift.GtUint64(255) {
+				if value.Sign() >= 0 {
+					value.Clear()
+				} else {
+					value.SetAllOne()
+				}
+			} else {
+				value.SRsh(value, uint(shift.Uint64()))
+			}
+
+		case opKeccak256:
+			offset := f.pop()
+			size := f.peek()
+			start, length, err := f.memoryRange(&offset, size)
+			if err != nil {
+				return nil, err
+			}
+			if !f.useGas(toWords(length) * params.Keccak256WordGas) {
+				return nil, errOutOfGas
+			}
+			size.SetBytes(crypto.Keccak256(f.memory[start : start+length]))
+
+		case opAddress:
+			f.pushAddress(f.address)
+		case opBalance:
+			slot := f.peek()
+			address := common.Address(slot.Bytes20())
+			if !f.useGas(e.accessGas(address)) {
+				return nil, errOutOfGas
+			}
+			v, _ := uint256.FromBig(e.state.balance(address))
+			slot.Set(v)
+		case opOrigin:
+			f.pushAddress(e.origin)
+		case opCaller:
+			f.pushAddress(f.caller)
+		case opCallValue:
+			f.pushBig(f.value)
+		case opCallDataLoad:
+			x := f.peek()
+			x.SetBytes(dataAt(f.input, x, 32))
+		case opCallDataSize:
+			f.push(uint256.NewInt(uint64(len(f.input))))
+		case opCallDataCopy, opCodeCopy:
+			memOffset, dataOffset, size := f.pop(), f.pop(), f.pop()
+			if err := f.copyToMemory(op, &memOffset, &dataOffset, &size); err != nil {
+				return nil, err
+			}
+		case opCodeSize:
+			f.push(uint256.NewInt(uint64(len(f.code))))
+		case opGasPrice:
+			f.pushBig(e.gasPrice)
+		case opExtCodeSize:
+			slot := f.peek()
+			address := common.Address(slot.Bytes20())
+			if !f.useGas(e.accessGas(address)) {
+				return nil, errOutOfGas
+			}
+			slot.SetUint64(uint64(len(e.state.code(address))))
+		case opExtCodeCopy:
+			a := f.pop()
+			address := common.Address(a.Bytes20())
+			if !f.useGas(e.accessGas(address)) {
+				return nil, errOutOfGas
+			}
+			memOffset, codeOffset, size := f.pop(), f.pop(), f.pop()
+			start, length, err := f.memoryRange(&memOffset, &size)
+			if err != nil {
+				return nil, err
+			}
+			if gas, ok := copyGas(&size); !ok || !f.useGas(gas) {
+				return nil, errOutOfGas
+			}
+			copy(f.memory[start:start+length], dataAt(e.state.code(address), &codeOffset, length))
+		case opReturnDataSize:
+			f.push(uint256.NewInt(uint64(len(f.returnData))))
+		case opReturnDataCopy:
+			memOffset, dataOffset, size := f.pop(), f.pop(), f.pop()
+			end, overflow := new(uint256.Int).AddOverflow(&dataOffset, &size)
+			if overflow || !end.IsUint64() || end.Uint64() > uint64(len(f.returnData)) {
+				return nil, errReturnDataOutOfBounds
+			}
+			start, length, err := f.memoryRange(&memOffset, &size)
+			if err != nil {
+				return nil, err
+			}
+			if gas, ok := copyGas(&size); !ok || !f.useGas(gas) {
+				return nil, errOutOfGas
+			}
+			copy(f.memory[start:start+length], f.returnData[dataOffset.Uint64():end.Uint64()])
+		case opExtCodeHash:
+			slot := f.peek()
+			address := common.Address(slot.Bytes20())
+			if !f.useGas(e.accessGas(address)) {
+				return nil, errOutOfGas
+			}
+			hash := e.state.codeHash(address)
+			slot.SetBytes32(hash[:])
+
+		case opBlockHash:
+			num := f.peek()
+			n, overflow := num.Uint64WithOverflow()
+			if !overflow && n < e.block.number && n+256 >= e.block.number {
+				hash := e.blockHash(n)
+				num.SetBytes32(hash[:])
+			} else {
+				num.Clear()
+			}
+		case opCoinbase:
+			f.pushAddress(e.block.coinbase)
+		case opTimestamp:
+			f.push(uint256.NewInt(e.block.time))
+		case opNumber:
+			f.push(uint256.NewInt(e.block.number))
+		case opPrevRandao:
+			f.push(new(uint256.Int).SetBytes32(e.block.random[:]))
+		case opGasLimit:
+			f.push(uint256.NewInt(e.block.gasLimit))
+		case opChainID:
+			f.pushBig(e.chainID)
+		case opSelfBalance:
+			f.pushBig(e.state.balance(f.address))
+		case opBaseFee:
+			f.pushBig(e.block.baseFee)
+		case opBlobHash:
+			// the dev chain has no blob transactions
+			f.peek().Clear()
+		case opBlobBaseFee:
+			f.push(uint256.NewInt(params.BlobTxMinBlobGasprice))
+
+		case opPop:
+			f.pop()
+		case opMLoad:
+			offset := f.peek()
+			start, _, err := f.memoryRange(offset, uint256.NewInt(32))
+			if err != nil {
+				return nil, err
+			}
+			offset.SetBytes32(f.memory[start : start+32])
+		case opMStore:
+			offset, value := f.pop(), f.pop()
+			start, _, err := f.memoryRange(&offset, uint256.NewInt(32))
+			if err != nil {
+				return nil, err
+			}
+			value.WriteToSlice(f.memory[start : start+32])
+		case opMStore8:
+			offset, value := f.pop(), f.pop()
+			start, _, err := f.memoryRange(&offset, uint256.NewInt(1))
+			if err != nil {
+				return nil, err
+			}
+			f.memory[start] = byte(value.Uint64())
+		case opSLoad:
+			slot := f.peek()
+			key := common.Hash(slot.Bytes32())
+			gas := params.WarmStorageReadCostEIP2929
+			if !e.state.warmSlot(f.address, key) {
+				gas = params.ColdSloadCostEIP2929
+			}
+			if !f.useGas(gas) {
+				return nil, errOutOfGas
+			}
+			value := e.state.storage(f.address, key)
+			slot.SetBytes32(value[:])
+		case opSStore:
+			if f.gas <= params.SstoreSentryGasEIP2200 {
+				return nil, errOutOfGas
+			}
+			k, v := f.pop(), f.pop()
+			key, value := common.Hash(k.Bytes32()), common.Hash(v.Bytes32())
+			if !f.useGas(e.sstoreGas(f.address, key, value)) {
+				return nil, errOutOfGas
+			}
+			e.state.setStorage(f.address, key, value)
+		case opJump:
+			dest := f.pop()
+			if err := f.jump(&dest); err != nil {
+				return nil, err
+			}
+			continue
+		case opJumpI:
+			dest, cond := f.pop(), f.pop()
+			if !cond.IsZero() {
+				if err := f.jump(&dest); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		case opPC:
+			f.push(uint256.NewInt(f.pc))
+		case opMSize:
+			f.push(uint256.NewInt(uint64(len(f.memory))))
+		case opGas:
+			f.push(uint256.NewInt(f.gas))
+		case opJumpDest:
+		case opTLoad:
+			slot := f.peek()
+			value := e.state.transientStorage(f.address, slot.Bytes32())
+			slot.SetBytes32(value[:])
+		case opTStore:
+			k, v := f.pop(), f.pop()
+			e.state.setTransientStorage(f.address, k.Bytes32(), v.Bytes32())
+		case opMCopy:
+			dst, src, size := f.pop(), f.pop(), f.pop()
+			if size.IsZero() {
+				break
+			}
+			if _, _, err := f.memoryRange(&src, &size); err != nil {
+				return nil, err
+			}
+			if _, _, err := f.memoryRange(&dst, &size); err != nil {
+				return nil, err
+			}
+			if gas, ok := copyGas(&size); !ok || !f.useGas(gas) {
+				return nil, errOutOfGas
+			}
+			copy(f.memory[dst.Uint64():], f.memory[src.Uint64():src.Uint64()+size.Uint64()])
+		case opPush0:
+			f.push(new(uint256.Int))
+
+		case opCreate, opCreate2:
+			if err := e.opCreate(f, op); err != nil {
+				return nil, err
+			}
+		case opCall, opCallCode, opDelegateCall, opStaticCall:
+			if err := e.opCall(f, op); err != nil {
+				return nil, err
+			}
+
+		case opReturn, opRevert:
+			offset, size := f.pop(), f.pop()
+			start, length, err := f.memoryRange(&offset, &size)
+			if err != nil {
+				return nil, err
+			}
+			ret := append([]byte{}, f.memory[start:start+length]...)
+			if op == opRevert {
+				return ret, errExecutionReverted
+			}
+			return ret, nil
+
+		case opSelfDestruct:
+			b := f.pop()
+			beneficiary := common.Address(b.Bytes20())
+			balance := e.state.balance(f.address)
+			gas := uint64(0)
+			if !e.state.warmAddress(beneficiary) {
+				gas += params.ColdAccountAccessCostEIP2929
+			}
+			if balance.Sign() > 0 && e.state.empty(beneficiary) {
+				gas += params.CreateBySelfdestructGas
+			}
+			if !f.useGas(gas) {
+				return nil, errOutOfGas
+			}
+			balance = new(big.Int).Set(balance)
+			e.state.subBalance(f.address, balance)
+			e.state.addBalance(beneficiary, balance)
+			e.state.selfDestruct(f.address)
+			return nil, nil
+
+		default:
+			return nil, fmt.Errorf("invalid opcode: 0x%x", op)
+		}
+		f.pc++
+	}
+}
+
+func setBool(v *uint256.Int, b bool) {
+	if b {
+		v.SetOne()
+	} else {
+		v.Clear()
+	}
+}
+
+// copyToMemory copies the calldata or code of the frame to its memory.
+func (f *frame) copyToMemory(op byte, memOffset, dataOffset, size *uint256.Int) error {
+	start, length, err := f.memoryRange(memOffset, size)
+	if err != nil {
+		return err
+	}
+	if gas, ok := copyGas(size); !ok || !f.useGas(gas) {
+		return errOutOfGas
+	}
+	data := f.input
+	if op == opCodeCopy {
+		data = f.code
+	}
+	copy(f.memory[start:start+length], dataAt(data, dataOffset, length))
+	return nil
+}
+
+// sstoreGas returns the gas of the SSTORE of the value, and updates the refund,
+// as of EIP-2200, EIP-2929 and EIP-3529.
+func (e *evm) sstoreGas(address common.Address, key, value common.Hash) uint64 {
+	var gas uint64
+	if !e.state.warmSlot(address, key) {
+		gas = params.ColdSloadCostEIP2929
+	}
+	current := e.state.storage(address, key)
+	if current == value {
+		return gas + params.WarmStorageReadCostEIP2929
+	}
+	original := e.state.originalStorage(address, key)
+	if original == current {
+		if original == (common.Hash{}) {
+			return gas + params.SstoreSetGasEIP2200
+		}
+		if value == (common.Hash{}) {
+			e.state.addRefund(params.SstoreClearsScheduleRefundEIP3529)
+		}
+		return gas + params.SstoreResetGasEIP2200 - params.ColdSloadCostEIP2929
+	}
+	if original != (common.Hash{}) {
+		if current == (common.Hash{}) {
+			e.state.subRefund(params.SstoreClearsScheduleRefundEIP3529)
+		} else if value == (common.Hash{}) {
+			e.state.addRefund(params.SstoreClearsScheduleRefundEIP3529)
+		}
+	}
+	if original == value {
+		if original == (common.Hash{}) {
+			e.state.addRefund(params.SstoreSetGasEIP2200 - params.WarmStorageReadCostEIP2929)
+		} else {
+			e.state.addRefund(params.SstoreResetGasEIP2200 - params.ColdSloadCostEIP2929 - params.WarmStorageReadCostEIP2929)
+		}
+	}
+	return gas + params.WarmStorageReadCostEIP2929
+}
+
+func (e *evm) opCreate(f *frame, op byte) error {
+	v, offset, size := f.pop(), f.pop(), f.pop()
+	var salt uint256.Int
+	if op == opCreate2 {
+		salt = f.pop()
+	}
+	if !size.IsUint64() || size.Uint64() > params.MaxInitCodeSize {
+		return errMaxInitCodeSize
+	}
+	start, length, err := f.memoryRange(&offset, &size)
+	if err != nil {
+		return err
+	}
+	gas := toWords(length) * params.InitCodeWordGas
+	if op == opCreate2 {
+		gas += toWords(length) * params.Keccak256WordGas
+	}
+	if !f.useGas(gas) {
+		return errOutOfGas
+	}
+	initCode := append([]byte{}, f.memory[start:start+length]...)
+
+	var address common.Address
+	if op == opCreate {
+		address = crypto.CreateAddress(f.address, e.state.nonce(f.address))
+	} else {
+		address = crypto.CreateAddress2(f.address, salt.Bytes32(), crypto.Keccak256(initCode))
+	}
+
+	childGas := f.gas - f.gas/64
+	f.gas -= childGas
+	ret, gasLeft, err := e.create(f.address, initCode, childGas, v.ToBig(), address)
+	f.gas += gasLeft
+
+	result := new(uint256.Int)
+	if err == nil {
+		result.SetBytes20(address.Bytes())
+	}
+	f.push(result)
+	f.returnData = nil
+	if err == errExecutionReverted {
+		f.returnData = ret
+	}
+	return nil
+}
+
+func (e *evm) opCall(f *frame, op byte) error {
+	requested, a := f.pop(), f.pop()
+	address := common.Address(a.Bytes20())
+	var value uint256.Int
+	if op == opCall || op == opCallCode {
+		value = f.pop()
+	}
+	inOffset, inSize, retOffset, retSize := f.pop(), f.pop(), f.pop(), f.pop()
+
+	if op == opCall && f.static && !value.IsZero() {
+		return errWriteProtection
+	}
+	if _, _, err := f.memoryRange(&inOffset, &inSize); err != nil {
+		return err
+	}
+	if _, _, err := f.memoryRange(&retOffset, &retSize); err != nil {
+		return err
+	}
+
+	gas := e.accessGas(address)
+	if !value.IsZero() {
+		gas += params.CallValueTransferGas
+		if op == opCall && e.state.empty(address) {
+			gas += params.CallNewAccountGas
+		}
+	}
+	if !f.useGas(gas) {
+		return errOutOfGas
+	}
+
+	// all but one 64th of the gas left, as of EIP-150
+	callGas := f.gas - f.gas/64
+	if requested.IsUint64() && requested.Uint64() < callGas {
+		callGas = requested.Uint64()
+	}
+	f.gas -= callGas
+	if !value.IsZero() {
+		callGas += params.CallStipend
+	}
+
+	var input []byte
+	if !inSize.IsZero() {
+		input = append([]byte{}, f.memory[inOffset.Uint64():inOffset.Uint64()+inSize.Uint64()]...)
+	}
+	msg := message{caller: f.address, address: address, codeAddress: address, value: value.ToBig(), input: input, gas: callGas, static: f.static}
+	switch op {
+	case opCallCode:
+		msg.address = f.address
+	case opDelegateCall:
+		msg.caller, msg.address, msg.value = f.caller, f.address, f.value
+	case opStaticCall:
+		msg.static = true
+	}
+
+	ret, gasLeft, err := e.call(op, msg)
+	f.gas += gasLeft
+
+	result := new(uint256.Int)
+	if err == nil {
+		result.SetOne()
+	}
+	f.push(result)
+	if err == nil || err == errExecutionReverted {
+		if !retSize.IsZero() {
+			copy(f.memory[retOffset.Uint64():retOffset.Uint64()+retSize.Uint64()], ret)
+		}
+	}
+	f.returnData = ret
+	return nil
+}
@@ -0,0 +1,74 @@
+package ethdevnode
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runCode calls the code at a contract of the storage on the genesis state.
+func runCode(t *testing.T, code string, storage map[common.Hash]common.Hash) *executionResult {
+	n, err := NewNode(context.Background())
+	require.NoError(t, err)
+
+	contract := common.HexToAddress("0xc0de")
+	n.states[0][contract] = &account{balance: new(big.Int), code: common.FromHex(code), storage: storage}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	result, err := n.doCall(context.Background(), txArgs{To: &contract}, 0, 0)
+	require.NoError(t, err)
+	return result
+}
+
+func TestEVM(t *testing.T) {
+	// returns the word at the top of the stack
+	ret := "60005260206000f3"
+
+	tests := []struct {
+		name string
+		code string
+		want string
+		err  error
+	}{
+		{"exp", "60036002" + "0a" + ret, "0x08", nil},
+		{"sdiv", "6002" + "7ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff8" + "05" + ret, "0xfffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffc", nil},
+		{"sar", "7ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff0" + "6002" + "1d" + ret, "0xfffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffc", nil},
+		{"shl", "600160ff" + "1b" + ret, "0x8000000000000000000000000000000000000000000000000000000000000000", nil},
+		{"signextend", "60ff6000" + "0b" + ret, "0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", nil},
+		{"transient storage", "602a60015d" + "60015c" + ret, "0x2a", nil},
+		{"sha256 precompile", "60206000600060006002" + "5afa" + "50" + "60206000f3", "0xe3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", nil},
+		{"create2", "6460016000f3600052" + "60006005601b6000f5" + "3b" + ret, "0x01", nil},
+		{"invalid jump", "600556", "", errInvalidJump},
+		{"jump into push data", "600456605b", "", errInvalidJump},
+		{"stack underflow", "01", "", errStackUnderflow},
+		{"revert", "60006000fd", "", errExecutionReverted},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := runCode(t, test.code, nil)
+			if test.err != nil {
+				assert.Equal(t, test.err, result.err)
+				return
+			}
+			require.NoError(t, result.err)
+			assert.Equal(t, common.HexToHash(test.want).Bytes(), result.ret)
+		})
+	}
+
+	t.Run("sstore", func(t *testing.T) {
+		// the set of a cold slot
+		result := runCode(t, "602a600055", nil)
+		require.NoError(t, result.err)
+		assert.Equal(t, uint64(21000+3+3+22100), result.gasUsed)
+
+		// the clear of a cold slot, of the refund of EIP-3529
+		result = runCode(t, "6000600055", map[common.Hash]common.Hash{{}: common.HexToHash("0x2a")})
+		require.NoError(t, result.err)
+		assert.Equal(t, uint64(21000+3+3+5000-4800), result.gasUsed)
+	})
+}
@@ -0,0 +1,285 @@
+package ethdevnode
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto/blake2b"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto/bn256"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto/kzg4844"
+	"github.com/0xsequence/ethkit/go-ethereum/params"
+	"golang.org/x/crypto/ripemd160"
+)
+
+// precompile is a precompiled contract of the cancun fork.
+type precompile struct {
+	gas func(input []byte) uint64
+	run func(input []byte) ([]byte, error)
+}
+
+var precompiles = map[common.Address]precompile{
+	common.BytesToAddress([]byte{0x01}): {fixedGas(params.EcrecoverGas), runEcrecover},
+	common.BytesToAddress([]byte{0x02}): {wordGas(params.Sha256BaseGas, params.Sha256PerWordGas), runSha256},
+	common.BytesToAddress([]byte{0x03}): {wordGas(params.Ripemd160BaseGas, params.Ripemd160PerWordGas), runRipemd160},
+	common.BytesToAddress([]byte{0x04}): {wordGas(params.IdentityBaseGas, params.IdentityPerWordGas), runIdentity},
+	common.BytesToAddress([]byte{0x05}): {modExpGas, runModExp},
+	common.BytesToAddress([]byte{0x06}): {fixedGas(params.Bn256AddGasIstanbul), runBn256Add},
+	common.BytesToAddress([]byte{0x07}): {fixedGas(params.Bn256ScalarMulGasIstanbul), runBn256ScalarMul},
+	common.BytesToAddress([]byte{0x08}): {bn256PairingGas, runBn256Pairing},
+	common.BytesToAddress([]byte{0x09}): {blake2FGas, runBlake2F},
+	common.BytesToAddress([]byte{0x0a}): {fixedGas(params.BlobTxPointEvaluationPrecompileGas), runPointEvaluation},
+}
+
+var (
+	errBadPairingInput         = errors.New("bad elliptic curve pairing input")
+	errBlake2FInvalidInput     = errors.New("invalid input length")
+	errBlake2FInvalidFinalFlag = errors.New("invalid final flag")
+	errPointEvaluationInput    = errors.New("invalid input length")
+	errPointEvaluationHash     = errors.New("mismatched versioned hash")
+)
+
+func fixedGas(gas uint64) func([]byte) uint64 {
+	return func([]byte) uint64 { return gas }
+}
+
+func wordGas(base, perWord uint64) func([]byte) uint64 {
+	return func(input []byte) uint64 { return base + toWords(uint64(len(input)))*perWord }
+}
+
+// rightPad returns the input of the size, of the missing bytes set to zero.
+func rightPad(input []byte, size int) []byte {
+	if len(input) >= size {
+		return input
+	}
+	padded := make([]byte, size)
+	copy(padded, input)
+	return padded
+}
+
+func runEcrecover(input []byte) ([]byte, error) {
+	input = rightPad(input, 128)
+	v := new(big.Int).SetBytes(input[32:64])
+	r, s := new(big.Int).SetBytes(input[64:96]), new(big.Int).SetBytes(input[96:128])
+	if !v.IsUint64() || (v.Uint64() != 27 && v.Uint64() != 28) || !crypto.ValidateSignatureValues(byte(v.Uint64()-27), r, s, false) {
+		return nil, nil
+	}
+	sig := append(append([]byte{}, input[64:128]...), byte(v.Uint64()-27))
+	pubkey, err := crypto.Ecrecover(input[:32], sig)
+	if err != nil {
+		return nil, nil
+	}
+	return common.LeftPadBytes(crypto.Keccak256(pubkey[1:])[12:], 32), nil
+}
+
+func runSha256(input []byte) ([]byte, error) {
+	h := sha256.Sum256(input)
+	return h[:], nil
+}
+
+func runRipemd160(input []byte) ([]byte, error) {
+	h := ripemd160.New()
+	h.Write(input)
+	return common.LeftPadBytes(h.Sum(nil), 32), nil
+}
+
+func runIdentity(input []byte) ([]byte, error) {
+	return append([]byte{}, input...), nil
+}
+
+// modExpLengths returns the lengths of the base, exponent and modulus of the
+// input of the modexp precompile.
+func modExpLengths(input []byte) (*big.Int, *big.Int, *big.Int) {
+	header := rightPad(input, 96)
+	return new(big.Int).SetBytes(header[:32]), new(big.Int).SetBytes(header[32:64]), new(big.Int).SetBytes(header[64:96])
+}
+
+// modExpGas is the gas of EIP-2565.
+func modExpGas(input []byte) uint64 {
+	baseLen, expLen, modLen := modExpLengths(input)
+	if len(input) > 96 {
+		input = input[96:]
+	} else {
+		input = nil
+	}
+
+	// the first 32 bytes of the exponent
+	var expHead *big.Int
+	if !baseLen.IsUint64() || baseLen.Uint64() >= uint64(len(input)) {
+		expHead = new(big.Int)
+	} else {
+		size := 32
+		if expLen.Cmp(big.NewInt(32)) < 0 {
+			size = int(expLen.Uint64())
+		}
+		expHead = new(big.Int).SetBytes(rightPad(input[baseLen.Uint64():], size)[:size])
+	}
+	var msb uint64
+	if bitlen := expHead.BitLen(); bitlen > 0 {
+		msb = uint64(bitlen - 1)
+	}
+
+	words := new(big.Int).Set(baseLen)
+	if modLen.Cmp(baseLen) > 0 {
+		words.Set(modLen)
+	}
+	words.Add(words, big.NewInt(7))
+	words.Rsh(words, 3)
+	complexity := words.Mul(words, words)
+
+	iterations := new(big.Int)
+	if expLen.Cmp(big.NewInt(32)) > 0 {
+		iterations.Sub(expLen, big.NewInt(32))
+		iterations.Lsh(iterations, 3)
+	}
+	iterations.Add(iterations, new(big.Int).SetUint64(msb))
+	if iterations.Sign() == 0 {
+		iterations.SetUint64(1)
+	}
+
+	gas := complexity.Mul(complexity, iterations)
+	gas.Div(gas, big.NewInt(3))
+	if !gas.IsUint64() {
+		return math.MaxUint64
+	}
+	return max(gas.Uint64(), 200)
+}
+
+func runModExp(input []byte) ([]byte, error) {
+	baseLenBig, expLenBig, modLenBig := modExpLengths(input)
+	baseLen, expLen, modLen := baseLenBig.Uint64(), expLenBig.Uint64(), modLenBig.Uint64()
+	if baseLen == 0 && modLen == 0 {
+		return []byte{}, nil
+	}
+	if len(input) > 96 {
+		input = input[96:]
+	} else {
+		input = nil
+	}
+	input = rightPad(input, int(baseLen+expLen+modLen))
+	base := new(big.Int).SetBytes(input[:baseLen])
+	exp := new(big.Int).SetBytes(input[baseLen : baseLen+expLen])
+	mod := new(big.Int).SetBytes(input[baseLen+expLen : baseLen+expLen+modLen])
+
+	if mod.BitLen() == 0 {
+		return make([]byte, modLen), nil
+	}
+	return common.LeftPadBytes(new(big.Int).Exp(base, exp, mod).Bytes(), int(modLen)), nil
+}
+
+func runBn256Add(input []byte) ([]byte, error) {
+	input = rightPad(input, 128)
+	a, b := new(bn256.G1), new(bn256.G1)
+	if _, err := a.Unmarshal(input[:64]); err != nil {
+		return nil, err
+	}
+	if _, err := b.Unmarshal(input[64:128]); err != nil {
+		return nil, err
+	}
+	return new(bn256.G1).Add(a, b).Marshal(), nil
+}
+
+func runBn256ScalarMul(input []byte) ([]byte, error) {
+	input = rightPad(input, 96)
+	p := new(bn256.G1)
+	if _, err := p.Unmarshal(input[:64]); err != nil {
+		return nil, err
+	}
+	return new(bn256.G1).ScalarMult(p, new(big.Int).SetBytes(input[64:96])).Marshal(), nil
+}
+
+func bn256PairingGas(input []byte) uint64 {
+	return params.Bn256PairingBaseGasIstanbul + uint64(len(input)/192)*params.Bn256PairingPerPointGasIstanbul
+}
+
+func runBn256Pairing(input []byte) ([]byte, error) {
+	if len(input)%192 != 0 {
+		return nil, errBadPairingInput
+	}
+	var g1s []*bn256.G1
+	var g2s []*bn256.G2
+	for i := 0; i < len(input); i += 192 {
+		g1, g2 := new(bn256.G1), new(bn256.G2)
+		if _, err := g1.Unmarshal(input[i : i+64]); err != nil {
+			return nil, err
+		}
+		if _, err := g2.Unmarshal(input[i+64 : i+192]); err != nil {
+			return nil, err
+		}
+		g1s = append(g1s, g1)
+		g2s = append(g2s, g2)
+	}
+	if bn256.PairingCheck(g1s, g2s) {
+		return common.LeftPadBytes([]byte{1}, 32), nil
+	}
+	return make([]byte, 32), nil
+}
+
+func blake2FGas(input []byte) uint64 {
+	if len(input) != 213 {
+		return 0
+	}
+	return uint64(binary.BigEndian.Uint32(input[:4]))
+}
+
+func runBlake2F(input []byte) ([]byte, error) {
+	if len(input) != 213 {
+		return nil, errBlake2FInvalidInput
+	}
+	if input[212] > 1 {
+		return nil, errBlake2FInvalidFinalFlag
+	}
+	rounds := binary.BigEndian.Uint32(input[:4])
+	final := input[212] == 1
+
+	var h [8]uint64
+	var m [16]uint64
+	var t [2]uint64
+	for i := range h {
+		h[i] = binary.LittleEndian.Uint64(input[4+i*8:])
+	}
+	for i := range m {
+		m[i] = binary.LittleEndian.Uint64(input[68+i*8:])
+	}
+	t[0] = binary.LittleEndian.Uint64(input[196:])
+	t[1] = binary.LittleEndian.Uint64(input[204:])
+
+	blake2b.F(&h, m, t, final, rounds)
+
+	output := make([]byte, 64)
+	for i := range h {
+		binary.LittleEndian.PutUint64(output[i*8:], h[i])
+	}
+	return output, nil
+}
+
+// blsModulus is the modulus of the field of the blob, of the output of the
+// point evaluation precompile.
+var blsModulus = common.FromHex("0x73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001")
+
+func runPointEvaluation(input []byte) ([]byte, error) {
+	if len(input) != 192 {
+		return nil, errPointEvaluationInput
+	}
+	var commitment kzg4844.Commitment
+	var point kzg4844.Point
+	var claim kzg4844.Claim
+	var proof kzg4844.Proof
+	copy(point[:], input[32:64])
+	copy(claim[:], input[64:96])
+	copy(commitment[:], input[96:144])
+	copy(proof[:], input[144:192])
+
+	if hash := kzg4844.CalcBlobHashV1(sha256.New(), &commitment); common.Hash(hash) != common.BytesToHash(input[:32]) {
+		return nil, errPointEvaluationHash
+	}
+	if err := kzg4844.VerifyProof(commitment, point, claim, proof); err != nil {
+		return nil, err
+	}
+	output := common.LeftPadBytes(big.NewInt(params.BlobTxFieldElementsPerBlob).Bytes(), 32)
+	return append(output, blsModulus...), nil
+}
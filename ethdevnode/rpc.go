@@ -0,0 +1,948 @@
+package ethdevnode
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethrpc/jsonrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+type rpcRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type rpcResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *jsonrpc.Error  `json:"error,omitempty"`
+}
+
+var errMethodNotFound = errors.New("method not found")
+
+type invalidParamsError struct {
+	err error
+}
+
+func (e invalidParamsError) Error() string {
+	return "invalid params: " + e.err.Error()
+}
+
+// ServeHTTP serves JSON-RPC requests and batches of requests.
+func (n *Node) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	body = []byte(strings.TrimSpace(string(body)))
+	if len(body) > 0 && body[0] == '[' {
+		var reqs []rpcRequest
+		if err := json.Unmarshal(body, &reqs); err != nil {
+			json.NewEncoder(w).Encode(parseErrorResponse(err))
+			return
+		}
+		resps := make([]rpcResponse, len(reqs))
+		for i, req := range reqs {
+			resps[i] = n.serve(r.Context(), req)
+		}
+		json.NewEncoder(w).Encode(resps)
+		return
+	}
+
+	var req rpcRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		json.NewEncoder(w).Encode(parseErrorResponse(err))
+		return
+	}
+	json.NewEncoder(w).Encode(n.serve(r.Context(), req))
+}
+
+func parseErrorResponse(err error) rpcResponse {
+	return rpcResponse{
+		Version: "2.0",
+		ID:      json.RawMessage("null"),
+		Error:   &jsonrpc.Error{Code: -32700, Message: err.Error()},
+	}
+}
+
+func (n *Node) serve(ctx context.Context, req rpcRequest) rpcResponse {
+	resp := rpcResponse{Version: "2.0", ID: req.ID}
+
+	result, err := n.handle(ctx, req.Method, req.Params)
+	if err != nil {
+		var paramsErr invalidParamsError
+		var rpcErr *jsonrpc.Error
+		switch {
+		case errors.Is(err, errMethodNotFound):
+			resp.Error = &jsonrpc.Error{Code: -32601, Message: fmt.Sprintf("the method %s does not exist/is not available", req.Method)}
+		case errors.As(err, &paramsErr):
+			resp.Error = &jsonrpc.Error{Code: -32602, Message: err.Error()}
+		case errors.As(err, &rpcErr):
+			resp.Error = rpcErr
+		default:
+			resp.Error = &jsonrpc.Error{Code: -32000, Message: err.Error()}
+		}
+		return resp
+	}
+
+	if raw, ok := result.(json.RawMessage); ok {
+		resp.Result = raw
+	} else if resp.Result, err = json.Marshal(result); err != nil {
+		resp.Error = &jsonrpc.Error{Code: -32603, Message: err.Error()}
+	}
+	if len(resp.Result) == 0 {
+		resp.Result = json.RawMessage("null")
+	}
+	return resp
+}
+
+func (n *Node) handle(ctx context.Context, method string, params []json.RawMessage) (any, error) {
+	switch method {
+	case "web3_clientVersion":
+		return "ethkit/devnode", nil
+	case "net_version":
+		return n.chainID.String(), nil
+	case "net_listening":
+		return true, nil
+	case "eth_chainId":
+		return (*hexutil.Big)(n.chainID), nil
+	case "eth_syncing":
+		return false, nil
+	case "eth_mining":
+		return true, nil
+	case "eth_coinbase":
+		return common.Address{}, nil
+	case "eth_accounts":
+		accounts := make([]common.Address, len(n.wallets))
+		for i, w := range n.wallets {
+			accounts[i] = w.Address()
+		}
+		return accounts, nil
+
+	case "eth_blockNumber":
+		return hexutil.Uint64(n.BlockNumber()), nil
+	case "eth_gasPrice":
+		return (*hexutil.Big)(new(big.Int).Add(n.options.BaseFee, big.NewInt(1))), nil
+	case "eth_maxPriorityFeePerGas":
+		return (*hexutil.Big)(big.NewInt(1)), nil
+	case "eth_feeHistory":
+		return n.feeHistory(params)
+
+	case "eth_getBalance", "eth_getTransactionCount", "eth_getCode", "eth_getStorageAt":
+		return n.getAccountField(ctx, method, params)
+
+	case "eth_getBlockByNumber", "eth_getBlockByHash":
+		return n.getBlock(ctx, method, params)
+	case "eth_getTransactionByHash":
+		return n.getTransaction(ctx, params)
+	case "eth_getTransactionReceipt":
+		return n.getTransactionReceipt(ctx, params)
+	case "eth_getBlockReceipts":
+		return n.getBlockReceipts(ctx, params)
+	case "eth_getLogs":
+		return n.getLogs(ctx, params)
+
+	case "eth_call":
+		return n.call(ctx, params)
+	case "eth_estimateGas":
+		var args txArgs
+		var blockParam json.RawMessage
+		if err := parseParams(params, &args, &blockParam); err != nil {
+			return nil, err
+		}
+		num, err := n.blockNumberArg(blockParam)
+		if err != nil {
+			return nil, err
+		}
+		if n.isUpstreamState(num) {
+			return n.proxy(ctx, method, args, hexutil.Uint64(num))
+		}
+		gas, err := n.estimateGas(ctx, args, num)
+		if err != nil {
+			return nil, err
+		}
+		return hexutil.Uint64(gas), nil
+
+	case "eth_sendRawTransaction":
+		var data hexutil.Bytes
+		if err := parseParams(params, &data); err != nil {
+			return nil, err
+		}
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(data); err != nil {
+			return nil, invalidParamsError{err}
+		}
+		if err := n.SendTransaction(ctx, tx); err != nil {
+			return nil, err
+		}
+		return tx.Hash(), nil
+	case "eth_sendTransaction":
+		var args txArgs
+		if err := parseParams(params, &args); err != nil {
+			return nil, err
+		}
+		tx, err := n.signTransaction(ctx, args)
+		if err != nil {
+			return nil, err
+		}
+		if err := n.SendTransaction(ctx, tx); err != nil {
+			return nil, err
+		}
+		return tx.Hash(), nil
+
+	case "evm_mine":
+		n.Mine()
+		return "0x0", nil
+	case "evm_snapshot":
+		return hexutil.Uint64(n.Snapshot()), nil
+	case "evm_revert":
+		var id hexutil.Uint64
+		if err := parseParams(params, &id); err != nil {
+			return nil, err
+		}
+		return n.Revert(uint64(id)) == nil, nil
+
+	default:
+		return nil, errMethodNotFound
+	}
+}
+
+// parseParams decodes the positional params into args. Missing trailing params
+// are left as is.
+func parseParams(params []json.RawMessage, args ...any) error {
+	if len(params) > len(args) {
+		return invalidParamsError{fmt.Errorf("too many arguments, want at most %d", len(args))}
+	}
+	for i, param := range params {
+		if err := json.Unmarshal(param, args[i]); err != nil {
+			return invalidParamsError{fmt.Errorf("argument %d: %w", i, err)}
+		}
+	}
+	return nil
+}
+
+// blockNumberArg resolves a block number or tag to a block number. Tags other
+// than earliest resolve to the head, as blocks are mined instantly.
+func (n *Node) blockNumberArg(param json.RawMessage) (uint64, error) {
+	if len(param) == 0 {
+		return n.BlockNumber(), nil
+	}
+
+	var tag string
+	if err := json.Unmarshal(param, &tag); err != nil {
+		return 0, invalidParamsError{err}
+	}
+	switch tag {
+	case "", "latest", "pending", "safe", "finalized":
+		return n.BlockNumber(), nil
+	case "earliest":
+		return 0, nil
+	}
+	num, err := hexutil.DecodeUint64(tag)
+	if err != nil {
+		return 0, invalidParamsError{err}
+	}
+	return num, nil
+}
+
+// isUpstream returns true if the block is served by the upstream node.
+func (n *Node) isUpstream(num uint64) bool {
+	return n.fork != nil && num <= n.fork.Number.Uint64()
+}
+
+// isUpstreamState returns true if the state after the block is only known to
+// the upstream node, ie. of the blocks before the fork block. The state after
+// the fork block is the one of the upstream with the prefunded accounts.
+func (n *Node) isUpstreamState(num uint64) bool {
+	return n.fork != nil && num < n.fork.Number.Uint64()
+}
+
+func (n *Node) proxy(ctx context.Context, method string, params ...any) (json.RawMessage, error) {
+	var raw json.RawMessage
+	_, err := n.upstream.Do(ctx, ethrpc.NewCallBuilder[json.RawMessage](method, nil, params...).Into(&raw))
+	if err != nil {
+		// pass through the errors of the upstream node as is
+		var rpcErr *jsonrpc.Error
+		if errors.As(err, &rpcErr) {
+			return nil, rpcErr
+		}
+		return nil, err
+	}
+	return raw, nil
+}
+
+// stateAt returns the local state at the block number, or nil if the block is
+// served by the upstream node.
+func (n *Node) stateAt(num uint64) (map[common.Address]*account, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	first := n.blocks[0].NumberU64()
+	if num < first || num > n.head().NumberU64() {
+		return nil, fmt.Errorf("ethdevnode: header for block %d not found", num)
+	}
+	return n.states[num-first], nil
+}
+
+func (n *Node) getAccountField(ctx context.Context, method string, params []json.RawMessage) (any, error) {
+	var address common.Address
+	var slot common.Hash
+	var blockParam json.RawMessage
+	var err error
+	if method == "eth_getStorageAt" {
+		err = parseParams(params, &address, &slot, &blockParam)
+	} else {
+		err = parseParams(params, &address, &blockParam)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	num, err := n.blockNumberArg(blockParam)
+	if err != nil {
+		return nil, err
+	}
+	if n.isUpstreamState(num) {
+		if method == "eth_getStorageAt" {
+			return n.proxy(ctx, method, address, slot, hexutil.Uint64(num))
+		}
+		return n.proxy(ctx, method, address, hexutil.Uint64(num))
+	}
+
+	state, err := n.stateAt(num)
+	if err != nil {
+		return nil, err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	acc, err := n.account(ctx, state, address)
+	if err != nil {
+		return nil, err
+	}
+
+	switch method {
+	case "eth_getBalance":
+		return (*hexutil.Big)(acc.balance), nil
+	case "eth_getTransactionCount":
+		return hexutil.Uint64(acc.nonce), nil
+	case "eth_getCode":
+		return hexutil.Bytes(acc.code), nil
+	default:
+		return n.storageAt(ctx, address, acc, slot)
+	}
+}
+
+func (n *Node) getBlock(ctx context.Context, method string, params []json.RawMessage) (any, error) {
+	var blockParam json.RawMessage
+	var fullTx bool
+	if err := parseParams(params, &blockParam, &fullTx); err != nil {
+		return nil, err
+	}
+
+	var block *types.Block
+	if method == "eth_getBlockByHash" {
+		var hash common.Hash
+		if err := json.Unmarshal(blockParam, &hash); err != nil {
+			return nil, invalidParamsError{err}
+		}
+		n.mu.Lock()
+		block = n.blockHashes[hash]
+		n.mu.Unlock()
+		if block == nil {
+			if n.fork != nil {
+				return n.proxy(ctx, method, hash, fullTx)
+			}
+			return nil, nil
+		}
+	} else {
+		num, err := n.blockNumberArg(blockParam)
+		if err != nil {
+			return nil, err
+		}
+		if n.isUpstream(num) {
+			return n.proxy(ctx, method, hexutil.Uint64(num), fullTx)
+		}
+		state, _ := n.stateAt(num)
+		if state == nil {
+			return nil, nil
+		}
+		n.mu.Lock()
+		block = n.blocks[num-n.blocks[0].NumberU64()]
+		n.mu.Unlock()
+	}
+
+	return marshalBlock(block, fullTx, n.signer)
+}
+
+func (n *Node) getTransaction(ctx context.Context, params []json.RawMessage) (any, error) {
+	var hash common.Hash
+	if err := parseParams(params, &hash); err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	tx, receipt := n.txs[hash], n.receipts[hash]
+	n.mu.Unlock()
+	if tx == nil {
+		if n.fork != nil {
+			return n.proxy(ctx, "eth_getTransactionByHash", hash)
+		}
+		return nil, nil
+	}
+	return marshalTransaction(tx, receipt, n.signer)
+}
+
+func (n *Node) getTransactionReceipt(ctx context.Context, params []json.RawMessage) (any, error) {
+	var hash common.Hash
+	if err := parseParams(params, &hash); err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	tx, receipt := n.txs[hash], n.receipts[hash]
+	n.mu.Unlock()
+	if receipt == nil {
+		if n.fork != nil {
+			return n.proxy(ctx, "eth_getTransactionReceipt", hash)
+		}
+		return nil, nil
+	}
+	return n.marshalReceipt(tx, receipt)
+}
+
+func (n *Node) getBlockReceipts(ctx context.Context, params []json.RawMessage) (any, error) {
+	var blockParam json.RawMessage
+	if err := parseParams(params, &blockParam); err != nil {
+		return nil, err
+	}
+
+	var block *types.Block
+	var hash common.Hash
+	if err := json.Unmarshal(blockParam, &hash); err == nil {
+		n.mu.Lock()
+		block = n.blockHashes[hash]
+		n.mu.Unlock()
+		if block == nil {
+			if n.fork != nil {
+				return n.proxy(ctx, "eth_getBlockReceipts", hash)
+			}
+			return nil, nil
+		}
+	} else {
+		num, err := n.blockNumberArg(blockParam)
+		if err != nil {
+			return nil, err
+		}
+		if n.isUpstream(num) {
+			return n.proxy(ctx, "eth_getBlockReceipts", hexutil.Uint64(num))
+		}
+		state, _ := n.stateAt(num)
+		if state == nil {
+			return nil, nil
+		}
+		n.mu.Lock()
+		block = n.blocks[num-n.blocks[0].NumberU64()]
+		n.mu.Unlock()
+	}
+
+	receipts := make([]any, 0, len(block.Transactions()))
+	for _, tx := range block.Transactions() {
+		n.mu.Lock()
+		receipt := n.receipts[tx.Hash()]
+		n.mu.Unlock()
+		fields, err := n.marshalReceipt(tx, receipt)
+		if err != nil {
+			return nil, err
+		}
+		receipts = append(receipts, fields)
+	}
+	return receipts, nil
+}
+
+func (n *Node) marshalReceipt(tx *types.Transaction, receipt *types.Receipt) (map[string]any, error) {
+	fields, err := toFields(receipt)
+	if err != nil {
+		return nil, err
+	}
+	from, _ := types.Sender(n.signer, tx)
+	fields["from"] = from
+	fields["to"] = tx.To()
+	fields["contractAddress"] = nil
+	if tx.To() == nil {
+		fields["contractAddress"] = receipt.ContractAddress
+	}
+	return fields, nil
+}
+
+func (n *Node) getLogs(ctx context.Context, params []json.RawMessage) (any, error) {
+	var filter struct {
+		FromBlock json.RawMessage `json:"fromBlock"`
+		ToBlock   json.RawMessage `json:"toBlock"`
+		BlockHash *common.Hash    `json:"blockHash"`
+		Address   any             `json:"address,omitempty"`
+		Topics    any             `json:"topics,omitempty"`
+	}
+	if err := parseParams(params, &filter); err != nil {
+		return nil, err
+	}
+	match, err := logMatcher(filter.Address, filter.Topics)
+	if err != nil {
+		return nil, invalidParamsError{err}
+	}
+
+	if filter.BlockHash != nil {
+		n.mu.Lock()
+		block, ok := n.blockHashes[*filter.BlockHash]
+		n.mu.Unlock()
+		if ok {
+			return n.blockLogs(block.NumberU64(), block.NumberU64(), match), nil
+		}
+		if n.fork != nil {
+			return n.proxy(ctx, "eth_getLogs", filter)
+		}
+		return []*types.Log{}, nil
+	}
+
+	from, err := n.blockNumberArg(filter.FromBlock)
+	if err != nil {
+		return nil, err
+	}
+	to, err := n.blockNumberArg(filter.ToBlock)
+	if err != nil {
+		return nil, err
+	}
+	if !n.isUpstream(from) {
+		return n.blockLogs(from, to, match), nil
+	}
+
+	// the logs up to the fork block are served by the upstream
+	filter.FromBlock, _ = json.Marshal(hexutil.Uint64(from))
+	filter.ToBlock, _ = json.Marshal(hexutil.Uint64(min(to, n.fork.Number.Uint64())))
+	raw, err := n.proxy(ctx, "eth_getLogs", filter)
+	if err != nil {
+		return nil, err
+	}
+	var logs []any
+	if err := json.Unmarshal(raw, &logs); err != nil {
+		return nil, err
+	}
+	for _, log := range n.blockLogs(n.fork.Number.Uint64()+1, to, match) {
+		logs = append(logs, log)
+	}
+	if logs == nil {
+		logs = []any{}
+	}
+	return logs, nil
+}
+
+// blockLogs returns the logs of the local blocks from and to the block numbers
+// which match the filter.
+func (n *Node) blockLogs(from, to uint64, match func(*types.Log) bool) []*types.Log {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	logs := []*types.Log{}
+	first := n.blocks[0].NumberU64()
+	from, to = max(from, first), min(to, n.head().NumberU64())
+	for num := from; num <= to; num++ {
+		for _, tx := range n.blocks[num-first].Transactions() {
+			for _, log := range n.receipts[tx.Hash()].Logs {
+				if match(log) {
+					logs = append(logs, log)
+				}
+			}
+		}
+	}
+	return logs
+}
+
+// logMatcher returns the matcher of the address and topics of a log filter, ie.
+// of an address or a list of addresses, and of a list of topics of which each
+// is null, a topic or a list of topics.
+func logMatcher(address any, topics any) (func(*types.Log) bool, error) {
+	var addresses []common.Address
+	if err := decodeFilterField(address, &addresses); err != nil {
+		return nil, fmt.Errorf("invalid address: %w", err)
+	}
+
+	var positions []any
+	if topics != nil {
+		var ok bool
+		if positions, ok = topics.([]any); !ok {
+			return nil, errors.New("invalid topics: not a list")
+		}
+	}
+	topicSets := make([][]common.Hash, len(positions))
+	for i, position := range positions {
+		if err := decodeFilterField(position, &topicSets[i]); err != nil {
+			return nil, fmt.Errorf("invalid topic %d: %w", i, err)
+		}
+	}
+
+	return func(log *types.Log) bool {
+		if len(addresses) > 0 && !slices.Contains(addresses, log.Address) {
+			return false
+		}
+		if len(topicSets) > len(log.Topics) {
+			return false
+		}
+		for i, set := range topicSets {
+			if len(set) > 0 && !slices.Contains(set, log.Topics[i]) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// decodeFilterField decodes a value or a list of values of a log filter into
+// values, which are left empty when the field is null.
+func decodeFilterField[T any](field any, values *[]T) error {
+	if field == nil {
+		return nil
+	}
+	data, err := json.Marshal(field)
+	if err != nil {
+		return err
+	}
+	if _, ok := field.([]any); ok {
+		return json.Unmarshal(data, values)
+	}
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*values = []T{value}
+	return nil
+}
+
+func (n *Node) call(ctx context.Context, params []json.RawMessage) (any, error) {
+	var args txArgs
+	var blockParam json.RawMessage
+	if err := parseParams(params, &args, &blockParam); err != nil {
+		return nil, err
+	}
+	num, err := n.blockNumberArg(blockParam)
+	if err != nil {
+		return nil, err
+	}
+	if n.isUpstreamState(num) {
+		return n.proxy(ctx, "eth_call", args, hexutil.Uint64(num))
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	result, err := n.doCall(ctx, args, num, 0)
+	if err != nil {
+		return nil, err
+	}
+	if result.err != nil {
+		return nil, executionError(result)
+	}
+	return hexutil.Bytes(result.ret), nil
+}
+
+// estimateGas returns the lowest gas limit of which the call succeeds, ie. of a
+// binary search up to the gas of the call or the block gas limit.
+func (n *Node) estimateGas(ctx context.Context, args txArgs, num uint64) (uint64, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	hi := n.options.GasLimit
+	if args.Gas != nil {
+		hi = uint64(*args.Gas)
+	}
+	result, err := n.doCall(ctx, args, num, hi)
+	if err != nil {
+		return 0, err
+	}
+	if result.err != nil {
+		if result.err == errExecutionReverted {
+			return 0, executionError(result)
+		}
+		return 0, fmt.Errorf("ethdevnode: gas required exceeds allowance (%d): %w", hi, result.err)
+	}
+
+	// the gas used is the lower bound, as of the gas refunded and the gas
+	// left to the calls
+	lo := result.gasUsed - 1
+	for lo+1 < hi {
+		mid := lo + (hi-lo)/2
+		result, err := n.doCall(ctx, args, num, mid)
+		if err == nil && result.err == nil {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	return hi, nil
+}
+
+// doCall executes the call on top of the state after the block number, of the
+// gas of the call or the gas given, or the block gas limit. It must be called
+// with the lock held.
+func (n *Node) doCall(ctx context.Context, args txArgs, num uint64, gas uint64) (*executionResult, error) {
+	first := n.blocks[0].NumberU64()
+	if num < first || num > n.head().NumberU64() {
+		return nil, fmt.Errorf("ethdevnode: header for block %d not found", num)
+	}
+	header := types.CopyHeader(n.blocks[num-first].Header())
+
+	msg := callMessage{
+		to:         args.To,
+		gas:        n.options.GasLimit,
+		gasPrice:   new(big.Int),
+		value:      new(big.Int),
+		data:       args.data(),
+		accessList: args.AccessList,
+	}
+	if args.From != nil {
+		msg.from = *args.From
+	}
+	if args.Gas != nil {
+		msg.gas = uint64(*args.Gas)
+	}
+	if gas > 0 {
+		msg.gas = gas
+	}
+	if args.GasPrice != nil {
+		msg.gasPrice = args.GasPrice.ToInt()
+	} else if args.MaxFeePerGas != nil {
+		msg.gasPrice = args.MaxFeePerGas.ToInt()
+	}
+	if msg.gasPrice.Sign() == 0 {
+		// calls without a gas price pay no base fee
+		header.BaseFee = new(big.Int)
+	}
+	if args.Value != nil {
+		msg.value = args.Value.ToInt()
+	}
+
+	state := newTxState(ctx, n, n.states[num-first])
+	return n.applyMessage(state, header, msg)
+}
+
+// executionError returns the error of the failed execution, of the revert
+// reason and data of a revert as the error of geth.
+func executionError(result *executionResult) error {
+	if result.err != errExecutionReverted {
+		return result.err
+	}
+	message := "execution reverted"
+	if reason, err := ethcoder.DecodeRevertReason(result.ret); err == nil {
+		message += ": " + reason
+	}
+	data, _ := json.Marshal(hexutil.Bytes(result.ret))
+	return &jsonrpc.Error{Code: 3, Message: message, Data: data}
+}
+
+func (n *Node) feeHistory(params []json.RawMessage) (any, error) {
+	var count hexutil.Uint64
+	var lastParam json.RawMessage
+	var percentiles []float64
+	if err := parseParams(params, &count, &lastParam, &percentiles); err != nil {
+		return nil, err
+	}
+	last, err := n.blockNumberArg(lastParam)
+	if err != nil {
+		return nil, err
+	}
+	count = min(count, hexutil.Uint64(last+1), 1024)
+
+	result := struct {
+		OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+		BaseFee      []*hexutil.Big   `json:"baseFeePerGas"`
+		GasUsedRatio []float64        `json:"gasUsedRatio"`
+		Reward       [][]*hexutil.Big `json:"reward,omitempty"`
+	}{
+		OldestBlock: (*hexutil.Big)(new(big.Int).SetUint64(last + 1 - uint64(count))),
+	}
+	for i := uint64(0); i <= uint64(count); i++ {
+		result.BaseFee = append(result.BaseFee, (*hexutil.Big)(n.options.BaseFee))
+	}
+	for i := uint64(0); i < uint64(count); i++ {
+		result.GasUsedRatio = append(result.GasUsedRatio, 0)
+		if len(percentiles) > 0 {
+			reward := make([]*hexutil.Big, len(percentiles))
+			for j := range reward {
+				reward[j] = (*hexutil.Big)(big.NewInt(1))
+			}
+			result.Reward = append(result.Reward, reward)
+		}
+	}
+	return result, nil
+}
+
+type txArgs struct {
+	From                 *common.Address  `json:"from"`
+	To                   *common.Address  `json:"to"`
+	Gas                  *hexutil.Uint64  `json:"gas"`
+	GasPrice             *hexutil.Big     `json:"gasPrice"`
+	MaxFeePerGas         *hexutil.Big     `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big     `json:"maxPriorityFeePerGas"`
+	Value                *hexutil.Big     `json:"value"`
+	Nonce                *hexutil.Uint64  `json:"nonce"`
+	Data                 *hexutil.Bytes   `json:"data"`
+	Input                *hexutil.Bytes   `json:"input"`
+	AccessList           types.AccessList `json:"accessList"`
+}
+
+func (args *txArgs) data() []byte {
+	if args.Input != nil {
+		return *args.Input
+	}
+	if args.Data != nil {
+		return *args.Data
+	}
+	return nil
+}
+
+// signTransaction fills and signs the transaction of eth_sendTransaction with
+// the key of a prefunded account.
+func (n *Node) signTransaction(ctx context.Context, args txArgs) (*types.Transaction, error) {
+	if args.From == nil {
+		return nil, invalidParamsError{errors.New("missing from")}
+	}
+	key, ok := n.keys[*args.From]
+	if !ok {
+		return nil, ErrUnknownAccount
+	}
+
+	var nonce uint64
+	if args.Nonce != nil {
+		nonce = uint64(*args.Nonce)
+	} else {
+		n.mu.Lock()
+		acc, err := n.account(ctx, n.states[len(n.states)-1], *args.From)
+		n.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		nonce = acc.nonce
+	}
+
+	var gas uint64
+	if args.Gas != nil {
+		gas = uint64(*args.Gas)
+	} else {
+		estimate, err := n.estimateGas(ctx, args, n.BlockNumber())
+		if err != nil {
+			return nil, err
+		}
+		gas = estimate
+	}
+	value := new(big.Int)
+	if args.Value != nil {
+		value = args.Value.ToInt()
+	}
+
+	var txData types.TxData
+	if args.GasPrice != nil {
+		txData = &types.LegacyTx{
+			Nonce:    nonce,
+			GasPrice: args.GasPrice.ToInt(),
+			Gas:      gas,
+			To:       args.To,
+			Value:    value,
+			Data:     args.data(),
+		}
+	} else {
+		tip := big.NewInt(1)
+		if args.MaxPriorityFeePerGas != nil {
+			tip = args.MaxPriorityFeePerGas.ToInt()
+		}
+		feeCap := new(big.Int).Add(new(big.Int).Mul(n.options.BaseFee, big.NewInt(2)), tip)
+		if args.MaxFeePerGas != nil {
+			feeCap = args.MaxFeePerGas.ToInt()
+		}
+		txData = &types.DynamicFeeTx{
+			ChainID:    n.chainID,
+			Nonce:      nonce,
+			GasTipCap:  tip,
+			GasFeeCap:  feeCap,
+			Gas:        gas,
+			To:         args.To,
+			Value:      value,
+			Data:       args.data(),
+			AccessList: args.AccessList,
+		}
+	}
+	return types.SignNewTx(key, n.signer, txData)
+}
+
+func marshalBlock(block *types.Block, fullTx bool, signer types.Signer) (map[string]any, error) {
+	fields, err := toFields(block.Header())
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range fields {
+		if v == nil {
+			// unset optional fields, ie. of forks not active on the dev chain
+			delete(fields, k)
+		}
+	}
+	fields["hash"] = block.Hash()
+	fields["size"] = hexutil.Uint64(block.Size())
+	fields["totalDifficulty"] = (*hexutil.Big)(new(big.Int))
+	fields["uncles"] = []common.Hash{}
+
+	txs := make([]any, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		if !fullTx {
+			txs[i] = tx.Hash()
+			continue
+		}
+		receipt := &types.Receipt{BlockHash: block.Hash(), BlockNumber: block.Number(), TransactionIndex: uint(i)}
+		if txs[i], err = marshalTransaction(tx, receipt, signer); err != nil {
+			return nil, err
+		}
+	}
+	fields["transactions"] = txs
+	return fields, nil
+}
+
+func marshalTransaction(tx *types.Transaction, receipt *types.Receipt, signer types.Signer) (map[string]any, error) {
+	fields, err := toFields(tx)
+	if err != nil {
+		return nil, err
+	}
+	from, _ := types.Sender(signer, tx)
+	fields["from"] = from
+	fields["blockHash"] = receipt.BlockHash
+	fields["blockNumber"] = (*hexutil.Big)(receipt.BlockNumber)
+	fields["transactionIndex"] = hexutil.Uint(receipt.TransactionIndex)
+	return fields, nil
+}
+
+func toFields(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
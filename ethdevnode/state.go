@@ -0,0 +1,306 @@
+package ethdevnode
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// txState is the state of a transaction on top of the state of its parent
+// block. The accounts are copied on their first write, and the writes are
+// journaled to revert the ones of the failed calls.
+type txState struct {
+	ctx    context.Context
+	node   *Node
+	parent map[common.Address]*account
+	dirty  map[common.Address]*account
+
+	journal    []func()
+	transient  map[common.Address]map[common.Hash]common.Hash
+	warm       map[common.Address]map[common.Hash]bool
+	created    map[common.Address]bool
+	destructed map[common.Address]bool
+	refund     uint64
+	logs       []*types.Log
+
+	// err is the first error of reading an account from the upstream node of a
+	// fork, of which the account is read as empty
+	err error
+}
+
+// newTxState must be called with the lock held, so must the methods of the
+// txState.
+func newTxState(ctx context.Context, node *Node, parent map[common.Address]*account) *txState {
+	return &txState{
+		ctx:        ctx,
+		node:       node,
+		parent:     parent,
+		dirty:      map[common.Address]*account{},
+		transient:  map[common.Address]map[common.Hash]common.Hash{},
+		warm:       map[common.Address]map[common.Hash]bool{},
+		created:    map[common.Address]bool{},
+		destructed: map[common.Address]bool{},
+	}
+}
+
+// commit returns the state of the block of the transaction.
+func (s *txState) commit() map[common.Address]*account {
+	state := maps.Clone(s.parent)
+	for address, acc := range s.dirty {
+		state[address] = acc
+	}
+	for address := range s.destructed {
+		state[address] = &account{balance: new(big.Int)}
+	}
+	return state
+}
+
+func (s *txState) snapshot() int {
+	return len(s.journal)
+}
+
+func (s *txState) revertToSnapshot(id int) {
+	for i := len(s.journal) - 1; i >= id; i-- {
+		s.journal[i]()
+	}
+	s.journal = s.journal[:id]
+}
+
+func (s *txState) get(address common.Address) *account {
+	if acc, ok := s.dirty[address]; ok {
+		return acc
+	}
+	acc, err := s.node.account(s.ctx, s.parent, address)
+	if err != nil {
+		if s.err == nil {
+			s.err = err
+		}
+		return &account{balance: new(big.Int)}
+	}
+	return acc
+}
+
+// write returns the copy of the account of the transaction, to modify.
+func (s *txState) write(address common.Address) *account {
+	if acc, ok := s.dirty[address]; ok {
+		return acc
+	}
+	acc := s.get(address).copy()
+	s.dirty[address] = acc
+	s.journal = append(s.journal, func() { delete(s.dirty, address) })
+	return acc
+}
+
+// empty reports whether the account is empty, ie. of no nonce, balance and
+// code, as the accounts which don't exist.
+func (s *txState) empty(address common.Address) bool {
+	acc := s.get(address)
+	return acc.nonce == 0 && acc.balance.Sign() == 0 && len(acc.code) == 0
+}
+
+func (s *txState) balance(address common.Address) *big.Int {
+	return s.get(address).balance
+}
+
+func (s *txState) addBalance(address common.Address, amount *big.Int) {
+	if amount.Sign() == 0 {
+		// touches the account, as a transfer of zero does
+		s.write(address)
+		return
+	}
+	acc := s.write(address)
+	prev := acc.balance
+	acc.balance = new(big.Int).Add(prev, amount)
+	s.journal = append(s.journal, func() { acc.balance = prev })
+}
+
+func (s *txState) subBalance(address common.Address, amount *big.Int) {
+	s.addBalance(address, new(big.Int).Neg(amount))
+}
+
+func (s *txState) nonce(address common.Address) uint64 {
+	return s.get(address).nonce
+}
+
+func (s *txState) setNonce(address common.Address, nonce uint64) {
+	acc := s.write(address)
+	prev := acc.nonce
+	acc.nonce = nonce
+	s.journal = append(s.journal, func() { acc.nonce = prev })
+}
+
+func (s *txState) code(address common.Address) []byte {
+	return s.get(address).code
+}
+
+// codeHash returns the hash of the code of the account, or the zero hash of an
+// empty account.
+func (s *txState) codeHash(address common.Address) common.Hash {
+	if s.empty(address) {
+		return common.Hash{}
+	}
+	return crypto.Keccak256Hash(s.get(address).code)
+}
+
+func (s *txState) setCode(address common.Address, code []byte) {
+	acc := s.write(address)
+	prev := acc.code
+	acc.code = code
+	s.journal = append(s.journal, func() { acc.code = prev })
+}
+
+// createAccount resets the account at the address of a contract creation, of
+// which the balance is kept.
+func (s *txState) createAccount(address common.Address) {
+	prev, ok := s.dirty[address]
+	s.dirty[address] = &account{balance: new(big.Int).Set(s.balance(address))}
+	s.created[address] = true
+	s.journal = append(s.journal, func() {
+		delete(s.created, address)
+		if ok {
+			s.dirty[address] = prev
+		} else {
+			delete(s.dirty, address)
+		}
+	})
+}
+
+// selfDestruct removes the account at the end of the transaction, if it's
+// created by the transaction as of EIP-6780.
+func (s *txState) selfDestruct(address common.Address) {
+	if !s.created[address] || s.destructed[address] {
+		return
+	}
+	s.destructed[address] = true
+	s.journal = append(s.journal, func() { delete(s.destructed, address) })
+}
+
+func (s *txState) storage(address common.Address, slot common.Hash) common.Hash {
+	value, err := s.node.storageAt(s.ctx, address, s.get(address), slot)
+	if err != nil && s.err == nil {
+		s.err = err
+	}
+	return value
+}
+
+// originalStorage returns the value of the slot at the start of the
+// transaction.
+func (s *txState) originalStorage(address common.Address, slot common.Hash) common.Hash {
+	if s.created[address] {
+		return common.Hash{}
+	}
+	acc, err := s.node.account(s.ctx, s.parent, address)
+	if err != nil {
+		return common.Hash{}
+	}
+	value, err := s.node.storageAt(s.ctx, address, acc, slot)
+	if err != nil && s.err == nil {
+		s.err = err
+	}
+	return value
+}
+
+func (s *txState) setStorage(address common.Address, slot, value common.Hash) {
+	acc := s.write(address)
+	prev, ok := acc.storage[slot]
+	if acc.storage == nil {
+		acc.storage = map[common.Hash]common.Hash{}
+	}
+	acc.storage[slot] = value
+	s.journal = append(s.journal, func() {
+		if ok {
+			acc.storage[slot] = prev
+		} else {
+			delete(acc.storage, slot)
+		}
+	})
+}
+
+func (s *txState) transientStorage(address common.Address, slot common.Hash) common.Hash {
+	return s.transient[address][slot]
+}
+
+func (s *txState) setTransientStorage(address common.Address, slot, value common.Hash) {
+	if s.transient[address] == nil {
+		s.transient[address] = map[common.Hash]common.Hash{}
+	}
+	prev := s.transient[address][slot]
+	s.transient[address][slot] = value
+	s.journal = append(s.journal, func() { s.transient[address][slot] = prev })
+}
+
+// warmAddress marks the address as accessed, as of EIP-2929, and reports
+// whether it was.
+func (s *txState) warmAddress(address common.Address) bool {
+	if _, ok := s.warm[address]; ok {
+		return true
+	}
+	s.warm[address] = map[common.Hash]bool{}
+	s.journal = append(s.journal, func() { delete(s.warm, address) })
+	return false
+}
+
+// warmSlot marks the slot as accessed, as of EIP-2929, and reports whether it
+// was.
+func (s *txState) warmSlot(address common.Address, slot common.Hash) bool {
+	s.warmAddress(address)
+	if s.warm[address][slot] {
+		return true
+	}
+	s.warm[address][slot] = true
+	s.journal = append(s.journal, func() {
+		if slots, ok := s.warm[address]; ok {
+			delete(slots, slot)
+		}
+	})
+	return false
+}
+
+func (s *txState) addRefund(gas uint64) {
+	prev := s.refund
+	s.refund += gas
+	s.journal = append(s.journal, func() { s.refund = prev })
+}
+
+func (s *txState) subRefund(gas uint64) {
+	prev := s.refund
+	s.refund -= min(gas, s.refund)
+	s.journal = append(s.journal, func() { s.refund = prev })
+}
+
+func (s *txState) addLog(log *types.Log) {
+	s.logs = append(s.logs, log)
+	s.journal = append(s.journal, func() { s.logs = s.logs[:len(s.logs)-1] })
+}
+
+// storageAt returns the value of the slot of the account, or of a forked
+// account from the upstream node at the fork block if the slot is not written
+// locally. It must be called with the lock held.
+func (n *Node) storageAt(ctx context.Context, address common.Address, acc *account, slot common.Hash) (common.Hash, error) {
+	if value, ok := acc.storage[slot]; ok {
+		return value, nil
+	}
+	if !acc.forked {
+		return common.Hash{}, nil
+	}
+	if value, ok := n.forkStorage[address][slot]; ok {
+		return value, nil
+	}
+	var data []byte
+	_, err := n.upstream.Do(ctx, ethrpc.StorageAt(address, slot, n.fork.Number).Into(&data))
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("ethdevnode: failed to fetch storage of %s from the upstream: %w", address, err)
+	}
+	if n.forkStorage[address] == nil {
+		n.forkStorage[address] = map[common.Hash]common.Hash{}
+	}
+	value := common.BytesToHash(data)
+	n.forkStorage[address][slot] = value
+	return value, nil
+}
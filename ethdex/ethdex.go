@@ -0,0 +1,62 @@
+// Package ethdex provides read-side quoting of Uniswap v2 and v3 style pools,
+// to sanity-check prices and estimate swap outputs.
+package ethdex
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi/bind"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// Uniswap deployments on Ethereum mainnet. Forks, and Uniswap on other chains,
+// are deployed at different addresses.
+var (
+	UniswapV2FactoryAddress  = common.HexToAddress("0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f")
+	UniswapV3FactoryAddress  = common.HexToAddress("0x1F98431c8aD98523631AE4a59f267346ea31F984")
+	UniswapV3QuoterV2Address = common.HexToAddress("0x61fFE014bA17989E743c5F6cB21bF9697530B21e")
+)
+
+// AdjustDecimals converts a price of raw token units, as returned by SpotPrice,
+// to a price of whole tokens.
+func AdjustDecimals(price *big.Rat, decimals0, decimals1 uint8) *big.Rat {
+	adjusted := new(big.Rat).Set(price)
+	if decimals0 > decimals1 {
+		return adjusted.Mul(adjusted, new(big.Rat).SetInt(pow10(decimals0-decimals1)))
+	}
+	return adjusted.Quo(adjusted, new(big.Rat).SetInt(pow10(decimals1-decimals0)))
+}
+
+func pow10(n uint8) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func fetchTokens(ctx context.Context, caller bind.ContractCaller, address common.Address, contractABI abi.ABI) ([2]common.Address, error) {
+	var tokens [2]common.Address
+	if err := call(ctx, caller, address, contractABI, &tokens[0], "token0"); err != nil {
+		return tokens, err
+	}
+	if err := call(ctx, caller, address, contractABI, &tokens[1], "token1"); err != nil {
+		return tokens, err
+	}
+	return tokens, nil
+}
+
+func call(ctx context.Context, caller bind.ContractCaller, address common.Address, contractABI abi.ABI, result interface{}, method string, args ...interface{}) error {
+	data, err := contractABI.Pack(method, args...)
+	if err != nil {
+		return fmt.Errorf("ethdex: failed to encode %s: %w", method, err)
+	}
+	output, err := caller.CallContract(ctx, ethereum.CallMsg{To: &address, Data: data}, nil)
+	if err != nil {
+		return err
+	}
+	if err := contractABI.UnpackIntoInterface(result, method, output); err != nil {
+		return fmt.Errorf("ethdex: failed to decode %s of %s: %w", method, address, err)
+	}
+	return nil
+}
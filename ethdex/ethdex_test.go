@@ -0,0 +1,111 @@
+package ethdex_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethdex"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	weth = common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	usdc = common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+)
+
+type mockCaller struct {
+	sqrtPriceX96 *big.Int
+}
+
+func (m *mockCaller) CodeAt(ctx context.Context, contract common.Address, blockNum *big.Int) ([]byte, error) {
+	return []byte{0x00}, nil
+}
+
+func (m *mockCaller) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNum *big.Int) ([]byte, error) {
+	for _, contractABI := range []abi.ABI{ethdex.UniswapV2PairABI, ethdex.UniswapV3PoolABI, ethdex.UniswapV3QuoterV2ABI} {
+		method, err := contractABI.MethodById(msg.Data[:4])
+		if err != nil {
+			continue
+		}
+		switch method.Name {
+		case "token0":
+			return method.Outputs.Pack(usdc)
+		case "token1":
+			return method.Outputs.Pack(weth)
+		case "getReserves":
+			// 200,000 USDC and 100 WETH
+			return method.Outputs.Pack(big.NewInt(200000e6), new(big.Int).Mul(big.NewInt(100), big.NewInt(1e18)), uint32(0))
+		case "slot0":
+			return method.Outputs.Pack(m.sqrtPriceX96, big.NewInt(0), uint16(0), uint16(1), uint16(1), uint8(0), true)
+		case "quoteExactInput":
+			args, err := method.Inputs.Unpack(msg.Data[4:])
+			if err != nil {
+				return nil, err
+			}
+			path := args[0].([]byte)
+			sqrtPrices := make([]*big.Int, (len(path)-20)/23)
+			for i := range sqrtPrices {
+				sqrtPrices[i] = m.sqrtPriceX96
+			}
+			return method.Outputs.Pack(args[1], sqrtPrices, make([]uint32, len(sqrtPrices)), big.NewInt(100000))
+		}
+	}
+	return nil, errors.New("execution reverted")
+}
+
+func TestV2Pair(t *testing.T) {
+	ctx := context.Background()
+	pair := ethdex.NewV2Pair(&mockCaller{}, common.HexToAddress("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"))
+
+	price, err := pair.SpotPrice(ctx)
+	require.NoError(t, err)
+	// 1 USDC = 0.0005 WETH
+	assert.Equal(t, "0.000500000000000000", ethdex.AdjustDecimals(price, 6, 18).FloatString(18))
+
+	out, err := pair.QuoteExactInput(ctx, weth, big.NewInt(1e18))
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(1974316068), out)
+
+	_, err = pair.QuoteExactInput(ctx, common.HexToAddress("0x1"), big.NewInt(1e18))
+	assert.Error(t, err)
+}
+
+func TestV3Pool(t *testing.T) {
+	ctx := context.Background()
+
+	// sqrt(4) * 2^96, ie. a price of 4
+	sqrtPriceX96 := new(big.Int).Lsh(big.NewInt(2), 96)
+	pool := ethdex.NewV3Pool(&mockCaller{sqrtPriceX96: sqrtPriceX96}, common.HexToAddress("0x88e6A0c2dDD26FEEb64F039a2c41296FcB3f5640"))
+
+	price, err := pool.SpotPrice(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewRat(4, 1), price)
+
+	token0, token1, err := pool.Tokens(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, usdc, token0)
+	assert.Equal(t, weth, token1)
+}
+
+func TestV3Quoter(t *testing.T) {
+	path, err := ethdex.EncodeV3Path([]common.Address{usdc, weth}, []uint32{ethdex.V3FeeLow})
+	require.NoError(t, err)
+	assert.Equal(t, "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb480001f4c02aaa39b223fe8d0a0e5c4f27ead9083c756cc2", ethcoder.HexEncode(path))
+
+	_, err = ethdex.EncodeV3Path([]common.Address{usdc, weth}, nil)
+	assert.Error(t, err)
+
+	quoter := ethdex.NewV3Quoter(&mockCaller{sqrtPriceX96: big.NewInt(1)}, ethdex.UniswapV3QuoterV2Address)
+	quote, err := quoter.QuoteExactInput(context.Background(), []common.Address{usdc, weth, usdc}, []uint32{ethdex.V3FeeLow, ethdex.V3FeeMedium}, big.NewInt(1000))
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(1000), quote.AmountOut)
+	assert.Len(t, quote.SqrtPriceX96After, 2)
+	assert.Equal(t, big.NewInt(100000), quote.GasEstimate)
+}
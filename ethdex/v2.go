@@ -0,0 +1,134 @@
+package ethdex
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethcontract"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi/bind"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+var UniswapV2PairABI = ethcontract.MustParseABI(`[
+	{"type":"function","name":"token0","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address"}]},
+	{"type":"function","name":"token1","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address"}]},
+	{"type":"function","name":"getReserves","stateMutability":"view","inputs":[],"outputs":[{"name":"reserve0","type":"uint112"},{"name":"reserve1","type":"uint112"},{"name":"blockTimestampLast","type":"uint32"}]}
+]`)
+
+var UniswapV2FactoryABI = ethcontract.MustParseABI(`[
+	{"type":"function","name":"getPair","stateMutability":"view","inputs":[{"name":"tokenA","type":"address"},{"name":"tokenB","type":"address"}],"outputs":[{"name":"pair","type":"address"}]}
+]`)
+
+// UniswapV2FeeBps is the swap fee of Uniswap v2 pairs, in basis points.
+const UniswapV2FeeBps = 30
+
+type V2Reserves struct {
+	Reserve0           *big.Int
+	Reserve1           *big.Int
+	BlockTimestampLast uint32
+}
+
+// V2Pair reads a Uniswap v2 style pair.
+type V2Pair struct {
+	Address common.Address
+
+	// FeeBps is the swap fee of the pair, which differs between forks.
+	FeeBps uint64
+
+	caller bind.ContractCaller
+	tokens *[2]common.Address
+}
+
+func NewV2Pair(caller bind.ContractCaller, address common.Address) *V2Pair {
+	return &V2Pair{
+		Address: address,
+		FeeBps:  UniswapV2FeeBps,
+		caller:  caller,
+	}
+}
+
+// FindV2Pair returns the pair of tokenA and tokenB from the factory, or an error
+// if the pair does not exist.
+func FindV2Pair(ctx context.Context, caller bind.ContractCaller, factory, tokenA, tokenB common.Address) (*V2Pair, error) {
+	var pair common.Address
+	if err := call(ctx, caller, factory, UniswapV2FactoryABI, &pair, "getPair", tokenA, tokenB); err != nil {
+		return nil, err
+	}
+	if pair == (common.Address{}) {
+		return nil, fmt.Errorf("ethdex: no pair for %s and %s", tokenA, tokenB)
+	}
+	return NewV2Pair(caller, pair), nil
+}
+
+// Tokens returns token0 and token1 of the pair. The value is cached after the
+// first call.
+func (p *V2Pair) Tokens(ctx context.Context) (common.Address, common.Address, error) {
+	if p.tokens == nil {
+		tokens, err := fetchTokens(ctx, p.caller, p.Address, UniswapV2PairABI)
+		if err != nil {
+			return common.Address{}, common.Address{}, err
+		}
+		p.tokens = &tokens
+	}
+	return p.tokens[0], p.tokens[1], nil
+}
+
+func (p *V2Pair) Reserves(ctx context.Context) (*V2Reserves, error) {
+	var reserves V2Reserves
+	if err := call(ctx, p.caller, p.Address, UniswapV2PairABI, &reserves, "getReserves"); err != nil {
+		return nil, err
+	}
+	return &reserves, nil
+}
+
+// SpotPrice returns the price of token0 in raw units of token1, from the
+// reserves of the pair. See AdjustDecimals.
+func (p *V2Pair) SpotPrice(ctx context.Context) (*big.Rat, error) {
+	reserves, err := p.Reserves(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if reserves.Reserve0.Sign() == 0 {
+		return nil, fmt.Errorf("ethdex: pair %s has no liquidity", p.Address)
+	}
+	return new(big.Rat).SetFrac(reserves.Reserve1, reserves.Reserve0), nil
+}
+
+// QuoteExactInput returns the amount of the other token received for swapping
+// amountIn of tokenIn, at the current reserves of the pair.
+func (p *V2Pair) QuoteExactInput(ctx context.Context, tokenIn common.Address, amountIn *big.Int) (*big.Int, error) {
+	token0, token1, err := p.Tokens(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reserves, err := p.Reserves(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch tokenIn {
+	case token0:
+		return V2AmountOut(amountIn, reserves.Reserve0, reserves.Reserve1, p.FeeBps)
+	case token1:
+		return V2AmountOut(amountIn, reserves.Reserve1, reserves.Reserve0, p.FeeBps)
+	default:
+		return nil, fmt.Errorf("ethdex: token %s is not in pair %s", tokenIn, p.Address)
+	}
+}
+
+// V2AmountOut is the constant product formula of UniswapV2Library.getAmountOut,
+// with the fee in basis points.
+func V2AmountOut(amountIn, reserveIn, reserveOut *big.Int, feeBps uint64) (*big.Int, error) {
+	if amountIn.Sign() <= 0 {
+		return nil, fmt.Errorf("ethdex: insufficient input amount")
+	}
+	if reserveIn.Sign() <= 0 || reserveOut.Sign() <= 0 {
+		return nil, fmt.Errorf("ethdex: insufficient liquidity")
+	}
+	amountInWithFee := new(big.Int).Mul(amountIn, new(big.Int).SetUint64(10000-feeBps))
+	numerator := new(big.Int).Mul(amountInWithFee, reserveOut)
+	denominator := new(big.Int).Mul(reserveIn, big.NewInt(10000))
+	denominator.Add(denominator, amountInWithFee)
+	return numerator.Quo(numerator, denominator), nil
+}
@@ -0,0 +1,231 @@
+package ethdex
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethcontract"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi/bind"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+var UniswapV3PoolABI = ethcontract.MustParseABI(`[
+	{"type":"function","name":"token0","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address"}]},
+	{"type":"function","name":"token1","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"address"}]},
+	{"type":"function","name":"fee","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint24"}]},
+	{"type":"function","name":"liquidity","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint128"}]},
+	{"type":"function","name":"slot0","stateMutability":"view","inputs":[],"outputs":[{"name":"sqrtPriceX96","type":"uint160"},{"name":"tick","type":"int24"},{"name":"observationIndex","type":"uint16"},{"name":"observationCardinality","type":"uint16"},{"name":"observationCardinalityNext","type":"uint16"},{"name":"feeProtocol","type":"uint8"},{"name":"unlocked","type":"bool"}]}
+]`)
+
+var UniswapV3FactoryABI = ethcontract.MustParseABI(`[
+	{"type":"function","name":"getPool","stateMutability":"view","inputs":[{"name":"tokenA","type":"address"},{"name":"tokenB","type":"address"},{"name":"fee","type":"uint24"}],"outputs":[{"name":"pool","type":"address"}]}
+]`)
+
+var UniswapV3QuoterV2ABI = ethcontract.MustParseABI(`[
+	{"type":"function","name":"quoteExactInputSingle","stateMutability":"nonpayable","inputs":[{"name":"params","type":"tuple","components":[{"name":"tokenIn","type":"address"},{"name":"tokenOut","type":"address"},{"name":"amountIn","type":"uint256"},{"name":"fee","type":"uint24"},{"name":"sqrtPriceLimitX96","type":"uint160"}]}],"outputs":[{"name":"amountOut","type":"uint256"},{"name":"sqrtPriceX96After","type":"uint160"},{"name":"initializedTicksCrossed","type":"uint32"},{"name":"gasEstimate","type":"uint256"}]},
+	{"type":"function","name":"quoteExactInput","stateMutability":"nonpayable","inputs":[{"name":"path","type":"bytes"},{"name":"amountIn","type":"uint256"}],"outputs":[{"name":"amountOut","type":"uint256"},{"name":"sqrtPriceX96AfterList","type":"uint160[]"},{"name":"initializedTicksCrossedList","type":"uint32[]"},{"name":"gasEstimate","type":"uint256"}]}
+]`)
+
+// Common fee tiers of Uniswap v3 pools, in hundredths of a basis point.
+const (
+	V3FeeLowest uint32 = 100
+	V3FeeLow    uint32 = 500
+	V3FeeMedium uint32 = 3000
+	V3FeeHigh   uint32 = 10000
+)
+
+var q192 = new(big.Int).Lsh(big.NewInt(1), 192)
+
+type V3Slot0 struct {
+	SqrtPriceX96               *big.Int
+	Tick                       *big.Int
+	ObservationIndex           uint16
+	ObservationCardinality     uint16
+	ObservationCardinalityNext uint16
+	FeeProtocol                uint8
+	Unlocked                   bool
+}
+
+// V3Pool reads a Uniswap v3 style pool.
+type V3Pool struct {
+	Address common.Address
+	caller  bind.ContractCaller
+	tokens  *[2]common.Address
+}
+
+func NewV3Pool(caller bind.ContractCaller, address common.Address) *V3Pool {
+	return &V3Pool{
+		Address: address,
+		caller:  caller,
+	}
+}
+
+// FindV3Pool returns the pool of tokenA and tokenB with the fee tier from the
+// factory, or an error if the pool does not exist.
+func FindV3Pool(ctx context.Context, caller bind.ContractCaller, factory, tokenA, tokenB common.Address, fee uint32) (*V3Pool, error) {
+	var pool common.Address
+	if err := call(ctx, caller, factory, UniswapV3FactoryABI, &pool, "getPool", tokenA, tokenB, big.NewInt(int64(fee))); err != nil {
+		return nil, err
+	}
+	if pool == (common.Address{}) {
+		return nil, fmt.Errorf("ethdex: no pool for %s and %s with fee %d", tokenA, tokenB, fee)
+	}
+	return NewV3Pool(caller, pool), nil
+}
+
+// Tokens returns token0 and token1 of the pool. The value is cached after the
+// first call.
+func (p *V3Pool) Tokens(ctx context.Context) (common.Address, common.Address, error) {
+	if p.tokens == nil {
+		tokens, err := fetchTokens(ctx, p.caller, p.Address, UniswapV3PoolABI)
+		if err != nil {
+			return common.Address{}, common.Address{}, err
+		}
+		p.tokens = &tokens
+	}
+	return p.tokens[0], p.tokens[1], nil
+}
+
+func (p *V3Pool) Fee(ctx context.Context) (uint32, error) {
+	var fee *big.Int
+	if err := call(ctx, p.caller, p.Address, UniswapV3PoolABI, &fee, "fee"); err != nil {
+		return 0, err
+	}
+	return uint32(fee.Uint64()), nil
+}
+
+func (p *V3Pool) Liquidity(ctx context.Context) (*big.Int, error) {
+	var liquidity *big.Int
+	if err := call(ctx, p.caller, p.Address, UniswapV3PoolABI, &liquidity, "liquidity"); err != nil {
+		return nil, err
+	}
+	return liquidity, nil
+}
+
+func (p *V3Pool) Slot0(ctx context.Context) (*V3Slot0, error) {
+	var slot0 V3Slot0
+	if err := call(ctx, p.caller, p.Address, UniswapV3PoolABI, &slot0, "slot0"); err != nil {
+		return nil, err
+	}
+	return &slot0, nil
+}
+
+// SpotPrice returns the price of token0 in raw units of token1, from the
+// current sqrt price of the pool. See AdjustDecimals.
+func (p *V3Pool) SpotPrice(ctx context.Context) (*big.Rat, error) {
+	slot0, err := p.Slot0(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if slot0.SqrtPriceX96.Sign() == 0 {
+		return nil, fmt.Errorf("ethdex: pool %s is not initialized", p.Address)
+	}
+	return SqrtPriceX96ToPrice(slot0.SqrtPriceX96), nil
+}
+
+// SqrtPriceX96ToPrice converts a Q64.96 sqrt price to the price of token0 in
+// raw units of token1.
+func SqrtPriceX96ToPrice(sqrtPriceX96 *big.Int) *big.Rat {
+	priceX192 := new(big.Int).Mul(sqrtPriceX96, sqrtPriceX96)
+	return new(big.Rat).SetFrac(priceX192, q192)
+}
+
+type V3Quote struct {
+	AmountOut *big.Int
+
+	// SqrtPriceX96After is the sqrt price of each pool of the path after the swap.
+	SqrtPriceX96After []*big.Int
+
+	// InitializedTicksCrossed is the number of initialized ticks crossed in
+	// each pool of the path.
+	InitializedTicksCrossed []uint32
+
+	GasEstimate *big.Int
+}
+
+// V3Quoter quotes swaps with the Uniswap v3 QuoterV2 contract, which simulates
+// the swap against the pools.
+type V3Quoter struct {
+	Address common.Address
+	caller  bind.ContractCaller
+}
+
+func NewV3Quoter(caller bind.ContractCaller, address common.Address) *V3Quoter {
+	return &V3Quoter{
+		Address: address,
+		caller:  caller,
+	}
+}
+
+// QuoteExactInputSingle quotes swapping amountIn of tokenIn for tokenOut in the
+// pool with the fee tier.
+func (q *V3Quoter) QuoteExactInputSingle(ctx context.Context, tokenIn, tokenOut common.Address, fee uint32, amountIn *big.Int) (*V3Quote, error) {
+	params := struct {
+		TokenIn           common.Address
+		TokenOut          common.Address
+		AmountIn          *big.Int
+		Fee               *big.Int
+		SqrtPriceLimitX96 *big.Int
+	}{tokenIn, tokenOut, amountIn, big.NewInt(int64(fee)), big.NewInt(0)}
+
+	var out struct {
+		AmountOut               *big.Int
+		SqrtPriceX96After       *big.Int
+		InitializedTicksCrossed uint32
+		GasEstimate             *big.Int
+	}
+	if err := call(ctx, q.caller, q.Address, UniswapV3QuoterV2ABI, &out, "quoteExactInputSingle", params); err != nil {
+		return nil, err
+	}
+	return &V3Quote{
+		AmountOut:               out.AmountOut,
+		SqrtPriceX96After:       []*big.Int{out.SqrtPriceX96After},
+		InitializedTicksCrossed: []uint32{out.InitializedTicksCrossed},
+		GasEstimate:             out.GasEstimate,
+	}, nil
+}
+
+// QuoteExactInput quotes swapping amountIn of tokens[0] for the last token,
+// through the pools of each consecutive pair of tokens and fee.
+func (q *V3Quoter) QuoteExactInput(ctx context.Context, tokens []common.Address, fees []uint32, amountIn *big.Int) (*V3Quote, error) {
+	path, err := EncodeV3Path(tokens, fees)
+	if err != nil {
+		return nil, err
+	}
+
+	var out struct {
+		AmountOut                   *big.Int
+		SqrtPriceX96AfterList       []*big.Int
+		InitializedTicksCrossedList []uint32
+		GasEstimate                 *big.Int
+	}
+	if err := call(ctx, q.caller, q.Address, UniswapV3QuoterV2ABI, &out, "quoteExactInput", path, amountIn); err != nil {
+		return nil, err
+	}
+	return &V3Quote{
+		AmountOut:               out.AmountOut,
+		SqrtPriceX96After:       out.SqrtPriceX96AfterList,
+		InitializedTicksCrossed: out.InitializedTicksCrossedList,
+		GasEstimate:             out.GasEstimate,
+	}, nil
+}
+
+// EncodeV3Path encodes a multi-hop swap path, as the token addresses
+// interleaved with the 3 byte fee of each pool.
+func EncodeV3Path(tokens []common.Address, fees []uint32) ([]byte, error) {
+	if len(tokens) < 2 || len(fees) != len(tokens)-1 {
+		return nil, fmt.Errorf("ethdex: path of %d tokens requires %d fees, got %d", len(tokens), max(len(tokens)-1, 1), len(fees))
+	}
+	path := make([]byte, 0, len(tokens)*common.AddressLength+len(fees)*3)
+	for i, token := range tokens {
+		path = append(path, token.Bytes()...)
+		if i < len(fees) {
+			if fees[i] >= 1<<24 {
+				return nil, fmt.Errorf("ethdex: invalid fee %d", fees[i])
+			}
+			path = append(path, byte(fees[i]>>16), byte(fees[i]>>8), byte(fees[i]))
+		}
+	}
+	return path, nil
+}
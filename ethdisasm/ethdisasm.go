@@ -0,0 +1,180 @@
+package ethdisasm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Instruction is a single decoded instruction of a program.
+type Instruction struct {
+	// PC is the byte offset of the opcode in the bytecode.
+	PC uint64
+
+	// Op is the opcode.
+	Op OpCode
+
+	// Data is the immediate push data. It may be shorter than Op.PushSize()
+	// when the bytecode is truncated, in which case Truncated is true.
+	Data      []byte
+	Truncated bool
+}
+
+// Size returns the number of bytes the instruction occupies in the bytecode.
+func (i Instruction) Size() uint64 {
+	return 1 + uint64(len(i.Data))
+}
+
+func (i Instruction) String() string {
+	if i.Op.IsPush() {
+		return fmt.Sprintf("%04x %s 0x%x", i.PC, i.Op, i.Data)
+	}
+	return fmt.Sprintf("%04x %s", i.PC, i.Op)
+}
+
+// BasicBlock is a straight-line run of instructions with a single entry
+// and a single exit.
+type BasicBlock struct {
+	// Start is the pc of the first instruction in the block.
+	Start uint64
+
+	// End is the pc just past the last instruction in the block.
+	End uint64
+
+	// Instructions is the list of instructions, in order.
+	Instructions []Instruction
+}
+
+// IsJumpDest reports whether the block begins with a JUMPDEST.
+func (b *BasicBlock) IsJumpDest() bool {
+	return len(b.Instructions) > 0 && b.Instructions[0].Op == JUMPDEST
+}
+
+// Program is disassembled EVM bytecode.
+type Program struct {
+	Code         []byte
+	Instructions []Instruction
+	Blocks       []*BasicBlock
+
+	jumpDests map[uint64]struct{}
+}
+
+// Disassemble decodes the runtime bytecode into instructions and basic blocks.
+// Undefined opcodes do not fail the disassembly, they are decoded as single
+// byte instructions which terminate their block, the same as INVALID.
+func Disassemble(code []byte) *Program {
+	p := &Program{
+		Code:         code,
+		Instructions: make([]Instruction, 0, len(code)),
+		jumpDests:    map[uint64]struct{}{},
+	}
+
+	var block *BasicBlock
+	for pc := uint64(0); pc < uint64(len(code)); {
+		ins := Instruction{PC: pc, Op: OpCode(code[pc])}
+
+		if n := ins.Op.PushSize(); n > 0 {
+			start := pc + 1
+			end := start + uint64(n)
+			if end > uint64(len(code)) {
+				end = uint64(len(code))
+				ins.Truncated = true
+			}
+			ins.Data = code[start:end]
+		}
+
+		if ins.Op == JUMPDEST {
+			p.jumpDests[pc] = struct{}{}
+			if block != nil && len(block.Instructions) > 0 {
+				p.Blocks = append(p.Blocks, block)
+				block = nil
+			}
+		}
+		if block == nil {
+			block = &BasicBlock{Start: pc}
+		}
+
+		p.Instructions = append(p.Instructions, ins)
+		block.Instructions = append(block.Instructions, ins)
+		pc += ins.Size()
+		block.End = pc
+
+		if ins.Op.IsTerminating() {
+			p.Blocks = append(p.Blocks, block)
+			block = nil
+		}
+	}
+	if block != nil {
+		p.Blocks = append(p.Blocks, block)
+	}
+
+	return p
+}
+
+// IsJumpDest reports whether pc is a valid jump destination, ie. a JUMPDEST
+// opcode which is not part of push data.
+func (p *Program) IsJumpDest(pc uint64) bool {
+	_, ok := p.jumpDests[pc]
+	return ok
+}
+
+// JumpDests returns the sorted list of valid jump destinations.
+func (p *Program) JumpDests() []uint64 {
+	dests := make([]uint64, 0, len(p.jumpDests))
+	for pc := range p.jumpDests {
+		dests = append(dests, pc)
+	}
+	sort.Slice(dests, func(i, j int) bool { return dests[i] < dests[j] })
+	return dests
+}
+
+// InstructionAt returns the instruction starting at pc. It returns false if pc
+// is out of range or falls within push data.
+func (p *Program) InstructionAt(pc uint64) (Instruction, bool) {
+	i := sort.Search(len(p.Instructions), func(i int) bool {
+		return p.Instructions[i].PC >= pc
+	})
+	if i < len(p.Instructions) && p.Instructions[i].PC == pc {
+		return p.Instructions[i], true
+	}
+	return Instruction{}, false
+}
+
+// InstructionIndex returns the index of the instruction starting at pc, which
+// is what solc source maps refer to, or -1 if there is none.
+func (p *Program) InstructionIndex(pc uint64) int {
+	i := sort.Search(len(p.Instructions), func(i int) bool {
+		return p.Instructions[i].PC >= pc
+	})
+	if i < len(p.Instructions) && p.Instructions[i].PC == pc {
+		return i
+	}
+	return -1
+}
+
+// BlockAt returns the basic block containing pc.
+func (p *Program) BlockAt(pc uint64) (*BasicBlock, bool) {
+	i := sort.Search(len(p.Blocks), func(i int) bool {
+		return p.Blocks[i].End > pc
+	})
+	if i < len(p.Blocks) && p.Blocks[i].Start <= pc {
+		return p.Blocks[i], true
+	}
+	return nil, false
+}
+
+// String returns the assembly listing of the program, one instruction per
+// line with basic blocks separated by an empty line.
+func (p *Program) String() string {
+	var sb strings.Builder
+	for i, block := range p.Blocks {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		for _, ins := range block.Instructions {
+			sb.WriteString(ins.String())
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,64 @@
+package ethdisasm_test
+
+import (
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethdisasm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisassemble(t *testing.T) {
+	code := ethcoder.MustHexDecode("0x60806040525f5c600b56fe5b615b5b5e007f0102")
+
+	p := ethdisasm.Disassemble(code)
+	require.Len(t, p.Instructions, 13)
+
+	assert.Equal(t, ethdisasm.PUSH1, p.Instructions[0].Op)
+	assert.Equal(t, []byte{0x80}, p.Instructions[0].Data)
+	assert.Equal(t, "0000 PUSH1 0x80", p.Instructions[0].String())
+	assert.Equal(t, "PUSH0", p.Instructions[3].Op.String())
+	assert.Equal(t, "TLOAD", p.Instructions[4].Op.String())
+	assert.Equal(t, "MCOPY", p.Instructions[10].Op.String())
+
+	last := p.Instructions[len(p.Instructions)-1]
+	assert.Equal(t, ethdisasm.PUSH32, last.Op)
+	assert.True(t, last.Truncated)
+	assert.Equal(t, []byte{0x01, 0x02}, last.Data)
+
+	// the 0x5b bytes within PUSH2 data are not jump destinations
+	assert.Equal(t, []uint64{0x0b}, p.JumpDests())
+	assert.True(t, p.IsJumpDest(0x0b))
+	assert.False(t, p.IsJumpDest(0x0d))
+
+	_, ok := p.InstructionAt(0x0d)
+	assert.False(t, ok)
+	ins, ok := p.InstructionAt(0x0c)
+	assert.True(t, ok)
+	assert.Equal(t, ethdisasm.OpCode(0x61), ins.Op)
+	assert.Equal(t, 9, p.InstructionIndex(0x0c))
+
+	require.Len(t, p.Blocks, 4)
+	assert.Equal(t, uint64(0x00), p.Blocks[0].Start)
+	assert.Equal(t, uint64(0x0a), p.Blocks[0].End)
+	assert.Equal(t, ethdisasm.INVALID, p.Blocks[1].Instructions[0].Op)
+	assert.True(t, p.Blocks[2].IsJumpDest())
+	assert.Equal(t, uint64(0x11), p.Blocks[2].End)
+
+	block, ok := p.BlockAt(0x0d)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(0x0b), block.Start)
+}
+
+func TestOpCodeString(t *testing.T) {
+	assert.Equal(t, "PUSH32", ethdisasm.PUSH32.String())
+	assert.Equal(t, "DUP16", ethdisasm.DUP16.String())
+	assert.Equal(t, "SWAP1", ethdisasm.SWAP1.String())
+	assert.Equal(t, "LOG4", ethdisasm.LOG4.String())
+	assert.Equal(t, "BLOBBASEFEE", ethdisasm.BLOBBASEFEE.String())
+	assert.Equal(t, "0xef", ethdisasm.OpCode(0xef).String())
+	assert.False(t, ethdisasm.OpCode(0xef).IsDefined())
+	assert.True(t, ethdisasm.OpCode(0xef).IsTerminating())
+	assert.Equal(t, 0, ethdisasm.PUSH0.PushSize())
+}
@@ -0,0 +1,184 @@
+package ethdisasm
+
+import (
+	"sort"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// SplitMetadata splits the CBOR encoded metadata trailer appended by solc
+// (and vyper) from the runtime bytecode. The last two bytes of the bytecode
+// are the big-endian length of the metadata, and are included in the returned
+// metadata. If no metadata is found, the code is returned as-is.
+func SplitMetadata(code []byte) ([]byte, []byte) {
+	if len(code) < 2 {
+		return code, nil
+	}
+	n := int(code[len(code)-2])<<8 | int(code[len(code)-1])
+	if n == 0 || n+2 > len(code) {
+		return code, nil
+	}
+	start := len(code) - 2 - n
+	// metadata is a CBOR map, major type 5
+	if code[start]&0xe0 != 0xa0 {
+		return code, nil
+	}
+	return code[:start], code[start:]
+}
+
+// Fingerprint is a summary of the structure of a contract bytecode which
+// ignores the compiler metadata and all push data, so contracts compiled from
+// the same source with different constructor immutables, linked library
+// addresses or metadata hashes share the same fingerprint.
+type Fingerprint struct {
+	// Hash of the opcode sequence of the program.
+	Hash common.Hash
+
+	// Blocks is the number of occurrences of each basic block, keyed by the
+	// hash of its opcode sequence.
+	Blocks map[common.Hash]int
+
+	// Selectors is the sorted list of function selectors found in the
+	// function dispatcher, ie. `PUSH4 <selector> EQ`.
+	Selectors [][4]byte
+}
+
+// NewFingerprint returns the fingerprint of the runtime bytecode.
+func NewFingerprint(code []byte) *Fingerprint {
+	code, _ = SplitMetadata(code)
+	p := Disassemble(code)
+
+	f := &Fingerprint{
+		Blocks: map[common.Hash]int{},
+	}
+
+	ops := make([]byte, 0, len(p.Instructions))
+	for _, block := range p.Blocks {
+		blockOps := make([]byte, len(block.Instructions))
+		for i, ins := range block.Instructions {
+			blockOps[i] = byte(ins.Op)
+		}
+		f.Blocks[ethcoder.Keccak256Hash(blockOps)]++
+		ops = append(ops, blockOps...)
+	}
+	f.Hash = ethcoder.Keccak256Hash(ops)
+
+	seen := map[[4]byte]bool{}
+	for i := 0; i+1 < len(p.Instructions); i++ {
+		ins := p.Instructions[i]
+		if ins.Op != PUSH1+3 || ins.Truncated || p.Instructions[i+1].Op != EQ {
+			continue
+		}
+		var selector [4]byte
+		copy(selector[:], ins.Data)
+		if !seen[selector] {
+			seen[selector] = true
+			f.Selectors = append(f.Selectors, selector)
+		}
+	}
+	sort.Slice(f.Selectors, func(i, j int) bool {
+		return string(f.Selectors[i][:]) < string(f.Selectors[j][:])
+	})
+
+	return f
+}
+
+// Similarity returns a score between 0 and 1 of how similar two fingerprints
+// are, computed as the mean of the weighted jaccard index of their basic blocks
+// and of the jaccard index of their selectors, or of the blocks only when
+// neither has selectors. Identical fingerprints have a score of 1.
+func (f *Fingerprint) Similarity(other *Fingerprint) float64 {
+	blocks := f.blockSimilarity(other)
+	if len(f.Selectors) == 0 && len(other.Selectors) == 0 {
+		return blocks
+	}
+	return (blocks + f.selectorSimilarity(other)) / 2
+}
+
+func (f *Fingerprint) blockSimilarity(other *Fingerprint) float64 {
+	if f.Hash == other.Hash {
+		return 1
+	}
+
+	var intersection, union int
+	for h, n := range f.Blocks {
+		m := other.Blocks[h]
+		intersection += min(n, m)
+		union += max(n, m)
+	}
+	for h, m := range other.Blocks {
+		if _, ok := f.Blocks[h]; !ok {
+			union += m
+		}
+	}
+	if union == 0 {
+		return 0
+	}
+	return float64(intersection) / float64(union)
+}
+
+func (f *Fingerprint) selectorSimilarity(other *Fingerprint) float64 {
+	var intersection int
+	for _, selector := range f.Selectors {
+		if other.HasSelector(selector) {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(f.Selectors)+len(other.Selectors)-intersection)
+}
+
+// HasSelector reports whether the selector is found in the function dispatcher.
+func (f *Fingerprint) HasSelector(selector [4]byte) bool {
+	i := sort.Search(len(f.Selectors), func(i int) bool {
+		return string(f.Selectors[i][:]) >= string(selector[:])
+	})
+	return i < len(f.Selectors) && f.Selectors[i] == selector
+}
+
+// FingerprintRegistry is a set of known contract fingerprints to match
+// unknown bytecode against.
+type FingerprintRegistry struct {
+	names        []string
+	fingerprints []*Fingerprint
+}
+
+type FingerprintMatch struct {
+	Name  string
+	Score float64
+}
+
+func NewFingerprintRegistry() *FingerprintRegistry {
+	return &FingerprintRegistry{}
+}
+
+// Add registers the runtime bytecode of a known contract under name.
+func (r *FingerprintRegistry) Add(name string, code []byte) *Fingerprint {
+	f := NewFingerprint(code)
+	r.AddFingerprint(name, f)
+	return f
+}
+
+// AddFingerprint registers a fingerprint of a known contract under name.
+func (r *FingerprintRegistry) AddFingerprint(name string, f *Fingerprint) {
+	r.names = append(r.names, name)
+	r.fingerprints = append(r.fingerprints, f)
+}
+
+// Match returns the registered contracts with a similarity score of at least
+// threshold to the runtime bytecode, best match first.
+func (r *FingerprintRegistry) Match(code []byte, threshold float64) []FingerprintMatch {
+	f := NewFingerprint(code)
+
+	var matches []FingerprintMatch
+	for i, known := range r.fingerprints {
+		score := f.Similarity(known)
+		if score >= threshold {
+			matches = append(matches, FingerprintMatch{Name: r.names[i], Score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	return matches
+}
@@ -0,0 +1,68 @@
+package ethdisasm_test
+
+import (
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethdisasm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitMetadata(t *testing.T) {
+	code := ethcoder.MustHexDecode("0x6001600055a16261614201020007")
+	runtime, metadata := ethdisasm.SplitMetadata(code)
+	assert.Equal(t, ethcoder.MustHexDecode("0x6001600055"), runtime)
+	assert.Equal(t, ethcoder.MustHexDecode("0xa16261614201020007"), metadata)
+
+	// no metadata
+	code = ethcoder.MustHexDecode("0x6001600055")
+	runtime, metadata = ethdisasm.SplitMetadata(code)
+	assert.Equal(t, code, runtime)
+	assert.Nil(t, metadata)
+}
+
+func TestFingerprint(t *testing.T) {
+	// PUSH4 sel EQ PUSH1 dest JUMPI STOP JUMPDEST PUSH1 x STOP
+	a := ethcoder.MustHexDecode("0x63a9059cbb14600b5700" + "5b600100" + "a1626161420a0b0007")
+	// same structure, different push value and metadata
+	b := ethcoder.MustHexDecode("0x63a9059cbb14600b5700" + "5b602a00" + "a162616142ffff0007")
+	// additional function
+	c := ethcoder.MustHexDecode("0x63a9059cbb14600b5700" + "5b600100" + "5b6001600055600000")
+
+	fa := ethdisasm.NewFingerprint(a)
+	fb := ethdisasm.NewFingerprint(b)
+	fc := ethdisasm.NewFingerprint(c)
+
+	assert.Equal(t, fa.Hash, fb.Hash)
+	assert.Equal(t, 1.0, fa.Similarity(fb))
+	assert.NotEqual(t, fa.Hash, fc.Hash)
+
+	score := fa.Similarity(fc)
+	assert.Greater(t, score, 0.5)
+	assert.Less(t, score, 1.0)
+
+	require.Len(t, fa.Selectors, 1)
+	assert.True(t, fa.HasSelector([4]byte{0xa9, 0x05, 0x9c, 0xbb}))
+	assert.False(t, fa.HasSelector([4]byte{0x70, 0xa0, 0x82, 0x31}))
+
+	// the same selector of a different implementation
+	d := ethcoder.MustHexDecode("0x63a9059cbb14600b5700" + "5b6001600055600000")
+	assert.Equal(t, 0.75, fa.Similarity(ethdisasm.NewFingerprint(d)))
+	// the same structure of a different selector
+	e := ethcoder.MustHexDecode("0x6370a0823114600b5700" + "5b600100")
+	assert.Equal(t, 0.5, fa.Similarity(ethdisasm.NewFingerprint(e)))
+
+	registry := ethdisasm.NewFingerprintRegistry()
+	registry.Add("A", a)
+	registry.Add("C", c)
+
+	matches := registry.Match(b, 0.5)
+	require.Len(t, matches, 2)
+	assert.Equal(t, "A", matches[0].Name)
+	assert.Equal(t, 1.0, matches[0].Score)
+	assert.Equal(t, "C", matches[1].Name)
+
+	matches = registry.Match(b, 0.99)
+	require.Len(t, matches, 1)
+}
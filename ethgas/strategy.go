@@ -0,0 +1,245 @@
+package ethgas
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+// Fees are the suggested gas fees of a transaction, either a GasPrice for a
+// legacy transaction or a GasFeeCap and GasTipCap for an EIP-1559 transaction.
+type Fees struct {
+	GasPrice  *big.Int
+	GasFeeCap *big.Int
+	GasTipCap *big.Int
+
+	// L1Fee is the estimated fee of an L2 transaction for posting its data to
+	// L1, charged on top of its gas, or nil.
+	L1Fee *big.Int
+}
+
+// IsDynamic reports whether the fees are for an EIP-1559 transaction.
+func (f *Fees) IsDynamic() bool {
+	return f.GasFeeCap != nil
+}
+
+// Strategy suggests the gas fees of the transactions of a chain.
+type Strategy interface {
+	Name() string
+	SuggestFees(ctx context.Context, provider ethrpc.Interface, msg ethereum.CallMsg) (*Fees, error)
+}
+
+var (
+	// LegacyStrategy suggests the eth_gasPrice, for the chains without EIP-1559.
+	LegacyStrategy Strategy = legacyStrategy{}
+
+	// EIP1559Strategy suggests the eth_maxPriorityFeePerGas tip, and a fee cap
+	// of twice the latest base fee plus the tip. It suggests a legacy gas price
+	// on the chains whose blocks have no base fee.
+	EIP1559Strategy Strategy = eip1559Strategy{}
+
+	// OptimismStrategy suggests the fees of EIP1559Strategy, and the L1 fee of
+	// the OP-stack chains from their GasPriceOracle.
+	OptimismStrategy Strategy = optimismStrategy{}
+
+	// ArbitrumStrategy suggests no tip, which Arbitrum doesn't pay, and a fee
+	// cap of twice the latest base fee. The L1 fee of Arbitrum is part of its
+	// gas estimates.
+	ArbitrumStrategy Strategy = arbitrumStrategy{}
+
+	// DefaultStrategy is the strategy of the chains with no registered strategy,
+	// ie. of legacy transactions, as of a TransactionRequest without a GasTip.
+	DefaultStrategy = LegacyStrategy
+)
+
+var strategies = struct {
+	byChainID map[uint64]Strategy
+	byName    map[string]Strategy
+	mu        sync.RWMutex
+}{
+	byChainID: map[uint64]Strategy{
+		1:        EIP1559Strategy,  // ethereum
+		11155111: EIP1559Strategy,  // sepolia
+		17000:    EIP1559Strategy,  // holesky
+		137:      EIP1559Strategy,  // polygon
+		80002:    EIP1559Strategy,  // polygon amoy
+		43114:    EIP1559Strategy,  // avalanche
+		43113:    EIP1559Strategy,  // avalanche fuji
+		30:       LegacyStrategy,   // rootstock
+		31:       LegacyStrategy,   // rootstock testnet
+		61:       LegacyStrategy,   // ethereum classic
+		63:       LegacyStrategy,   // ethereum classic mordor
+		10:       OptimismStrategy, // optimism
+		11155420: OptimismStrategy, // optimism sepolia
+		8453:     OptimismStrategy, // base
+		84532:    OptimismStrategy, // base sepolia
+		7777777:  OptimismStrategy, // zora
+		42161:    ArbitrumStrategy, // arbitrum one
+		42170:    ArbitrumStrategy, // arbitrum nova
+		421614:   ArbitrumStrategy, // arbitrum sepolia
+	},
+	byName: map[string]Strategy{
+		LegacyStrategy.Name():   LegacyStrategy,
+		EIP1559Strategy.Name():  EIP1559Strategy,
+		OptimismStrategy.Name(): OptimismStrategy,
+		ArbitrumStrategy.Name(): ArbitrumStrategy,
+	},
+}
+
+// RegisterStrategy sets the strategy of a chain, and registers it by name.
+func RegisterStrategy(chainID uint64, strategy Strategy) {
+	strategies.mu.Lock()
+	defer strategies.mu.Unlock()
+	strategies.byChainID[chainID] = strategy
+	strategies.byName[strategy.Name()] = strategy
+}
+
+// RegisterNamedStrategy registers a strategy by name, for RegisterStrategyByName.
+func RegisterNamedStrategy(strategy Strategy) {
+	strategies.mu.Lock()
+	defer strategies.mu.Unlock()
+	strategies.byName[strategy.Name()] = strategy
+}
+
+// RegisterStrategyByName sets the strategy of a chain to a registered strategy,
+// ie. from a config file.
+func RegisterStrategyByName(chainID uint64, name string) error {
+	strategies.mu.Lock()
+	defer strategies.mu.Unlock()
+	strategy, ok := strategies.byName[name]
+	if !ok {
+		return fmt.Errorf("ethgas: unknown strategy %q", name)
+	}
+	strategies.byChainID[chainID] = strategy
+	return nil
+}
+
+// GetStrategy returns the strategy of a chain, or DefaultStrategy.
+func GetStrategy(chainID uint64) Strategy {
+	strategies.mu.RLock()
+	defer strategies.mu.RUnlock()
+	if strategy, ok := strategies.byChainID[chainID]; ok {
+		return strategy
+	}
+	return DefaultStrategy
+}
+
+// SuggestFees suggests the fees of msg with the strategy of the chain of the
+// provider.
+func SuggestFees(ctx context.Context, provider ethrpc.Interface, msg ethereum.CallMsg) (*Fees, error) {
+	chainID, err := provider.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ethgas: unable to get chain ID: %w", err)
+	}
+	return GetStrategy(chainID.Uint64()).SuggestFees(ctx, provider, msg)
+}
+
+type legacyStrategy struct{}
+
+func (legacyStrategy) Name() string {
+	return "legacy"
+}
+
+func (legacyStrategy) SuggestFees(ctx context.Context, provider ethrpc.Interface, msg ethereum.CallMsg) (*Fees, error) {
+	gasPrice, err := provider.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ethgas: %w", err)
+	}
+	return &Fees{GasPrice: gasPrice}, nil
+}
+
+type eip1559Strategy struct{}
+
+func (eip1559Strategy) Name() string {
+	return "eip1559"
+}
+
+func (eip1559Strategy) SuggestFees(ctx context.Context, provider ethrpc.Interface, msg ethereum.CallMsg) (*Fees, error) {
+	header, err := provider.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ethgas: %w", err)
+	}
+	if header.BaseFee == nil {
+		return LegacyStrategy.SuggestFees(ctx, provider, msg)
+	}
+
+	tip, err := provider.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ethgas: %w", err)
+	}
+	return &Fees{
+		GasFeeCap: new(big.Int).Add(new(big.Int).Mul(header.BaseFee, big.NewInt(2)), tip),
+		GasTipCap: tip,
+	}, nil
+}
+
+// OptimismGasPriceOracle is the predeploy of the OP-stack chains with the L1 fees.
+var OptimismGasPriceOracle = common.HexToAddress("0x420000000000000000000000000000000000000F")
+
+type optimismStrategy struct{}
+
+func (optimismStrategy) Name() string {
+	return "optimism"
+}
+
+func (optimismStrategy) SuggestFees(ctx context.Context, provider ethrpc.Interface, msg ethereum.CallMsg) (*Fees, error) {
+	fees, err := EIP1559Strategy.SuggestFees(ctx, provider, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	chainID, err := provider.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ethgas: unable to get chain ID: %w", err)
+	}
+	// the oracle estimates the l1 fee of the unsigned transaction
+	tx, err := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainID,
+		To:        msg.To,
+		Value:     msg.Value,
+		Data:      msg.Data,
+		Gas:       msg.Gas,
+		GasFeeCap: fees.GasFeeCap,
+		GasTipCap: fees.GasTipCap,
+	}).MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("ethgas: %w", err)
+	}
+	calldata, err := ethcoder.AbiEncodeMethodCalldata("getL1Fee(bytes)", []interface{}{tx})
+	if err != nil {
+		return nil, fmt.Errorf("ethgas: %w", err)
+	}
+	result, err := provider.CallContract(ctx, ethereum.CallMsg{To: &OptimismGasPriceOracle, Data: calldata}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ethgas: unable to get l1 fee: %w", err)
+	}
+	fees.L1Fee = new(big.Int).SetBytes(result)
+	return fees, nil
+}
+
+type arbitrumStrategy struct{}
+
+func (arbitrumStrategy) Name() string {
+	return "arbitrum"
+}
+
+func (arbitrumStrategy) SuggestFees(ctx context.Context, provider ethrpc.Interface, msg ethereum.CallMsg) (*Fees, error) {
+	header, err := provider.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ethgas: %w", err)
+	}
+	if header.BaseFee == nil {
+		return LegacyStrategy.SuggestFees(ctx, provider, msg)
+	}
+	return &Fees{
+		GasFeeCap: new(big.Int).Mul(header.BaseFee, big.NewInt(2)),
+		GasTipCap: new(big.Int),
+	}, nil
+}
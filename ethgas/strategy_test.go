@@ -0,0 +1,69 @@
+package ethgas_test
+
+import (
+	"context"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethdevnode"
+	"github.com/0xsequence/ethkit/ethgas"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrategies(t *testing.T) {
+	require.NoError(t, ethgas.RegisterStrategyByName(1338, "eip1559"))
+	assert.Error(t, ethgas.RegisterStrategyByName(1338, "unknown"))
+
+	assert.Equal(t, "eip1559", ethgas.GetStrategy(1).Name())
+	assert.Equal(t, "optimism", ethgas.GetStrategy(10).Name())
+	assert.Equal(t, "arbitrum", ethgas.GetStrategy(42161).Name())
+	assert.Equal(t, ethgas.LegacyStrategy, ethgas.GetStrategy(1337))
+
+	baseFee := ethdevnode.DefaultOptions.BaseFee
+	tests := []struct {
+		chainID  int64
+		txType   uint8
+		gasPrice *big.Int
+		gasTip   *big.Int
+	}{
+		{1337, types.LegacyTxType, new(big.Int).Add(baseFee, big.NewInt(1)), big.NewInt(1)},
+		{1338, types.DynamicFeeTxType, new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), big.NewInt(1)), big.NewInt(1)},
+		{61, types.LegacyTxType, new(big.Int).Add(baseFee, big.NewInt(1)), big.NewInt(1)},
+		{42161, types.DynamicFeeTxType, new(big.Int).Mul(baseFee, big.NewInt(2)), big.NewInt(0)},
+	}
+	for _, test := range tests {
+		ctx := context.Background()
+		options := ethdevnode.DefaultOptions
+		options.ChainID = big.NewInt(test.chainID)
+		node, err := ethdevnode.NewNode(ctx, options)
+		require.NoError(t, err)
+		server := httptest.NewServer(node)
+		defer server.Close()
+		provider, err := ethrpc.NewProvider(server.URL)
+		require.NoError(t, err)
+
+		wallet := node.Accounts()[0]
+		wallet.SetProvider(provider)
+		to := common.HexToAddress("0x1234567890123456789012345678901234567890")
+
+		txn, err := wallet.NewTransaction(ctx, &ethtxn.TransactionRequest{To: &to, ETHValue: big.NewInt(1)})
+		require.NoError(t, err)
+		assert.Equal(t, test.txType, txn.Type(), "chain %d", test.chainID)
+		assert.Equal(t, test.gasPrice, txn.GasFeeCap(), "chain %d", test.chainID)
+		if test.txType == types.DynamicFeeTxType {
+			assert.Equal(t, test.gasTip, txn.GasTipCap(), "chain %d", test.chainID)
+		}
+
+		_, waitReceipt, err := wallet.SendTransaction(ctx, txn)
+		require.NoError(t, err)
+		receipt, err := waitReceipt(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), receipt.Status)
+	}
+}
@@ -0,0 +1,370 @@
+package ethmempool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/goware/logger"
+)
+
+type TxEventType int
+
+const (
+	// TxEventReplaced is emitted when another transaction from the same sender
+	// and nonce, with a higher fee, is seen in the mempool or mined.
+	TxEventReplaced TxEventType = iota
+
+	// TxEventCancelled is a replacement which is a zero value self-transfer.
+	TxEventCancelled
+
+	// TxEventDropped is emitted when the transaction is no longer known by the
+	// node and its nonce has not been used after TrackerOptions.DropTimeout.
+	TxEventDropped
+
+	// TxEventMined is emitted when the tracked transaction, or its latest
+	// replacement, is mined. Tracking stops after this event.
+	TxEventMined
+)
+
+func (t TxEventType) String() string {
+	switch t {
+	case TxEventReplaced:
+		return "replaced"
+	case TxEventCancelled:
+		return "cancelled"
+	case TxEventDropped:
+		return "dropped"
+	case TxEventMined:
+		return "mined"
+	default:
+		return fmt.Sprintf("TxEventType(%d)", int(t))
+	}
+}
+
+type TxEvent struct {
+	Type TxEventType
+
+	// OriginalHash is the hash of the transaction passed to Track, for all events
+	// of the transaction and any of its replacements.
+	OriginalHash common.Hash
+
+	// Hash of the replacement for TxEventReplaced and TxEventCancelled, or of
+	// the mined transaction for TxEventMined. It is the zero hash when the
+	// nonce was used by a transaction which was never seen in the mempool.
+	Hash common.Hash
+
+	// Replacement transaction, when known.
+	Replacement *types.Transaction
+
+	Sender common.Address
+	Nonce  uint64
+}
+
+var DefaultTrackerOptions = TrackerOptions{
+	PollInterval: 5 * time.Second,
+	DropTimeout:  10 * time.Minute,
+}
+
+type TrackerOptions struct {
+	Logger logger.Logger
+
+	// PollInterval to check the node for the state of the tracked transactions.
+	PollInterval time.Duration
+
+	// DropTimeout is how long a transaction must be unknown to the node before
+	// it is considered dropped.
+	DropTimeout time.Duration
+}
+
+// Tracker follows pending transactions and emits events when they are replaced,
+// cancelled, dropped or mined. Pending transaction hashes from the mempool are
+// resolved through the provider only while there are transactions tracked.
+type Tracker struct {
+	options  TrackerOptions
+	log      logger.Logger
+	mempool  *Mempool
+	provider ethrpc.Interface
+
+	tracked map[trackerKey]*trackedTx
+	events  chan TxEvent
+	mu      sync.Mutex
+}
+
+type trackerKey struct {
+	sender common.Address
+	nonce  uint64
+}
+
+type trackedTx struct {
+	originalHash common.Hash
+	tx           *types.Transaction
+	sender       common.Address
+	notFoundAt   time.Time
+}
+
+func NewTracker(mempool *Mempool, provider ethrpc.Interface, opts ...TrackerOptions) *Tracker {
+	options := DefaultTrackerOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	if options.Logger == nil {
+		options.Logger = logger.Nop()
+	}
+	if options.PollInterval == 0 {
+		options.PollInterval = DefaultTrackerOptions.PollInterval
+	}
+	if options.DropTimeout == 0 {
+		options.DropTimeout = DefaultTrackerOptions.DropTimeout
+	}
+
+	return &Tracker{
+		options:  options,
+		log:      options.Logger,
+		mempool:  mempool,
+		provider: provider,
+		tracked:  map[trackerKey]*trackedTx{},
+		events:   make(chan TxEvent, 1024),
+	}
+}
+
+// Events returns the channel of events of all tracked transactions.
+func (t *Tracker) Events() <-chan TxEvent {
+	return t.events
+}
+
+// Track fetches the pending transaction and starts tracking it.
+func (t *Tracker) Track(ctx context.Context, txHash common.Hash) error {
+	tx, _, err := t.provider.TransactionByHash(ctx, txHash)
+	if err != nil {
+		return fmt.Errorf("ethmempool: failed to fetch transaction %s: %w", txHash, err)
+	}
+	sender, err := txSender(tx)
+	if err != nil {
+		return err
+	}
+	t.TrackTransaction(tx, sender)
+	return nil
+}
+
+// TrackTransaction starts tracking a transaction signed by sender.
+func (t *Tracker) TrackTransaction(tx *types.Transaction, sender common.Address) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tracked[trackerKey{sender, tx.Nonce()}] = &trackedTx{
+		originalHash: tx.Hash(),
+		tx:           tx,
+		sender:       sender,
+	}
+}
+
+// Untrack stops tracking the transaction with the original or current hash.
+func (t *Tracker) Untrack(txHash common.Hash) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key, tracked := range t.tracked {
+		if tracked.originalHash == txHash || tracked.tx.Hash() == txHash {
+			delete(t.tracked, key)
+		}
+	}
+}
+
+// Tracked returns the number of transactions being tracked.
+func (t *Tracker) Tracked() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.tracked)
+}
+
+// Run consumes the mempool pending transactions and polls the state of the
+// tracked transactions until the context is done. The mempool must be run
+// separately.
+func (t *Tracker) Run(ctx context.Context) error {
+	sub := t.mempool.Subscribe()
+	defer sub.Unsubscribe()
+
+	ticker := time.NewTicker(t.options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-sub.Done():
+			return nil
+
+		case pendingTxnHash := <-sub.PendingTransactionHash():
+			if t.Tracked() == 0 {
+				continue
+			}
+			tx, _, err := t.provider.TransactionByHash(ctx, common.HexToHash(pendingTxnHash))
+			if err != nil {
+				if !errors.Is(err, ethereum.NotFound) {
+					t.log.Warnf("ethmempool: tracker failed to fetch pending transaction %s: %v", pendingTxnHash, err)
+				}
+				continue
+			}
+			if err := t.handlePendingTransaction(tx); err != nil {
+				t.log.Warnf("ethmempool: tracker failed to handle pending transaction %s: %v", pendingTxnHash, err)
+			}
+
+		case <-ticker.C:
+			t.poll(ctx, time.Now())
+		}
+	}
+}
+
+func (t *Tracker) handlePendingTransaction(tx *types.Transaction) error {
+	sender, err := txSender(tx)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tracked, ok := t.tracked[trackerKey{sender, tx.Nonce()}]
+	if !ok || tracked.tx.Hash() == tx.Hash() {
+		return nil
+	}
+	if !isHigherFee(tx, tracked.tx) {
+		return nil
+	}
+
+	tracked.tx = tx
+	tracked.notFoundAt = time.Time{}
+	t.emit(TxEvent{
+		Type:         replacementType(tx, sender),
+		OriginalHash: tracked.originalHash,
+		Hash:         tx.Hash(),
+		Replacement:  tx,
+		Sender:       sender,
+		Nonce:        tx.Nonce(),
+	})
+	return nil
+}
+
+func (t *Tracker) poll(ctx context.Context, now time.Time) {
+	t.mu.Lock()
+	tracked := make(map[trackerKey]*trackedTx, len(t.tracked))
+	for key, v := range t.tracked {
+		tracked[key] = v
+	}
+	t.mu.Unlock()
+
+	for key, v := range tracked {
+		if err := t.check(ctx, key, v, now); err != nil {
+			t.log.Warnf("ethmempool: tracker failed to check transaction %s: %v", v.tx.Hash(), err)
+		}
+	}
+}
+
+func (t *Tracker) check(ctx context.Context, key trackerKey, tracked *trackedTx, now time.Time) error {
+	txHash := tracked.tx.Hash()
+
+	receipt, err := t.provider.TransactionReceipt(ctx, txHash)
+	if err != nil && !errors.Is(err, ethereum.NotFound) {
+		return err
+	}
+	if receipt != nil {
+		t.finish(key, TxEvent{
+			Type:         TxEventMined,
+			OriginalHash: tracked.originalHash,
+			Hash:         txHash,
+			Sender:       key.sender,
+			Nonce:        key.nonce,
+		})
+		return nil
+	}
+
+	_, _, err = t.provider.TransactionByHash(ctx, txHash)
+	if err == nil {
+		t.mu.Lock()
+		tracked.notFoundAt = time.Time{}
+		t.mu.Unlock()
+		return nil
+	}
+	if !errors.Is(err, ethereum.NotFound) {
+		return err
+	}
+
+	// the transaction is unknown to the node, check if its nonce was used by
+	// a transaction we haven't seen
+	nonce, err := t.provider.NonceAt(ctx, key.sender, nil)
+	if err != nil {
+		return err
+	}
+	if nonce > key.nonce {
+		t.finish(key, TxEvent{
+			Type:         TxEventReplaced,
+			OriginalHash: tracked.originalHash,
+			Sender:       key.sender,
+			Nonce:        key.nonce,
+		})
+		return nil
+	}
+
+	t.mu.Lock()
+	if tracked.notFoundAt.IsZero() {
+		tracked.notFoundAt = now
+	}
+	dropped := now.Sub(tracked.notFoundAt) >= t.options.DropTimeout
+	t.mu.Unlock()
+
+	if dropped {
+		t.finish(key, TxEvent{
+			Type:         TxEventDropped,
+			OriginalHash: tracked.originalHash,
+			Hash:         txHash,
+			Sender:       key.sender,
+			Nonce:        key.nonce,
+		})
+	}
+	return nil
+}
+
+func (t *Tracker) finish(key trackerKey, ev TxEvent) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.tracked[key]; !ok {
+		return
+	}
+	delete(t.tracked, key)
+	t.emit(ev)
+}
+
+// emit must be called with the lock held.
+func (t *Tracker) emit(ev TxEvent) {
+	select {
+	case t.events <- ev:
+	default:
+		t.log.Warnf("ethmempool: tracker events channel is full, dropping %s event for %s", ev.Type, ev.OriginalHash)
+	}
+}
+
+func txSender(tx *types.Transaction) (common.Address, error) {
+	sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("ethmempool: failed to recover transaction sender: %w", err)
+	}
+	return sender, nil
+}
+
+func isHigherFee(tx, prev *types.Transaction) bool {
+	return tx.GasTipCap().Cmp(prev.GasTipCap()) > 0 || tx.GasFeeCap().Cmp(prev.GasFeeCap()) > 0
+}
+
+func replacementType(tx *types.Transaction, sender common.Address) TxEventType {
+	if tx.To() != nil && *tx.To() == sender && tx.Value().Cmp(big.NewInt(0)) == 0 && len(tx.Data()) == 0 {
+		return TxEventCancelled
+	}
+	return TxEventReplaced
+}
@@ -0,0 +1,148 @@
+package ethmempool
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockProvider struct {
+	ethrpc.Interface
+	pending  map[common.Hash]*types.Transaction
+	receipts map[common.Hash]*types.Receipt
+	nonce    uint64
+}
+
+func (p *mockProvider) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	tx, ok := p.pending[hash]
+	if !ok {
+		return nil, false, ethereum.NotFound
+	}
+	return tx, true, nil
+}
+
+func (p *mockProvider) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	receipt, ok := p.receipts[txHash]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return receipt, nil
+}
+
+func (p *mockProvider) NonceAt(ctx context.Context, account common.Address, blockNum *big.Int) (uint64, error) {
+	return p.nonce, nil
+}
+
+func TestTrackerReplacement(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.HexToAddress("0x1234")
+
+	signer := types.LatestSignerForChainID(big.NewInt(1))
+	newTx := func(to common.Address, value int64, tip int64) *types.Transaction {
+		tx, err := types.SignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   big.NewInt(1),
+			Nonce:     7,
+			GasTipCap: big.NewInt(tip),
+			GasFeeCap: big.NewInt(100 + tip),
+			Gas:       21000,
+			To:        &to,
+			Value:     big.NewInt(value),
+		})
+		require.NoError(t, err)
+		return tx
+	}
+
+	original := newTx(to, 1, 1)
+	speedup := newTx(to, 1, 2)
+	cancel := newTx(sender, 0, 3)
+	underpriced := newTx(to, 1, 1)
+
+	provider := &mockProvider{
+		pending:  map[common.Hash]*types.Transaction{original.Hash(): original},
+		receipts: map[common.Hash]*types.Receipt{},
+		nonce:    7,
+	}
+	tracker := NewTracker(nil, provider, TrackerOptions{DropTimeout: time.Minute})
+
+	ctx := context.Background()
+	require.NoError(t, tracker.Track(ctx, original.Hash()))
+	assert.Equal(t, 1, tracker.Tracked())
+
+	require.NoError(t, tracker.handlePendingTransaction(underpriced))
+	assert.Len(t, tracker.events, 0)
+
+	require.NoError(t, tracker.handlePendingTransaction(speedup))
+	ev := <-tracker.Events()
+	assert.Equal(t, TxEventReplaced, ev.Type)
+	assert.Equal(t, original.Hash(), ev.OriginalHash)
+	assert.Equal(t, speedup.Hash(), ev.Hash)
+	assert.Equal(t, sender, ev.Sender)
+
+	require.NoError(t, tracker.handlePendingTransaction(cancel))
+	ev = <-tracker.Events()
+	assert.Equal(t, TxEventCancelled, ev.Type)
+	assert.Equal(t, original.Hash(), ev.OriginalHash)
+	assert.Equal(t, cancel.Hash(), ev.Hash)
+
+	// the cancellation is mined
+	provider.receipts[cancel.Hash()] = &types.Receipt{TxHash: cancel.Hash()}
+	tracker.poll(ctx, time.Now())
+	ev = <-tracker.Events()
+	assert.Equal(t, TxEventMined, ev.Type)
+	assert.Equal(t, original.Hash(), ev.OriginalHash)
+	assert.Equal(t, cancel.Hash(), ev.Hash)
+	assert.Equal(t, 0, tracker.Tracked())
+}
+
+func TestTrackerDropped(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(big.NewInt(1)), &types.DynamicFeeTx{
+		ChainID:   big.NewInt(1),
+		Nonce:     3,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(100),
+		Gas:       21000,
+		To:        &sender,
+	})
+	require.NoError(t, err)
+
+	provider := &mockProvider{
+		pending:  map[common.Hash]*types.Transaction{},
+		receipts: map[common.Hash]*types.Receipt{},
+		nonce:    3,
+	}
+	tracker := NewTracker(nil, provider, TrackerOptions{DropTimeout: time.Minute})
+	tracker.TrackTransaction(tx, sender)
+
+	ctx := context.Background()
+	now := time.Now()
+	tracker.poll(ctx, now)
+	assert.Len(t, tracker.events, 0)
+
+	tracker.poll(ctx, now.Add(2*time.Minute))
+	ev := <-tracker.Events()
+	assert.Equal(t, TxEventDropped, ev.Type)
+	assert.Equal(t, tx.Hash(), ev.OriginalHash)
+
+	// nonce used by an unseen transaction
+	tracker.TrackTransaction(tx, sender)
+	provider.nonce = 4
+	tracker.poll(ctx, now)
+	ev = <-tracker.Events()
+	assert.Equal(t, TxEventReplaced, ev.Type)
+	assert.Equal(t, common.Hash{}, ev.Hash)
+}
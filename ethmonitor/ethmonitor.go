@@ -39,6 +39,8 @@ var DefaultOptions = Options{
 	BlockRetentionLimit:              200,
 	WithLogs:                         false,
 	LogTopics:                        []common.Hash{}, // all logs
+	BlockReceiptsCacheNumBlocks:      50,
+	BlockReceiptsCacheMaxBytes:       64 << 20, // 64 MiB
 	DebugLogging:                     false,
 	CacheExpiry:                      300 * time.Second,
 	Alerter:                          util.NoopAlerter(),
@@ -63,6 +65,11 @@ type Options struct {
 	// StreamingErrNumToSwitchToPolling is the number of errors before switching to polling
 	StreamingErrNumToSwitchToPolling int
 
+	// ReorgPause is the pause before each block reverted by a reorg, to allow the
+	// nodes to sync to the canonical chain. By default twice the PollingInterval,
+	// of at least 2 seconds. A negative value disables the pause, ie. of a replay.
+	ReorgPause time.Duration
+
 	// Auto-unsubscribe on monitor stop or error
 	UnsubscribeOnStop bool
 
@@ -94,6 +101,26 @@ type Options struct {
 	// LogTopics will filter only specific log topics to include.
 	LogTopics []common.Hash
 
+	// LogAddresses will filter only the logs of specific addresses to include.
+	LogAddresses []common.Address
+
+	// LogsOnly will drive the monitor off block headers and filtered logs, instead
+	// of full blocks with all transactions. The published blocks have no transactions,
+	// and the logs are filtered by LogAddresses and LogTopics, or when these are empty,
+	// by the union of the filters of the subscribers registered with
+	// SubscribeWithLogFilter. Implies WithLogs, and needs a provider which is also
+	// an ethrpc.RawHeaderInterface, ie. an *ethrpc.Provider.
+	LogsOnly bool
+
+	// BlockReceiptsCacheNumBlocks is the max number of blocks whose receipts,
+	// fetched with BlockReceipts, are kept in memory. A value of 0 disables the
+	// cache, the receipts of a block are then fetched on every call.
+	BlockReceiptsCacheNumBlocks int
+
+	// BlockReceiptsCacheMaxBytes is the max estimated size of the receipts
+	// kept in memory. A value of 0 means no limit besides the number of blocks.
+	BlockReceiptsCacheMaxBytes int
+
 	// CacheBackend to use for caching block data
 	// NOTE: do not use this unless you know what you're doing.
 	// In most cases leave this nil.
@@ -126,6 +153,9 @@ type Monitor struct {
 	alert    util.Alerter
 	provider ethrpc.RawInterface
 
+	// headers is the provider of the headers of the LogsOnly mode
+	headers ethrpc.RawHeaderInterface
+
 	chain             *Chain
 	chainID           *big.Int
 	nextBlockNumber   *big.Int
@@ -134,6 +164,9 @@ type Monitor struct {
 
 	cache cachestore.Store[[]byte]
 
+	// receipts is the cache of the block receipts
+	receipts *receiptsCache
+
 	publishCh    chan Blocks
 	publishQueue *queue
 	subscribers  []*subscriber
@@ -159,6 +192,16 @@ func NewMonitor(provider ethrpc.RawInterface, options ...Options) (*Monitor, err
 
 	opts.BlockRetentionLimit += opts.TrailNumBlocksBehindHead
 
+	var headers ethrpc.RawHeaderInterface
+	if opts.LogsOnly {
+		opts.WithLogs = true
+
+		var ok bool
+		if headers, ok = provider.(ethrpc.RawHeaderInterface); !ok {
+			return nil, fmt.Errorf("ethmonitor: LogsOnly needs a provider of ethrpc.RawHeaderInterface")
+		}
+	}
+
 	if opts.DebugLogging {
 		stdLogger, ok := opts.Logger.(*logger.StdLogAdapter)
 		if ok {
@@ -184,9 +227,11 @@ func NewMonitor(provider ethrpc.RawInterface, options ...Options) (*Monitor, err
 		log:          opts.Logger,
 		alert:        opts.Alerter,
 		provider:     provider,
+		headers:      headers,
 		chain:        newChain(opts.BlockRetentionLimit, opts.Bootstrap),
 		chainID:      nil,
 		cache:        cache,
+		receipts:     newReceiptsCache(opts.BlockReceiptsCacheNumBlocks, opts.BlockReceiptsCacheMaxBytes),
 		publishCh:    make(chan Blocks),
 		publishQueue: newQueue(opts.BlockRetentionLimit * 2),
 		subscribers:  make([]*subscriber, 0),
@@ -512,7 +557,7 @@ func (m *Monitor) monitor() error {
 
 			m.chain.mu.Lock()
 			if m.options.WithLogs {
-				m.addLogs(ctx, events)
+				m.addLogs(ctx, events, false)
 				m.backfillChainLogs(ctx, events)
 			} else {
 				for _, b := range events {
@@ -561,9 +606,10 @@ func (m *Monitor) buildCanonicalChain(ctx context.Context, nextBlock *types.Bloc
 	poppedBlock.Event = Removed
 	poppedBlock.OK = true // removed blocks are ready
 
-	// purge the block num from the cache
+	// purge the block receipts and the block num from the caches
+	m.receipts.remove(poppedBlock.Hash())
 	if m.cache != nil {
-		key := cacheKeyBlockNum(m.chainID, poppedBlock.Number())
+		key := m.cacheKeyBlockNum(poppedBlock.Number())
 		err := m.cache.Delete(ctx, key)
 		if err != nil {
 			m.log.Warnf("ethmonitor: error deleting block cache for block num %d due to: '%v'", err, poppedBlock.Number().Uint64())
@@ -575,8 +621,13 @@ func (m *Monitor) buildCanonicalChain(ctx context.Context, nextBlock *types.Bloc
 
 	// let's always take a pause between any reorg for the polling interval time
 	// to allow nodes to sync to the correct chain
-	pause := calc.Max(2*m.options.PollingInterval, 2*time.Second)
-	time.Sleep(pause)
+	pause := m.options.ReorgPause
+	if pause == 0 {
+		pause = calc.Max(2*m.options.PollingInterval, 2*time.Second)
+	}
+	if pause > 0 {
+		time.Sleep(pause)
+	}
 
 	// Fetch/connect the broken chain backwards by traversing recursively via parent hashes
 	nextParentBlock, nextParentBlockPayload, err := m.fetchBlockByHash(ctx, nextBlock.ParentHash())
@@ -601,10 +652,14 @@ func (m *Monitor) buildCanonicalChain(ctx context.Context, nextBlock *types.Bloc
 	return events, nil
 }
 
-func (m *Monitor) addLogs(ctx context.Context, blocks Blocks) {
+// addLogs fetches the logs of the blocks. A block with no logs matching the
+// log filter is ready when its bloom shows it has none, or with trustEmpty.
+func (m *Monitor) addLogs(ctx context.Context, blocks Blocks, trustEmpty bool) {
 	tctx, cancel := context.WithTimeout(ctx, m.options.Timeout)
 	defer cancel()
 
+	filter := m.logFilter()
+
 	for _, block := range blocks {
 		select {
 		case <-ctx.Done():
@@ -627,16 +682,16 @@ func (m *Monitor) addLogs(ctx context.Context, blocks Blocks) {
 		blockHash := block.Hash()
 
 		topics := [][]common.Hash{}
-		if len(m.options.LogTopics) > 0 {
-			topics = append(topics, m.options.LogTopics)
+		if len(filter.Topics) > 0 {
+			topics = append(topics, filter.Topics)
 		}
 
-		logs, logsPayload, err := m.filterLogs(tctx, blockHash, topics)
+		logs, logsPayload, err := m.filterLogs(tctx, blockHash, filter.Addresses, topics)
 
 		if err == nil {
 			// check the logsBloom from the block to check if we should be expecting logs. logsBloom
 			// will be included for any indexed logs.
-			if len(logs) > 0 || block.Bloom() == (types.Bloom{}) {
+			if len(logs) > 0 || trustEmpty || !filter.mayMatchBloom(block.Bloom()) {
 				// successful backfill
 				if logs == nil {
 					block.Logs = []types.Log{}
@@ -659,7 +714,7 @@ func (m *Monitor) addLogs(ctx context.Context, blocks Blocks) {
 	}
 }
 
-func (m *Monitor) filterLogs(ctx context.Context, blockHash common.Hash, topics [][]common.Hash) ([]types.Log, []byte, error) {
+func (m *Monitor) filterLogs(ctx context.Context, blockHash common.Hash, addresses []common.Address, topics [][]common.Hash) ([]types.Log, []byte, error) {
 	getter := func(ctx context.Context, _ string) ([]byte, error) {
 		m.log.Debugf("ethmonitor: filterLogs is calling origin for block hash %s", blockHash)
 
@@ -668,6 +723,7 @@ func (m *Monitor) filterLogs(ctx context.Context, blockHash common.Hash, topics
 
 		logsPayload, err := m.provider.RawFilterLogs(tctx, ethereum.FilterQuery{
 			BlockHash: &blockHash,
+			Addresses: addresses,
 			Topics:    topics,
 		})
 		return logsPayload, err
@@ -683,6 +739,10 @@ func (m *Monitor) filterLogs(ctx context.Context, blockHash common.Hash, topics
 	}
 
 	topicsDigest := xxhash.New()
+	for _, address := range addresses {
+		topicsDigest.Write(address.Bytes())
+	}
+	topicsDigest.Write([]byte{'\n'})
 	for _, hashes := range topics {
 		for _, hash := range hashes {
 			topicsDigest.Write(hash.Bytes())
@@ -728,9 +788,11 @@ func (m *Monitor) backfillChainLogs(ctx context.Context, newBlocks Blocks) {
 			}
 		}
 
-		// attempt to backfill if necessary
+		// attempt to backfill if necessary. With a log filter, the bloom of a block
+		// can match without any log matching the filter, so an empty result of a
+		// backfill is trusted.
 		if !blocks[i].OK {
-			m.addLogs(ctx, Blocks{blocks[i]})
+			m.addLogs(ethrpc.WithPriority(ctx, ethrpc.PriorityLow), Blocks{blocks[i]}, !m.logFilter().IsEmpty())
 			if blocks[i].Event == Added && blocks[i].OK {
 				m.log.Infof("ethmonitor: [getLogs backfill successful for block:%d %s]", blocks[i].NumberU64(), blocks[i].Hash().Hex())
 			}
@@ -785,22 +847,25 @@ func (m *Monitor) fetchNextBlock(ctx context.Context) (*types.Block, []byte, boo
 		if err != nil {
 			return nil, resp, miss, err
 		}
-		block, err := unmarshalBlock(resp)
+		block, err := m.unmarshalBlock(resp)
 		return block, resp, miss, err
 	}
 
 	// fetch with distributed mutex
-	key := cacheKeyBlockNum(m.chainID, nextBlockNumber)
+	key := m.cacheKeyBlockNum(nextBlockNumber)
 	resp, err := m.cache.GetOrSetWithLockEx(ctx, key, getter, m.options.CacheExpiry)
 	if err != nil {
 		return nil, resp, miss, err
 	}
-	block, err := unmarshalBlock(resp)
+	block, err := m.unmarshalBlock(resp)
 	return block, resp, miss, err
 }
 
-func cacheKeyBlockNum(chainID *big.Int, num *big.Int) string {
-	return fmt.Sprintf("ethmonitor:%s:BlockNum:%s", chainID.String(), num.String())
+func (m *Monitor) cacheKeyBlockNum(num *big.Int) string {
+	if m.options.LogsOnly {
+		return fmt.Sprintf("ethmonitor:%s:HeaderNum:%s", m.chainID.String(), num.String())
+	}
+	return fmt.Sprintf("ethmonitor:%s:BlockNum:%s", m.chainID.String(), num.String())
 }
 
 func (m *Monitor) fetchRawBlockByNumber(ctx context.Context, num *big.Int) ([]byte, error) {
@@ -825,7 +890,11 @@ func (m *Monitor) fetchRawBlockByNumber(ctx context.Context, num *big.Int) ([]by
 		tctx, cancel := context.WithTimeout(ctx, m.options.Timeout)
 		defer cancel()
 
-		blockPayload, err = m.provider.RawBlockByNumber(tctx, num)
+		if m.options.LogsOnly {
+			blockPayload, err = m.headers.RawHeaderByNumber(tctx, num)
+		} else {
+			blockPayload, err = m.provider.RawBlockByNumber(tctx, num)
+		}
 		if err != nil {
 			if errors.Is(err, ethereum.NotFound) {
 				return nil, ethereum.NotFound
@@ -865,7 +934,11 @@ func (m *Monitor) fetchBlockByHash(ctx context.Context, hash common.Hash) (*type
 				return nil, superr.New(ErrMaxAttempts, err)
 			}
 
-			blockPayload, err = m.provider.RawBlockByHash(ctx, hash)
+			if m.options.LogsOnly {
+				blockPayload, err = m.headers.RawHeaderByHash(ctx, hash)
+			} else {
+				blockPayload, err = m.provider.RawBlockByHash(ctx, hash)
+			}
 			if err != nil {
 				if errors.Is(err, ethereum.NotFound) {
 					notFoundAttempts++
@@ -889,17 +962,20 @@ func (m *Monitor) fetchBlockByHash(ctx context.Context, hash common.Hash) (*type
 		if err != nil {
 			return nil, nil, err
 		}
-		block, err := unmarshalBlock(resp)
+		block, err := m.unmarshalBlock(resp)
 		return block, nil, err
 	}
 
 	// fetch with distributed mutex
 	key := fmt.Sprintf("ethmonitor:%s:BlockHash:%s", m.chainID.String(), hash.String())
+	if m.options.LogsOnly {
+		key = fmt.Sprintf("ethmonitor:%s:HeaderHash:%s", m.chainID.String(), hash.String())
+	}
 	resp, err := m.cache.GetOrSetWithLockEx(ctx, key, getter, m.options.CacheExpiry)
 	if err != nil {
 		return nil, nil, err
 	}
-	block, err := unmarshalBlock(resp)
+	block, err := m.unmarshalBlock(resp)
 	return block, resp, err
 }
 
@@ -938,11 +1014,15 @@ func (m *Monitor) broadcast(events Blocks) {
 	defer m.mu.Unlock()
 
 	for _, sub := range m.subscribers {
-		sub.ch.Send(events)
+		sub.ch.Send(filterBlocks(events, sub.logFilter))
 	}
 }
 
 func (m *Monitor) Subscribe(optLabel ...string) Subscription {
+	return m.subscribe(nil, optLabel...)
+}
+
+func (m *Monitor) subscribe(logFilter *LogFilter, optLabel ...string) Subscription {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -957,7 +1037,8 @@ func (m *Monitor) Subscribe(optLabel ...string) Subscription {
 			Alerter: m.alert,
 			Label:   label,
 		}),
-		done: make(chan struct{}),
+		done:      make(chan struct{}),
+		logFilter: logFilter,
 	}
 
 	subscriber.unsubscribe = func() {
@@ -1091,6 +1172,7 @@ func (m *Monitor) PurgeHistory() {
 		defer m.chain.mu.Unlock()
 		m.chain.blocks = m.chain.blocks[1:1]
 	}
+	m.receipts.purge()
 }
 
 func (m *Monitor) setPayload(value []byte) []byte {
@@ -1130,6 +1212,22 @@ func clampDuration(x, y time.Duration) time.Duration {
 	}
 }
 
+// unmarshalBlock unmarshals a block payload, or in LogsOnly mode a header
+// payload into a block without transactions.
+func (m *Monitor) unmarshalBlock(blockPayload []byte) (*types.Block, error) {
+	if !m.options.LogsOnly {
+		return unmarshalBlock(blockPayload)
+	}
+	var header *types.Header
+	if err := json.Unmarshal(blockPayload, &header); err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, ethereum.NotFound
+	}
+	return types.NewBlockWithHeader(header), nil
+}
+
 func unmarshalBlock(blockPayload []byte) (*types.Block, error) {
 	var block *types.Block
 	err := ethrpc.IntoBlock(blockPayload, &block)
@@ -2,19 +2,30 @@ package ethmonitor_test
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"math/big"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/0xsequence/ethkit/ethdevnode"
 	"github.com/0xsequence/ethkit/ethmonitor"
 	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethrpc/jsonrpc"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/go-ethereum"
 	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
 	"github.com/0xsequence/ethkit/util"
 	"github.com/go-chi/httpvcr"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMonitorBasic(t *testing.T) {
@@ -295,3 +306,187 @@ func TestMonitorWithReorgme(t *testing.T) {
 
 	monitor.Stop()
 }
+
+// logsProvider serves the logs of a fake contract for every block.
+type logsProvider struct {
+	ethrpc.RawInterface
+	ethrpc.RawHeaderInterface
+
+	logs    []types.Log
+	queries []ethereum.FilterQuery
+	mu      sync.Mutex
+}
+
+func (p *logsProvider) RawBlockByNumber(ctx context.Context, blockNum *big.Int) (json.RawMessage, error) {
+	panic("full blocks must not be fetched in LogsOnly mode")
+}
+
+func (p *logsProvider) RawFilterLogs(ctx context.Context, q ethereum.FilterQuery) (json.RawMessage, error) {
+	p.mu.Lock()
+	p.queries = append(p.queries, q)
+	p.mu.Unlock()
+
+	filter := &ethmonitor.LogFilter{Addresses: q.Addresses}
+	if len(q.Topics) > 0 {
+		filter.Topics = q.Topics[0]
+	}
+	logs := []types.Log{}
+	for _, log := range p.logs {
+		if filter.Match(&log) {
+			log.BlockHash = *q.BlockHash
+			logs = append(logs, log)
+		}
+	}
+	return json.Marshal(logs)
+}
+
+func TestMonitorLogsOnly(t *testing.T) {
+	node, err := ethdevnode.NewNode(context.Background())
+	require.NoError(t, err)
+	server := httptest.NewServer(node)
+	defer server.Close()
+
+	rpcProvider, err := ethrpc.NewProvider(server.URL)
+	require.NoError(t, err)
+
+	addrA := common.HexToAddress("0xaAaAaAaaAaAaAaaAaAAAAAAAAaaaAaAaAaaAaaAa")
+	addrB := common.HexToAddress("0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB")
+	addrC := common.HexToAddress("0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC")
+	topic := common.HexToHash("0x01")
+	provider := &logsProvider{
+		RawInterface:       rpcProvider,
+		RawHeaderInterface: rpcProvider,
+		logs: []types.Log{
+			{Address: addrA, Topics: []common.Hash{topic}, Data: []byte{}},
+			{Address: addrB, Topics: []common.Hash{topic}, Data: []byte{}},
+			{Address: addrC, Topics: []common.Hash{topic}, Data: []byte{}},
+		},
+	}
+
+	options := ethmonitor.DefaultOptions
+	options.LogsOnly = true
+	options.PollingInterval = 5 * time.Millisecond
+	monitor, err := ethmonitor.NewMonitor(provider, options)
+	require.NoError(t, err)
+	assert.True(t, monitor.Options().WithLogs)
+
+	// the headers are fetched of a provider of ethrpc.RawHeaderInterface
+	_, err = ethmonitor.NewMonitor(struct{ ethrpc.RawInterface }{rpcProvider}, options)
+	assert.Error(t, err)
+
+	subA := monitor.SubscribeWithLogFilter(ethmonitor.LogFilter{Addresses: []common.Address{addrA}})
+	defer subA.Unsubscribe()
+	subB := monitor.SubscribeWithLogFilter(ethmonitor.LogFilter{Addresses: []common.Address{addrB}, Topics: []common.Hash{topic}})
+	defer subB.Unsubscribe()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go monitor.Run(ctx)
+	defer monitor.Stop()
+
+	node.Mine()
+	node.Mine()
+
+	for _, sub := range []ethmonitor.Subscription{subA, subB} {
+		select {
+		case blocks := <-sub.Blocks():
+			block := blocks.LatestBlock()
+			assert.True(t, block.OK)
+			assert.Empty(t, block.Transactions())
+			require.Len(t, block.Logs, 1)
+			if sub == subA {
+				assert.Equal(t, addrA, block.Logs[0].Address)
+			} else {
+				assert.Equal(t, addrB, block.Logs[0].Address)
+			}
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for blocks")
+		}
+	}
+
+	provider.mu.Lock()
+	query := provider.queries[0]
+	provider.mu.Unlock()
+	assert.ElementsMatch(t, []common.Address{addrA, addrB}, query.Addresses)
+	assert.Empty(t, query.Topics, "subA matches all topics")
+}
+
+// countingProvider counts the requests of the provider, and optionally fails
+// them with err.
+type countingProvider struct {
+	ethrpc.RawInterface
+
+	calls atomic.Int32
+	err   error
+}
+
+func (p *countingProvider) Do(ctx context.Context, calls ...ethrpc.Call) ([]byte, error) {
+	p.calls.Add(1)
+	if p.err != nil {
+		return nil, p.err
+	}
+	// give the concurrent callers a chance to wait on the in-flight request
+	time.Sleep(20 * time.Millisecond)
+	return p.RawInterface.Do(ctx, calls...)
+}
+
+func TestMonitorBlockReceipts(t *testing.T) {
+	node, err := ethdevnode.NewNode(context.Background())
+	require.NoError(t, err)
+	server := httptest.NewServer(node)
+	defer server.Close()
+
+	rpcProvider, err := ethrpc.NewProvider(server.URL)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	wallet := node.Accounts()[0]
+	wallet.SetProvider(rpcProvider)
+	to := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	txn, err := wallet.NewTransaction(ctx, &ethtxn.TransactionRequest{To: &to, ETHValue: big.NewInt(1)})
+	require.NoError(t, err)
+	_, waitReceipt, err := wallet.SendTransaction(ctx, txn)
+	require.NoError(t, err)
+	receipt, err := waitReceipt(ctx)
+	require.NoError(t, err)
+
+	provider := &countingProvider{RawInterface: rpcProvider}
+	monitor, err := ethmonitor.NewMonitor(provider)
+	require.NoError(t, err)
+
+	// concurrent calls share a single request
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			receipts, err := monitor.BlockReceipts(ctx, receipt.BlockHash)
+			assert.NoError(t, err)
+			if assert.Len(t, receipts, 1) {
+				assert.Equal(t, txn.Hash(), receipts[0].TxHash)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), provider.calls.Load())
+
+	// and the receipts are cached
+	_, err = monitor.BlockReceipts(ctx, receipt.BlockHash)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), provider.calls.Load())
+
+	monitor.PurgeHistory()
+	_, err = monitor.BlockReceipts(ctx, receipt.BlockHash)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), provider.calls.Load())
+
+	// the nodes without eth_getBlockReceipts are asked only once
+	provider = &countingProvider{RawInterface: rpcProvider, err: &jsonrpc.Error{Code: -32601, Message: "the method eth_getBlockReceipts does not exist/is not available"}}
+	monitor, err = ethmonitor.NewMonitor(provider)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, err = monitor.BlockReceipts(ctx, receipt.BlockHash)
+		assert.ErrorIs(t, err, ethmonitor.ErrBlockReceiptsUnsupported)
+	}
+	assert.Equal(t, int32(1), provider.calls.Load())
+}
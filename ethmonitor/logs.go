@@ -0,0 +1,159 @@
+package ethmonitor
+
+import (
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+// LogFilter is the logs a subscriber is interested in: the logs emitted by any
+// of Addresses, with a first topic in any of Topics. Empty fields match all.
+type LogFilter struct {
+	Addresses []common.Address
+	Topics    []common.Hash
+}
+
+// IsEmpty returns true when the filter matches all logs.
+func (f *LogFilter) IsEmpty() bool {
+	return f == nil || (len(f.Addresses) == 0 && len(f.Topics) == 0)
+}
+
+// Match returns true when the log matches the filter.
+func (f *LogFilter) Match(log *types.Log) bool {
+	if f.IsEmpty() {
+		return true
+	}
+	if len(f.Addresses) > 0 && !containsAddress(f.Addresses, log.Address) {
+		return false
+	}
+	if len(f.Topics) > 0 && (len(log.Topics) == 0 || !containsHash(f.Topics, log.Topics[0])) {
+		return false
+	}
+	return true
+}
+
+// mayMatchBloom returns false when the bloom proves that the block has no
+// logs matching the filter.
+func (f *LogFilter) mayMatchBloom(bloom types.Bloom) bool {
+	if bloom == (types.Bloom{}) {
+		return false
+	}
+	if len(f.Addresses) > 0 {
+		found := false
+		for _, address := range f.Addresses {
+			if types.BloomLookup(bloom, address) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(f.Topics) > 0 {
+		found := false
+		for _, topic := range f.Topics {
+			if types.BloomLookup(bloom, topic) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// SubscribeWithLogFilter subscribes to the blocks of the monitor with only the
+// logs matching filter. In LogsOnly mode, when every subscriber has a filter,
+// the monitor fetches only the logs matching the union of the filters.
+func (m *Monitor) SubscribeWithLogFilter(filter LogFilter, optLabel ...string) Subscription {
+	return m.subscribe(&filter, optLabel...)
+}
+
+// logFilter returns the filter of the logs fetched by the monitor: the
+// LogAddresses and LogTopics options when set, or in LogsOnly mode the union
+// of the subscriber filters.
+func (m *Monitor) logFilter() *LogFilter {
+	filter := &LogFilter{Addresses: m.options.LogAddresses, Topics: m.options.LogTopics}
+	if !filter.IsEmpty() || !m.options.LogsOnly {
+		return filter
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.subscribers) == 0 {
+		return filter
+	}
+	filter = &LogFilter{}
+	allAddresses, allTopics := false, false
+	for _, sub := range m.subscribers {
+		if sub.logFilter.IsEmpty() {
+			return &LogFilter{}
+		}
+		if len(sub.logFilter.Addresses) == 0 {
+			allAddresses = true
+		}
+		if len(sub.logFilter.Topics) == 0 {
+			allTopics = true
+		}
+		for _, address := range sub.logFilter.Addresses {
+			if !allAddresses && !containsAddress(filter.Addresses, address) {
+				filter.Addresses = append(filter.Addresses, address)
+			}
+		}
+		for _, topic := range sub.logFilter.Topics {
+			if !allTopics && !containsHash(filter.Topics, topic) {
+				filter.Topics = append(filter.Topics, topic)
+			}
+		}
+	}
+	if allAddresses {
+		filter.Addresses = nil
+	}
+	if allTopics {
+		filter.Topics = nil
+	}
+	return filter
+}
+
+// filterBlocks returns a copy of blocks with only the logs matching filter.
+func filterBlocks(blocks Blocks, filter *LogFilter) Blocks {
+	if filter.IsEmpty() {
+		return blocks
+	}
+	filtered := make(Blocks, len(blocks))
+	for i, block := range blocks {
+		b := *block
+		b.LogsPayload = nil
+		if block.Logs != nil {
+			b.Logs = []types.Log{}
+			for _, log := range block.Logs {
+				if filter.Match(&log) {
+					b.Logs = append(b.Logs, log)
+				}
+			}
+		}
+		filtered[i] = &b
+	}
+	return filtered
+}
+
+func containsAddress(addresses []common.Address, address common.Address) bool {
+	for _, a := range addresses {
+		if a == address {
+			return true
+		}
+	}
+	return false
+}
+
+func containsHash(hashes []common.Hash, hash common.Hash) bool {
+	for _, h := range hashes {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package ethmonitor
+
+import (
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogFilter(t *testing.T) {
+	addr := common.HexToAddress("0x01")
+	topic := common.HexToHash("0x02")
+	log := &types.Log{Address: addr, Topics: []common.Hash{topic}}
+
+	assert.True(t, (*LogFilter)(nil).Match(log))
+	assert.True(t, (&LogFilter{Addresses: []common.Address{addr}}).Match(log))
+	assert.True(t, (&LogFilter{Topics: []common.Hash{topic}}).Match(log))
+	assert.False(t, (&LogFilter{Addresses: []common.Address{{0x03}}}).Match(log))
+	assert.False(t, (&LogFilter{Topics: []common.Hash{{0x03}}}).Match(&types.Log{Address: addr}))
+
+	bloom := types.CreateBloom(types.Receipts{{Logs: []*types.Log{log}}})
+	assert.True(t, (&LogFilter{}).mayMatchBloom(bloom))
+	assert.True(t, (&LogFilter{Addresses: []common.Address{addr}, Topics: []common.Hash{topic}}).mayMatchBloom(bloom))
+	assert.False(t, (&LogFilter{Addresses: []common.Address{{0x03}}}).mayMatchBloom(bloom))
+	assert.False(t, (&LogFilter{}).mayMatchBloom(types.Bloom{}))
+
+	monitor, err := NewMonitor(&ethrpc.Provider{}, Options{Logger: DefaultOptions.Logger, LogsOnly: true})
+	require.NoError(t, err)
+	assert.True(t, monitor.logFilter().IsEmpty())
+
+	monitor.SubscribeWithLogFilter(LogFilter{Addresses: []common.Address{addr}, Topics: []common.Hash{topic}})
+	monitor.SubscribeWithLogFilter(LogFilter{Addresses: []common.Address{addr, {0x03}}})
+	assert.Equal(t, &LogFilter{Addresses: []common.Address{addr, {0x03}}}, monitor.logFilter())
+
+	monitor.Subscribe()
+	assert.True(t, monitor.logFilter().IsEmpty())
+}
@@ -23,6 +23,9 @@ type subscriber struct {
 	err             error
 	unsubscribe     func()
 	unsubscribeOnce sync.Once
+
+	// logFilter of the logs delivered to the subscriber, nil for all logs
+	logFilter *LogFilter
 }
 
 func (s *subscriber) Blocks() <-chan Blocks {
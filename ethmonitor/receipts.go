@@ -0,0 +1,201 @@
+package ethmonitor
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethrpc/jsonrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+var ErrBlockReceiptsUnsupported = errors.New("ethmonitor: eth_getBlockReceipts is not supported by the node")
+
+// BlockReceipts returns the receipts of all the transactions of a block. The
+// receipts are fetched once per block with eth_getBlockReceipts, concurrent
+// calls for the same block share the same request, and the receipts are
+// cached within the BlockReceiptsCacheNumBlocks and BlockReceiptsCacheMaxBytes
+// bounds. The receipts of the blocks removed by a reorg are evicted.
+func (m *Monitor) BlockReceipts(ctx context.Context, blockHash common.Hash) ([]*types.Receipt, error) {
+	return m.receipts.get(ctx, blockHash, func(ctx context.Context) ([]*types.Receipt, error) {
+		if m.receipts.unsupported() {
+			return nil, ErrBlockReceiptsUnsupported
+		}
+
+		tctx, cancel := context.WithTimeout(ctx, m.options.Timeout)
+		defer cancel()
+
+		var receipts []*types.Receipt
+		_, err := m.provider.Do(tctx, ethrpc.BlockReceipts(blockHash).Into(&receipts))
+		var rpcErr *jsonrpc.Error
+		if errors.As(err, &rpcErr) && rpcErr.Code == -32601 {
+			m.log.Warnf("ethmonitor: node does not support eth_getBlockReceipts: %v", err)
+			m.receipts.setUnsupported()
+			return nil, ErrBlockReceiptsUnsupported
+		}
+		return receipts, err
+	})
+}
+
+// receiptsCache is a cache of the receipts of blocks, bounded by a number of
+// blocks and an estimated size in bytes, evicting the least recently used
+// blocks first.
+type receiptsCache struct {
+	maxBlocks int
+	maxBytes  int
+	size      int
+
+	// lru is the list of the cached *receiptsEntry, most recently used first
+	lru     *list.List
+	entries map[common.Hash]*list.Element
+
+	// calls are the in-flight fetches by block hash
+	calls map[common.Hash]*receiptsCall
+
+	noBlockReceipts bool
+	mu              sync.Mutex
+}
+
+type receiptsEntry struct {
+	blockHash common.Hash
+	receipts  []*types.Receipt
+	size      int
+}
+
+type receiptsCall struct {
+	done     chan struct{}
+	receipts []*types.Receipt
+	err      error
+}
+
+func newReceiptsCache(maxBlocks, maxBytes int) *receiptsCache {
+	return &receiptsCache{
+		maxBlocks: maxBlocks,
+		maxBytes:  maxBytes,
+		lru:       list.New(),
+		entries:   map[common.Hash]*list.Element{},
+		calls:     map[common.Hash]*receiptsCall{},
+	}
+}
+
+// get returns the cached receipts of the block, or fetches them sharing the
+// fetch with the concurrent calls for the same block.
+func (c *receiptsCache) get(ctx context.Context, blockHash common.Hash, fetch func(ctx context.Context) ([]*types.Receipt, error)) ([]*types.Receipt, error) {
+	for {
+		c.mu.Lock()
+		if elem, ok := c.entries[blockHash]; ok {
+			c.lru.MoveToFront(elem)
+			c.mu.Unlock()
+			return elem.Value.(*receiptsEntry).receipts, nil
+		}
+
+		if call, ok := c.calls[blockHash]; ok {
+			c.mu.Unlock()
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-call.done:
+			}
+			// retry when the fetch was cancelled by the context of another caller
+			if errors.Is(call.err, context.Canceled) || errors.Is(call.err, context.DeadlineExceeded) {
+				if ctx.Err() == nil {
+					continue
+				}
+			}
+			return call.receipts, call.err
+		}
+
+		call := &receiptsCall{done: make(chan struct{})}
+		c.calls[blockHash] = call
+		c.mu.Unlock()
+
+		call.receipts, call.err = fetch(ctx)
+
+		c.mu.Lock()
+		delete(c.calls, blockHash)
+		if call.err == nil {
+			c.add(blockHash, call.receipts)
+		}
+		c.mu.Unlock()
+		close(call.done)
+
+		return call.receipts, call.err
+	}
+}
+
+// add caches the receipts of the block and evicts the least recently used
+// blocks beyond the bounds. It must be called with the lock held.
+func (c *receiptsCache) add(blockHash common.Hash, receipts []*types.Receipt) {
+	if c.maxBlocks <= 0 {
+		return
+	}
+	size := receiptsSize(receipts)
+	if c.maxBytes > 0 && size > c.maxBytes {
+		return
+	}
+
+	c.entries[blockHash] = c.lru.PushFront(&receiptsEntry{blockHash: blockHash, receipts: receipts, size: size})
+	c.size += size
+
+	for c.lru.Len() > c.maxBlocks || (c.maxBytes > 0 && c.size > c.maxBytes) {
+		c.removeElement(c.lru.Back())
+	}
+}
+
+// remove evicts the receipts of the block.
+func (c *receiptsCache) remove(blockHash common.Hash) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[blockHash]; ok {
+		c.removeElement(elem)
+	}
+}
+
+func (c *receiptsCache) removeElement(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*receiptsEntry)
+	delete(c.entries, entry.blockHash)
+	c.size -= entry.size
+}
+
+// purge evicts the receipts of all the blocks.
+func (c *receiptsCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lru.Init()
+	c.entries = map[common.Hash]*list.Element{}
+	c.size = 0
+}
+
+// len returns the number of cached blocks and their estimated size in bytes.
+func (c *receiptsCache) len() (int, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len(), c.size
+}
+
+func (c *receiptsCache) unsupported() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.noBlockReceipts
+}
+
+func (c *receiptsCache) setUnsupported() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.noBlockReceipts = true
+}
+
+// receiptsSize returns an estimate of the memory used by the receipts.
+func receiptsSize(receipts []*types.Receipt) int {
+	size := 0
+	for _, receipt := range receipts {
+		size += 512 + len(receipt.PostState)
+		for _, log := range receipt.Logs {
+			size += 192 + 32*len(log.Topics) + len(log.Data)
+		}
+	}
+	return size
+}
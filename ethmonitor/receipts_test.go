@@ -0,0 +1,60 @@
+package ethmonitor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReceiptsCacheBounds(t *testing.T) {
+	ctx := context.Background()
+	receipts := []*types.Receipt{{Logs: []*types.Log{{Data: make([]byte, 1000)}}}}
+	size := receiptsSize(receipts)
+	fetch := func(context.Context) ([]*types.Receipt, error) { return receipts, nil }
+
+	cache := newReceiptsCache(2, 0)
+	for i := byte(1); i <= 3; i++ {
+		_, err := cache.get(ctx, common.Hash{i}, fetch)
+		require.NoError(t, err)
+	}
+	n, bytes := cache.len()
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 2*size, bytes)
+	assert.NotContains(t, cache.entries, common.Hash{1}, "least recently used block is evicted")
+
+	cache.remove(common.Hash{2})
+	n, bytes = cache.len()
+	assert.Equal(t, 1, n)
+	assert.Equal(t, size, bytes)
+
+	// bounded by size
+	cache = newReceiptsCache(10, 2*size+1)
+	for i := byte(1); i <= 3; i++ {
+		_, err := cache.get(ctx, common.Hash{i}, fetch)
+		require.NoError(t, err)
+	}
+	n, bytes = cache.len()
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 2*size, bytes)
+
+	// the blocks larger than the bound and the errors are not cached
+	cache = newReceiptsCache(10, size-1)
+	_, err := cache.get(ctx, common.Hash{1}, fetch)
+	require.NoError(t, err)
+	_, err = cache.get(ctx, common.Hash{2}, func(context.Context) ([]*types.Receipt, error) { return nil, errors.New("fail") })
+	assert.Error(t, err)
+	n, _ = cache.len()
+	assert.Equal(t, 0, n)
+
+	// disabled
+	cache = newReceiptsCache(0, 0)
+	_, err = cache.get(ctx, common.Hash{1}, fetch)
+	require.NoError(t, err)
+	n, _ = cache.len()
+	assert.Equal(t, 0, n)
+}
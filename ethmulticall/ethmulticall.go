@@ -0,0 +1,78 @@
+package ethmulticall
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethcontract"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// Multicall3Address is the address of the Multicall3 contract, which is deployed
+// at the same address on most chains, see https://www.multicall3.com
+var Multicall3Address = common.HexToAddress("0xcA11bde05779ba9Ab0F8b3dBEd2b0dE02cA1bD3b")
+
+var Multicall3ABI = ethcontract.MustParseABI(`[
+	{"type":"function","name":"aggregate3","stateMutability":"payable","inputs":[{"name":"calls","type":"tuple[]","components":[{"name":"target","type":"address"},{"name":"allowFailure","type":"bool"},{"name":"callData","type":"bytes"}]}],"outputs":[{"name":"returnData","type":"tuple[]","components":[{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}]}]},
+	{"type":"function","name":"getEthBalance","stateMutability":"view","inputs":[{"name":"addr","type":"address"}],"outputs":[{"name":"balance","type":"uint256"}]},
+	{"type":"function","name":"getBlockNumber","stateMutability":"view","inputs":[],"outputs":[{"name":"blockNumber","type":"uint256"}]}
+]`)
+
+// Call is a single call of an aggregate3 batch.
+type Call struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// Result is the result of a single call of an aggregate3 batch. When Success is
+// false, ReturnData is the revert data of the call.
+type Result struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// EncodeAggregate3 returns the calldata of Multicall3.aggregate3 for the calls.
+func EncodeAggregate3(calls []Call) ([]byte, error) {
+	return Multicall3ABI.Pack("aggregate3", calls)
+}
+
+// DecodeAggregate3 decodes the return data of Multicall3.aggregate3.
+func DecodeAggregate3(data []byte) ([]Result, error) {
+	var results []Result
+	if err := Multicall3ABI.UnpackIntoInterface(&results, "aggregate3", data); err != nil {
+		return nil, fmt.Errorf("ethmulticall: failed to decode aggregate3 result: %w", err)
+	}
+	return results, nil
+}
+
+// Aggregate3 executes the calls in a single eth_call to the Multicall3 contract
+// at multicallAddress.
+func Aggregate3(ctx context.Context, provider ethrpc.Interface, multicallAddress common.Address, calls []Call, blockNum *big.Int) ([]Result, error) {
+	return Aggregate3WithOpts(ctx, provider, multicallAddress, calls, &ethcontract.CallOpts{BlockNum: blockNum})
+}
+
+// Aggregate3WithOpts executes the calls like Aggregate3, with the call options,
+// ie. a block hash or a state override.
+func Aggregate3WithOpts(ctx context.Context, provider ethrpc.Interface, multicallAddress common.Address, calls []Call, opts *ethcontract.CallOpts) ([]Result, error) {
+	calldata, err := EncodeAggregate3(calls)
+	if err != nil {
+		return nil, fmt.Errorf("ethmulticall: failed to encode aggregate3: %w", err)
+	}
+
+	data, err := ethcontract.Call(ctx, provider, multicallAddress, calldata, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := DecodeAggregate3(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) != len(calls) {
+		return nil, fmt.Errorf("ethmulticall: expecting %d results but received %d", len(calls), len(results))
+	}
+	return results, nil
+}
@@ -0,0 +1,249 @@
+package ethmulticall
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi/bind"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+var DefaultSchedulerOptions = SchedulerOptions{
+	Window:           10 * time.Millisecond,
+	MaxBatchSize:     100,
+	MulticallAddress: Multicall3Address,
+	Timeout:          30 * time.Second,
+}
+
+type SchedulerOptions struct {
+	// Window is how long to wait for more calls before executing a batch.
+	Window time.Duration
+
+	// MaxBatchSize is the max number of calls per batch. A batch is executed
+	// as soon as it is full.
+	MaxBatchSize int
+
+	// MulticallAddress is the address of the Multicall3 contract.
+	MulticallAddress common.Address
+
+	// Timeout of the execution of a batch, which is shared by all of its callers.
+	Timeout time.Duration
+}
+
+// Scheduler coalesces contract reads issued within a small window into
+// Multicall3 batches. If Multicall3 is not deployed on the chain, the batch is
+// sent as a JSON-RPC batch of plain eth_call's instead.
+//
+// The Scheduler implements bind.ContractCaller, so it can be passed to any
+// contract binding as-is. Calls which can't be batched, ie. with a From, Value
+// or Gas set, are forwarded to the provider. Reverted calls are retried with
+// a plain eth_call, so callers receive the same result and error as if they
+// had called the provider directly.
+type Scheduler struct {
+	options  SchedulerOptions
+	provider ethrpc.Interface
+
+	batches map[string]*batch
+	mu      sync.Mutex
+
+	supported   *bool
+	supportedMu sync.Mutex
+}
+
+type batch struct {
+	blockNum *big.Int
+	calls    []*pendingCall
+	timer    *time.Timer
+}
+
+type pendingCall struct {
+	msg    ethereum.CallMsg
+	result []byte
+	err    error
+	done   chan struct{}
+}
+
+var _ bind.ContractCaller = &Scheduler{}
+
+func NewScheduler(provider ethrpc.Interface, opts ...SchedulerOptions) *Scheduler {
+	options := DefaultSchedulerOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	if options.MaxBatchSize <= 0 {
+		options.MaxBatchSize = DefaultSchedulerOptions.MaxBatchSize
+	}
+	if options.MulticallAddress == (common.Address{}) {
+		options.MulticallAddress = Multicall3Address
+	}
+	if options.Timeout == 0 {
+		options.Timeout = DefaultSchedulerOptions.Timeout
+	}
+
+	return &Scheduler{
+		options:  options,
+		provider: provider,
+		batches:  map[string]*batch{},
+	}
+}
+
+func (s *Scheduler) CodeAt(ctx context.Context, contract common.Address, blockNum *big.Int) ([]byte, error) {
+	return s.provider.CodeAt(ctx, contract, blockNum)
+}
+
+func (s *Scheduler) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNum *big.Int) ([]byte, error) {
+	if !isBatchable(msg) {
+		return s.provider.CallContract(ctx, msg, blockNum)
+	}
+
+	call := &pendingCall{msg: msg, done: make(chan struct{})}
+	s.enqueue(call, blockNum)
+
+	select {
+	case <-call.done:
+		return call.result, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *Scheduler) enqueue(call *pendingCall, blockNum *big.Int) {
+	key := "latest"
+	if blockNum != nil {
+		key = blockNum.String()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.batches[key]
+	if !ok {
+		b = &batch{blockNum: blockNum}
+		b.timer = time.AfterFunc(s.options.Window, func() {
+			s.mu.Lock()
+			if s.batches[key] == b {
+				delete(s.batches, key)
+			}
+			s.mu.Unlock()
+			s.execute(b)
+		})
+		s.batches[key] = b
+	}
+
+	b.calls = append(b.calls, call)
+	if len(b.calls) >= s.options.MaxBatchSize {
+		delete(s.batches, key)
+		if b.timer.Stop() {
+			go s.execute(b)
+		}
+	}
+}
+
+func (s *Scheduler) execute(b *batch) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.options.Timeout)
+	defer cancel()
+
+	defer func() {
+		for _, call := range b.calls {
+			close(call.done)
+		}
+	}()
+
+	if len(b.calls) == 1 {
+		call := b.calls[0]
+		call.result, call.err = s.provider.CallContract(ctx, call.msg, b.blockNum)
+		return
+	}
+
+	if !s.isMulticallSupported(ctx) {
+		s.executeEthCalls(ctx, b.calls, b.blockNum)
+		return
+	}
+
+	calls := make([]Call, len(b.calls))
+	for i, call := range b.calls {
+		calls[i] = Call{Target: *call.msg.To, AllowFailure: true, CallData: call.msg.Data}
+	}
+
+	results, err := Aggregate3(ctx, s.provider, s.options.MulticallAddress, calls, b.blockNum)
+	if err != nil {
+		// the batch as a whole failed, ie. ran out of gas, so we
+		// fallback to individual calls
+		s.executeEthCalls(ctx, b.calls, b.blockNum)
+		return
+	}
+
+	var failed []*pendingCall
+	for i, result := range results {
+		if result.Success {
+			b.calls[i].result = result.ReturnData
+		} else {
+			failed = append(failed, b.calls[i])
+		}
+	}
+	if len(failed) > 0 {
+		s.executeEthCalls(ctx, failed, b.blockNum)
+	}
+}
+
+// executeEthCalls sends the calls as a single JSON-RPC batch of eth_call's.
+func (s *Scheduler) executeEthCalls(ctx context.Context, calls []*pendingCall, blockNum *big.Int) {
+	rpcCalls := make([]ethrpc.Call, len(calls))
+	for i, call := range calls {
+		rpcCalls[i] = ethrpc.CallContract(call.msg, blockNum).Into(&call.result)
+	}
+
+	_, err := s.provider.Do(ctx, rpcCalls...)
+	if err == nil {
+		return
+	}
+
+	var batchErr ethrpc.BatchError
+	if !errors.As(err, &batchErr) {
+		for _, call := range calls {
+			call.result, call.err = nil, err
+		}
+		return
+	}
+
+	// return errors the same as the provider would for a single call
+	for i, c := range batchErr {
+		calls[i].result, calls[i].err = nil, ethrpc.BatchError{0: c}
+	}
+}
+
+func (s *Scheduler) isMulticallSupported(ctx context.Context) bool {
+	s.supportedMu.Lock()
+	defer s.supportedMu.Unlock()
+
+	if s.supported != nil {
+		return *s.supported
+	}
+
+	code, err := s.provider.CodeAt(ctx, s.options.MulticallAddress, nil)
+	if err != nil {
+		// try again on the next batch
+		return false
+	}
+	supported := len(code) > 0
+	s.supported = &supported
+	return supported
+}
+
+func isBatchable(msg ethereum.CallMsg) bool {
+	if msg.To == nil || msg.From != (common.Address{}) || msg.Gas != 0 {
+		return false
+	}
+	if msg.Value != nil && msg.Value.Sign() != 0 {
+		return false
+	}
+	if msg.GasPrice != nil || msg.GasFeeCap != nil || msg.GasTipCap != nil {
+		return false
+	}
+	return len(msg.AccessList) == 0 && len(msg.BlobHashes) == 0
+}
@@ -0,0 +1,228 @@
+package ethmulticall_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethcontract"
+	"github.com/0xsequence/ethkit/ethmulticall"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	okContract     = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	revertContract = common.HexToAddress("0x2222222222222222222222222222222222222222")
+)
+
+// fakeNode is a minimal JSON-RPC node with two contracts, one which returns
+// its calldata and one which always reverts, and optionally Multicall3.
+type fakeNode struct {
+	withMulticall bool
+	ethCalls      int32
+	requests      int32
+	lastCall      []json.RawMessage
+	mu            sync.Mutex
+}
+
+type rpcRequest struct {
+	ID     uint64            `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type rpcResponse struct {
+	Version string `json:"jsonrpc"`
+	ID      uint64 `json:"id"`
+	Result  any    `json:"result,omitempty"`
+	Error   any    `json:"error,omitempty"`
+}
+
+func (n *fakeNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&n.requests, 1)
+	body, _ := io.ReadAll(r.Body)
+
+	var reqs []rpcRequest
+	batch := len(body) > 0 && body[0] == '['
+	if batch {
+		json.Unmarshal(body, &reqs)
+	} else {
+		reqs = make([]rpcRequest, 1)
+		json.Unmarshal(body, &reqs[0])
+	}
+
+	resps := make([]rpcResponse, len(reqs))
+	for i, req := range reqs {
+		resps[i] = n.handle(req)
+	}
+	if batch {
+		json.NewEncoder(w).Encode(resps)
+	} else {
+		json.NewEncoder(w).Encode(resps[0])
+	}
+}
+
+func (n *fakeNode) handle(req rpcRequest) rpcResponse {
+	resp := rpcResponse{Version: "2.0", ID: req.ID}
+	switch req.Method {
+	case "eth_getCode":
+		var addr common.Address
+		json.Unmarshal(req.Params[0], &addr)
+		if addr == ethmulticall.Multicall3Address && n.withMulticall {
+			resp.Result = "0x00"
+		} else {
+			resp.Result = "0x"
+		}
+	case "eth_call":
+		atomic.AddInt32(&n.ethCalls, 1)
+		n.mu.Lock()
+		n.lastCall = req.Params
+		n.mu.Unlock()
+		var msg struct {
+			To   common.Address `json:"to"`
+			Data hexutil.Bytes  `json:"data"`
+		}
+		json.Unmarshal(req.Params[0], &msg)
+
+		if msg.To == ethmulticall.Multicall3Address && n.withMulticall {
+			method := ethmulticall.Multicall3ABI.Methods["aggregate3"]
+			var calls []ethmulticall.Call
+			args, _ := method.Inputs.Unpack(msg.Data[4:])
+			method.Inputs.Copy(&calls, args)
+
+			results := make([]ethmulticall.Result, len(calls))
+			for i, call := range calls {
+				results[i].ReturnData, results[i].Success = call.CallData, call.Target == okContract
+			}
+			out, _ := method.Outputs.Pack(results)
+			resp.Result = hexutil.Bytes(out)
+			return resp
+		}
+
+		if msg.To == revertContract {
+			resp.Error = map[string]any{"code": 3, "message": "execution reverted"}
+			return resp
+		}
+		resp.Result = hexutil.Bytes(msg.Data)
+	default:
+		resp.Error = map[string]any{"code": -32601, "message": "method not found"}
+	}
+	return resp
+}
+
+func runCalls(t *testing.T, scheduler *ethmulticall.Scheduler, n int) ([][]byte, []error) {
+	results := make([][]byte, n)
+	errs := make([]error, n)
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			to := okContract
+			if i%3 == 2 {
+				to = revertContract
+			}
+			results[i], errs[i] = scheduler.CallContract(context.Background(), ethereum.CallMsg{
+				To:   &to,
+				Data: big.NewInt(int64(i + 1)).Bytes(),
+			}, nil)
+		}(i)
+	}
+	wg.Wait()
+	return results, errs
+}
+
+func TestSchedulerMulticall(t *testing.T) {
+	node := &fakeNode{withMulticall: true}
+	server := httptest.NewServer(node)
+	defer server.Close()
+
+	provider, err := ethrpc.NewProvider(server.URL)
+	require.NoError(t, err)
+
+	scheduler := ethmulticall.NewScheduler(provider, ethmulticall.SchedulerOptions{Window: 100 * time.Millisecond})
+	results, errs := runCalls(t, scheduler, 9)
+
+	for i := range results {
+		if i%3 == 2 {
+			assert.Error(t, errs[i])
+			assert.Contains(t, errs[i].Error(), "execution reverted")
+			assert.Nil(t, results[i])
+		} else {
+			assert.NoError(t, errs[i])
+			assert.Equal(t, big.NewInt(int64(i+1)).Bytes(), results[i])
+		}
+	}
+
+	// one aggregate3 call, and three eth_call retries of the reverted calls
+	assert.Equal(t, int32(4), atomic.LoadInt32(&node.ethCalls))
+
+	// direct calls to the provider return the same error
+	_, directErr := provider.CallContract(context.Background(), ethereum.CallMsg{To: &revertContract}, nil)
+	assert.Equal(t, directErr.Error(), errs[2].Error())
+}
+
+func TestSchedulerFallback(t *testing.T) {
+	node := &fakeNode{withMulticall: false}
+	server := httptest.NewServer(node)
+	defer server.Close()
+
+	provider, err := ethrpc.NewProvider(server.URL)
+	require.NoError(t, err)
+
+	scheduler := ethmulticall.NewScheduler(provider, ethmulticall.SchedulerOptions{Window: 100 * time.Millisecond})
+	results, errs := runCalls(t, scheduler, 6)
+
+	for i := range results {
+		if i%3 == 2 {
+			assert.Error(t, errs[i])
+		} else {
+			assert.NoError(t, errs[i])
+			assert.Equal(t, ethcoder.HexEncode(big.NewInt(int64(i+1)).Bytes()), ethcoder.HexEncode(results[i]))
+		}
+	}
+
+	// eth_getCode and a single JSON-RPC batch of eth_call's
+	assert.Equal(t, int32(6), atomic.LoadInt32(&node.ethCalls))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&node.requests))
+}
+
+func TestAggregate3WithOpts(t *testing.T) {
+	node := &fakeNode{withMulticall: true}
+	server := httptest.NewServer(node)
+	defer server.Close()
+	provider, err := ethrpc.NewProvider(server.URL)
+	require.NoError(t, err)
+
+	blockHash := common.HexToHash("0x01")
+	nonce := uint64(7)
+	opts := &ethcontract.CallOpts{
+		From:          common.HexToAddress("0x03"),
+		BlockHash:     blockHash,
+		StateOverride: ethrpc.StateOverride{okContract: {Code: []byte{0x60, 0x00}, Nonce: &nonce}},
+	}
+	results, err := ethmulticall.Aggregate3WithOpts(context.Background(), provider, ethmulticall.Multicall3Address, []ethmulticall.Call{{Target: okContract, CallData: []byte{0x01}}}, opts)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Success)
+
+	require.Len(t, node.lastCall, 3)
+	assert.Contains(t, string(node.lastCall[0]), `"from":"0x0000000000000000000000000000000000000003"`)
+	assert.Contains(t, string(node.lastCall[1]), blockHash.Hex())
+	assert.JSONEq(t, `{"`+strings.ToLower(okContract.Hex())+`":{"nonce":"0x7","code":"0x6000"}}`, string(node.lastCall[2]))
+}
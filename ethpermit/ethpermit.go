@@ -0,0 +1,199 @@
+package ethpermit
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethcontract"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// PermitABI is the abi of the views of the permits of ERC-2612 and of DAI.
+var PermitABI = ethcontract.MustParseABI(`[
+	{"type":"function","name":"name","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+	{"type":"function","name":"version","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+	{"type":"function","name":"nonces","stateMutability":"view","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"DOMAIN_SEPARATOR","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"bytes32"}]}
+]`)
+
+// Permit is the message of an ERC-2612 permit.
+type Permit struct {
+	Owner    common.Address
+	Spender  common.Address
+	Value    *big.Int
+	Nonce    *big.Int
+	Deadline *big.Int
+}
+
+// DAIPermit is the message of the legacy permit of DAI, which allows the spender
+// the max allowance, or none, until the expiry.
+type DAIPermit struct {
+	Holder  common.Address
+	Spender common.Address
+	Nonce   *big.Int
+	Expiry  *big.Int
+	Allowed bool
+}
+
+// TypedData returns the typed data of the permit of the domain of the token.
+func (p Permit) TypedData(domain ethcoder.TypedDataDomain) *ethcoder.TypedData {
+	return &ethcoder.TypedData{
+		Types: ethcoder.TypedDataTypes{
+			"EIP712Domain": domain.Type(),
+			"Permit": {
+				{Name: "owner", Type: "address"},
+				{Name: "spender", Type: "address"},
+				{Name: "value", Type: "uint256"},
+				{Name: "nonce", Type: "uint256"},
+				{Name: "deadline", Type: "uint256"},
+			},
+		},
+		PrimaryType: "Permit",
+		Domain:      domain,
+		Message: map[string]interface{}{
+			"owner":    p.Owner,
+			"spender":  p.Spender,
+			"value":    p.Value,
+			"nonce":    p.Nonce,
+			"deadline": p.Deadline,
+		},
+	}
+}
+
+// TypedData returns the typed data of the permit of the domain of the token.
+func (p DAIPermit) TypedData(domain ethcoder.TypedDataDomain) *ethcoder.TypedData {
+	return &ethcoder.TypedData{
+		Types: ethcoder.TypedDataTypes{
+			"EIP712Domain": domain.Type(),
+			"Permit": {
+				{Name: "holder", Type: "address"},
+				{Name: "spender", Type: "address"},
+				{Name: "nonce", Type: "uint256"},
+				{Name: "expiry", Type: "uint256"},
+				{Name: "allowed", Type: "bool"},
+			},
+		},
+		PrimaryType: "Permit",
+		Domain:      domain,
+		Message: map[string]interface{}{
+			"holder":  p.Holder,
+			"spender": p.Spender,
+			"nonce":   p.Nonce,
+			"expiry":  p.Expiry,
+			"allowed": p.Allowed,
+		},
+	}
+}
+
+// BuildPermit returns the typed data of the ERC-2612 permit of the value of the
+// token of the owner to the spender until the deadline, of the nonce of the owner
+// and the domain of the token, see Domain. The digest to sign is of its
+// EncodeDigest.
+func BuildPermit(ctx context.Context, provider ethrpc.Interface, token, owner, spender common.Address, value, deadline *big.Int) (*ethcoder.TypedData, error) {
+	domain, err := Domain(ctx, provider, token, nil)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := Nonce(ctx, provider, token, owner, nil)
+	if err != nil {
+		return nil, err
+	}
+	permit := Permit{Owner: owner, Spender: spender, Value: value, Nonce: nonce, Deadline: deadline}
+	return permit.TypedData(*domain), nil
+}
+
+// BuildDAIPermit returns the typed data of the DAI permit of the token of the
+// holder to the spender until the expiry, as BuildPermit.
+func BuildDAIPermit(ctx context.Context, provider ethrpc.Interface, token, holder, spender common.Address, expiry *big.Int, allowed bool) (*ethcoder.TypedData, error) {
+	domain, err := Domain(ctx, provider, token, nil)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := Nonce(ctx, provider, token, holder, nil)
+	if err != nil {
+		return nil, err
+	}
+	permit := DAIPermit{Holder: holder, Spender: spender, Nonce: nonce, Expiry: expiry, Allowed: allowed}
+	return permit.TypedData(*domain), nil
+}
+
+// PermitDigest returns the digest to sign of the ERC-2612 permit, see
+// BuildPermit.
+func PermitDigest(ctx context.Context, provider ethrpc.Interface, token, owner, spender common.Address, value, deadline *big.Int) (common.Hash, error) {
+	typedData, err := BuildPermit(ctx, provider, token, owner, spender, value, deadline)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	digest, err := typedData.EncodeDigest()
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("ethpermit: failed to encode permit: %w", err)
+	}
+	return common.BytesToHash(digest), nil
+}
+
+// Domain returns the EIP-712 domain of the permits of the token, of its EIP-5267
+// eip712Domain() when it has one. Otherwise it is of the name and version() of
+// the token, or version "1" without one, the chain id of the provider and the
+// token, which is checked against the DOMAIN_SEPARATOR() of the token. The calls
+// are of the options, or at the latest block when opts is nil.
+func Domain(ctx context.Context, provider ethrpc.Interface, token common.Address, opts *ethcontract.CallOpts) (*ethcoder.TypedDataDomain, error) {
+	if domain, err := ethcontract.EIP712Domain(ctx, provider, token, opts); err == nil {
+		return domain, nil
+	}
+
+	name, err := callString(ctx, provider, token, opts, "name")
+	if err != nil {
+		return nil, err
+	}
+	version, err := callString(ctx, provider, token, opts, "version")
+	if err != nil {
+		version = "1"
+	}
+	chainID, err := provider.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ethpermit: failed to get chain id: %w", err)
+	}
+	domain := &ethcoder.TypedDataDomain{Name: name, Version: version, ChainID: chainID, VerifyingContract: &token}
+
+	var separator [32]byte
+	if err := call(ctx, provider, token, opts, "DOMAIN_SEPARATOR", &separator); err != nil {
+		return nil, err
+	}
+	expected, err := domain.Separator()
+	if err != nil {
+		return nil, err
+	}
+	if expected != separator {
+		return nil, fmt.Errorf("ethpermit: domain separator of %s is not of name %q and version %q", token.Hex(), name, version)
+	}
+	return domain, nil
+}
+
+// Nonce returns the permit nonce of the owner of the token, with the options or
+// at the latest block when opts is nil.
+func Nonce(ctx context.Context, provider ethrpc.Interface, token, owner common.Address, opts *ethcontract.CallOpts) (*big.Int, error) {
+	var nonce *big.Int
+	if err := call(ctx, provider, token, opts, "nonces", &nonce, owner); err != nil {
+		return nil, err
+	}
+	return nonce, nil
+}
+
+func callString(ctx context.Context, provider ethrpc.Interface, token common.Address, opts *ethcontract.CallOpts, method string) (string, error) {
+	var s string
+	if err := call(ctx, provider, token, opts, method, &s); err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
+func call(ctx context.Context, provider ethrpc.Interface, token common.Address, opts *ethcontract.CallOpts, method string, result interface{}, args ...interface{}) error {
+	contract := ethcontract.NewContractCaller(token, PermitABI, nil)
+	if err := contract.QueryInto(ctx, provider, opts, result, method, args...); err != nil {
+		return fmt.Errorf("ethpermit: failed to call %s of %s: %w", method, token.Hex(), err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package ethpermit_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethpermit"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	token   = common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+	owner   = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	spender = common.HexToAddress("0x2222222222222222222222222222222222222222")
+)
+
+// mockToken is a token without eip712Domain(), of the results of its methods
+type mockToken struct {
+	ethrpc.Interface
+	results map[string][]byte
+}
+
+func (p *mockToken) ChainID(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(1), nil
+}
+
+func (p *mockToken) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNum *big.Int) ([]byte, error) {
+	for name, method := range ethpermit.PermitABI.Methods {
+		if string(method.ID) == string(msg.Data[:4]) {
+			if result, ok := p.results[name]; ok {
+				return result, nil
+			}
+		}
+	}
+	return nil, errors.New("execution reverted")
+}
+
+func newMockToken(t *testing.T, name, version string, separatorVersion string) *mockToken {
+	pack := func(method string, values ...interface{}) []byte {
+		data, err := ethpermit.PermitABI.Methods[method].Outputs.Pack(values...)
+		require.NoError(t, err)
+		return data
+	}
+	separator, err := ethcoder.NewTypedDataDomain().Name(name).Version(separatorVersion).ChainID(big.NewInt(1)).VerifyingContract(token).Separator()
+	require.NoError(t, err)
+
+	results := map[string][]byte{
+		"name":             pack("name", name),
+		"nonces":           pack("nonces", big.NewInt(3)),
+		"DOMAIN_SEPARATOR": pack("DOMAIN_SEPARATOR", [32]byte(separator)),
+	}
+	if version != "" {
+		results["version"] = pack("version", version)
+	}
+	return &mockToken{results: results}
+}
+
+func TestBuildPermit(t *testing.T) {
+	ctx := context.Background()
+	provider := newMockToken(t, "Token", "2", "2")
+
+	typedData, err := ethpermit.BuildPermit(ctx, provider, token, owner, spender, big.NewInt(100), big.NewInt(1700000000))
+	require.NoError(t, err)
+	assert.Equal(t, "2", typedData.Domain.Version)
+	assert.Equal(t, big.NewInt(3), typedData.Message["nonce"])
+
+	typeHash, err := typedData.Types.TypeHash("Permit")
+	require.NoError(t, err)
+	assert.Equal(t, "0x6e71edae12b1b97f4d1f60370fef10105fa2faae0126114a169c64845d6126c9", ethcoder.HexEncode(typeHash))
+
+	// the digest of the permit of the domain separator of the token
+	separator, err := typedData.Domain.Separator()
+	require.NoError(t, err)
+	structHash := crypto.Keccak256(
+		typeHash,
+		common.BytesToHash(owner.Bytes()).Bytes(),
+		common.BytesToHash(spender.Bytes()).Bytes(),
+		common.BigToHash(big.NewInt(100)).Bytes(),
+		common.BigToHash(big.NewInt(3)).Bytes(),
+		common.BigToHash(big.NewInt(1700000000)).Bytes(),
+	)
+	expected := crypto.Keccak256Hash([]byte{0x19, 0x01}, separator.Bytes(), structHash)
+
+	digest, err := ethpermit.PermitDigest(ctx, provider, token, owner, spender, big.NewInt(100), big.NewInt(1700000000))
+	require.NoError(t, err)
+	assert.Equal(t, expected, digest)
+
+	// the default version of the tokens without version()
+	typedData, err = ethpermit.BuildPermit(ctx, newMockToken(t, "Token", "", "1"), token, owner, spender, big.NewInt(100), big.NewInt(1700000000))
+	require.NoError(t, err)
+	assert.Equal(t, "1", typedData.Domain.Version)
+
+	// a domain separator of another version
+	_, err = ethpermit.BuildPermit(ctx, newMockToken(t, "Token", "", "2"), token, owner, spender, big.NewInt(100), big.NewInt(1700000000))
+	assert.Error(t, err)
+}
+
+func TestBuildDAIPermit(t *testing.T) {
+	provider := newMockToken(t, "Dai Stablecoin", "1", "1")
+
+	typedData, err := ethpermit.BuildDAIPermit(context.Background(), provider, token, owner, spender, big.NewInt(0), true)
+	require.NoError(t, err)
+	assert.Equal(t, true, typedData.Message["allowed"])
+
+	typeHash, err := typedData.Types.TypeHash("Permit")
+	require.NoError(t, err)
+	assert.Equal(t, "0xea2aa0a1be11a07ed86d755c93467f4f82362b452371d1ba94d1715123511acb", ethcoder.HexEncode(typeHash))
+
+	_, err = typedData.EncodeDigest()
+	require.NoError(t, err)
+}
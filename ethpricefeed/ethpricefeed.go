@@ -0,0 +1,305 @@
+package ethpricefeed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcontract"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi/bind"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+var (
+	ErrInvalidPrice    = errors.New("ethpricefeed: invalid price")
+	ErrIncompleteRound = errors.New("ethpricefeed: incomplete round")
+	ErrStalePrice      = errors.New("ethpricefeed: stale price")
+)
+
+// FeedRegistryAddress is the address of the Chainlink Feed Registry on Ethereum mainnet.
+var FeedRegistryAddress = common.HexToAddress("0x47Fb2585D2C56Fe188D0E6ec628a38b74fCeeeDf")
+
+// Denominations used by the Feed Registry for assets which are not tokens,
+// see https://github.com/smartcontractkit/chainlink/blob/develop/contracts/src/v0.8/Denominations.sol
+var (
+	DenominationETH = common.HexToAddress("0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE")
+	DenominationBTC = common.HexToAddress("0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB")
+	DenominationUSD = common.HexToAddress("0x0000000000000000000000000000000000000348")
+	DenominationEUR = common.HexToAddress("0x00000000000000000000000000000000000003d2")
+	DenominationGBP = common.HexToAddress("0x000000000000000000000000000000000000033a")
+)
+
+var AggregatorV3ABI = ethcontract.MustParseABI(`[
+	{"type":"function","name":"decimals","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint8"}]},
+	{"type":"function","name":"description","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+	{"type":"function","name":"latestRoundData","stateMutability":"view","inputs":[],"outputs":[{"name":"roundId","type":"uint80"},{"name":"answer","type":"int256"},{"name":"startedAt","type":"uint256"},{"name":"updatedAt","type":"uint256"},{"name":"answeredInRound","type":"uint80"}]},
+	{"type":"function","name":"getRoundData","stateMutability":"view","inputs":[{"name":"roundId","type":"uint80"}],"outputs":[{"name":"roundId","type":"uint80"},{"name":"answer","type":"int256"},{"name":"startedAt","type":"uint256"},{"name":"updatedAt","type":"uint256"},{"name":"answeredInRound","type":"uint80"}]}
+]`)
+
+var FeedRegistryABI = ethcontract.MustParseABI(`[
+	{"type":"function","name":"decimals","stateMutability":"view","inputs":[{"name":"base","type":"address"},{"name":"quote","type":"address"}],"outputs":[{"name":"","type":"uint8"}]},
+	{"type":"function","name":"getFeed","stateMutability":"view","inputs":[{"name":"base","type":"address"},{"name":"quote","type":"address"}],"outputs":[{"name":"aggregator","type":"address"}]},
+	{"type":"function","name":"latestRoundData","stateMutability":"view","inputs":[{"name":"base","type":"address"},{"name":"quote","type":"address"}],"outputs":[{"name":"roundId","type":"uint80"},{"name":"answer","type":"int256"},{"name":"startedAt","type":"uint256"},{"name":"updatedAt","type":"uint256"},{"name":"answeredInRound","type":"uint80"}]}
+]`)
+
+var DefaultOptions = Options{
+	MaxStaleness: 24 * time.Hour,
+}
+
+type Options struct {
+	// MaxStaleness is the max age of a round before its price is rejected
+	// with ErrStalePrice. Zero disables the check. It should be set according
+	// to the heartbeat of the feed.
+	MaxStaleness time.Duration
+
+	// Now returns the current time for the staleness check, defaults to time.Now.
+	Now func() time.Time
+}
+
+func (o Options) now() time.Time {
+	if o.Now != nil {
+		return o.Now()
+	}
+	return time.Now()
+}
+
+// RoundData is the raw result of latestRoundData or getRoundData.
+type RoundData struct {
+	RoundID         *big.Int
+	Answer          *big.Int
+	StartedAt       time.Time
+	UpdatedAt       time.Time
+	AnsweredInRound *big.Int
+}
+
+// Price is the answer of a feed together with its decimals, so it can be
+// converted without losing precision.
+type Price struct {
+	Value     *big.Int
+	Decimals  uint8
+	RoundID   *big.Int
+	UpdatedAt time.Time
+}
+
+// Rat returns the exact price as a rational number.
+func (p *Price) Rat() *big.Rat {
+	denom := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(p.Decimals)), nil)
+	return new(big.Rat).SetFrac(p.Value, denom)
+}
+
+// Float64 returns the nearest float64 value of the price.
+func (p *Price) Float64() float64 {
+	f, _ := p.Rat().Float64()
+	return f
+}
+
+// Scale returns the price as an integer with the given decimals, rounding
+// down when reducing the number of decimals.
+func (p *Price) Scale(decimals uint8) *big.Int {
+	v := new(big.Int).Set(p.Value)
+	if decimals >= p.Decimals {
+		return v.Mul(v, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals-p.Decimals)), nil))
+	}
+	return v.Quo(v, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(p.Decimals-decimals)), nil))
+}
+
+// String returns the exact decimal representation of the price, ie. "1834.50000000".
+func (p *Price) String() string {
+	s := new(big.Int).Abs(p.Value).String()
+	if p.Decimals > 0 {
+		if len(s) <= int(p.Decimals) {
+			s = strings.Repeat("0", int(p.Decimals)-len(s)+1) + s
+		}
+		s = s[:len(s)-int(p.Decimals)] + "." + s[len(s)-int(p.Decimals):]
+	}
+	if p.Value.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// Feed reads a Chainlink AggregatorV3Interface price feed. The caller can be
+// a provider, or an ethmulticall.Scheduler to batch reads of many feeds.
+type Feed struct {
+	Address  common.Address
+	options  Options
+	caller   bind.ContractCaller
+	decimals *uint8
+	mu       sync.Mutex
+}
+
+func NewFeed(caller bind.ContractCaller, address common.Address, opts ...Options) *Feed {
+	options := DefaultOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	return &Feed{
+		Address: address,
+		options: options,
+		caller:  caller,
+	}
+}
+
+// Decimals returns the decimals of the feed answers. The value is cached
+// after the first call.
+func (f *Feed) Decimals(ctx context.Context) (uint8, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.decimals != nil {
+		return *f.decimals, nil
+	}
+	var decimals uint8
+	if err := call(ctx, f.caller, f.Address, AggregatorV3ABI, &decimals, "decimals"); err != nil {
+		return 0, err
+	}
+	f.decimals = &decimals
+	return decimals, nil
+}
+
+func (f *Feed) Description(ctx context.Context) (string, error) {
+	var description string
+	if err := call(ctx, f.caller, f.Address, AggregatorV3ABI, &description, "description"); err != nil {
+		return "", err
+	}
+	return description, nil
+}
+
+// LatestRoundData returns the latest round of the feed without validating it.
+func (f *Feed) LatestRoundData(ctx context.Context) (*RoundData, error) {
+	return callRoundData(ctx, f.caller, f.Address, AggregatorV3ABI, "latestRoundData")
+}
+
+// RoundData returns the given round of the feed without validating it.
+func (f *Feed) RoundData(ctx context.Context, roundID *big.Int) (*RoundData, error) {
+	return callRoundData(ctx, f.caller, f.Address, AggregatorV3ABI, "getRoundData", roundID)
+}
+
+// LatestPrice returns the price of the latest round, or an error if the round
+// is incomplete, stale or its answer is not positive.
+func (f *Feed) LatestPrice(ctx context.Context) (*Price, error) {
+	decimals, err := f.Decimals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	round, err := f.LatestRoundData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newPrice(round, decimals, f.options)
+}
+
+// FeedRegistry reads prices of base/quote pairs from the Chainlink Feed Registry.
+type FeedRegistry struct {
+	Address common.Address
+	options Options
+	caller  bind.ContractCaller
+}
+
+func NewFeedRegistry(caller bind.ContractCaller, address common.Address, opts ...Options) *FeedRegistry {
+	options := DefaultOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	return &FeedRegistry{
+		Address: address,
+		options: options,
+		caller:  caller,
+	}
+}
+
+func (r *FeedRegistry) Decimals(ctx context.Context, base, quote common.Address) (uint8, error) {
+	var decimals uint8
+	if err := call(ctx, r.caller, r.Address, FeedRegistryABI, &decimals, "decimals", base, quote); err != nil {
+		return 0, err
+	}
+	return decimals, nil
+}
+
+// Feed returns the aggregator of the base/quote pair as a Feed, with the
+// options of the registry.
+func (r *FeedRegistry) Feed(ctx context.Context, base, quote common.Address) (*Feed, error) {
+	var aggregator common.Address
+	if err := call(ctx, r.caller, r.Address, FeedRegistryABI, &aggregator, "getFeed", base, quote); err != nil {
+		return nil, err
+	}
+	return NewFeed(r.caller, aggregator, r.options), nil
+}
+
+func (r *FeedRegistry) LatestRoundData(ctx context.Context, base, quote common.Address) (*RoundData, error) {
+	return callRoundData(ctx, r.caller, r.Address, FeedRegistryABI, "latestRoundData", base, quote)
+}
+
+// LatestPrice returns the latest price of base denominated in quote, with the
+// same validation as Feed.LatestPrice.
+func (r *FeedRegistry) LatestPrice(ctx context.Context, base, quote common.Address) (*Price, error) {
+	decimals, err := r.Decimals(ctx, base, quote)
+	if err != nil {
+		return nil, err
+	}
+	round, err := r.LatestRoundData(ctx, base, quote)
+	if err != nil {
+		return nil, err
+	}
+	return newPrice(round, decimals, r.options)
+}
+
+func newPrice(round *RoundData, decimals uint8, options Options) (*Price, error) {
+	if round.Answer.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: answer %s in round %s", ErrInvalidPrice, round.Answer, round.RoundID)
+	}
+	if round.UpdatedAt.Unix() == 0 || round.AnsweredInRound.Cmp(round.RoundID) < 0 {
+		return nil, fmt.Errorf("%w: round %s", ErrIncompleteRound, round.RoundID)
+	}
+	if options.MaxStaleness > 0 {
+		if age := options.now().Sub(round.UpdatedAt); age > options.MaxStaleness {
+			return nil, fmt.Errorf("%w: round %s was updated %s ago", ErrStalePrice, round.RoundID, age.Truncate(time.Second))
+		}
+	}
+	return &Price{
+		Value:     round.Answer,
+		Decimals:  decimals,
+		RoundID:   round.RoundID,
+		UpdatedAt: round.UpdatedAt,
+	}, nil
+}
+
+func call(ctx context.Context, caller bind.ContractCaller, address common.Address, contractABI abi.ABI, result interface{}, method string, args ...interface{}) error {
+	data, err := contractABI.Pack(method, args...)
+	if err != nil {
+		return fmt.Errorf("ethpricefeed: failed to encode %s: %w", method, err)
+	}
+	output, err := caller.CallContract(ctx, ethereum.CallMsg{To: &address, Data: data}, nil)
+	if err != nil {
+		return err
+	}
+	if err := contractABI.UnpackIntoInterface(result, method, output); err != nil {
+		return fmt.Errorf("ethpricefeed: failed to decode %s of %s: %w", method, address, err)
+	}
+	return nil
+}
+
+func callRoundData(ctx context.Context, caller bind.ContractCaller, address common.Address, contractABI abi.ABI, method string, args ...interface{}) (*RoundData, error) {
+	var out struct {
+		RoundId         *big.Int
+		Answer          *big.Int
+		StartedAt       *big.Int
+		UpdatedAt       *big.Int
+		AnsweredInRound *big.Int
+	}
+	if err := call(ctx, caller, address, contractABI, &out, method, args...); err != nil {
+		return nil, err
+	}
+	return &RoundData{
+		RoundID:         out.RoundId,
+		Answer:          out.Answer,
+		StartedAt:       time.Unix(out.StartedAt.Int64(), 0),
+		UpdatedAt:       time.Unix(out.UpdatedAt.Int64(), 0),
+		AnsweredInRound: out.AnsweredInRound,
+	}, nil
+}
@@ -0,0 +1,102 @@
+package ethpricefeed_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethpricefeed"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockFeed answers AggregatorV3 and Feed Registry calls with a single round.
+type mockFeed struct {
+	decimals  uint8
+	answer    *big.Int
+	updatedAt time.Time
+	calls     int
+}
+
+func (m *mockFeed) CodeAt(ctx context.Context, contract common.Address, blockNum *big.Int) ([]byte, error) {
+	return []byte{0x00}, nil
+}
+
+func (m *mockFeed) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNum *big.Int) ([]byte, error) {
+	m.calls++
+	for _, contractABI := range []abi.ABI{ethpricefeed.AggregatorV3ABI, ethpricefeed.FeedRegistryABI} {
+		method, err := contractABI.MethodById(msg.Data[:4])
+		if err != nil {
+			continue
+		}
+		switch method.Name {
+		case "decimals":
+			return method.Outputs.Pack(m.decimals)
+		case "description":
+			return method.Outputs.Pack("ETH / USD")
+		case "getFeed":
+			return method.Outputs.Pack(common.HexToAddress("0xfeed"))
+		case "latestRoundData", "getRoundData":
+			round := big.NewInt(100)
+			updatedAt := big.NewInt(m.updatedAt.Unix())
+			return method.Outputs.Pack(round, m.answer, updatedAt, updatedAt, round)
+		}
+	}
+	return nil, errors.New("execution reverted")
+}
+
+func TestFeedLatestPrice(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	mock := &mockFeed{decimals: 8, answer: big.NewInt(183450000000), updatedAt: now.Add(-time.Minute)}
+
+	feed := ethpricefeed.NewFeed(mock, common.HexToAddress("0xfeed"), ethpricefeed.Options{
+		MaxStaleness: time.Hour,
+		Now:          func() time.Time { return now },
+	})
+
+	ctx := context.Background()
+	price, err := feed.LatestPrice(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "1834.50000000", price.String())
+	assert.Equal(t, 1834.5, price.Float64())
+	assert.Equal(t, big.NewInt(1834500000), price.Scale(6))
+	assert.Equal(t, "1834500000000000000000", price.Scale(18).String())
+	assert.Equal(t, big.NewInt(100), price.RoundID)
+
+	// decimals are cached
+	_, err = feed.LatestPrice(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, mock.calls)
+
+	description, err := feed.Description(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "ETH / USD", description)
+
+	mock.updatedAt = now.Add(-2 * time.Hour)
+	_, err = feed.LatestPrice(ctx)
+	assert.ErrorIs(t, err, ethpricefeed.ErrStalePrice)
+
+	mock.updatedAt = now
+	mock.answer = big.NewInt(0)
+	_, err = feed.LatestPrice(ctx)
+	assert.ErrorIs(t, err, ethpricefeed.ErrInvalidPrice)
+}
+
+func TestFeedRegistryLatestPrice(t *testing.T) {
+	mock := &mockFeed{decimals: 8, answer: big.NewInt(5), updatedAt: time.Now()}
+	registry := ethpricefeed.NewFeedRegistry(mock, ethpricefeed.FeedRegistryAddress)
+
+	ctx := context.Background()
+	price, err := registry.LatestPrice(ctx, ethpricefeed.DenominationETH, ethpricefeed.DenominationUSD)
+	require.NoError(t, err)
+	assert.Equal(t, "0.00000005", price.String())
+
+	feed, err := registry.Feed(ctx, ethpricefeed.DenominationETH, ethpricefeed.DenominationUSD)
+	require.NoError(t, err)
+	assert.Equal(t, common.HexToAddress("0xfeed"), feed.Address)
+}
@@ -0,0 +1,329 @@
+package ethproviders
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+type Strategy string
+
+const (
+	// StrategyFailover sends the requests to the first healthy endpoint, in
+	// the order of the config.
+	StrategyFailover Strategy = "failover"
+
+	// StrategyRoundRobin spreads the requests evenly over the healthy endpoints.
+	StrategyRoundRobin Strategy = "round-robin"
+
+	// StrategyLeastLatency sends the requests to the healthy endpoint with the
+	// lowest average latency.
+	StrategyLeastLatency Strategy = "least-latency"
+
+	// StrategyWeighted spreads the requests over the healthy endpoints in
+	// proportion of their weights.
+	StrategyWeighted Strategy = "weighted"
+
+	// StrategySticky sends all the requests of a session, see WithSession, to
+	// the same healthy endpoint, and spreads the sessions over the endpoints.
+	// The requests without a session are spread round-robin.
+	StrategySticky Strategy = "sticky"
+)
+
+var DefaultBalancerOptions = BalancerOptions{
+	Strategy: StrategyFailover,
+	Cooldown: 30 * time.Second,
+}
+
+type BalancerOptions struct {
+	// Strategy of the balancer, default StrategyFailover.
+	Strategy Strategy
+
+	// Cooldown is the time an endpoint is skipped after a failed request.
+	Cooldown time.Duration
+
+	// HTTPClient sends the requests, default http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// BalancedEndpoint is an endpoint of the balancer, and its state.
+type BalancedEndpoint struct {
+	URL     string        `json:"url"`
+	Weight  int           `json:"weight"`
+	Latency time.Duration `json:"latency"`
+	Healthy bool          `json:"healthy"`
+	Error   string        `json:"error,omitempty"`
+}
+
+// Balancer is an http client for ethrpc.WithHTTPClient, sending the JSON-RPC
+// requests of a provider to several endpoints of the same chain. A failed request
+// is retried on the next endpoint, and the endpoints failing requests are skipped
+// for the Cooldown.
+//
+// The stateful requests, ie. eth_newFilter and eth_getFilterChanges, are always
+// sent to the same endpoint with any strategy, per session when the context of
+// the request has one.
+type Balancer struct {
+	options   BalancerOptions
+	endpoints []*balancedEndpoint
+	next      int
+	now       func() time.Time
+	mu        sync.Mutex
+}
+
+type balancedEndpoint struct {
+	url     *url.URL
+	weight  int
+	current int // current weight of the smooth weighted round-robin
+	latency time.Duration
+	downAt  time.Time
+	err     error
+}
+
+func NewBalancer(endpoints []EndpointConfig, options ...BalancerOptions) (*Balancer, error) {
+	opts := DefaultBalancerOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	if opts.Strategy == "" {
+		opts.Strategy = StrategyFailover
+	}
+	switch opts.Strategy {
+	case StrategyFailover, StrategyRoundRobin, StrategyLeastLatency, StrategyWeighted, StrategySticky:
+	default:
+		return nil, fmt.Errorf("ethproviders: unknown load balancing strategy %q", opts.Strategy)
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("ethproviders: balancer has no endpoints")
+	}
+
+	b := &Balancer{options: opts, now: time.Now}
+	for _, endpoint := range endpoints {
+		u, err := url.ParseRequestURI(endpoint.URL)
+		if err != nil {
+			return nil, fmt.Errorf("ethproviders: invalid endpoint url %q: %w", endpoint.URL, err)
+		}
+		weight := endpoint.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		b.endpoints = append(b.endpoints, &balancedEndpoint{url: u, weight: weight})
+	}
+	return b, nil
+}
+
+type sessionKey struct{}
+
+// WithSession returns a context sending the requests of the session to the same
+// endpoint of a Balancer, for the stateful requests or with StrategySticky.
+func WithSession(ctx context.Context, session string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, session)
+}
+
+// statefulMethods are the methods whose state lives on a single node.
+var statefulMethods = map[string]bool{
+	"eth_newFilter":                   true,
+	"eth_newBlockFilter":              true,
+	"eth_newPendingTransactionFilter": true,
+	"eth_getFilterChanges":            true,
+	"eth_getFilterLogs":               true,
+	"eth_uninstallFilter":             true,
+}
+
+// Do sends the request to the endpoints in the order of the strategy, until
+// one of them responds without a server error.
+func (b *Balancer) Do(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	session, hasSession := req.Context().Value(sessionKey{}).(string)
+	sticky := (hasSession && b.options.Strategy == StrategySticky) || isStateful(body)
+
+	var lastErr error
+	endpoints := b.order(session, sticky)
+	for i, endpoint := range endpoints {
+		r := req.Clone(req.Context())
+		r.URL = endpoint.url
+		r.Host = ""
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+
+		start := time.Now()
+		res, err := b.options.HTTPClient.Do(r)
+		if err != nil {
+			b.failed(endpoint, err)
+			lastErr = err
+			continue
+		}
+		if res.StatusCode != http.StatusTooManyRequests && res.StatusCode < 500 {
+			b.succeeded(endpoint, time.Since(start))
+			return res, nil
+		}
+
+		// server error, the response of the last endpoint is returned as is
+		b.failed(endpoint, fmt.Errorf("ethproviders: endpoint responded with status code %d", res.StatusCode))
+		if i == len(endpoints)-1 {
+			return res, nil
+		}
+		res.Body.Close()
+	}
+	return nil, lastErr
+}
+
+// order returns the endpoints in the order they are tried: the healthy
+// endpoints first, in the order of the strategy, then the others.
+func (b *Balancer) order(session string, sticky bool) []*balancedEndpoint {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	var healthy, unhealthy []*balancedEndpoint
+	for _, endpoint := range b.endpoints {
+		if endpoint.err != nil && now.Sub(endpoint.downAt) < b.options.Cooldown {
+			unhealthy = append(unhealthy, endpoint)
+		} else {
+			healthy = append(healthy, endpoint)
+		}
+	}
+	sort.SliceStable(unhealthy, func(i, j int) bool {
+		return unhealthy[i].downAt.Before(unhealthy[j].downAt)
+	})
+	if len(healthy) == 0 {
+		return unhealthy
+	}
+
+	var first *balancedEndpoint
+	switch {
+	case sticky:
+		// rendezvous hashing keeps the sessions of the healthy endpoints on
+		// their endpoint when another endpoint goes down
+		var best uint64
+		for _, endpoint := range healthy {
+			h := fnv.New64a()
+			h.Write([]byte(session))
+			h.Write([]byte(endpoint.url.String()))
+			if score := h.Sum64(); first == nil || score > best {
+				first, best = endpoint, score
+			}
+		}
+
+	case b.options.Strategy == StrategyRoundRobin || b.options.Strategy == StrategySticky:
+		first = healthy[b.next%len(healthy)]
+		b.next++
+
+	case b.options.Strategy == StrategyLeastLatency:
+		// the endpoints without a measured latency are tried first
+		for _, endpoint := range healthy {
+			if first == nil || endpoint.latency < first.latency {
+				first = endpoint
+			}
+		}
+
+	case b.options.Strategy == StrategyWeighted:
+		// smooth weighted round-robin
+		total := 0
+		for _, endpoint := range healthy {
+			endpoint.current += endpoint.weight
+			total += endpoint.weight
+			if first == nil || endpoint.current > first.current {
+				first = endpoint
+			}
+		}
+		first.current -= total
+
+	default:
+		first = healthy[0]
+	}
+
+	order := make([]*balancedEndpoint, 0, len(b.endpoints))
+	order = append(order, first)
+	for _, endpoint := range healthy {
+		if endpoint != first {
+			order = append(order, endpoint)
+		}
+	}
+	return append(order, unhealthy...)
+}
+
+func (b *Balancer) succeeded(endpoint *balancedEndpoint, latency time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	endpoint.err = nil
+	if endpoint.latency == 0 {
+		endpoint.latency = latency
+	} else {
+		// exponential moving average
+		endpoint.latency = (4*endpoint.latency + latency) / 5
+	}
+}
+
+func (b *Balancer) failed(endpoint *balancedEndpoint, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	endpoint.err = err
+	endpoint.downAt = b.now()
+}
+
+// Endpoints returns the endpoints of the balancer and their state.
+func (b *Balancer) Endpoints() []BalancedEndpoint {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	endpoints := make([]BalancedEndpoint, 0, len(b.endpoints))
+	for _, endpoint := range b.endpoints {
+		e := BalancedEndpoint{
+			URL:     endpoint.url.String(),
+			Weight:  endpoint.weight,
+			Latency: endpoint.latency,
+			Healthy: endpoint.err == nil || now.Sub(endpoint.downAt) >= b.options.Cooldown,
+		}
+		if endpoint.err != nil {
+			e.Error = endpoint.err.Error()
+		}
+		endpoints = append(endpoints, e)
+	}
+	return endpoints
+}
+
+// isStateful returns true when the JSON-RPC request body has a stateful method.
+func isStateful(body []byte) bool {
+	var requests []struct {
+		Method string `json:"method"`
+	}
+	if err := json.Unmarshal(body, &requests); err != nil {
+		var request struct {
+			Method string `json:"method"`
+		}
+		if json.Unmarshal(body, &request) != nil {
+			return false
+		}
+		requests = append(requests, request)
+	}
+	for _, request := range requests {
+		if statefulMethods[request.Method] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,187 @@
+package ethproviders
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testEndpoint struct {
+	server *httptest.Server
+	hits   atomic.Int32
+	fail   atomic.Bool
+}
+
+func newTestEndpoints(t *testing.T, n int) []*testEndpoint {
+	endpoints := make([]*testEndpoint, n)
+	for i := range endpoints {
+		e := &testEndpoint{}
+		e.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			e.hits.Add(1)
+			if e.fail.Load() {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			var request struct {
+				ID uint64 `json:"id"`
+			}
+			json.NewDecoder(r.Body).Decode(&request)
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x539"}`, request.ID)
+		}))
+		t.Cleanup(e.server.Close)
+		endpoints[i] = e
+	}
+	return endpoints
+}
+
+func newTestBalancer(t *testing.T, endpoints []*testEndpoint, strategy Strategy, weights ...int) (*Balancer, *ethrpc.Provider) {
+	configs := make([]EndpointConfig, len(endpoints))
+	for i, e := range endpoints {
+		configs[i] = EndpointConfig{URL: e.server.URL}
+		if i < len(weights) {
+			configs[i].Weight = weights[i]
+		}
+	}
+	b, err := NewBalancer(configs, BalancerOptions{Strategy: strategy, Cooldown: time.Minute})
+	require.NoError(t, err)
+	provider, err := ethrpc.NewProvider(configs[0].URL, ethrpc.WithHTTPClient(b))
+	require.NoError(t, err)
+	return b, provider
+}
+
+func hits(endpoints []*testEndpoint) []int32 {
+	h := make([]int32, len(endpoints))
+	for i, e := range endpoints {
+		h[i] = e.hits.Swap(0)
+	}
+	return h
+}
+
+func TestBalancerStrategies(t *testing.T) {
+	ctx := context.Background()
+	endpoints := newTestEndpoints(t, 3)
+
+	call := func(provider *ethrpc.Provider, n int) {
+		for i := 0; i < n; i++ {
+			num, err := provider.BlockNumber(ctx)
+			require.NoError(t, err)
+			require.Equal(t, uint64(1337), num)
+		}
+	}
+
+	_, provider := newTestBalancer(t, endpoints, StrategyFailover)
+	call(provider, 6)
+	assert.Equal(t, []int32{6, 0, 0}, hits(endpoints))
+
+	_, provider = newTestBalancer(t, endpoints, StrategyRoundRobin)
+	call(provider, 6)
+	assert.Equal(t, []int32{2, 2, 2}, hits(endpoints))
+
+	_, provider = newTestBalancer(t, endpoints, StrategyWeighted, 3, 2, 1)
+	call(provider, 12)
+	assert.Equal(t, []int32{6, 4, 2}, hits(endpoints))
+
+	b, provider := newTestBalancer(t, endpoints, StrategyLeastLatency)
+	b.endpoints[0].latency = time.Second
+	b.endpoints[1].latency = time.Millisecond
+	b.endpoints[2].latency = time.Minute
+	call(provider, 3)
+	assert.Equal(t, []int32{0, 3, 0}, hits(endpoints))
+
+	// the sessions stick to an endpoint, the others are round-robin
+	_, provider = newTestBalancer(t, endpoints, StrategySticky)
+	sessionCtx := WithSession(ctx, "user-1")
+	for i := 0; i < 6; i++ {
+		_, err := provider.BlockNumber(sessionCtx)
+		require.NoError(t, err)
+	}
+	h := hits(endpoints)
+	assert.ElementsMatch(t, []int32{0, 0, 6}, h)
+	call(provider, 3)
+	assert.Equal(t, []int32{1, 1, 1}, hits(endpoints))
+}
+
+func TestBalancerFailover(t *testing.T) {
+	ctx := context.Background()
+	endpoints := newTestEndpoints(t, 2)
+	b, provider := newTestBalancer(t, endpoints, StrategyRoundRobin)
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	endpoints[0].fail.Store(true)
+	for i := 0; i < 4; i++ {
+		_, err := provider.BlockNumber(ctx)
+		require.NoError(t, err)
+	}
+	// the failed endpoint is skipped during the cooldown
+	assert.Equal(t, []int32{1, 4}, hits(endpoints))
+
+	status := b.Endpoints()
+	assert.False(t, status[0].Healthy)
+	assert.Contains(t, status[0].Error, "502")
+	assert.True(t, status[1].Healthy)
+
+	// and tried again after the cooldown
+	endpoints[0].fail.Store(false)
+	now = now.Add(2 * time.Minute)
+	for i := 0; i < 4; i++ {
+		_, err := provider.BlockNumber(ctx)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []int32{2, 2}, hits(endpoints))
+	assert.True(t, b.Endpoints()[0].Healthy)
+
+	// all the endpoints failing
+	endpoints[0].fail.Store(true)
+	endpoints[1].fail.Store(true)
+	_, err := provider.BlockNumber(ctx)
+	assert.ErrorContains(t, err, "502")
+}
+
+func TestBalancerStatefulMethods(t *testing.T) {
+	assert.True(t, isStateful([]byte(`[{"jsonrpc":"2.0","id":1,"method":"eth_getFilterChanges","params":["0x1"]}]`)))
+	assert.True(t, isStateful([]byte(`{"jsonrpc":"2.0","id":1,"method":"eth_newFilter","params":[]}`)))
+	assert.False(t, isStateful([]byte(`[{"jsonrpc":"2.0","id":1,"method":"eth_chainId"}]`)))
+
+	endpoints := newTestEndpoints(t, 3)
+	b, _ := newTestBalancer(t, endpoints, StrategyRoundRobin)
+	first := b.order("", true)[0]
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, first, b.order("", true)[0])
+	}
+}
+
+func TestNewProvidersEndpoints(t *testing.T) {
+	endpoints := newTestEndpoints(t, 2)
+	ps, err := NewProviders(Config{
+		"devnet": {
+			ID:            1337,
+			URL:           endpoints[0].server.URL,
+			Endpoints:     []EndpointConfig{{URL: endpoints[1].server.URL, Weight: 2}},
+			LoadBalancing: StrategyRoundRobin,
+		},
+		"single": {ID: 1, URL: endpoints[0].server.URL},
+	})
+	require.NoError(t, err)
+	assert.Nil(t, ps.GetBalancer(1))
+	require.NotNil(t, ps.GetBalancer(1337))
+	assert.Len(t, ps.GetBalancer(1337).Endpoints(), 2)
+
+	for i := 0; i < 4; i++ {
+		_, err := ps.Get("devnet").BlockNumber(context.Background())
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []int32{2, 2}, hits(endpoints))
+
+	_, err = NewProviders(Config{"devnet": {ID: 1337, URL: endpoints[0].server.URL, Endpoints: []EndpointConfig{{URL: endpoints[1].server.URL}}, LoadBalancing: "random"}})
+	assert.ErrorContains(t, err, "unknown load balancing strategy")
+}
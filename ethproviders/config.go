@@ -8,6 +8,13 @@ type NetworkConfig struct {
 	ID  uint64 `toml:"id" json:"id"`
 	URL string `toml:"url" json:"url"`
 
+	// Endpoints are more urls of the network, balanced with URL by LoadBalancing.
+	Endpoints []EndpointConfig `toml:"endpoints" json:"endpoints"`
+
+	// LoadBalancing is the Strategy of the requests to the endpoints, default
+	// "failover".
+	LoadBalancing Strategy `toml:"load_balancing" json:"loadBalancing"`
+
 	WSEnabled bool   `toml:"ws_enabled" json:"wsEnabled"`
 	WSURL     string `toml:"ws_url" json:"wsUrl"`
 
@@ -16,6 +23,22 @@ type NetworkConfig struct {
 	Disabled  bool `toml:"disabled" json:"disabled"`
 }
 
+type EndpointConfig struct {
+	URL string `toml:"url" json:"url"`
+
+	// Weight of the endpoint with the "weighted" LoadBalancing, default 1.
+	Weight int `toml:"weight" json:"weight"`
+}
+
+// AllEndpoints returns the URL endpoint followed by the Endpoints.
+func (n NetworkConfig) AllEndpoints() []EndpointConfig {
+	var endpoints []EndpointConfig
+	if n.URL != "" {
+		endpoints = append(endpoints, EndpointConfig{URL: n.URL, Weight: 1})
+	}
+	return append(endpoints, n.Endpoints...)
+}
+
 func (n Config) GetByID(id uint64) (NetworkConfig, bool) {
 	for _, v := range n {
 		if v.ID == id {
@@ -11,6 +11,8 @@ type Providers struct {
 	byID          map[uint64]*ethrpc.Provider
 	byName        map[string]*ethrpc.Provider
 	configByID    map[uint64]NetworkConfig
+	balancers     map[uint64]*Balancer
+	endpoints     map[uint64][]Endpoint
 	authChain     *ethrpc.Provider
 	testAuthChain *ethrpc.Provider
 	chainList     []ChainInfo
@@ -26,6 +28,8 @@ func NewProviders(cfg Config, optJwtToken ...string) (*Providers, error) {
 		byID:       map[uint64]*ethrpc.Provider{},
 		byName:     map[string]*ethrpc.Provider{},
 		configByID: map[uint64]NetworkConfig{},
+		balancers:  map[uint64]*Balancer{},
+		endpoints:  map[uint64][]Endpoint{},
 	}
 
 	var providerJwtAuth ethrpc.Option
@@ -38,12 +42,46 @@ func NewProviders(cfg Config, optJwtToken ...string) (*Providers, error) {
 			continue
 		}
 
-		p, err := ethrpc.NewProvider(details.URL, providerJwtAuth)
+		endpoints := details.AllEndpoints()
+		if len(endpoints) == 0 {
+			return nil, fmt.Errorf("ethproviders: network %s has no url", name)
+		}
+		var providerBalancer ethrpc.Option
+		if len(endpoints) > 1 {
+			balancer, err := NewBalancer(endpoints, BalancerOptions{
+				Strategy: details.LoadBalancing,
+				Cooldown: DefaultBalancerOptions.Cooldown,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("ethproviders: network %s: %w", name, err)
+			}
+			providers.balancers[details.ID] = balancer
+			providerBalancer = ethrpc.WithHTTPClient(balancer)
+
+			// the health of each endpoint is monitored on its own provider, as
+			// the balancer hides the endpoints behind the provider of the chain
+			for i, endpoint := range endpoints {
+				p, err := ethrpc.NewProvider(endpoint.URL, providerJwtAuth)
+				if err != nil {
+					return nil, err
+				}
+				providers.endpoints[details.ID] = append(providers.endpoints[details.ID], Endpoint{
+					Name:     fmt.Sprintf("%s#%d", name, i),
+					ChainID:  details.ID,
+					Provider: p,
+				})
+			}
+		}
+
+		p, err := ethrpc.NewProvider(endpoints[0].URL, providerJwtAuth, providerBalancer)
 		if err != nil {
 			return nil, err
 		}
 		providers.byID[details.ID] = p
 		providers.byName[name] = p
+		if len(endpoints) == 1 {
+			providers.endpoints[details.ID] = []Endpoint{{Name: name, ChainID: details.ID, Provider: p}}
+		}
 		providers.configByID[details.ID] = details
 
 		if (details.AuthChain && !details.Testnet && providers.authChain != nil) || (details.AuthChain && details.Testnet && providers.testAuthChain != nil) {
@@ -98,6 +136,12 @@ func (p *Providers) GetByChainName(chainName string) *ethrpc.Provider {
 	return p.byName[chainName]
 }
 
+// GetBalancer returns the balancer of the endpoints of a chain, or nil when the
+// chain has a single endpoint.
+func (p *Providers) GetBalancer(chainID uint64) *Balancer {
+	return p.balancers[chainID]
+}
+
 func (p *Providers) GetAuthChain() *ethrpc.Provider {
 	return p.authChain
 }
@@ -0,0 +1,303 @@
+package ethproviders
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/util"
+)
+
+var DefaultHealthOptions = HealthOptions{
+	CheckInterval:      15 * time.Second,
+	Timeout:            10 * time.Second,
+	StallTimeout:       2 * time.Minute,
+	MaxBlockDivergence: 10,
+	Alerter:            util.NoopAlerter(),
+}
+
+type HealthOptions struct {
+	// CheckInterval is the time between the checks of the endpoints.
+	CheckInterval time.Duration
+
+	// Timeout of the requests of a check.
+	Timeout time.Duration
+
+	// StallTimeout is the max time without a new block before an endpoint is
+	// considered stalled.
+	StallTimeout time.Duration
+
+	// MaxBlockDivergence is the max number of blocks the head of an endpoint
+	// may be behind the head of another endpoint of the same chain.
+	MaxBlockDivergence uint64
+
+	// Alerter is notified of the alerts, optional.
+	Alerter util.Alerter
+
+	// OnAlert is called with the alerts, optional.
+	OnAlert func(Alert)
+}
+
+// Endpoint is an rpc endpoint of a chain monitored by the HealthMonitor.
+type Endpoint struct {
+	Name     string
+	ChainID  uint64
+	Provider ethrpc.Interface
+}
+
+// Endpoints returns the endpoints of the providers, one for each url of the
+// chains. The endpoints of a chain with several urls are named by the chain
+// name and the index of the url, ie. "mainnet#0" and "mainnet#1".
+func (p *Providers) Endpoints() []Endpoint {
+	endpoints := make([]Endpoint, 0, len(p.chainList))
+	for _, chain := range p.chainList {
+		endpoints = append(endpoints, p.endpoints[chain.ID]...)
+	}
+	return endpoints
+}
+
+type AlertKind string
+
+const (
+	// AlertUnreachable is raised when the endpoint fails to return its head.
+	AlertUnreachable AlertKind = "unreachable"
+
+	// AlertStalled is raised when the endpoint has no new block in StallTimeout.
+	AlertStalled AlertKind = "stalled"
+
+	// AlertDiverged is raised when the head of the endpoint is more than
+	// MaxBlockDivergence blocks behind the head of another endpoint of the chain.
+	AlertDiverged AlertKind = "diverged"
+
+	// AlertRecovered is raised when an endpoint with an alert is healthy again.
+	AlertRecovered AlertKind = "recovered"
+)
+
+// Alert is a change of the health of an endpoint.
+type Alert struct {
+	Kind     AlertKind `json:"kind"`
+	ChainID  uint64    `json:"chainId"`
+	Endpoint string    `json:"endpoint"`
+	Head     uint64    `json:"head"`
+	Time     time.Time `json:"time"`
+	Message  string    `json:"message"`
+
+	// Reference is the endpoint with the highest head of the chain, for
+	// AlertDiverged.
+	Reference     string `json:"reference,omitempty"`
+	ReferenceHead uint64 `json:"referenceHead,omitempty"`
+}
+
+func (a Alert) String() string {
+	return fmt.Sprintf("ethproviders: endpoint %s (chain %d) %s: %s", a.Endpoint, a.ChainID, a.Kind, a.Message)
+}
+
+// EndpointStatus is the health of an endpoint as of its last check.
+type EndpointStatus struct {
+	Name      string        `json:"name"`
+	ChainID   uint64        `json:"chainId"`
+	Head      uint64        `json:"head"`
+	HeadSince time.Time     `json:"headSince"`
+	Latency   time.Duration `json:"latency"`
+	CheckedAt time.Time     `json:"checkedAt"`
+	Healthy   bool          `json:"healthy"`
+	Alert     AlertKind     `json:"alert,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// HealthMonitor checks the endpoints of chains for stalled heads and for
+// heads diverging from the other endpoints of their chain, and raises alerts
+// on the changes of their health.
+type HealthMonitor struct {
+	options   HealthOptions
+	endpoints []Endpoint
+	status    []EndpointStatus
+	now       func() time.Time
+	mu        sync.RWMutex
+}
+
+func NewHealthMonitor(endpoints []Endpoint, options ...HealthOptions) (*HealthMonitor, error) {
+	opts := DefaultHealthOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	if opts.Alerter == nil {
+		opts.Alerter = util.NoopAlerter()
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultHealthOptions.Timeout
+	}
+	if opts.CheckInterval <= 0 || opts.StallTimeout <= 0 {
+		return nil, fmt.Errorf("ethproviders: health CheckInterval and StallTimeout must be positive")
+	}
+
+	status := make([]EndpointStatus, len(endpoints))
+	for i, endpoint := range endpoints {
+		if endpoint.Provider == nil {
+			return nil, fmt.Errorf("ethproviders: endpoint %s has no provider", endpoint.Name)
+		}
+		status[i] = EndpointStatus{Name: endpoint.Name, ChainID: endpoint.ChainID}
+	}
+
+	return &HealthMonitor{
+		options:   opts,
+		endpoints: endpoints,
+		status:    status,
+		now:       time.Now,
+	}, nil
+}
+
+// Run checks the endpoints every CheckInterval until ctx is done.
+func (h *HealthMonitor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(h.options.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		h.Check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check checks the endpoints once, and returns the raised alerts.
+func (h *HealthMonitor) Check(ctx context.Context) []Alert {
+	type result struct {
+		head    uint64
+		latency time.Duration
+		err     error
+	}
+	results := make([]result, len(h.endpoints))
+
+	var wg sync.WaitGroup
+	for i, endpoint := range h.endpoints {
+		wg.Add(1)
+		go func(i int, endpoint Endpoint) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, h.options.Timeout)
+			defer cancel()
+
+			start := time.Now()
+			head, err := endpoint.Provider.BlockNumber(ctx)
+			results[i] = result{head: head, latency: time.Since(start), err: err}
+		}(i, endpoint)
+	}
+	wg.Wait()
+
+	h.mu.Lock()
+	now := h.now()
+
+	// highest head of each chain
+	highest := map[uint64]int{}
+	for i, r := range results {
+		if r.err != nil {
+			continue
+		}
+		if j, ok := highest[h.endpoints[i].ChainID]; !ok || r.head > results[j].head {
+			highest[h.endpoints[i].ChainID] = i
+		}
+	}
+
+	var alerts []Alert
+	for i, r := range results {
+		status := &h.status[i]
+		status.CheckedAt = now
+		status.Latency = r.latency
+		status.Error = ""
+
+		alert := Alert{ChainID: status.ChainID, Endpoint: status.Name, Time: now}
+		switch {
+		case r.err != nil:
+			alert.Head = status.Head
+			alert.Kind = AlertUnreachable
+			alert.Message = r.err.Error()
+
+		default:
+			if r.head != status.Head || status.HeadSince.IsZero() {
+				status.Head = r.head
+				status.HeadSince = now
+			}
+			alert.Head = status.Head
+
+			ref := highest[status.ChainID]
+			if lag := results[ref].head - r.head; lag > h.options.MaxBlockDivergence {
+				alert.Kind = AlertDiverged
+				alert.Reference = h.endpoints[ref].Name
+				alert.ReferenceHead = results[ref].head
+				alert.Message = fmt.Sprintf("head %d is %d blocks behind %s", r.head, lag, alert.Reference)
+			} else if stalled := now.Sub(status.HeadSince); stalled > h.options.StallTimeout {
+				alert.Kind = AlertStalled
+				alert.Message = fmt.Sprintf("no new block in %s, head %d", stalled.Round(time.Second), r.head)
+			}
+		}
+
+		if alert.Kind != "" {
+			status.Error = alert.Message
+		}
+		status.Healthy = alert.Kind == ""
+
+		// raise the alerts on changes only
+		if alert.Kind == status.Alert {
+			continue
+		}
+		if alert.Kind == "" {
+			alert.Kind = AlertRecovered
+			alert.Message = fmt.Sprintf("healthy again after %s, head %d", status.Alert, alert.Head)
+			status.Alert = ""
+		} else {
+			status.Alert = alert.Kind
+		}
+		alerts = append(alerts, alert)
+	}
+	h.mu.Unlock()
+
+	for _, alert := range alerts {
+		h.options.Alerter.Alert(ctx, "%s", alert)
+		if h.options.OnAlert != nil {
+			h.options.OnAlert(alert)
+		}
+	}
+	return alerts
+}
+
+// Status returns the health of the endpoints as of their last check.
+func (h *HealthMonitor) Status() []EndpointStatus {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	status := make([]EndpointStatus, len(h.status))
+	copy(status, h.status)
+	sort.SliceStable(status, func(i, j int) bool {
+		return status[i].ChainID < status[j].ChainID
+	})
+	return status
+}
+
+// Healthy returns true when all the checked endpoints are healthy.
+func (h *HealthMonitor) Healthy() bool {
+	for _, status := range h.Status() {
+		if !status.CheckedAt.IsZero() && !status.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// ServeHTTP serves the status of the endpoints as JSON, with a 503 status code
+// when an endpoint is unhealthy.
+func (h *HealthMonitor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	status, healthy := h.Status(), h.Healthy()
+	w.Header().Set("Content-Type", "application/json")
+	if !healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(map[string]any{"healthy": healthy, "endpoints": status})
+}
@@ -0,0 +1,143 @@
+package ethproviders
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethdevnode"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthMonitor(t *testing.T) {
+	var providers []*ethrpc.Provider
+	var nodes []*ethdevnode.Node
+	for i := 0; i < 2; i++ {
+		node, err := ethdevnode.NewNode(context.Background())
+		require.NoError(t, err)
+		server := httptest.NewServer(node)
+		defer server.Close()
+		provider, err := ethrpc.NewProvider(server.URL)
+		require.NoError(t, err)
+		nodes = append(nodes, node)
+		providers = append(providers, provider)
+	}
+	down, err := ethrpc.NewProvider("http://127.0.0.1:1")
+	require.NoError(t, err)
+
+	var received []Alert
+	options := DefaultHealthOptions
+	options.StallTimeout = time.Minute
+	options.MaxBlockDivergence = 2
+	options.OnAlert = func(alert Alert) { received = append(received, alert) }
+
+	h, err := NewHealthMonitor([]Endpoint{
+		{Name: "a", ChainID: 1337, Provider: providers[0]},
+		{Name: "b", ChainID: 1337, Provider: providers[1]},
+		{Name: "down", ChainID: 1, Provider: down},
+	}, options)
+	require.NoError(t, err)
+	now := time.Now()
+	h.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	alerts := h.Check(ctx)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, AlertUnreachable, alerts[0].Kind)
+	assert.Equal(t, "down", alerts[0].Endpoint)
+
+	// alerts are raised on changes only
+	assert.Empty(t, h.Check(ctx))
+
+	// b is 3 blocks behind a
+	for i := 0; i < 3; i++ {
+		nodes[0].Mine()
+	}
+	alerts = h.Check(ctx)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, AlertDiverged, alerts[0].Kind)
+	assert.Equal(t, "b", alerts[0].Endpoint)
+	assert.Equal(t, "a", alerts[0].Reference)
+	assert.Equal(t, uint64(3), alerts[0].ReferenceHead)
+
+	for i := 0; i < 3; i++ {
+		nodes[1].Mine()
+	}
+	alerts = h.Check(ctx)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, AlertRecovered, alerts[0].Kind)
+	assert.Equal(t, "b", alerts[0].Endpoint)
+
+	// a keeps mining while b stalls
+	now = now.Add(2 * time.Minute)
+	nodes[0].Mine()
+	alerts = h.Check(ctx)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, AlertStalled, alerts[0].Kind)
+	assert.Equal(t, "b", alerts[0].Endpoint)
+	assert.Len(t, received, 4)
+
+	status := h.Status()
+	require.Len(t, status, 3)
+	assert.Equal(t, "down", status[0].Name)
+	assert.False(t, status[0].Healthy)
+	assert.Equal(t, uint64(4), status[1].Head)
+	assert.True(t, status[1].Healthy)
+	assert.Equal(t, AlertStalled, status[2].Alert)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	var body struct {
+		Healthy   bool             `json:"healthy"`
+		Endpoints []EndpointStatus `json:"endpoints"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.False(t, body.Healthy)
+	assert.Len(t, body.Endpoints, 3)
+}
+
+func TestHealthMonitorProviders(t *testing.T) {
+	var urls []string
+	var nodes []*ethdevnode.Node
+	for i := 0; i < 2; i++ {
+		node, err := ethdevnode.NewNode(context.Background())
+		require.NoError(t, err)
+		server := httptest.NewServer(node)
+		defer server.Close()
+		nodes = append(nodes, node)
+		urls = append(urls, server.URL)
+	}
+
+	ps, err := NewProviders(Config{
+		"devnet": {ID: 1337, URL: urls[0], Endpoints: []EndpointConfig{{URL: urls[1]}}},
+	})
+	require.NoError(t, err)
+	endpoints := ps.Endpoints()
+	require.Len(t, endpoints, 2)
+	assert.Equal(t, "devnet#0", endpoints[0].Name)
+	assert.Equal(t, "devnet#1", endpoints[1].Name)
+
+	options := DefaultHealthOptions
+	options.MaxBlockDivergence = 2
+	h, err := NewHealthMonitor(endpoints, options)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	assert.Empty(t, h.Check(ctx))
+
+	// the second url is 3 blocks behind the first
+	for i := 0; i < 3; i++ {
+		nodes[0].Mine()
+	}
+	alerts := h.Check(ctx)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, AlertDiverged, alerts[0].Kind)
+	assert.Equal(t, "devnet#1", alerts[0].Endpoint)
+	assert.Equal(t, "devnet#0", alerts[0].Reference)
+}
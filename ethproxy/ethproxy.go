@@ -0,0 +1,111 @@
+package ethproxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethcontract"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+var (
+	// ImplementationSlot is the EIP-1967 storage slot of the implementation of a
+	// proxy, ie. bytes32(uint256(keccak256("eip1967.proxy.implementation")) - 1)
+	ImplementationSlot = EIP1967Slot("eip1967.proxy.implementation")
+
+	// AdminSlot is the EIP-1967 storage slot of the admin of a proxy
+	AdminSlot = EIP1967Slot("eip1967.proxy.admin")
+
+	// BeaconSlot is the EIP-1967 storage slot of the beacon of a beacon proxy,
+	// of which the implementation is the one of the beacon
+	BeaconSlot = EIP1967Slot("eip1967.proxy.beacon")
+
+	// ProxiableSlot is the EIP-1822 (UUPS) storage slot of the implementation of
+	// a proxy, ie. keccak256("PROXIABLE")
+	ProxiableSlot = crypto.Keccak256Hash([]byte("PROXIABLE"))
+)
+
+// ErrNotProxy is returned when none of the proxy slots of a contract is set.
+var ErrNotProxy = errors.New("ethproxy: not a proxy")
+
+// EIP1967Slot returns the EIP-1967 storage slot of the name, ie.
+// keccak256(name) - 1.
+func EIP1967Slot(name string) common.Hash {
+	slot := new(big.Int).SetBytes(crypto.Keccak256([]byte(name)))
+	return common.BigToHash(slot.Sub(slot, big.NewInt(1)))
+}
+
+// Implementation returns the implementation of the proxy at the block of the
+// options, or the latest block when opts is nil. It reads the EIP-1967
+// implementation slot, then the implementation of the EIP-1967 beacon, then the
+// EIP-1822 slot, and returns ErrNotProxy when none is set.
+func Implementation(ctx context.Context, provider ethrpc.Interface, proxy common.Address, opts *ethcontract.CallOpts) (common.Address, error) {
+	implementation, err := SlotAddress(ctx, provider, proxy, ImplementationSlot, opts)
+	if err != nil || implementation != (common.Address{}) {
+		return implementation, err
+	}
+
+	beacon, err := Beacon(ctx, provider, proxy, opts)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if beacon != (common.Address{}) {
+		return BeaconImplementation(ctx, provider, beacon, opts)
+	}
+
+	implementation, err = SlotAddress(ctx, provider, proxy, ProxiableSlot, opts)
+	if err != nil || implementation != (common.Address{}) {
+		return implementation, err
+	}
+	return common.Address{}, fmt.Errorf("%w: %s", ErrNotProxy, proxy.Hex())
+}
+
+// Admin returns the EIP-1967 admin of the proxy, or the zero address if it's
+// not set.
+func Admin(ctx context.Context, provider ethrpc.Interface, proxy common.Address, opts *ethcontract.CallOpts) (common.Address, error) {
+	return SlotAddress(ctx, provider, proxy, AdminSlot, opts)
+}
+
+// Beacon returns the EIP-1967 beacon of the proxy, or the zero address if it's
+// not a beacon proxy.
+func Beacon(ctx context.Context, provider ethrpc.Interface, proxy common.Address, opts *ethcontract.CallOpts) (common.Address, error) {
+	return SlotAddress(ctx, provider, proxy, BeaconSlot, opts)
+}
+
+// BeaconImplementation returns the implementation of a beacon, ie. of its
+// implementation() method.
+func BeaconImplementation(ctx context.Context, provider ethrpc.Interface, beacon common.Address, opts *ethcontract.CallOpts) (common.Address, error) {
+	// implementation()
+	data, err := ethcontract.Call(ctx, provider, beacon, common.FromHex("0x5c60da1b"), opts)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("ethproxy: failed to call implementation of beacon %s: %w", beacon.Hex(), err)
+	}
+	if len(data) != 32 {
+		return common.Address{}, fmt.Errorf("ethproxy: invalid implementation of beacon %s: %x", beacon.Hex(), data)
+	}
+	return common.BytesToAddress(data), nil
+}
+
+// SlotAddress returns the address stored at the slot of the account, ie. at a
+// proxy slot, at the BlockHash or BlockNum of the options.
+func SlotAddress(ctx context.Context, provider ethrpc.Interface, account common.Address, slot common.Hash, opts *ethcontract.CallOpts) (common.Address, error) {
+	var data []byte
+	var err error
+	if opts != nil && opts.BlockHash != (common.Hash{}) {
+		_, err = provider.Do(ctx, ethrpc.StorageAtHash(account, slot, opts.BlockHash).Into(&data))
+	} else {
+		var blockNum *big.Int
+		if opts != nil {
+			blockNum = opts.BlockNum
+		}
+		data, err = provider.StorageAt(ctx, account, slot, blockNum)
+	}
+	if err != nil {
+		return common.Address{}, fmt.Errorf("ethproxy: failed to read slot %s of %s: %w", slot.Hex(), account.Hex(), err)
+	}
+	return common.BytesToAddress(data), nil
+}
@@ -0,0 +1,91 @@
+package ethproxy_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcontract"
+	"github.com/0xsequence/ethkit/ethproxy"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockProvider struct {
+	ethrpc.Interface
+	storage map[common.Address]map[common.Hash]common.Hash
+	calls   map[common.Address][]byte
+
+	blockNums []*big.Int
+}
+
+func (p *mockProvider) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNum *big.Int) ([]byte, error) {
+	p.blockNums = append(p.blockNums, blockNum)
+	value := p.storage[account][key]
+	return value.Bytes(), nil
+}
+
+func (p *mockProvider) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNum *big.Int) ([]byte, error) {
+	p.blockNums = append(p.blockNums, blockNum)
+	return p.calls[*msg.To], nil
+}
+
+func TestSlots(t *testing.T) {
+	assert.Equal(t, common.HexToHash("0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc"), ethproxy.ImplementationSlot)
+	assert.Equal(t, common.HexToHash("0xb53127684a568b3173ae13b9f8a6016e243e63b6e8ee1178d6a717850b5d6103"), ethproxy.AdminSlot)
+	assert.Equal(t, common.HexToHash("0xa3f0ad74e5423aebfd80d3ef4346578335a9a72aeaee59ff6cb3582b35133d50"), ethproxy.BeaconSlot)
+	assert.Equal(t, common.HexToHash("0xc5f16f0fcc639fa48a6947836d9850f504798523bf8c9a3a87d5876cf622bcf7"), ethproxy.ProxiableSlot)
+}
+
+func TestImplementation(t *testing.T) {
+	var (
+		transparent    = common.HexToAddress("0x01")
+		beaconProxy    = common.HexToAddress("0x02")
+		uups           = common.HexToAddress("0x03")
+		contract       = common.HexToAddress("0x04")
+		beacon         = common.HexToAddress("0xbeac0")
+		admin          = common.HexToAddress("0xad")
+		implementation = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	)
+	provider := &mockProvider{
+		storage: map[common.Address]map[common.Hash]common.Hash{
+			transparent: {
+				ethproxy.ImplementationSlot: common.BytesToHash(implementation.Bytes()),
+				ethproxy.AdminSlot:          common.BytesToHash(admin.Bytes()),
+			},
+			beaconProxy: {ethproxy.BeaconSlot: common.BytesToHash(beacon.Bytes())},
+			uups:        {ethproxy.ProxiableSlot: common.BytesToHash(implementation.Bytes())},
+		},
+		calls: map[common.Address][]byte{
+			beacon: common.BytesToHash(implementation.Bytes()).Bytes(),
+		},
+	}
+	ctx := context.Background()
+
+	for _, proxy := range []common.Address{transparent, beaconProxy, uups} {
+		address, err := ethproxy.Implementation(ctx, provider, proxy, nil)
+		require.NoError(t, err)
+		assert.Equal(t, implementation, address)
+	}
+
+	address, err := ethproxy.Admin(ctx, provider, transparent, nil)
+	require.NoError(t, err)
+	assert.Equal(t, admin, address)
+
+	address, err = ethproxy.Beacon(ctx, provider, beaconProxy, nil)
+	require.NoError(t, err)
+	assert.Equal(t, beacon, address)
+
+	_, err = ethproxy.Implementation(ctx, provider, contract, nil)
+	assert.ErrorIs(t, err, ethproxy.ErrNotProxy)
+
+	// the slots and the beacon are read at the block of the options
+	provider.blockNums = nil
+	address, err = ethproxy.Implementation(ctx, provider, beaconProxy, &ethcontract.CallOpts{BlockNum: big.NewInt(100)})
+	require.NoError(t, err)
+	assert.Equal(t, implementation, address)
+	assert.Equal(t, []*big.Int{big.NewInt(100), big.NewInt(100), big.NewInt(100)}, provider.blockNums)
+}
@@ -115,6 +115,9 @@ func NewReceiptsListener(log logger.Logger, provider ethrpc.Interface, monitor *
 	if !monitor.Options().WithLogs {
 		return nil, fmt.Errorf("ethreceipts: ReceiptsListener needs a monitor with WithLogs enabled to function")
 	}
+	if monitor.Options().LogsOnly {
+		return nil, fmt.Errorf("ethreceipts: ReceiptsListener needs the transactions of the blocks, LogsOnly monitor is not supported")
+	}
 
 	minBlockRetentionLimit := 50
 	if monitor.Options().BlockRetentionLimit < minBlockRetentionLimit {
@@ -422,7 +425,7 @@ func (l *ReceiptsListener) fetchTransactionReceipt(ctx context.Context, txnHash
 
 		// Fetch the transaction receipt from the node, and use the breaker in case of node failures.
 		err := l.br.Do(ctx, func() error {
-			tctx, clearTimeout := context.WithTimeout(ctx, 4*time.Second)
+			tctx, clearTimeout := context.WithTimeout(ethrpc.WithPriority(ctx, ethrpc.PriorityHigh), 4*time.Second)
 			defer clearTimeout()
 
 			receipt, err := l.provider.TransactionReceipt(tctx, txnHash)
@@ -467,6 +470,38 @@ func (l *ReceiptsListener) fetchTransactionReceipt(ctx context.Context, txnHash
 	}
 }
 
+// fetchBlockTransactionReceipt returns the receipt of a transaction found by the monitor in the block blockHash.
+// The receipts of the block are fetched once and shared with the monitor, so that the filters matching many
+// transactions of the same blocks don't refetch receipts. It falls back to fetchTransactionReceipt when the
+// block receipts are disabled or unavailable.
+func (l *ReceiptsListener) fetchBlockTransactionReceipt(ctx context.Context, blockHash, txnHash common.Hash) (*types.Receipt, error) {
+	if blockHash == (common.Hash{}) || l.monitor.Options().BlockReceiptsCacheNumBlocks <= 0 {
+		return l.fetchTransactionReceipt(ctx, txnHash, true)
+	}
+
+	txnHashHex := txnHash.String()
+	receipt, ok, _ := l.pastReceipts.Get(ctx, txnHashHex)
+	if ok {
+		return receipt, nil
+	}
+
+	receipts, err := l.monitor.BlockReceipts(ethrpc.WithPriority(ctx, ethrpc.PriorityHigh), blockHash)
+	if err != nil {
+		if !errors.Is(err, ethmonitor.ErrBlockReceiptsUnsupported) {
+			l.log.Debugf("fetchBlockTransactionReceipt(%s) failed to fetch receipts of block %s: %v", txnHashHex, blockHash, err)
+		}
+		return l.fetchTransactionReceipt(ctx, txnHash, true)
+	}
+	for _, receipt := range receipts {
+		if receipt.TxHash == txnHash {
+			l.pastReceipts.Set(ctx, txnHashHex, receipt)
+			l.notFoundTxnHashes.Delete(ctx, txnHashHex)
+			return receipt, nil
+		}
+	}
+	return l.fetchTransactionReceipt(ctx, txnHash, true)
+}
+
 func (l *ReceiptsListener) listener() error {
 	monitor := l.monitor.Subscribe("ethreceipts")
 	defer monitor.Unsubscribe()
@@ -684,6 +719,7 @@ func (l *ReceiptsListener) processBlocks(blocks ethmonitor.Blocks, subscribers [
 				Final:       l.isBlockFinal(block.Number()),
 				logs:        txnLog,
 				transaction: txn,
+				blockHash:   block.Hash(),
 			}
 			txnMsg, err := ethtxn.AsMessage(txn)
 			if err != nil {
@@ -15,6 +15,7 @@ type Receipt struct {
 	Reorged bool     // chain reorged / removed the txn
 
 	transaction *types.Transaction
+	blockHash   common.Hash   // hash of the block of the transaction, when found by the monitor
 	message     *core.Message // TODO: this intermediate type is lame.. with new ethrpc we can remove
 	receipt     *types.Receipt
 	logs        []*types.Log
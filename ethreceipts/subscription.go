@@ -121,7 +121,7 @@ func (s *subscriber) matchFilters(ctx context.Context, filterers []Filterer, rec
 
 			// fetch transaction receipt if its not been marked as reorged
 			if !receipt.Reorged {
-				r, err := s.listener.fetchTransactionReceipt(ctx, receipt.TransactionHash(), true)
+				r, err := s.listener.fetchBlockTransactionReceipt(ctx, receipt.blockHash, receipt.TransactionHash())
 				if err != nil {
 					// TODO: is this fine to return error..? its a bit abrupt.
 					// Options are to set FailedFetch bool on the Receipt, and still send to s.ch,
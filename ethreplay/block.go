@@ -0,0 +1,175 @@
+package ethreplay
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+func parseBlock(c *CapturedBlock) (*block, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(c.Block, &fields); err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		return nil, fmt.Errorf("empty block")
+	}
+
+	var header struct {
+		Number     hexutil.Uint64    `json:"number"`
+		Hash       common.Hash       `json:"hash"`
+		ParentHash common.Hash       `json:"parentHash"`
+		Txs        []json.RawMessage `json:"transactions"`
+	}
+	if err := json.Unmarshal(c.Block, &header); err != nil {
+		return nil, err
+	}
+
+	b := &block{
+		number:   uint64(header.Number),
+		hash:     header.Hash,
+		parent:   header.ParentHash,
+		fields:   fields,
+		txs:      header.Txs,
+		receipts: c.Receipts,
+	}
+	for i, tx := range header.Txs {
+		var t struct {
+			Hash common.Hash `json:"hash"`
+		}
+		if err := json.Unmarshal(tx, &t); err != nil || t.Hash == (common.Hash{}) {
+			return nil, fmt.Errorf("transaction %d of the block is not a full transaction", i)
+		}
+		b.txHashes = append(b.txHashes, t.Hash)
+	}
+	if len(c.Receipts) > 0 && len(c.Receipts) != len(header.Txs) {
+		return nil, fmt.Errorf("%d receipts for %d transactions", len(c.Receipts), len(header.Txs))
+	}
+	for i, raw := range c.Receipts {
+		var receipt struct {
+			Logs []types.Log `json:"logs"`
+		}
+		if err := json.Unmarshal(raw, &receipt); err != nil {
+			return nil, fmt.Errorf("receipt %d: %w", i, err)
+		}
+		b.logs = append(b.logs, receipt.Logs...)
+	}
+	return b, nil
+}
+
+// synthesize returns a synthetic copy of the captured block on top of the parent,
+// ie. of the same transactions and a new hash, and adds it to the replay.
+func (r *Replayer) synthesize(origin, parent *block) (*block, error) {
+	var header *types.Header
+	if err := json.Unmarshal(marshalFields(origin.fields), &header); err != nil {
+		return nil, fmt.Errorf("ethreplay: failed to decode the header of block %s: %w", origin.hash, err)
+	}
+	header.ParentHash = parent.hash
+	header.Extra = append(append([]byte{}, header.Extra...), []byte(fmt.Sprintf("ethreplay:%d", r.numForks))...)
+	hash := header.Hash()
+	if b, ok := r.blocks[hash]; ok {
+		return b, nil
+	}
+
+	b := &block{
+		number:   origin.number,
+		hash:     hash,
+		parent:   parent.hash,
+		fields:   copyFields(origin.fields),
+		txHashes: origin.txHashes,
+		origin:   origin,
+	}
+	b.fields["hash"] = mustMarshal(hash)
+	b.fields["parentHash"] = mustMarshal(parent.hash)
+	b.fields["extraData"] = mustMarshal(hexutil.Bytes(header.Extra))
+
+	var err error
+	if b.txs, err = setBlockHash(origin.txs, hash); err != nil {
+		return nil, err
+	}
+	if b.receipts, err = setBlockHash(origin.receipts, hash); err != nil {
+		return nil, err
+	}
+	for i := range origin.receipts {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(b.receipts[i], &fields); err != nil {
+			return nil, err
+		}
+		logs, err := setBlockHash(rawArray(fields["logs"]), hash)
+		if err != nil {
+			return nil, err
+		}
+		fields["logs"] = mustMarshal(logs)
+		b.receipts[i] = marshalFields(fields)
+	}
+	for _, log := range origin.logs {
+		log.BlockHash = hash
+		b.logs = append(b.logs, log)
+	}
+	if b.txs != nil {
+		b.fields["transactions"] = mustMarshal(b.txs)
+	}
+
+	r.add(b)
+	return b, nil
+}
+
+// setBlockHash returns a copy of the objects with their blockHash field set.
+func setBlockHash(objects []json.RawMessage, hash common.Hash) ([]json.RawMessage, error) {
+	if objects == nil {
+		return nil, nil
+	}
+	out := make([]json.RawMessage, len(objects))
+	for i, raw := range objects {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return nil, err
+		}
+		fields["blockHash"] = mustMarshal(hash)
+		out[i] = marshalFields(fields)
+	}
+	return out, nil
+}
+
+// json returns the block as a result of eth_getBlockByNumber.
+func (b *block) json(fullTx bool) json.RawMessage {
+	if fullTx {
+		return marshalFields(b.fields)
+	}
+	fields := copyFields(b.fields)
+	hashes := b.txHashes
+	if hashes == nil {
+		hashes = []common.Hash{}
+	}
+	fields["transactions"] = mustMarshal(hashes)
+	return marshalFields(fields)
+}
+
+func rawArray(raw json.RawMessage) []json.RawMessage {
+	var out []json.RawMessage
+	json.Unmarshal(raw, &out)
+	return out
+}
+
+func copyFields(fields map[string]json.RawMessage) map[string]json.RawMessage {
+	out := make(map[string]json.RawMessage, len(fields))
+	for k, v := range fields {
+		out[k] = v
+	}
+	return out
+}
+
+func marshalFields(fields map[string]json.RawMessage) json.RawMessage {
+	return mustMarshal(fields)
+}
+
+func mustMarshal(v any) json.RawMessage {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
@@ -0,0 +1,95 @@
+package ethreplay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+)
+
+// Capture captures the blocks from..to of the node with their receipts, for a
+// replay.
+func Capture(ctx context.Context, provider ethrpc.Interface, from, to uint64) ([]*CapturedBlock, error) {
+	var blocks []*CapturedBlock
+	for num := from; num <= to; num++ {
+		var raw json.RawMessage
+		if _, err := provider.Do(ctx, ethrpc.RawBlockByNumber(new(big.Int).SetUint64(num)).Into(&raw)); err != nil {
+			return nil, fmt.Errorf("ethreplay: failed to fetch block %d: %w", num, err)
+		}
+		if len(raw) == 0 || string(raw) == "null" {
+			return nil, fmt.Errorf("ethreplay: block %d not found", num)
+		}
+
+		var receipts []json.RawMessage
+		call := ethrpc.NewCallBuilder[[]json.RawMessage]("eth_getBlockReceipts", nil, hexutil.EncodeUint64(num))
+		if _, err := provider.Do(ctx, call.Into(&receipts)); err != nil {
+			return nil, fmt.Errorf("ethreplay: failed to fetch the receipts of block %d: %w", num, err)
+		}
+		blocks = append(blocks, &CapturedBlock{Block: raw, Receipts: receipts})
+	}
+	return blocks, nil
+}
+
+// WriteDir writes the captured blocks to the directory, a json file per block
+// named by its number and hash.
+func WriteDir(dir string, blocks []*CapturedBlock) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, c := range blocks {
+		var header struct {
+			Number hexutil.Uint64 `json:"number"`
+			Hash   common.Hash    `json:"hash"`
+		}
+		if err := json.Unmarshal(c.Block, &header); err != nil {
+			return fmt.Errorf("ethreplay: invalid captured block: %w", err)
+		}
+		data, err := json.Marshal(c)
+		if err != nil {
+			return err
+		}
+		name := fmt.Sprintf("%012d-%s.json", uint64(header.Number), header.Hash.Hex())
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadDir reads the captured blocks of the directory, in the order of their file
+// names, ie. of their numbers for the files of WriteDir.
+func ReadDir(dir string) ([]*CapturedBlock, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	blocks := make([]*CapturedBlock, 0, len(names))
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		var c CapturedBlock
+		if err := json.Unmarshal(data, &c); err != nil {
+			return nil, fmt.Errorf("ethreplay: invalid captured block %s: %w", name, err)
+		}
+		blocks = append(blocks, &c)
+	}
+	return blocks, nil
+}
@@ -0,0 +1,369 @@
+// Package ethreplay replays previously captured blocks as a JSON-RPC node, to
+// drive ethmonitor and everything downstream of it, ie. ethreceipts and the
+// indexers, deterministically without a live node.
+//
+// The head of the replayed chain is advanced by the steps of a Script, which
+// can reorg the chain to a captured fork or to synthetic blocks, ie. copies of
+// the captured blocks with new hashes. A monitor of a replay should disable the
+// pause of its reorgs, with a negative ethmonitor.Options.ReorgPause.
+package ethreplay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+var (
+	ErrEndOfReplay  = errors.New("ethreplay: end of replay")
+	ErrUnknownBlock = errors.New("ethreplay: unknown block")
+	ErrInvalidStep  = errors.New("ethreplay: invalid step")
+)
+
+var DefaultOptions = Options{
+	ChainID: big.NewInt(1),
+}
+
+type Options struct {
+	// ChainID of the replayed chain.
+	ChainID *big.Int
+
+	// Script of the replay, which by default advances the head one block per
+	// step until the last captured block.
+	Script []Step
+
+	// StartBlock is the hash of the head of the replay before its first step, by
+	// default the first captured block of the lowest number.
+	StartBlock *common.Hash
+}
+
+// CapturedBlock is a block captured from a node, see Capture.
+type CapturedBlock struct {
+	// Block is the result of eth_getBlockByNumber, with the full transactions.
+	Block json.RawMessage `json:"block"`
+
+	// Receipts are the receipts of the transactions of the block.
+	Receipts []json.RawMessage `json:"receipts,omitempty"`
+}
+
+// block is a captured or synthetic block of the replay.
+type block struct {
+	number uint64
+	hash   common.Hash
+	parent common.Hash
+
+	fields   map[string]json.RawMessage
+	txs      []json.RawMessage
+	txHashes []common.Hash
+	receipts []json.RawMessage
+	logs     []types.Log
+
+	// origin is the captured block of a synthetic block
+	origin *block
+}
+
+// Replayer serves the captured blocks up to its head as a JSON-RPC node. It
+// implements http.Handler.
+type Replayer struct {
+	options Options
+
+	blocks   map[common.Hash]*block
+	children map[common.Hash][]*block
+	heights  map[common.Hash]int
+	numForks int
+
+	// canonical chain up to the head, and the location of its transactions
+	canonical []*block
+	txs       map[common.Hash]txLocation
+
+	script []Step
+	step   int
+
+	mu sync.Mutex
+}
+
+type txLocation struct {
+	block *block
+	index int
+}
+
+// NewReplayer creates a replay of the captured blocks, with its head at the start
+// block.
+func NewReplayer(captured []*CapturedBlock, opts ...Options) (*Replayer, error) {
+	options := DefaultOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	if options.ChainID == nil {
+		options.ChainID = DefaultOptions.ChainID
+	}
+	if len(captured) == 0 {
+		return nil, fmt.Errorf("ethreplay: no captured blocks")
+	}
+
+	r := &Replayer{
+		options:  options,
+		blocks:   map[common.Hash]*block{},
+		children: map[common.Hash][]*block{},
+		heights:  map[common.Hash]int{},
+		txs:      map[common.Hash]txLocation{},
+		script:   options.Script,
+	}
+
+	var start *block
+	for i, c := range captured {
+		b, err := parseBlock(c)
+		if err != nil {
+			return nil, fmt.Errorf("ethreplay: captured block %d: %w", i, err)
+		}
+		if _, ok := r.blocks[b.hash]; ok {
+			return nil, fmt.Errorf("ethreplay: duplicate block %s", b.hash)
+		}
+		r.add(b)
+		if start == nil || b.number < start.number {
+			start = b
+		}
+	}
+
+	if options.StartBlock != nil {
+		var ok bool
+		if start, ok = r.blocks[*options.StartBlock]; !ok {
+			return nil, fmt.Errorf("%w %s", ErrUnknownBlock, *options.StartBlock)
+		}
+	}
+	r.setHead(start)
+	return r, nil
+}
+
+func (r *Replayer) add(b *block) {
+	r.blocks[b.hash] = b
+	r.children[b.parent] = append(r.children[b.parent], b)
+}
+
+// ChainID returns the chain id of the replay.
+func (r *Replayer) ChainID() *big.Int {
+	return new(big.Int).Set(r.options.ChainID)
+}
+
+// Head returns the number and hash of the head of the replay.
+func (r *Replayer) Head() (uint64, common.Hash) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	head := r.head()
+	return head.number, head.hash
+}
+
+func (r *Replayer) head() *block {
+	return r.canonical[len(r.canonical)-1]
+}
+
+// Next executes the next step of the script, and returns ErrEndOfReplay once all
+// the steps are executed. Without a script, it advances the head by one block.
+func (r *Replayer) Next() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.script == nil {
+		return r.advance(1)
+	}
+	if r.step >= len(r.script) {
+		return ErrEndOfReplay
+	}
+	step := r.script[r.step]
+	if err := r.apply(step); err != nil {
+		return fmt.Errorf("ethreplay: step %d: %w", r.step, err)
+	}
+	r.step++
+	return nil
+}
+
+// Run executes the next step every interval, until the end of the replay or the
+// context is done.
+func (r *Replayer) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := r.Next(); err != nil {
+				if errors.Is(err, ErrEndOfReplay) {
+					return nil
+				}
+				return err
+			}
+		}
+	}
+}
+
+// Apply executes the step, besides the script.
+func (r *Replayer) Apply(step Step) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.apply(step)
+}
+
+func (r *Replayer) apply(step Step) error {
+	if err := step.validate(); err != nil {
+		return err
+	}
+	switch {
+	case step.Advance > 0:
+		return r.advance(step.Advance)
+	case step.Reorg > 0:
+		return r.reorg(step.Reorg)
+	default:
+		b, ok := r.blocks[*step.Head]
+		if !ok {
+			return fmt.Errorf("%w %s", ErrUnknownBlock, *step.Head)
+		}
+		r.setHead(b)
+		return nil
+	}
+}
+
+// advance moves the head to the next n blocks. The next block of a captured
+// block is its captured child of the longest chain, and of a synthetic block a
+// synthetic copy of the next block of its origin.
+func (r *Replayer) advance(n int) error {
+	for i := 0; i < n; i++ {
+		head := r.head()
+		var next *block
+		if head.origin == nil {
+			next = r.longestChild(head)
+		} else if origin := r.longestChild(head.origin); origin != nil {
+			var err error
+			if next, err = r.synthesize(origin, head); err != nil {
+				return err
+			}
+		}
+		if next == nil {
+			return ErrEndOfReplay
+		}
+		r.push(next)
+	}
+	return nil
+}
+
+// reorg replaces the last depth blocks of the canonical chain by synthetic
+// copies of them.
+func (r *Replayer) reorg(depth int) error {
+	if depth >= len(r.canonical) {
+		return fmt.Errorf("%w: reorg of %d blocks is deeper than the replayed chain", ErrInvalidStep, depth)
+	}
+	replaced := append([]*block{}, r.canonical[len(r.canonical)-depth:]...)
+	r.numForks++
+	r.truncate(len(r.canonical) - depth)
+	for _, b := range replaced {
+		origin := b
+		if b.origin != nil {
+			origin = b.origin
+		}
+		next, err := r.synthesize(origin, r.head())
+		if err != nil {
+			return err
+		}
+		r.push(next)
+	}
+	return nil
+}
+
+// setHead sets the canonical chain to the chain of the block, as far back as the
+// blocks are known.
+func (r *Replayer) setHead(b *block) {
+	var chain []*block
+	for ; b != nil; b = r.blocks[b.parent] {
+		chain = append(chain, b)
+	}
+
+	// keep the common ancestors
+	n := 0
+	for n < len(r.canonical) && n < len(chain) {
+		if r.canonical[n] != chain[len(chain)-1-n] {
+			break
+		}
+		n++
+	}
+	r.truncate(n)
+	for i := len(chain) - 1 - n; i >= 0; i-- {
+		r.push(chain[i])
+	}
+}
+
+func (r *Replayer) push(b *block) {
+	r.canonical = append(r.canonical, b)
+	for i, hash := range b.txHashes {
+		r.txs[hash] = txLocation{block: b, index: i}
+	}
+}
+
+func (r *Replayer) truncate(n int) {
+	for _, b := range r.canonical[n:] {
+		for _, hash := range b.txHashes {
+			if r.txs[hash].block == b {
+				delete(r.txs, hash)
+			}
+		}
+	}
+	r.canonical = r.canonical[:n]
+}
+
+// longestChild returns the captured child of the block with the longest chain of
+// descendants, the first captured one on ties.
+func (r *Replayer) longestChild(b *block) *block {
+	var best *block
+	for _, c := range r.children[b.hash] {
+		if c.origin != nil {
+			continue
+		}
+		if best == nil || r.height(c) > r.height(best) {
+			best = c
+		}
+	}
+	return best
+}
+
+func (r *Replayer) height(b *block) int {
+	if h, ok := r.heights[b.hash]; ok {
+		return h
+	}
+	// iterative, for the long chains of a single child
+	chain := []*block{b}
+	for {
+		last := chain[len(chain)-1]
+		children := r.captured(r.children[last.hash])
+		if len(children) != 1 {
+			break
+		}
+		if _, ok := r.heights[children[0].hash]; ok {
+			break
+		}
+		chain = append(chain, children[0])
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		h := 0
+		for _, c := range r.captured(r.children[chain[i].hash]) {
+			h = max(h, r.height(c)+1)
+		}
+		r.heights[chain[i].hash] = h
+	}
+	return r.heights[b.hash]
+}
+
+func (r *Replayer) captured(blocks []*block) []*block {
+	var out []*block
+	for _, b := range blocks {
+		if b.origin == nil {
+			out = append(out, b)
+		}
+	}
+	return out
+}
@@ -0,0 +1,216 @@
+package ethreplay_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethdevnode"
+	"github.com/0xsequence/ethkit/ethmonitor"
+	"github.com/0xsequence/ethkit/ethreplay"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testTopic = common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef")
+
+// captureTestChain captures the blocks 0..4 of a dev chain of a transfer in each
+// of the blocks 1..3, with a log added to the receipt of block 2.
+func captureTestChain(t *testing.T) ([]*ethreplay.CapturedBlock, []common.Hash) {
+	ctx := context.Background()
+	node, err := ethdevnode.NewNode(ctx)
+	require.NoError(t, err)
+	server := httptest.NewServer(node)
+	defer server.Close()
+
+	provider, err := ethrpc.NewProvider(server.URL)
+	require.NoError(t, err)
+	_, err = provider.ChainID(ctx)
+	require.NoError(t, err)
+
+	wallet := node.Accounts()[0]
+	wallet.SetProvider(provider)
+	to := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	var txHashes []common.Hash
+	for i := 0; i < 3; i++ {
+		txn, err := wallet.NewTransaction(ctx, &ethtxn.TransactionRequest{To: &to, ETHValue: big.NewInt(1000)})
+		require.NoError(t, err)
+		_, wait, err := wallet.SendTransaction(ctx, txn)
+		require.NoError(t, err)
+		_, err = wait(ctx)
+		require.NoError(t, err)
+		txHashes = append(txHashes, txn.Hash())
+	}
+	node.Mine()
+
+	blocks, err := ethreplay.Capture(ctx, provider, 0, 4)
+	require.NoError(t, err)
+	require.Len(t, blocks, 5)
+	require.Len(t, blocks[2].Receipts, 1)
+
+	var receipt map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(blocks[2].Receipts[0], &receipt))
+	var blockHash common.Hash
+	require.NoError(t, json.Unmarshal(receipt["blockHash"], &blockHash))
+	log, err := json.Marshal([]types.Log{{Address: to, Topics: []common.Hash{testTopic}, Data: []byte{}, TxHash: txHashes[1], BlockNumber: 2, BlockHash: blockHash}})
+	require.NoError(t, err)
+	receipt["logs"] = log
+	blocks[2].Receipts[0], err = json.Marshal(receipt)
+	require.NoError(t, err)
+
+	return blocks, txHashes
+}
+
+func TestReplayDir(t *testing.T) {
+	blocks, _ := captureTestChain(t)
+	dir := t.TempDir()
+	require.NoError(t, ethreplay.WriteDir(dir, blocks))
+	read, err := ethreplay.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, read, len(blocks))
+	assert.JSONEq(t, string(blocks[2].Block), string(read[2].Block))
+	assert.JSONEq(t, string(blocks[2].Receipts[0]), string(read[2].Receipts[0]))
+}
+
+func TestReplayReorg(t *testing.T) {
+	ctx := context.Background()
+	captured, txHashes := captureTestChain(t)
+
+	script, err := ethreplay.ParseScript([]byte(`[{"advance": 2}, {"reorg": 1}, {"advance": 2}]`))
+	require.NoError(t, err)
+	replay, err := ethreplay.NewReplayer(captured, ethreplay.Options{ChainID: big.NewInt(1337), Script: script})
+	require.NoError(t, err)
+	server := httptest.NewServer(replay)
+	defer server.Close()
+
+	provider, err := ethrpc.NewProvider(server.URL)
+	require.NoError(t, err)
+	chainID, err := provider.ChainID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(1337), chainID)
+
+	num, err := provider.BlockNumber(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), num)
+	_, err = provider.BlockByNumber(ctx, big.NewInt(1))
+	assert.ErrorIs(t, err, ethereum.NotFound)
+
+	require.NoError(t, replay.Next())
+	block2, err := provider.BlockByNumber(ctx, big.NewInt(2))
+	require.NoError(t, err)
+	logs, err := provider.FilterLogs(ctx, ethereum.FilterQuery{FromBlock: big.NewInt(0), Topics: [][]common.Hash{{testTopic}}})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, block2.Hash(), logs[0].BlockHash)
+
+	// the synthetic block 2 has the same transactions, and a new hash
+	require.NoError(t, replay.Next())
+	reorged, err := provider.BlockByNumber(ctx, big.NewInt(2))
+	require.NoError(t, err)
+	assert.NotEqual(t, block2.Hash(), reorged.Hash())
+	assert.Equal(t, block2.ParentHash(), reorged.ParentHash())
+	require.Len(t, reorged.Transactions(), 1)
+	assert.Equal(t, txHashes[1], reorged.Transactions()[0].Hash())
+
+	receipt, err := provider.TransactionReceipt(ctx, txHashes[1])
+	require.NoError(t, err)
+	assert.Equal(t, reorged.Hash(), receipt.BlockHash)
+	block2Hash := block2.Hash()
+	logs, err = provider.FilterLogs(ctx, ethereum.FilterQuery{BlockHash: &block2Hash})
+	require.NoError(t, err)
+	assert.Len(t, logs, 1)
+	logs, err = provider.FilterLogs(ctx, ethereum.FilterQuery{FromBlock: big.NewInt(0), Addresses: []common.Address{logs[0].Address}})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, reorged.Hash(), logs[0].BlockHash)
+
+	// the next blocks are built on the synthetic block
+	require.NoError(t, replay.Next())
+	block3, err := provider.BlockByNumber(ctx, big.NewInt(3))
+	require.NoError(t, err)
+	assert.Equal(t, reorged.Hash(), block3.ParentHash())
+	num, head := replay.Head()
+	assert.Equal(t, uint64(4), num)
+	latest, err := provider.BlockByNumber(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, head, latest.Hash())
+
+	assert.ErrorIs(t, replay.Next(), ethreplay.ErrEndOfReplay)
+}
+
+func TestReplayMonitor(t *testing.T) {
+	captured, _ := captureTestChain(t)
+
+	script := []ethreplay.Step{ethreplay.Advance(3), ethreplay.Reorg(2), ethreplay.Advance(1)}
+	replay, err := ethreplay.NewReplayer(captured, ethreplay.Options{ChainID: big.NewInt(1337), Script: script})
+	require.NoError(t, err)
+	server := httptest.NewServer(replay)
+	defer server.Close()
+
+	provider, err := ethrpc.NewProvider(server.URL)
+	require.NoError(t, err)
+
+	options := ethmonitor.DefaultOptions
+	options.PollingInterval = 5 * time.Millisecond
+	options.ReorgPause = -1
+	monitor, err := ethmonitor.NewMonitor(provider, options)
+	require.NoError(t, err)
+
+	sub := monitor.Subscribe("TestReplayMonitor")
+	defer sub.Unsubscribe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go monitor.Run(ctx)
+	defer monitor.Stop()
+
+	replayed := make(chan error, 1)
+	go func() {
+		replayed <- replay.Run(ctx, 50*time.Millisecond)
+	}()
+
+	var removed []common.Hash
+	var head *common.Hash
+	timeout := time.After(5 * time.Second)
+	for head == nil || monitor.LatestBlock() == nil || monitor.LatestBlock().Hash() != *head {
+		select {
+		case blocks := <-sub.Blocks():
+			for _, b := range blocks {
+				if b.Event == ethmonitor.Removed {
+					removed = append(removed, b.Hash())
+				}
+			}
+		case err := <-replayed:
+			require.NoError(t, err)
+			_, hash := replay.Head()
+			head = &hash
+		case <-time.After(10 * time.Millisecond):
+		case <-timeout:
+			t.Fatal("timeout waiting for the monitor to reach the head of the replay")
+		}
+	}
+
+	t.Log("reached", time.Now())
+	// the original blocks 2 and 3 are removed
+	originals := map[common.Hash]bool{}
+	for _, c := range captured[2:4] {
+		var h struct {
+			Hash common.Hash `json:"hash"`
+		}
+		require.NoError(t, json.Unmarshal(c.Block, &h))
+		originals[h.Hash] = true
+	}
+	for _, hash := range removed {
+		assert.True(t, originals[hash], hash.Hex())
+	}
+	assert.NotEmpty(t, removed)
+	assert.Equal(t, uint64(4), monitor.LatestBlock().NumberU64())
+}
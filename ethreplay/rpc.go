@@ -0,0 +1,344 @@
+package ethreplay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/0xsequence/ethkit/ethrpc/jsonrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+type rpcRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type rpcResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *jsonrpc.Error  `json:"error,omitempty"`
+}
+
+var errMethodNotFound = errors.New("method not found")
+
+type invalidParamsError struct {
+	err error
+}
+
+func (e invalidParamsError) Error() string {
+	return "invalid params: " + e.err.Error()
+}
+
+// ServeHTTP serves the JSON-RPC requests and batches of requests of the replayed
+// chain up to its head.
+func (r *Replayer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	body = []byte(strings.TrimSpace(string(body)))
+	if len(body) > 0 && body[0] == '[' {
+		var reqs []rpcRequest
+		if err := json.Unmarshal(body, &reqs); err != nil {
+			json.NewEncoder(w).Encode(parseErrorResponse(err))
+			return
+		}
+		resps := make([]rpcResponse, len(reqs))
+		for i, req := range reqs {
+			resps[i] = r.serve(req)
+		}
+		json.NewEncoder(w).Encode(resps)
+		return
+	}
+
+	var rpcReq rpcRequest
+	if err := json.Unmarshal(body, &rpcReq); err != nil {
+		json.NewEncoder(w).Encode(parseErrorResponse(err))
+		return
+	}
+	json.NewEncoder(w).Encode(r.serve(rpcReq))
+}
+
+func parseErrorResponse(err error) rpcResponse {
+	return rpcResponse{
+		Version: "2.0",
+		ID:      json.RawMessage("null"),
+		Error:   &jsonrpc.Error{Code: -32700, Message: err.Error()},
+	}
+}
+
+func (r *Replayer) serve(req rpcRequest) rpcResponse {
+	resp := rpcResponse{Version: "2.0", ID: req.ID}
+
+	r.mu.Lock()
+	result, err := r.handle(req.Method, req.Params)
+	r.mu.Unlock()
+	if err != nil {
+		var paramsErr invalidParamsError
+		switch {
+		case errors.Is(err, errMethodNotFound):
+			resp.Error = &jsonrpc.Error{Code: -32601, Message: fmt.Sprintf("the method %s does not exist/is not available", req.Method)}
+		case errors.As(err, &paramsErr):
+			resp.Error = &jsonrpc.Error{Code: -32602, Message: err.Error()}
+		default:
+			resp.Error = &jsonrpc.Error{Code: -32000, Message: err.Error()}
+		}
+		return resp
+	}
+
+	if raw, ok := result.(json.RawMessage); ok {
+		resp.Result = raw
+	} else if resp.Result, err = json.Marshal(result); err != nil {
+		resp.Error = &jsonrpc.Error{Code: -32603, Message: err.Error()}
+	}
+	if len(resp.Result) == 0 {
+		resp.Result = json.RawMessage("null")
+	}
+	return resp
+}
+
+func (r *Replayer) handle(method string, params []json.RawMessage) (any, error) {
+	switch method {
+	case "web3_clientVersion":
+		return "ethkit/replay", nil
+	case "net_version":
+		return r.options.ChainID.String(), nil
+	case "eth_chainId":
+		return (*hexutil.Big)(r.options.ChainID), nil
+	case "eth_syncing":
+		return false, nil
+	case "eth_blockNumber":
+		return hexutil.Uint64(r.head().number), nil
+
+	case "eth_getBlockByNumber":
+		var blockParam json.RawMessage
+		var fullTx bool
+		if err := parseParams(params, &blockParam, &fullTx); err != nil {
+			return nil, err
+		}
+		b, err := r.blockArg(blockParam)
+		if err != nil || b == nil {
+			return nil, err
+		}
+		return b.json(fullTx), nil
+	case "eth_getBlockByHash":
+		var hash common.Hash
+		var fullTx bool
+		if err := parseParams(params, &hash, &fullTx); err != nil {
+			return nil, err
+		}
+		if b, ok := r.blocks[hash]; ok {
+			return b.json(fullTx), nil
+		}
+		return nil, nil
+
+	case "eth_getTransactionByHash":
+		var hash common.Hash
+		if err := parseParams(params, &hash); err != nil {
+			return nil, err
+		}
+		if loc, ok := r.txs[hash]; ok {
+			return loc.block.txs[loc.index], nil
+		}
+		return nil, nil
+	case "eth_getTransactionReceipt":
+		var hash common.Hash
+		if err := parseParams(params, &hash); err != nil {
+			return nil, err
+		}
+		if loc, ok := r.txs[hash]; ok && loc.block.receipts != nil {
+			return loc.block.receipts[loc.index], nil
+		}
+		return nil, nil
+	case "eth_getBlockReceipts":
+		var blockParam json.RawMessage
+		if err := parseParams(params, &blockParam); err != nil {
+			return nil, err
+		}
+		var b *block
+		var hash common.Hash
+		if err := json.Unmarshal(blockParam, &hash); err == nil && len(blockParam) == 2+2+2*common.HashLength {
+			b = r.blocks[hash]
+		} else if b, err = r.blockArg(blockParam); err != nil {
+			return nil, err
+		}
+		if b == nil || (b.receipts == nil && len(b.txs) > 0) {
+			return nil, nil
+		}
+		if b.receipts == nil {
+			return []json.RawMessage{}, nil
+		}
+		return b.receipts, nil
+	case "eth_getLogs":
+		return r.getLogs(params)
+
+	default:
+		return nil, errMethodNotFound
+	}
+}
+
+// parseParams decodes the positional params into args. Missing trailing params
+// are left as is.
+func parseParams(params []json.RawMessage, args ...any) error {
+	if len(params) > len(args) {
+		return invalidParamsError{fmt.Errorf("too many arguments, want at most %d", len(args))}
+	}
+	for i, param := range params {
+		if err := json.Unmarshal(param, args[i]); err != nil {
+			return invalidParamsError{fmt.Errorf("argument %d: %w", i, err)}
+		}
+	}
+	return nil
+}
+
+// blockArg resolves a block number or tag to a block of the canonical chain, or
+// nil when the block is after the head. The tags other than earliest resolve to
+// the head.
+func (r *Replayer) blockArg(param json.RawMessage) (*block, error) {
+	if len(param) == 0 {
+		return r.head(), nil
+	}
+	var tag string
+	if err := json.Unmarshal(param, &tag); err != nil {
+		return nil, invalidParamsError{err}
+	}
+	switch tag {
+	case "", "latest", "pending", "safe", "finalized":
+		return r.head(), nil
+	case "earliest":
+		return r.canonical[0], nil
+	}
+	num, err := hexutil.DecodeUint64(tag)
+	if err != nil {
+		return nil, invalidParamsError{err}
+	}
+	return r.canonicalAt(num), nil
+}
+
+func (r *Replayer) canonicalAt(num uint64) *block {
+	first := r.canonical[0].number
+	if num < first || num > r.head().number {
+		return nil
+	}
+	return r.canonical[num-first]
+}
+
+func (r *Replayer) getLogs(params []json.RawMessage) (any, error) {
+	var filter struct {
+		FromBlock json.RawMessage   `json:"fromBlock"`
+		ToBlock   json.RawMessage   `json:"toBlock"`
+		BlockHash *common.Hash      `json:"blockHash"`
+		Address   json.RawMessage   `json:"address,omitempty"`
+		Topics    []json.RawMessage `json:"topics,omitempty"`
+	}
+	if err := parseParams(params, &filter); err != nil {
+		return nil, err
+	}
+
+	var addresses []common.Address
+	if len(filter.Address) > 0 && string(filter.Address) != "null" {
+		var address common.Address
+		if err := json.Unmarshal(filter.Address, &address); err == nil {
+			addresses = []common.Address{address}
+		} else if err := json.Unmarshal(filter.Address, &addresses); err != nil {
+			return nil, invalidParamsError{err}
+		}
+	}
+	topics := make([][]common.Hash, len(filter.Topics))
+	for i, raw := range filter.Topics {
+		if string(raw) == "null" {
+			continue
+		}
+		var topic common.Hash
+		if err := json.Unmarshal(raw, &topic); err == nil {
+			topics[i] = []common.Hash{topic}
+		} else if err := json.Unmarshal(raw, &topics[i]); err != nil {
+			return nil, invalidParamsError{err}
+		}
+	}
+
+	var blocks []*block
+	if filter.BlockHash != nil {
+		b, ok := r.blocks[*filter.BlockHash]
+		if !ok {
+			return nil, fmt.Errorf("%w %s", ErrUnknownBlock, *filter.BlockHash)
+		}
+		blocks = []*block{b}
+	} else {
+		from, err := r.blockArg(filter.FromBlock)
+		if err != nil {
+			return nil, err
+		}
+		to, err := r.blockArg(filter.ToBlock)
+		if err != nil {
+			return nil, err
+		}
+		if to == nil {
+			to = r.head()
+		}
+		if from != nil {
+			for num := from.number; num <= to.number; num++ {
+				blocks = append(blocks, r.canonicalAt(num))
+			}
+		}
+	}
+
+	logs := []types.Log{}
+	for _, b := range blocks {
+		for _, log := range b.logs {
+			if matchLog(&log, addresses, topics) {
+				logs = append(logs, log)
+			}
+		}
+	}
+	return logs, nil
+}
+
+func matchLog(log *types.Log, addresses []common.Address, topics [][]common.Hash) bool {
+	if len(addresses) > 0 {
+		found := false
+		for _, a := range addresses {
+			if log.Address == a {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(topics) > len(log.Topics) {
+		return false
+	}
+	for i, sub := range topics {
+		if len(sub) == 0 {
+			continue
+		}
+		found := false
+		for _, topic := range sub {
+			if log.Topics[i] == topic {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
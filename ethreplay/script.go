@@ -0,0 +1,81 @@
+package ethreplay
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// Step is a step of the script of a replay, of exactly one of its fields.
+type Step struct {
+	// Advance the head by the number of blocks.
+	Advance int `json:"advance,omitempty"`
+
+	// Reorg replaces the number of blocks at the head by synthetic blocks, ie.
+	// copies of them with new hashes.
+	Reorg int `json:"reorg,omitempty"`
+
+	// Head sets the head to the captured block of the hash, ie. to reorg to a
+	// captured fork.
+	Head *common.Hash `json:"head,omitempty"`
+}
+
+// Advance returns a step which advances the head by n blocks.
+func Advance(n int) Step {
+	return Step{Advance: n}
+}
+
+// Reorg returns a step which replaces the depth last blocks by synthetic blocks.
+func Reorg(depth int) Step {
+	return Step{Reorg: depth}
+}
+
+// SetHead returns a step which sets the head to the captured block.
+func SetHead(hash common.Hash) Step {
+	return Step{Head: &hash}
+}
+
+func (s Step) validate() error {
+	n := 0
+	if s.Advance != 0 {
+		n++
+	}
+	if s.Reorg != 0 {
+		n++
+	}
+	if s.Head != nil {
+		n++
+	}
+	if n != 1 || s.Advance < 0 || s.Reorg < 0 {
+		return fmt.Errorf("%w %+v", ErrInvalidStep, s)
+	}
+	return nil
+}
+
+// ParseScript parses the json array of the steps of a script, ie.
+// [{"advance": 5}, {"reorg": 2}, {"advance": 1}].
+func ParseScript(data []byte) ([]Step, error) {
+	var steps []Step
+	if err := json.Unmarshal(data, &steps); err != nil {
+		return nil, fmt.Errorf("ethreplay: invalid script: %w", err)
+	}
+	for i, step := range steps {
+		if err := step.validate(); err != nil {
+			return nil, fmt.Errorf("ethreplay: step %d: %w", i, err)
+		}
+	}
+	if steps == nil {
+		steps = []Step{}
+	}
+	return steps, nil
+}
+
+func ParseScriptFile(path string) ([]Step, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseScript(data)
+}
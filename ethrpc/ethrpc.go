@@ -10,6 +10,7 @@ import (
 	"math/big"
 	"net/http"
 	"sync/atomic"
+	"time"
 
 	"github.com/0xsequence/ethkit/go-ethereum"
 	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi/bind"
@@ -28,6 +29,11 @@ type Provider struct {
 	httpClient httpClient
 	br         breaker.Breaker
 	jwtToken   string // optional
+	scheduler  *scheduler
+	logHook    *logHook
+
+	// requestTimeout optional of the calls without a deadline
+	requestTimeout time.Duration
 
 	chainID *big.Int
 	// cache   cachestore.Store[[]byte] // NOTE: unused for now
@@ -72,6 +78,17 @@ func (p *Provider) Do(ctx context.Context, calls ...Call) ([]byte, error) {
 	if len(calls) == 0 {
 		return nil, nil
 	}
+	if p.logHook == nil {
+		return p.do(ctx, calls...)
+	}
+
+	start := time.Now()
+	body, err := p.do(ctx, calls...)
+	p.logHook.log(ctx, p.nodeURL, calls, time.Since(start), err)
+	return body, err
+}
+
+func (p *Provider) do(ctx context.Context, calls ...Call) ([]byte, error) {
 
 	batch := make(BatchCall, 0, len(calls))
 	for i, call := range calls {
@@ -90,6 +107,21 @@ func (p *Provider) Do(ctx context.Context, calls ...Call) ([]byte, error) {
 		return nil, superr.Wrap(ErrRequestFail, fmt.Errorf("failed to marshal JSONRPC request: %w", err))
 	}
 
+	if p.scheduler != nil {
+		if err := p.scheduler.acquire(ctx, callsPriority(ctx, calls)); err != nil {
+			return nil, superr.Wrap(ErrRequestFail, err)
+		}
+		defer p.scheduler.release()
+	}
+
+	if p.requestTimeout > 0 {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, p.requestTimeout)
+			defer cancel()
+		}
+	}
+
 	req, err := http.NewRequest(http.MethodPost, p.nodeURL, bytes.NewBuffer(b))
 	if err != nil {
 		return nil, superr.Wrap(ErrRequestFail, fmt.Errorf("failed to initialize http.Request: %w", err))
@@ -225,6 +257,30 @@ func (p *Provider) RawBlockByNumber(ctx context.Context, blockNum *big.Int) (jso
 	return result, nil
 }
 
+func (p *Provider) RawHeaderByHash(ctx context.Context, hash common.Hash) (json.RawMessage, error) {
+	var result json.RawMessage
+	_, err := p.Do(ctx, RawHeaderByHash(hash).Into(&result))
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 || string(result) == "null" {
+		return nil, ethereum.NotFound
+	}
+	return result, nil
+}
+
+func (p *Provider) RawHeaderByNumber(ctx context.Context, blockNum *big.Int) (json.RawMessage, error) {
+	var result json.RawMessage
+	_, err := p.Do(ctx, RawHeaderByNumber(blockNum).Into(&result))
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 || string(result) == "null" {
+		return nil, ethereum.NotFound
+	}
+	return result, nil
+}
+
 func (p *Provider) BlockByNumber(ctx context.Context, blockNum *big.Int) (*types.Block, error) {
 	var ret *types.Block
 	_, err := p.Do(ctx, BlockByNumber(blockNum).Into(&ret))
@@ -316,6 +372,12 @@ func (p *Provider) TransactionReceipt(ctx context.Context, txHash common.Hash) (
 	return receipt, err
 }
 
+func (p *Provider) BlockReceipts(ctx context.Context, blockHash common.Hash) ([]*types.Receipt, error) {
+	var receipts []*types.Receipt
+	_, err := p.Do(ctx, BlockReceipts(blockHash).Into(&receipts))
+	return receipts, err
+}
+
 func (p *Provider) SyncProgress(ctx context.Context) (*ethereum.SyncProgress, error) {
 	var progress *ethereum.SyncProgress
 	_, err := p.Do(ctx, SyncProgress().Into(&progress))
@@ -334,6 +396,15 @@ func (p *Provider) StorageAt(ctx context.Context, account common.Address, key co
 	return result, err
 }
 
+func (p *Provider) GetProof(ctx context.Context, account common.Address, keys []common.Hash, blockNum *big.Int) (*AccountProof, error) {
+	var proof *AccountProof
+	_, err := p.Do(ctx, GetProof(account, keys, blockNum).Into(&proof))
+	if err == nil && proof == nil {
+		return nil, ethereum.NotFound
+	}
+	return proof, err
+}
+
 func (p *Provider) CodeAt(ctx context.Context, account common.Address, blockNum *big.Int) ([]byte, error) {
 	var result []byte
 	_, err := p.Do(ctx, CodeAt(account, blockNum).Into(&result))
@@ -433,6 +504,24 @@ func (p *Provider) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint6
 	return result, err
 }
 
+func (p *Provider) DebugTraceTransaction(ctx context.Context, txHash common.Hash) (*CallFrame, error) {
+	var frame *CallFrame
+	_, err := p.Do(ctx, DebugTraceTransaction(txHash).Into(&frame))
+	if err == nil && frame == nil {
+		return nil, ethereum.NotFound
+	}
+	return frame, err
+}
+
+func (p *Provider) DebugTraceTransactionStructLogs(ctx context.Context, txHash common.Hash) (*StructLogTrace, error) {
+	var trace *StructLogTrace
+	_, err := p.Do(ctx, DebugTraceTransactionStructLogs(txHash).Into(&trace))
+	if err == nil && trace == nil {
+		return nil, ethereum.NotFound
+	}
+	return trace, err
+}
+
 // SubscribeFilterLogs is stubbed below so we can adhere to the bind.ContractBackend interface.
 func (p *Provider) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
 	if !p.IsStreamingEnabled() {
@@ -190,3 +190,11 @@ type RawInterface interface {
 	RawBlockByNumber(ctx context.Context, blockNum *big.Int) (json.RawMessage, error)
 	RawFilterLogs(ctx context.Context, q ethereum.FilterQuery) (json.RawMessage, error)
 }
+
+// RawHeaderInterface also returns the bytes of the block headers. It is optional
+// of a RawInterface, and checked by a type assertion, ie. by the LogsOnly mode
+// of ethmonitor.
+type RawHeaderInterface interface {
+	RawHeaderByHash(ctx context.Context, hash common.Hash) (json.RawMessage, error)
+	RawHeaderByNumber(ctx context.Context, blockNum *big.Int) (json.RawMessage, error)
+}
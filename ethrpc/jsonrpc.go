@@ -85,14 +85,25 @@ func (b CallBuilder2[T1, T2]) Into(ret1 *T1, ret2 *T2) Call {
 	}
 }
 
-var Pending = big.NewInt(-1)
+// Block tags which can be passed as the block number of queries, the same as
+// the negative block numbers of go-ethereum's rpc package.
+var (
+	Pending   = big.NewInt(-1)
+	Finalized = big.NewInt(-3)
+	Safe      = big.NewInt(-4)
+)
 
 func toBlockNumArg(blockNum *big.Int) string {
 	if blockNum == nil {
 		return "latest"
 	}
-	if blockNum.Cmp(Pending) == 0 {
+	switch {
+	case blockNum.Cmp(Pending) == 0:
 		return "pending"
+	case blockNum.Cmp(Finalized) == 0:
+		return "finalized"
+	case blockNum.Cmp(Safe) == 0:
+		return "safe"
 	}
 	return hexutil.EncodeBig(blockNum)
 }
@@ -114,6 +125,18 @@ func toCallArg(msg ethereum.CallMsg) any {
 	if msg.GasPrice != nil {
 		arg["gasPrice"] = (*hexutil.Big)(msg.GasPrice)
 	}
+	if msg.GasFeeCap != nil {
+		arg["maxFeePerGas"] = (*hexutil.Big)(msg.GasFeeCap)
+	}
+	if msg.GasTipCap != nil {
+		arg["maxPriorityFeePerGas"] = (*hexutil.Big)(msg.GasTipCap)
+	}
+	if msg.AccessList != nil {
+		arg["accessList"] = msg.AccessList
+	}
+	if msg.AuthorizationList != nil {
+		arg["authorizationList"] = msg.AuthorizationList
+	}
 	return arg
 }
 
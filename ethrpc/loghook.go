@@ -0,0 +1,172 @@
+package ethrpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/goware/logger"
+)
+
+// RequestLog is the record of a JSON-RPC request of a Provider, passed to its
+// LogHook. A batch request has several methods.
+type RequestLog struct {
+	// Methods are the methods of the calls of the request.
+	Methods []string
+
+	// Params are the redacted and truncated JSON params of each call.
+	Params []string
+
+	// Endpoint is the scheme and host of the node url, without its path and
+	// query which may contain an api key.
+	Endpoint string
+
+	Duration time.Duration
+	Err      error
+}
+
+func (r RequestLog) String() string {
+	calls := make([]string, len(r.Methods))
+	for i, method := range r.Methods {
+		calls[i] = method + r.Params[i]
+	}
+	s := fmt.Sprintf("ethrpc: %s %s %s", strings.Join(calls, ", "), r.Endpoint, r.Duration.Round(time.Microsecond))
+	if r.Err != nil {
+		s += ": " + r.Err.Error()
+	}
+	return s
+}
+
+// LogHook receives the records of the requests of a Provider.
+type LogHook func(ctx context.Context, log RequestLog)
+
+// LoggerHook returns a LogHook logging the requests with log, the failed
+// requests as warnings and the others as debug messages.
+func LoggerHook(log logger.Logger) LogHook {
+	return func(ctx context.Context, r RequestLog) {
+		if r.Err != nil {
+			log.Warn(r.String())
+		} else {
+			log.Debug(r.String())
+		}
+	}
+}
+
+// Redacted replaces the params of the redacted methods.
+const Redacted = "[redacted]"
+
+var DefaultLogHookOptions = LogHookOptions{
+	SampleRate:      1,
+	AlwaysLogErrors: true,
+	MaxParamsLength: 256,
+	RedactMethods: []string{
+		"eth_sendRawTransaction",
+		"eth_sendTransaction",
+		"eth_signTransaction",
+		"eth_sign",
+		"eth_signTypedData",
+		"eth_signTypedData_v3",
+		"eth_signTypedData_v4",
+		"personal_sign",
+		"eth_sendPrivateTransaction",
+		"eth_sendBundle",
+	},
+}
+
+type LogHookOptions struct {
+	// SampleRate is the fraction of the requests passed to the hook, from 0 to 1.
+	SampleRate float64
+
+	// AlwaysLogErrors passes all the failed requests to the hook, regardless of
+	// the SampleRate.
+	AlwaysLogErrors bool
+
+	// MaxParamsLength is the max length of the params of a call, the longer
+	// params are truncated. A value of 0 means no limit.
+	MaxParamsLength int
+
+	// RedactMethods are the methods whose params are replaced by Redacted, ie.
+	// with raw signed transactions or private payloads.
+	RedactMethods []string
+
+	// Redact optionally returns the params of a call to log, in place of the
+	// params, after RedactMethods.
+	Redact func(method string, params []any) []any
+}
+
+type logHook struct {
+	hook    LogHook
+	options LogHookOptions
+	redact  map[string]bool
+}
+
+// WithLogHook passes the records of the requests of the provider to hook, with
+// the params redacted and sampled by the options, default DefaultLogHookOptions.
+func WithLogHook(hook LogHook, options ...LogHookOptions) Option {
+	opts := DefaultLogHookOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	h := &logHook{hook: hook, options: opts, redact: map[string]bool{}}
+	for _, method := range opts.RedactMethods {
+		h.redact[method] = true
+	}
+	return func(p *Provider) {
+		p.logHook = h
+	}
+}
+
+func (h *logHook) log(ctx context.Context, nodeURL string, calls []Call, duration time.Duration, err error) {
+	sampled := h.options.SampleRate >= 1 || rand.Float64() < h.options.SampleRate
+	if !sampled && !(err != nil && h.options.AlwaysLogErrors) {
+		return
+	}
+
+	r := RequestLog{
+		Methods:  make([]string, len(calls)),
+		Params:   make([]string, len(calls)),
+		Endpoint: redactURL(nodeURL),
+		Duration: duration,
+		Err:      err,
+	}
+	for i, call := range calls {
+		r.Methods[i] = call.request.Method
+		r.Params[i] = h.params(call.request.Method, call.request.Params)
+	}
+	h.hook(ctx, r)
+}
+
+func (h *logHook) params(method string, params []any) string {
+	if h.redact[method] {
+		return "(" + Redacted + ")"
+	}
+	if h.options.Redact != nil {
+		params = h.options.Redact(method, params)
+	}
+	if params == nil {
+		params = []any{}
+	}
+
+	data, err := json.Marshal(params)
+	if err != nil {
+		return "(" + err.Error() + ")"
+	}
+	s := "(" + string(data[1:len(data)-1]) + ")"
+	if limit := h.options.MaxParamsLength; limit > 0 && len(s) > limit {
+		s = fmt.Sprintf("%s...(%d bytes)", s[:limit], len(s))
+	}
+	return s
+}
+
+// redactURL returns the scheme and host of the url.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return Redacted
+	}
+	return u.Scheme + "://" + u.Host
+}
@@ -0,0 +1,71 @@
+package ethrpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethrpc/jsonrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogHook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req jsonrpc.Message
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Method == "eth_sendRawTransaction" {
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32000,"message":"nonce too low"}}`, req.ID)
+			return
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x1"}`, req.ID)
+	}))
+	defer server.Close()
+
+	var logs []RequestLog
+	hook := func(ctx context.Context, r RequestLog) { logs = append(logs, r) }
+
+	options := DefaultLogHookOptions
+	options.MaxParamsLength = 20
+	provider, err := NewProvider(server.URL+"/v3/secret-api-key", WithLogHook(hook, options))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = provider.BalanceAt(ctx, common.HexToAddress("0x1234567890123456789012345678901234567890"), nil)
+	require.NoError(t, err)
+	_, err = provider.SendRawTransaction(ctx, "0xf86c0a8502540be400825208")
+	require.Error(t, err)
+
+	require.Len(t, logs, 2)
+	assert.Equal(t, []string{"eth_getBalance"}, logs[0].Methods)
+	assert.Equal(t, `("0x1234567890123456...(55 bytes)`, logs[0].Params[0])
+	assert.Equal(t, server.URL, logs[0].Endpoint)
+	assert.NoError(t, logs[0].Err)
+	assert.Positive(t, logs[0].Duration)
+
+	assert.Equal(t, []string{"eth_sendRawTransaction"}, logs[1].Methods)
+	assert.Equal(t, "("+Redacted+")", logs[1].Params[0])
+	assert.ErrorContains(t, logs[1].Err, "nonce too low")
+	assert.NotContains(t, logs[1].String(), "secret-api-key")
+	assert.NotContains(t, logs[1].String(), "0xf86c")
+
+	// only the errors are logged with a sample rate of 0
+	logs = nil
+	options.SampleRate = 0
+	options.Redact = func(method string, params []any) []any { return nil }
+	provider, err = NewProvider(server.URL, WithLogHook(hook, options))
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		_, err = provider.BlockNumber(ctx)
+		require.NoError(t, err)
+	}
+	_, err = provider.SendRawTransaction(ctx, "0x00")
+	require.Error(t, err)
+	require.Len(t, logs, 1)
+	assert.True(t, strings.HasPrefix(logs[0].String(), "ethrpc: eth_sendRawTransaction([redacted]) "+server.URL))
+}
@@ -90,6 +90,22 @@ func RawBlockByNumber(blockNum *big.Int) CallBuilder[json.RawMessage] {
 	}
 }
 
+func RawHeaderByHash(hash common.Hash) CallBuilder[json.RawMessage] {
+	return CallBuilder[json.RawMessage]{
+		method: "eth_getBlockByHash",
+		params: []any{hash, false},
+		intoFn: IntoJSONRawMessage,
+	}
+}
+
+func RawHeaderByNumber(blockNum *big.Int) CallBuilder[json.RawMessage] {
+	return CallBuilder[json.RawMessage]{
+		method: "eth_getBlockByNumber",
+		params: []any{toBlockNumArg(blockNum), false},
+		intoFn: IntoJSONRawMessage,
+	}
+}
+
 func PeerCount() CallBuilder[uint64] {
 	return CallBuilder[uint64]{
 		method: "net_peerCount",
@@ -170,6 +186,20 @@ func TransactionReceipt(txHash common.Hash) CallBuilder[*types.Receipt] {
 	}
 }
 
+func BlockReceipts(blockHash common.Hash) CallBuilder[[]*types.Receipt] {
+	return CallBuilder[[]*types.Receipt]{
+		method: "eth_getBlockReceipts",
+		params: []any{blockHash},
+		intoFn: func(raw json.RawMessage, receipts *[]*types.Receipt) error {
+			err := json.Unmarshal(raw, receipts)
+			if err == nil && *receipts == nil {
+				return ethereum.NotFound
+			}
+			return err
+		},
+	}
+}
+
 func SyncProgress() CallBuilder[*ethereum.SyncProgress] {
 	return CallBuilder[*ethereum.SyncProgress]{
 		method: "eth_syncing",
@@ -205,6 +235,16 @@ func StorageAt(account common.Address, key common.Hash, blockNum *big.Int) CallB
 	}
 }
 
+// StorageAtHash returns the value of the storage key of the account at the
+// block of blockHash.
+func StorageAtHash(account common.Address, key common.Hash, blockHash common.Hash) CallBuilder[[]byte] {
+	return CallBuilder[[]byte]{
+		method: "eth_getStorageAt",
+		params: []any{account, key, rpc.BlockNumberOrHashWithHash(blockHash, false)},
+		intoFn: hexIntoBytes,
+	}
+}
+
 func CodeAt(account common.Address, blockNum *big.Int) CallBuilder[[]byte] {
 	return CallBuilder[[]byte]{
 		method: "eth_getCode",
@@ -213,6 +253,18 @@ func CodeAt(account common.Address, blockNum *big.Int) CallBuilder[[]byte] {
 	}
 }
 
+// GetProof returns the account and storage values of the account, with their
+// merkle proofs, see EIP-1186.
+func GetProof(account common.Address, keys []common.Hash, blockNum *big.Int) CallBuilder[*AccountProof] {
+	if keys == nil {
+		keys = []common.Hash{}
+	}
+	return CallBuilder[*AccountProof]{
+		method: "eth_getProof",
+		params: []any{account, keys, toBlockNumArg(blockNum)},
+	}
+}
+
 func NonceAt(account common.Address, blockNum *big.Int) CallBuilder[uint64] {
 	return CallBuilder[uint64]{
 		method: "eth_getTransactionCount",
@@ -416,3 +468,23 @@ func EstimateGas(msg ethereum.CallMsg) CallBuilder[uint64] {
 		intoFn: hexIntoUint64,
 	}
 }
+
+func DebugTraceTransaction(txHash common.Hash) CallBuilder[*CallFrame] {
+	return CallBuilder[*CallFrame]{
+		method: "debug_traceTransaction",
+		params: []any{txHash, map[string]any{"tracer": "callTracer"}},
+	}
+}
+
+// DebugTraceTransactionStructLogs traces the transaction with the default struct
+// logger with stack, memory and storage capture disabled.
+func DebugTraceTransactionStructLogs(txHash common.Hash) CallBuilder[*StructLogTrace] {
+	return CallBuilder[*StructLogTrace]{
+		method: "debug_traceTransaction",
+		params: []any{txHash, map[string]any{
+			"disableStack":   true,
+			"disableStorage": true,
+			"enableMemory":   false,
+		}},
+	}
+}
@@ -47,6 +47,32 @@ func WithBreaker(br breaker.Breaker) Option {
 // 	}
 // }
 
+// WithConcurrencyLimit limits the number of concurrent requests of the provider
+// to n. The waiting calls are sent by Priority.
+func WithConcurrencyLimit(n int) Option {
+	return func(p *Provider) {
+		if p.scheduler == nil {
+			p.scheduler = newScheduler()
+		}
+		p.scheduler.maxConcurrency = n
+	}
+}
+
+// WithRateLimit limits the requests of the provider to requestsPerSecond, with
+// bursts of up to burst requests. The waiting calls are sent by Priority.
+func WithRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(p *Provider) {
+		if p.scheduler == nil {
+			p.scheduler = newScheduler()
+		}
+		if burst < 1 {
+			burst = 1
+		}
+		p.scheduler.rate = requestsPerSecond
+		p.scheduler.burst = float64(burst)
+	}
+}
+
 func WithJWTAuthorization(jwtToken string) Option {
 	return func(p *Provider) {
 		p.jwtToken = jwtToken
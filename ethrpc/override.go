@@ -0,0 +1,70 @@
+package ethrpc
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/rpc"
+)
+
+// OverrideAccount is the state of an account to override in an eth_call. State
+// replaces the whole storage of the account, while StateDiff only replaces the
+// given slots.
+type OverrideAccount struct {
+	Nonce     *uint64
+	Code      []byte
+	Balance   *big.Int
+	State     map[common.Hash]common.Hash
+	StateDiff map[common.Hash]common.Hash
+}
+
+// StateOverride is the state to override in an eth_call, by account.
+type StateOverride map[common.Address]OverrideAccount
+
+func (a OverrideAccount) MarshalJSON() ([]byte, error) {
+	type account struct {
+		Nonce     *hexutil.Uint64             `json:"nonce,omitempty"`
+		Code      hexutil.Bytes               `json:"code,omitempty"`
+		Balance   *hexutil.Big                `json:"balance,omitempty"`
+		State     map[common.Hash]common.Hash `json:"state,omitempty"`
+		StateDiff map[common.Hash]common.Hash `json:"stateDiff,omitempty"`
+	}
+	return json.Marshal(account{
+		Nonce:     (*hexutil.Uint64)(a.Nonce),
+		Code:      a.Code,
+		Balance:   (*hexutil.Big)(a.Balance),
+		State:     a.State,
+		StateDiff: a.StateDiff,
+	})
+}
+
+// CallContractWithOverride is an eth_call with the state override, at the block
+// number or tag of blockNum.
+func CallContractWithOverride(msg ethereum.CallMsg, blockNum *big.Int, override StateOverride) CallBuilder[[]byte] {
+	params := []any{toCallArg(msg), toBlockNumArg(blockNum)}
+	if len(override) > 0 {
+		params = append(params, override)
+	}
+	return CallBuilder[[]byte]{
+		method: "eth_call",
+		params: params,
+		intoFn: hexIntoBytes,
+	}
+}
+
+// CallContractAtHashWithOverride is an eth_call with the state override, at the
+// block of blockHash.
+func CallContractAtHashWithOverride(msg ethereum.CallMsg, blockHash common.Hash, override StateOverride) CallBuilder[[]byte] {
+	params := []any{toCallArg(msg), rpc.BlockNumberOrHashWithHash(blockHash, false)}
+	if len(override) > 0 {
+		params = append(params, override)
+	}
+	return CallBuilder[[]byte]{
+		method: "eth_call",
+		params: params,
+		intoFn: hexIntoBytes,
+	}
+}
@@ -0,0 +1,186 @@
+package ethrpc
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Priority of the calls of a Provider with a concurrency or rate limit. When the
+// limits are reached, the calls wait, and the waiting calls of the highest
+// priority are sent first.
+type Priority int
+
+const (
+	// PriorityLow is for bulk work, ie. backfills and historical log queries.
+	PriorityLow Priority = iota
+
+	// PriorityNormal is the priority of the calls without a priority.
+	PriorityNormal
+
+	// PriorityHigh is for latency sensitive calls, ie. receipt polling.
+	PriorityHigh
+
+	// PriorityCritical is for transaction submission, and is the priority of
+	// the calls sending transactions without a priority.
+	PriorityCritical
+)
+
+const numPriorities = int(PriorityCritical) + 1
+
+type priorityKey struct{}
+
+// WithPriority returns a context sending the calls of a Provider with the
+// priority p.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFromContext returns the priority of the context, and false when it
+// has none.
+func PriorityFromContext(ctx context.Context) (Priority, bool) {
+	p, ok := ctx.Value(priorityKey{}).(Priority)
+	return p, ok
+}
+
+// callsPriority returns the priority of the calls sent with ctx.
+func callsPriority(ctx context.Context, calls []Call) Priority {
+	if p, ok := PriorityFromContext(ctx); ok {
+		if p < PriorityLow {
+			return PriorityLow
+		}
+		if p > PriorityCritical {
+			return PriorityCritical
+		}
+		return p
+	}
+	for _, call := range calls {
+		if call.request.Method == "eth_sendRawTransaction" || call.request.Method == "eth_sendTransaction" {
+			return PriorityCritical
+		}
+	}
+	return PriorityNormal
+}
+
+// scheduler limits the number of concurrent requests and the rate of the
+// requests of a Provider, and grants the waiting requests by priority, then in
+// order of arrival.
+type scheduler struct {
+	maxConcurrency int
+	inflight       int
+
+	// token bucket of the rate limit
+	rate   float64 // tokens per second, 0 for no limit
+	burst  float64
+	tokens float64
+	last   time.Time
+	timer  *time.Timer
+
+	queues [numPriorities][]*schedulerWaiter
+	now    func() time.Time
+	mu     sync.Mutex
+}
+
+type schedulerWaiter struct {
+	ch      chan struct{}
+	granted bool
+}
+
+func newScheduler() *scheduler {
+	return &scheduler{now: time.Now}
+}
+
+// acquire waits for the limits to allow a request of priority p.
+func (s *scheduler) acquire(ctx context.Context, p Priority) error {
+	w := &schedulerWaiter{ch: make(chan struct{})}
+
+	s.mu.Lock()
+	s.queues[p] = append(s.queues[p], w)
+	s.dispatch()
+	s.mu.Unlock()
+
+	select {
+	case <-w.ch:
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if w.granted {
+		s.inflight--
+		s.dispatch()
+		return ctx.Err()
+	}
+	for i, x := range s.queues[p] {
+		if x == w {
+			s.queues[p] = append(s.queues[p][:i], s.queues[p][i+1:]...)
+			break
+		}
+	}
+	return ctx.Err()
+}
+
+// release ends a request granted by acquire.
+func (s *scheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inflight--
+	s.dispatch()
+}
+
+// dispatch grants the waiting requests allowed by the limits. It must be
+// called with the lock held.
+func (s *scheduler) dispatch() {
+	for p := numPriorities - 1; p >= 0; p-- {
+		for len(s.queues[p]) > 0 {
+			if s.maxConcurrency > 0 && s.inflight >= s.maxConcurrency {
+				return
+			}
+			if !s.takeToken() {
+				return
+			}
+
+			w := s.queues[p][0]
+			s.queues[p] = s.queues[p][1:]
+			s.inflight++
+			w.granted = true
+			close(w.ch)
+		}
+	}
+}
+
+// takeToken takes a token of the rate limit, or schedules a dispatch for when
+// the next token is available. It must be called with the lock held.
+func (s *scheduler) takeToken() bool {
+	if s.rate <= 0 {
+		return true
+	}
+
+	now := s.now()
+	if s.last.IsZero() {
+		s.tokens = s.burst
+	} else {
+		s.tokens += now.Sub(s.last).Seconds() * s.rate
+		if s.tokens > s.burst {
+			s.tokens = s.burst
+		}
+	}
+	s.last = now
+
+	if s.tokens >= 1 {
+		s.tokens--
+		return true
+	}
+
+	if s.timer == nil {
+		wait := time.Duration((1 - s.tokens) / s.rate * float64(time.Second))
+		s.timer = time.AfterFunc(wait, func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.timer = nil
+			s.dispatch()
+		})
+	}
+	return false
+}
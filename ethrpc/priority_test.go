@@ -0,0 +1,154 @@
+package ethrpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc/jsonrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitQueued waits for n requests to wait on the scheduler.
+func waitQueued(t *testing.T, s *scheduler, n int) {
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		queued := 0
+		for _, q := range s.queues {
+			queued += len(q)
+		}
+		return queued == n
+	}, time.Second, time.Millisecond)
+}
+
+func TestSchedulerPriority(t *testing.T) {
+	ctx := context.Background()
+	s := newScheduler()
+	s.maxConcurrency = 1
+	require.NoError(t, s.acquire(ctx, PriorityNormal))
+
+	var order []Priority
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i, p := range []Priority{PriorityLow, PriorityLow, PriorityNormal, PriorityCritical, PriorityHigh} {
+		wg.Add(1)
+		go func(p Priority) {
+			defer wg.Done()
+			require.NoError(t, s.acquire(ctx, p))
+			mu.Lock()
+			order = append(order, p)
+			mu.Unlock()
+			s.release()
+		}(p)
+		waitQueued(t, s, i+1)
+	}
+	s.release()
+	wg.Wait()
+
+	assert.Equal(t, []Priority{PriorityCritical, PriorityHigh, PriorityNormal, PriorityLow, PriorityLow}, order)
+}
+
+func TestSchedulerCancel(t *testing.T) {
+	s := newScheduler()
+	s.maxConcurrency = 1
+	require.NoError(t, s.acquire(context.Background(), PriorityNormal))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error)
+	go func() { errCh <- s.acquire(ctx, PriorityHigh) }()
+	waitQueued(t, s, 1)
+	cancel()
+	assert.ErrorIs(t, <-errCh, context.Canceled)
+	waitQueued(t, s, 0)
+
+	s.release()
+	assert.Equal(t, 0, s.inflight)
+}
+
+func TestSchedulerRateLimit(t *testing.T) {
+	ctx := context.Background()
+	s := newScheduler()
+	s.rate, s.burst = 50, 2
+
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		require.NoError(t, s.acquire(ctx, PriorityNormal))
+		s.release()
+	}
+	assert.Less(t, time.Since(start), 10*time.Millisecond, "burst is not limited")
+
+	s.mu.Lock()
+	s.tokens, s.last = 0, time.Now()
+	s.mu.Unlock()
+
+	// the waiting calls are granted by priority as the tokens refill
+	var order []Priority
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i, p := range []Priority{PriorityLow, PriorityCritical} {
+		wg.Add(1)
+		go func(p Priority) {
+			defer wg.Done()
+			require.NoError(t, s.acquire(ctx, p))
+			mu.Lock()
+			order = append(order, p)
+			mu.Unlock()
+			s.release()
+		}(p)
+		waitQueued(t, s, i+1)
+	}
+	wg.Wait()
+	assert.Equal(t, []Priority{PriorityCritical, PriorityLow}, order)
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+}
+
+func TestCallsPriority(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, PriorityNormal, callsPriority(ctx, []Call{BlockNumber().Into(new(uint64))}))
+	assert.Equal(t, PriorityCritical, callsPriority(ctx, []Call{SendRawTransaction("0x00").Into(nil)}))
+	assert.Equal(t, PriorityLow, callsPriority(WithPriority(ctx, PriorityLow), []Call{SendRawTransaction("0x00").Into(nil)}))
+}
+
+func TestProviderConcurrencyLimit(t *testing.T) {
+	var inflight, maxInflight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inflight.Add(1)
+		defer inflight.Add(-1)
+		for {
+			max := maxInflight.Load()
+			if n <= max || maxInflight.CompareAndSwap(max, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		var req jsonrpc.Message
+		json.NewDecoder(r.Body).Decode(&req)
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x1"}`, req.ID)
+	}))
+	defer server.Close()
+
+	provider, err := NewProvider(server.URL, WithConcurrencyLimit(2), WithRateLimit(1000, 10))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			num, err := provider.BlockNumber(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, uint64(1), num)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), maxInflight.Load())
+}
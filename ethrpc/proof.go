@@ -0,0 +1,308 @@
+package ethrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/ethkit/go-ethereum/rlp"
+)
+
+var ErrInvalidProof = errors.New("ethrpc: invalid merkle proof")
+
+// AccountProof is the result of eth_getProof, see EIP-1186.
+type AccountProof struct {
+	Address      common.Address  `json:"address"`
+	AccountProof [][]byte        `json:"accountProof"`
+	Balance      *big.Int        `json:"balance"`
+	CodeHash     common.Hash     `json:"codeHash"`
+	Nonce        uint64          `json:"nonce"`
+	StorageHash  common.Hash     `json:"storageHash"`
+	StorageProof []*StorageProof `json:"storageProof"`
+}
+
+// StorageProof is the proof of a storage slot of an AccountProof.
+type StorageProof struct {
+	Key   common.Hash `json:"key"`
+	Value *big.Int    `json:"value"`
+	Proof [][]byte    `json:"proof"`
+}
+
+// rpcAccountProof is a copy of AccountProof with hex-encoded fields.
+type rpcAccountProof struct {
+	Address      common.Address     `json:"address"`
+	AccountProof []hexutil.Bytes    `json:"accountProof"`
+	Balance      *hexutil.Big       `json:"balance"`
+	CodeHash     common.Hash        `json:"codeHash"`
+	Nonce        hexutil.Uint64     `json:"nonce"`
+	StorageHash  common.Hash        `json:"storageHash"`
+	StorageProof []*rpcStorageProof `json:"storageProof"`
+}
+
+type rpcStorageProof struct {
+	Key   hexutil.Bytes   `json:"key"`
+	Value *hexutil.Big    `json:"value"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+func (p *AccountProof) MarshalJSON() ([]byte, error) {
+	proof := rpcAccountProof{
+		Address:      p.Address,
+		AccountProof: toHexBytes(p.AccountProof),
+		Balance:      (*hexutil.Big)(p.Balance),
+		CodeHash:     p.CodeHash,
+		Nonce:        hexutil.Uint64(p.Nonce),
+		StorageHash:  p.StorageHash,
+	}
+	for _, s := range p.StorageProof {
+		proof.StorageProof = append(proof.StorageProof, &rpcStorageProof{
+			Key:   s.Key.Bytes(),
+			Value: (*hexutil.Big)(s.Value),
+			Proof: toHexBytes(s.Proof),
+		})
+	}
+	return json.Marshal(proof)
+}
+
+func (p *AccountProof) UnmarshalJSON(data []byte) error {
+	var proof rpcAccountProof
+	if err := json.Unmarshal(data, &proof); err != nil {
+		return err
+	}
+
+	*p = AccountProof{
+		Address:      proof.Address,
+		AccountProof: fromHexBytes(proof.AccountProof),
+		Balance:      (*big.Int)(proof.Balance),
+		CodeHash:     proof.CodeHash,
+		Nonce:        uint64(proof.Nonce),
+		StorageHash:  proof.StorageHash,
+	}
+	for _, s := range proof.StorageProof {
+		// nodes return the key as requested, which may be not be left-padded
+		if len(s.Key) > common.HashLength {
+			return fmt.Errorf("ethrpc: invalid storage proof key %s", s.Key)
+		}
+		p.StorageProof = append(p.StorageProof, &StorageProof{
+			Key:   common.BytesToHash(s.Key),
+			Value: (*big.Int)(s.Value),
+			Proof: fromHexBytes(s.Proof),
+		})
+	}
+	return nil
+}
+
+// Verify verifies the account proof against the state root of a block, and the
+// storage proofs against the storage hash of the account.
+func (p *AccountProof) Verify(stateRoot common.Hash) error {
+	value, err := VerifyMerkleProof(stateRoot, crypto.Keccak256(p.Address.Bytes()), p.AccountProof)
+	if err != nil {
+		return fmt.Errorf("%w: account %s: %v", ErrInvalidProof, p.Address, err)
+	}
+
+	balance := p.Balance
+	if balance == nil {
+		balance = new(big.Int)
+	}
+
+	if value == nil {
+		// proof of absence, the account must be empty
+		if p.Nonce != 0 || balance.Sign() != 0 ||
+			(p.CodeHash != (common.Hash{}) && p.CodeHash != types.EmptyCodeHash) ||
+			(p.StorageHash != (common.Hash{}) && p.StorageHash != types.EmptyRootHash) {
+			return fmt.Errorf("%w: account %s does not exist", ErrInvalidProof, p.Address)
+		}
+	} else {
+		var account types.StateAccount
+		if err := rlp.DecodeBytes(value, &account); err != nil {
+			return fmt.Errorf("%w: account %s: %v", ErrInvalidProof, p.Address, err)
+		}
+		if account.Nonce != p.Nonce || account.Balance.ToBig().Cmp(balance) != 0 ||
+			common.BytesToHash(account.CodeHash) != p.CodeHash || account.Root != p.StorageHash {
+			return fmt.Errorf("%w: account %s does not match the proof", ErrInvalidProof, p.Address)
+		}
+	}
+
+	for _, s := range p.StorageProof {
+		if err := s.Verify(p.StorageHash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Verify verifies the storage proof against the storage hash of the account.
+func (s *StorageProof) Verify(storageHash common.Hash) error {
+	value := s.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	if storageHash == (common.Hash{}) || storageHash == types.EmptyRootHash {
+		if value.Sign() != 0 {
+			return fmt.Errorf("%w: slot %s of an empty storage is not zero", ErrInvalidProof, s.Key)
+		}
+		return nil
+	}
+
+	data, err := VerifyMerkleProof(storageHash, crypto.Keccak256(s.Key.Bytes()), s.Proof)
+	if err != nil {
+		return fmt.Errorf("%w: slot %s: %v", ErrInvalidProof, s.Key, err)
+	}
+
+	var proven []byte
+	if data != nil {
+		if err := rlp.DecodeBytes(data, &proven); err != nil {
+			return fmt.Errorf("%w: slot %s: %v", ErrInvalidProof, s.Key, err)
+		}
+	}
+	if new(big.Int).SetBytes(proven).Cmp(value) != 0 {
+		return fmt.Errorf("%w: slot %s does not match the proof", ErrInvalidProof, s.Key)
+	}
+	return nil
+}
+
+// VerifyMerkleProof verifies a merkle patricia trie proof of the key, and
+// returns the proven value, or nil when the proof is a proof of absence of the
+// key. For the state and storage tries, key is the keccak256 of the address or
+// slot.
+func VerifyMerkleProof(root common.Hash, key []byte, proof [][]byte) ([]byte, error) {
+	nodes := make(map[common.Hash][]byte, len(proof))
+	for _, node := range proof {
+		nodes[crypto.Keccak256Hash(node)] = node
+	}
+
+	path := keyToNibbles(key)
+	node, ok := nodes[root]
+	if !ok {
+		return nil, fmt.Errorf("missing root node %s", root)
+	}
+
+	for {
+		elems, err := splitNode(node)
+		if err != nil {
+			return nil, err
+		}
+
+		var child []byte
+		switch len(elems) {
+		case 17:
+			if len(path) == 0 {
+				return nodeValue(elems[16])
+			}
+			child, path = elems[path[0]], path[1:]
+
+		case 2:
+			compact, err := nodeValue(elems[0])
+			if err != nil {
+				return nil, err
+			}
+			nibbles, leaf := compactToNibbles(compact)
+			if leaf {
+				if !bytes.Equal(nibbles, path) {
+					return nil, nil
+				}
+				return nodeValue(elems[1])
+			}
+			if len(path) < len(nibbles) || !bytes.Equal(nibbles, path[:len(nibbles)]) {
+				return nil, nil
+			}
+			child, path = elems[1], path[len(nibbles):]
+
+		default:
+			return nil, fmt.Errorf("invalid node with %d elements", len(elems))
+		}
+
+		// resolve the child node, which is either embedded or referenced by hash
+		kind, content, _, err := rlp.Split(child)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case kind == rlp.List:
+			node = child
+		case len(content) == 0:
+			return nil, nil
+		case len(content) == common.HashLength:
+			if node, ok = nodes[common.BytesToHash(content)]; !ok {
+				return nil, fmt.Errorf("missing node %x", content)
+			}
+		default:
+			return nil, fmt.Errorf("invalid node reference %x", content)
+		}
+	}
+}
+
+// splitNode returns the raw rlp elements of a trie node.
+func splitNode(node []byte) ([][]byte, error) {
+	content, _, err := rlp.SplitList(node)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node: %w", err)
+	}
+	var elems [][]byte
+	for len(content) > 0 {
+		_, _, rest, err := rlp.Split(content)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node: %w", err)
+		}
+		elems = append(elems, content[:len(content)-len(rest)])
+		content = rest
+	}
+	return elems, nil
+}
+
+// nodeValue returns the content of a string element, or nil if it is empty.
+func nodeValue(elem []byte) ([]byte, error) {
+	content, _, err := rlp.SplitString(elem)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node value: %w", err)
+	}
+	if len(content) == 0 {
+		return nil, nil
+	}
+	return content, nil
+}
+
+func keyToNibbles(key []byte) []byte {
+	nibbles := make([]byte, len(key)*2)
+	for i, b := range key {
+		nibbles[i*2], nibbles[i*2+1] = b>>4, b&0x0f
+	}
+	return nibbles
+}
+
+// compactToNibbles decodes the hex-prefix encoded path of a leaf or extension
+// node.
+func compactToNibbles(compact []byte) ([]byte, bool) {
+	if len(compact) == 0 {
+		return nil, false
+	}
+	nibbles := keyToNibbles(compact)
+	leaf := nibbles[0] >= 2
+	if nibbles[0]&1 == 1 {
+		// odd length, the first nibble is part of the path
+		return nibbles[1:], leaf
+	}
+	return nibbles[2:], leaf
+}
+
+func toHexBytes(values [][]byte) []hexutil.Bytes {
+	out := make([]hexutil.Bytes, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
+
+func fromHexBytes(values []hexutil.Bytes) [][]byte {
+	out := make([][]byte, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
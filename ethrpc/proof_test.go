@@ -0,0 +1,135 @@
+package ethrpc_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/ethkit/go-ethereum/rlp"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func nibbles(key []byte) []byte {
+	out := make([]byte, 0, len(key)*2)
+	for _, b := range key {
+		out = append(out, b>>4, b&0x0f)
+	}
+	return out
+}
+
+// hexPrefix encodes the nibbles of a leaf path, see the yellow paper appendix C.
+func hexPrefix(path []byte) []byte {
+	flag := byte(2)
+	if len(path)%2 == 1 {
+		flag++
+		path = append([]byte{flag}, path...)
+	} else {
+		path = append([]byte{flag, 0}, path...)
+	}
+	out := make([]byte, len(path)/2)
+	for i := range out {
+		out[i] = path[i*2]<<4 | path[i*2+1]
+	}
+	return out
+}
+
+func leafNode(t *testing.T, path []byte, value []byte) []byte {
+	node, err := rlp.EncodeToBytes([][]byte{hexPrefix(path), value})
+	require.NoError(t, err)
+	return node
+}
+
+func TestVerifyAccountProof(t *testing.T) {
+	// find two accounts whose hashed keys start with a different nibble
+	var addrs []common.Address
+	used := map[byte]bool{}
+	for i := 1; len(addrs) < 3; i++ {
+		addr := common.BigToAddress(big.NewInt(int64(i)))
+		first := nibbles(crypto.Keccak256(addr.Bytes()))[0]
+		if !used[first] {
+			used[first] = true
+			addrs = append(addrs, addr)
+		}
+	}
+
+	accountValue := func(nonce uint64, balance int64) []byte {
+		data, err := rlp.EncodeToBytes(&types.StateAccount{
+			Nonce:    nonce,
+			Balance:  uint256.NewInt(uint64(balance)),
+			Root:     types.EmptyRootHash,
+			CodeHash: types.EmptyCodeHash.Bytes(),
+		})
+		require.NoError(t, err)
+		return data
+	}
+
+	// a branch node with a leaf for each of the first two accounts
+	branch := make([]any, 17)
+	for i := range branch {
+		branch[i] = []byte{}
+	}
+	leaves := map[common.Address][]byte{}
+	for i, addr := range addrs[:2] {
+		path := nibbles(crypto.Keccak256(addr.Bytes()))
+		leaf := leafNode(t, path[1:], accountValue(uint64(i+1), int64(1000*(i+1))))
+		leaves[addr] = leaf
+		branch[path[0]] = crypto.Keccak256(leaf)
+	}
+	branchNode, err := rlp.EncodeToBytes(branch)
+	require.NoError(t, err)
+	root := crypto.Keccak256Hash(branchNode)
+
+	proof := &ethrpc.AccountProof{
+		Address:      addrs[1],
+		AccountProof: [][]byte{branchNode, leaves[addrs[1]]},
+		Balance:      big.NewInt(2000),
+		CodeHash:     types.EmptyCodeHash,
+		Nonce:        2,
+		StorageHash:  types.EmptyRootHash,
+		StorageProof: []*ethrpc.StorageProof{{Key: common.Hash{}, Value: big.NewInt(0)}},
+	}
+	require.NoError(t, proof.Verify(root))
+
+	// the proof survives a JSON roundtrip, as returned by eth_getProof
+	data, err := json.Marshal(proof)
+	require.NoError(t, err)
+	var decoded *ethrpc.AccountProof
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.NoError(t, decoded.Verify(root))
+
+	// tampered values are rejected
+	decoded.Balance = big.NewInt(1)
+	assert.ErrorIs(t, decoded.Verify(root), ethrpc.ErrInvalidProof)
+	assert.ErrorIs(t, proof.Verify(common.Hash{1}), ethrpc.ErrInvalidProof)
+
+	// proof of absence of the third account
+	absent := &ethrpc.AccountProof{Address: addrs[2], AccountProof: [][]byte{branchNode}, Balance: big.NewInt(0)}
+	require.NoError(t, absent.Verify(root))
+	absent.Balance = big.NewInt(1)
+	assert.ErrorIs(t, absent.Verify(root), ethrpc.ErrInvalidProof)
+}
+
+func TestVerifyStorageProof(t *testing.T) {
+	slot := common.HexToHash("0x01")
+	value, err := rlp.EncodeToBytes(big.NewInt(42).Bytes())
+	require.NoError(t, err)
+
+	leaf := leafNode(t, nibbles(crypto.Keccak256(slot.Bytes())), value)
+	storageHash := crypto.Keccak256Hash(leaf)
+
+	proof := &ethrpc.StorageProof{Key: slot, Value: big.NewInt(42), Proof: [][]byte{leaf}}
+	require.NoError(t, proof.Verify(storageHash))
+
+	proof.Value = big.NewInt(43)
+	assert.ErrorIs(t, proof.Verify(storageHash), ethrpc.ErrInvalidProof)
+
+	// a different slot is proven absent, so its value must be zero
+	absent := &ethrpc.StorageProof{Key: common.HexToHash("0x02"), Value: big.NewInt(0), Proof: [][]byte{leaf}}
+	require.NoError(t, absent.Verify(storageHash))
+}
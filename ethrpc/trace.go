@@ -0,0 +1,112 @@
+package ethrpc
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+)
+
+// CallFrame is a single call frame as returned by the geth `callTracer`,
+// see https://geth.ethereum.org/docs/developers/evm-tracing/built-in-tracers#call-tracer
+type CallFrame struct {
+	Type         string          `json:"type"`
+	From         common.Address  `json:"from"`
+	To           *common.Address `json:"to,omitempty"`
+	Value        *big.Int        `json:"value,omitempty"`
+	Gas          uint64          `json:"gas"`
+	GasUsed      uint64          `json:"gasUsed"`
+	Input        []byte          `json:"input"`
+	Output       []byte          `json:"output,omitempty"`
+	Error        string          `json:"error,omitempty"`
+	RevertReason string          `json:"revertReason,omitempty"`
+	Calls        []*CallFrame    `json:"calls,omitempty"`
+}
+
+// rpcCallFrame is a copy of CallFrame with hex-encoded fields.
+type rpcCallFrame struct {
+	Type         string          `json:"type"`
+	From         common.Address  `json:"from"`
+	To           *common.Address `json:"to,omitempty"`
+	Value        *hexutil.Big    `json:"value,omitempty"`
+	Gas          hexutil.Uint64  `json:"gas"`
+	GasUsed      hexutil.Uint64  `json:"gasUsed"`
+	Input        hexutil.Bytes   `json:"input"`
+	Output       hexutil.Bytes   `json:"output,omitempty"`
+	Error        string          `json:"error,omitempty"`
+	RevertReason string          `json:"revertReason,omitempty"`
+	Calls        []*CallFrame    `json:"calls,omitempty"`
+}
+
+func (f *CallFrame) MarshalJSON() ([]byte, error) {
+	return json.Marshal(rpcCallFrame{
+		Type:         f.Type,
+		From:         f.From,
+		To:           f.To,
+		Value:        (*hexutil.Big)(f.Value),
+		Gas:          hexutil.Uint64(f.Gas),
+		GasUsed:      hexutil.Uint64(f.GasUsed),
+		Input:        f.Input,
+		Output:       f.Output,
+		Error:        f.Error,
+		RevertReason: f.RevertReason,
+		Calls:        f.Calls,
+	})
+}
+
+func (f *CallFrame) UnmarshalJSON(data []byte) error {
+	var frame rpcCallFrame
+	if err := json.Unmarshal(data, &frame); err != nil {
+		return err
+	}
+	*f = CallFrame{
+		Type:         frame.Type,
+		From:         frame.From,
+		To:           frame.To,
+		Value:        (*big.Int)(frame.Value),
+		Gas:          uint64(frame.Gas),
+		GasUsed:      uint64(frame.GasUsed),
+		Input:        frame.Input,
+		Output:       frame.Output,
+		Error:        frame.Error,
+		RevertReason: frame.RevertReason,
+		Calls:        frame.Calls,
+	}
+	return nil
+}
+
+// Walk visits the frame and all of its nested calls depth-first. The depth of
+// the root frame is 0.
+func (f *CallFrame) Walk(fn func(frame *CallFrame, depth int)) {
+	f.walk(fn, 0)
+}
+
+func (f *CallFrame) walk(fn func(frame *CallFrame, depth int), depth int) {
+	if f == nil {
+		return
+	}
+	fn(f, depth)
+	for _, call := range f.Calls {
+		call.walk(fn, depth+1)
+	}
+}
+
+// StructLogTrace is the result of the default geth struct logger, which
+// includes every executed opcode.
+type StructLogTrace struct {
+	Gas         uint64      `json:"gas"`
+	Failed      bool        `json:"failed"`
+	ReturnValue string      `json:"returnValue"`
+	StructLogs  []StructLog `json:"structLogs"`
+}
+
+// StructLog is a single executed opcode. The depth of the top-level call is 1.
+type StructLog struct {
+	PC      uint64 `json:"pc"`
+	Op      string `json:"op"`
+	Gas     uint64 `json:"gas"`
+	GasCost uint64 `json:"gasCost"`
+	Depth   int    `json:"depth"`
+	Error   string `json:"error,omitempty"`
+}
@@ -0,0 +1,126 @@
+package ethrpc
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+// TransportOptions are the settings of the http connections of a provider, see
+// DefaultTransportOptions and HighThroughputTransportOptions. The zero values are
+// the ones of a zero http.Transport, ie. without limits nor timeouts.
+type TransportOptions struct {
+	// MaxIdleConns is the max number of idle connections, of all the hosts.
+	MaxIdleConns int
+
+	// MaxIdleConnsPerHost is the max number of idle connections kept per host. The
+	// default of http.Transport is 2, which closes the connections of the
+	// concurrent requests above it, ie. their churn at high request volumes.
+	MaxIdleConnsPerHost int
+
+	// MaxConnsPerHost limits the number of connections per host, unlimited when 0.
+	MaxConnsPerHost int
+
+	// IdleConnTimeout is the time an idle connection is kept.
+	IdleConnTimeout time.Duration
+
+	// DialTimeout and KeepAlive of the tcp connections.
+	DialTimeout time.Duration
+	KeepAlive   time.Duration
+
+	// TLSHandshakeTimeout of the https connections.
+	TLSHandshakeTimeout time.Duration
+
+	// ResponseHeaderTimeout is the time to wait for the headers of a response,
+	// after the request is written.
+	ResponseHeaderTimeout time.Duration
+
+	// EnableHTTP2 negotiates HTTP/2 with the https nodes. HTTP/2 multiplexes the
+	// requests on a single connection, where a slow response blocks the others,
+	// ie. head-of-line blocking, so HTTP/1.1 with a large pool is preferred at high
+	// request volumes.
+	EnableHTTP2 bool
+
+	// RequestTimeout is the default timeout of a request of the provider, applied
+	// when the context of the call has no deadline. Unlimited when 0.
+	RequestTimeout time.Duration
+}
+
+var (
+	// DefaultTransportOptions are the settings of http.DefaultTransport, with a
+	// larger pool of idle connections per host.
+	DefaultTransportOptions = TransportOptions{
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
+		DialTimeout:         30 * time.Second,
+		KeepAlive:           30 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+		EnableHTTP2:         true,
+		RequestTimeout:      60 * time.Second,
+	}
+
+	// HighThroughputTransportOptions are the settings of a provider of many
+	// concurrent requests to the same node, over a large pool of HTTP/1.1
+	// connections kept alive.
+	HighThroughputTransportOptions = TransportOptions{
+		MaxIdleConns:          1024,
+		MaxIdleConnsPerHost:   256,
+		IdleConnTimeout:       120 * time.Second,
+		DialTimeout:           5 * time.Second,
+		KeepAlive:             30 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
+		EnableHTTP2:           false,
+		RequestTimeout:        30 * time.Second,
+	}
+)
+
+// NewHTTPTransport returns a new http transport of the options.
+func NewHTTPTransport(opts TransportOptions) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   opts.DialTimeout,
+		KeepAlive: opts.KeepAlive,
+	}
+	t := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		MaxIdleConns:          opts.MaxIdleConns,
+		MaxIdleConnsPerHost:   opts.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       opts.MaxConnsPerHost,
+		IdleConnTimeout:       opts.IdleConnTimeout,
+		TLSHandshakeTimeout:   opts.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: opts.ResponseHeaderTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+		ForceAttemptHTTP2:     opts.EnableHTTP2,
+	}
+	if !opts.EnableHTTP2 {
+		// a non-nil empty map disables HTTP/2
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return t
+}
+
+// NewHTTPClient returns a new http client of the options. The RequestTimeout is
+// not a timeout of the client, see WithTransport.
+func NewHTTPClient(opts TransportOptions) *http.Client {
+	return &http.Client{Transport: NewHTTPTransport(opts)}
+}
+
+// WithTransport sets the http client of the provider to a new client of the
+// options, and its default request timeout to opts.RequestTimeout.
+func WithTransport(opts TransportOptions) Option {
+	return func(p *Provider) {
+		p.httpClient = NewHTTPClient(opts)
+		p.requestTimeout = opts.RequestTimeout
+	}
+}
+
+// WithRequestTimeout sets the default timeout of the requests of the provider,
+// applied when the context of the call has no deadline.
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(p *Provider) {
+		p.requestTimeout = timeout
+	}
+}
@@ -0,0 +1,90 @@
+package ethrpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethrpc/jsonrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportOptions(t *testing.T) {
+	transport := NewHTTPTransport(HighThroughputTransportOptions)
+	assert.Equal(t, 256, transport.MaxIdleConnsPerHost)
+	assert.False(t, transport.ForceAttemptHTTP2)
+	assert.NotNil(t, transport.TLSNextProto)
+	assert.Empty(t, transport.TLSNextProto)
+
+	transport = NewHTTPTransport(DefaultTransportOptions)
+	assert.True(t, transport.ForceAttemptHTTP2)
+	assert.Nil(t, transport.TLSNextProto)
+}
+
+func TestTransportConnectionReuse(t *testing.T) {
+	var conns int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		var req jsonrpc.Message
+		json.NewDecoder(r.Body).Decode(&req)
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x1"}`, req.ID)
+	}))
+	server.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	p, err := NewProvider(server.URL, WithTransport(HighThroughputTransportOptions))
+	require.NoError(t, err)
+
+	// the connections of the concurrent requests are kept idle, and reused
+	for round := 0; round < 3; round++ {
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := p.Do(context.Background(), ChainID().Into(nil))
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+	}
+	assert.LessOrEqual(t, atomic.LoadInt32(&conns), int32(20))
+}
+
+func TestRequestTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+		var req jsonrpc.Message
+		json.NewDecoder(r.Body).Decode(&req)
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x1"}`, req.ID)
+	}))
+	defer server.Close()
+
+	p, err := NewProvider(server.URL, WithTransport(DefaultTransportOptions), WithRequestTimeout(20*time.Millisecond))
+	require.NoError(t, err)
+
+	_, err = p.Do(context.Background(), ChainID().Into(nil))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the deadline of the call has precedence
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = p.Do(ctx, ChainID().Into(nil))
+	assert.NoError(t, err)
+}
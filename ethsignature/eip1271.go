@@ -0,0 +1,53 @@
+package ethsignature
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethcontract"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// EIP1271MagicValue is the result of isValidSignature of a valid signature, ie.
+// its selector bytes4(keccak256("isValidSignature(bytes32,bytes)")).
+var EIP1271MagicValue = [4]byte{0x16, 0x26, 0xba, 0x7e}
+
+// ValidateSignature returns whether sig of the digest is of the signer, of the
+// EIP-1271 isValidSignature of the signer when it's a contract, and otherwise
+// of the ecdsa signature of an EOA. The EIP-6492 signatures of counterfactual
+// wallets are verified by ValidateEIP6492Signature.
+func ValidateSignature(ctx context.Context, provider ethrpc.Interface, signer common.Address, digest common.Hash, sig []byte, opts ...Options) (bool, error) {
+	var o Options
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	code, err := provider.CodeAt(ctx, signer, o.BlockNum)
+	if err != nil {
+		return false, fmt.Errorf("ethsignature: failed to get code of %s: %w", signer.Hex(), err)
+	}
+	if len(code) == 0 {
+		return ethcoder.ValidateSignature(signer, digest.Bytes(), sig)
+	}
+	return IsValidSignature(ctx, provider, signer, digest, sig, &ethcontract.CallOpts{BlockNum: o.BlockNum})
+}
+
+// IsValidSignature calls isValidSignature(bytes32,bytes) of the EIP-1271
+// contract with the options, or at the latest block when opts is nil, and
+// returns whether its result is the magic value. The error is of the call, ie.
+// of a contract which reverts for the invalid signatures.
+func IsValidSignature(ctx context.Context, provider ethrpc.Interface, contract common.Address, digest common.Hash, sig []byte, opts *ethcontract.CallOpts) (bool, error) {
+	calldata, err := ethcoder.AbiEncodeMethodCalldata("isValidSignature(bytes32,bytes)", []interface{}{digest, sig})
+	if err != nil {
+		return false, fmt.Errorf("ethsignature: failed to encode isValidSignature: %w", err)
+	}
+	data, err := ethcontract.Call(ctx, provider, contract, calldata, opts)
+	if err != nil {
+		return false, fmt.Errorf("ethsignature: failed to call isValidSignature of %s: %w", contract.Hex(), err)
+	}
+	// the bytes4 result, which is left aligned
+	return len(data) == 32 && bytes.Equal(data[:4], EIP1271MagicValue[:]) && bytes.Equal(data[4:], make([]byte, 28)), nil
+}
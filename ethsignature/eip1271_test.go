@@ -0,0 +1,75 @@
+package ethsignature_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethsignature"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSignature(t *testing.T) {
+	ctx := context.Background()
+	digest := ethcoder.Keccak256Hash([]byte("hello"))
+	wallet := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	walletSig := common.FromHex("0xabcdef")
+
+	provider := &mockProvider{
+		code: map[common.Address][]byte{wallet: {0x60}},
+		call: isValidSignatureOf(wallet, digest, walletSig),
+	}
+
+	valid, err := ethsignature.ValidateSignature(ctx, provider, wallet, digest, walletSig)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, err = ethsignature.ValidateSignature(ctx, provider, wallet, digest, common.FromHex("0x01"))
+	require.NoError(t, err)
+	assert.False(t, valid)
+
+	// the ecdsa signature of an eoa
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sig, err := crypto.Sign(digest.Bytes(), key)
+	require.NoError(t, err)
+	valid, err = ethsignature.ValidateSignature(ctx, provider, crypto.PubkeyToAddress(key.PublicKey), digest, sig)
+	require.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestIsValidSignature(t *testing.T) {
+	ctx := context.Background()
+	digest := ethcoder.Keccak256Hash([]byte("hello"))
+	wallet := common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+	provider := &mockProvider{}
+	for _, c := range []struct {
+		result []byte
+		valid  bool
+	}{
+		{result: common.RightPadBytes(ethsignature.EIP1271MagicValue[:], 32), valid: true},
+		{result: common.LeftPadBytes(ethsignature.EIP1271MagicValue[:], 32)},
+		{result: append(ethsignature.EIP1271MagicValue[:], append(make([]byte, 27), 1)...)},
+		{result: ethsignature.EIP1271MagicValue[:]},
+		{result: nil},
+	} {
+		provider.call = func(msg ethereum.CallMsg) ([]byte, error) {
+			return c.result, nil
+		}
+		valid, err := ethsignature.IsValidSignature(ctx, provider, wallet, digest, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, c.valid, valid, "%x", c.result)
+	}
+
+	provider.call = func(msg ethereum.CallMsg) ([]byte, error) {
+		return nil, errors.New("execution reverted")
+	}
+	_, err := ethsignature.IsValidSignature(ctx, provider, wallet, digest, nil, nil)
+	assert.ErrorContains(t, err, "execution reverted")
+}
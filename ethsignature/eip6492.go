@@ -0,0 +1,147 @@
+// Package ethsignature verifies the signatures of EOAs and smart contract
+// wallets, of EIP-1271 and of the counterfactual wallets of EIP-6492.
+package ethsignature
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethcontract"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// EIP6492MagicSuffix is the suffix of the EIP-6492 wrapped signatures.
+var EIP6492MagicSuffix = common.FromHex("0x6492649264926492649264926492649264926492649264926492649264926492")
+
+// ErrNoValidator is returned when an EIP-6492 signature of a wallet which isn't
+// deployed is verified without a universal validator.
+var ErrNoValidator = errors.New("ethsignature: no universal validator of eip-6492 signatures")
+
+// EIP6492Signature is the signature of a counterfactual wallet, of the factory
+// and calldata which deploy the wallet, ie. create2 factory.
+type EIP6492Signature struct {
+	Factory         common.Address
+	FactoryCalldata []byte
+	Signature       []byte
+}
+
+// Encode returns the wrapped signature, ie.
+// abi.encode(factory, factoryCalldata, signature) ++ magicSuffix.
+func (s EIP6492Signature) Encode() ([]byte, error) {
+	data, err := ethcoder.AbiCoder([]string{"address", "bytes", "bytes"}, []interface{}{s.Factory, s.FactoryCalldata, s.Signature})
+	if err != nil {
+		return nil, fmt.Errorf("ethsignature: failed to encode eip-6492 signature: %w", err)
+	}
+	return append(data, EIP6492MagicSuffix...), nil
+}
+
+// IsEIP6492Signature returns whether sig is a wrapped EIP-6492 signature.
+func IsEIP6492Signature(sig []byte) bool {
+	return len(sig) >= len(EIP6492MagicSuffix) && bytes.HasSuffix(sig, EIP6492MagicSuffix)
+}
+
+// DecodeEIP6492Signature returns the unwrapped EIP-6492 signature.
+func DecodeEIP6492Signature(sig []byte) (*EIP6492Signature, error) {
+	if !IsEIP6492Signature(sig) {
+		return nil, fmt.Errorf("ethsignature: not an eip-6492 signature")
+	}
+	var s EIP6492Signature
+	err := ethcoder.AbiDecoder([]string{"address", "bytes", "bytes"}, sig[:len(sig)-len(EIP6492MagicSuffix)], []interface{}{&s.Factory, &s.FactoryCalldata, &s.Signature})
+	if err != nil {
+		return nil, fmt.Errorf("ethsignature: invalid eip-6492 signature: %w", err)
+	}
+	return &s, nil
+}
+
+// Options are the options of the verification of signatures.
+type Options struct {
+	// BlockNum is the block of the verification, or the latest block when nil.
+	BlockNum *big.Int
+
+	// Validator is the address of a deployed universal validator of EIP-6492,
+	// of isValidSig(address,bytes32,bytes).
+	Validator common.Address
+
+	// ValidatorBytecode is the bytecode of the off-chain validator of EIP-6492,
+	// ie. ValidateSigOffchain, which is called without being deployed when no
+	// Validator is set.
+	ValidatorBytecode []byte
+}
+
+// ValidateEIP6492Signature returns whether sig of the digest is of the signer,
+// where sig is an EIP-6492 signature of a counterfactual wallet, an EIP-1271
+// signature of a deployed contract, or an ecdsa signature of an EOA.
+//
+// The wrapped signatures of wallets which are deployed are verified with
+// isValidSignature, and otherwise with the universal validator of the options,
+// which deploys the wallet in an eth_call.
+func ValidateEIP6492Signature(ctx context.Context, provider ethrpc.Interface, signer common.Address, digest common.Hash, sig []byte, opts ...Options) (bool, error) {
+	var o Options
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	if !IsEIP6492Signature(sig) {
+		return ValidateSignature(ctx, provider, signer, digest, sig, o)
+	}
+
+	code, err := provider.CodeAt(ctx, signer, o.BlockNum)
+	if err != nil {
+		return false, fmt.Errorf("ethsignature: failed to get code of %s: %w", signer.Hex(), err)
+	}
+	wrapped, err := DecodeEIP6492Signature(sig)
+	if err != nil {
+		return false, err
+	}
+	if len(code) != 0 {
+		valid, err := IsValidSignature(ctx, provider, signer, digest, wrapped.Signature, &ethcontract.CallOpts{BlockNum: o.BlockNum})
+		if err == nil && valid {
+			return true, nil
+		}
+		// the wallet may need the calldata of the factory, ie. of an upgrade, so
+		// it's verified by the validator
+	}
+	return validateWithValidator(ctx, provider, signer, digest, sig, o)
+}
+
+// validateWithValidator calls the universal validator with the wrapped sig.
+func validateWithValidator(ctx context.Context, provider ethrpc.Interface, signer common.Address, digest common.Hash, sig []byte, o Options) (bool, error) {
+	if o.Validator != (common.Address{}) {
+		calldata, err := ethcoder.AbiEncodeMethodCalldata("isValidSig(address,bytes32,bytes)", []interface{}{signer, digest, sig})
+		if err != nil {
+			return false, fmt.Errorf("ethsignature: failed to encode isValidSig: %w", err)
+		}
+		data, err := provider.CallContract(ctx, ethereum.CallMsg{To: &o.Validator, Data: calldata}, o.BlockNum)
+		if err != nil {
+			return false, fmt.Errorf("ethsignature: failed to call validator %s: %w", o.Validator.Hex(), err)
+		}
+		var valid bool
+		if err := ethcoder.AbiDecoder([]string{"bool"}, data, []interface{}{&valid}); err != nil {
+			return false, fmt.Errorf("ethsignature: invalid result of validator %s: %x", o.Validator.Hex(), data)
+		}
+		return valid, nil
+	}
+
+	if len(o.ValidatorBytecode) == 0 {
+		return false, ErrNoValidator
+	}
+	args, err := ethcoder.AbiCoder([]string{"address", "bytes32", "bytes"}, []interface{}{signer, digest, sig})
+	if err != nil {
+		return false, fmt.Errorf("ethsignature: failed to encode validator arguments: %w", err)
+	}
+	// the validator returns a byte of its result from its constructor
+	data, err := provider.CallContract(ctx, ethereum.CallMsg{Data: append(append([]byte{}, o.ValidatorBytecode...), args...)}, o.BlockNum)
+	if err != nil {
+		return false, fmt.Errorf("ethsignature: failed to call off-chain validator: %w", err)
+	}
+	if len(data) != 1 || data[0] > 1 {
+		return false, fmt.Errorf("ethsignature: invalid result of off-chain validator: %x", data)
+	}
+	return data[0] == 1, nil
+}
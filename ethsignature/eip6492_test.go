@@ -0,0 +1,142 @@
+package ethsignature_test
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethsignature"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockProvider struct {
+	ethrpc.Interface
+	code map[common.Address][]byte
+	call func(msg ethereum.CallMsg) ([]byte, error)
+}
+
+func (p *mockProvider) CodeAt(ctx context.Context, account common.Address, blockNum *big.Int) ([]byte, error) {
+	return p.code[account], nil
+}
+
+func (p *mockProvider) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNum *big.Int) ([]byte, error) {
+	return p.call(msg)
+}
+
+// isValidSignatureOf returns the eip-1271 results of a wallet of the signature.
+func isValidSignatureOf(wallet common.Address, digest common.Hash, sig []byte) func(msg ethereum.CallMsg) ([]byte, error) {
+	expected, _ := ethcoder.AbiEncodeMethodCalldata("isValidSignature(bytes32,bytes)", []interface{}{digest, sig})
+	return func(msg ethereum.CallMsg) ([]byte, error) {
+		if msg.To != nil && *msg.To == wallet && bytes.Equal(msg.Data, expected) {
+			return common.RightPadBytes(common.FromHex("0x1626ba7e"), 32), nil
+		}
+		return make([]byte, 32), nil
+	}
+}
+
+func TestEIP6492Signature(t *testing.T) {
+	sig := ethsignature.EIP6492Signature{
+		Factory:         common.HexToAddress("0xfac"),
+		FactoryCalldata: common.FromHex("0x12345678"),
+		Signature:       common.FromHex("0xabcdef"),
+	}
+	data, err := sig.Encode()
+	require.NoError(t, err)
+	assert.True(t, ethsignature.IsEIP6492Signature(data))
+	assert.Equal(t, ethsignature.EIP6492MagicSuffix, data[len(data)-32:])
+
+	decoded, err := ethsignature.DecodeEIP6492Signature(data)
+	require.NoError(t, err)
+	assert.Equal(t, sig, *decoded)
+
+	assert.False(t, ethsignature.IsEIP6492Signature(sig.Signature))
+	_, err = ethsignature.DecodeEIP6492Signature(sig.Signature)
+	assert.Error(t, err)
+	_, err = ethsignature.DecodeEIP6492Signature(ethsignature.EIP6492MagicSuffix)
+	assert.Error(t, err)
+}
+
+func TestValidateEIP6492Signature(t *testing.T) {
+	ctx := context.Background()
+	digest := ethcoder.Keccak256Hash([]byte("hello"))
+	wallet := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	validator := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	walletSig := common.FromHex("0xabcdef")
+	wrapped, err := ethsignature.EIP6492Signature{
+		Factory:         common.HexToAddress("0xfac"),
+		FactoryCalldata: common.FromHex("0x12345678"),
+		Signature:       walletSig,
+	}.Encode()
+	require.NoError(t, err)
+
+	t.Run("eoa", func(t *testing.T) {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		sig, err := crypto.Sign(digest.Bytes(), key)
+		require.NoError(t, err)
+
+		provider := &mockProvider{}
+		valid, err := ethsignature.ValidateEIP6492Signature(ctx, provider, crypto.PubkeyToAddress(key.PublicKey), digest, sig)
+		require.NoError(t, err)
+		assert.True(t, valid)
+
+		valid, err = ethsignature.ValidateEIP6492Signature(ctx, provider, wallet, digest, sig)
+		require.NoError(t, err)
+		assert.False(t, valid)
+	})
+
+	t.Run("deployed", func(t *testing.T) {
+		provider := &mockProvider{
+			code: map[common.Address][]byte{wallet: {0x60}},
+			call: isValidSignatureOf(wallet, digest, walletSig),
+		}
+		valid, err := ethsignature.ValidateEIP6492Signature(ctx, provider, wallet, digest, walletSig)
+		require.NoError(t, err)
+		assert.True(t, valid)
+
+		// the unwrapped signature of the deployed wallet
+		valid, err = ethsignature.ValidateEIP6492Signature(ctx, provider, wallet, digest, wrapped)
+		require.NoError(t, err)
+		assert.True(t, valid)
+
+		valid, err = ethsignature.ValidateEIP6492Signature(ctx, provider, wallet, digest, common.FromHex("0x01"))
+		require.NoError(t, err)
+		assert.False(t, valid)
+	})
+
+	t.Run("counterfactual", func(t *testing.T) {
+		provider := &mockProvider{call: isValidSignatureOf(wallet, digest, walletSig)}
+		_, err := ethsignature.ValidateEIP6492Signature(ctx, provider, wallet, digest, wrapped)
+		assert.ErrorIs(t, err, ethsignature.ErrNoValidator)
+
+		expected, err := ethcoder.AbiEncodeMethodCalldata("isValidSig(address,bytes32,bytes)", []interface{}{wallet, digest, wrapped})
+		require.NoError(t, err)
+		provider.call = func(msg ethereum.CallMsg) ([]byte, error) {
+			require.Equal(t, validator, *msg.To)
+			require.Equal(t, expected, msg.Data)
+			return common.LeftPadBytes([]byte{1}, 32), nil
+		}
+		valid, err := ethsignature.ValidateEIP6492Signature(ctx, provider, wallet, digest, wrapped, ethsignature.Options{Validator: validator})
+		require.NoError(t, err)
+		assert.True(t, valid)
+
+		// the off-chain validator of its bytecode
+		bytecode := common.FromHex("0x6080604052")
+		provider.call = func(msg ethereum.CallMsg) ([]byte, error) {
+			require.Nil(t, msg.To)
+			require.Equal(t, bytecode, msg.Data[:len(bytecode)])
+			return []byte{0}, nil
+		}
+		valid, err = ethsignature.ValidateEIP6492Signature(ctx, provider, wallet, digest, wrapped, ethsignature.Options{ValidatorBytecode: bytecode})
+		require.NoError(t, err)
+		assert.False(t, valid)
+	})
+}
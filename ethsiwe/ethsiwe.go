@@ -0,0 +1,372 @@
+// Package ethsiwe builds, parses and verifies the Sign-In with Ethereum messages
+// of EIP-4361.
+package ethsiwe
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethsignature"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+var (
+	ErrInvalidMessage   = errors.New("ethsiwe: invalid message")
+	ErrInvalidSignature = errors.New("ethsiwe: invalid signature")
+	ErrDomainMismatch   = errors.New("ethsiwe: domain mismatch")
+	ErrNonceMismatch    = errors.New("ethsiwe: nonce mismatch")
+	ErrExpired          = errors.New("ethsiwe: message is expired")
+	ErrNotYetValid      = errors.New("ethsiwe: message is not yet valid")
+)
+
+const (
+	headerSuffix  = " wants you to sign in with your Ethereum account:"
+	nonceAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+)
+
+// Message is a Sign-In with Ethereum message. The optional fields are empty,
+// or nil of the times.
+type Message struct {
+	// Scheme is the optional scheme of the domain, ie. "https"
+	Scheme string
+
+	// Domain is the authority requesting the signing, ie. "example.com:8080"
+	Domain string
+
+	Address   common.Address
+	Statement string
+
+	// URI is the subject of the signing, ie. the uri of the resource
+	URI string
+
+	// Version is the version of the message, which is "1"
+	Version string
+
+	ChainID uint64
+
+	// Nonce is the random nonce of at least 8 alphanumeric characters of the
+	// session, see GenerateNonce
+	Nonce string
+
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+	RequestID      string
+	Resources      []string
+}
+
+// GenerateNonce returns a random nonce of 17 alphanumeric characters.
+func GenerateNonce() (string, error) {
+	b := make([]byte, 17)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("ethsiwe: failed to generate nonce: %w", err)
+	}
+	for i := range b {
+		// the bias of 256 % 62 is negligible for a nonce
+		b[i] = nonceAlphabet[int(b[i])%len(nonceAlphabet)]
+	}
+	return string(b), nil
+}
+
+// Validate returns an error of the invalid fields of the message.
+func (m *Message) Validate() error {
+	if m.Domain == "" || strings.ContainsAny(m.Domain, " \n/") {
+		return fmt.Errorf("%w: invalid domain %q", ErrInvalidMessage, m.Domain)
+	}
+	if m.Scheme != "" && !isScheme(m.Scheme) {
+		return fmt.Errorf("%w: invalid scheme %q", ErrInvalidMessage, m.Scheme)
+	}
+	if strings.Contains(m.Statement, "\n") {
+		return fmt.Errorf("%w: statement has a newline", ErrInvalidMessage)
+	}
+	if u, err := url.Parse(m.URI); err != nil || u.Scheme == "" {
+		return fmt.Errorf("%w: invalid uri %q", ErrInvalidMessage, m.URI)
+	}
+	if m.Version != "1" {
+		return fmt.Errorf("%w: invalid version %q", ErrInvalidMessage, m.Version)
+	}
+	if len(m.Nonce) < 8 || strings.Trim(m.Nonce, nonceAlphabet) != "" {
+		return fmt.Errorf("%w: nonce must be at least 8 alphanumeric characters", ErrInvalidMessage)
+	}
+	if m.IssuedAt.IsZero() {
+		return fmt.Errorf("%w: issued at is not set", ErrInvalidMessage)
+	}
+	if strings.Contains(m.RequestID, "\n") {
+		return fmt.Errorf("%w: request id has a newline", ErrInvalidMessage)
+	}
+	for _, resource := range m.Resources {
+		if u, err := url.Parse(resource); err != nil || u.Scheme == "" {
+			return fmt.Errorf("%w: invalid resource %q", ErrInvalidMessage, resource)
+		}
+	}
+	return nil
+}
+
+// String returns the text of the message, which is signed with personal_sign.
+func (m *Message) String() string {
+	var b strings.Builder
+	if m.Scheme != "" {
+		b.WriteString(m.Scheme + "://")
+	}
+	b.WriteString(m.Domain + headerSuffix + "\n")
+	b.WriteString(m.Address.Hex() + "\n\n")
+	if m.Statement != "" {
+		b.WriteString(m.Statement + "\n")
+	}
+	b.WriteString("\n")
+
+	b.WriteString("URI: " + m.URI + "\n")
+	b.WriteString("Version: " + m.Version + "\n")
+	b.WriteString("Chain ID: " + strconv.FormatUint(m.ChainID, 10) + "\n")
+	b.WriteString("Nonce: " + m.Nonce + "\n")
+	b.WriteString("Issued At: " + formatTime(m.IssuedAt))
+	if m.ExpirationTime != nil {
+		b.WriteString("\nExpiration Time: " + formatTime(*m.ExpirationTime))
+	}
+	if m.NotBefore != nil {
+		b.WriteString("\nNot Before: " + formatTime(*m.NotBefore))
+	}
+	if m.RequestID != "" {
+		b.WriteString("\nRequest ID: " + m.RequestID)
+	}
+	if len(m.Resources) > 0 {
+		b.WriteString("\nResources:")
+		for _, resource := range m.Resources {
+			b.WriteString("\n- " + resource)
+		}
+	}
+	return b.String()
+}
+
+// Digest returns the EIP-191 personal message digest of the text of the message.
+func (m *Message) Digest() common.Hash {
+	return ethcoder.EIP191PersonalMessageDigest([]byte(m.String()))
+}
+
+// ParseMessage parses the text of a message, and validates it.
+func ParseMessage(message string) (*Message, error) {
+	lines := strings.Split(message, "\n")
+	p := &parser{lines: lines}
+
+	m := &Message{}
+	header, ok := strings.CutSuffix(p.next(), headerSuffix)
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid header", ErrInvalidMessage)
+	}
+	if scheme, domain, ok := strings.Cut(header, "://"); ok {
+		m.Scheme, m.Domain = scheme, domain
+	} else {
+		m.Domain = header
+	}
+
+	address := p.next()
+	if !common.IsHexAddress(address) || common.HexToAddress(address).Hex() != address {
+		return nil, fmt.Errorf("%w: address %q is not of eip-55 checksum", ErrInvalidMessage, address)
+	}
+	m.Address = common.HexToAddress(address)
+
+	if p.next() != "" {
+		return nil, fmt.Errorf("%w: expected empty line after address", ErrInvalidMessage)
+	}
+	if line := p.next(); strings.HasPrefix(line, "URI: ") {
+		// the messages without the empty line of the statement
+		p.pos--
+	} else if line != "" {
+		m.Statement = line
+		if p.next() != "" {
+			return nil, fmt.Errorf("%w: expected empty line after statement", ErrInvalidMessage)
+		}
+	}
+
+	m.URI = p.field("URI")
+	m.Version = p.field("Version")
+	chainID := p.field("Chain ID")
+	m.Nonce = p.field("Nonce")
+	issuedAt := p.field("Issued At")
+	expirationTime, hasExpirationTime := p.optionalField("Expiration Time")
+	notBefore, hasNotBefore := p.optionalField("Not Before")
+	m.RequestID, _ = p.optionalField("Request ID")
+	if _, ok := p.optionalField("Resources"); ok {
+		for p.pos < len(p.lines) && strings.HasPrefix(p.lines[p.pos], "- ") {
+			m.Resources = append(m.Resources, strings.TrimPrefix(p.next(), "- "))
+		}
+		if len(m.Resources) == 0 {
+			return nil, fmt.Errorf("%w: no resources", ErrInvalidMessage)
+		}
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	if p.pos != len(p.lines) {
+		return nil, fmt.Errorf("%w: unexpected line %q", ErrInvalidMessage, p.lines[p.pos])
+	}
+
+	var err error
+	if m.ChainID, err = strconv.ParseUint(chainID, 10, 64); err != nil {
+		return nil, fmt.Errorf("%w: invalid chain id %q", ErrInvalidMessage, chainID)
+	}
+	if m.IssuedAt, err = parseTime(issuedAt); err != nil {
+		return nil, err
+	}
+	if hasExpirationTime {
+		t, err := parseTime(expirationTime)
+		if err != nil {
+			return nil, err
+		}
+		m.ExpirationTime = &t
+	}
+	if hasNotBefore {
+		t, err := parseTime(notBefore)
+		if err != nil {
+			return nil, err
+		}
+		m.NotBefore = &t
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// VerifyOptions are the options of Verify.
+type VerifyOptions struct {
+	// Domain is the expected domain of the message, if set.
+	Domain string
+
+	// Nonce is the expected nonce of the message, if set, ie. of the session.
+	Nonce string
+
+	// Time is the time of the expiration time and not before of the message, or
+	// the current time when zero.
+	Time time.Time
+
+	// SignatureOptions are the options of the verification of the signatures
+	// of contract wallets, ie. the universal validator of EIP-6492.
+	SignatureOptions ethsignature.Options
+}
+
+// Verify parses the text of the message, and verifies it and its signature. The
+// signature of the parsed message is of the exact text, which is signed with
+// personal_sign, and is verified as of the ecdsa signature of an EOA, or of
+// EIP-1271 or EIP-6492 of a contract wallet. The provider may be nil for the
+// verification of the signatures of EOAs only.
+func Verify(ctx context.Context, provider ethrpc.Interface, message string, sig []byte, opts ...VerifyOptions) (*Message, error) {
+	var o VerifyOptions
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+
+	m, err := ParseMessage(message)
+	if err != nil {
+		return nil, err
+	}
+	if o.Domain != "" && o.Domain != m.Domain {
+		return nil, fmt.Errorf("%w: expected %q, got %q", ErrDomainMismatch, o.Domain, m.Domain)
+	}
+	if o.Nonce != "" && o.Nonce != m.Nonce {
+		return nil, ErrNonceMismatch
+	}
+
+	now := o.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if m.ExpirationTime != nil && !now.Before(*m.ExpirationTime) {
+		return nil, ErrExpired
+	}
+	if m.NotBefore != nil && now.Before(*m.NotBefore) {
+		return nil, ErrNotYetValid
+	}
+
+	digest := ethcoder.EIP191PersonalMessageDigest([]byte(message))
+	var valid bool
+	if provider == nil {
+		valid, err = ethcoder.ValidateSignature(m.Address, digest.Bytes(), sig)
+	} else {
+		valid, err = ethsignature.ValidateEIP6492Signature(ctx, provider, m.Address, digest, sig, o.SignatureOptions)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+	if !valid {
+		return nil, ErrInvalidSignature
+	}
+	return m, nil
+}
+
+// parser reads the lines of a message, where the first error is sticky.
+type parser struct {
+	lines []string
+	pos   int
+	err   error
+}
+
+func (p *parser) next() string {
+	if p.pos >= len(p.lines) {
+		return ""
+	}
+	line := p.lines[p.pos]
+	p.pos++
+	return line
+}
+
+func (p *parser) field(name string) string {
+	value, ok := p.optionalField(name)
+	if !ok && p.err == nil {
+		p.err = fmt.Errorf("%w: expected %s", ErrInvalidMessage, name)
+	}
+	return value
+}
+
+func (p *parser) optionalField(name string) (string, bool) {
+	if p.err != nil || p.pos >= len(p.lines) {
+		return "", false
+	}
+	line := p.lines[p.pos]
+	if name == "Resources" {
+		if line != "Resources:" {
+			return "", false
+		}
+		p.pos++
+		return "", true
+	}
+	value, ok := strings.CutPrefix(line, name+": ")
+	if !ok {
+		return "", false
+	}
+	p.pos++
+	return value, true
+}
+
+// isScheme returns whether s is a scheme of rfc 3986, ie. ALPHA *( ALPHA /
+// DIGIT / "+" / "-" / "." ).
+func isScheme(s string) bool {
+	for i, c := range s {
+		isAlpha := ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+		if !isAlpha && (i == 0 || !('0' <= c && c <= '9' || c == '+' || c == '-' || c == '.')) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func parseTime(s string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: invalid time %q", ErrInvalidMessage, s)
+	}
+	return t, nil
+}
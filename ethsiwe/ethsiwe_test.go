@@ -0,0 +1,166 @@
+package ethsiwe_test
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethsiwe"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const exampleMessage = `example.com wants you to sign in with your Ethereum account:
+0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2
+
+I accept the ExampleOrg Terms of Service: https://example.com/tos
+
+URI: https://example.com/login
+Version: 1
+Chain ID: 1
+Nonce: 32891756
+Issued At: 2021-09-30T16:25:24Z
+Resources:
+- ipfs://bafybeiemxf5abjwjbikoz4mc3a3dla6ual3jsgpdr4cjr3oz3evfyavhwq/
+- https://example.com/my-web2-claim.json`
+
+func TestParseMessage(t *testing.T) {
+	m, err := ethsiwe.ParseMessage(exampleMessage)
+	require.NoError(t, err)
+	assert.Equal(t, "example.com", m.Domain)
+	assert.Equal(t, common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"), m.Address)
+	assert.Equal(t, "I accept the ExampleOrg Terms of Service: https://example.com/tos", m.Statement)
+	assert.Equal(t, "https://example.com/login", m.URI)
+	assert.Equal(t, uint64(1), m.ChainID)
+	assert.Equal(t, "32891756", m.Nonce)
+	assert.Equal(t, time.Date(2021, 9, 30, 16, 25, 24, 0, time.UTC), m.IssuedAt)
+	assert.Nil(t, m.ExpirationTime)
+	assert.Len(t, m.Resources, 2)
+	assert.Equal(t, exampleMessage, m.String())
+
+	t.Run("optional fields", func(t *testing.T) {
+		expiration := time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)
+		m := &ethsiwe.Message{
+			Scheme:         "https",
+			Domain:         "example.com:8080",
+			Address:        common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"),
+			URI:            "https://example.com/login",
+			Version:        "1",
+			ChainID:        137,
+			Nonce:          "abcdefgh1234",
+			IssuedAt:       time.Date(2021, 9, 30, 16, 25, 24, 500000000, time.UTC),
+			ExpirationTime: &expiration,
+			RequestID:      "request-1",
+		}
+		require.NoError(t, m.Validate())
+		assert.Equal(t, `https://example.com:8080 wants you to sign in with your Ethereum account:
+0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2
+
+
+URI: https://example.com/login
+Version: 1
+Chain ID: 137
+Nonce: abcdefgh1234
+Issued At: 2021-09-30T16:25:24.5Z
+Expiration Time: 2021-10-01T00:00:00Z
+Request ID: request-1`, m.String())
+
+		parsed, err := ethsiwe.ParseMessage(m.String())
+		require.NoError(t, err)
+		assert.Equal(t, m, parsed)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, message := range []string{
+			"",
+			strings.Replace(exampleMessage, "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2", "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2", 1),
+			strings.Replace(exampleMessage, "Nonce: 32891756", "Nonce: 1234", 1),
+			strings.Replace(exampleMessage, "Version: 1", "Version: 2", 1),
+			strings.Replace(exampleMessage, "Chain ID: 1\n", "", 1),
+			strings.Replace(exampleMessage, "2021-09-30T16:25:24Z", "yesterday", 1),
+			exampleMessage + "\nfoo",
+		} {
+			_, err := ethsiwe.ParseMessage(message)
+			assert.ErrorIs(t, err, ethsiwe.ErrInvalidMessage, message)
+		}
+	})
+}
+
+type mockProvider struct {
+	ethrpc.Interface
+	wallet common.Address
+	digest common.Hash
+}
+
+func (p *mockProvider) CodeAt(ctx context.Context, account common.Address, blockNum *big.Int) ([]byte, error) {
+	if account == p.wallet {
+		return []byte{0x60}, nil
+	}
+	return nil, nil
+}
+
+func (p *mockProvider) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNum *big.Int) ([]byte, error) {
+	expected, _ := ethcoder.AbiEncodeMethodCalldata("isValidSignature(bytes32,bytes)", []interface{}{p.digest, []byte{1}})
+	if bytes.Equal(msg.Data, expected) {
+		return common.RightPadBytes(common.FromHex("0x1626ba7e"), 32), nil
+	}
+	return make([]byte, 32), nil
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+	wallet, err := ethwallet.NewWalletFromMnemonic("dose weasel clever culture letter volume endorse used harvest ripple circle install")
+	require.NoError(t, err)
+
+	nonce, err := ethsiwe.GenerateNonce()
+	require.NoError(t, err)
+	expiration := time.Now().Add(time.Hour)
+	m := &ethsiwe.Message{
+		Domain:         "example.com",
+		Address:        wallet.Address(),
+		Statement:      "Sign in to example.com",
+		URI:            "https://example.com/login",
+		Version:        "1",
+		ChainID:        1,
+		Nonce:          nonce,
+		IssuedAt:       time.Now(),
+		ExpirationTime: &expiration,
+	}
+	require.NoError(t, m.Validate())
+	message := m.String()
+
+	sig, err := wallet.SignMessage([]byte(message))
+	require.NoError(t, err)
+
+	verified, err := ethsiwe.Verify(ctx, nil, message, sig, ethsiwe.VerifyOptions{Domain: "example.com", Nonce: nonce})
+	require.NoError(t, err)
+	assert.Equal(t, wallet.Address(), verified.Address)
+
+	_, err = ethsiwe.Verify(ctx, nil, message, sig, ethsiwe.VerifyOptions{Domain: "evil.com"})
+	assert.ErrorIs(t, err, ethsiwe.ErrDomainMismatch)
+	_, err = ethsiwe.Verify(ctx, nil, message, sig, ethsiwe.VerifyOptions{Nonce: "otherNonce"})
+	assert.ErrorIs(t, err, ethsiwe.ErrNonceMismatch)
+	_, err = ethsiwe.Verify(ctx, nil, message, sig, ethsiwe.VerifyOptions{Time: expiration})
+	assert.ErrorIs(t, err, ethsiwe.ErrExpired)
+	_, err = ethsiwe.Verify(ctx, nil, strings.Replace(message, "Chain ID: 1", "Chain ID: 2", 1), sig)
+	assert.ErrorIs(t, err, ethsiwe.ErrInvalidSignature)
+
+	t.Run("contract wallet", func(t *testing.T) {
+		m := *m
+		m.Address = common.HexToAddress("0x1111111111111111111111111111111111111111")
+		provider := &mockProvider{wallet: m.Address, digest: m.Digest()}
+
+		_, err := ethsiwe.Verify(ctx, provider, m.String(), []byte{1})
+		require.NoError(t, err)
+		_, err = ethsiwe.Verify(ctx, provider, m.String(), []byte{2})
+		assert.ErrorIs(t, err, ethsiwe.ErrInvalidSignature)
+	})
+}
@@ -0,0 +1,76 @@
+package ethsnapshot
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// Checkpoint is the state of the balances of a token at a block, so a
+// reconstruction can resume from it instead of replaying all the logs.
+type Checkpoint struct {
+	Token     common.Address              `json:"token"`
+	BlockNum  uint64                      `json:"blockNum"`
+	BlockHash common.Hash                 `json:"blockHash"`
+	Balances  map[common.Address]*big.Int `json:"balances"`
+}
+
+// CheckpointStore persists checkpoints. Implementations must be safe for
+// concurrent use.
+type CheckpointStore interface {
+	// Save stores the checkpoint, replacing any of the same token and block.
+	Save(ctx context.Context, checkpoint *Checkpoint) error
+
+	// Latest returns the checkpoint of the token with the highest block number
+	// at or below blockNum, or nil if there is none.
+	Latest(ctx context.Context, token common.Address, blockNum uint64) (*Checkpoint, error)
+}
+
+// NewMemoryCheckpointStore returns a CheckpointStore which keeps checkpoints
+// in memory.
+func NewMemoryCheckpointStore() CheckpointStore {
+	return &memoryCheckpointStore{
+		checkpoints: map[common.Address][]*Checkpoint{},
+	}
+}
+
+type memoryCheckpointStore struct {
+	checkpoints map[common.Address][]*Checkpoint
+	mu          sync.RWMutex
+}
+
+func (s *memoryCheckpointStore) Save(ctx context.Context, checkpoint *Checkpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	checkpoints := s.checkpoints[checkpoint.Token]
+	i := sort.Search(len(checkpoints), func(i int) bool {
+		return checkpoints[i].BlockNum >= checkpoint.BlockNum
+	})
+	if i < len(checkpoints) && checkpoints[i].BlockNum == checkpoint.BlockNum {
+		checkpoints[i] = checkpoint
+		return nil
+	}
+	checkpoints = append(checkpoints, nil)
+	copy(checkpoints[i+1:], checkpoints[i:])
+	checkpoints[i] = checkpoint
+	s.checkpoints[checkpoint.Token] = checkpoints
+	return nil
+}
+
+func (s *memoryCheckpointStore) Latest(ctx context.Context, token common.Address, blockNum uint64) (*Checkpoint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	checkpoints := s.checkpoints[token]
+	i := sort.Search(len(checkpoints), func(i int) bool {
+		return checkpoints[i].BlockNum > blockNum
+	})
+	if i == 0 {
+		return nil, nil
+	}
+	return checkpoints[i-1], nil
+}
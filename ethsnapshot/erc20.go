@@ -0,0 +1,369 @@
+package ethsnapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethmonitor"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+var (
+	ErrBlockGap       = errors.New("ethsnapshot: block is not the next block to apply")
+	ErrReorgTooDeep   = errors.New("ethsnapshot: reorg is deeper than the retained blocks")
+	ErrBlockNotSynced = errors.New("ethsnapshot: block is ahead of the synced block")
+)
+
+// TransferEventSig is the topic of the Transfer event of ERC-20 and ERC-721
+// tokens, which are told apart by the number of indexed arguments.
+var TransferEventSig = ethcoder.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+var DefaultERC20Options = ERC20Options{
+	BatchSize:          2000,
+	CheckpointInterval: 100_000,
+	ReorgDepth:         128,
+}
+
+type ERC20Options struct {
+	// StartBlock is the block the token was deployed at. Logs before it are
+	// not queried.
+	StartBlock uint64
+
+	// BatchSize is the number of blocks per eth_getLogs request.
+	BatchSize uint64
+
+	// CheckpointInterval is the number of blocks between checkpoints saved to
+	// the CheckpointStore while syncing. Checkpoints are saved at multiples of
+	// the interval. Zero disables checkpointing.
+	CheckpointInterval uint64
+
+	// CheckpointStore to save checkpoints to, and restore from. Checkpointing
+	// is disabled if nil.
+	CheckpointStore CheckpointStore
+
+	// ReorgDepth is the number of most recent blocks which can be rolled
+	// back by removed blocks passed to HandleBlocks.
+	ReorgDepth uint64
+}
+
+// ERC20Balances reconstructs the balances of all holders of an ERC-20 token by
+// replaying its Transfer logs.
+//
+// Historical ranges are replayed with Sync, after which the balances can be
+// kept at the head of the chain by passing the blocks of an ethmonitor
+// subscription to HandleBlocks, which rolls back the transfers of reorged
+// blocks. Sync should only be used up to final blocks, as it can't detect
+// reorgs of the blocks it replays.
+type ERC20Balances struct {
+	Token common.Address
+
+	options  ERC20Options
+	provider ethrpc.Interface
+
+	balances map[common.Address]*big.Int
+
+	// next block to apply
+	next uint64
+
+	// journal of the changes of the most recent blocks with transfers, and the
+	// lowest block number which can be rolled back
+	journal      []*blockJournal
+	journalStart uint64
+
+	mu sync.Mutex
+}
+
+type blockJournal struct {
+	num    uint64
+	hash   common.Hash
+	deltas map[common.Address]*big.Int
+}
+
+func NewERC20Balances(provider ethrpc.Interface, token common.Address, opts ...ERC20Options) *ERC20Balances {
+	options := DefaultERC20Options
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	if options.BatchSize == 0 {
+		options.BatchSize = DefaultERC20Options.BatchSize
+	}
+
+	return &ERC20Balances{
+		Token:        token,
+		options:      options,
+		provider:     provider,
+		balances:     map[common.Address]*big.Int{},
+		next:         options.StartBlock,
+		journalStart: options.StartBlock,
+	}
+}
+
+// ERC20BalancesAt reconstructs the balances of the token at blockNum, resuming
+// from the latest checkpoint of the CheckpointStore when set.
+func ERC20BalancesAt(ctx context.Context, provider ethrpc.Interface, token common.Address, blockNum uint64, opts ...ERC20Options) (map[common.Address]*big.Int, error) {
+	b := NewERC20Balances(provider, token, opts...)
+	if _, err := b.Restore(ctx, blockNum); err != nil {
+		return nil, err
+	}
+	if err := b.Sync(ctx, blockNum); err != nil {
+		return nil, err
+	}
+	return b.Balances(), nil
+}
+
+// Restore loads the latest checkpoint at or below blockNum from the
+// CheckpointStore, and reports if one was found.
+func (b *ERC20Balances) Restore(ctx context.Context, blockNum uint64) (bool, error) {
+	if b.options.CheckpointStore == nil {
+		return false, nil
+	}
+	checkpoint, err := b.options.CheckpointStore.Latest(ctx, b.Token, blockNum)
+	if err != nil {
+		return false, fmt.Errorf("ethsnapshot: failed to load checkpoint: %w", err)
+	}
+	if checkpoint == nil {
+		return false, nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.balances = copyBalances(checkpoint.Balances)
+	b.next = checkpoint.BlockNum + 1
+	b.journal = nil
+	b.journalStart = b.next
+	return true, nil
+}
+
+// Sync replays the Transfer logs of all blocks up to and including toBlock.
+func (b *ERC20Balances) Sync(ctx context.Context, toBlock uint64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for b.next <= toBlock {
+		from := b.next
+		to := min(from+b.options.BatchSize-1, toBlock)
+
+		checkpoint := false
+		if b.options.CheckpointStore != nil && b.options.CheckpointInterval > 0 {
+			boundary := (from/b.options.CheckpointInterval+1)*b.options.CheckpointInterval - 1
+			if boundary <= to {
+				to, checkpoint = boundary, true
+			}
+		}
+
+		logs, err := b.provider.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(from),
+			ToBlock:   new(big.Int).SetUint64(to),
+			Addresses: []common.Address{b.Token},
+			Topics:    [][]common.Hash{{TransferEventSig}},
+		})
+		if err != nil {
+			return fmt.Errorf("ethsnapshot: failed to fetch logs of blocks %d to %d: %w", from, to, err)
+		}
+
+		for i := 0; i < len(logs); {
+			j := i
+			for j < len(logs) && logs[j].BlockHash == logs[i].BlockHash {
+				j++
+			}
+			b.applyBlock(logs[i].BlockNumber, logs[i].BlockHash, logs[i:j])
+			i = j
+		}
+		b.next = to + 1
+		b.trimJournal()
+
+		if checkpoint {
+			if err := b.saveCheckpoint(ctx, to); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// HandleBlocks applies the transfers of added blocks and rolls back the
+// transfers of removed blocks, as published by ethmonitor. Added blocks must
+// be the next block to apply, blocks which have already been synced are
+// skipped.
+func (b *ERC20Balances) HandleBlocks(blocks ethmonitor.Blocks) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, block := range blocks {
+		num := block.NumberU64()
+
+		switch block.Event {
+		case ethmonitor.Added:
+			if num < b.next {
+				continue
+			}
+			if num > b.next {
+				return fmt.Errorf("%w: expecting block %d but got %d", ErrBlockGap, b.next, num)
+			}
+			var logs []types.Log
+			for _, log := range block.Logs {
+				if log.Address == b.Token && !log.Removed && len(log.Topics) > 0 && log.Topics[0] == TransferEventSig {
+					logs = append(logs, log)
+				}
+			}
+			b.applyBlock(num, block.Hash(), logs)
+			b.next = num + 1
+
+		case ethmonitor.Removed:
+			if num >= b.next {
+				continue
+			}
+			if num < b.journalStart {
+				return fmt.Errorf("%w: block %d", ErrReorgTooDeep, num)
+			}
+			for len(b.journal) > 0 && b.journal[len(b.journal)-1].num >= num {
+				entry := b.journal[len(b.journal)-1]
+				for addr, delta := range entry.deltas {
+					b.addBalance(addr, new(big.Int).Neg(delta))
+				}
+				b.journal = b.journal[:len(b.journal)-1]
+			}
+			b.next = num
+		}
+	}
+
+	b.trimJournal()
+	return nil
+}
+
+// BlockNum returns the last block applied, or StartBlock if none were.
+func (b *ERC20Balances) BlockNum() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.blockNum()
+}
+
+// BalanceOf returns the balance of the account at the last block applied.
+func (b *ERC20Balances) BalanceOf(account common.Address) *big.Int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if balance, ok := b.balances[account]; ok {
+		return new(big.Int).Set(balance)
+	}
+	return big.NewInt(0)
+}
+
+// Balances returns a copy of the non-zero balances of all holders at the last
+// block applied.
+func (b *ERC20Balances) Balances() map[common.Address]*big.Int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return copyBalances(b.balances)
+}
+
+// Checkpoint returns the current state as a checkpoint, which can be saved to
+// a CheckpointStore.
+func (b *ERC20Balances) Checkpoint() *Checkpoint {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return &Checkpoint{
+		Token:    b.Token,
+		BlockNum: b.blockNum(),
+		Balances: copyBalances(b.balances),
+	}
+}
+
+func (b *ERC20Balances) blockNum() uint64 {
+	if b.next == 0 {
+		return 0
+	}
+	return b.next - 1
+}
+
+func (b *ERC20Balances) applyBlock(num uint64, hash common.Hash, logs []types.Log) {
+	entry := &blockJournal{num: num, hash: hash, deltas: map[common.Address]*big.Int{}}
+
+	for _, log := range logs {
+		// ERC-721 transfers have the tokenId indexed as well
+		if len(log.Topics) != 3 || len(log.Data) != 32 {
+			continue
+		}
+		from := common.BytesToAddress(log.Topics[1].Bytes())
+		to := common.BytesToAddress(log.Topics[2].Bytes())
+		value := new(big.Int).SetBytes(log.Data)
+
+		if from != (common.Address{}) {
+			b.addBalance(from, new(big.Int).Neg(value))
+			addDelta(entry.deltas, from, new(big.Int).Neg(value))
+		}
+		if to != (common.Address{}) {
+			b.addBalance(to, value)
+			addDelta(entry.deltas, to, value)
+		}
+	}
+
+	if len(entry.deltas) > 0 {
+		b.journal = append(b.journal, entry)
+	}
+}
+
+func (b *ERC20Balances) addBalance(account common.Address, delta *big.Int) {
+	balance, ok := b.balances[account]
+	if !ok {
+		balance = new(big.Int)
+		b.balances[account] = balance
+	}
+	balance.Add(balance, delta)
+	if balance.Sign() == 0 {
+		delete(b.balances, account)
+	}
+}
+
+func (b *ERC20Balances) trimJournal() {
+	if b.next < b.options.ReorgDepth || b.next-b.options.ReorgDepth <= b.journalStart {
+		return
+	}
+	b.journalStart = b.next - b.options.ReorgDepth
+	i := 0
+	for i < len(b.journal) && b.journal[i].num < b.journalStart {
+		i++
+	}
+	b.journal = b.journal[i:]
+}
+
+func (b *ERC20Balances) saveCheckpoint(ctx context.Context, blockNum uint64) error {
+	header, err := b.provider.HeaderByNumber(ctx, new(big.Int).SetUint64(blockNum))
+	if err != nil {
+		return fmt.Errorf("ethsnapshot: failed to fetch header of checkpoint block %d: %w", blockNum, err)
+	}
+	err = b.options.CheckpointStore.Save(ctx, &Checkpoint{
+		Token:     b.Token,
+		BlockNum:  blockNum,
+		BlockHash: header.Hash(),
+		Balances:  copyBalances(b.balances),
+	})
+	if err != nil {
+		return fmt.Errorf("ethsnapshot: failed to save checkpoint: %w", err)
+	}
+	return nil
+}
+
+func addDelta(deltas map[common.Address]*big.Int, account common.Address, delta *big.Int) {
+	if d, ok := deltas[account]; ok {
+		d.Add(d, delta)
+	} else {
+		deltas[account] = new(big.Int).Set(delta)
+	}
+}
+
+func copyBalances(balances map[common.Address]*big.Int) map[common.Address]*big.Int {
+	c := make(map[common.Address]*big.Int, len(balances))
+	for addr, balance := range balances {
+		if balance.Sign() != 0 {
+			c[addr] = new(big.Int).Set(balance)
+		}
+	}
+	return c
+}
@@ -0,0 +1,127 @@
+package ethsnapshot_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethmonitor"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethsnapshot"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	token = common.HexToAddress("0x70c0")
+	alice = common.HexToAddress("0xa1")
+	bob   = common.HexToAddress("0xb0")
+)
+
+type mockProvider struct {
+	ethrpc.Interface
+	logs          []types.Log
+	filterQueries int
+}
+
+func (p *mockProvider) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	p.filterQueries++
+	var logs []types.Log
+	for _, log := range p.logs {
+		if log.BlockNumber >= q.FromBlock.Uint64() && log.BlockNumber <= q.ToBlock.Uint64() {
+			logs = append(logs, log)
+		}
+	}
+	return logs, nil
+}
+
+func (p *mockProvider) HeaderByNumber(ctx context.Context, blockNum *big.Int) (*types.Header, error) {
+	return &types.Header{Number: blockNum}, nil
+}
+
+func transferLog(blockNum uint64, from, to common.Address, value int64) types.Log {
+	return types.Log{
+		Address:     token,
+		Topics:      []common.Hash{ethsnapshot.TransferEventSig, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+		Data:        common.BigToHash(big.NewInt(value)).Bytes(),
+		BlockNumber: blockNum,
+		BlockHash:   common.BigToHash(new(big.Int).SetUint64(blockNum)),
+	}
+}
+
+func TestERC20BalancesAt(t *testing.T) {
+	provider := &mockProvider{logs: []types.Log{
+		transferLog(10, common.Address{}, alice, 100),
+		transferLog(15, alice, bob, 30),
+		transferLog(15, alice, bob, 20),
+		transferLog(25, bob, common.Address{}, 50),
+	}}
+	store := ethsnapshot.NewMemoryCheckpointStore()
+	options := ethsnapshot.ERC20Options{
+		BatchSize:          4,
+		CheckpointInterval: 10,
+		CheckpointStore:    store,
+	}
+
+	ctx := context.Background()
+	balances, err := ethsnapshot.ERC20BalancesAt(ctx, provider, token, 15, options)
+	require.NoError(t, err)
+	assert.Equal(t, map[common.Address]*big.Int{alice: big.NewInt(50), bob: big.NewInt(50)}, balances)
+
+	balances, err = ethsnapshot.ERC20BalancesAt(ctx, provider, token, 30, options)
+	require.NoError(t, err)
+	assert.Equal(t, map[common.Address]*big.Int{alice: big.NewInt(50)}, balances)
+
+	checkpoint, err := store.Latest(ctx, token, 30)
+	require.NoError(t, err)
+	require.NotNil(t, checkpoint)
+	assert.Equal(t, uint64(29), checkpoint.BlockNum)
+
+	// resuming from the checkpoint of block 19 only queries blocks 20 to 21
+	provider.filterQueries = 0
+	balances, err = ethsnapshot.ERC20BalancesAt(ctx, provider, token, 21, options)
+	require.NoError(t, err)
+	assert.Equal(t, map[common.Address]*big.Int{alice: big.NewInt(50), bob: big.NewInt(50)}, balances)
+	assert.Equal(t, 1, provider.filterQueries)
+}
+
+func TestERC20BalancesReorg(t *testing.T) {
+	provider := &mockProvider{logs: []types.Log{
+		transferLog(1, common.Address{}, alice, 100),
+	}}
+	b := ethsnapshot.NewERC20Balances(provider, token)
+
+	ctx := context.Background()
+	require.NoError(t, b.Sync(ctx, 1))
+
+	newBlock := func(num uint64, event ethmonitor.Event, logs ...types.Log) *ethmonitor.Block {
+		return &ethmonitor.Block{
+			Block: types.NewBlockWithHeader(&types.Header{Number: new(big.Int).SetUint64(num)}),
+			Event: event,
+			Logs:  logs,
+		}
+	}
+
+	block2 := newBlock(2, ethmonitor.Added, transferLog(2, alice, bob, 40))
+	block3 := newBlock(3, ethmonitor.Added, transferLog(3, bob, alice, 10))
+	require.NoError(t, b.HandleBlocks(ethmonitor.Blocks{block2, block3}))
+	assert.Equal(t, big.NewInt(70), b.BalanceOf(alice))
+	assert.Equal(t, big.NewInt(30), b.BalanceOf(bob))
+
+	// blocks 2 and 3 are reorged
+	err := b.HandleBlocks(ethmonitor.Blocks{
+		newBlock(3, ethmonitor.Removed),
+		newBlock(2, ethmonitor.Removed),
+		newBlock(2, ethmonitor.Added, transferLog(2, alice, bob, 1)),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), b.BlockNum())
+	assert.Equal(t, big.NewInt(99), b.BalanceOf(alice))
+	assert.Equal(t, big.NewInt(1), b.BalanceOf(bob))
+
+	err = b.HandleBlocks(ethmonitor.Blocks{newBlock(5, ethmonitor.Added)})
+	assert.ErrorIs(t, err, ethsnapshot.ErrBlockGap)
+}
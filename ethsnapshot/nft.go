@@ -0,0 +1,373 @@
+package ethsnapshot
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethcontract"
+	"github.com/0xsequence/ethkit/ethmulticall"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi/bind"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	ERC721ABI = ethcontract.MustParseABI(`[
+		{"type":"function","name":"supportsInterface","stateMutability":"view","inputs":[{"name":"interfaceId","type":"bytes4"}],"outputs":[{"name":"","type":"bool"}]},
+		{"type":"function","name":"totalSupply","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+		{"type":"function","name":"tokenByIndex","stateMutability":"view","inputs":[{"name":"index","type":"uint256"}],"outputs":[{"name":"","type":"uint256"}]},
+		{"type":"function","name":"ownerOf","stateMutability":"view","inputs":[{"name":"tokenId","type":"uint256"}],"outputs":[{"name":"","type":"address"}]}
+	]`)
+
+	ERC1155ABI = ethcontract.MustParseABI(`[
+		{"type":"event","name":"TransferSingle","anonymous":false,"inputs":[{"name":"operator","type":"address","indexed":true},{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"id","type":"uint256","indexed":false},{"name":"value","type":"uint256","indexed":false}]},
+		{"type":"event","name":"TransferBatch","anonymous":false,"inputs":[{"name":"operator","type":"address","indexed":true},{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":true},{"name":"ids","type":"uint256[]","indexed":false},{"name":"values","type":"uint256[]","indexed":false}]}
+	]`)
+
+	// ERC721EnumerableInterfaceID is the ERC-165 interface id of ERC721Enumerable.
+	ERC721EnumerableInterfaceID = [4]byte{0x78, 0x0e, 0x9d, 0x63}
+
+	TransferSingleEventSig = ethcoder.Keccak256Hash([]byte("TransferSingle(address,address,address,uint256,uint256)"))
+	TransferBatchEventSig  = ethcoder.Keccak256Hash([]byte("TransferBatch(address,address,address,uint256[],uint256[])"))
+)
+
+type NFTStandard string
+
+const (
+	ERC721  NFTStandard = "erc721"
+	ERC1155 NFTStandard = "erc1155"
+)
+
+var DefaultNFTSnapshotOptions = NFTSnapshotOptions{
+	BatchSize:   2000,
+	Concurrency: 100,
+}
+
+type NFTSnapshotOptions struct {
+	// StartBlock is the block the collection was deployed at. Logs before it
+	// are not queried.
+	StartBlock uint64
+
+	// BatchSize is the number of blocks per eth_getLogs request.
+	BatchSize uint64
+
+	// Concurrency is the max number of contract reads in flight, which are
+	// batched into Multicall3 calls.
+	Concurrency int
+}
+
+// NFTHolding is the amount of a token held by an owner. The amount of ERC-721
+// tokens is always one.
+type NFTHolding struct {
+	Owner   common.Address
+	TokenID *big.Int
+	Amount  *big.Int
+}
+
+func (h NFTHolding) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Owner   common.Address `json:"owner"`
+		TokenID string         `json:"tokenId"`
+		Amount  string         `json:"amount"`
+	}{h.Owner, h.TokenID.String(), h.Amount.String()})
+}
+
+// NFTSnapshot is the set of owners of all tokens of a collection at a block.
+type NFTSnapshot struct {
+	Contract common.Address `json:"contract"`
+	Standard NFTStandard    `json:"standard"`
+	BlockNum uint64         `json:"blockNum"`
+	Holdings []NFTHolding   `json:"holdings"`
+}
+
+// Owners returns the total amount of tokens held by each owner.
+func (s *NFTSnapshot) Owners() map[common.Address]*big.Int {
+	owners := map[common.Address]*big.Int{}
+	for _, h := range s.Holdings {
+		if _, ok := owners[h.Owner]; !ok {
+			owners[h.Owner] = new(big.Int)
+		}
+		owners[h.Owner].Add(owners[h.Owner], h.Amount)
+	}
+	return owners
+}
+
+// WriteCSV writes the holdings as CSV with an owner,token_id,amount header.
+func (s *NFTSnapshot) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"owner", "token_id", "amount"}); err != nil {
+		return err
+	}
+	for _, h := range s.Holdings {
+		if err := cw.Write([]string{h.Owner.Hex(), h.TokenID.String(), h.Amount.String()}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (s *NFTSnapshot) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// ERC721Snapshot returns the owner of every token of an ERC-721 collection at
+// blockNum. Token ids are enumerated through ERC721Enumerable when supported,
+// or otherwise from the Transfer logs, and their owners are read with batched
+// ownerOf calls.
+func ERC721Snapshot(ctx context.Context, provider ethrpc.Interface, contract common.Address, blockNum uint64, opts ...NFTSnapshotOptions) (*NFTSnapshot, error) {
+	options := nftSnapshotOptions(opts)
+	caller := ethmulticall.NewScheduler(provider)
+	block := new(big.Int).SetUint64(blockNum)
+
+	var enumerable bool
+	if err := nftCall(ctx, caller, contract, block, &enumerable, "supportsInterface", ERC721EnumerableInterfaceID); err != nil {
+		// contracts without ERC-165 revert
+		enumerable = false
+	}
+
+	var tokenIDs []*big.Int
+	var err error
+	if enumerable {
+		tokenIDs, err = enumerateERC721(ctx, caller, contract, block, options)
+	} else {
+		tokenIDs, err = replayERC721(ctx, provider, contract, blockNum, options)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	holdings := make([]NFTHolding, len(tokenIDs))
+	err = parallel(ctx, options.Concurrency, len(tokenIDs), func(ctx context.Context, i int) error {
+		holdings[i] = NFTHolding{TokenID: tokenIDs[i], Amount: big.NewInt(1)}
+		if err := nftCall(ctx, caller, contract, block, &holdings[i].Owner, "ownerOf", tokenIDs[i]); err != nil {
+			return fmt.Errorf("ethsnapshot: failed to read owner of token %s: %w", tokenIDs[i], err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortHoldings(holdings)
+	return &NFTSnapshot{
+		Contract: contract,
+		Standard: ERC721,
+		BlockNum: blockNum,
+		Holdings: holdings,
+	}, nil
+}
+
+// ERC1155Snapshot returns the balances of every owner of every token of an
+// ERC-1155 collection at blockNum, by replaying its TransferSingle and
+// TransferBatch logs.
+func ERC1155Snapshot(ctx context.Context, provider ethrpc.Interface, contract common.Address, blockNum uint64, opts ...NFTSnapshotOptions) (*NFTSnapshot, error) {
+	options := nftSnapshotOptions(opts)
+
+	type holdingKey struct {
+		owner   common.Address
+		tokenID string
+	}
+	balances := map[holdingKey]*NFTHolding{}
+	add := func(owner common.Address, tokenID, amount *big.Int) {
+		if owner == (common.Address{}) {
+			return
+		}
+		key := holdingKey{owner, tokenID.String()}
+		h, ok := balances[key]
+		if !ok {
+			h = &NFTHolding{Owner: owner, TokenID: tokenID, Amount: new(big.Int)}
+			balances[key] = h
+		}
+		h.Amount.Add(h.Amount, amount)
+	}
+
+	topics := [][]common.Hash{{TransferSingleEventSig, TransferBatchEventSig}}
+	err := replayLogs(ctx, provider, contract, topics, options.StartBlock, blockNum, options.BatchSize, func(log types.Log) error {
+		if len(log.Topics) != 4 {
+			return nil
+		}
+		from := common.BytesToAddress(log.Topics[2].Bytes())
+		to := common.BytesToAddress(log.Topics[3].Bytes())
+
+		var ids, values []*big.Int
+		switch log.Topics[0] {
+		case TransferSingleEventSig:
+			var ev struct{ Id, Value *big.Int }
+			if err := ERC1155ABI.UnpackIntoInterface(&ev, "TransferSingle", log.Data); err != nil {
+				return fmt.Errorf("ethsnapshot: failed to decode TransferSingle log of tx %s: %w", log.TxHash, err)
+			}
+			ids, values = []*big.Int{ev.Id}, []*big.Int{ev.Value}
+		case TransferBatchEventSig:
+			var ev struct{ Ids, Values []*big.Int }
+			if err := ERC1155ABI.UnpackIntoInterface(&ev, "TransferBatch", log.Data); err != nil {
+				return fmt.Errorf("ethsnapshot: failed to decode TransferBatch log of tx %s: %w", log.TxHash, err)
+			}
+			if len(ev.Ids) != len(ev.Values) {
+				return fmt.Errorf("ethsnapshot: TransferBatch log of tx %s has %d ids and %d values", log.TxHash, len(ev.Ids), len(ev.Values))
+			}
+			ids, values = ev.Ids, ev.Values
+		}
+
+		for i := range ids {
+			add(from, ids[i], new(big.Int).Neg(values[i]))
+			add(to, ids[i], values[i])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	holdings := make([]NFTHolding, 0, len(balances))
+	for _, h := range balances {
+		if h.Amount.Sign() > 0 {
+			holdings = append(holdings, *h)
+		}
+	}
+	sortHoldings(holdings)
+
+	return &NFTSnapshot{
+		Contract: contract,
+		Standard: ERC1155,
+		BlockNum: blockNum,
+		Holdings: holdings,
+	}, nil
+}
+
+func enumerateERC721(ctx context.Context, caller bind.ContractCaller, contract common.Address, block *big.Int, options NFTSnapshotOptions) ([]*big.Int, error) {
+	var totalSupply *big.Int
+	if err := nftCall(ctx, caller, contract, block, &totalSupply, "totalSupply"); err != nil {
+		return nil, fmt.Errorf("ethsnapshot: failed to read total supply: %w", err)
+	}
+	if !totalSupply.IsInt64() {
+		return nil, fmt.Errorf("ethsnapshot: total supply %s is too large to enumerate", totalSupply)
+	}
+
+	tokenIDs := make([]*big.Int, totalSupply.Int64())
+	err := parallel(ctx, options.Concurrency, len(tokenIDs), func(ctx context.Context, i int) error {
+		if err := nftCall(ctx, caller, contract, block, &tokenIDs[i], "tokenByIndex", big.NewInt(int64(i))); err != nil {
+			return fmt.Errorf("ethsnapshot: failed to read token at index %d: %w", i, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tokenIDs, nil
+}
+
+// replayERC721 returns the ids of the tokens which are not burned at blockNum.
+func replayERC721(ctx context.Context, provider ethrpc.Interface, contract common.Address, blockNum uint64, options NFTSnapshotOptions) ([]*big.Int, error) {
+	burned := map[common.Hash]bool{}
+	var order []common.Hash
+
+	topics := [][]common.Hash{{TransferEventSig}}
+	err := replayLogs(ctx, provider, contract, topics, options.StartBlock, blockNum, options.BatchSize, func(log types.Log) error {
+		// ERC-20 transfers don't have the tokenId indexed
+		if len(log.Topics) != 4 {
+			return nil
+		}
+		tokenID := log.Topics[3]
+		if _, ok := burned[tokenID]; !ok {
+			order = append(order, tokenID)
+		}
+		burned[tokenID] = log.Topics[2] == (common.Hash{})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tokenIDs := make([]*big.Int, 0, len(order))
+	for _, tokenID := range order {
+		if !burned[tokenID] {
+			tokenIDs = append(tokenIDs, tokenID.Big())
+		}
+	}
+	return tokenIDs, nil
+}
+
+func replayLogs(ctx context.Context, provider ethrpc.Interface, contract common.Address, topics [][]common.Hash, fromBlock, toBlock, batchSize uint64, fn func(types.Log) error) error {
+	for from := fromBlock; from <= toBlock; from += batchSize {
+		to := min(from+batchSize-1, toBlock)
+		logs, err := provider.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(from),
+			ToBlock:   new(big.Int).SetUint64(to),
+			Addresses: []common.Address{contract},
+			Topics:    topics,
+		})
+		if err != nil {
+			return fmt.Errorf("ethsnapshot: failed to fetch logs of blocks %d to %d: %w", from, to, err)
+		}
+		for _, log := range logs {
+			if err := fn(log); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func nftCall(ctx context.Context, caller bind.ContractCaller, contract common.Address, block *big.Int, result interface{}, method string, args ...interface{}) error {
+	return call(ctx, caller, contract, ERC721ABI, block, result, method, args...)
+}
+
+func call(ctx context.Context, caller bind.ContractCaller, contract common.Address, contractABI abi.ABI, block *big.Int, result interface{}, method string, args ...interface{}) error {
+	data, err := contractABI.Pack(method, args...)
+	if err != nil {
+		return err
+	}
+	output, err := caller.CallContract(ctx, ethereum.CallMsg{To: &contract, Data: data}, block)
+	if err != nil {
+		return err
+	}
+	return contractABI.UnpackIntoInterface(result, method, output)
+}
+
+func parallel(ctx context.Context, concurrency, n int, fn func(ctx context.Context, i int) error) error {
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	for i := 0; i < n; i++ {
+		i := i
+		g.Go(func() error {
+			return fn(ctx, i)
+		})
+	}
+	return g.Wait()
+}
+
+func nftSnapshotOptions(opts []NFTSnapshotOptions) NFTSnapshotOptions {
+	options := DefaultNFTSnapshotOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	if options.BatchSize == 0 {
+		options.BatchSize = DefaultNFTSnapshotOptions.BatchSize
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = DefaultNFTSnapshotOptions.Concurrency
+	}
+	return options
+}
+
+func sortHoldings(holdings []NFTHolding) {
+	sort.Slice(holdings, func(i, j int) bool {
+		if c := holdings[i].TokenID.Cmp(holdings[j].TokenID); c != 0 {
+			return c < 0
+		}
+		return holdings[i].Owner.Hex() < holdings[j].Owner.Hex()
+	})
+}
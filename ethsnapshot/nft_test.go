@@ -0,0 +1,159 @@
+package ethsnapshot_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethmulticall"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethsnapshot"
+	"github.com/0xsequence/ethkit/go-ethereum"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var collection = common.HexToAddress("0xc011")
+
+// mockCollection is an ERC-721 collection behind a node with Multicall3.
+type mockCollection struct {
+	mockProvider
+	enumerable bool
+	owners     map[int64]common.Address
+}
+
+func (p *mockCollection) CodeAt(ctx context.Context, contract common.Address, blockNum *big.Int) ([]byte, error) {
+	return []byte{0x00}, nil
+}
+
+func (p *mockCollection) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNum *big.Int) ([]byte, error) {
+	if *msg.To == ethmulticall.Multicall3Address {
+		method := ethmulticall.Multicall3ABI.Methods["aggregate3"]
+		var calls []ethmulticall.Call
+		args, err := method.Inputs.Unpack(msg.Data[4:])
+		if err != nil {
+			return nil, err
+		}
+		method.Inputs.Copy(&calls, args)
+
+		results := make([]ethmulticall.Result, len(calls))
+		for i, call := range calls {
+			data, err := p.call(call.CallData)
+			results[i] = ethmulticall.Result{Success: err == nil, ReturnData: data}
+		}
+		return method.Outputs.Pack(results)
+	}
+	return p.call(msg.Data)
+}
+
+func (p *mockCollection) call(data []byte) ([]byte, error) {
+	method, err := ethsnapshot.ERC721ABI.MethodById(data[:4])
+	if err != nil {
+		return nil, err
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, err
+	}
+
+	switch method.Name {
+	case "supportsInterface":
+		return method.Outputs.Pack(p.enumerable)
+	case "totalSupply":
+		return method.Outputs.Pack(big.NewInt(int64(len(p.owners))))
+	case "tokenByIndex":
+		var tokenIDs []int64
+		for tokenID := range p.owners {
+			tokenIDs = append(tokenIDs, tokenID)
+		}
+		sort.Slice(tokenIDs, func(i, j int) bool { return tokenIDs[i] < tokenIDs[j] })
+		return method.Outputs.Pack(big.NewInt(tokenIDs[args[0].(*big.Int).Int64()]))
+	case "ownerOf":
+		owner, ok := p.owners[args[0].(*big.Int).Int64()]
+		if !ok {
+			return nil, errors.New("execution reverted")
+		}
+		return method.Outputs.Pack(owner)
+	}
+	return nil, errors.New("execution reverted")
+}
+
+func erc721TransferLog(blockNum uint64, from, to common.Address, tokenID int64) types.Log {
+	return types.Log{
+		Address:     collection,
+		Topics:      []common.Hash{ethsnapshot.TransferEventSig, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes()), common.BigToHash(big.NewInt(tokenID))},
+		BlockNumber: blockNum,
+	}
+}
+
+func TestERC721Snapshot(t *testing.T) {
+	provider := &mockCollection{
+		mockProvider: mockProvider{logs: []types.Log{
+			erc721TransferLog(1, common.Address{}, alice, 1),
+			erc721TransferLog(1, common.Address{}, bob, 2),
+			erc721TransferLog(1, common.Address{}, bob, 3),
+			erc721TransferLog(2, alice, bob, 1),
+			erc721TransferLog(3, bob, common.Address{}, 2),
+		}},
+		owners: map[int64]common.Address{1: bob, 3: bob},
+	}
+
+	ctx := context.Background()
+	for _, enumerable := range []bool{false, true} {
+		provider.enumerable = enumerable
+		snapshot, err := ethsnapshot.ERC721Snapshot(ctx, provider, collection, 10)
+		require.NoError(t, err)
+
+		assert.Equal(t, ethsnapshot.ERC721, snapshot.Standard)
+		require.Len(t, snapshot.Holdings, 2)
+		assert.Equal(t, big.NewInt(1), snapshot.Holdings[0].TokenID)
+		assert.Equal(t, bob, snapshot.Holdings[0].Owner)
+		assert.Equal(t, big.NewInt(3), snapshot.Holdings[1].TokenID)
+		assert.Equal(t, map[common.Address]*big.Int{bob: big.NewInt(2)}, snapshot.Owners())
+	}
+}
+
+func TestERC1155Snapshot(t *testing.T) {
+	single := func(from, to common.Address, id, value int64) types.Log {
+		data, _ := ethsnapshot.ERC1155ABI.Events["TransferSingle"].Inputs.NonIndexed().Pack(big.NewInt(id), big.NewInt(value))
+		return types.Log{
+			Address: collection,
+			Topics:  []common.Hash{ethsnapshot.TransferSingleEventSig, {}, common.BytesToHash(from.Bytes()), common.BytesToHash(to.Bytes())},
+			Data:    data,
+		}
+	}
+	batchData, err := ethsnapshot.ERC1155ABI.Events["TransferBatch"].Inputs.NonIndexed().Pack(
+		[]*big.Int{big.NewInt(1), big.NewInt(2)}, []*big.Int{big.NewInt(5), big.NewInt(7)},
+	)
+	require.NoError(t, err)
+
+	provider := &mockProvider{logs: []types.Log{
+		single(common.Address{}, alice, 1, 10),
+		{
+			Address: collection,
+			Topics:  []common.Hash{ethsnapshot.TransferBatchEventSig, {}, {}, common.BytesToHash(bob.Bytes())},
+			Data:    batchData,
+		},
+		single(alice, bob, 1, 10),
+	}}
+
+	snapshot, err := ethsnapshot.ERC1155Snapshot(context.Background(), provider, collection, 0)
+	require.NoError(t, err)
+	require.Len(t, snapshot.Holdings, 2)
+	assert.Equal(t, map[common.Address]*big.Int{bob: big.NewInt(22)}, snapshot.Owners())
+
+	var buf bytes.Buffer
+	require.NoError(t, snapshot.WriteCSV(&buf))
+	assert.Equal(t, "owner,token_id,amount\n"+bob.Hex()+",1,15\n"+bob.Hex()+",2,7\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, snapshot.WriteJSON(&buf))
+	assert.Contains(t, buf.String(), `"tokenId": "2"`)
+}
+
+var _ ethrpc.Interface = &mockCollection{}
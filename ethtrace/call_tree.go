@@ -0,0 +1,186 @@
+package ethtrace
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// ABIRegistry are the abis of the contracts of a trace, by address, and the abis
+// of any contract, ie. of the erc20 methods.
+type ABIRegistry struct {
+	contracts map[common.Address][]abi.ABI
+	any       []abi.ABI
+	mu        sync.RWMutex
+}
+
+// NewABIRegistry returns a registry of the abis of any contract.
+func NewABIRegistry(abis ...abi.ABI) *ABIRegistry {
+	return &ABIRegistry{contracts: map[common.Address][]abi.ABI{}, any: abis}
+}
+
+// Add adds the abi of any contract.
+func (r *ABIRegistry) Add(contractABI abi.ABI) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.any = append(r.any, contractABI)
+}
+
+// AddContract adds the abi of the contract, which is tried before the abis of
+// any contract for its calls.
+func (r *ABIRegistry) AddContract(contract common.Address, contractABI abi.ABI) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.contracts[contract] = append(r.contracts[contract], contractABI)
+}
+
+// ABIs returns the abis of the contract, then the abis of any contract.
+func (r *ABIRegistry) ABIs(contract common.Address) []abi.ABI {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	abis := make([]abi.ABI, 0, len(r.contracts[contract])+len(r.any))
+	abis = append(abis, r.contracts[contract]...)
+	return append(abis, r.any...)
+}
+
+// DecodedFrame is a call frame of a callTracer trace, with its input, output
+// and revert error decoded by the abis of an ABIRegistry.
+type DecodedFrame struct {
+	*ethrpc.CallFrame
+
+	// Call is the decoded input, or nil when the selector is of none of the abis
+	// or the frame is a contract creation
+	Call *ethcoder.DecodedCall
+
+	// Outputs are the decoded outputs of the method of Call, of a frame which
+	// didn't revert
+	Outputs ethcoder.AbiValues
+
+	// RevertReason is the message of the Error(string) or Panic(uint256) of a
+	// frame which reverted
+	RevertReason string
+
+	// RevertError is the decoded custom error of a frame which reverted
+	RevertError *ethcoder.DecodedError
+
+	// Err is the error of the decoding of the frame, ie. of malformed calldata
+	Err error
+
+	Calls []*DecodedFrame
+}
+
+// Walk visits the frame and all of its nested calls depth-first. The depth of
+// the root frame is 0.
+func (f *DecodedFrame) Walk(fn func(frame *DecodedFrame, depth int)) {
+	f.walk(fn, 0)
+}
+
+func (f *DecodedFrame) walk(fn func(frame *DecodedFrame, depth int), depth int) {
+	if f == nil {
+		return
+	}
+	fn(f, depth)
+	for _, call := range f.Calls {
+		call.walk(fn, depth+1)
+	}
+}
+
+// DecodeCallTrace decodes the frames of a callTracer trace, ie. of
+// debug_traceTransaction, by the abis of the registry of the contracts of the
+// frames. The frames of the selectors of none of the abis are not decoded.
+func DecodeCallTrace(root *ethrpc.CallFrame, registry *ABIRegistry) *DecodedFrame {
+	if root == nil {
+		return nil
+	}
+	frame := &DecodedFrame{CallFrame: root, Calls: make([]*DecodedFrame, len(root.Calls))}
+	frame.decode(registry)
+	for i, call := range root.Calls {
+		frame.Calls[i] = DecodeCallTrace(call, registry)
+	}
+	return frame
+}
+
+// DecodeCallTraceJSON decodes the json of a callTracer trace, see
+// DecodeCallTrace.
+func DecodeCallTraceJSON(data []byte, registry *ABIRegistry) (*DecodedFrame, error) {
+	var root *ethrpc.CallFrame
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("ethtrace: failed to decode call trace: %w", err)
+	}
+	if root == nil {
+		return nil, fmt.Errorf("ethtrace: call trace is empty")
+	}
+	return DecodeCallTrace(root, registry), nil
+}
+
+func (f *DecodedFrame) decode(registry *ABIRegistry) {
+	var abis []abi.ABI
+	if f.To != nil {
+		abis = registry.ABIs(*f.To)
+	}
+	reverted := f.Error != ""
+
+	var method *abi.Method
+	if f.To != nil && !isCreateOp(f.Type) && len(f.Input) >= 4 {
+		method = findMethod(abis, f.Input[:4])
+	}
+	if method != nil {
+		var err error
+		contractABI := abi.ABI{Methods: map[string]abi.Method{method.Name: *method}}
+		if f.Call, err = ethcoder.DecodeCalldata(contractABI, f.Input); err != nil {
+			f.Err = err
+		} else if !reverted && len(method.Outputs) > 0 {
+			values, err := method.Outputs.UnpackValues(f.Output)
+			if err != nil {
+				f.Err = fmt.Errorf("ethtrace: failed to decode output of %s: %w", method.Sig, err)
+			} else {
+				f.Outputs = ethcoder.NewAbiValues(method.Outputs, values)
+			}
+		}
+	}
+
+	if !reverted || len(f.Output) < 4 {
+		return
+	}
+	reason, err := ethcoder.DecodeRevertReason(f.Output)
+	if err == nil {
+		f.RevertReason = reason
+		return
+	}
+	if !errors.Is(err, ethcoder.ErrUnknownRevertError) {
+		f.Err = err
+		return
+	}
+	var errs []abi.Error
+	for _, contractABI := range abis {
+		for _, abiErr := range contractABI.Errors {
+			errs = append(errs, abiErr)
+		}
+	}
+	revertErr, err := ethcoder.DecodeRevertError(errs, f.Output)
+	if err != nil && !errors.Is(err, ethcoder.ErrUnknownRevertError) {
+		f.Err = err
+		return
+	}
+	f.RevertError = revertErr
+}
+
+// findMethod returns the method of the selector of the first abi which has it.
+func findMethod(abis []abi.ABI, selector []byte) *abi.Method {
+	for _, contractABI := range abis {
+		for _, method := range contractABI.Methods {
+			if bytes.Equal(method.ID, selector) {
+				m := method
+				return &m
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package ethtrace_test
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethcontract"
+	"github.com/0xsequence/ethkit/ethtrace"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeCallTrace(t *testing.T) {
+	token := common.HexToAddress("0x2000000000000000000000000000000000000002")
+	vault := common.HexToAddress("0x3000000000000000000000000000000000000003")
+	recipient := common.HexToAddress("0x4000000000000000000000000000000000000004")
+
+	erc20ABI := ethcontract.MustParseABI(`[
+		{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+		{"type":"function","name":"balanceOf","inputs":[{"name":"account","type":"address"}],"outputs":[{"name":"balance","type":"uint256"}]}
+	]`)
+	vaultABI := ethcontract.MustParseABI(`[
+		{"type":"function","name":"withdraw","inputs":[{"name":"amount","type":"uint256"}],"outputs":[]},
+		{"type":"error","name":"InsufficientBalance","inputs":[{"name":"available","type":"uint256"}]}
+	]`)
+	registry := ethtrace.NewABIRegistry(erc20ABI)
+	registry.AddContract(vault, vaultABI)
+
+	transfer, err := erc20ABI.Pack("transfer", recipient, big.NewInt(100))
+	require.NoError(t, err)
+	balanceOf, err := erc20ABI.Pack("balanceOf", recipient)
+	require.NoError(t, err)
+	withdraw, err := vaultABI.Pack("withdraw", big.NewInt(5))
+	require.NoError(t, err)
+	insufficientBalance, err := ethcoder.AbiEncodeMethodCalldata("InsufficientBalance(uint256)", []interface{}{big.NewInt(1)})
+	require.NoError(t, err)
+	reason, err := ethcoder.AbiEncodeMethodCalldata("Error(string)", []interface{}{"not allowed"})
+	require.NoError(t, err)
+
+	trace := fmt.Sprintf(`{
+		"type": "CALL", "from": "0x1000000000000000000000000000000000000001", "to": "%s",
+		"gas": "0x30d40", "gasUsed": "0xc350", "input": "%s", "output": "%s",
+		"calls": [
+			{"type": "STATICCALL", "from": "%s", "to": "%s", "gas": "0x2710", "gasUsed": "0x1388", "input": "%s", "output": "%s"},
+			{"type": "CALL", "from": "%s", "to": "%s", "gas": "0x2710", "gasUsed": "0x1388", "input": "%s", "output": "%s", "error": "execution reverted"},
+			{"type": "CALL", "from": "%s", "to": "%s", "gas": "0x2710", "gasUsed": "0x1388", "input": "%s", "output": "%s", "error": "execution reverted"},
+			{"type": "CALL", "from": "%s", "to": "%s", "gas": "0x2710", "gasUsed": "0x1388", "input": "0xdeadbeef"},
+			{"type": "CREATE", "from": "%s", "to": "%s", "gas": "0x2710", "gasUsed": "0x1388", "input": "0x6080604052"}
+		]
+	}`,
+		token, ethcoder.HexEncode(transfer), ethcoder.HexEncode(common.LeftPadBytes([]byte{1}, 32)),
+		token, token, ethcoder.HexEncode(balanceOf), ethcoder.HexEncode(common.LeftPadBytes([]byte{42}, 32)),
+		token, vault, ethcoder.HexEncode(withdraw), ethcoder.HexEncode(insufficientBalance),
+		token, recipient, ethcoder.HexEncode(transfer), ethcoder.HexEncode(reason),
+		token, recipient,
+		token, recipient,
+	)
+
+	root, err := ethtrace.DecodeCallTraceJSON([]byte(trace), registry)
+	require.NoError(t, err)
+
+	require.NotNil(t, root.Call)
+	assert.Equal(t, "transfer(address,uint256)", root.Call.Signature)
+	assert.Equal(t, big.NewInt(100), root.Call.Map()["amount"])
+	assert.Equal(t, true, root.Outputs[0].Value)
+	require.Len(t, root.Calls, 5)
+
+	balance, ok := root.Calls[0].Outputs.Get("balance")
+	assert.True(t, ok)
+	assert.Equal(t, big.NewInt(42), balance)
+
+	// the custom error of the abi of the contract
+	require.NotNil(t, root.Calls[1].RevertError)
+	assert.Equal(t, "InsufficientBalance", root.Calls[1].RevertError.Name)
+	assert.Nil(t, root.Calls[1].Outputs)
+
+	assert.Equal(t, "not allowed", root.Calls[2].RevertReason)
+	assert.Nil(t, root.Calls[2].RevertError)
+
+	assert.Nil(t, root.Calls[3].Call)
+	assert.NoError(t, root.Calls[3].Err)
+	assert.Nil(t, root.Calls[4].Call)
+
+	var depths []int
+	root.Walk(func(frame *ethtrace.DecodedFrame, depth int) {
+		depths = append(depths, depth)
+	})
+	assert.Equal(t, []int{0, 1, 1, 1, 1, 1}, depths)
+
+	t.Run("malformed", func(t *testing.T) {
+		frame, err := ethtrace.DecodeCallTraceJSON([]byte(fmt.Sprintf(`{"type":"CALL","from":"%s","to":"%s","gas":"0x0","gasUsed":"0x0","input":"%s"}`, token, token, ethcoder.HexEncode(transfer[:20]))), registry)
+		require.NoError(t, err)
+		assert.Nil(t, frame.Call)
+		assert.Error(t, frame.Err)
+
+		_, err = ethtrace.DecodeCallTraceJSON([]byte(`null`), registry)
+		assert.Error(t, err)
+	})
+}
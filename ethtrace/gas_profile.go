@@ -0,0 +1,175 @@
+package ethtrace
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/abi"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// FunctionResolver returns the human readable name of the function identified by
+// selector on the contract, ie. "transfer(address,uint256)".
+type FunctionResolver func(contract common.Address, selector [4]byte) (string, bool)
+
+// ABIFunctionResolver returns a FunctionResolver which looks up selectors in the
+// methods of the given contract abis, regardless of the contract address.
+func ABIFunctionResolver(contractABIs ...abi.ABI) FunctionResolver {
+	return func(contract common.Address, selector [4]byte) (string, bool) {
+		for _, contractABI := range contractABIs {
+			method, err := contractABI.MethodById(selector[:])
+			if err == nil {
+				return method.Sig, true
+			}
+		}
+		return "", false
+	}
+}
+
+type GasProfileOptions struct {
+	// ResolveFunction is used to name the functions in the report, otherwise
+	// functions are reported by their selector.
+	ResolveFunction FunctionResolver
+}
+
+// GasProfile is the gas usage of a transaction trace aggregated by contract
+// and function.
+type GasProfile struct {
+	// TotalGas is the gas used by the root frame, including intrinsic gas.
+	TotalGas uint64
+
+	// Entries sorted by SelfGas, descending.
+	Entries []*GasProfileEntry
+}
+
+type GasProfileEntry struct {
+	Contract common.Address
+	Selector [4]byte
+	Function string
+
+	// Calls is the number of frames aggregated into this entry.
+	Calls int
+
+	// GasUsed is the gas used by the frames including their nested calls.
+	// NOTE: recursive calls into the same function are counted more than once.
+	GasUsed uint64
+
+	// SelfGas is the gas used by the frames excluding their nested calls. The
+	// sum of SelfGas over all entries equals the profile TotalGas.
+	SelfGas uint64
+
+	// Percent is SelfGas as a percentage of the profile TotalGas.
+	Percent float64
+}
+
+type gasProfileKey struct {
+	contract common.Address
+	selector [4]byte
+	function string
+}
+
+// ProfileGas aggregates the frames of a callTracer trace by contract and function.
+func ProfileGas(root *ethrpc.CallFrame, options ...GasProfileOptions) *GasProfile {
+	var opts GasProfileOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	profile := &GasProfile{}
+	if root == nil {
+		return profile
+	}
+	profile.TotalGas = root.GasUsed
+
+	entries := map[gasProfileKey]*GasProfileEntry{}
+	root.Walk(func(frame *ethrpc.CallFrame, depth int) {
+		key := frameKey(frame, opts.ResolveFunction)
+
+		entry, ok := entries[key]
+		if !ok {
+			entry = &GasProfileEntry{
+				Contract: key.contract,
+				Selector: key.selector,
+				Function: key.function,
+			}
+			entries[key] = entry
+		}
+
+		var childGas uint64
+		for _, call := range frame.Calls {
+			childGas += call.GasUsed
+		}
+
+		entry.Calls++
+		entry.GasUsed += frame.GasUsed
+		if frame.GasUsed > childGas {
+			entry.SelfGas += frame.GasUsed - childGas
+		}
+	})
+
+	for _, entry := range entries {
+		if profile.TotalGas > 0 {
+			entry.Percent = float64(entry.SelfGas) * 100 / float64(profile.TotalGas)
+		}
+		profile.Entries = append(profile.Entries, entry)
+	}
+
+	sort.Slice(profile.Entries, func(i, j int) bool {
+		a, b := profile.Entries[i], profile.Entries[j]
+		if a.SelfGas != b.SelfGas {
+			return a.SelfGas > b.SelfGas
+		}
+		if a.Contract != b.Contract {
+			return a.Contract.Hex() < b.Contract.Hex()
+		}
+		return a.Function < b.Function
+	})
+
+	return profile
+}
+
+func frameKey(frame *ethrpc.CallFrame, resolve FunctionResolver) gasProfileKey {
+	var key gasProfileKey
+	if frame.To != nil {
+		key.contract = *frame.To
+	}
+
+	switch frame.Type {
+	case "CREATE", "CREATE2":
+		key.function = "(create)"
+		return key
+	}
+
+	if len(frame.Input) == 0 {
+		key.function = "(receive)"
+		return key
+	}
+	if len(frame.Input) < 4 {
+		key.function = "(fallback)"
+		return key
+	}
+
+	copy(key.selector[:], frame.Input[:4])
+	if resolve != nil {
+		if name, ok := resolve(key.contract, key.selector); ok {
+			key.function = name
+			return key
+		}
+	}
+	key.function = fmt.Sprintf("0x%x", key.selector)
+	return key
+}
+
+// WriteTable writes the profile as an aligned text table.
+func (p *GasProfile) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CONTRACT\tFUNCTION\tCALLS\tGAS\tSELF GAS\t% TOTAL")
+	for _, e := range p.Entries {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%.2f%%\n", e.Contract.Hex(), e.Function, e.Calls, e.GasUsed, e.SelfGas, e.Percent)
+	}
+	fmt.Fprintf(tw, "TOTAL\t\t\t%d\t\t\n", p.TotalGas)
+	return tw.Flush()
+}
@@ -0,0 +1,89 @@
+package ethtrace_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcontract"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethtrace"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTrace = `{
+	"type": "CALL",
+	"from": "0x1000000000000000000000000000000000000001",
+	"to": "0x2000000000000000000000000000000000000002",
+	"gas": "0x30d40",
+	"gasUsed": "0xc350",
+	"input": "0xa9059cbb",
+	"calls": [
+		{
+			"type": "CALL",
+			"from": "0x2000000000000000000000000000000000000002",
+			"to": "0x3000000000000000000000000000000000000003",
+			"gas": "0x2710",
+			"gasUsed": "0x1388",
+			"input": "0x70a08231"
+		},
+		{
+			"type": "STATICCALL",
+			"from": "0x2000000000000000000000000000000000000002",
+			"to": "0x3000000000000000000000000000000000000003",
+			"gas": "0x2710",
+			"gasUsed": "0x1388",
+			"input": "0x70a08231"
+		},
+		{
+			"type": "CALL",
+			"from": "0x2000000000000000000000000000000000000002",
+			"to": "0x4000000000000000000000000000000000000004",
+			"gas": "0x2710",
+			"gasUsed": "0x2710",
+			"value": "0x1",
+			"input": "0x"
+		}
+	]
+}`
+
+func TestProfileGas(t *testing.T) {
+	var frame *ethrpc.CallFrame
+	require.NoError(t, json.Unmarshal([]byte(testTrace), &frame))
+	assert.Equal(t, uint64(50000), frame.GasUsed)
+	assert.Len(t, frame.Calls, 3)
+
+	erc20ABI := ethcontract.MustParseABI(`[{"type":"function","name":"balanceOf","inputs":[{"name":"account","type":"address"}],"outputs":[{"name":"","type":"uint256"}]}]`)
+
+	profile := ethtrace.ProfileGas(frame, ethtrace.GasProfileOptions{
+		ResolveFunction: ethtrace.ABIFunctionResolver(erc20ABI),
+	})
+	assert.Equal(t, uint64(50000), profile.TotalGas)
+	require.Len(t, profile.Entries, 3)
+
+	// root frame: 50000 - 5000 - 5000 - 10000
+	assert.Equal(t, common.HexToAddress("0x2000000000000000000000000000000000000002"), profile.Entries[0].Contract)
+	assert.Equal(t, "0xa9059cbb", profile.Entries[0].Function)
+	assert.Equal(t, uint64(30000), profile.Entries[0].SelfGas)
+	assert.Equal(t, uint64(50000), profile.Entries[0].GasUsed)
+	assert.Equal(t, 60.0, profile.Entries[0].Percent)
+
+	assert.Equal(t, "balanceOf(address)", profile.Entries[1].Function)
+	assert.Equal(t, 2, profile.Entries[1].Calls)
+	assert.Equal(t, uint64(10000), profile.Entries[1].SelfGas)
+
+	assert.Equal(t, "(receive)", profile.Entries[2].Function)
+
+	var total uint64
+	for _, e := range profile.Entries {
+		total += e.SelfGas
+	}
+	assert.Equal(t, profile.TotalGas, total)
+
+	var buf bytes.Buffer
+	require.NoError(t, profile.WriteTable(&buf))
+	assert.Contains(t, buf.String(), "balanceOf(address)")
+	assert.Contains(t, buf.String(), "60.00%")
+}
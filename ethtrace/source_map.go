@@ -0,0 +1,212 @@
+package ethtrace
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/0xsequence/ethkit/ethartifact"
+	"github.com/0xsequence/ethkit/ethdisasm"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// SourceMap is a decompressed solc source map, with one entry per instruction,
+// see https://docs.soliditylang.org/en/latest/internals/source_mappings.html
+type SourceMap []SourceMapEntry
+
+type SourceMapEntry struct {
+	// Offset is the byte offset of the source range in the file.
+	Offset int
+
+	// Length is the byte length of the source range.
+	Length int
+
+	// FileIndex is the solc source id of the file, or -1 if the instruction
+	// does not map to any source file (ie. compiler generated code).
+	FileIndex int
+
+	// Jump is "i" for a jump into a function, "o" for a return from
+	// a function and "-" for a regular jump.
+	Jump string
+
+	// ModifierDepth is the modifier depth of the instruction.
+	ModifierDepth int
+}
+
+// ParseSourceMap decompresses a solc source map in the "s:l:f:j:m;..." format.
+func ParseSourceMap(sourceMap string) (SourceMap, error) {
+	sourceMap = strings.TrimSpace(sourceMap)
+	if sourceMap == "" {
+		return SourceMap{}, nil
+	}
+
+	items := strings.Split(sourceMap, ";")
+	sm := make(SourceMap, 0, len(items))
+
+	prev := SourceMapEntry{FileIndex: -1, Jump: "-"}
+	for i, item := range items {
+		entry := prev
+		fields := strings.Split(item, ":")
+		if len(fields) > 5 {
+			return nil, fmt.Errorf("ethtrace: invalid source map entry %d '%s'", i, item)
+		}
+
+		for j, field := range fields {
+			if field == "" {
+				continue
+			}
+			if j == 3 {
+				if field != "i" && field != "o" && field != "-" {
+					return nil, fmt.Errorf("ethtrace: invalid jump type '%s' in source map entry %d", field, i)
+				}
+				entry.Jump = field
+				continue
+			}
+
+			v, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("ethtrace: invalid source map entry %d '%s': %w", i, item, err)
+			}
+			switch j {
+			case 0:
+				entry.Offset = v
+			case 1:
+				entry.Length = v
+			case 2:
+				entry.FileIndex = v
+			case 4:
+				entry.ModifierDepth = v
+			}
+		}
+
+		sm = append(sm, entry)
+		prev = entry
+	}
+
+	return sm, nil
+}
+
+// Source is a source file referenced by a source map.
+type Source struct {
+	Path    string
+	Content string
+}
+
+// SourceLocation is the resolved source position of an instruction.
+type SourceLocation struct {
+	Path   string
+	Line   int // 1-based
+	Column int // 1-based
+	Offset int
+	Length int
+
+	// Snippet is the source text of the mapped range.
+	Snippet string
+}
+
+func (l SourceLocation) String() string {
+	return fmt.Sprintf("%s:%d:%d", l.Path, l.Line, l.Column)
+}
+
+// SourceMapper maps program counters of a contract to source locations.
+type SourceMapper struct {
+	program   *ethdisasm.Program
+	sourceMap SourceMap
+	sources   map[int]Source
+}
+
+// NewSourceMapper returns a SourceMapper for the bytecode and its source map,
+// with sources keyed by their solc source id.
+func NewSourceMapper(code []byte, sourceMap string, sources map[int]Source) (*SourceMapper, error) {
+	sm, err := ParseSourceMap(sourceMap)
+	if err != nil {
+		return nil, err
+	}
+	if sources == nil {
+		sources = map[int]Source{}
+	}
+	return &SourceMapper{
+		program:   ethdisasm.Disassemble(code),
+		sourceMap: sm,
+		sources:   sources,
+	}, nil
+}
+
+// NewSourceMapperFromArtifact returns a SourceMapper for the deployed
+// bytecode of a truffle artifact which includes its source map and source.
+func NewSourceMapperFromArtifact(artifact ethartifact.RawArtifact) (*SourceMapper, error) {
+	if artifact.DeployedSourceMap == "" {
+		return nil, fmt.Errorf("ethtrace: artifact %s has no deployed source map", artifact.ContractName)
+	}
+
+	// The solc source id of the artifact source is found in the "src" of the
+	// root ast node, ie. "0:1234:3"
+	fileIndex := 0
+	if len(artifact.AST) > 0 {
+		var ast struct {
+			Src string `json:"src"`
+		}
+		if err := json.Unmarshal(artifact.AST, &ast); err == nil {
+			p := strings.Split(ast.Src, ":")
+			if len(p) == 3 {
+				if v, err := strconv.Atoi(p[2]); err == nil {
+					fileIndex = v
+				}
+			}
+		}
+	}
+
+	sources := map[int]Source{}
+	if artifact.Source != "" {
+		sources[fileIndex] = Source{Path: artifact.SourcePath, Content: artifact.Source}
+	}
+
+	return NewSourceMapper(common.FromHex(artifact.DeployedBytecode), artifact.DeployedSourceMap, sources)
+}
+
+// Program returns the disassembled program of the mapper.
+func (m *SourceMapper) Program() *ethdisasm.Program {
+	return m.program
+}
+
+// Entry returns the source map entry of the instruction at pc.
+func (m *SourceMapper) Entry(pc uint64) (SourceMapEntry, bool) {
+	i := m.program.InstructionIndex(pc)
+	if i < 0 || i >= len(m.sourceMap) {
+		return SourceMapEntry{}, false
+	}
+	return m.sourceMap[i], true
+}
+
+// Lookup returns the source location of the instruction at pc. It returns
+// false if the instruction does not map to a known source file.
+func (m *SourceMapper) Lookup(pc uint64) (SourceLocation, bool) {
+	entry, ok := m.Entry(pc)
+	if !ok || entry.FileIndex < 0 {
+		return SourceLocation{}, false
+	}
+	source, ok := m.sources[entry.FileIndex]
+	if !ok {
+		return SourceLocation{}, false
+	}
+	if entry.Offset < 0 || entry.Offset > len(source.Content) {
+		return SourceLocation{}, false
+	}
+
+	loc := SourceLocation{
+		Path:   source.Path,
+		Line:   1 + strings.Count(source.Content[:entry.Offset], "\n"),
+		Offset: entry.Offset,
+		Length: entry.Length,
+	}
+	loc.Column = entry.Offset - strings.LastIndex(source.Content[:entry.Offset], "\n")
+
+	end := entry.Offset + entry.Length
+	if end > len(source.Content) {
+		end = len(source.Content)
+	}
+	loc.Snippet = source.Content[entry.Offset:end]
+
+	return loc, true
+}
@@ -0,0 +1,84 @@
+package ethtrace_test
+
+import (
+	"testing"
+
+	"github.com/0xsequence/ethkit"
+	"github.com/0xsequence/ethkit/ethartifact"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethtrace"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSource = "contract A {\n  function f() public {\n    revert();\n  }\n}\n"
+
+func TestParseSourceMap(t *testing.T) {
+	sm, err := ethtrace.ParseSourceMap("1:2:1;:9;2:1:2;;;1:3:-1:o")
+	require.NoError(t, err)
+	require.Len(t, sm, 6)
+
+	assert.Equal(t, ethtrace.SourceMapEntry{Offset: 1, Length: 2, FileIndex: 1, Jump: "-"}, sm[0])
+	assert.Equal(t, ethtrace.SourceMapEntry{Offset: 1, Length: 9, FileIndex: 1, Jump: "-"}, sm[1])
+	assert.Equal(t, ethtrace.SourceMapEntry{Offset: 2, Length: 1, FileIndex: 2, Jump: "-"}, sm[2])
+	assert.Equal(t, sm[2], sm[4])
+	assert.Equal(t, ethtrace.SourceMapEntry{Offset: 1, Length: 3, FileIndex: -1, Jump: "o"}, sm[5])
+
+	_, err = ethtrace.ParseSourceMap("1:2:x")
+	assert.Error(t, err)
+}
+
+func TestSourceMapperFromArtifact(t *testing.T) {
+	artifact := ethartifact.RawArtifact{
+		ContractName:      "A",
+		DeployedBytecode:  "0x60006000fd",
+		DeployedSourceMap: "0:56:3:-:0;;41:8:3",
+		Source:            testSource,
+		SourcePath:        "contracts/A.sol",
+		AST:               []byte(`{"src":"0:56:3"}`),
+	}
+	mapper, err := ethtrace.NewSourceMapperFromArtifact(artifact)
+	require.NoError(t, err)
+
+	loc, ok := mapper.Lookup(4)
+	require.True(t, ok)
+	assert.Equal(t, "contracts/A.sol:3:5", loc.String())
+	assert.Equal(t, "revert()", loc.Snippet)
+
+	// pc 1 is push data
+	_, ok = mapper.Lookup(1)
+	assert.False(t, ok)
+
+	// revert inside of a nested call
+	addrA := common.HexToAddress("0xaa")
+	addrB := common.HexToAddress("0xbb")
+	root := &ethrpc.CallFrame{Type: "CALL", To: &addrB, Error: "execution reverted", Calls: []*ethrpc.CallFrame{
+		{Type: "STATICCALL", To: ethkit.ToPtr(common.HexToAddress("0x01"))},
+		{Type: "CALL", To: &addrA, Error: "execution reverted"},
+	}}
+	trace := &ethrpc.StructLogTrace{Failed: true, StructLogs: []ethrpc.StructLog{
+		{PC: 0, Op: "PUSH1", Depth: 1},
+		{PC: 10, Op: "STATICCALL", Depth: 1},
+		{PC: 11, Op: "POP", Depth: 1},
+		{PC: 20, Op: "CALL", Depth: 1},
+		{PC: 0, Op: "PUSH1", Depth: 2},
+		{PC: 2, Op: "PUSH1", Depth: 2},
+		{PC: 4, Op: "REVERT", Depth: 2},
+		{PC: 21, Op: "REVERT", Depth: 1},
+	}}
+
+	// the stack of the inner revert, which bubbles up to the outer frame
+	stack := ethtrace.RevertStackTrace(trace, root)
+	require.Len(t, stack, 2)
+	assert.Equal(t, addrB, stack[0].Address)
+	assert.Equal(t, addrA, stack[1].Address)
+	assert.Equal(t, "REVERT", stack[1].Op)
+	assert.Equal(t, uint64(4), stack[1].PC)
+
+	stack = stack.Annotate(map[common.Address]*ethtrace.SourceMapper{addrA: mapper})
+	require.NotNil(t, stack[1].Location)
+	assert.Nil(t, stack[0].Location)
+	assert.Equal(t, 3, stack[1].Location.Line)
+	assert.Contains(t, stack.String(), "contracts/A.sol:3:5")
+}
@@ -0,0 +1,180 @@
+package ethtrace
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// StackFrame is the position of execution inside of a single call frame.
+type StackFrame struct {
+	// Address of the contract executing, which is the address being created
+	// for CREATE and CREATE2 frames.
+	Address common.Address
+
+	// Type is the call type, ie. CALL, DELEGATECALL or CREATE.
+	Type string
+
+	// PC and Op of the last executed instruction in the frame.
+	PC uint64
+	Op string
+
+	// Location is the source location of PC, if it could be resolved.
+	Location *SourceLocation
+}
+
+func (f StackFrame) String() string {
+	if f.Location != nil {
+		return fmt.Sprintf("%s %s pc=%d (%s) at %s", f.Type, f.Address.Hex(), f.PC, f.Op, f.Location)
+	}
+	return fmt.Sprintf("%s %s pc=%d (%s)", f.Type, f.Address.Hex(), f.PC, f.Op)
+}
+
+// StackTrace is a list of frames, outermost call first.
+type StackTrace []StackFrame
+
+func (s StackTrace) String() string {
+	var sb strings.Builder
+	for i := len(s) - 1; i >= 0; i-- {
+		sb.WriteString(s[i].String())
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// RevertStackTrace returns the stack of call frames at the revert of a failed
+// transaction, ie. at the REVERT, INVALID or erroring instruction of the
+// deepest frame of which the error reached the top level. A revert caught by
+// its caller, ie. of a try/catch or a low-level call, is not part of the stack.
+// The struct logs are paired with the call frames of the callTracer trace of
+// the same transaction to know which contract is executing at each depth. It
+// returns nil if the transaction did not fail.
+func RevertStackTrace(trace *ethrpc.StructLogTrace, root *ethrpc.CallFrame) StackTrace {
+	if trace == nil || root == nil || (!trace.Failed && root.Error == "") {
+		return nil
+	}
+	chain := failedCallChain(root)
+
+	// call frames in execution order, excluding the root
+	var frames []*ethrpc.CallFrame
+	root.Walk(func(frame *ethrpc.CallFrame, depth int) {
+		if depth > 0 {
+			frames = append(frames, frame)
+		}
+	})
+
+	stack := StackTrace{newStackFrame(root)}
+	stackFrames := []*ethrpc.CallFrame{root}
+	next := 0
+
+	var reverted StackTrace
+	var revertedOp bool
+	var prevOp string
+	for _, log := range trace.StructLogs {
+		if log.Depth > len(stack) {
+			for log.Depth > len(stack) && next < len(frames) {
+				stack = append(stack, newStackFrame(frames[next]))
+				stackFrames = append(stackFrames, frames[next])
+				next++
+			}
+		} else if log.Depth == len(stack) && isCallOp(prevOp) && next < len(frames) && len(frames[next].Calls) == 0 {
+			// the previous call did not execute any code, ie. a call to an
+			// account without code or to a precompile, but it still has a frame
+			// in the callTracer trace. Skip it.
+			next++
+		}
+		if log.Depth < len(stack) && log.Depth > 0 {
+			stack = stack[:log.Depth]
+			stackFrames = stackFrames[:log.Depth]
+		}
+
+		top := &stack[len(stack)-1]
+		top.PC = log.PC
+		top.Op = log.Op
+		prevOp = log.Op
+
+		// the position in the deepest frame of the chain, at its revert
+		if !isCallChainPrefix(stackFrames, chain) || len(stack) < len(reverted) || (len(stack) == len(reverted) && revertedOp) {
+			continue
+		}
+		reverted = append(reverted[:0], stack...)
+		revertedOp = log.Op == "REVERT" || log.Op == "INVALID" || log.Error != ""
+	}
+
+	if len(reverted) == 0 {
+		return nil
+	}
+	return reverted
+}
+
+// failedCallChain returns the chain of the failed call frames from the root of
+// which the error reached the top level, ie. of the last failed call of each
+// frame of which the revert data is the one of the frame.
+func failedCallChain(root *ethrpc.CallFrame) []*ethrpc.CallFrame {
+	chain := []*ethrpc.CallFrame{root}
+	for frame := root; ; {
+		var failed *ethrpc.CallFrame
+		for _, call := range frame.Calls {
+			if call.Error != "" {
+				failed = call
+			}
+		}
+		if failed == nil || !bytes.Equal(failed.Output, frame.Output) {
+			return chain
+		}
+		chain = append(chain, failed)
+		frame = failed
+	}
+}
+
+func isCallChainPrefix(frames, chain []*ethrpc.CallFrame) bool {
+	if len(frames) > len(chain) {
+		return false
+	}
+	for i, frame := range frames {
+		if frame != chain[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Annotate resolves the source location of each frame using the source mappers
+// keyed by contract address. Frames without a mapper are left as-is.
+func (s StackTrace) Annotate(mappers map[common.Address]*SourceMapper) StackTrace {
+	out := make(StackTrace, len(s))
+	for i, frame := range s {
+		out[i] = frame
+		mapper, ok := mappers[frame.Address]
+		if !ok || mapper == nil || isCreateOp(frame.Type) {
+			continue
+		}
+		if loc, ok := mapper.Lookup(frame.PC); ok {
+			out[i].Location = &loc
+		}
+	}
+	return out
+}
+
+func newStackFrame(frame *ethrpc.CallFrame) StackFrame {
+	f := StackFrame{Type: frame.Type}
+	if frame.To != nil {
+		f.Address = *frame.To
+	}
+	return f
+}
+
+func isCallOp(op string) bool {
+	switch op {
+	case "CALL", "CALLCODE", "DELEGATECALL", "STATICCALL":
+		return true
+	}
+	return isCreateOp(op)
+}
+
+func isCreateOp(op string) bool {
+	return op == "CREATE" || op == "CREATE2"
+}
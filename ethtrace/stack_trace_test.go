@@ -0,0 +1,60 @@
+package ethtrace_test
+
+import (
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethtrace"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevertStackTraceCaught(t *testing.T) {
+	addrA := common.HexToAddress("0xaa")
+	addrB := common.HexToAddress("0xbb")
+	addrC := common.HexToAddress("0xcc")
+
+	// B calls A, which reverts and is caught by B, then calls C
+	logs := []ethrpc.StructLog{
+		{PC: 0, Op: "PUSH1", Depth: 1},
+		{PC: 10, Op: "CALL", Depth: 1},
+		{PC: 0, Op: "PUSH1", Depth: 2},
+		{PC: 4, Op: "REVERT", Depth: 2},
+		{PC: 11, Op: "POP", Depth: 1},
+		{PC: 20, Op: "CALL", Depth: 1},
+		{PC: 0, Op: "PUSH1", Depth: 2},
+		{PC: 7, Op: "STOP", Depth: 2},
+		{PC: 21, Op: "STOP", Depth: 1},
+	}
+	root := &ethrpc.CallFrame{Type: "CALL", To: &addrB, Calls: []*ethrpc.CallFrame{
+		{Type: "CALL", To: &addrA, Error: "execution reverted", Output: []byte{0x01}},
+		{Type: "CALL", To: &addrC},
+	}}
+
+	// the transaction succeeded
+	stack := ethtrace.RevertStackTrace(&ethrpc.StructLogTrace{StructLogs: logs}, root)
+	assert.Nil(t, stack)
+
+	// B reverts of its own after the caught revert of A, so the revert of A is
+	// not part of the stack
+	logs[len(logs)-1].Op = "REVERT"
+	root.Error = "execution reverted"
+	root.Output = []byte{0x02}
+	stack = ethtrace.RevertStackTrace(&ethrpc.StructLogTrace{Failed: true, StructLogs: logs}, root)
+	require.Len(t, stack, 1)
+	assert.Equal(t, addrB, stack[0].Address)
+	assert.Equal(t, "REVERT", stack[0].Op)
+	assert.Equal(t, uint64(21), stack[0].PC)
+
+	// B bubbles up the revert of C after the caught revert of A
+	root.Calls[1].Error = "execution reverted"
+	root.Calls[1].Output = root.Output
+	logs[len(logs)-2].Op = "REVERT"
+	stack = ethtrace.RevertStackTrace(&ethrpc.StructLogTrace{Failed: true, StructLogs: logs}, root)
+	require.Len(t, stack, 2)
+	assert.Equal(t, addrB, stack[0].Address)
+	assert.Equal(t, uint64(20), stack[0].PC)
+	assert.Equal(t, addrC, stack[1].Address)
+	assert.Equal(t, uint64(7), stack[1].PC)
+}
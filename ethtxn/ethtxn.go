@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/0xsequence/ethkit/ethgas"
 	"github.com/0xsequence/ethkit/ethrpc"
 	"github.com/0xsequence/ethkit/go-ethereum"
 	"github.com/0xsequence/ethkit/go-ethereum/common"
 	"github.com/0xsequence/ethkit/go-ethereum/core"
 	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/holiman/uint256"
 )
 
 type TransactionRequest struct {
@@ -36,10 +38,19 @@ type TransactionRequest struct {
 	// If this value is left empty (nil), it will be considered a pre-EIP1559 or "legacy" transaction
 	GasTip *big.Int
 
+	// GasStrategy optional strategy sampling the GasPrice and GasTip when GasPrice is left empty (nil).
+	// If this value is left empty (nil), the registered ethgas strategy of the chain is used.
+	GasStrategy ethgas.Strategy
+
 	// AccessList optional key-values to pre-import
 	// saves cost by pre-importing storage related values before executing the tx
 	AccessList types.AccessList
 
+	// AuthorizationList optional EIP-7702 authorizations, of which the transaction is a set code
+	// transaction delegating the code of the authorities. The authorization of the sender itself
+	// is of the nonce following the nonce of the transaction.
+	AuthorizationList []types.SetCodeAuthorization
+
 	// ETHValue (in WEI) amount of ETH currency to send with this transaction. Optional.
 	ETHValue *big.Int
 
@@ -68,12 +79,35 @@ func NewTransaction(ctx context.Context, provider *ethrpc.Provider, txnRequest *
 	}
 
 	if txnRequest.GasPrice == nil {
-		// Get suggested gas price, the user can change this on their own too
-		gasPrice, err := provider.SuggestGasPrice(ctx)
+		// Get suggested gas fees of the chain, the user can change this on their own too
+		strategy := txnRequest.GasStrategy
+		if strategy == nil {
+			chainID, err := provider.ChainID(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("ethtxn: %w", err)
+			}
+			strategy = ethgas.GetStrategy(chainID.Uint64())
+		}
+
+		fees, err := strategy.SuggestFees(ctx, provider, ethereum.CallMsg{
+			From:              txnRequest.From,
+			To:                txnRequest.To,
+			Gas:               txnRequest.GasLimit,
+			Value:             txnRequest.ETHValue,
+			Data:              txnRequest.Data,
+			AuthorizationList: txnRequest.AuthorizationList,
+		})
 		if err != nil {
 			return nil, fmt.Errorf("ethtxn: %w", err)
 		}
-		txnRequest.GasPrice = gasPrice
+		if fees.IsDynamic() {
+			txnRequest.GasPrice = fees.GasFeeCap
+			if txnRequest.GasTip == nil {
+				txnRequest.GasTip = fees.GasTipCap
+			}
+		} else {
+			txnRequest.GasPrice = fees.GasPrice
+		}
 	}
 
 	if txnRequest.GasLimit == 0 {
@@ -84,6 +118,8 @@ func NewTransaction(ctx context.Context, provider *ethrpc.Provider, txnRequest *
 			GasPrice: txnRequest.GasPrice,
 			Value:    txnRequest.ETHValue,
 			Data:     txnRequest.Data,
+
+			AuthorizationList: txnRequest.AuthorizationList,
 		}
 
 		gasLimit, err := provider.EstimateGas(ctx, callMsg)
@@ -98,7 +134,41 @@ func NewTransaction(ctx context.Context, provider *ethrpc.Provider, txnRequest *
 	}
 
 	var rawTx *types.Transaction
-	if txnRequest.GasTip != nil {
+	if txnRequest.AuthorizationList != nil {
+		if txnRequest.To == nil {
+			return nil, fmt.Errorf("ethtxn: set code txn request requires the to field")
+		}
+		chainId, err := provider.ChainID(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		// the gas price of the chains of legacy fees is both the fee cap and the tip
+		gasTip := txnRequest.GasTip
+		if gasTip == nil {
+			gasTip = txnRequest.GasPrice
+		}
+		var values [4]*uint256.Int
+		for i, v := range []*big.Int{chainId, bigOrZero(txnRequest.ETHValue), txnRequest.GasPrice, gasTip} {
+			value, overflow := uint256.FromBig(v)
+			if v.Sign() < 0 || overflow {
+				return nil, fmt.Errorf("ethtxn: invalid txn value %s", v)
+			}
+			values[i] = value
+		}
+		rawTx = types.NewTx(&types.SetCodeTx{
+			ChainID:    values[0],
+			To:         *txnRequest.To,
+			Nonce:      txnRequest.Nonce.Uint64(),
+			Value:      values[1],
+			GasFeeCap:  values[2],
+			GasTipCap:  values[3],
+			Data:       txnRequest.Data,
+			Gas:        txnRequest.GasLimit,
+			AccessList: txnRequest.AccessList,
+			AuthList:   txnRequest.AuthorizationList,
+		})
+	} else if txnRequest.GasTip != nil {
 		chainId, err := provider.ChainID(ctx)
 		if err != nil {
 			return nil, err
@@ -157,6 +227,44 @@ func SendTransaction(ctx context.Context, provider *ethrpc.Provider, signedTx *t
 	return signedTx, waitFn, provider.SendTransaction(ctx, signedTx)
 }
 
+// WithAuthorizationList returns the set code transaction of the unsigned transaction with the
+// EIP-7702 authorizations, ie. of an access list or dynamic fee transaction of NewTransaction,
+// of which the fees of an access list transaction are its gas price.
+func WithAuthorizationList(txn *types.Transaction, authList []types.SetCodeAuthorization) (*types.Transaction, error) {
+	switch txn.Type() {
+	case types.AccessListTxType, types.DynamicFeeTxType, types.SetCodeTxType:
+	default:
+		return nil, fmt.Errorf("ethtxn: txn of type %d can't have an authorization list", txn.Type())
+	}
+	if txn.To() == nil {
+		return nil, fmt.Errorf("ethtxn: contract creation txn can't have an authorization list")
+	}
+	for _, v := range []*big.Int{txn.ChainId(), txn.GasFeeCap(), txn.GasTipCap(), txn.Value()} {
+		if v.Sign() < 0 || v.BitLen() > 256 {
+			return nil, fmt.Errorf("ethtxn: invalid txn value %s", v)
+		}
+	}
+	return types.NewTx(&types.SetCodeTx{
+		ChainID:    uint256.MustFromBig(txn.ChainId()),
+		To:         *txn.To(),
+		Nonce:      txn.Nonce(),
+		Value:      uint256.MustFromBig(txn.Value()),
+		GasFeeCap:  uint256.MustFromBig(txn.GasFeeCap()),
+		GasTipCap:  uint256.MustFromBig(txn.GasTipCap()),
+		Data:       txn.Data(),
+		Gas:        txn.Gas(),
+		AccessList: txn.AccessList(),
+		AuthList:   append([]types.SetCodeAuthorization{}, authList...),
+	}), nil
+}
+
+func bigOrZero(v *big.Int) *big.Int {
+	if v == nil {
+		return new(big.Int)
+	}
+	return v
+}
+
 var zeroBigInt = big.NewInt(0)
 
 func AsMessage(txn *types.Transaction) (*core.Message, error) {
@@ -179,7 +287,11 @@ func AsMessageWithSigner(txn *types.Transaction, signer types.Signer, baseFee *b
 			GasTipCap:         txn.GasTipCap(),
 			Data:              txn.Data(),
 			AccessList:        txn.AccessList(),
+			BlobGasFeeCap:     txn.BlobGasFeeCap(),
+			BlobHashes:        txn.BlobHashes(),
 			SkipAccountChecks: true,
+
+			SetCodeAuthorizations: txn.SetCodeAuthorizations(),
 		}, nil
 	} else {
 		return core.TransactionToMessage(txn, signer, baseFee)
@@ -1,7 +1,219 @@
 package ethtxn_test
 
-import "testing"
+import (
+	"context"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethdevnode"
+	"github.com/0xsequence/ethkit/ethgas"
+	"github.com/0xsequence/ethkit/ethmonitor"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/ethkit/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
 
 func TestTxnSend(t *testing.T) {
 
 }
+
+func TestScheduler(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	node, err := ethdevnode.NewNode(ctx)
+	require.NoError(t, err)
+	server := httptest.NewServer(node)
+	defer server.Close()
+	provider, err := ethrpc.NewProvider(server.URL)
+	require.NoError(t, err)
+	_, err = provider.ChainID(ctx)
+	require.NoError(t, err)
+
+	monitorOptions := ethmonitor.DefaultOptions
+	monitorOptions.PollingInterval = 5 * time.Millisecond
+	monitor, err := ethmonitor.NewMonitor(provider, monitorOptions)
+	require.NoError(t, err)
+	go monitor.Run(ctx)
+	defer monitor.Stop()
+
+	gauge, err := ethgas.NewGasGauge(monitorOptions.Logger, monitor, 1, true)
+	require.NoError(t, err)
+	scheduler, err := ethtxn.NewScheduler(provider, gauge, ethtxn.SchedulerOptions{DeadlineInterval: 10 * time.Millisecond})
+	require.NoError(t, err)
+	go scheduler.Run(ctx)
+	defer scheduler.Stop()
+
+	// the base fee of the dev node is 1 gwei
+	cheap := big.NewInt(params.GWei / 2)
+	accounts := node.Accounts()
+	to := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	newTx := func(i int, nonce int64) *ethtxn.TransactionRequest {
+		accounts[i].SetProvider(provider)
+		return &ethtxn.TransactionRequest{To: &to, ETHValue: big.NewInt(1), Nonce: big.NewInt(nonce)}
+	}
+
+	txA, err := accounts[0].NewTransaction(ctx, newTx(0, 0))
+	require.NoError(t, err)
+	waitA, err := scheduler.Schedule(txA, ethtxn.ScheduleOptions{MaxBaseFee: big.NewInt(params.GWei)})
+	require.NoError(t, err)
+
+	// txC waits for txB, of the same sender
+	txB, err := accounts[1].NewTransaction(ctx, newTx(1, 0))
+	require.NoError(t, err)
+	waitB, err := scheduler.Schedule(txB, ethtxn.ScheduleOptions{MaxBaseFee: cheap, Deadline: time.Now().Add(300 * time.Millisecond)})
+	require.NoError(t, err)
+	txC, err := accounts[1].NewTransaction(ctx, newTx(1, 1))
+	require.NoError(t, err)
+	waitC, err := scheduler.Schedule(txC, ethtxn.ScheduleOptions{MaxBaseFee: big.NewInt(params.GWei)})
+	require.NoError(t, err)
+
+	txD, err := accounts[2].NewTransaction(ctx, newTx(2, 0))
+	require.NoError(t, err)
+	waitD, err := scheduler.Schedule(txD, ethtxn.ScheduleOptions{MaxBaseFee: cheap})
+	require.NoError(t, err)
+
+	node.Mine()
+	receipt, err := waitA(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, txA.Hash(), receipt.TxHash)
+	assert.Equal(t, 3, scheduler.Pending())
+
+	start := time.Now()
+	receipt, err = waitC(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, txC.Hash(), receipt.TxHash)
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond, "txC is released after the deadline of txB")
+	receipt, err = waitB(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, txB.Hash(), receipt.TxHash)
+
+	assert.True(t, scheduler.Cancel(txD.Hash()))
+	assert.False(t, scheduler.Cancel(txD.Hash()))
+	_, err = waitD(ctx)
+	assert.ErrorIs(t, err, ethtxn.ErrScheduleCanceled)
+	assert.Equal(t, 0, scheduler.Pending())
+}
+
+func TestUnsignedPayload(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	chainID := big.NewInt(10)
+	to := common.HexToAddress("0x1234567890123456789012345678901234567890")
+
+	txs := []*types.Transaction{
+		types.NewTx(&types.LegacyTx{Nonce: 1, GasPrice: big.NewInt(5), Gas: 21000, To: &to, Value: big.NewInt(1)}),
+		types.NewTx(&types.AccessListTx{ChainID: chainID, Nonce: 2, GasPrice: big.NewInt(5), Gas: 30000, To: &to, Data: []byte{1, 2},
+			AccessList: types.AccessList{{Address: to, StorageKeys: []common.Hash{{1}}}}}),
+		types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: 3, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(7), Gas: 60000, Data: []byte{0x60}}),
+	}
+	signer := types.LatestSignerForChainID(chainID)
+	for _, tx := range txs {
+		payload, err := ethtxn.EncodeUnsignedPayload(tx, chainID)
+		require.NoError(t, err)
+		assert.Equal(t, signer.Hash(tx), ethtxn.SigningHash(payload), "type %d", tx.Type())
+
+		decoded, decodedChainID, err := ethtxn.DecodeUnsignedPayload(payload)
+		require.NoError(t, err)
+		assert.Equal(t, chainID, decodedChainID)
+		assert.Equal(t, tx.Hash(), decoded.Hash())
+
+		sig, err := crypto.Sign(ethtxn.SigningHash(payload).Bytes(), key)
+		require.NoError(t, err)
+		sig[64] += 27
+		signed, err := ethtxn.CombineSignature(decoded, decodedChainID, sig)
+		require.NoError(t, err)
+		sender, err := types.Sender(signer, signed)
+		require.NoError(t, err)
+		assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), sender)
+	}
+
+	// a legacy transaction without a chain id is of the homestead payload
+	payload, err := ethtxn.EncodeUnsignedPayload(txs[0], nil)
+	require.NoError(t, err)
+	assert.Equal(t, types.HomesteadSigner{}.Hash(txs[0]), ethtxn.SigningHash(payload))
+	decoded, decodedChainID, err := ethtxn.DecodeUnsignedPayload(payload)
+	require.NoError(t, err)
+	assert.Nil(t, decodedChainID)
+	assert.Equal(t, txs[0].Hash(), decoded.Hash())
+	sig, err := crypto.Sign(ethtxn.SigningHash(payload).Bytes(), key)
+	require.NoError(t, err)
+	signed, err := ethtxn.CombineSignature(decoded, decodedChainID, sig)
+	require.NoError(t, err)
+	assert.False(t, signed.Protected())
+	sender, err := types.Sender(types.HomesteadSigner{}, signed)
+	require.NoError(t, err)
+	assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), sender)
+
+	_, err = ethtxn.EncodeUnsignedPayload(txs[2], nil)
+	assert.Error(t, err)
+	_, _, err = ethtxn.DecodeUnsignedPayload([]byte{0x05, 0xc0})
+	assert.ErrorIs(t, err, ethtxn.ErrInvalidUnsignedPayload)
+	_, err = ethtxn.CombineSignature(txs[0], chainID, []byte{1})
+	assert.Error(t, err)
+}
+
+func TestTransactionRequestMarshalCompactJSON(t *testing.T) {
+	to := common.HexToAddress("0xcccccccccccccccccccccccccccccccccccccccc")
+	txnRequest := &ethtxn.TransactionRequest{
+		From:     common.HexToAddress("0x1111111111111111111111111111111111111111"),
+		To:       &to,
+		GasLimit: 21000,
+		GasPrice: big.NewInt(30_000_000_000),
+		GasTip:   big.NewInt(1_000_000_000),
+		ETHValue: big.NewInt(1_000_000_000_000_000_000),
+		Data:     common.FromHex("0xabcd"),
+	}
+
+	data, err := txnRequest.MarshalCompactJSON(big.NewInt(137))
+	require.NoError(t, err)
+	assert.Equal(t, `{"from":"0x1111111111111111111111111111111111111111","to":"0xcccccccccccccccccccccccccccccccccccccccc","gas":"0x5208","maxFeePerGas":"0x6fc23ac00","maxPriorityFeePerGas":"0x3b9aca00","value":"0xde0b6b3a7640000","data":"0xabcd","chainId":"0x89"}`, string(data))
+
+	encoded, err := txnRequest.EncodeBase64URL(big.NewInt(137))
+	require.NoError(t, err)
+	decoded, err := ethcoder.Base64URLDecode(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, data, decoded)
+
+	// the legacy request of the unset fields
+	data, err = (&ethtxn.TransactionRequest{Data: common.FromHex("0x6080"), GasPrice: big.NewInt(1)}).MarshalCompactJSON(nil)
+	require.NoError(t, err)
+	assert.Equal(t, `{"gasPrice":"0x1","data":"0x6080"}`, string(data))
+}
+
+func TestNewSetCodeTransaction(t *testing.T) {
+	ctx := context.Background()
+	node, err := ethdevnode.NewNode(ctx)
+	require.NoError(t, err)
+	server := httptest.NewServer(node)
+	defer server.Close()
+	provider, err := ethrpc.NewProvider(server.URL)
+	require.NoError(t, err)
+
+	to := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	newTx := func(value *big.Int) (*types.Transaction, error) {
+		return ethtxn.NewTransaction(ctx, provider, &ethtxn.TransactionRequest{
+			To: &to, Nonce: big.NewInt(0), GasLimit: 100000, GasPrice: big.NewInt(params.GWei), ETHValue: value,
+			AuthorizationList: []types.SetCodeAuthorization{},
+		})
+	}
+
+	tx, err := newTx(big.NewInt(1))
+	require.NoError(t, err)
+	assert.Equal(t, uint8(types.SetCodeTxType), tx.Type())
+	assert.Equal(t, big.NewInt(1), tx.Value())
+
+	// the values out of the range of uint256 are errors, not panics
+	_, err = newTx(big.NewInt(-1))
+	assert.ErrorContains(t, err, "invalid txn value")
+	_, err = newTx(new(big.Int).Lsh(big.NewInt(1), 256))
+	assert.ErrorContains(t, err, "invalid txn value")
+}
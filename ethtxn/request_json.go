@@ -0,0 +1,79 @@
+package ethtxn
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+// MarshalCompactJSON returns the canonical eth_sendTransaction params of the
+// request of the chain, of compact json with hex quantities, as wallets and
+// WalletConnect expect in deep links. The request is of the dynamic fees when
+// its GasTip is set, and the unset fields are omitted, ie. for the wallet to
+// assign them.
+func (r *TransactionRequest) MarshalCompactJSON(chainID *big.Int) ([]byte, error) {
+	if r == nil {
+		return nil, fmt.Errorf("ethtxn: txnRequest is required")
+	}
+
+	params := txRequestJSON{
+		To:         r.To,
+		Value:      (*hexutil.Big)(r.ETHValue),
+		Nonce:      (*hexutil.Big)(r.Nonce),
+		ChainID:    (*hexutil.Big)(chainID),
+		AccessList: r.AccessList,
+
+		AuthorizationList: r.AuthorizationList,
+	}
+	if r.From != (common.Address{}) {
+		params.From = &r.From
+	}
+	if r.GasLimit != 0 {
+		params.Gas = (*hexutil.Uint64)(&r.GasLimit)
+	}
+	if r.GasTip != nil {
+		params.MaxFeePerGas = (*hexutil.Big)(r.GasPrice)
+		params.MaxPriorityFeePerGas = (*hexutil.Big)(r.GasTip)
+	} else {
+		params.GasPrice = (*hexutil.Big)(r.GasPrice)
+	}
+	if len(r.Data) > 0 {
+		params.Data = r.Data
+	}
+
+	data, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("ethtxn: failed to encode txnRequest: %w", err)
+	}
+	return data, nil
+}
+
+// EncodeBase64URL returns the base64url encoding of the compact json of the
+// request, see MarshalCompactJSON.
+func (r *TransactionRequest) EncodeBase64URL(chainID *big.Int) (string, error) {
+	data, err := r.MarshalCompactJSON(chainID)
+	if err != nil {
+		return "", err
+	}
+	return ethcoder.Base64URLEncode(data), nil
+}
+
+type txRequestJSON struct {
+	From                 *common.Address              `json:"from,omitempty"`
+	To                   *common.Address              `json:"to,omitempty"`
+	Gas                  *hexutil.Uint64              `json:"gas,omitempty"`
+	GasPrice             *hexutil.Big                 `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big                 `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big                 `json:"maxPriorityFeePerGas,omitempty"`
+	Value                *hexutil.Big                 `json:"value,omitempty"`
+	Nonce                *hexutil.Big                 `json:"nonce,omitempty"`
+	Data                 hexutil.Bytes                `json:"data,omitempty"`
+	AccessList           types.AccessList             `json:"accessList,omitempty"`
+	AuthorizationList    []types.SetCodeAuthorization `json:"authorizationList,omitempty"`
+	ChainID              *hexutil.Big                 `json:"chainId,omitempty"`
+}
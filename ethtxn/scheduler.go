@@ -0,0 +1,261 @@
+package ethtxn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethgas"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+var (
+	ErrScheduleCanceled = errors.New("ethtxn: scheduled transaction canceled")
+	ErrSchedulerStopped = errors.New("ethtxn: scheduler stopped")
+)
+
+var DefaultSchedulerOptions = SchedulerOptions{
+	DeadlineInterval: time.Second,
+}
+
+type SchedulerOptions struct {
+	// DeadlineInterval is the interval of the checks of the deadlines, between
+	// the blocks.
+	DeadlineInterval time.Duration
+
+	// Send sends the released transactions, default SendTransaction. It is the
+	// hook of the senders managing their transactions once sent, ie. to bump
+	// their fees.
+	Send func(ctx context.Context, provider *ethrpc.Provider, signedTx *types.Transaction) (*types.Transaction, WaitReceipt, error)
+}
+
+// ScheduleOptions are the release conditions of a scheduled transaction.
+type ScheduleOptions struct {
+	// MaxBaseFee is the base fee at or below which the transaction is released.
+	// If this value is left empty (nil), the slow gas price of the gas gauge is
+	// used.
+	MaxBaseFee *big.Int
+
+	// Deadline is the time at which the transaction is released regardless of
+	// the base fee. Optional.
+	Deadline time.Time
+}
+
+// Scheduler queues the non-urgent transactions, and sends them once the base
+// fee of the chain drops below their target or by their deadline. The
+// transactions of a sender are released in the order they are scheduled, so
+// that their nonces don't gap.
+type Scheduler struct {
+	provider *ethrpc.Provider
+	gauge    *ethgas.GasGauge
+	options  SchedulerOptions
+
+	queue   []*scheduledTx
+	baseFee *big.Int
+	mu      sync.Mutex
+
+	ctx     context.Context
+	ctxStop context.CancelFunc
+	running int32
+}
+
+type scheduledTx struct {
+	tx       *types.Transaction
+	from     common.Address
+	options  ScheduleOptions
+	released chan struct{}
+
+	waitReceipt WaitReceipt
+	err         error
+}
+
+func NewScheduler(provider *ethrpc.Provider, gauge *ethgas.GasGauge, opts ...SchedulerOptions) (*Scheduler, error) {
+	if provider == nil {
+		return nil, fmt.Errorf("ethtxn: provider is not set")
+	}
+	if gauge == nil {
+		return nil, fmt.Errorf("ethtxn: gas gauge is not set")
+	}
+
+	options := DefaultSchedulerOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	if options.DeadlineInterval <= 0 {
+		options.DeadlineInterval = DefaultSchedulerOptions.DeadlineInterval
+	}
+	if options.Send == nil {
+		options.Send = SendTransaction
+	}
+
+	return &Scheduler{provider: provider, gauge: gauge, options: options}, nil
+}
+
+func (s *Scheduler) Run(ctx context.Context) error {
+	if s.IsRunning() {
+		return fmt.Errorf("ethtxn: scheduler already running")
+	}
+
+	s.ctx, s.ctxStop = context.WithCancel(ctx)
+
+	atomic.StoreInt32(&s.running, 1)
+	defer atomic.StoreInt32(&s.running, 0)
+	defer s.cancelAll(ErrSchedulerStopped)
+
+	sub := s.gauge.Subscribe()
+	defer sub.Unsubscribe()
+
+	ticker := time.NewTicker(s.options.DeadlineInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+
+		// service is stopping
+		case <-s.ctx.Done():
+			return nil
+
+		// eth monitor has stopped
+		case <-sub.Done():
+			return fmt.Errorf("ethmonitor has stopped so the scheduler cannot continue, stopping")
+
+		case blocks := <-sub.Blocks():
+			latestBlock := blocks.LatestBlock()
+			if latestBlock == nil || latestBlock.BaseFee() == nil {
+				continue
+			}
+			s.mu.Lock()
+			s.baseFee = new(big.Int).Set(latestBlock.BaseFee())
+			s.mu.Unlock()
+			s.release()
+
+		case <-ticker.C:
+			s.release()
+		}
+	}
+}
+
+func (s *Scheduler) Stop() {
+	s.ctxStop()
+}
+
+func (s *Scheduler) IsRunning() bool {
+	return atomic.LoadInt32(&s.running) == 1
+}
+
+// Schedule queues a signed transaction until its release conditions are met.
+// The returned WaitReceipt waits for the release, and then for the receipt of
+// the transaction.
+func (s *Scheduler) Schedule(signedTx *types.Transaction, options ScheduleOptions) (WaitReceipt, error) {
+	var signer types.Signer = types.HomesteadSigner{}
+	if signedTx.Protected() {
+		signer = types.LatestSignerForChainID(signedTx.ChainId())
+	}
+	from, err := types.Sender(signer, signedTx)
+	if err != nil {
+		return nil, fmt.Errorf("ethtxn: %w", err)
+	}
+
+	stx := &scheduledTx{tx: signedTx, from: from, options: options, released: make(chan struct{})}
+	s.mu.Lock()
+	s.queue = append(s.queue, stx)
+	s.mu.Unlock()
+
+	waitFn := func(ctx context.Context) (*types.Receipt, error) {
+		select {
+		case <-stx.released:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if stx.err != nil {
+			return nil, stx.err
+		}
+		return stx.waitReceipt(ctx)
+	}
+	return waitFn, nil
+}
+
+// Cancel removes a queued transaction, and the transactions of the same sender
+// scheduled after it, whose nonces would gap. It reports whether the
+// transaction was queued.
+func (s *Scheduler) Cancel(txnHash common.Hash) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var canceled *scheduledTx
+	queue := s.queue[:0]
+	for _, stx := range s.queue {
+		if canceled == nil && stx.tx.Hash() == txnHash {
+			canceled = stx
+		}
+		if canceled != nil && stx.from == canceled.from {
+			stx.err = ErrScheduleCanceled
+			close(stx.released)
+			continue
+		}
+		queue = append(queue, stx)
+	}
+	s.queue = queue
+	return canceled != nil
+}
+
+// Pending returns the number of queued transactions.
+func (s *Scheduler) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queue)
+}
+
+// release sends the queued transactions whose conditions are met.
+func (s *Scheduler) release() {
+	s.mu.Lock()
+	now := time.Now()
+	slow := s.gauge.SuggestedGasPrice().SlowWei
+
+	var released []*scheduledTx
+	held := map[common.Address]bool{}
+	queue := s.queue[:0]
+	for _, stx := range s.queue {
+		if !held[stx.from] && s.isReleased(stx, now, slow) {
+			released = append(released, stx)
+			continue
+		}
+		held[stx.from] = true
+		queue = append(queue, stx)
+	}
+	s.queue = queue
+	s.mu.Unlock()
+
+	for _, stx := range released {
+		_, stx.waitReceipt, stx.err = s.options.Send(s.ctx, s.provider, stx.tx)
+		close(stx.released)
+	}
+}
+
+// isReleased must be called with the lock held.
+func (s *Scheduler) isReleased(stx *scheduledTx, now time.Time, slow *big.Int) bool {
+	if !stx.options.Deadline.IsZero() && !now.Before(stx.options.Deadline) {
+		return true
+	}
+	target := stx.options.MaxBaseFee
+	if target == nil {
+		target = slow
+	}
+	return s.baseFee != nil && target != nil && s.baseFee.Cmp(target) <= 0
+}
+
+func (s *Scheduler) cancelAll(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, stx := range s.queue {
+		stx.err = err
+		close(stx.released)
+	}
+	s.queue = nil
+}
@@ -0,0 +1,157 @@
+package ethtxn
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/ethkit/go-ethereum/rlp"
+)
+
+var ErrInvalidUnsignedPayload = errors.New("ethtxn: invalid unsigned transaction payload")
+
+// EncodeUnsignedPayload returns the canonical unsigned payload of the transaction
+// on the chain, ie. the EIP-2718 typed payload of which the keccak256 is the
+// signing hash, for signing on an air-gapped device. A legacy transaction of a
+// nil chain id is of the payload of the HomesteadSigner, ie. without the
+// EIP-155 replay protection.
+func EncodeUnsignedPayload(tx *types.Transaction, chainID *big.Int) ([]byte, error) {
+	if chainID == nil && tx.Type() != types.LegacyTxType {
+		return nil, fmt.Errorf("ethtxn: transaction type %d requires a chain id", tx.Type())
+	}
+
+	switch tx.Type() {
+	case types.LegacyTxType:
+		if chainID == nil {
+			return rlp.EncodeToBytes([]interface{}{
+				tx.Nonce(), tx.GasPrice(), tx.Gas(), tx.To(), tx.Value(), tx.Data(),
+			})
+		}
+		// EIP-155
+		return rlp.EncodeToBytes([]interface{}{
+			tx.Nonce(), tx.GasPrice(), tx.Gas(), tx.To(), tx.Value(), tx.Data(),
+			chainID, uint(0), uint(0),
+		})
+	case types.AccessListTxType:
+		payload, err := rlp.EncodeToBytes([]interface{}{
+			chainID, tx.Nonce(), tx.GasPrice(), tx.Gas(), tx.To(), tx.Value(), tx.Data(),
+			tx.AccessList(),
+		})
+		return append([]byte{types.AccessListTxType}, payload...), err
+	case types.DynamicFeeTxType:
+		payload, err := rlp.EncodeToBytes([]interface{}{
+			chainID, tx.Nonce(), tx.GasTipCap(), tx.GasFeeCap(), tx.Gas(), tx.To(), tx.Value(), tx.Data(),
+			tx.AccessList(),
+		})
+		return append([]byte{types.DynamicFeeTxType}, payload...), err
+	}
+	return nil, fmt.Errorf("ethtxn: unsupported transaction type %d", tx.Type())
+}
+
+type unsignedLegacyTx struct {
+	Nonce    uint64
+	GasPrice *big.Int
+	Gas      uint64
+	To       *common.Address `rlp:"nil"`
+	Value    *big.Int
+	Data     []byte
+
+	// the EIP-155 fields, which are not part of the payload without a chain id
+	ChainID *big.Int `rlp:"optional"`
+	R, S    uint     `rlp:"optional"`
+}
+
+type unsignedAccessListTx struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasPrice   *big.Int
+	Gas        uint64
+	To         *common.Address `rlp:"nil"`
+	Value      *big.Int
+	Data       []byte
+	AccessList types.AccessList
+}
+
+type unsignedDynamicFeeTx struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasTipCap  *big.Int
+	GasFeeCap  *big.Int
+	Gas        uint64
+	To         *common.Address `rlp:"nil"`
+	Value      *big.Int
+	Data       []byte
+	AccessList types.AccessList
+}
+
+// DecodeUnsignedPayload decodes the unsigned payload of EncodeUnsignedPayload,
+// and returns the unsigned transaction and its chain id, which is nil for a
+// legacy transaction without EIP-155.
+func DecodeUnsignedPayload(payload []byte) (*types.Transaction, *big.Int, error) {
+	if len(payload) == 0 {
+		return nil, nil, ErrInvalidUnsignedPayload
+	}
+
+	switch payload[0] {
+	case types.AccessListTxType:
+		var tx unsignedAccessListTx
+		if err := rlp.DecodeBytes(payload[1:], &tx); err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidUnsignedPayload, err)
+		}
+		return types.NewTx(&types.AccessListTx{
+			ChainID: tx.ChainID, Nonce: tx.Nonce, GasPrice: tx.GasPrice, Gas: tx.Gas,
+			To: tx.To, Value: tx.Value, Data: tx.Data, AccessList: tx.AccessList,
+		}), tx.ChainID, nil
+
+	case types.DynamicFeeTxType:
+		var tx unsignedDynamicFeeTx
+		if err := rlp.DecodeBytes(payload[1:], &tx); err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidUnsignedPayload, err)
+		}
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID: tx.ChainID, Nonce: tx.Nonce, GasTipCap: tx.GasTipCap, GasFeeCap: tx.GasFeeCap, Gas: tx.Gas,
+			To: tx.To, Value: tx.Value, Data: tx.Data, AccessList: tx.AccessList,
+		}), tx.ChainID, nil
+	}
+
+	// legacy transactions are a rlp list
+	if payload[0] < 0xc0 {
+		return nil, nil, fmt.Errorf("%w: unsupported transaction type %d", ErrInvalidUnsignedPayload, payload[0])
+	}
+	var tx unsignedLegacyTx
+	if err := rlp.DecodeBytes(payload, &tx); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidUnsignedPayload, err)
+	}
+	if tx.R != 0 || tx.S != 0 {
+		return nil, nil, fmt.Errorf("%w: the transaction is signed", ErrInvalidUnsignedPayload)
+	}
+	return types.NewTx(&types.LegacyTx{
+		Nonce: tx.Nonce, GasPrice: tx.GasPrice, Gas: tx.Gas, To: tx.To, Value: tx.Value, Data: tx.Data,
+	}), tx.ChainID, nil
+}
+
+// SigningHash returns the hash to sign of the unsigned payload.
+func SigningHash(payload []byte) common.Hash {
+	return crypto.Keccak256Hash(payload)
+}
+
+// CombineSignature attaches the signature of the signing hash, of 65 bytes
+// [R || S || V] with V of 0/1 or 27/28, to the unsigned transaction of the chain
+// and returns the signed transaction. The signature of a legacy transaction of
+// a nil chain id is of the HomesteadSigner.
+func CombineSignature(tx *types.Transaction, chainID *big.Int, signature []byte) (*types.Transaction, error) {
+	if len(signature) != crypto.SignatureLength {
+		return nil, fmt.Errorf("ethtxn: invalid signature length %d, expecting %d", len(signature), crypto.SignatureLength)
+	}
+	sig := append([]byte{}, signature...)
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	if sig[64] > 1 {
+		return nil, fmt.Errorf("ethtxn: invalid signature recovery id %d", signature[64])
+	}
+	return tx.WithSignature(types.LatestSignerForChainID(chainID), sig)
+}
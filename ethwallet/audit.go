@@ -0,0 +1,402 @@
+package ethwallet
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+var (
+	ErrSignRejected       = errors.New("ethwallet: sign request rejected")
+	ErrAuditLogTampered   = errors.New("ethwallet: audit log tampered")
+	ErrAuditLogIncomplete = errors.New("ethwallet: audit log write failed, signature discarded")
+)
+
+type SignKind string
+
+const (
+	SignKindTransaction SignKind = "transaction"
+	SignKindMessage     SignKind = "message"
+	SignKindData        SignKind = "data"
+	SignKindTypedData   SignKind = "typedData"
+)
+
+// SignRequest is the canonical description of what is being signed.
+type SignRequest struct {
+	Kind   SignKind       `json:"kind"`
+	Signer common.Address `json:"signer"`
+
+	// ChainID of a transaction, or of the domain of typed data.
+	ChainID *big.Int `json:"chainId,omitempty"`
+
+	Tx        *SignRequestTx      `json:"tx,omitempty"`
+	Message   hexutil.Bytes       `json:"message,omitempty"`
+	TypedData *ethcoder.TypedData `json:"typedData,omitempty"`
+
+	// Digest is the hash that is signed.
+	Digest common.Hash `json:"digest"`
+}
+
+type SignRequestTx struct {
+	Type      uint8           `json:"type"`
+	Nonce     uint64          `json:"nonce"`
+	To        *common.Address `json:"to"`
+	Value     *big.Int        `json:"value"`
+	Gas       uint64          `json:"gas"`
+	GasPrice  *big.Int        `json:"gasPrice,omitempty"`
+	GasFeeCap *big.Int        `json:"maxFeePerGas,omitempty"`
+	GasTipCap *big.Int        `json:"maxPriorityFeePerGas,omitempty"`
+	Data      hexutil.Bytes   `json:"data"`
+}
+
+func (r SignRequest) String() string {
+	switch r.Kind {
+	case SignKindTransaction:
+		to := "contract creation"
+		if r.Tx.To != nil {
+			to = "to " + r.Tx.To.Hex()
+		}
+		return fmt.Sprintf("%s signs transaction on chain %v %s value %s nonce %d data %s", r.Signer.Hex(), r.ChainID, to, r.Tx.Value, r.Tx.Nonce, summarizeBytes(r.Tx.Data))
+	case SignKindTypedData:
+		return fmt.Sprintf("%s signs typed data %s of %s on chain %v, digest %s", r.Signer.Hex(), r.TypedData.PrimaryType, r.TypedData.Domain.Name, r.ChainID, r.Digest.Hex())
+	default:
+		return fmt.Sprintf("%s signs %s %s, digest %s", r.Signer.Hex(), r.Kind, summarizeBytes(r.Message), r.Digest.Hex())
+	}
+}
+
+func summarizeBytes(data []byte) string {
+	if len(data) <= 36 {
+		return hexutil.Encode(data)
+	}
+	return fmt.Sprintf("%s...(%d bytes)", hexutil.Encode(data[:36]), len(data))
+}
+
+// Approver approves a sign request, or vetoes it by returning an error. It may
+// block while waiting for an out-of-band approval, ie. a human confirmation.
+type Approver func(req SignRequest) error
+
+type AuditOptions struct {
+	// Approver of the sign requests, optional.
+	Approver Approver
+
+	// Log records the sign requests, optional.
+	Log *AuditLog
+}
+
+// AuditSigner wraps a signer, passing every sign request to the Approver and
+// recording it with its outcome in the audit Log. The signature is returned
+// only once recorded.
+type AuditSigner struct {
+	signer  Signer
+	options AuditOptions
+}
+
+var _ Signer = &AuditSigner{}
+
+func NewAuditSigner(signer Signer, options AuditOptions) (*AuditSigner, error) {
+	if signer == nil {
+		return nil, fmt.Errorf("ethwallet: audit signer is nil")
+	}
+	return &AuditSigner{signer: signer, options: options}, nil
+}
+
+func (s *AuditSigner) Address() common.Address {
+	return s.signer.Address()
+}
+
+func (s *AuditSigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	req := SignRequest{
+		Kind:    SignKindTransaction,
+		Signer:  s.Address(),
+		ChainID: chainID,
+		Tx: &SignRequestTx{
+			Type:      tx.Type(),
+			Nonce:     tx.Nonce(),
+			To:        tx.To(),
+			Value:     tx.Value(),
+			Gas:       tx.Gas(),
+			GasPrice:  tx.GasPrice(),
+			GasFeeCap: tx.GasFeeCap(),
+			GasTipCap: tx.GasTipCap(),
+			Data:      tx.Data(),
+		},
+		Digest: types.LatestSignerForChainID(chainID).Hash(tx),
+	}
+	if tx.Type() != types.LegacyTxType && tx.Type() != types.AccessListTxType {
+		req.Tx.GasPrice = nil
+	} else {
+		req.Tx.GasFeeCap, req.Tx.GasTipCap = nil, nil
+	}
+
+	var signedTx *types.Transaction
+	err := s.sign(req, func() ([]byte, error) {
+		var err error
+		signedTx, err = s.signer.SignTx(tx, chainID)
+		if err != nil {
+			return nil, err
+		}
+		return txSignature(signedTx), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return signedTx, nil
+}
+
+// txSignature returns the r, s, v signature of a signed transaction, with a v
+// of 27 or 28.
+func txSignature(tx *types.Transaction) []byte {
+	v, r, s := tx.RawSignatureValues()
+	recid := new(big.Int).Set(v)
+	if tx.Type() == types.LegacyTxType {
+		if tx.Protected() {
+			recid.Sub(recid, new(big.Int).Add(new(big.Int).Mul(tx.ChainId(), big.NewInt(2)), big.NewInt(35)))
+		} else {
+			recid.Sub(recid, big.NewInt(27))
+		}
+	}
+
+	sig := make([]byte, 65)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:64])
+	sig[64] = byte(recid.Uint64()) + 27
+	return sig
+}
+
+func (s *AuditSigner) SignMessage(message []byte) ([]byte, error) {
+	message191 := message
+	if !strings.HasPrefix(string(message), "\x19Ethereum Signed Message:\n") {
+		message191 = []byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(message), message))
+	}
+	req := SignRequest{
+		Kind:    SignKindMessage,
+		Signer:  s.Address(),
+		Message: message,
+		Digest:  crypto.Keccak256Hash(message191),
+	}
+	return s.signBytes(req, func() ([]byte, error) { return s.signer.SignMessage(message) })
+}
+
+func (s *AuditSigner) SignData(data []byte) ([]byte, error) {
+	req := SignRequest{
+		Kind:    SignKindData,
+		Signer:  s.Address(),
+		Message: data,
+		Digest:  crypto.Keccak256Hash(data),
+	}
+	return s.signBytes(req, func() ([]byte, error) { return s.signer.SignData(data) })
+}
+
+// SignTypedData signs the EIP-712 digest of typedData, with the SignTypedData
+// of the signer when it has one, otherwise with SignData of the preimage of the
+// digest, ie. 0x1901 || domainSeparator || hashStruct(message).
+func (s *AuditSigner) SignTypedData(typedData *ethcoder.TypedData) ([]byte, error) {
+	domainHash, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		return nil, err
+	}
+	messageHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return nil, err
+	}
+	preimage := append(append([]byte{0x19, 0x01}, domainHash...), messageHash...)
+
+	req := SignRequest{
+		Kind:      SignKindTypedData,
+		Signer:    s.Address(),
+		ChainID:   typedData.Domain.ChainID,
+		TypedData: typedData,
+		Digest:    crypto.Keccak256Hash(preimage),
+	}
+	return s.signBytes(req, func() ([]byte, error) {
+		if signer, ok := s.signer.(interface {
+			SignTypedData(typedData *ethcoder.TypedData) ([]byte, error)
+		}); ok {
+			return signer.SignTypedData(typedData)
+		}
+		return s.signer.SignData(preimage)
+	})
+}
+
+func (s *AuditSigner) signBytes(req SignRequest, sign func() ([]byte, error)) ([]byte, error) {
+	var sig []byte
+	err := s.sign(req, func() ([]byte, error) {
+		var err error
+		sig, err = sign()
+		return sig, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// sign approves, signs and records the request.
+func (s *AuditSigner) sign(req SignRequest, sign func() ([]byte, error)) error {
+	record := AuditRecord{Request: req}
+
+	var err error
+	if s.options.Approver != nil {
+		err = s.options.Approver(req)
+		if err != nil && !errors.Is(err, ErrSignRejected) {
+			err = fmt.Errorf("%w: %v", ErrSignRejected, err)
+		}
+	}
+	if err == nil {
+		record.Approved = true
+		record.Signature, err = sign()
+	}
+	if err != nil {
+		record.Error = err.Error()
+		record.Signature = nil
+	}
+
+	if s.options.Log != nil {
+		if _, logErr := s.options.Log.Append(record); logErr != nil {
+			return fmt.Errorf("%w: %v", ErrAuditLogIncomplete, logErr)
+		}
+	}
+	return err
+}
+
+// AuditRecord is an entry of an AuditLog. Each record has the hash of the
+// previous record, so that any change, removal or reordering of the records is
+// detected by VerifyAuditLog.
+type AuditRecord struct {
+	Seq       uint64        `json:"seq"`
+	Time      time.Time     `json:"time"`
+	Request   SignRequest   `json:"request"`
+	Approved  bool          `json:"approved"`
+	Signature hexutil.Bytes `json:"signature,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	PrevHash  common.Hash   `json:"prevHash"`
+	Hash      common.Hash   `json:"hash"`
+}
+
+// ComputeHash returns the hash of the record, of its JSON encoding without the
+// Hash.
+func (r AuditRecord) ComputeHash() (common.Hash, error) {
+	r.Hash = common.Hash{}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	hashed, ok := hashedBytes(data, r.Hash)
+	if !ok {
+		return common.Hash{}, fmt.Errorf("ethwallet: audit record encoding has no trailing hash")
+	}
+	return crypto.Keccak256Hash(hashed), nil
+}
+
+// hashedBytes returns the bytes of the JSON encoding of a record which are
+// hashed, ie. the encoding without its trailing hash field. The records are
+// verified of the bytes as written, as the request may not encode back to the
+// same bytes once decoded, ie. of the numbers of typed data.
+func hashedBytes(data []byte, hash common.Hash) ([]byte, bool) {
+	suffix := []byte(`,"hash":"` + hash.Hex() + `"}`)
+	if !bytes.HasSuffix(data, suffix) {
+		return nil, false
+	}
+	n := len(data) - len(suffix)
+	return append(data[:n:n], '}'), true
+}
+
+// AuditLog is an append-only hash chain of AuditRecord, written as JSON lines.
+type AuditLog struct {
+	w    io.Writer
+	seq  uint64
+	last common.Hash
+	now  func() time.Time
+	mu   sync.Mutex
+}
+
+// NewAuditLog returns an audit log writing new records to w.
+func NewAuditLog(w io.Writer) *AuditLog {
+	return &AuditLog{w: w, now: time.Now}
+}
+
+// OpenAuditLog opens the audit log file at path, verifying its records and
+// appending the new records after them.
+func OpenAuditLog(path string) (*AuditLog, *os.File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, nil, err
+	}
+	records, err := VerifyAuditLog(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	log := NewAuditLog(file)
+	if len(records) > 0 {
+		last := records[len(records)-1]
+		log.seq, log.last = last.Seq+1, last.Hash
+	}
+	return log, file, nil
+}
+
+// Append appends the record to the log, setting its Seq, Time, PrevHash and
+// Hash.
+func (l *AuditLog) Append(record AuditRecord) (AuditRecord, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	record.Seq = l.seq
+	record.Time = l.now().UTC()
+	record.PrevHash = l.last
+
+	var err error
+	record.Hash, err = record.ComputeHash()
+	if err != nil {
+		return AuditRecord{}, err
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return AuditRecord{}, err
+	}
+	if _, err := l.w.Write(append(data, '\n')); err != nil {
+		return AuditRecord{}, err
+	}
+
+	l.seq++
+	l.last = record.Hash
+	return record, nil
+}
+
+// VerifyAuditLog reads the records of an audit log, and returns
+// ErrAuditLogTampered when the chain of records is broken.
+func VerifyAuditLog(r io.Reader) ([]AuditRecord, error) {
+	var records []AuditRecord
+	var prev common.Hash
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 16<<20)
+	for scanner.Scan() {
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return records, fmt.Errorf("%w: record %d: %v", ErrAuditLogTampered, len(records), err)
+		}
+		hashed, ok := hashedBytes(scanner.Bytes(), record.Hash)
+		if !ok || crypto.Keccak256Hash(hashed) != record.Hash || record.Seq != uint64(len(records)) || record.PrevHash != prev {
+			return records, fmt.Errorf("%w: record %d", ErrAuditLogTampered, len(records))
+		}
+		records = append(records, record)
+		prev = record.Hash
+	}
+	return records, scanner.Err()
+}
@@ -0,0 +1,176 @@
+package ethwallet
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditSigner(t *testing.T) {
+	wallet, err := NewWalletFromMnemonic("dose weasel clever culture letter volume endorse used harvest ripple circle install")
+	require.NoError(t, err)
+
+	var requests []SignRequest
+	approver := func(req SignRequest) error {
+		requests = append(requests, req)
+		if req.Kind == SignKindData {
+			return errors.New("operator declined")
+		}
+		return nil
+	}
+
+	var buf bytes.Buffer
+	signer, err := NewAuditSigner(wallet, AuditOptions{Approver: approver, Log: NewAuditLog(&buf)})
+	require.NoError(t, err)
+
+	to := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	tx := types.NewTx(&types.DynamicFeeTx{To: &to, Value: big.NewInt(100), Gas: 21000, Data: []byte{0xa9, 0x05, 0x9c, 0xbb}})
+	signedTx, err := signer.SignTx(tx, big.NewInt(1))
+	require.NoError(t, err)
+
+	sig, err := signer.SignMessage([]byte("hi"))
+	require.NoError(t, err)
+	address, err := RecoverAddress([]byte("hi"), sig)
+	require.NoError(t, err)
+	assert.Equal(t, wallet.Address(), address)
+
+	_, err = signer.SignData([]byte("hi"))
+	assert.ErrorIs(t, err, ErrSignRejected)
+	assert.ErrorContains(t, err, "operator declined")
+
+	verifyingContract := common.HexToAddress("0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC")
+	typedData := &ethcoder.TypedData{
+		Types: ethcoder.TypedDataTypes{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"Person": {
+				{Name: "name", Type: "string"},
+				{Name: "wallet", Type: "address"},
+			},
+		},
+		PrimaryType: "Person",
+		Domain: ethcoder.TypedDataDomain{
+			Name:              "Ether Mail",
+			Version:           "1",
+			ChainID:           big.NewInt(1),
+			VerifyingContract: &verifyingContract,
+		},
+		Message: map[string]interface{}{
+			"name":   "Bob",
+			"wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB",
+		},
+	}
+	sig, err = signer.SignTypedData(typedData)
+	require.NoError(t, err)
+	digest, err := typedData.EncodeDigest()
+	require.NoError(t, err)
+	valid, err := ethcoder.ValidateSignature(wallet.Address(), digest, sig)
+	require.NoError(t, err)
+	assert.True(t, valid)
+	assert.Equal(t, common.BytesToHash(digest), requests[3].Digest)
+
+	// the typed data of a signer without SignTypedData, of its SignData
+	plainSigner, err := NewAuditSigner(struct{ Signer }{wallet}, AuditOptions{})
+	require.NoError(t, err)
+	sig, err = plainSigner.SignTypedData(typedData)
+	require.NoError(t, err)
+	valid, err = ethcoder.ValidateSignature(wallet.Address(), digest, sig)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	require.Len(t, requests, 4)
+	assert.Equal(t, SignKindTransaction, requests[0].Kind)
+	assert.Equal(t, types.LatestSignerForChainID(big.NewInt(1)).Hash(tx), requests[0].Digest)
+	assert.Contains(t, requests[0].String(), "on chain 1 to "+to.Hex()+" value 100 nonce 0 data 0xa9059cbb")
+	assert.Equal(t, "0x0a94cf6625e5860fc4f330d75bcd0c3a4737957d2321d1a024540ab5320fe903", requests[3].Digest.Hex())
+	assert.Contains(t, requests[3].String(), "typed data Person of Ether Mail on chain 1")
+
+	records, err := VerifyAuditLog(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	assert.True(t, records[0].Approved)
+	assert.Equal(t, txSignature(signedTx), []byte(records[0].Signature))
+	assert.False(t, records[2].Approved)
+	assert.Empty(t, records[2].Signature)
+	assert.Contains(t, records[2].Error, "operator declined")
+	assert.Equal(t, records[2].Hash, records[3].PrevHash)
+
+	// any change to a record breaks the chain
+	tampered := strings.Replace(buf.String(), `"approved":false`, `"approved":true`, 1)
+	_, err = VerifyAuditLog(strings.NewReader(tampered))
+	assert.ErrorIs(t, err, ErrAuditLogTampered)
+
+	lines := strings.SplitAfter(buf.String(), "\n")
+	_, err = VerifyAuditLog(strings.NewReader(lines[0] + lines[2] + lines[3]))
+	assert.ErrorIs(t, err, ErrAuditLogTampered)
+}
+
+func TestAuditSignerPolicy(t *testing.T) {
+	session, err := NewSessionKey(Policy{})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	signer, err := NewAuditSigner(session, AuditOptions{Log: NewAuditLog(&buf)})
+	require.NoError(t, err)
+
+	// the policy rejections are recorded too
+	_, err = signer.SignMessage([]byte("hi"))
+	assert.ErrorIs(t, err, ErrPolicyMessageSigning)
+
+	records, err := VerifyAuditLog(&buf)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.True(t, records[0].Approved)
+	assert.Equal(t, ErrPolicyMessageSigning.Error(), records[0].Error)
+}
+
+func TestOpenAuditLog(t *testing.T) {
+	wallet, err := NewWalletFromRandomEntropy()
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	for i := 0; i < 2; i++ {
+		log, file, err := OpenAuditLog(path)
+		require.NoError(t, err)
+		signer, err := NewAuditSigner(wallet, AuditOptions{Log: log})
+		require.NoError(t, err)
+		_, err = signer.SignData([]byte{byte(i)})
+		require.NoError(t, err)
+
+		// the numbers of the typed data are decoded as json numbers, of which
+		// the record is verified as written
+		_, err = signer.SignTypedData(&ethcoder.TypedData{
+			Types: ethcoder.TypedDataTypes{
+				"EIP712Domain": {{Name: "name", Type: "string"}},
+				"Mail":         {{Name: "amount", Type: "uint256"}},
+			},
+			PrimaryType: "Mail",
+			Domain:      ethcoder.TypedDataDomain{Name: "Ether Mail"},
+			Message:     map[string]interface{}{"amount": new(big.Int).Lsh(big.NewInt(1), 200)},
+		})
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+	}
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	records, err := VerifyAuditLog(file)
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	assert.Equal(t, uint64(3), records[3].Seq)
+}
@@ -0,0 +1,114 @@
+package ethwallet
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/0xsequence/ethkit/go-ethereum/accounts"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// DerivationScheme is a scheme of the derivation paths of the accounts of a
+// wallet, of which the template is the path of the account of index "x", ie.
+// "m/44'/60'/x'/0/0" of Ledger Live.
+type DerivationScheme struct {
+	Name     string
+	Template string
+}
+
+var (
+	// DerivationSchemeBIP44 is the scheme of the default path of ethkit, of
+	// metamask and of trezor.
+	DerivationSchemeBIP44 = DerivationScheme{Name: "bip44", Template: "m/44'/60'/0'/0/x"}
+
+	// DerivationSchemeLedgerLive is the scheme of Ledger Live, of an account of
+	// each hardened index.
+	DerivationSchemeLedgerLive = DerivationScheme{Name: "ledger-live", Template: "m/44'/60'/x'/0/0"}
+
+	// DerivationSchemeLegacy is the legacy scheme of MEW and of the ledger
+	// chrome app.
+	DerivationSchemeLegacy = DerivationScheme{Name: "legacy", Template: "m/44'/60'/0'/x"}
+)
+
+// DerivationSchemes are the preset derivation schemes.
+var DerivationSchemes = []DerivationScheme{
+	DerivationSchemeBIP44,
+	DerivationSchemeLedgerLive,
+	DerivationSchemeLegacy,
+}
+
+// NewDerivationScheme returns the scheme of the template, of which one
+// component is the index "x" or the hardened index "x'".
+func NewDerivationScheme(name, template string) (DerivationScheme, error) {
+	scheme := DerivationScheme{Name: name, Template: template}
+	if _, err := scheme.Path(0); err != nil {
+		return DerivationScheme{}, err
+	}
+	return scheme, nil
+}
+
+// Path returns the derivation path of the account of the index.
+func (s DerivationScheme) Path(index uint32) (accounts.DerivationPath, error) {
+	components := strings.Split(s.Template, "/")
+	n := 0
+	for i, component := range components {
+		if strings.TrimSuffix(strings.TrimSpace(component), "'") == "x" {
+			components[i] = strings.Replace(component, "x", strconv.FormatUint(uint64(index), 10), 1)
+			n++
+		}
+	}
+	if n != 1 {
+		return nil, fmt.Errorf("ethwallet: derivation scheme template %q must have one index component", s.Template)
+	}
+
+	path, err := ParseDerivationPath(strings.Join(components, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("ethwallet: invalid derivation scheme template %q: %w", s.Template, err)
+	}
+	return path, nil
+}
+
+// DerivedAccount is an account of a derivation scheme.
+type DerivedAccount struct {
+	Index   uint32
+	Path    accounts.DerivationPath
+	Address common.Address
+}
+
+// Accounts returns the count accounts of the scheme from the index start, and
+// leaves the path of the node as it is.
+func (h *HDNode) Accounts(scheme DerivationScheme, start, count uint32) ([]DerivedAccount, error) {
+	if h.masterKey == nil {
+		return nil, fmt.Errorf("ethwallet: hdnode of a private key has no accounts")
+	}
+	derived := make([]DerivedAccount, 0, count)
+	for index := start; index-start < count; index++ {
+		path, err := scheme.Path(index)
+		if err != nil {
+			return nil, err
+		}
+		address, err := deriveAddress(h.masterKey, path)
+		if err != nil {
+			return nil, err
+		}
+		derived = append(derived, DerivedAccount{Index: index, Path: path, Address: address})
+	}
+	return derived, nil
+}
+
+// Accounts returns the count accounts of the scheme of the wallet from the
+// index start, see HDNode.Accounts.
+func (w *Wallet) Accounts(scheme DerivationScheme, start, count uint32) ([]DerivedAccount, error) {
+	return w.hdnode.Accounts(scheme, start, count)
+}
+
+// DeriveSchemeIndex returns the wallet of the account of the index of the
+// scheme.
+func (w *Wallet) DeriveSchemeIndex(scheme DerivationScheme, index uint32) (*Wallet, common.Address, error) {
+	path, err := scheme.Path(index)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	return w.DerivePath(path)
+}
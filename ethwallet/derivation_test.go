@@ -0,0 +1,65 @@
+package ethwallet_test
+
+import (
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDerivationSchemes(t *testing.T) {
+	path, err := ethwallet.DerivationSchemeLedgerLive.Path(2)
+	require.NoError(t, err)
+	assert.Equal(t, "m/44'/60'/2'/0/0", path.String())
+
+	path, err = ethwallet.DerivationSchemeLegacy.Path(2)
+	require.NoError(t, err)
+	assert.Equal(t, "m/44'/60'/0'/2", path.String())
+
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	bip44, err := wallet.Accounts(ethwallet.DerivationSchemeBIP44, 0, 2)
+	require.NoError(t, err)
+	require.Len(t, bip44, 2)
+	assert.Equal(t, "0xe0C9828dee3411A28CcB4bb82a18d0aAd24489E0", bip44[0].Address.Hex())
+	assert.Equal(t, "0x9e02d584c27Ec74f832154985046C0f3c5E0f724", bip44[1].Address.Hex())
+	assert.Equal(t, uint32(1), bip44[1].Index)
+
+	// the first account of ledger live is the one of bip44
+	ledgerLive, err := wallet.Accounts(ethwallet.DerivationSchemeLedgerLive, 0, 3)
+	require.NoError(t, err)
+	require.Len(t, ledgerLive, 3)
+	assert.Equal(t, bip44[0].Address, ledgerLive[0].Address)
+	assert.NotEqual(t, bip44[1].Address, ledgerLive[1].Address)
+
+	legacy, err := wallet.Accounts(ethwallet.DerivationSchemeLegacy, 5, 1)
+	require.NoError(t, err)
+	require.Len(t, legacy, 1)
+	assert.Equal(t, uint32(5), legacy[0].Index)
+
+	// the path of the wallet is unchanged
+	assert.Equal(t, "0xe0C9828dee3411A28CcB4bb82a18d0aAd24489E0", wallet.Address().Hex())
+
+	derived, address, err := wallet.DeriveSchemeIndex(ethwallet.DerivationSchemeLedgerLive, 2)
+	require.NoError(t, err)
+	assert.Equal(t, ledgerLive[2].Address, address)
+	assert.Equal(t, ledgerLive[2].Address, derived.Address())
+	assert.Equal(t, "0xe0C9828dee3411A28CcB4bb82a18d0aAd24489E0", wallet.Address().Hex())
+
+	t.Run("custom", func(t *testing.T) {
+		scheme, err := ethwallet.NewDerivationScheme("custom", "m/44'/60'/1'/x'/7")
+		require.NoError(t, err)
+		path, err := scheme.Path(3)
+		require.NoError(t, err)
+		assert.Equal(t, "m/44'/60'/1'/3'/7", path.String())
+
+		_, err = ethwallet.NewDerivationScheme("none", "m/44'/60'/0'/0/0")
+		assert.Error(t, err)
+		_, err = ethwallet.NewDerivationScheme("many", "m/44'/60'/x'/0/x")
+		assert.Error(t, err)
+		_, err = ethwallet.NewDerivationScheme("invalid", "m/44'/60'/y'/x")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,111 @@
+package ethwallet
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"golang.org/x/sync/errgroup"
+)
+
+var DefaultDiscoveryOptions = DiscoveryOptions{
+	Scheme:      DerivationSchemeBIP44,
+	GapLimit:    20,
+	Concurrency: 8,
+}
+
+// DiscoveryOptions are the options of the discovery of the accounts of a
+// wallet, of which the zero values are the ones of DefaultDiscoveryOptions.
+type DiscoveryOptions struct {
+	// Scheme is the derivation scheme of the accounts.
+	Scheme DerivationScheme
+
+	// GapLimit is the number of consecutive unused accounts after which the
+	// discovery stops, as of BIP-44.
+	GapLimit int
+
+	// Concurrency is the number of the accounts queried at once.
+	Concurrency int
+
+	// BlockNum is the block of the balances and nonces, or the latest block.
+	BlockNum *big.Int
+}
+
+// DiscoveredAccount is a used account, of a balance or of sent transactions.
+type DiscoveredAccount struct {
+	DerivedAccount
+	Balance *big.Int
+	Nonce   uint64
+}
+
+// DiscoverAccounts returns the used accounts of the scheme, of which the
+// balance or the nonce isn't zero, from the first index until GapLimit
+// consecutive accounts are unused.
+func (h *HDNode) DiscoverAccounts(ctx context.Context, provider ethrpc.Interface, options ...DiscoveryOptions) ([]DiscoveredAccount, error) {
+	opts := DefaultDiscoveryOptions
+	if len(options) > 0 {
+		opts = options[0]
+		if opts.Scheme.Template == "" {
+			opts.Scheme = DefaultDiscoveryOptions.Scheme
+		}
+		if opts.GapLimit <= 0 {
+			opts.GapLimit = DefaultDiscoveryOptions.GapLimit
+		}
+		if opts.Concurrency <= 0 {
+			opts.Concurrency = DefaultDiscoveryOptions.Concurrency
+		}
+	}
+
+	var used []DiscoveredAccount
+	gap := 0
+	for start := uint32(0); ; start += uint32(opts.GapLimit) {
+		// the window of the accounts which may end the gap
+		window, err := h.Accounts(opts.Scheme, start, uint32(opts.GapLimit))
+		if err != nil {
+			return nil, err
+		}
+		discovered := make([]DiscoveredAccount, len(window))
+
+		g, gctx := errgroup.WithContext(ctx)
+		g.SetLimit(opts.Concurrency)
+		for i := range window {
+			i := i
+			g.Go(func() error {
+				account := DiscoveredAccount{DerivedAccount: window[i]}
+				balance, err := provider.BalanceAt(gctx, account.Address, opts.BlockNum)
+				if err != nil {
+					return fmt.Errorf("ethwallet: failed to get balance of %s: %w", account.Address.Hex(), err)
+				}
+				nonce, err := provider.NonceAt(gctx, account.Address, opts.BlockNum)
+				if err != nil {
+					return fmt.Errorf("ethwallet: failed to get nonce of %s: %w", account.Address.Hex(), err)
+				}
+				account.Balance, account.Nonce = balance, nonce
+				discovered[i] = account
+				return nil
+			})
+		}
+		if err := g.Wait(); err != nil {
+			return nil, err
+		}
+
+		for _, account := range discovered {
+			if account.Nonce == 0 && (account.Balance == nil || account.Balance.Sign() == 0) {
+				gap++
+				if gap >= opts.GapLimit {
+					return used, nil
+				}
+				continue
+			}
+			used = append(used, account)
+			gap = 0
+		}
+	}
+}
+
+// DiscoverAccounts returns the used accounts of the wallet, see
+// HDNode.DiscoverAccounts.
+func (w *Wallet) DiscoverAccounts(ctx context.Context, provider ethrpc.Interface, options ...DiscoveryOptions) ([]DiscoveredAccount, error) {
+	return w.hdnode.DiscoverAccounts(ctx, provider, options...)
+}
@@ -0,0 +1,81 @@
+package ethwallet_test
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockDiscoveryProvider struct {
+	ethrpc.Interface
+	balances map[common.Address]*big.Int
+	nonces   map[common.Address]uint64
+	queried  sync.Map
+	err      error
+}
+
+func (p *mockDiscoveryProvider) BalanceAt(ctx context.Context, account common.Address, blockNum *big.Int) (*big.Int, error) {
+	p.queried.Store(account, true)
+	if p.err != nil {
+		return nil, p.err
+	}
+	if balance, ok := p.balances[account]; ok {
+		return balance, nil
+	}
+	return new(big.Int), nil
+}
+
+func (p *mockDiscoveryProvider) NonceAt(ctx context.Context, account common.Address, blockNum *big.Int) (uint64, error) {
+	return p.nonces[account], nil
+}
+
+func TestWalletDiscoverAccounts(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("outdoor sentence roast truly flower surface power begin ocean silent debate funny")
+	require.NoError(t, err)
+
+	accounts, err := wallet.Accounts(ethwallet.DerivationSchemeBIP44, 0, 20)
+	require.NoError(t, err)
+
+	// the accounts 0 and 3 are used, and 9 is after the gap of the 5 unused
+	// accounts following 3
+	provider := &mockDiscoveryProvider{
+		balances: map[common.Address]*big.Int{accounts[0].Address: big.NewInt(1)},
+		nonces:   map[common.Address]uint64{accounts[3].Address: 2, accounts[9].Address: 1},
+	}
+	discovered, err := wallet.DiscoverAccounts(context.Background(), provider, ethwallet.DiscoveryOptions{GapLimit: 5})
+	require.NoError(t, err)
+	require.Len(t, discovered, 2)
+	assert.Equal(t, accounts[0].Address, discovered[0].Address)
+	assert.Equal(t, big.NewInt(1), discovered[0].Balance)
+	assert.Equal(t, uint32(3), discovered[1].Index)
+	assert.Equal(t, uint64(2), discovered[1].Nonce)
+
+	_, queried := provider.queried.Load(accounts[8].Address)
+	assert.True(t, queried)
+	_, queried = provider.queried.Load(accounts[10].Address)
+	assert.False(t, queried)
+
+	t.Run("scheme", func(t *testing.T) {
+		ledgerLive, err := wallet.Accounts(ethwallet.DerivationSchemeLedgerLive, 0, 2)
+		require.NoError(t, err)
+		provider := &mockDiscoveryProvider{nonces: map[common.Address]uint64{ledgerLive[1].Address: 1}}
+
+		discovered, err := wallet.DiscoverAccounts(context.Background(), provider, ethwallet.DiscoveryOptions{Scheme: ethwallet.DerivationSchemeLedgerLive})
+		require.NoError(t, err)
+		require.Len(t, discovered, 1)
+		assert.Equal(t, "m/44'/60'/1'/0/0", discovered[0].Path.String())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := wallet.DiscoverAccounts(context.Background(), &mockDiscoveryProvider{err: fmt.Errorf("rpc down")})
+		assert.ErrorContains(t, err, "rpc down")
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/0xsequence/ethkit/ethcoder"
 	"github.com/0xsequence/ethkit/ethrpc"
 	"github.com/0xsequence/ethkit/ethtxn"
 	"github.com/0xsequence/ethkit/go-ethereum/accounts"
@@ -31,6 +32,10 @@ type Wallet struct {
 type WalletOptions struct {
 	DerivationPath             string
 	RandomWalletEntropyBitSize int
+
+	// MnemonicLanguage is the language of the mnemonic of a random wallet, which
+	// is english by default.
+	MnemonicLanguage MnemonicLanguage
 }
 
 func NewWalletFromPrivateKey(key string) (*Wallet, error) {
@@ -67,7 +72,12 @@ func NewWalletFromRandomEntropy(options ...WalletOptions) (*Wallet, error) {
 		return nil, err
 	}
 
-	hdnode, err := NewHDNodeFromRandomEntropy(opts.RandomWalletEntropyBitSize, &derivationPath)
+	lang := opts.MnemonicLanguage
+	if lang == "" {
+		lang = MnemonicLanguageEnglish
+	}
+
+	hdnode, err := NewHDNodeFromRandomEntropy(opts.RandomWalletEntropyBitSize, &derivationPath, lang)
 	if err != nil {
 		return nil, err
 	}
@@ -80,6 +90,12 @@ func NewWalletFromRandomEntropy(options ...WalletOptions) (*Wallet, error) {
 }
 
 func NewWalletFromMnemonic(mnemonic string, optPath ...string) (*Wallet, error) {
+	return NewWalletFromMnemonicWithPassphrase(mnemonic, "", optPath...)
+}
+
+// NewWalletFromMnemonicWithPassphrase returns the wallet of the mnemonic and the
+// BIP-39 passphrase, ie. of a hidden wallet of a hardware wallet.
+func NewWalletFromMnemonicWithPassphrase(mnemonic, passphrase string, optPath ...string) (*Wallet, error) {
 	var err error
 	derivationPath := DefaultBaseDerivationPath
 	if len(optPath) > 0 {
@@ -89,7 +105,7 @@ func NewWalletFromMnemonic(mnemonic string, optPath ...string) (*Wallet, error)
 		}
 	}
 
-	hdnode, err := NewHDNodeFromMnemonic(mnemonic, &derivationPath)
+	hdnode, err := NewHDNodeFromMnemonicWithPassphrase(mnemonic, passphrase, &derivationPath)
 	if err != nil {
 		return nil, err
 	}
@@ -234,7 +250,13 @@ func (w *Wallet) GetNonce(ctx context.Context) (uint64, error) {
 	return w.GetProvider().NonceAt(ctx, w.Address(), nil)
 }
 
+// SignTx signs the transaction of any type, ie. legacy, access list, dynamic fee,
+// blob or set code, with the latest signer of the chain. The chain id of a typed
+// transaction is used when chainID is nil.
 func (w *Wallet) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	if chainID == nil && tx.Type() != types.LegacyTxType {
+		chainID = tx.ChainId()
+	}
 	signer := types.LatestSignerForChainID(chainID)
 	signedTx, err := types.SignTx(tx, signer, w.hdnode.PrivateKey())
 	if err != nil {
@@ -254,6 +276,36 @@ func (w *Wallet) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transac
 	return signedTx, nil
 }
 
+// SignAuthorization signs the EIP-7702 authorization of the wallet delegating
+// its code to the address, ie. for the authorization list of a set code
+// transaction. The nonce is the account nonce of the wallet when the
+// authorization is applied, so the authorization of a set code transaction
+// sent by the wallet itself is of the nonce of the transaction + 1. The
+// authorization of nil or zero chainID is valid on any chain.
+func (w *Wallet) SignAuthorization(chainID *big.Int, address common.Address, nonce uint64) (types.SetCodeAuthorization, error) {
+	auth := types.SetCodeAuthorization{Address: address, Nonce: nonce}
+	if chainID != nil {
+		if chainID.Sign() < 0 || auth.ChainID.SetFromBig(chainID) {
+			return types.SetCodeAuthorization{}, fmt.Errorf("ethwallet: invalid authorization chain id %s", chainID)
+		}
+	}
+
+	signedAuth, err := types.SignSetCode(w.hdnode.PrivateKey(), auth)
+	if err != nil {
+		return types.SetCodeAuthorization{}, err
+	}
+
+	authority, err := signedAuth.Authority()
+	if err != nil {
+		return types.SetCodeAuthorization{}, err
+	}
+	if authority != w.hdnode.Address() {
+		return types.SetCodeAuthorization{}, fmt.Errorf("signer mismatch: expected %s, got %s", w.hdnode.Address().Hex(), authority.Hex())
+	}
+
+	return signedAuth, nil
+}
+
 func (w *Wallet) SignMessage(message []byte) ([]byte, error) {
 	message191 := []byte("\x19Ethereum Signed Message:\n")
 	if !bytes.HasPrefix(message, message191) {
@@ -287,6 +339,23 @@ func (w *Wallet) SignData(data []byte) ([]byte, error) {
 	return sig, nil
 }
 
+// SignTypedData signs the EIP-712 digest of the typed data, of which the
+// signature is the one of eth_signTypedData_v4.
+func (w *Wallet) SignTypedData(typedData *ethcoder.TypedData) ([]byte, error) {
+	digest, err := typedData.EncodeDigest()
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := crypto.Sign(digest, w.hdnode.PrivateKey())
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+
+	return sig, nil
+}
+
 func (w *Wallet) IsValidSignature(msg, sig []byte) (bool, error) {
 	recoveredAddress, err := RecoverAddress(msg, sig)
 	if err != nil {
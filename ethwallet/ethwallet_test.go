@@ -2,12 +2,19 @@ package ethwallet_test
 
 import (
 	"fmt"
+	"math/big"
 	"testing"
 
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethtxn"
 	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
 	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
 	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/holiman/uint256"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestWalletRandom(t *testing.T) {
@@ -128,3 +135,292 @@ func TestWalletSignDataAndRecover(t *testing.T) {
 
 	assert.Equal(t, address, recoveredAddress)
 }
+
+func TestCompactSignature(t *testing.T) {
+	// the test cases of EIP-2098
+	cases := []struct {
+		signature string
+		compact   string
+	}{
+		{
+			"0x68a020a209d3d56c46f38cc50a33f704f4a9a10a59377f8dd762ac66910e9b90" + "7e865ad05c4035ab5792787d4a0297a43617ae897930a6fe4d822b8faea52064" + "1b",
+			"0x68a020a209d3d56c46f38cc50a33f704f4a9a10a59377f8dd762ac66910e9b90" + "7e865ad05c4035ab5792787d4a0297a43617ae897930a6fe4d822b8faea52064",
+		},
+		{
+			"0x9328da16089fcba9bececa81663203989f2df5fe1faa6291a45381c81bd17f76" + "139c6d6b623b42da56557e5e734a43dc83345ddfadec52cbe24d0cc64f550793" + "1c",
+			"0x9328da16089fcba9bececa81663203989f2df5fe1faa6291a45381c81bd17f76" + "939c6d6b623b42da56557e5e734a43dc83345ddfadec52cbe24d0cc64f550793",
+		},
+	}
+	for _, c := range cases {
+		compact, err := ethwallet.CompactSignature(hexutil.MustDecode(c.signature))
+		assert.NoError(t, err)
+		assert.Equal(t, c.compact, hexutil.Encode(compact))
+
+		signature, err := ethwallet.ExpandCompactSignature(compact)
+		assert.NoError(t, err)
+		assert.Equal(t, c.signature, hexutil.Encode(signature))
+	}
+
+	// the v of 0 or 1, and of a signed message
+	wallet, err := ethwallet.NewWalletFromPrivateKey("3c121e5b2c2b2426f386bfc0257820846d77610c20e0fd4144417fb8fd79bfb8")
+	assert.NoError(t, err)
+	sig, err := wallet.SignMessage([]byte("hi"))
+	assert.NoError(t, err)
+	sig[64] -= 27
+	compact, err := ethwallet.CompactSignature(sig)
+	assert.NoError(t, err)
+	expanded, err := ethwallet.ExpandCompactSignature(compact)
+	assert.NoError(t, err)
+	recoveredAddress, err := ethwallet.RecoverAddress([]byte("hi"), expanded)
+	assert.NoError(t, err)
+	assert.Equal(t, wallet.Address(), recoveredAddress)
+
+	_, err = ethwallet.CompactSignature(sig[:64])
+	assert.Error(t, err)
+	_, err = ethwallet.ExpandCompactSignature(sig)
+	assert.Error(t, err)
+	sig[64] = 5
+	_, err = ethwallet.CompactSignature(sig)
+	assert.Error(t, err)
+
+	// s is of the lower half of the curve order up to n/2, of which the top bit
+	// is not set above n/2 too
+	halfN := hexutil.MustDecode("0x7fffffffffffffffffffffffffffffff5d576e7357a4501ddfe92f46681b20a0")
+	sig[64] = 27
+	copy(sig[32:64], halfN)
+	_, err = ethwallet.CompactSignature(sig)
+	assert.NoError(t, err)
+	sig[63]++
+	_, err = ethwallet.CompactSignature(sig)
+	assert.Error(t, err)
+}
+
+func TestSplitSignature(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromPrivateKey("3c121e5b2c2b2426f386bfc0257820846d77610c20e0fd4144417fb8fd79bfb8")
+	assert.NoError(t, err)
+	signature, err := wallet.SignMessage([]byte("hi"))
+	assert.NoError(t, err)
+
+	sig, err := ethwallet.SplitSignature(signature)
+	assert.NoError(t, err)
+	assert.Equal(t, signature, sig.Bytes())
+	assert.Equal(t, signature[64]-27, sig.YParity())
+	assert.False(t, sig.IsHighS())
+
+	// the v of 0 or 1, of EIP-155 and of a compact signature
+	for _, v := range []uint64{uint64(sig.YParity()), uint64(sig.V), 1*2 + 35 + uint64(sig.YParity()), 137*2 + 35 + uint64(sig.YParity())} {
+		joined, err := ethwallet.JoinSignature(sig.R, sig.S, v)
+		assert.NoError(t, err)
+		assert.Equal(t, signature, joined)
+	}
+	compact, err := sig.Compact()
+	assert.NoError(t, err)
+	fromCompact, err := ethwallet.SplitSignature(compact)
+	assert.NoError(t, err)
+	assert.Equal(t, sig, fromCompact)
+
+	// the malleable signature of n-s recovers the same address
+	n := crypto.S256().Params().N
+	high := &ethwallet.Signature{R: sig.R, V: 27 + 1 - sig.YParity()}
+	new(big.Int).Sub(n, new(big.Int).SetBytes(sig.S[:])).FillBytes(high.S[:])
+	assert.True(t, high.IsHighS())
+	recovered, err := ethwallet.RecoverAddress([]byte("hi"), high.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, wallet.Address(), recovered)
+	assert.Equal(t, sig, high.LowS())
+	assert.Equal(t, sig, sig.LowS())
+	_, err = high.Compact()
+	assert.Error(t, err)
+
+	_, err = ethwallet.JoinSignature(sig.R, sig.S, 29)
+	assert.Error(t, err)
+	_, err = ethwallet.SplitSignature(signature[:63])
+	assert.Error(t, err)
+}
+
+func TestWalletSignTx(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromRandomEntropy()
+	require.NoError(t, err)
+
+	chainID := big.NewInt(1337)
+	to := common.HexToAddress("0x8e7b6c2a3f9d1e4b5c6a7d8e9f0a1b2c3d4e5f60")
+	accessList := types.AccessList{{Address: to, StorageKeys: []common.Hash{{0x01}}}}
+
+	txs := map[string]types.TxData{
+		"legacy": &types.LegacyTx{
+			Nonce: 1, GasPrice: big.NewInt(1e9), Gas: 21000, To: &to, Value: big.NewInt(1),
+		},
+		"access list": &types.AccessListTx{
+			ChainID: chainID, Nonce: 2, GasPrice: big.NewInt(1e9), Gas: 30000, To: &to, AccessList: accessList,
+		},
+		"dynamic fee": &types.DynamicFeeTx{
+			ChainID: chainID, Nonce: 3, GasTipCap: big.NewInt(1e9), GasFeeCap: big.NewInt(2e9), Gas: 21000, To: &to,
+		},
+		"blob": &types.BlobTx{
+			ChainID: uint256.MustFromBig(chainID), Nonce: 4, GasTipCap: uint256.NewInt(1e9), GasFeeCap: uint256.NewInt(2e9),
+			Gas: 21000, To: to, Value: uint256.NewInt(0), BlobFeeCap: uint256.NewInt(1e9),
+			BlobHashes: []common.Hash{{0x01}},
+		},
+		"set code": &types.SetCodeTx{
+			ChainID: uint256.MustFromBig(chainID), Nonce: 5, GasTipCap: uint256.NewInt(1e9), GasFeeCap: uint256.NewInt(2e9),
+			Gas: 50000, To: to, Value: uint256.NewInt(0), AccessList: accessList,
+			AuthList: []types.SetCodeAuthorization{{ChainID: *uint256.MustFromBig(chainID), Address: to, Nonce: 6}},
+		},
+	}
+
+	for name, txData := range txs {
+		t.Run(name, func(t *testing.T) {
+			tx := types.NewTx(txData)
+			signedTx, err := wallet.SignTx(tx, chainID)
+			require.NoError(t, err)
+			assert.Equal(t, tx.Type(), signedTx.Type())
+
+			sender, err := types.Sender(types.LatestSignerForChainID(chainID), signedTx)
+			require.NoError(t, err)
+			assert.Equal(t, wallet.Address(), sender)
+
+			// the same transaction of its encoding
+			data, err := signedTx.MarshalBinary()
+			require.NoError(t, err)
+			decodedTx := &types.Transaction{}
+			require.NoError(t, decodedTx.UnmarshalBinary(data))
+			assert.Equal(t, signedTx.Hash(), decodedTx.Hash())
+
+			if tx.Type() != types.LegacyTxType {
+				// the chain id of the transaction
+				signedTx, err = wallet.SignTx(tx, nil)
+				require.NoError(t, err)
+				sender, err = types.Sender(types.LatestSignerForChainID(chainID), signedTx)
+				require.NoError(t, err)
+				assert.Equal(t, wallet.Address(), sender)
+
+				_, err = wallet.SignTx(tx, big.NewInt(1))
+				assert.ErrorIs(t, err, types.ErrInvalidChainId)
+			}
+		})
+	}
+}
+
+func TestWalletSignAuthorization(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromRandomEntropy()
+	require.NoError(t, err)
+
+	chainID := big.NewInt(1337)
+	delegate := common.HexToAddress("0x63c0c19a282a1b52b07dd5a65b58948a07dae32b")
+
+	auth, err := wallet.SignAuthorization(chainID, delegate, 8)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1337), auth.ChainID.Uint64())
+	assert.Equal(t, delegate, auth.Address)
+	assert.Equal(t, uint64(8), auth.Nonce)
+
+	authority, err := auth.Authority()
+	require.NoError(t, err)
+	assert.Equal(t, wallet.Address(), authority)
+
+	// the same authorization of its json
+	data, err := auth.MarshalJSON()
+	require.NoError(t, err)
+	var decodedAuth types.SetCodeAuthorization
+	require.NoError(t, decodedAuth.UnmarshalJSON(data))
+	assert.Equal(t, auth, decodedAuth)
+
+	// the authorization of any chain
+	anyChainAuth, err := wallet.SignAuthorization(nil, delegate, 8)
+	require.NoError(t, err)
+	assert.True(t, anyChainAuth.ChainID.IsZero())
+	authority, err = anyChainAuth.Authority()
+	require.NoError(t, err)
+	assert.Equal(t, wallet.Address(), authority)
+
+	_, err = wallet.SignAuthorization(big.NewInt(-1), delegate, 8)
+	assert.Error(t, err)
+
+	t.Run("authorization list", func(t *testing.T) {
+		tx := types.NewTx(&types.DynamicFeeTx{
+			ChainID: chainID, Nonce: 7, GasTipCap: big.NewInt(1e9), GasFeeCap: big.NewInt(2e9), Gas: 80000,
+			To: &delegate, Value: big.NewInt(1), Data: []byte{0x01},
+		})
+		setCodeTx, err := ethtxn.WithAuthorizationList(tx, []types.SetCodeAuthorization{auth})
+		require.NoError(t, err)
+		assert.Equal(t, uint8(types.SetCodeTxType), setCodeTx.Type())
+		assert.Equal(t, tx.Nonce(), setCodeTx.Nonce())
+		assert.Equal(t, tx.GasFeeCap(), setCodeTx.GasFeeCap())
+		assert.Equal(t, tx.Value(), setCodeTx.Value())
+		assert.Equal(t, []types.SetCodeAuthorization{auth}, setCodeTx.SetCodeAuthorizations())
+
+		signedTx, err := wallet.SignTx(setCodeTx, chainID)
+		require.NoError(t, err)
+		sender, err := types.Sender(types.LatestSignerForChainID(chainID), signedTx)
+		require.NoError(t, err)
+		assert.Equal(t, wallet.Address(), sender)
+
+		data, err := signedTx.MarshalJSON()
+		require.NoError(t, err)
+		decodedTx := &types.Transaction{}
+		require.NoError(t, decodedTx.UnmarshalJSON(data))
+		assert.Equal(t, signedTx.Hash(), decodedTx.Hash())
+		assert.Equal(t, []types.SetCodeAuthorization{auth}, decodedTx.SetCodeAuthorizations())
+
+		_, err = ethtxn.WithAuthorizationList(types.NewTx(&types.LegacyTx{To: &delegate, GasPrice: big.NewInt(1)}), []types.SetCodeAuthorization{auth})
+		assert.Error(t, err)
+		_, err = ethtxn.WithAuthorizationList(types.NewTx(&types.DynamicFeeTx{ChainID: chainID, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(1)}), []types.SetCodeAuthorization{auth})
+		assert.Error(t, err)
+	})
+}
+
+func TestWalletSignTypedData(t *testing.T) {
+	// the example of EIP-712, of the key of "cow"
+	wallet, err := ethwallet.NewWalletFromPrivateKey(hexutil.Encode(crypto.Keccak256([]byte("cow")))[2:])
+	assert.NoError(t, err)
+	assert.Equal(t, "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826", wallet.Address().Hex())
+
+	verifyingContract := common.HexToAddress("0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC")
+	typedData := &ethcoder.TypedData{
+		Types: ethcoder.TypedDataTypes{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"Person": {
+				{Name: "name", Type: "string"},
+				{Name: "wallet", Type: "address"},
+			},
+			"Mail": {
+				{Name: "from", Type: "Person"},
+				{Name: "to", Type: "Person"},
+				{Name: "contents", Type: "string"},
+			},
+		},
+		PrimaryType: "Mail",
+		Domain: ethcoder.TypedDataDomain{
+			Name:              "Ether Mail",
+			Version:           "1",
+			ChainID:           big.NewInt(1),
+			VerifyingContract: &verifyingContract,
+		},
+		Message: map[string]interface{}{
+			"from": map[string]interface{}{
+				"name":   "Cow",
+				"wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826",
+			},
+			"to": map[string]interface{}{
+				"name":   "Bob",
+				"wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB",
+			},
+			"contents": "Hello, Bob!",
+		},
+	}
+
+	sig, err := wallet.SignTypedData(typedData)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b915621c", hexutil.Encode(sig))
+
+	digest, err := typedData.EncodeDigest()
+	assert.NoError(t, err)
+	valid, err := wallet.IsValidSignatureOfDigest(digest, sig)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/tyler-smith/go-bip39"
+	"golang.org/x/text/unicode/norm"
 )
 
 // DefaultBaseDerivationPath is the base path from which custom derivation endpoints
@@ -31,6 +32,7 @@ type HDNode struct {
 
 	entropy        []byte
 	mnemonic       string
+	passphrase     string
 	derivationPath accounts.DerivationPath
 
 	address common.Address
@@ -49,27 +51,41 @@ func NewHDNodeFromPrivateKey(privateKey string) (*HDNode, error) {
 	}, nil
 }
 
+// NewHDNodeFromMnemonic returns the node of the mnemonic of any of the
+// MnemonicLanguages, of which the language is detected.
 func NewHDNodeFromMnemonic(mnemonic string, path *accounts.DerivationPath) (*HDNode, error) {
-	entropy, err := MnemonicToEntropy(mnemonic)
+	return NewHDNodeFromMnemonicWithPassphrase(mnemonic, "", path)
+}
+
+// NewHDNodeFromMnemonicWithPassphrase returns the node of the mnemonic and the
+// BIP-39 passphrase, ie. the "25th word" of the hidden wallets of hardware
+// wallets. Any passphrase is valid, and each is of a different wallet.
+func NewHDNodeFromMnemonicWithPassphrase(mnemonic, passphrase string, path *accounts.DerivationPath) (*HDNode, error) {
+	entropy, lang, err := decodeMnemonic(mnemonic)
 	if err != nil {
 		return nil, err
 	}
 
-	hdnode, err := NewHDNodeFromEntropy(entropy, path)
+	// the mnemonic of the words of the wordlist, ie. of its separator
+	mnemonic, err = entropyToMnemonic(entropy, lang)
 	if err != nil {
 		return nil, err
 	}
-	hdnode.mnemonic = mnemonic
-	return hdnode, nil
+	return newHDNode(entropy, mnemonic, passphrase, path)
 }
 
-func NewHDNodeFromEntropy(entropy []byte, path *accounts.DerivationPath) (*HDNode, error) {
-	mnemonic, err := EntropyToMnemonic(entropy)
+// NewHDNodeFromEntropy returns the node of the mnemonic of the entropy, of the
+// language or of english.
+func NewHDNodeFromEntropy(entropy []byte, path *accounts.DerivationPath, optLanguage ...MnemonicLanguage) (*HDNode, error) {
+	mnemonic, err := EntropyToMnemonic(entropy, optLanguage...)
 	if err != nil {
 		return nil, err
 	}
+	return newHDNode(entropy, mnemonic, "", path)
+}
 
-	seed, err := NewSeedFromMnemonic(mnemonic)
+func newHDNode(entropy []byte, mnemonic, passphrase string, path *accounts.DerivationPath) (*HDNode, error) {
+	seed, err := NewSeedFromMnemonic(mnemonic, passphrase)
 	if err != nil {
 		return nil, err
 	}
@@ -105,33 +121,56 @@ func NewHDNodeFromEntropy(entropy []byte, path *accounts.DerivationPath) (*HDNod
 		publicKey:      publicKey,
 		entropy:        entropy,
 		mnemonic:       mnemonic,
+		passphrase:     passphrase,
 		derivationPath: derivationPath,
 		address:        address,
 	}, nil
 }
 
-func NewHDNodeFromRandomEntropy(bitSize int, path *accounts.DerivationPath) (*HDNode, error) {
+func NewHDNodeFromRandomEntropy(bitSize int, path *accounts.DerivationPath, optLanguage ...MnemonicLanguage) (*HDNode, error) {
 	entropy, err := RandomEntropy(bitSize)
 	if err != nil {
 		return nil, err
 	}
-	return NewHDNodeFromEntropy(entropy, path)
+	return NewHDNodeFromEntropy(entropy, path, optLanguage...)
 }
 
-// NewSeedFromMnemonic returns a BIP-39 seed based on a BIP-39 mnemonic.
-func NewSeedFromMnemonic(mnemonic string) ([]byte, error) {
+// NewSeedFromMnemonic returns a BIP-39 seed based on a BIP-39 mnemonic, of any
+// of the MnemonicLanguages, and of the optional BIP-39 passphrase.
+func NewSeedFromMnemonic(mnemonic string, optPassphrase ...string) ([]byte, error) {
 	if mnemonic == "" {
 		return nil, fmt.Errorf("mnemonic is required")
 	}
-	return bip39.NewSeedWithErrorChecking(mnemonic, "")
+	entropy, lang, err := decodeMnemonic(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	mnemonic, err = entropyToMnemonic(entropy, lang)
+	if err != nil {
+		return nil, err
+	}
+	passphrase := ""
+	if len(optPassphrase) > 0 {
+		passphrase = optPassphrase[0]
+	}
+	return bip39.NewSeed(norm.NFKD.String(mnemonic), norm.NFKD.String(passphrase)), nil
 }
 
-func MnemonicToEntropy(mnemonic string) ([]byte, error) {
-	return bip39.MnemonicToByteArray(mnemonic, true)
+// MnemonicToEntropy returns the entropy of the mnemonic, of the language or of
+// the detected language.
+func MnemonicToEntropy(mnemonic string, optLanguage ...MnemonicLanguage) ([]byte, error) {
+	entropy, _, err := decodeMnemonic(mnemonic, optLanguage...)
+	return entropy, err
 }
 
-func EntropyToMnemonic(entropy []byte) (string, error) {
-	return bip39.NewMnemonic(entropy)
+// EntropyToMnemonic returns the mnemonic of the entropy, of the language or of
+// english.
+func EntropyToMnemonic(entropy []byte, optLanguage ...MnemonicLanguage) (string, error) {
+	lang := MnemonicLanguageEnglish
+	if len(optLanguage) > 0 {
+		lang = optLanguage[0]
+	}
+	return entropyToMnemonic(entropy, lang)
 }
 
 func RandomEntropy(bitSize ...int) ([]byte, error) {
@@ -149,8 +188,11 @@ func RandomSeed() ([]byte, error) {
 	return b, err
 }
 
+// IsValidMnemonic returns whether the mnemonic is valid, of any of the
+// MnemonicLanguages.
 func IsValidMnemonic(mnemonic string) bool {
-	return bip39.IsMnemonicValid(mnemonic)
+	_, _, err := decodeMnemonic(mnemonic)
+	return err == nil
 }
 
 // ParseDerivationPath parses the derivation path in string format into []uint32
@@ -229,7 +271,7 @@ func (h *HDNode) DeriveAccountIndex(accountIndex uint32) error {
 func (h *HDNode) Clone() (*HDNode, error) {
 	derivationPath := make(accounts.DerivationPath, len(h.derivationPath))
 	copy(derivationPath, h.derivationPath)
-	return NewHDNodeFromMnemonic(h.Mnemonic(), &derivationPath)
+	return NewHDNodeFromMnemonicWithPassphrase(h.Mnemonic(), h.passphrase, &derivationPath)
 }
 
 // DerivePrivateKey derives the private key of the derivation path.
@@ -1,10 +1,14 @@
 package ethwallet_test
 
 import (
+	"encoding/hex"
+	"strings"
 	"testing"
 
 	"github.com/0xsequence/ethkit/ethwallet"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/unicode/norm"
 )
 
 func TestHDNodeMnemonicAndEntropy(t *testing.T) {
@@ -71,3 +75,134 @@ func TestHDNodeDerivationFailsWithInvalidMnemonic(t *testing.T) {
 		assert.Nilf(t, hdnode, "Expected nil hdnode for invalid mnemonic '%v'", mnemonic)
 	}
 }
+
+func TestHDNodeMnemonicLanguages(t *testing.T) {
+	entropy := make([]byte, 16)
+
+	for _, lang := range ethwallet.MnemonicLanguages {
+		t.Run(string(lang), func(t *testing.T) {
+			mnemonic, err := ethwallet.EntropyToMnemonic(entropy, lang)
+			require.NoError(t, err)
+
+			// the mnemonic of the zero entropy, of the checksum of 3
+			wordlist := lang.Wordlist()
+			words := strings.Fields(mnemonic)
+			require.Len(t, words, 12)
+			assert.Equal(t, wordlist[0], words[0])
+			assert.Equal(t, wordlist[3], words[11])
+
+			detected, err := ethwallet.DetectMnemonicLanguage(mnemonic)
+			require.NoError(t, err)
+			if lang != ethwallet.MnemonicLanguageChineseTraditional {
+				assert.Equal(t, lang, detected)
+			}
+			assert.True(t, ethwallet.IsValidMnemonic(mnemonic))
+
+			decoded, err := ethwallet.MnemonicToEntropy(mnemonic, lang)
+			require.NoError(t, err)
+			assert.Equal(t, entropy, decoded)
+
+			hdnode, err := ethwallet.NewHDNodeFromMnemonic(mnemonic, nil)
+			require.NoError(t, err)
+			assert.Equal(t, mnemonic, hdnode.Mnemonic())
+		})
+	}
+
+	t.Run("japanese separator", func(t *testing.T) {
+		mnemonic, err := ethwallet.EntropyToMnemonic(entropy, ethwallet.MnemonicLanguageJapanese)
+		require.NoError(t, err)
+		assert.Contains(t, mnemonic, "\u3000")
+
+		hdnode, err := ethwallet.NewHDNodeFromMnemonic(mnemonic, nil)
+		require.NoError(t, err)
+		hdnode2, err := ethwallet.NewHDNodeFromMnemonic(strings.ReplaceAll(mnemonic, "\u3000", " "), nil)
+		require.NoError(t, err)
+		assert.Equal(t, hdnode.Address(), hdnode2.Address())
+		assert.Equal(t, mnemonic, hdnode2.Mnemonic())
+	})
+
+	t.Run("accents", func(t *testing.T) {
+		mnemonic, err := ethwallet.EntropyToMnemonic(entropy, ethwallet.MnemonicLanguageSpanish)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(norm.NFC.String(mnemonic), "ábaco"))
+
+		// the accents typed composed or decomposed
+		for _, form := range []norm.Form{norm.NFC, norm.NFD} {
+			hdnode, err := ethwallet.NewHDNodeFromMnemonic(form.String(mnemonic), nil)
+			require.NoError(t, err)
+			assert.Equal(t, mnemonic, hdnode.Mnemonic())
+		}
+	})
+
+	t.Run("random", func(t *testing.T) {
+		wallet, err := ethwallet.NewWalletFromRandomEntropy(ethwallet.WalletOptions{
+			DerivationPath:             "m/44'/60'/0'/0/0",
+			RandomWalletEntropyBitSize: ethwallet.EntropyBitSize24WordMnemonic,
+			MnemonicLanguage:           ethwallet.MnemonicLanguageKorean,
+		})
+		require.NoError(t, err)
+		lang, err := ethwallet.DetectMnemonicLanguage(wallet.HDNode().Mnemonic())
+		require.NoError(t, err)
+		assert.Equal(t, ethwallet.MnemonicLanguageKorean, lang)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ethwallet.DetectMnemonicLanguage("ábaco ábaco ábaco ábaco ábaco ábaco ábaco ábaco ábaco ábaco ábaco ábaco")
+		assert.ErrorIs(t, err, ethwallet.ErrMnemonicChecksum)
+
+		_, err = ethwallet.DetectMnemonicLanguage("ábaco ábaco ábaco ábaco ábaco ábaco ábaco ábaco ábaco ábaco ábaco abandon")
+		assert.ErrorIs(t, err, ethwallet.ErrInvalidMnemonic)
+
+		_, err = ethwallet.EntropyToMnemonic(make([]byte, 15))
+		assert.ErrorIs(t, err, ethwallet.ErrInvalidEntropy)
+	})
+}
+
+func TestHDNodeMnemonicPassphrase(t *testing.T) {
+	// the test vectors of BIP-39, and of the japanese wordlist
+	vectors := []struct {
+		mnemonic   string
+		passphrase string
+		seed       string
+	}{
+		{
+			mnemonic:   "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			passphrase: "TREZOR",
+			seed:       "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		},
+		{
+			mnemonic:   "あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あいこくしん　あおぞら",
+			passphrase: "㍍ガバヴァぱばぐゞちぢ十人十色",
+			seed:       "a262d6fb6122ecf45be09c50492b31f92e9beb7d9a845987a02cefda57a15f9c467a17872029a9e92299b5cbdf306e3a0ee620245cbd508959b6cb7ca637bd55",
+		},
+	}
+	for _, v := range vectors {
+		seed, err := ethwallet.NewSeedFromMnemonic(v.mnemonic, v.passphrase)
+		require.NoError(t, err)
+		assert.Equal(t, v.seed, hex.EncodeToString(seed))
+	}
+
+	testMnemonic := "outdoor sentence roast truly flower surface power begin ocean silent debate funny"
+
+	hdnode, err := ethwallet.NewHDNodeFromMnemonicWithPassphrase(testMnemonic, "", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "0xe0C9828dee3411A28CcB4bb82a18d0aAd24489E0", hdnode.Address().Hex())
+
+	// the hidden wallet of the passphrase
+	hidden, err := ethwallet.NewHDNodeFromMnemonicWithPassphrase(testMnemonic, "hidden", nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, hdnode.Address(), hidden.Address())
+
+	clone, err := hidden.Clone()
+	require.NoError(t, err)
+	assert.Equal(t, hidden.Address(), clone.Address())
+
+	wallet, err := ethwallet.NewWalletFromMnemonicWithPassphrase(testMnemonic, "hidden")
+	require.NoError(t, err)
+	assert.Equal(t, hidden.Address(), wallet.Address())
+
+	derived, _, err := wallet.DeriveAccountIndex(1)
+	require.NoError(t, err)
+	require.NoError(t, hidden.DeriveAccountIndex(1))
+	assert.Equal(t, hidden.Address(), derived.Address())
+}
@@ -0,0 +1,147 @@
+package ethwallet
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/keystore"
+	"github.com/0xsequence/ethkit/go-ethereum/common/math"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// ErrKeystorePassword is returned when a keystore is not of the password.
+var ErrKeystorePassword = keystore.ErrDecrypt
+
+// The key derivation functions of the keystores.
+const (
+	KeystoreKDFScrypt = "scrypt"
+	KeystoreKDFPBKDF2 = "pbkdf2"
+)
+
+var DefaultKeystoreOptions = KeystoreOptions{
+	KDF:              KeystoreKDFScrypt,
+	ScryptN:          keystore.StandardScryptN,
+	ScryptP:          keystore.StandardScryptP,
+	PBKDF2Iterations: 262144,
+}
+
+// KeystoreOptions are the key derivation parameters of an encrypted keystore, of
+// which the zero values are the ones of DefaultKeystoreOptions.
+type KeystoreOptions struct {
+	// KDF is the key derivation function, ie. KeystoreKDFScrypt or
+	// KeystoreKDFPBKDF2.
+	KDF string
+
+	// ScryptN and ScryptP are the parameters of scrypt, ie. the ones of geth of
+	// keystore.LightScryptN and keystore.LightScryptP for a lighter keystore.
+	ScryptN int
+	ScryptP int
+
+	// PBKDF2Iterations is the number of iterations of pbkdf2 of hmac-sha256.
+	PBKDF2Iterations int
+}
+
+// NewWalletFromKeystore returns the wallet of the encrypted keystore json, of
+// the V3 format of geth and clef, or of V1.
+func NewWalletFromKeystore(keystoreJSON []byte, password string) (*Wallet, error) {
+	key, err := keystore.DecryptKey(keystoreJSON, password)
+	if err != nil {
+		return nil, fmt.Errorf("ethwallet: failed to decrypt keystore: %w", err)
+	}
+	return NewWalletFromPrivateKey(hex.EncodeToString(crypto.FromECDSA(key.PrivateKey)))
+}
+
+// EncryptKeystore returns the private key of the wallet encrypted with the
+// password, as the keystore json of the V3 format of geth and clef.
+func (w *Wallet) EncryptKeystore(password string, options ...KeystoreOptions) ([]byte, error) {
+	opts := DefaultKeystoreOptions
+	if len(options) > 0 {
+		opts = options[0]
+		if opts.KDF == "" {
+			opts.KDF = DefaultKeystoreOptions.KDF
+		}
+		if opts.ScryptN == 0 {
+			opts.ScryptN = DefaultKeystoreOptions.ScryptN
+		}
+		if opts.ScryptP == 0 {
+			opts.ScryptP = DefaultKeystoreOptions.ScryptP
+		}
+		if opts.PBKDF2Iterations == 0 {
+			opts.PBKDF2Iterations = DefaultKeystoreOptions.PBKDF2Iterations
+		}
+	}
+
+	keyBytes := math.PaddedBigBytes(w.hdnode.PrivateKey().D, 32)
+
+	var cryptoJSON keystore.CryptoJSON
+	var err error
+	switch opts.KDF {
+	case KeystoreKDFScrypt:
+		cryptoJSON, err = keystore.EncryptDataV3(keyBytes, []byte(password), opts.ScryptN, opts.ScryptP)
+	case KeystoreKDFPBKDF2:
+		cryptoJSON, err = encryptKeystorePBKDF2(keyBytes, []byte(password), opts.PBKDF2Iterations)
+	default:
+		return nil, fmt.Errorf("ethwallet: unsupported keystore kdf %q", opts.KDF)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ethwallet: failed to encrypt keystore: %w", err)
+	}
+
+	address := w.Address()
+	return json.Marshal(keystoreJSON{
+		Address: hex.EncodeToString(address[:]),
+		Crypto:  cryptoJSON,
+		ID:      uuid.New().String(),
+		Version: 3,
+	})
+}
+
+type keystoreJSON struct {
+	Address string              `json:"address"`
+	Crypto  keystore.CryptoJSON `json:"crypto"`
+	ID      string              `json:"id"`
+	Version int                 `json:"version"`
+}
+
+// encryptKeystorePBKDF2 is keystore.EncryptDataV3 of a pbkdf2 key, which geth
+// decrypts but doesn't encrypt.
+func encryptKeystorePBKDF2(data, password []byte, iterations int) (keystore.CryptoJSON, error) {
+	salt := make([]byte, 32)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(salt); err != nil {
+		return keystore.CryptoJSON{}, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return keystore.CryptoJSON{}, err
+	}
+	derivedKey := pbkdf2.Key(password, salt, iterations, 32, sha256.New)
+
+	block, err := aes.NewCipher(derivedKey[:16])
+	if err != nil {
+		return keystore.CryptoJSON{}, err
+	}
+	cipherText := make([]byte, len(data))
+	cipher.NewCTR(block, iv).XORKeyStream(cipherText, data)
+
+	cryptoJSON := keystore.CryptoJSON{
+		Cipher:     "aes-128-ctr",
+		CipherText: hex.EncodeToString(cipherText),
+		KDF:        KeystoreKDFPBKDF2,
+		KDFParams: map[string]interface{}{
+			"c":     iterations,
+			"dklen": 32,
+			"prf":   "hmac-sha256",
+			"salt":  hex.EncodeToString(salt),
+		},
+		MAC: hex.EncodeToString(crypto.Keccak256(derivedKey[16:32], cipherText)),
+	}
+	cryptoJSON.CipherParams.IV = hex.EncodeToString(iv)
+	return cryptoJSON, nil
+}
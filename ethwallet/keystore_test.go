@@ -0,0 +1,71 @@
+package ethwallet_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts/keystore"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalletKeystore(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("dose weasel clever culture letter volume endorse used harvest ripple circle install")
+	require.NoError(t, err)
+
+	for _, opts := range []ethwallet.KeystoreOptions{
+		{KDF: ethwallet.KeystoreKDFScrypt, ScryptN: keystore.LightScryptN, ScryptP: keystore.LightScryptP},
+		{KDF: ethwallet.KeystoreKDFPBKDF2, PBKDF2Iterations: 1024},
+	} {
+		t.Run(opts.KDF, func(t *testing.T) {
+			data, err := wallet.EncryptKeystore("testpassword", opts)
+			require.NoError(t, err)
+
+			var keystoreJSON map[string]interface{}
+			require.NoError(t, json.Unmarshal(data, &keystoreJSON))
+			assert.Equal(t, float64(3), keystoreJSON["version"])
+			assert.Equal(t, "b59ba5a13f0fb106ea6094a1f69786aa69be1424", keystoreJSON["address"])
+			assert.Equal(t, opts.KDF, keystoreJSON["crypto"].(map[string]interface{})["kdf"])
+
+			imported, err := ethwallet.NewWalletFromKeystore(data, "testpassword")
+			require.NoError(t, err)
+			assert.Equal(t, wallet.Address(), imported.Address())
+
+			// the keystore of geth
+			key, err := keystore.DecryptKey(data, "testpassword")
+			require.NoError(t, err)
+			assert.Equal(t, wallet.Address(), key.Address)
+
+			_, err = ethwallet.NewWalletFromKeystore(data, "wrongpassword")
+			assert.ErrorIs(t, err, ethwallet.ErrKeystorePassword)
+		})
+	}
+
+	t.Run("test vector", func(t *testing.T) {
+		data := `{
+			"crypto": {
+				"cipher": "aes-128-ctr",
+				"cipherparams": {"iv": "6087dab2f9fdbbfaddc31a909735c1e6"},
+				"ciphertext": "5318b4d5bcd28de64ee5559e671353e16f075ecae9f99c7a79a38af5f869aa46",
+				"kdf": "pbkdf2",
+				"kdfparams": {"c": 262144, "dklen": 32, "prf": "hmac-sha256", "salt": "ae3cd4e7013836a3df6bd7241b12db061dbe2c6785853cce422d148a624ce0bd"},
+				"mac": "517ead924a9d0dc3124507e3393d175ce3ff7c1e96529c6c555ce9e51205e9b2"
+			},
+			"id": "3198bc9c-6672-5ab3-d995-4942343ae5b6",
+			"version": 3
+		}`
+		imported, err := ethwallet.NewWalletFromKeystore([]byte(data), "testpassword")
+		require.NoError(t, err)
+
+		key, err := crypto.HexToECDSA("7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d")
+		require.NoError(t, err)
+		assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), imported.Address())
+	})
+
+	t.Run("unsupported kdf", func(t *testing.T) {
+		_, err := wallet.EncryptKeystore("testpassword", ethwallet.KeystoreOptions{KDF: "argon2"})
+		assert.Error(t, err)
+	})
+}
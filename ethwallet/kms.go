@@ -0,0 +1,94 @@
+package ethwallet
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+)
+
+// AWSKMSClient is the client of the GetPublicKey and Sign of AWS KMS, ie. an
+// adapter of the kms.Client of aws-sdk-go-v2, of a key of the ECC_SECG_P256K1
+// spec:
+//
+//	out, err := client.Sign(ctx, &kms.SignInput{
+//		KeyId:            &keyID,
+//		Message:          digest,
+//		MessageType:      types.MessageTypeDigest,
+//		SigningAlgorithm: types.SigningAlgorithmSpecEcdsaSha256,
+//	})
+//	return out.Signature, err
+type AWSKMSClient interface {
+	// GetPublicKey returns the DER encoded SubjectPublicKeyInfo of the key.
+	GetPublicKey(ctx context.Context, keyID string) ([]byte, error)
+
+	// Sign returns the DER encoded ecdsa signature of the digest.
+	Sign(ctx context.Context, keyID string, digest []byte) ([]byte, error)
+}
+
+// GCPKMSClient is the client of the GetPublicKey and AsymmetricSign of Google
+// Cloud KMS, ie. an adapter of the KeyManagementClient of cloud.google.com/go/kms,
+// of a key version of the EC_SIGN_SECP256K1_SHA256 algorithm, of which the
+// digest is the sha256 digest of the request.
+type GCPKMSClient interface {
+	// GetPublicKey returns the PEM encoded public key of the key version.
+	GetPublicKey(ctx context.Context, keyVersionName string) ([]byte, error)
+
+	// AsymmetricSign returns the DER encoded ecdsa signature of the digest.
+	AsymmetricSign(ctx context.Context, keyVersionName string, digest []byte) ([]byte, error)
+}
+
+// KMSSigner is the DigestSigner of a secp256k1 key of AWS KMS or Google Cloud
+// KMS, see NewRemoteSigner for its Signer.
+type KMSSigner struct {
+	keyID   string
+	address common.Address
+	sign    func(ctx context.Context, keyID string, digest []byte) ([]byte, error)
+}
+
+var _ DigestSigner = &KMSSigner{}
+
+// NewAWSKMSSigner returns the signer of the key of AWS KMS, of its id or arn.
+func NewAWSKMSSigner(ctx context.Context, client AWSKMSClient, keyID string) (*KMSSigner, error) {
+	publicKey, err := client.GetPublicKey(ctx, keyID)
+	if err != nil {
+		return nil, fmt.Errorf("ethwallet: failed to get aws kms public key: %w", err)
+	}
+	return newKMSSigner(keyID, publicKey, client.Sign)
+}
+
+// NewGCPKMSSigner returns the signer of the key version of Google Cloud KMS, ie.
+// "projects/p/locations/l/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1".
+func NewGCPKMSSigner(ctx context.Context, client GCPKMSClient, keyVersionName string) (*KMSSigner, error) {
+	publicKey, err := client.GetPublicKey(ctx, keyVersionName)
+	if err != nil {
+		return nil, fmt.Errorf("ethwallet: failed to get gcp kms public key: %w", err)
+	}
+	return newKMSSigner(keyVersionName, publicKey, client.AsymmetricSign)
+}
+
+func newKMSSigner(keyID string, publicKey []byte, sign func(ctx context.Context, keyID string, digest []byte) ([]byte, error)) (*KMSSigner, error) {
+	address, err := parseKMSPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &KMSSigner{keyID: keyID, address: address, sign: sign}, nil
+}
+
+func (s *KMSSigner) Address() common.Address {
+	return s.address
+}
+
+// KeyID returns the id of the key of AWS KMS, or the name of the key version of
+// Google Cloud KMS.
+func (s *KMSSigner) KeyID() string {
+	return s.keyID
+}
+
+func (s *KMSSigner) SignDigest(ctx context.Context, digest common.Hash) ([]byte, error) {
+	der, err := s.sign(ctx, s.keyID, digest[:])
+	if err != nil {
+		return nil, fmt.Errorf("ethwallet: failed to sign with kms: %w", err)
+	}
+	return recoverableSignature(der, digest, s.address)
+}
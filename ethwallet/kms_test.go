@@ -0,0 +1,122 @@
+package ethwallet_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/asn1"
+	"encoding/pem"
+	"math/big"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockKMS is a kms of a secp256k1 key, of which every other signature is of a
+// high s, as kms signatures aren't of EIP-2.
+type mockKMS struct {
+	key   *ecdsa.PrivateKey
+	calls int
+}
+
+func (m *mockKMS) publicKey() []byte {
+	spki := struct {
+		Algorithm struct {
+			Algorithm  asn1.ObjectIdentifier
+			Parameters asn1.ObjectIdentifier
+		}
+		PublicKey asn1.BitString
+	}{}
+	spki.Algorithm.Algorithm = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	spki.Algorithm.Parameters = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+	pub := crypto.FromECDSAPub(&m.key.PublicKey)
+	spki.PublicKey = asn1.BitString{Bytes: pub, BitLength: len(pub) * 8}
+	der, err := asn1.Marshal(spki)
+	if err != nil {
+		panic(err)
+	}
+	return der
+}
+
+func (m *mockKMS) sign(digest []byte) ([]byte, error) {
+	sig, err := crypto.Sign(digest, m.key)
+	if err != nil {
+		return nil, err
+	}
+	r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64])
+	if m.calls++; m.calls%2 == 0 {
+		s.Sub(crypto.S256().Params().N, s)
+	}
+	return asn1.Marshal(struct{ R, S *big.Int }{r, s})
+}
+
+type mockAWSKMS struct{ *mockKMS }
+
+func (m mockAWSKMS) GetPublicKey(ctx context.Context, keyID string) ([]byte, error) {
+	return m.publicKey(), nil
+}
+
+func (m mockAWSKMS) Sign(ctx context.Context, keyID string, digest []byte) ([]byte, error) {
+	return m.sign(digest)
+}
+
+type mockGCPKMS struct{ *mockKMS }
+
+func (m mockGCPKMS) GetPublicKey(ctx context.Context, keyVersionName string) ([]byte, error) {
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: m.publicKey()}), nil
+}
+
+func (m mockGCPKMS) AsymmetricSign(ctx context.Context, keyVersionName string, digest []byte) ([]byte, error) {
+	return m.sign(digest)
+}
+
+func TestKMSSigner(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	kms := &mockKMS{key: key}
+
+	awsSigner, err := ethwallet.NewAWSKMSSigner(context.Background(), mockAWSKMS{kms}, "alias/eth")
+	require.NoError(t, err)
+	gcpSigner, err := ethwallet.NewGCPKMSSigner(context.Background(), mockGCPKMS{kms}, "projects/p/locations/l/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1")
+	require.NoError(t, err)
+
+	for _, kmsSigner := range []*ethwallet.KMSSigner{awsSigner, gcpSigner} {
+		assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), kmsSigner.Address())
+		signer := ethwallet.NewRemoteSigner(kmsSigner)
+
+		// the signatures of both the low and the high s of the kms
+		for i := 0; i < 2; i++ {
+			sig, err := signer.SignMessage([]byte("hi"))
+			require.NoError(t, err)
+			assert.False(t, new(big.Int).SetBytes(sig[32:64]).Cmp(new(big.Int).Rsh(crypto.S256().Params().N, 1)) > 0)
+			valid, err := ethcoder.ValidateSignature(signer.Address(), ethcoder.EIP191PersonalMessageDigest([]byte("hi")).Bytes(), sig)
+			require.NoError(t, err)
+			assert.True(t, valid)
+		}
+
+		chainID := big.NewInt(1)
+		to := common.HexToAddress("0x8ba1f109551bD432803012645Ac136ddd64DBA72")
+		tx := types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: 1, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 21000, To: &to})
+		signedTx, err := signer.SignTx(tx, chainID)
+		require.NoError(t, err)
+		sender, err := types.Sender(types.LatestSignerForChainID(chainID), signedTx)
+		require.NoError(t, err)
+		assert.Equal(t, signer.Address(), sender)
+	}
+
+	t.Run("invalid public key", func(t *testing.T) {
+		_, err := ethwallet.NewGCPKMSSigner(context.Background(), invalidGCPKMS{mockGCPKMS{kms}}, "key")
+		assert.ErrorContains(t, err, "invalid kms public key")
+	})
+}
+
+type invalidGCPKMS struct{ mockGCPKMS }
+
+func (m invalidGCPKMS) GetPublicKey(ctx context.Context, keyVersionName string) ([]byte, error) {
+	return []byte("-----BEGIN PUBLIC KEY-----\nAAAA\n-----END PUBLIC KEY-----\n"), nil
+}
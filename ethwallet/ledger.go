@@ -0,0 +1,267 @@
+package ethwallet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+// ErrLedgerDenied is returned when the user rejects a request on the ledger.
+var ErrLedgerDenied = errors.New("ethwallet: ledger request denied by the user")
+
+// The instructions of the ethereum app of the ledger.
+const (
+	ledgerInsGetAddress      = 0x02
+	ledgerInsSignTx          = 0x04
+	ledgerInsGetAppConfig    = 0x06
+	ledgerInsSignMessage     = 0x08
+	ledgerInsSignEIP712Hash  = 0x0c
+	ledgerP1FirstChunk       = 0x00
+	ledgerP1MoreChunks       = 0x80
+	ledgerMaxChunkSize       = 255
+	ledgerStatusOK           = 0x9000
+	ledgerStatusUserRejected = 0x6985
+)
+
+// LedgerTransport exchanges the APDUs of the ethereum app of a ledger, ie. over
+// usb hid, see NewLedgerHIDTransport. The response is of the status word of the
+// APDU, its last 2 bytes.
+type LedgerTransport interface {
+	Exchange(apdu []byte) ([]byte, error)
+}
+
+// Ledger is a wallet of the ethereum app of a ledger device, of which the keys
+// never leave the device. The transactions, personal messages and typed data
+// are signed on the device, once the user confirms them.
+type Ledger struct {
+	transport LedgerTransport
+	path      accounts.DerivationPath
+	address   common.Address
+	mu        sync.Mutex
+}
+
+var _ WalletSigner = &Ledger{}
+
+// NewLedger returns the wallet of the account of the path of the ledger, or of
+// the default path "m/44'/60'/0'/0/0".
+func NewLedger(transport LedgerTransport, optPath ...accounts.DerivationPath) (*Ledger, error) {
+	l := &Ledger{transport: transport, path: DefaultBaseDerivationPath}
+	if len(optPath) > 0 {
+		l.path = optPath[0]
+	}
+
+	resp, err := l.exchange(ledgerInsGetAddress, 0x00, 0x00, l.encodePath())
+	if err != nil {
+		return nil, fmt.Errorf("ethwallet: failed to get ledger address: %w", err)
+	}
+	// pubkey length, pubkey, address length, hex address
+	if len(resp) < 1 || len(resp) < 1+int(resp[0])+1 {
+		return nil, fmt.Errorf("ethwallet: invalid ledger address response")
+	}
+	resp = resp[1+int(resp[0]):]
+	if len(resp) < 1+int(resp[0]) || !common.IsHexAddress(string(resp[1:1+int(resp[0])])) {
+		return nil, fmt.Errorf("ethwallet: invalid ledger address response")
+	}
+	l.address = common.HexToAddress(string(resp[1 : 1+int(resp[0])]))
+	return l, nil
+}
+
+func (l *Ledger) Address() common.Address {
+	return l.address
+}
+
+func (l *Ledger) DerivationPath() accounts.DerivationPath {
+	return l.path
+}
+
+// Version returns the version of the ethereum app of the ledger, ie. "1.10.3".
+func (l *Ledger) Version() (string, error) {
+	l.mu.Lock()
+	resp, err := l.exchange(ledgerInsGetAppConfig, 0x00, 0x00, nil)
+	l.mu.Unlock()
+	if err != nil {
+		return "", fmt.Errorf("ethwallet: failed to get ledger app configuration: %w", err)
+	}
+	if len(resp) < 4 {
+		return "", fmt.Errorf("ethwallet: invalid ledger app configuration response")
+	}
+	return fmt.Sprintf("%d.%d.%d", resp[1], resp[2], resp[3]), nil
+}
+
+// SignTx signs the transaction for the chain on the ledger.
+func (l *Ledger) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	payload, err := ethtxn.EncodeUnsignedPayload(tx, chainID)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := l.sign(ledgerInsSignTx, append(l.encodePath(), payload...))
+	if err != nil {
+		return nil, fmt.Errorf("ethwallet: failed to sign transaction on ledger: %w", err)
+	}
+
+	// the v of legacy transactions is of eip-155 and truncated to a byte, so the
+	// recovery id is the one of the address of the ledger
+	for _, v := range []byte{0, 1} {
+		sig[64] = v
+		signedTx, err := ethtxn.CombineSignature(tx, chainID, sig)
+		if err != nil {
+			return nil, err
+		}
+		sender, err := types.Sender(types.LatestSignerForChainID(chainID), signedTx)
+		if err == nil && sender == l.address {
+			return signedTx, nil
+		}
+	}
+	return nil, fmt.Errorf("ethwallet: ledger signature is not of %s", l.address.Hex())
+}
+
+// SignMessage signs the personal message on the ledger, of which the
+// "\x19Ethereum Signed Message:\n" prefix is added by the ledger. The prefix
+// of an already prefixed message is trimmed, so it isn't signed twice.
+func (l *Ledger) SignMessage(message []byte) ([]byte, error) {
+	message = trimPersonalMessagePrefix(message)
+	data := l.encodePath()
+	data = binary.BigEndian.AppendUint32(data, uint32(len(message)))
+	sig, err := l.sign(ledgerInsSignMessage, append(data, message...))
+	if err != nil {
+		return nil, fmt.Errorf("ethwallet: failed to sign message on ledger: %w", err)
+	}
+	return sig, nil
+}
+
+// SignTypedData signs the EIP-712 typed data on the ledger, of the hashes of its
+// domain and message, which the ledger displays.
+func (l *Ledger) SignTypedData(typedData *ethcoder.TypedData) ([]byte, error) {
+	domainHash, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		return nil, err
+	}
+	messageHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return nil, err
+	}
+	data := append(append(l.encodePath(), domainHash...), messageHash...)
+	sig, err := l.sign(ledgerInsSignEIP712Hash, data)
+	if err != nil {
+		return nil, fmt.Errorf("ethwallet: failed to sign typed data on ledger: %w", err)
+	}
+	return sig, nil
+}
+
+// sign sends the data of the instruction in chunks, and returns the signature of
+// the response [V || R || S] as [R || S || V], with V of 27/28.
+func (l *Ledger) sign(ins byte, data []byte) ([]byte, error) {
+	// the chunks of a request must not interleave with the ones of another
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var resp []byte
+	for p1 := byte(ledgerP1FirstChunk); len(data) > 0; p1 = ledgerP1MoreChunks {
+		chunk := data[:min(len(data), ledgerMaxChunkSize)]
+		data = data[len(chunk):]
+
+		var err error
+		if resp, err = l.exchange(ins, p1, 0x00, chunk); err != nil {
+			return nil, err
+		}
+	}
+	if len(resp) != 65 {
+		return nil, fmt.Errorf("invalid ledger signature of %d bytes", len(resp))
+	}
+	sig := append(append([]byte{}, resp[1:]...), resp[0])
+	if sig[64] < 27 {
+		sig[64] += 27
+	}
+	return sig, nil
+}
+
+// exchange sends the apdu of the instruction, of which the caller holds mu.
+func (l *Ledger) exchange(ins, p1, p2 byte, data []byte) ([]byte, error) {
+	apdu := append([]byte{0xe0, ins, p1, p2, byte(len(data))}, data...)
+	resp, err := l.transport.Exchange(apdu)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) < 2 {
+		return nil, fmt.Errorf("invalid ledger response of %d bytes", len(resp))
+	}
+	status := binary.BigEndian.Uint16(resp[len(resp)-2:])
+	switch status {
+	case ledgerStatusOK:
+		return resp[:len(resp)-2], nil
+	case ledgerStatusUserRejected:
+		return nil, ErrLedgerDenied
+	}
+	return nil, fmt.Errorf("ledger status 0x%04x", status)
+}
+
+func (l *Ledger) encodePath() []byte {
+	data := []byte{byte(len(l.path))}
+	for _, component := range l.path {
+		data = binary.BigEndian.AppendUint32(data, component)
+	}
+	return data
+}
+
+// The framing of the APDUs of the usb hid reports of a ledger.
+const (
+	ledgerHIDChannel    = 0x0101
+	ledgerHIDTag        = 0x05
+	ledgerHIDReportSize = 64
+)
+
+// NewLedgerHIDTransport returns the transport of the usb hid device of a ledger,
+// ie. of a hid library, which reads and writes its reports of 64 bytes.
+func NewLedgerHIDTransport(device io.ReadWriter) LedgerTransport {
+	return &ledgerHIDTransport{device: device}
+}
+
+type ledgerHIDTransport struct {
+	device io.ReadWriter
+}
+
+func (t *ledgerHIDTransport) Exchange(apdu []byte) ([]byte, error) {
+	// the apdu of its length, in the reports of their sequence
+	data := binary.BigEndian.AppendUint16(nil, uint16(len(apdu)))
+	data = append(data, apdu...)
+	for seq := uint16(0); len(data) > 0; seq++ {
+		report := make([]byte, ledgerHIDReportSize)
+		binary.BigEndian.PutUint16(report[0:], ledgerHIDChannel)
+		report[2] = ledgerHIDTag
+		binary.BigEndian.PutUint16(report[3:], seq)
+		n := copy(report[5:], data)
+		data = data[n:]
+		if _, err := t.device.Write(report); err != nil {
+			return nil, fmt.Errorf("ethwallet: failed to write to ledger: %w", err)
+		}
+	}
+
+	var resp []byte
+	size := -1
+	for seq := uint16(0); size < 0 || len(resp) < size; seq++ {
+		report := make([]byte, ledgerHIDReportSize)
+		if _, err := io.ReadFull(t.device, report); err != nil {
+			return nil, fmt.Errorf("ethwallet: failed to read from ledger: %w", err)
+		}
+		if binary.BigEndian.Uint16(report[0:]) != ledgerHIDChannel || report[2] != ledgerHIDTag || binary.BigEndian.Uint16(report[3:]) != seq {
+			return nil, fmt.Errorf("ethwallet: invalid ledger report %s", strings.TrimPrefix(ethcoder.HexEncode(report[:5]), "0x"))
+		}
+		payload := report[5:]
+		if seq == 0 {
+			size = int(binary.BigEndian.Uint16(payload))
+			payload = payload[2:]
+		}
+		resp = append(resp, payload[:min(len(payload), size-len(resp))]...)
+	}
+	return resp, nil
+}
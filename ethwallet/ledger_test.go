@@ -0,0 +1,227 @@
+package ethwallet_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/0xsequence/ethkit/go-ethereum/rlp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockLedger is the usb hid device of the ethereum app of a ledger, which signs
+// with its key.
+type mockLedger struct {
+	key    *ecdsa.PrivateKey
+	chunks int
+	reject bool
+	delay  time.Duration
+
+	request  []byte
+	reports  [][]byte
+	ins      byte
+	instData []byte
+}
+
+func (m *mockLedger) Write(report []byte) (int, error) {
+	if binary.BigEndian.Uint16(report[3:]) == 0 {
+		m.request = append([]byte{}, report[5:]...)
+	} else {
+		m.request = append(m.request, report[5:]...)
+	}
+	size := int(binary.BigEndian.Uint16(m.request))
+	if len(m.request)-2 < size {
+		return len(report), nil
+	}
+	resp := m.handle(m.request[2 : 2+size])
+
+	data := binary.BigEndian.AppendUint16(nil, uint16(len(resp)))
+	data = append(data, resp...)
+	for seq := uint16(0); len(data) > 0; seq++ {
+		out := make([]byte, 64)
+		copy(out, []byte{0x01, 0x01, 0x05})
+		binary.BigEndian.PutUint16(out[3:], seq)
+		data = data[copy(out[5:], data):]
+		m.reports = append(m.reports, out)
+	}
+	return len(report), nil
+}
+
+func (m *mockLedger) Read(p []byte) (int, error) {
+	time.Sleep(m.delay)
+	n := copy(p, m.reports[0])
+	m.reports = m.reports[1:]
+	return n, nil
+}
+
+func (m *mockLedger) handle(apdu []byte) []byte {
+	ins, p1, data := apdu[1], apdu[2], apdu[5:5+int(apdu[4])]
+	ok := []byte{0x90, 0x00}
+
+	switch ins {
+	case 0x02:
+		pubkey := crypto.FromECDSAPub(&m.key.PublicKey)
+		address := crypto.PubkeyToAddress(m.key.PublicKey).Hex()[2:]
+		resp := append([]byte{byte(len(pubkey))}, pubkey...)
+		resp = append(append(resp, byte(len(address))), address...)
+		return append(resp, ok...)
+	case 0x06:
+		return append([]byte{0x01, 1, 10, 3}, ok...)
+	}
+
+	m.chunks++
+	if p1 == 0x00 {
+		m.ins, m.instData = ins, append([]byte{}, data[1+4*int(data[0]):]...)
+	} else {
+		m.instData = append(m.instData, data...)
+	}
+
+	var digest []byte
+	switch m.ins {
+	case 0x04:
+		payload := m.instData
+		if payload[0] < 0x7f {
+			payload = payload[1:]
+		}
+		if _, rest, err := rlp.SplitList(payload); err != nil || len(rest) != 0 {
+			return ok
+		}
+		digest = crypto.Keccak256(m.instData)
+	case 0x08:
+		n := int(binary.BigEndian.Uint32(m.instData))
+		if len(m.instData)-4 < n {
+			return ok
+		}
+		digest = ethcoder.EIP191PersonalMessageDigest(m.instData[4:]).Bytes()
+	case 0x0c:
+		digest = ethcoder.EIP191StructuredDataDigest(common.BytesToHash(m.instData[:32]), common.BytesToHash(m.instData[32:])).Bytes()
+	}
+	if m.reject {
+		return []byte{0x69, 0x85}
+	}
+
+	sig, err := crypto.Sign(digest, m.key)
+	if err != nil {
+		panic(err)
+	}
+	// the v of eip-155 of a legacy transaction, truncated to a byte
+	v := sig[64]
+	if m.ins == 0x04 && m.instData[0] >= 0xc0 {
+		v += 1*2 + 35
+	} else if m.ins != 0x04 {
+		v += 27
+	}
+	return append(append([]byte{v}, sig[:64]...), ok...)
+}
+
+func TestLedger(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	device := &mockLedger{key: key}
+
+	ledger, err := ethwallet.NewLedger(ethwallet.NewLedgerHIDTransport(device))
+	require.NoError(t, err)
+	assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), ledger.Address())
+	assert.Equal(t, ethwallet.DefaultBaseDerivationPath, ledger.DerivationPath())
+
+	version, err := ledger.Version()
+	require.NoError(t, err)
+	assert.Equal(t, "1.10.3", version)
+
+	t.Run("transactions", func(t *testing.T) {
+		chainID := big.NewInt(1)
+		to := common.HexToAddress("0x8ba1f109551bD432803012645Ac136ddd64DBA72")
+		txs := []*types.Transaction{
+			types.NewTx(&types.LegacyTx{Nonce: 1, GasPrice: big.NewInt(1e9), Gas: 21000, To: &to, Value: big.NewInt(1)}),
+			types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: 2, GasTipCap: big.NewInt(1e9), GasFeeCap: big.NewInt(2e9), Gas: 100000, To: &to, Data: bytes.Repeat([]byte{0xab}, 600)}),
+		}
+		for _, tx := range txs {
+			signedTx, err := ledger.SignTx(tx, chainID)
+			require.NoError(t, err)
+			sender, err := types.Sender(types.LatestSignerForChainID(chainID), signedTx)
+			require.NoError(t, err)
+			assert.Equal(t, ledger.Address(), sender)
+		}
+	})
+
+	t.Run("message", func(t *testing.T) {
+		device.chunks = 0
+		message := bytes.Repeat([]byte("hello "), 100)
+		sig, err := ledger.SignMessage(message)
+		require.NoError(t, err)
+		assert.Equal(t, 3, device.chunks)
+
+		valid, err := ethcoder.ValidateSignature(ledger.Address(), ethcoder.EIP191PersonalMessageDigest(message).Bytes(), sig)
+		require.NoError(t, err)
+		assert.True(t, valid)
+
+		// the prefix of a prefixed message isn't sent to the ledger
+		payload := device.instData
+		prefixedSig, err := ledger.SignMessage(append([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(message))), message...))
+		require.NoError(t, err)
+		assert.Equal(t, payload, device.instData)
+		assert.Equal(t, sig, prefixedSig)
+	})
+
+	t.Run("typed data", func(t *testing.T) {
+		typedData := &ethcoder.TypedData{
+			Types: ethcoder.TypedDataTypes{
+				"EIP712Domain": {{Name: "name", Type: "string"}, {Name: "chainId", Type: "uint256"}},
+				"Person":       {{Name: "name", Type: "string"}, {Name: "wallet", Type: "address"}},
+			},
+			PrimaryType: "Person",
+			Domain:      ethcoder.TypedDataDomain{Name: "Ether Mail", ChainID: big.NewInt(1)},
+			Message:     map[string]interface{}{"name": "Bob", "wallet": common.HexToAddress("0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB")},
+		}
+		sig, err := ledger.SignTypedData(typedData)
+		require.NoError(t, err)
+
+		digest, err := typedData.EncodeDigest()
+		require.NoError(t, err)
+		valid, err := ethcoder.ValidateSignature(ledger.Address(), digest, sig)
+		require.NoError(t, err)
+		assert.True(t, valid)
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		// the chunks of the requests don't interleave, even of a slow device
+		device.delay = 2 * time.Millisecond
+		defer func() { device.delay = 0 }()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				message := bytes.Repeat([]byte{byte('a' + i)}, 600)
+				sig, err := ledger.SignMessage(message)
+				if !assert.NoError(t, err) {
+					return
+				}
+				valid, err := ethcoder.ValidateSignature(ledger.Address(), ethcoder.EIP191PersonalMessageDigest(message).Bytes(), sig)
+				assert.NoError(t, err)
+				assert.True(t, valid)
+			}(i)
+		}
+		wg.Wait()
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		device.reject = true
+		defer func() { device.reject = false }()
+
+		_, err := ledger.SignMessage([]byte("hi"))
+		assert.ErrorIs(t, err, ethwallet.ErrLedgerDenied)
+	})
+}
@@ -0,0 +1,182 @@
+package ethwallet
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/tyler-smith/go-bip39/wordlists"
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	ErrInvalidMnemonic  = errors.New("ethwallet: invalid mnemonic")
+	ErrMnemonicChecksum = errors.New("ethwallet: invalid mnemonic checksum")
+	ErrInvalidEntropy   = errors.New("ethwallet: entropy must be of 128 to 256 bits, of a multiple of 32")
+)
+
+// MnemonicLanguage is the language of the BIP-39 wordlist of a mnemonic.
+type MnemonicLanguage string
+
+const (
+	MnemonicLanguageEnglish            MnemonicLanguage = "english"
+	MnemonicLanguageSpanish            MnemonicLanguage = "spanish"
+	MnemonicLanguageFrench             MnemonicLanguage = "french"
+	MnemonicLanguageItalian            MnemonicLanguage = "italian"
+	MnemonicLanguageCzech              MnemonicLanguage = "czech"
+	MnemonicLanguageJapanese           MnemonicLanguage = "japanese"
+	MnemonicLanguageKorean             MnemonicLanguage = "korean"
+	MnemonicLanguageChineseSimplified  MnemonicLanguage = "chinese_simplified"
+	MnemonicLanguageChineseTraditional MnemonicLanguage = "chinese_traditional"
+)
+
+// MnemonicLanguages are the languages of the BIP-39 wordlists, in the order of
+// which the language of a mnemonic is detected, ie. a mnemonic of the words
+// of both the simplified and traditional chinese wordlists is simplified.
+var MnemonicLanguages = []MnemonicLanguage{
+	MnemonicLanguageEnglish,
+	MnemonicLanguageSpanish,
+	MnemonicLanguageFrench,
+	MnemonicLanguageItalian,
+	MnemonicLanguageCzech,
+	MnemonicLanguageJapanese,
+	MnemonicLanguageKorean,
+	MnemonicLanguageChineseSimplified,
+	MnemonicLanguageChineseTraditional,
+}
+
+var mnemonicWordlists = map[MnemonicLanguage][]string{
+	MnemonicLanguageEnglish:            wordlists.English,
+	MnemonicLanguageSpanish:            wordlists.Spanish,
+	MnemonicLanguageFrench:             wordlists.French,
+	MnemonicLanguageItalian:            wordlists.Italian,
+	MnemonicLanguageCzech:              wordlists.Czech,
+	MnemonicLanguageJapanese:           wordlists.Japanese,
+	MnemonicLanguageKorean:             wordlists.Korean,
+	MnemonicLanguageChineseSimplified:  wordlists.ChineseSimplified,
+	MnemonicLanguageChineseTraditional: wordlists.ChineseTraditional,
+}
+
+var (
+	mnemonicIndexesOnce sync.Once
+	mnemonicIndexes     map[MnemonicLanguage]map[string]int
+)
+
+// Wordlist returns the 2048 words of the language.
+func (l MnemonicLanguage) Wordlist() []string {
+	return mnemonicWordlists[l]
+}
+
+// separator returns the separator of the words of the mnemonics of the
+// language, which is the ideographic space of japanese.
+func (l MnemonicLanguage) separator() string {
+	if l == MnemonicLanguageJapanese {
+		return "　"
+	}
+	return " "
+}
+
+// wordIndex returns the index of the word of the wordlist of the language, of
+// the NFKD forms of the words, ie. of the accents of spanish typed either way.
+func (l MnemonicLanguage) wordIndex(word string) (int, bool) {
+	mnemonicIndexesOnce.Do(func() {
+		mnemonicIndexes = make(map[MnemonicLanguage]map[string]int, len(mnemonicWordlists))
+		for lang, words := range mnemonicWordlists {
+			index := make(map[string]int, len(words))
+			for i, w := range words {
+				index[norm.NFKD.String(w)] = i
+			}
+			mnemonicIndexes[lang] = index
+		}
+	})
+	i, ok := mnemonicIndexes[l][word]
+	return i, ok
+}
+
+// DetectMnemonicLanguage returns the language of the mnemonic, which is the
+// first of MnemonicLanguages of which the mnemonic is valid.
+func DetectMnemonicLanguage(mnemonic string) (MnemonicLanguage, error) {
+	_, lang, err := decodeMnemonic(mnemonic)
+	return lang, err
+}
+
+// decodeMnemonic returns the entropy and the language of the mnemonic, of the
+// languages or of any language.
+func decodeMnemonic(mnemonic string, languages ...MnemonicLanguage) ([]byte, MnemonicLanguage, error) {
+	if len(languages) == 0 {
+		languages = MnemonicLanguages
+	}
+	words := strings.Fields(norm.NFKD.String(mnemonic))
+
+	err := ErrInvalidMnemonic
+	for _, lang := range languages {
+		if _, ok := mnemonicWordlists[lang]; !ok {
+			return nil, "", fmt.Errorf("ethwallet: unknown mnemonic language %q", lang)
+		}
+		entropy, langErr := mnemonicWordsToEntropy(words, lang)
+		if langErr == nil {
+			return entropy, lang, nil
+		}
+		if errors.Is(langErr, ErrMnemonicChecksum) {
+			err = langErr
+		}
+	}
+	return nil, "", err
+}
+
+func mnemonicWordsToEntropy(words []string, lang MnemonicLanguage) ([]byte, error) {
+	n := len(words)
+	if n < 12 || n > 24 || n%3 != 0 {
+		return nil, ErrInvalidMnemonic
+	}
+
+	// the 11 bits of the indexes of the words, of the entropy and its checksum
+	bits := make([]byte, (n*11+7)/8)
+	for i, word := range words {
+		index, ok := lang.wordIndex(word)
+		if !ok {
+			return nil, ErrInvalidMnemonic
+		}
+		for b := 0; b < 11; b++ {
+			if index&(1<<(10-b)) != 0 {
+				pos := i*11 + b
+				bits[pos/8] |= 1 << (7 - pos%8)
+			}
+		}
+	}
+
+	entropy := bits[:n*4/3]
+	checksumBits := n / 3
+	checksum := bits[len(entropy)] >> (8 - checksumBits)
+	if sha256.Sum256(entropy)[0]>>(8-checksumBits) != checksum {
+		return nil, ErrMnemonicChecksum
+	}
+	return entropy, nil
+}
+
+func entropyToMnemonic(entropy []byte, lang MnemonicLanguage) (string, error) {
+	if len(entropy) < 16 || len(entropy) > 32 || len(entropy)%4 != 0 {
+		return "", ErrInvalidEntropy
+	}
+	wordlist, ok := mnemonicWordlists[lang]
+	if !ok {
+		return "", fmt.Errorf("ethwallet: unknown mnemonic language %q", lang)
+	}
+
+	checksum := sha256.Sum256(entropy)
+	bits := append(append([]byte{}, entropy...), checksum[0])
+	n := (len(entropy)*8 + len(entropy)/4) / 11
+
+	words := make([]string, n)
+	for i := range words {
+		index := 0
+		for b := 0; b < 11; b++ {
+			pos := i*11 + b
+			index = index<<1 | int(bits[pos/8]>>(7-pos%8)&1)
+		}
+		words[i] = wordlist[index]
+	}
+	return strings.Join(words, lang.separator()), nil
+}
@@ -0,0 +1,242 @@
+package ethwallet
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+// Signer signs transactions and messages for an address, ie. a *Wallet.
+type Signer interface {
+	Address() common.Address
+	SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+	SignMessage(message []byte) ([]byte, error)
+	SignData(data []byte) ([]byte, error)
+}
+
+var _ Signer = &Wallet{}
+var _ Signer = &PolicySigner{}
+
+var (
+	ErrPolicyExpired        = errors.New("ethwallet: policy expired")
+	ErrPolicyChainID        = errors.New("ethwallet: chain id not allowed by policy")
+	ErrPolicyDestination    = errors.New("ethwallet: destination not allowed by policy")
+	ErrPolicySelector       = errors.New("ethwallet: function selector not allowed by policy")
+	ErrPolicyValue          = errors.New("ethwallet: value exceeds the policy max value per transaction")
+	ErrPolicyPeriodValue    = errors.New("ethwallet: value exceeds the policy max value per period")
+	ErrPolicyMessageSigning = errors.New("ethwallet: message signing not allowed by policy")
+)
+
+// Policy is the constraints of the sign requests of a PolicySigner. The zero
+// value of a field places no constraint.
+type Policy struct {
+	// ChainIDs are the chains the transactions may be signed for.
+	ChainIDs []uint64
+
+	// Destinations are the addresses the transactions may be sent to. Contract
+	// creations are rejected when set.
+	Destinations []common.Address
+
+	// Selectors are the function selectors the transactions with data may call.
+	Selectors [][4]byte
+
+	// MaxValuePerTx is the max value of a transaction.
+	MaxValuePerTx *big.Int
+
+	// MaxValuePerPeriod is the max total value of the transactions signed in
+	// any Period.
+	MaxValuePerPeriod *big.Int
+	Period            time.Duration
+
+	// ExpiresAt is the time after which all the sign requests are rejected.
+	ExpiresAt time.Time
+
+	// AllowMessageSigning allows to sign messages and data, ie. typed data,
+	// which are otherwise rejected as their effects can't be constrained.
+	AllowMessageSigning bool
+}
+
+// PolicySigner wraps a signer, and rejects the sign requests out of its policy
+// with the ErrPolicy errors.
+type PolicySigner struct {
+	signer Signer
+	policy Policy
+	now    func() time.Time
+
+	// spent is the value of the transactions signed in the last period
+	spent []policySpend
+	mu    sync.Mutex
+}
+
+type policySpend struct {
+	at    time.Time
+	value *big.Int
+}
+
+func NewPolicySigner(signer Signer, policy Policy) (*PolicySigner, error) {
+	if signer == nil {
+		return nil, fmt.Errorf("ethwallet: policy signer is nil")
+	}
+	if policy.MaxValuePerPeriod != nil && policy.Period <= 0 {
+		return nil, fmt.Errorf("ethwallet: policy MaxValuePerPeriod needs a Period")
+	}
+	return &PolicySigner{signer: signer, policy: policy, now: time.Now}, nil
+}
+
+// NewSessionKey returns a signer of a new random key, restricted by policy,
+// ie. for short-lived keys of hot services granted limited permissions.
+func NewSessionKey(policy Policy) (*PolicySigner, error) {
+	wallet, err := NewWalletFromRandomEntropy()
+	if err != nil {
+		return nil, err
+	}
+	return NewPolicySigner(wallet, policy)
+}
+
+func (s *PolicySigner) Address() common.Address {
+	return s.signer.Address()
+}
+
+func (s *PolicySigner) Policy() Policy {
+	return s.policy
+}
+
+// Signer returns the wrapped signer.
+func (s *PolicySigner) Signer() Signer {
+	return s.signer
+}
+
+// CheckTx returns the policy error of a transaction, without signing it.
+func (s *PolicySigner) CheckTx(tx *types.Transaction, chainID *big.Int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.checkTx(tx, chainID, s.now())
+}
+
+func (s *PolicySigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if err := s.checkTx(tx, chainID, now); err != nil {
+		return nil, err
+	}
+	signedTx, err := s.signer.SignTx(tx, chainID)
+	if err != nil {
+		return nil, err
+	}
+	if s.policy.MaxValuePerPeriod != nil && tx.Value().Sign() > 0 {
+		s.spent = append(s.spent, policySpend{at: now, value: new(big.Int).Set(tx.Value())})
+	}
+	return signedTx, nil
+}
+
+func (s *PolicySigner) SignMessage(message []byte) ([]byte, error) {
+	if err := s.checkMessage(); err != nil {
+		return nil, err
+	}
+	return s.signer.SignMessage(message)
+}
+
+func (s *PolicySigner) SignData(data []byte) ([]byte, error) {
+	if err := s.checkMessage(); err != nil {
+		return nil, err
+	}
+	return s.signer.SignData(data)
+}
+
+func (s *PolicySigner) checkMessage() error {
+	if err := s.checkExpiry(s.now()); err != nil {
+		return err
+	}
+	if !s.policy.AllowMessageSigning {
+		return ErrPolicyMessageSigning
+	}
+	return nil
+}
+
+func (s *PolicySigner) checkExpiry(now time.Time) error {
+	if !s.policy.ExpiresAt.IsZero() && !now.Before(s.policy.ExpiresAt) {
+		return fmt.Errorf("%w at %s", ErrPolicyExpired, s.policy.ExpiresAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// checkTx must be called with the lock held.
+func (s *PolicySigner) checkTx(tx *types.Transaction, chainID *big.Int, now time.Time) error {
+	p := &s.policy
+	if err := s.checkExpiry(now); err != nil {
+		return err
+	}
+
+	if len(p.ChainIDs) > 0 {
+		allowed := false
+		for _, id := range p.ChainIDs {
+			if chainID != nil && chainID.IsUint64() && chainID.Uint64() == id {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("%w: %v", ErrPolicyChainID, chainID)
+		}
+	}
+
+	if len(p.Destinations) > 0 {
+		if tx.To() == nil {
+			return fmt.Errorf("%w: contract creation", ErrPolicyDestination)
+		}
+		allowed := false
+		for _, to := range p.Destinations {
+			if to == *tx.To() {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("%w: %s", ErrPolicyDestination, tx.To().Hex())
+		}
+	}
+
+	if len(p.Selectors) > 0 && len(tx.Data()) > 0 {
+		allowed := false
+		for _, selector := range p.Selectors {
+			if len(tx.Data()) >= 4 && [4]byte(tx.Data()[:4]) == selector {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("%w: 0x%x", ErrPolicySelector, tx.Data()[:min(4, len(tx.Data()))])
+		}
+	}
+
+	value := tx.Value()
+	if p.MaxValuePerTx != nil && value.Cmp(p.MaxValuePerTx) > 0 {
+		return fmt.Errorf("%w: %s > %s", ErrPolicyValue, value, p.MaxValuePerTx)
+	}
+
+	if p.MaxValuePerPeriod != nil {
+		// drop the spends older than the period
+		i := 0
+		for i < len(s.spent) && now.Sub(s.spent[i].at) >= p.Period {
+			i++
+		}
+		s.spent = s.spent[i:]
+
+		total := new(big.Int).Set(value)
+		for _, spend := range s.spent {
+			total.Add(total, spend.value)
+		}
+		if total.Cmp(p.MaxValuePerPeriod) > 0 {
+			return fmt.Errorf("%w: %s > %s in %s", ErrPolicyPeriodValue, total, p.MaxValuePerPeriod, p.Period)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package ethwallet
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicySigner(t *testing.T) {
+	wallet, err := NewWalletFromMnemonic("dose weasel clever culture letter volume endorse used harvest ripple circle install")
+	require.NoError(t, err)
+
+	token := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	other := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	transfer := [4]byte{0xa9, 0x05, 0x9c, 0xbb}
+
+	now := time.Now()
+	signer, err := NewPolicySigner(wallet, Policy{
+		ChainIDs:          []uint64{1, 137},
+		Destinations:      []common.Address{token},
+		Selectors:         [][4]byte{transfer},
+		MaxValuePerTx:     big.NewInt(100),
+		MaxValuePerPeriod: big.NewInt(150),
+		Period:            time.Hour,
+		ExpiresAt:         now.Add(24 * time.Hour),
+	})
+	require.NoError(t, err)
+	signer.now = func() time.Time { return now }
+	assert.Equal(t, wallet.Address(), signer.Address())
+
+	tx := func(to *common.Address, value int64, data []byte) *types.Transaction {
+		return types.NewTx(&types.DynamicFeeTx{To: to, Value: big.NewInt(value), Data: data, Gas: 21000})
+	}
+
+	signed, err := signer.SignTx(tx(&token, 100, append(transfer[:], 0x01)), big.NewInt(1))
+	require.NoError(t, err)
+	sender, err := types.Sender(types.LatestSignerForChainID(big.NewInt(1)), signed)
+	require.NoError(t, err)
+	assert.Equal(t, wallet.Address(), sender)
+
+	_, err = signer.SignTx(tx(&token, 1, nil), big.NewInt(5))
+	assert.ErrorIs(t, err, ErrPolicyChainID)
+	_, err = signer.SignTx(tx(&other, 1, nil), big.NewInt(1))
+	assert.ErrorIs(t, err, ErrPolicyDestination)
+	_, err = signer.SignTx(tx(nil, 0, []byte{0x60, 0x80}), big.NewInt(1))
+	assert.ErrorIs(t, err, ErrPolicyDestination)
+	_, err = signer.SignTx(tx(&token, 0, []byte{0x09, 0x5e, 0xa7, 0xb3}), big.NewInt(1))
+	assert.ErrorIs(t, err, ErrPolicySelector)
+	_, err = signer.SignTx(tx(&token, 101, nil), big.NewInt(1))
+	assert.ErrorIs(t, err, ErrPolicyValue)
+
+	// 100 of 150 spent in the period
+	assert.NoError(t, signer.CheckTx(tx(&token, 50, nil), big.NewInt(137)))
+	_, err = signer.SignTx(tx(&token, 51, nil), big.NewInt(137))
+	assert.ErrorIs(t, err, ErrPolicyPeriodValue)
+	_, err = signer.SignTx(tx(&token, 50, nil), big.NewInt(137))
+	require.NoError(t, err)
+	_, err = signer.SignTx(tx(&token, 1, nil), big.NewInt(137))
+	assert.ErrorIs(t, err, ErrPolicyPeriodValue)
+
+	// the spends expire with the period
+	now = now.Add(time.Hour)
+	_, err = signer.SignTx(tx(&token, 100, nil), big.NewInt(137))
+	require.NoError(t, err)
+
+	_, err = signer.SignMessage([]byte("hi"))
+	assert.ErrorIs(t, err, ErrPolicyMessageSigning)
+	_, err = signer.SignData([]byte("hi"))
+	assert.ErrorIs(t, err, ErrPolicyMessageSigning)
+
+	now = now.Add(24 * time.Hour)
+	_, err = signer.SignTx(tx(&token, 1, nil), big.NewInt(1))
+	assert.ErrorIs(t, err, ErrPolicyExpired)
+}
+
+func TestSessionKey(t *testing.T) {
+	session, err := NewSessionKey(Policy{AllowMessageSigning: true, ExpiresAt: time.Now().Add(time.Minute)})
+	require.NoError(t, err)
+
+	sig, err := session.SignMessage([]byte("hi"))
+	require.NoError(t, err)
+	address, err := RecoverAddress([]byte("hi"), sig)
+	require.NoError(t, err)
+	assert.Equal(t, session.Address(), address)
+
+	_, err = NewPolicySigner(session, Policy{MaxValuePerPeriod: big.NewInt(1)})
+	assert.ErrorContains(t, err, "needs a Period")
+}
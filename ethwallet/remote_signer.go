@@ -0,0 +1,140 @@
+package ethwallet
+
+import (
+	"bytes"
+	"context"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+)
+
+// DigestSigner signs digests for an address, ie. of a key of a kms which never
+// leaves it, see NewAWSKMSSigner and NewGCPKMSSigner.
+type DigestSigner interface {
+	Address() common.Address
+
+	// SignDigest returns the signature of the digest, of 65 bytes
+	// [R || S || V] with V of 27/28.
+	SignDigest(ctx context.Context, digest common.Hash) ([]byte, error)
+}
+
+// RemoteSigner is the Signer of a DigestSigner, which signs the digests of the
+// transactions, messages and typed data with it.
+type RemoteSigner struct {
+	signer DigestSigner
+}
+
+var _ Signer = &RemoteSigner{}
+var _ WalletSigner = &RemoteSigner{}
+
+// NewRemoteSigner returns the Signer of the digest signer.
+func NewRemoteSigner(signer DigestSigner) *RemoteSigner {
+	return &RemoteSigner{signer: signer}
+}
+
+func (s *RemoteSigner) Address() common.Address {
+	return s.signer.Address()
+}
+
+// DigestSigner returns the digest signer of the signer.
+func (s *RemoteSigner) DigestSigner() DigestSigner {
+	return s.signer
+}
+
+func (s *RemoteSigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	digest := types.LatestSignerForChainID(chainID).Hash(tx)
+	sig, err := s.signer.SignDigest(context.Background(), digest)
+	if err != nil {
+		return nil, err
+	}
+	return ethtxn.CombineSignature(tx, chainID, sig)
+}
+
+func (s *RemoteSigner) SignMessage(message []byte) ([]byte, error) {
+	digest := ethcoder.EIP191PersonalMessageDigest(message)
+	if bytes.HasPrefix(message, []byte("\x19Ethereum Signed Message:\n")) {
+		// the message of its prefix, as of Wallet.SignMessage
+		digest = crypto.Keccak256Hash(message)
+	}
+	return s.signer.SignDigest(context.Background(), digest)
+}
+
+func (s *RemoteSigner) SignData(data []byte) ([]byte, error) {
+	return s.signer.SignDigest(context.Background(), crypto.Keccak256Hash(data))
+}
+
+func (s *RemoteSigner) SignTypedData(typedData *ethcoder.TypedData) ([]byte, error) {
+	digest, err := typedData.EncodeDigest()
+	if err != nil {
+		return nil, err
+	}
+	return s.signer.SignDigest(context.Background(), common.BytesToHash(digest))
+}
+
+var (
+	oidPublicKeyECDSA = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidSecp256k1      = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+)
+
+// parseKMSPublicKey returns the address of the DER or PEM encoded
+// SubjectPublicKeyInfo of a secp256k1 key, which x509 doesn't parse.
+func parseKMSPublicKey(data []byte) (common.Address, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	var spki struct {
+		Algorithm struct {
+			Algorithm  asn1.ObjectIdentifier
+			Parameters asn1.ObjectIdentifier
+		}
+		PublicKey asn1.BitString
+	}
+	if rest, err := asn1.Unmarshal(data, &spki); err != nil || len(rest) != 0 {
+		return common.Address{}, fmt.Errorf("ethwallet: invalid kms public key")
+	}
+	if !spki.Algorithm.Algorithm.Equal(oidPublicKeyECDSA) || !spki.Algorithm.Parameters.Equal(oidSecp256k1) {
+		return common.Address{}, fmt.Errorf("ethwallet: kms public key is not of secp256k1")
+	}
+	publicKey, err := crypto.UnmarshalPubkey(spki.PublicKey.Bytes)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("ethwallet: invalid kms public key: %w", err)
+	}
+	return crypto.PubkeyToAddress(*publicKey), nil
+}
+
+// recoverableSignature returns the signature [R || S || V] of the DER encoded
+// ecdsa signature of a kms, of the low S of EIP-2 and of the recovery id of the
+// address, which a kms doesn't return.
+func recoverableSignature(der []byte, digest common.Hash, address common.Address) ([]byte, error) {
+	var ecdsaSig struct{ R, S *big.Int }
+	if rest, err := asn1.Unmarshal(der, &ecdsaSig); err != nil || len(rest) != 0 {
+		return nil, fmt.Errorf("ethwallet: invalid kms signature")
+	}
+	if ecdsaSig.R.Sign() <= 0 || ecdsaSig.S.Sign() <= 0 || ecdsaSig.R.Cmp(secp256k1N) >= 0 || ecdsaSig.S.Cmp(secp256k1N) >= 0 {
+		return nil, fmt.Errorf("ethwallet: invalid kms signature")
+	}
+	s := ecdsaSig.S
+	if s.Cmp(secp256k1HalfN) > 0 {
+		s = new(big.Int).Sub(secp256k1N, s)
+	}
+
+	sig := make([]byte, 65)
+	ecdsaSig.R.FillBytes(sig[0:32])
+	s.FillBytes(sig[32:64])
+	for _, v := range []byte{0, 1} {
+		sig[64] = v
+		publicKey, err := crypto.SigToPub(digest[:], sig)
+		if err == nil && crypto.PubkeyToAddress(*publicKey) == address {
+			sig[64] += 27
+			return sig, nil
+		}
+	}
+	return nil, fmt.Errorf("ethwallet: kms signature is not of %s", address.Hex())
+}
@@ -0,0 +1,30 @@
+package ethwallet
+
+import (
+	"math/big"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+// WalletSigner is the signing interface of the wallets, of the keys of a Wallet
+// and of the hardware wallets, ie. of a Ledger or Trezor. Unlike a Signer, it
+// signs typed data rather than the digests of raw data, which hardware wallets
+// don't sign.
+type WalletSigner interface {
+	Address() common.Address
+
+	// SignTx returns the transaction signed for the chain.
+	SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+
+	// SignMessage returns the personal_sign signature of the message, of 65
+	// bytes [R || S || V] with V of 27/28.
+	SignMessage(message []byte) ([]byte, error)
+
+	// SignTypedData returns the EIP-712 signature of the typed data, of
+	// eth_signTypedData_v4.
+	SignTypedData(typedData *ethcoder.TypedData) ([]byte, error)
+}
+
+var _ WalletSigner = &Wallet{}
@@ -0,0 +1,473 @@
+package ethwallet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sync"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethtxn"
+	"github.com/0xsequence/ethkit/go-ethereum/accounts"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+var (
+	// ErrTrezorDenied is returned when the user rejects a request on the trezor.
+	ErrTrezorDenied = errors.New("ethwallet: trezor request denied by the user")
+
+	// ErrTrezorPINRequired is returned when the trezor is locked, and there is no
+	// PIN callback of the options.
+	ErrTrezorPINRequired = errors.New("ethwallet: trezor requires a PIN")
+)
+
+// The message types of the trezor protocol.
+const (
+	trezorMsgInitialize                    = 0
+	trezorMsgFailure                       = 3
+	trezorMsgFeatures                      = 17
+	trezorMsgPinMatrixRequest              = 18
+	trezorMsgPinMatrixAck                  = 19
+	trezorMsgButtonRequest                 = 26
+	trezorMsgButtonAck                     = 27
+	trezorMsgPassphraseRequest             = 41
+	trezorMsgPassphraseAck                 = 42
+	trezorMsgEthereumGetAddress            = 56
+	trezorMsgEthereumAddress               = 57
+	trezorMsgEthereumSignTx                = 58
+	trezorMsgEthereumTxRequest             = 59
+	trezorMsgEthereumTxAck                 = 60
+	trezorMsgEthereumSignMessage           = 64
+	trezorMsgEthereumMessageSignature      = 66
+	trezorMsgEthereumSignTxEIP1559         = 452
+	trezorMsgEthereumTypedDataSig          = 469
+	trezorMsgEthereumSignTypedHash         = 470
+	trezorFailureActionCancelled           = 4
+	trezorFailurePinCancelled              = 6
+	trezorMaxDataChunkSize                 = 1024
+	trezorMaxMessageSize                   = 1 << 20
+	trezorHIDReportSize                    = 64
+	trezorHIDFirstReportHeaderSize         = 9
+	trezorHIDContinuationReportHeader      = 1
+	trezorHIDReportMagic              byte = '?'
+)
+
+// TrezorTransport exchanges the protobuf messages of a trezor, ie. over usb hid,
+// see NewTrezorHIDTransport.
+type TrezorTransport interface {
+	Exchange(kind uint16, msg []byte) (replyKind uint16, reply []byte, err error)
+}
+
+var DefaultTrezorOptions = TrezorOptions{
+	DerivationPath: DefaultBaseDerivationPath,
+}
+
+type TrezorOptions struct {
+	// DerivationPath of the account, "m/44'/60'/0'/0/0" by default.
+	DerivationPath accounts.DerivationPath
+
+	// PIN returns the PIN of a locked trezor, of the positions of its digits in
+	// the scrambled matrix shown on the device, ie. "7415" for the keypad layout.
+	PIN func() (string, error)
+
+	// Passphrase returns the passphrase of the wallet of a trezor of passphrase
+	// protection. The empty passphrase is of the standard wallet.
+	Passphrase func() (string, error)
+}
+
+// Trezor is a wallet of a trezor device, of which the keys never leave the
+// device. The transactions, personal messages and typed data are signed on the
+// device, once the user confirms them.
+type Trezor struct {
+	transport TrezorTransport
+	options   TrezorOptions
+	address   common.Address
+	version   string
+	mu        sync.Mutex
+}
+
+var _ WalletSigner = &Trezor{}
+
+// NewTrezor returns the wallet of the account of the derivation path of the
+// trezor.
+func NewTrezor(transport TrezorTransport, opts ...TrezorOptions) (*Trezor, error) {
+	options := DefaultTrezorOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	if options.DerivationPath == nil {
+		options.DerivationPath = DefaultTrezorOptions.DerivationPath
+	}
+	t := &Trezor{transport: transport, options: options}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	features, err := t.call(trezorMsgInitialize, nil, trezorMsgFeatures)
+	if err != nil {
+		return nil, fmt.Errorf("ethwallet: failed to initialize trezor: %w", err)
+	}
+	t.version = fmt.Sprintf("%d.%d.%d", features.uint(2), features.uint(3), features.uint(4))
+
+	msg := protoAppendPath(nil, 1, options.DerivationPath)
+	reply, err := t.call(trezorMsgEthereumGetAddress, msg, trezorMsgEthereumAddress)
+	if err != nil {
+		return nil, fmt.Errorf("ethwallet: failed to get trezor address: %w", err)
+	}
+	// the address is a string of the newer firmwares, and bytes of the older ones
+	if address := reply.string(2); common.IsHexAddress(address) {
+		t.address = common.HexToAddress(address)
+	} else if address := reply.bytes(1); len(address) == common.AddressLength {
+		t.address = common.BytesToAddress(address)
+	} else {
+		return nil, fmt.Errorf("ethwallet: invalid trezor address response")
+	}
+	return t, nil
+}
+
+func (t *Trezor) Address() common.Address {
+	return t.address
+}
+
+func (t *Trezor) DerivationPath() accounts.DerivationPath {
+	return t.options.DerivationPath
+}
+
+// Version returns the version of the firmware of the trezor, ie. "2.6.0".
+func (t *Trezor) Version() string {
+	return t.version
+}
+
+// SignTx signs the legacy or dynamic fee transaction for the chain on the
+// trezor.
+func (t *Trezor) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	if chainID == nil || chainID.Sign() == 0 || !chainID.IsUint64() {
+		return nil, fmt.Errorf("ethwallet: trezor requires the chain id of the transaction")
+	}
+	if tx.To() == nil {
+		return nil, fmt.Errorf("ethwallet: trezor can't sign contract creation, ie. of no to address")
+	}
+
+	data := tx.Data()
+	initial := data[:min(len(data), trezorMaxDataChunkSize)]
+
+	var kind uint16
+	var msg []byte
+	msg = protoAppendPath(msg, 1, t.options.DerivationPath)
+	switch tx.Type() {
+	case types.LegacyTxType:
+		kind = trezorMsgEthereumSignTx
+		msg = protoAppendBytes(msg, 2, new(big.Int).SetUint64(tx.Nonce()).Bytes())
+		msg = protoAppendBytes(msg, 3, tx.GasPrice().Bytes())
+		msg = protoAppendBytes(msg, 4, new(big.Int).SetUint64(tx.Gas()).Bytes())
+		msg = protoAppendString(msg, 11, tx.To().Hex())
+		msg = protoAppendBytes(msg, 6, tx.Value().Bytes())
+		msg = protoAppendBytes(msg, 7, initial)
+		msg = protoAppendUint(msg, 8, uint64(len(data)))
+		msg = protoAppendUint(msg, 9, chainID.Uint64())
+	case types.DynamicFeeTxType:
+		kind = trezorMsgEthereumSignTxEIP1559
+		msg = protoAppendBytes(msg, 2, new(big.Int).SetUint64(tx.Nonce()).Bytes())
+		msg = protoAppendBytes(msg, 3, tx.GasFeeCap().Bytes())
+		msg = protoAppendBytes(msg, 4, tx.GasTipCap().Bytes())
+		msg = protoAppendBytes(msg, 5, new(big.Int).SetUint64(tx.Gas()).Bytes())
+		msg = protoAppendString(msg, 6, tx.To().Hex())
+		msg = protoAppendBytes(msg, 7, tx.Value().Bytes())
+		msg = protoAppendBytes(msg, 8, initial)
+		msg = protoAppendUint(msg, 9, uint64(len(data)))
+		msg = protoAppendUint(msg, 10, chainID.Uint64())
+		for _, tuple := range tx.AccessList() {
+			entry := protoAppendString(nil, 1, tuple.Address.Hex())
+			for _, key := range tuple.StorageKeys {
+				entry = protoAppendBytes(entry, 2, key.Bytes())
+			}
+			msg = protoAppendBytes(msg, 11, entry)
+		}
+	default:
+		return nil, fmt.Errorf("ethwallet: trezor can't sign transaction of type %d", tx.Type())
+	}
+	data = data[len(initial):]
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// the trezor asks for the rest of the data in chunks, then replies with the
+	// signature
+	reply, err := t.call(kind, msg, trezorMsgEthereumTxRequest)
+	for err == nil && reply.uint(1) > 0 {
+		n := int(reply.uint(1))
+		if n > len(data) {
+			return nil, fmt.Errorf("ethwallet: trezor asks for %d bytes of %d bytes of data", n, len(data))
+		}
+		chunk := data[:n]
+		data = data[n:]
+		reply, err = t.call(trezorMsgEthereumTxAck, protoAppendBytes(nil, 1, chunk), trezorMsgEthereumTxRequest)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ethwallet: failed to sign transaction on trezor: %w", err)
+	}
+	r, s := reply.bytes(3), reply.bytes(4)
+	if len(r) > 32 || len(s) > 32 {
+		return nil, fmt.Errorf("ethwallet: invalid trezor signature")
+	}
+	sig := make([]byte, 65)
+	copy(sig[32-len(r):32], r)
+	copy(sig[64-len(s):64], s)
+
+	// the v of legacy transactions is of eip-155, so the recovery id is the one
+	// of the address of the trezor
+	for _, v := range []byte{0, 1} {
+		sig[64] = v
+		signedTx, err := ethtxn.CombineSignature(tx, chainID, sig)
+		if err != nil {
+			return nil, err
+		}
+		sender, err := types.Sender(types.LatestSignerForChainID(chainID), signedTx)
+		if err == nil && sender == t.address {
+			return signedTx, nil
+		}
+	}
+	return nil, fmt.Errorf("ethwallet: trezor signature is not of %s", t.address.Hex())
+}
+
+// SignMessage signs the personal message on the trezor, of which the
+// "\x19Ethereum Signed Message:\n" prefix is added by the trezor. The prefix
+// of an already prefixed message is trimmed, so it isn't signed twice.
+func (t *Trezor) SignMessage(message []byte) ([]byte, error) {
+	message = trimPersonalMessagePrefix(message)
+	msg := protoAppendPath(nil, 1, t.options.DerivationPath)
+	msg = protoAppendBytes(msg, 2, message)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	reply, err := t.call(trezorMsgEthereumSignMessage, msg, trezorMsgEthereumMessageSignature)
+	if err != nil {
+		return nil, fmt.Errorf("ethwallet: failed to sign message on trezor: %w", err)
+	}
+	return trezorSignature(reply.bytes(2))
+}
+
+// SignTypedData signs the EIP-712 typed data on the trezor, of the hashes of its
+// domain and message, which the trezor displays. The firmwares of the models
+// without the signing of the hashes reject it.
+func (t *Trezor) SignTypedData(typedData *ethcoder.TypedData) ([]byte, error) {
+	domainHash, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		return nil, err
+	}
+	msg := protoAppendPath(nil, 1, t.options.DerivationPath)
+	msg = protoAppendBytes(msg, 2, domainHash)
+	// the message hash is omitted for the typed data of the domain only
+	if typedData.PrimaryType != "EIP712Domain" {
+		messageHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+		if err != nil {
+			return nil, err
+		}
+		msg = protoAppendBytes(msg, 3, messageHash)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	reply, err := t.call(trezorMsgEthereumSignTypedHash, msg, trezorMsgEthereumTypedDataSig)
+	if err != nil {
+		return nil, fmt.Errorf("ethwallet: failed to sign typed data on trezor: %w", err)
+	}
+	return trezorSignature(reply.bytes(1))
+}
+
+// call sends the message, and returns the reply of the kind once the button,
+// PIN and passphrase requests of the trezor are answered. The caller holds mu.
+func (t *Trezor) call(kind uint16, msg []byte, wantKind uint16) (protoMessage, error) {
+	for {
+		replyKind, reply, err := t.transport.Exchange(kind, msg)
+		if err != nil {
+			return nil, err
+		}
+		fields, err := protoDecode(reply)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trezor message of type %d: %w", replyKind, err)
+		}
+
+		switch replyKind {
+		case wantKind:
+			return fields, nil
+
+		case trezorMsgButtonRequest:
+			kind, msg = trezorMsgButtonAck, nil
+
+		case trezorMsgPinMatrixRequest:
+			if t.options.PIN == nil {
+				return nil, ErrTrezorPINRequired
+			}
+			pin, err := t.options.PIN()
+			if err != nil {
+				return nil, err
+			}
+			kind, msg = trezorMsgPinMatrixAck, protoAppendString(nil, 1, pin)
+
+		case trezorMsgPassphraseRequest:
+			var passphrase string
+			if t.options.Passphrase != nil {
+				if passphrase, err = t.options.Passphrase(); err != nil {
+					return nil, err
+				}
+			}
+			kind, msg = trezorMsgPassphraseAck, protoAppendString(nil, 1, passphrase)
+
+		case trezorMsgFailure:
+			switch fields.uint(1) {
+			case trezorFailureActionCancelled, trezorFailurePinCancelled:
+				return nil, ErrTrezorDenied
+			}
+			return nil, fmt.Errorf("trezor failure %d: %s", fields.uint(1), fields.string(2))
+
+		default:
+			return nil, fmt.Errorf("unexpected trezor message of type %d", replyKind)
+		}
+	}
+}
+
+// trezorSignature returns the signature [R || S || V], with V of 27/28.
+func trezorSignature(sig []byte) ([]byte, error) {
+	if len(sig) != 65 {
+		return nil, fmt.Errorf("ethwallet: invalid trezor signature of %d bytes", len(sig))
+	}
+	sig = append([]byte{}, sig...)
+	if sig[64] < 27 {
+		sig[64] += 27
+	}
+	return sig, nil
+}
+
+// NewTrezorHIDTransport returns the transport of the usb hid device of a trezor,
+// ie. of a hid library, which reads and writes its reports of 64 bytes.
+func NewTrezorHIDTransport(device io.ReadWriter) TrezorTransport {
+	return &trezorHIDTransport{device: device}
+}
+
+type trezorHIDTransport struct {
+	device io.ReadWriter
+}
+
+func (t *trezorHIDTransport) Exchange(kind uint16, msg []byte) (uint16, []byte, error) {
+	// the message of its type and length, in the reports of the magic
+	data := []byte{'#', '#'}
+	data = binary.BigEndian.AppendUint16(data, kind)
+	data = binary.BigEndian.AppendUint32(data, uint32(len(msg)))
+	data = append(data, msg...)
+	for len(data) > 0 {
+		report := make([]byte, trezorHIDReportSize)
+		report[0] = trezorHIDReportMagic
+		data = data[copy(report[1:], data):]
+		if _, err := t.device.Write(report); err != nil {
+			return 0, nil, fmt.Errorf("ethwallet: failed to write to trezor: %w", err)
+		}
+	}
+
+	report := make([]byte, trezorHIDReportSize)
+	if _, err := io.ReadFull(t.device, report); err != nil {
+		return 0, nil, fmt.Errorf("ethwallet: failed to read from trezor: %w", err)
+	}
+	if report[0] != trezorHIDReportMagic || report[1] != '#' || report[2] != '#' {
+		return 0, nil, fmt.Errorf("ethwallet: invalid trezor report header")
+	}
+	replyKind := binary.BigEndian.Uint16(report[3:])
+	size := int(binary.BigEndian.Uint32(report[5:]))
+	if size > trezorMaxMessageSize {
+		return 0, nil, fmt.Errorf("ethwallet: trezor message of %d bytes is too large", size)
+	}
+	reply := append(make([]byte, 0, size), report[trezorHIDFirstReportHeaderSize:][:min(size, trezorHIDReportSize-trezorHIDFirstReportHeaderSize)]...)
+	for len(reply) < size {
+		if _, err := io.ReadFull(t.device, report); err != nil {
+			return 0, nil, fmt.Errorf("ethwallet: failed to read from trezor: %w", err)
+		}
+		if report[0] != trezorHIDReportMagic {
+			return 0, nil, fmt.Errorf("ethwallet: invalid trezor report header")
+		}
+		payload := report[trezorHIDContinuationReportHeader:]
+		reply = append(reply, payload[:min(len(payload), size-len(reply))]...)
+	}
+	return replyKind, reply, nil
+}
+
+// protoMessage is the fields of a protobuf message, of which a repeated field is
+// of its last value.
+type protoMessage map[uint64]protoValue
+
+type protoValue struct {
+	varint uint64
+	bytes  []byte
+}
+
+func (m protoMessage) uint(field uint64) uint64   { return m[field].varint }
+func (m protoMessage) bytes(field uint64) []byte  { return m[field].bytes }
+func (m protoMessage) string(field uint64) string { return string(m[field].bytes) }
+
+func protoDecode(data []byte) (protoMessage, error) {
+	m := protoMessage{}
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, fmt.Errorf("invalid field key")
+		}
+		data = data[n:]
+
+		field, wireType := key>>3, key&7
+		switch wireType {
+		case 0:
+			v, n := binary.Uvarint(data)
+			if n <= 0 {
+				return nil, fmt.Errorf("invalid varint of field %d", field)
+			}
+			data = data[n:]
+			m[field] = protoValue{varint: v}
+		case 2:
+			size, n := binary.Uvarint(data)
+			if n <= 0 || size > uint64(len(data)-n) {
+				return nil, fmt.Errorf("invalid length of field %d", field)
+			}
+			data = data[n:]
+			m[field] = protoValue{bytes: data[:size]}
+			data = data[size:]
+		case 1, 5:
+			size := 8
+			if wireType == 5 {
+				size = 4
+			}
+			if len(data) < size {
+				return nil, fmt.Errorf("invalid fixed field %d", field)
+			}
+			data = data[size:]
+		default:
+			return nil, fmt.Errorf("invalid wire type %d of field %d", wireType, field)
+		}
+	}
+	return m, nil
+}
+
+func protoAppendUint(b []byte, field uint64, v uint64) []byte {
+	b = binary.AppendUvarint(b, field<<3)
+	return binary.AppendUvarint(b, v)
+}
+
+func protoAppendBytes(b []byte, field uint64, v []byte) []byte {
+	b = binary.AppendUvarint(b, field<<3|2)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+func protoAppendString(b []byte, field uint64, v string) []byte {
+	return protoAppendBytes(b, field, []byte(v))
+}
+
+func protoAppendPath(b []byte, field uint64, path accounts.DerivationPath) []byte {
+	for _, component := range path {
+		b = protoAppendUint(b, field, uint64(component))
+	}
+	return b
+}
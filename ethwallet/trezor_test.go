@@ -0,0 +1,304 @@
+package ethwallet
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/0xsequence/ethkit/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockTrezor is the usb hid device of a trezor, which signs with its key once
+// the button requests are acked.
+type mockTrezor struct {
+	key      *ecdsa.PrivateKey
+	pin      string
+	reject   bool
+	unlocked bool
+	buttons  int
+
+	request []byte
+	reports [][]byte
+
+	pendingKind uint16
+	pending     protoMessage
+	tx          protoMessage
+	txKind      uint16
+	data        []byte
+}
+
+func (m *mockTrezor) Write(report []byte) (int, error) {
+	if len(m.request) == 0 {
+		m.request = append([]byte{}, report[1:]...)
+	} else {
+		m.request = append(m.request, report[1:]...)
+	}
+	size := int(binary.BigEndian.Uint32(m.request[4:]))
+	if len(m.request)-8 < size {
+		return len(report), nil
+	}
+	kind := binary.BigEndian.Uint16(m.request[2:])
+	msg, err := protoDecode(m.request[8 : 8+size])
+	if err != nil {
+		panic(err)
+	}
+	m.request = nil
+
+	replyKind, reply := m.handle(kind, msg)
+	data := []byte{'#', '#'}
+	data = binary.BigEndian.AppendUint16(data, replyKind)
+	data = binary.BigEndian.AppendUint32(data, uint32(len(reply)))
+	data = append(data, reply...)
+	for len(data) > 0 {
+		out := make([]byte, 64)
+		out[0] = '?'
+		data = data[copy(out[1:], data):]
+		m.reports = append(m.reports, out)
+	}
+	return len(report), nil
+}
+
+func (m *mockTrezor) Read(p []byte) (int, error) {
+	n := copy(p, m.reports[0])
+	m.reports = m.reports[1:]
+	return n, nil
+}
+
+func (m *mockTrezor) handle(kind uint16, msg protoMessage) (uint16, []byte) {
+	switch kind {
+	case trezorMsgInitialize:
+		features := protoAppendUint(nil, 2, 2)
+		features = protoAppendUint(features, 3, 6)
+		return trezorMsgFeatures, protoAppendUint(features, 4, 0)
+
+	case trezorMsgPinMatrixAck:
+		if msg.string(1) != m.pin {
+			return trezorMsgFailure, protoAppendUint(nil, 1, 7)
+		}
+		m.unlocked = true
+		return m.handle(m.pendingKind, m.pending)
+
+	case trezorMsgButtonAck:
+		m.buttons++
+		if m.reject {
+			return trezorMsgFailure, protoAppendUint(nil, 1, trezorFailureActionCancelled)
+		}
+		return m.sign(m.pendingKind, m.pending)
+
+	case trezorMsgEthereumTxAck:
+		return m.signTx(msg.bytes(1))
+	}
+
+	if m.pin != "" && !m.unlocked {
+		m.pendingKind, m.pending = kind, msg
+		return trezorMsgPinMatrixRequest, nil
+	}
+	if kind == trezorMsgEthereumGetAddress {
+		return trezorMsgEthereumAddress, protoAppendString(nil, 2, crypto.PubkeyToAddress(m.key.PublicKey).Hex())
+	}
+	m.pendingKind, m.pending = kind, msg
+	return trezorMsgButtonRequest, nil
+}
+
+func (m *mockTrezor) sign(kind uint16, msg protoMessage) (uint16, []byte) {
+	address := crypto.PubkeyToAddress(m.key.PublicKey).Hex()
+
+	switch kind {
+	case trezorMsgEthereumSignTx:
+		m.tx, m.txKind, m.data = msg, kind, append([]byte{}, msg.bytes(7)...)
+		return m.signTx(nil)
+	case trezorMsgEthereumSignTxEIP1559:
+		m.tx, m.txKind, m.data = msg, kind, append([]byte{}, msg.bytes(8)...)
+		return m.signTx(nil)
+	case trezorMsgEthereumSignMessage:
+		sig := m.signDigest(ethcoder.EIP191PersonalMessageDigest(msg.bytes(2)).Bytes())
+		sig[64] += 27
+		reply := protoAppendBytes(nil, 2, sig)
+		return trezorMsgEthereumMessageSignature, protoAppendString(reply, 3, address)
+	case trezorMsgEthereumSignTypedHash:
+		sig := m.signDigest(ethcoder.EIP191StructuredDataDigest(common.BytesToHash(msg.bytes(2)), common.BytesToHash(msg.bytes(3))).Bytes())
+		sig[64] += 27
+		reply := protoAppendBytes(nil, 1, sig)
+		return trezorMsgEthereumTypedDataSig, protoAppendString(reply, 2, address)
+	}
+	return trezorMsgFailure, protoAppendString(protoAppendUint(nil, 1, 1), 2, "unexpected message")
+}
+
+func (m *mockTrezor) signTx(chunk []byte) (uint16, []byte) {
+	m.data = append(m.data, chunk...)
+	size := m.tx.uint(8)
+	if m.txKind == trezorMsgEthereumSignTxEIP1559 {
+		size = m.tx.uint(9)
+	}
+	if n := size - uint64(len(m.data)); n > 0 {
+		return trezorMsgEthereumTxRequest, protoAppendUint(nil, 1, min(n, trezorMaxDataChunkSize))
+	}
+
+	var tx *types.Transaction
+	var chainID *big.Int
+	if m.txKind == trezorMsgEthereumSignTx {
+		chainID = new(big.Int).SetUint64(m.tx.uint(9))
+		to := common.HexToAddress(m.tx.string(11))
+		tx = types.NewTx(&types.LegacyTx{
+			Nonce:    new(big.Int).SetBytes(m.tx.bytes(2)).Uint64(),
+			GasPrice: new(big.Int).SetBytes(m.tx.bytes(3)),
+			Gas:      new(big.Int).SetBytes(m.tx.bytes(4)).Uint64(),
+			To:       &to,
+			Value:    new(big.Int).SetBytes(m.tx.bytes(6)),
+			Data:     m.data,
+		})
+	} else {
+		chainID = new(big.Int).SetUint64(m.tx.uint(10))
+		to := common.HexToAddress(m.tx.string(6))
+		var accessList types.AccessList
+		if entry := m.tx.bytes(11); entry != nil {
+			// the mock is of the access lists of a single entry and storage key
+			tuple, _ := protoDecode(entry)
+			accessList = types.AccessList{{Address: common.HexToAddress(tuple.string(1)), StorageKeys: []common.Hash{common.BytesToHash(tuple.bytes(2))}}}
+		}
+		tx = types.NewTx(&types.DynamicFeeTx{
+			ChainID:    chainID,
+			Nonce:      new(big.Int).SetBytes(m.tx.bytes(2)).Uint64(),
+			GasFeeCap:  new(big.Int).SetBytes(m.tx.bytes(3)),
+			GasTipCap:  new(big.Int).SetBytes(m.tx.bytes(4)),
+			Gas:        new(big.Int).SetBytes(m.tx.bytes(5)).Uint64(),
+			To:         &to,
+			Value:      new(big.Int).SetBytes(m.tx.bytes(7)),
+			Data:       m.data,
+			AccessList: accessList,
+		})
+	}
+
+	sig := m.signDigest(types.LatestSignerForChainID(chainID).Hash(tx).Bytes())
+	v := uint64(sig[64])
+	if m.txKind == trezorMsgEthereumSignTx {
+		v += chainID.Uint64()*2 + 35
+	}
+	reply := protoAppendUint(nil, 2, v)
+	reply = protoAppendBytes(reply, 3, sig[:32])
+	return trezorMsgEthereumTxRequest, protoAppendBytes(reply, 4, sig[32:64])
+}
+
+func (m *mockTrezor) signDigest(digest []byte) []byte {
+	sig, err := crypto.Sign(digest, m.key)
+	if err != nil {
+		panic(err)
+	}
+	return sig
+}
+
+func TestTrezor(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	device := &mockTrezor{key: key, pin: "1234"}
+
+	_, err = NewTrezor(NewTrezorHIDTransport(device))
+	assert.ErrorIs(t, err, ErrTrezorPINRequired)
+
+	trezor, err := NewTrezor(NewTrezorHIDTransport(device), TrezorOptions{
+		PIN: func() (string, error) { return "1234", nil },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, crypto.PubkeyToAddress(key.PublicKey), trezor.Address())
+	assert.Equal(t, DefaultBaseDerivationPath, trezor.DerivationPath())
+	assert.Equal(t, "2.6.0", trezor.Version())
+
+	t.Run("transactions", func(t *testing.T) {
+		chainID := big.NewInt(137)
+		to := common.HexToAddress("0x8ba1f109551bD432803012645Ac136ddd64DBA72")
+		txs := []*types.Transaction{
+			types.NewTx(&types.LegacyTx{Nonce: 1, GasPrice: big.NewInt(1e9), Gas: 21000, To: &to, Value: big.NewInt(1)}),
+			types.NewTx(&types.LegacyTx{Nonce: 2, GasPrice: big.NewInt(1e9), Gas: 100000, To: &to, Data: bytes.Repeat([]byte{0xcd}, 2500)}),
+			types.NewTx(&types.DynamicFeeTx{
+				ChainID: chainID, Nonce: 3, GasTipCap: big.NewInt(1e9), GasFeeCap: big.NewInt(2e9), Gas: 100000, To: &to,
+				Data:       bytes.Repeat([]byte{0xab}, 1500),
+				AccessList: types.AccessList{{Address: to, StorageKeys: []common.Hash{common.HexToHash("0x01")}}},
+			}),
+		}
+		for _, tx := range txs {
+			signedTx, err := trezor.SignTx(tx, chainID)
+			require.NoError(t, err)
+			sender, err := types.Sender(types.LatestSignerForChainID(chainID), signedTx)
+			require.NoError(t, err)
+			assert.Equal(t, trezor.Address(), sender)
+		}
+
+		_, err := trezor.SignTx(types.NewTx(&types.LegacyTx{Nonce: 1, Gas: 21000}), chainID)
+		assert.Error(t, err)
+	})
+
+	t.Run("message", func(t *testing.T) {
+		device.buttons = 0
+		message := bytes.Repeat([]byte("hello "), 100)
+		sig, err := trezor.SignMessage(message)
+		require.NoError(t, err)
+		assert.Equal(t, 1, device.buttons)
+
+		valid, err := ethcoder.ValidateSignature(trezor.Address(), ethcoder.EIP191PersonalMessageDigest(message).Bytes(), sig)
+		require.NoError(t, err)
+		assert.True(t, valid)
+
+		// the prefix of a prefixed message isn't sent to the trezor
+		payload := device.pending.bytes(2)
+		prefixedSig, err := trezor.SignMessage(append([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(message))), message...))
+		require.NoError(t, err)
+		assert.Equal(t, payload, device.pending.bytes(2))
+		assert.Equal(t, sig, prefixedSig)
+	})
+
+	t.Run("typed data", func(t *testing.T) {
+		typedData := &ethcoder.TypedData{
+			Types: ethcoder.TypedDataTypes{
+				"EIP712Domain": {{Name: "name", Type: "string"}, {Name: "chainId", Type: "uint256"}},
+				"Person":       {{Name: "name", Type: "string"}, {Name: "wallet", Type: "address"}},
+			},
+			PrimaryType: "Person",
+			Domain:      ethcoder.TypedDataDomain{Name: "Ether Mail", ChainID: big.NewInt(1)},
+			Message:     map[string]interface{}{"name": "Bob", "wallet": common.HexToAddress("0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB")},
+		}
+		sig, err := trezor.SignTypedData(typedData)
+		require.NoError(t, err)
+
+		digest, err := typedData.EncodeDigest()
+		require.NoError(t, err)
+		valid, err := ethcoder.ValidateSignature(trezor.Address(), digest, sig)
+		require.NoError(t, err)
+		assert.True(t, valid)
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				message := bytes.Repeat([]byte{byte('a' + i)}, 600)
+				sig, err := trezor.SignMessage(message)
+				if !assert.NoError(t, err) {
+					return
+				}
+				valid, err := ethcoder.ValidateSignature(trezor.Address(), ethcoder.EIP191PersonalMessageDigest(message).Bytes(), sig)
+				assert.NoError(t, err)
+				assert.True(t, valid)
+			}(i)
+		}
+		wg.Wait()
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		device.reject = true
+		defer func() { device.reject = false }()
+
+		_, err := trezor.SignMessage([]byte("hi"))
+		assert.ErrorIs(t, err, ErrTrezorDenied)
+	})
+}
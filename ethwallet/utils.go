@@ -3,6 +3,7 @@ package ethwallet
 import (
 	"bytes"
 	"fmt"
+	"math/big"
 
 	"github.com/0xsequence/ethkit/ethcoder"
 	"github.com/0xsequence/ethkit/go-ethereum/common"
@@ -41,6 +42,132 @@ func RecoverAddressFromDigest(digest, signature []byte) (common.Address, error)
 	return address, nil
 }
 
+// CompactSignature returns the EIP-2098 compact form of a 65-byte signature, ie.
+// r followed by s with the y parity of v in its top bit.
+func CompactSignature(signature []byte) ([]byte, error) {
+	if len(signature) != 65 {
+		return nil, fmt.Errorf("signature is not of proper length (=65)")
+	}
+	v := signature[64]
+	if v >= 27 {
+		v -= 27
+	}
+	if v > 1 {
+		return nil, fmt.Errorf("invalid signature v %d", signature[64])
+	}
+	if new(big.Int).SetBytes(signature[32:64]).Cmp(secp256k1HalfN) > 0 {
+		return nil, fmt.Errorf("signature s is not in the lower half of the curve order")
+	}
+
+	compact := make([]byte, 64)
+	copy(compact, signature[:64])
+	compact[32] |= v << 7
+	return compact, nil
+}
+
+// ExpandCompactSignature returns the 65-byte signature of an EIP-2098 compact
+// signature, with v of 27 or 28.
+func ExpandCompactSignature(compact []byte) ([]byte, error) {
+	if len(compact) != 64 {
+		return nil, fmt.Errorf("compact signature is not of proper length (=64)")
+	}
+	signature := make([]byte, 65)
+	copy(signature, compact)
+	signature[32] &= 0x7f
+	signature[64] = 27 + compact[32]>>7
+	return signature, nil
+}
+
+var (
+	secp256k1N     = crypto.S256().Params().N
+	secp256k1HalfN = new(big.Int).Rsh(secp256k1N, 1)
+)
+
+// Signature is the r, s and v of an ECDSA signature, with v of 27 or 28.
+type Signature struct {
+	R [32]byte
+	S [32]byte
+	V byte
+}
+
+// SplitSignature returns the r, s and v of a 65-byte signature of v in {0,1} or
+// {27,28}, or of a 64-byte EIP-2098 compact signature.
+func SplitSignature(signature []byte) (*Signature, error) {
+	if len(signature) == 64 {
+		var err error
+		if signature, err = ExpandCompactSignature(signature); err != nil {
+			return nil, err
+		}
+	}
+	if len(signature) != 65 {
+		return nil, fmt.Errorf("signature is not of proper length (=65 or 64)")
+	}
+	v, err := NormalizeSignatureV(uint64(signature[64]))
+	if err != nil {
+		return nil, err
+	}
+	sig := &Signature{V: v}
+	copy(sig.R[:], signature[:32])
+	copy(sig.S[:], signature[32:64])
+	return sig, nil
+}
+
+// JoinSignature returns the 65-byte signature of r, s and v, with v of 27 or 28.
+// The v is in {0,1}, {27,28}, or of a transaction of EIP-155, ie.
+// chainId*2+35+yParity.
+func JoinSignature(r, s [32]byte, v uint64) ([]byte, error) {
+	normalizedV, err := NormalizeSignatureV(v)
+	if err != nil {
+		return nil, err
+	}
+	sig := &Signature{R: r, S: s, V: normalizedV}
+	return sig.Bytes(), nil
+}
+
+// NormalizeSignatureV returns the v in {27,28} of a v in {0,1}, {27,28}, or of a
+// transaction of EIP-155, see ethcoder.NormalizeSignatureV.
+func NormalizeSignatureV(v uint64) (byte, error) {
+	return ethcoder.NormalizeSignatureV(v)
+}
+
+// YParity returns the y parity of the signature, ie. v of 0 or 1.
+func (s *Signature) YParity() byte {
+	return s.V - 27
+}
+
+// Bytes returns the 65-byte signature, with v of 27 or 28.
+func (s *Signature) Bytes() []byte {
+	b := make([]byte, 65)
+	copy(b, s.R[:])
+	copy(b[32:], s.S[:])
+	b[64] = s.V
+	return b
+}
+
+// Compact returns the EIP-2098 compact signature, see CompactSignature.
+func (s *Signature) Compact() ([]byte, error) {
+	return CompactSignature(s.Bytes())
+}
+
+// IsHighS returns whether s is in the upper half of the curve order, ie. the
+// malleable form of the signature, which EIP-2 rejects for transactions and
+// OpenZeppelin's ECDSA for messages.
+func (s *Signature) IsHighS() bool {
+	return new(big.Int).SetBytes(s.S[:]).Cmp(secp256k1HalfN) > 0
+}
+
+// LowS returns the equivalent signature of s in the lower half of the curve
+// order, ie. of n-s and the other y parity, or the signature itself.
+func (s *Signature) LowS() *Signature {
+	if !s.IsHighS() {
+		return &Signature{R: s.R, S: s.S, V: s.V}
+	}
+	lowS := new(big.Int).Sub(secp256k1N, new(big.Int).SetBytes(s.S[:]))
+	sig := &Signature{R: s.R, V: 27 + (1 - s.YParity())}
+	lowS.FillBytes(sig.S[:])
+	return sig
+}
+
 func IsValidEOASignature(address common.Address, digest, signature []byte) (bool, error) {
 	if len(digest) == 0 || len(signature) == 0 {
 		return false, fmt.Errorf("digest and signature must not be empty")
@@ -0,0 +1,192 @@
+package ethwallet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+)
+
+// Web3Signer is the signer of an account of a remote signer of the json-rpc
+// methods eth_signTransaction, eth_sign and eth_signTypedData, ie. of Consensys
+// Web3Signer, or of clef. The signatures of the remote signer are verified to be
+// of the account and of the request.
+type Web3Signer struct {
+	provider *ethrpc.Provider
+	address  common.Address
+}
+
+var _ WalletSigner = &Web3Signer{}
+
+// NewWeb3Signer returns the signer of the account of the remote signer of the
+// provider, ie. of ethrpc.NewProvider("http://localhost:9000").
+func NewWeb3Signer(provider *ethrpc.Provider, address common.Address) *Web3Signer {
+	return &Web3Signer{provider: provider, address: address}
+}
+
+// Web3SignerAccounts returns the accounts of the remote signer, of eth_accounts.
+func Web3SignerAccounts(ctx context.Context, provider *ethrpc.Provider) ([]common.Address, error) {
+	var accounts []common.Address
+	_, err := provider.Do(ctx, ethrpc.NewCallBuilder[[]common.Address]("eth_accounts", nil).Into(&accounts))
+	if err != nil {
+		return nil, fmt.Errorf("ethwallet: failed to get web3signer accounts: %w", err)
+	}
+	return accounts, nil
+}
+
+func (s *Web3Signer) Address() common.Address {
+	return s.address
+}
+
+// SignTx signs the transaction with eth_signTransaction, of the chain of the
+// remote signer, which must be the chain.
+func (s *Web3Signer) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	args := map[string]any{
+		"from":  s.address,
+		"gas":   hexutil.Uint64(tx.Gas()),
+		"value": (*hexutil.Big)(tx.Value()),
+		"data":  hexutil.Bytes(tx.Data()),
+		"nonce": hexutil.Uint64(tx.Nonce()),
+	}
+	if tx.To() != nil {
+		args["to"] = tx.To()
+	}
+	switch tx.Type() {
+	case types.LegacyTxType:
+		args["gasPrice"] = (*hexutil.Big)(tx.GasPrice())
+	case types.AccessListTxType:
+		args["gasPrice"] = (*hexutil.Big)(tx.GasPrice())
+		args["accessList"] = accessListOrEmpty(tx.AccessList())
+	case types.DynamicFeeTxType:
+		args["maxFeePerGas"] = (*hexutil.Big)(tx.GasFeeCap())
+		args["maxPriorityFeePerGas"] = (*hexutil.Big)(tx.GasTipCap())
+		if len(tx.AccessList()) > 0 {
+			args["accessList"] = tx.AccessList()
+		}
+	default:
+		return nil, fmt.Errorf("ethwallet: web3signer doesn't sign transactions of type %d", tx.Type())
+	}
+	if chainID != nil {
+		args["chainId"] = (*hexutil.Big)(chainID)
+	}
+
+	var raw json.RawMessage
+	_, err := s.provider.Do(context.Background(), ethrpc.NewCallBuilder[json.RawMessage]("eth_signTransaction", nil, args).Into(&raw))
+	if err != nil {
+		return nil, fmt.Errorf("ethwallet: failed to sign transaction with web3signer: %w", err)
+	}
+
+	// the raw transaction, or the {raw, tx} of geth and clef
+	var data hexutil.Bytes
+	if err := json.Unmarshal(raw, &data); err != nil {
+		var result struct {
+			Raw hexutil.Bytes `json:"raw"`
+		}
+		if err := json.Unmarshal(raw, &result); err != nil {
+			return nil, fmt.Errorf("ethwallet: invalid web3signer transaction: %w", err)
+		}
+		data = result.Raw
+	}
+	signedTx := new(types.Transaction)
+	if err := signedTx.UnmarshalBinary(data); err != nil {
+		return nil, fmt.Errorf("ethwallet: invalid web3signer transaction: %w", err)
+	}
+
+	if chainID != nil && signedTx.Protected() && signedTx.ChainId().Cmp(chainID) != 0 {
+		return nil, fmt.Errorf("ethwallet: web3signer transaction is of chain %s, not %s", signedTx.ChainId(), chainID)
+	}
+	signer := types.LatestSignerForChainID(chainID)
+	if signer.Hash(signedTx) != signer.Hash(tx) {
+		return nil, fmt.Errorf("ethwallet: web3signer transaction is not of the request")
+	}
+	sender, err := types.Sender(signer, signedTx)
+	if err != nil {
+		return nil, fmt.Errorf("ethwallet: invalid web3signer transaction: %w", err)
+	}
+	if sender != s.address {
+		return nil, fmt.Errorf("ethwallet: signer mismatch: expected %s, got %s", s.address.Hex(), sender.Hex())
+	}
+	return signedTx, nil
+}
+
+// SignMessage signs the personal message with eth_sign, which adds the
+// "\x19Ethereum Signed Message:\n" prefix to the message.
+func (s *Web3Signer) SignMessage(message []byte) ([]byte, error) {
+	message = trimPersonalMessagePrefix(message)
+
+	var sig hexutil.Bytes
+	_, err := s.provider.Do(context.Background(), ethrpc.NewCallBuilder[hexutil.Bytes]("eth_sign", nil, s.address, hexutil.Bytes(message)).Into(&sig))
+	if err != nil {
+		return nil, fmt.Errorf("ethwallet: failed to sign message with web3signer: %w", err)
+	}
+	return s.verify(ethcoder.EIP191PersonalMessageDigest(message), sig)
+}
+
+// SignTypedData signs the typed data with eth_signTypedData.
+func (s *Web3Signer) SignTypedData(typedData *ethcoder.TypedData) ([]byte, error) {
+	digest, err := typedData.EncodeDigest()
+	if err != nil {
+		return nil, err
+	}
+	typedDataJSON, err := typedData.MarshalCompactJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	var sig hexutil.Bytes
+	_, err = s.provider.Do(context.Background(), ethrpc.NewCallBuilder[hexutil.Bytes]("eth_signTypedData", nil, s.address, json.RawMessage(typedDataJSON)).Into(&sig))
+	if err != nil {
+		return nil, fmt.Errorf("ethwallet: failed to sign typed data with web3signer: %w", err)
+	}
+	return s.verify(common.BytesToHash(digest), sig)
+}
+
+// verify returns the signature of the digest of v of 27/28, or an error when
+// it's not of the account.
+func (s *Web3Signer) verify(digest common.Hash, sig []byte) ([]byte, error) {
+	valid, err := ethcoder.ValidateSignature(s.address, digest.Bytes(), sig)
+	if err != nil {
+		return nil, fmt.Errorf("ethwallet: invalid web3signer signature: %w", err)
+	}
+	if !valid {
+		return nil, fmt.Errorf("ethwallet: web3signer signature is not of %s", s.address.Hex())
+	}
+	sig = append([]byte{}, sig...)
+	if sig[64] < 27 {
+		sig[64] += 27
+	}
+	return sig, nil
+}
+
+// accessListOrEmpty returns the access list, or the empty one of nil, of which
+// the remote signer infers an access list transaction from a gas price.
+func accessListOrEmpty(accessList types.AccessList) types.AccessList {
+	if accessList == nil {
+		return types.AccessList{}
+	}
+	return accessList
+}
+
+// trimPersonalMessagePrefix returns the message of a message of its eip-191
+// prefix and length.
+func trimPersonalMessagePrefix(message []byte) []byte {
+	prefix := []byte("\x19Ethereum Signed Message:\n")
+	if !bytes.HasPrefix(message, prefix) {
+		return message
+	}
+	rest := message[len(prefix):]
+	for i := 1; i <= len(rest); i++ {
+		if n, err := strconv.Atoi(string(rest[:i])); err == nil && n == len(rest)-i {
+			return rest[i:]
+		}
+	}
+	return message
+}
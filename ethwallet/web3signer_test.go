@@ -0,0 +1,160 @@
+package ethwallet_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/0xsequence/ethkit/ethcoder"
+	"github.com/0xsequence/ethkit/ethrpc"
+	"github.com/0xsequence/ethkit/ethwallet"
+	"github.com/0xsequence/ethkit/go-ethereum/common"
+	"github.com/0xsequence/ethkit/go-ethereum/common/hexutil"
+	"github.com/0xsequence/ethkit/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMockWeb3Signer returns the server of a remote signer of the wallet.
+func newMockWeb3Signer(t *testing.T, wallet *ethwallet.Wallet, chainID *big.Int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		var result any
+		switch req.Method {
+		case "eth_accounts":
+			result = []common.Address{wallet.Address()}
+
+		case "eth_sign":
+			var message hexutil.Bytes
+			require.NoError(t, json.Unmarshal(req.Params[1], &message))
+			sig, err := wallet.SignMessage(message)
+			require.NoError(t, err)
+			result = hexutil.Bytes(sig)
+
+		case "eth_signTypedData":
+			var typedData ethcoder.TypedData
+			require.NoError(t, json.Unmarshal(req.Params[1], &typedData))
+			sig, err := wallet.SignTypedData(&typedData)
+			require.NoError(t, err)
+			result = hexutil.Bytes(sig)
+
+		case "eth_signTransaction":
+			var args struct {
+				To                   *common.Address   `json:"to"`
+				Gas                  hexutil.Uint64    `json:"gas"`
+				GasPrice             *hexutil.Big      `json:"gasPrice"`
+				MaxFeePerGas         *hexutil.Big      `json:"maxFeePerGas"`
+				MaxPriorityFeePerGas *hexutil.Big      `json:"maxPriorityFeePerGas"`
+				Value                *hexutil.Big      `json:"value"`
+				Data                 hexutil.Bytes     `json:"data"`
+				Nonce                hexutil.Uint64    `json:"nonce"`
+				AccessList           *types.AccessList `json:"accessList"`
+			}
+			require.NoError(t, json.Unmarshal(req.Params[0], &args))
+			var tx *types.Transaction
+			if args.GasPrice != nil && args.AccessList != nil {
+				tx = types.NewTx(&types.AccessListTx{ChainID: chainID, Nonce: uint64(args.Nonce), GasPrice: args.GasPrice.ToInt(), Gas: uint64(args.Gas), To: args.To, Value: args.Value.ToInt(), Data: args.Data, AccessList: *args.AccessList})
+			} else if args.GasPrice != nil {
+				tx = types.NewTx(&types.LegacyTx{Nonce: uint64(args.Nonce), GasPrice: args.GasPrice.ToInt(), Gas: uint64(args.Gas), To: args.To, Value: args.Value.ToInt(), Data: args.Data})
+			} else {
+				var accessList types.AccessList
+				if args.AccessList != nil {
+					accessList = *args.AccessList
+				}
+				tx = types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: uint64(args.Nonce), GasTipCap: args.MaxPriorityFeePerGas.ToInt(), GasFeeCap: args.MaxFeePerGas.ToInt(), Gas: uint64(args.Gas), To: args.To, Value: args.Value.ToInt(), Data: args.Data, AccessList: accessList})
+			}
+			signedTx, err := wallet.SignTx(tx, chainID)
+			require.NoError(t, err)
+			raw, err := signedTx.MarshalBinary()
+			require.NoError(t, err)
+			// the {raw, tx} of geth
+			result = map[string]any{"raw": hexutil.Bytes(raw), "tx": signedTx}
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+}
+
+func TestWeb3Signer(t *testing.T) {
+	wallet, err := ethwallet.NewWalletFromMnemonic("dose weasel clever culture letter volume endorse used harvest ripple circle install")
+	require.NoError(t, err)
+	chainID := big.NewInt(1)
+
+	server := newMockWeb3Signer(t, wallet, chainID)
+	defer server.Close()
+	provider, err := ethrpc.NewProvider(server.URL)
+	require.NoError(t, err)
+
+	accounts, err := ethwallet.Web3SignerAccounts(context.Background(), provider)
+	require.NoError(t, err)
+	require.Equal(t, []common.Address{wallet.Address()}, accounts)
+	signer := ethwallet.NewWeb3Signer(provider, accounts[0])
+
+	t.Run("message", func(t *testing.T) {
+		sig, err := signer.SignMessage([]byte("hi"))
+		require.NoError(t, err)
+		expected, err := wallet.SignMessage([]byte("hi"))
+		require.NoError(t, err)
+		assert.Equal(t, expected, sig)
+
+		// the message of its prefix
+		sig, err = signer.SignMessage([]byte("\x19Ethereum Signed Message:\n2hi"))
+		require.NoError(t, err)
+		assert.Equal(t, expected, sig)
+	})
+
+	t.Run("typed data", func(t *testing.T) {
+		typedData := &ethcoder.TypedData{
+			Types: ethcoder.TypedDataTypes{
+				"EIP712Domain": {{Name: "name", Type: "string"}, {Name: "chainId", Type: "uint256"}},
+				"Transfer":     {{Name: "to", Type: "address"}, {Name: "amount", Type: "uint256"}},
+			},
+			PrimaryType: "Transfer",
+			Domain:      ethcoder.TypedDataDomain{Name: "Token", ChainID: chainID},
+			Message:     map[string]interface{}{"to": common.HexToAddress("0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"), "amount": big.NewInt(100)},
+		}
+		sig, err := signer.SignTypedData(typedData)
+		require.NoError(t, err)
+		expected, err := wallet.SignTypedData(typedData)
+		require.NoError(t, err)
+		assert.Equal(t, expected, sig)
+	})
+
+	t.Run("transactions", func(t *testing.T) {
+		to := common.HexToAddress("0x8ba1f109551bD432803012645Ac136ddd64DBA72")
+		accessList := types.AccessList{{Address: to, StorageKeys: []common.Hash{common.HexToHash("0x01")}}}
+		for _, tx := range []*types.Transaction{
+			types.NewTx(&types.LegacyTx{Nonce: 1, GasPrice: big.NewInt(1e9), Gas: 21000, To: &to, Value: big.NewInt(1)}),
+			types.NewTx(&types.AccessListTx{ChainID: chainID, Nonce: 2, GasPrice: big.NewInt(1e9), Gas: 30000, To: &to, AccessList: accessList}),
+			types.NewTx(&types.AccessListTx{ChainID: chainID, Nonce: 3, GasPrice: big.NewInt(1e9), Gas: 30000, To: &to}),
+			types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: 4, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 50000, To: &to, Data: []byte{0x01}}),
+			types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: 5, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(2), Gas: 50000, To: &to, AccessList: accessList}),
+		} {
+			signedTx, err := signer.SignTx(tx, chainID)
+			require.NoError(t, err)
+			sender, err := types.Sender(types.LatestSignerForChainID(chainID), signedTx)
+			require.NoError(t, err)
+			assert.Equal(t, wallet.Address(), sender)
+			assert.Equal(t, tx.Type(), signedTx.Type())
+			assert.Equal(t, tx.AccessList(), signedTx.AccessList())
+		}
+
+		// the remote signer signs with another account
+		other := ethwallet.NewWeb3Signer(provider, common.HexToAddress("0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"))
+		_, err := other.SignTx(types.NewTx(&types.LegacyTx{Nonce: 1, Gas: 21000, To: &to}), chainID)
+		assert.ErrorContains(t, err, "ethwallet: signer mismatch")
+
+		// the remote signer is of chain 1
+		_, err = signer.SignTx(types.NewTx(&types.DynamicFeeTx{ChainID: big.NewInt(5), Nonce: 3, Gas: 21000, To: &to}), big.NewInt(5))
+		assert.ErrorContains(t, err, "is of chain 1, not 5")
+	})
+}
@@ -35,13 +35,13 @@ require (
 	golang.org/x/net v0.24.0
 	golang.org/x/sync v0.3.0
 	golang.org/x/sys v0.19.0
+	golang.org/x/text v0.14.0
 	golang.org/x/tools v0.11.0
 )
 
 require (
 	golang.org/x/exp v0.0.0-20230124195608-d38c7dcee874 // indirect
 	golang.org/x/mod v0.12.0 // indirect
-	golang.org/x/text v0.14.0 // indirect
 )
 
 require (